@@ -5,11 +5,13 @@ import (
 	"encoding/base64"
 	"fmt"
 	"log"
+	"os"
 	"os/exec"
 	"path/filepath"
 	goruntime "runtime"
 	"time"
 
+	"wepassword/internal/bitwarden"
 	"wepassword/internal/config"
 	"wepassword/internal/crypto"
 	"wepassword/internal/database"
@@ -29,22 +31,30 @@ import (
  * @description 管理应用的生命周期和核心服务
  */
 type App struct {
-	ctx                   context.Context
-	configManager         *config.ConfigManager
-	dbManager             *database.DatabaseManager
-	vaultService          *services.VaultService
-	accountService        *services.AccountService // 20251002 陈凤庆 passwordService改名为accountService
-	groupService          *services.GroupService
-	typeService           *services.TypeService           // 20251002 陈凤庆 tabService改名为typeService
-	exportService         *services.ExportService         // 20251003 陈凤庆 导出服务
-	importService         *services.ImportService         // 20251003 陈凤庆 导入服务
-	lockService           *services.LockService           // 20251004 陈凤庆 锁定服务
-	keyboardService       interface{}                     // 20251003 陈凤庆 平台特定的键盘服务
-	keyboardHelperService *services.KeyboardHelperService // 20251005 陈凤庆 键盘助手服务
-	remoteInputService    *services.RemoteInputService    // 20251005 陈凤庆 远程输入服务
-	globalHotkeyService   *services.GlobalHotkeyService   // 20251014 陈凤庆 全局快捷键服务
-	passwordRuleApp       *PasswordRuleApp                // 20251017 陈凤庆 密码规则应用服务
-	usernameHistoryApp    *UsernameHistoryApp             // 20251017 陈凤庆 用户名历史记录应用服务
+	ctx                      context.Context
+	configManager            *config.ConfigManager
+	dbManager                *database.DatabaseManager
+	vaultService             *services.VaultService
+	accountService           *services.AccountService // 20251002 陈凤庆 passwordService改名为accountService
+	groupService             *services.GroupService
+	typeService              *services.TypeService              // 20251002 陈凤庆 tabService改名为typeService
+	exportService            *services.ExportService            // 20251003 陈凤庆 导出服务
+	importService            *services.ImportService            // 20251003 陈凤庆 导入服务
+	lockService              *services.LockService              // 20251004 陈凤庆 锁定服务
+	keyboardService          interface{}                        // 20251003 陈凤庆 平台特定的键盘服务
+	keyboardHelperService    *services.KeyboardHelperService    // 20251005 陈凤庆 键盘助手服务
+	remoteInputService       *services.RemoteInputService       // 20251005 陈凤庆 远程输入服务
+	globalHotkeyService      *services.GlobalHotkeyService      // 20251014 陈凤庆 全局快捷键服务
+	passwordRuleApp          *PasswordRuleApp                   // 20251017 陈凤庆 密码规则应用服务
+	usernameHistoryApp       *UsernameHistoryApp                // 20251017 陈凤庆 用户名历史记录应用服务
+	fieldReferenceService    *services.FieldReferenceService    // 20251020 陈凤庆 字段引用服务
+	sshAgentApp              *SSHAgentApp                       // 20251020 陈凤庆 SSH代理应用服务
+	vaultSessionService      *services.VaultSessionService      // 20251020 陈凤庆 多密码库会话服务
+	passwordExpiryService    *services.PasswordExpiryService    // 20251021 陈凤庆 密码到期提醒服务
+	passwordRotationService  *services.PasswordRotationService  // 20251021 陈凤庆 密码轮换服务
+	rotationHookService      *services.RotationHookService      // 20251021 陈凤庆 密码轮换钩子服务
+	bulkRotationScheduler    *services.BulkRotationScheduler    // 20251021 陈凤庆 批量轮换调度器
+	accountAttachmentService *services.AccountAttachmentService // 20251021 陈凤庆 账号附件服务
 }
 
 /**
@@ -82,6 +92,19 @@ func (a *App) Startup(ctx context.Context) {
 	// 20251003 陈凤庆 初始化导出导入服务
 	a.exportService = services.NewExportService(a.dbManager, a.accountService, a.groupService, a.typeService)
 	a.importService = services.NewImportService(a.dbManager, a.accountService, a.groupService, a.typeService, nil) // cryptoManager稍后设置
+	// 20251020 陈凤庆 初始化字段引用服务
+	a.fieldReferenceService = services.NewFieldReferenceService(a.accountService)
+	// 20251020 陈凤庆 初始化多密码库会话服务
+	a.vaultSessionService = services.NewVaultSessionService()
+	// 20251021 陈凤庆 初始化密码到期提醒服务
+	a.passwordExpiryService = services.NewPasswordExpiryService(a.dbManager)
+	// 20251021 陈凤庆 初始化密码轮换服务
+	a.passwordRotationService = services.NewPasswordRotationService(a.dbManager, a.accountService)
+	// 20251021 陈凤庆 初始化密码轮换钩子服务和批量轮换调度器
+	a.rotationHookService = services.NewRotationHookService(a.dbManager)
+	a.bulkRotationScheduler = services.NewBulkRotationScheduler(a.accountService, a.passwordRotationService, a.rotationHookService)
+	// 20251021 陈凤庆 初始化账号附件服务
+	a.accountAttachmentService = services.NewAccountAttachmentService(a.dbManager, a.accountService)
 	// 20251004 陈凤庆 初始化锁定服务
 	a.lockService = services.NewLockService(a.configManager)
 	// 20251003 陈凤庆 平台特定的键盘服务初始化
@@ -92,6 +115,8 @@ func (a *App) Startup(ctx context.Context) {
 	a.initPasswordRuleApp()
 	// 20251017 陈凤庆 初始化用户名历史记录应用服务
 	a.initUsernameHistoryApp()
+	// 20251020 陈凤庆 初始化SSH代理应用服务
+	a.initSSHAgentApp()
 	log.Println("[启动] 服务初始化完成")
 
 	log.Printf("[启动] %s 启动完成\n", version.GetAppName())
@@ -119,6 +144,12 @@ func (a *App) handleLock() error {
 		logger.Info("[锁定] 加密管理器已清理")
 	}
 
+	// 20251020 陈凤庆 移除SSH代理中的全部私钥
+	a.clearSSHAgentKeys()
+
+	// 20251021 陈凤庆 锁定后无法解密账号，取消未执行的批量轮换
+	a.cancelBulkRotation()
+
 	// 停止锁定服务（避免重复锁定）
 	if a.lockService != nil {
 		a.lockService.StopLockService()
@@ -251,6 +282,43 @@ func (a *App) SetHotkeyConfig(hotkeyConfig models.HotkeyConfig) error {
 	return nil
 }
 
+/**
+ * GetPasswordPolicyConfig 获取密码规则强度策略配置
+ * @return models.PasswordPolicyConfig 密码规则强度策略配置
+ * @author 陈凤庆
+ * @date 20251021
+ */
+func (a *App) GetPasswordPolicyConfig() models.PasswordPolicyConfig {
+	return a.configManager.GetPasswordPolicyConfig()
+}
+
+/**
+ * SetPasswordPolicyConfig 设置密码规则强度策略配置
+ * @param policyConfig 密码规则强度策略配置
+ * @return error 设置错误
+ * @author 陈凤庆
+ * @date 20251021
+ */
+func (a *App) SetPasswordPolicyConfig(policyConfig models.PasswordPolicyConfig) error {
+	logger.Info("[规则强度策略] 开始更新规则强度策略: 警告阈值%.0f位, 最小值%.0f位", policyConfig.WarnEntropyBits, policyConfig.MinEntropyBits)
+
+	if policyConfig.WarnEntropyBits < 0 || policyConfig.MinEntropyBits < 0 {
+		return fmt.Errorf("熵值阈值不能为负数")
+	}
+
+	if err := a.configManager.SetPasswordPolicyConfig(policyConfig); err != nil {
+		logger.Error("[规则强度策略] 保存配置失败: %v", err)
+		return fmt.Errorf("保存规则强度策略失败: %w", err)
+	}
+
+	if a.passwordRuleApp != nil {
+		a.passwordRuleApp.SetPolicyConfig(policyConfig)
+	}
+
+	logger.Info("[规则强度策略] 规则强度策略更新成功")
+	return nil
+}
+
 /**
  * UpdateUserActivity 更新用户活动
  * @author 陈凤庆
@@ -503,6 +571,19 @@ func (a *App) Shutdown(ctx context.Context) {
 		}
 	}
 
+	// 20251020 陈凤庆 关闭全部附加密码库
+	if a.vaultSessionService != nil {
+		a.vaultSessionService.CloseAll()
+	}
+
+	// 20251020 陈凤庆 停止SSH代理
+	if a.sshAgentApp != nil {
+		log.Println("[关闭] 正在停止SSH代理...")
+		if err := a.sshAgentApp.Stop(ctx); err != nil {
+			log.Printf("[关闭] ⚠️ 停止SSH代理失败: %v", err)
+		}
+	}
+
 	// 关闭数据库连接
 	if a.dbManager != nil {
 		log.Println("[关闭] 正在关闭数据库连接...")
@@ -680,6 +761,17 @@ func (a *App) CloseVault() {
 		logger.Info("[密码库] 密码库服务已关闭")
 	}
 
+	// 20251020 陈凤庆 移除SSH代理中的全部私钥
+	a.clearSSHAgentKeys()
+
+	// 20251021 陈凤庆 取消未执行的批量轮换
+	a.cancelBulkRotation()
+
+	// 20251020 陈凤庆 退出登录时同时关闭全部附加密码库
+	if a.vaultSessionService != nil {
+		a.vaultSessionService.CloseAll()
+	}
+
 	// 清理配置文件中的当前密码库路径
 	if a.configManager != nil {
 		if err := a.configManager.SetCurrentVaultPath(""); err != nil {
@@ -716,6 +808,8 @@ func (a *App) GetCurrentVaultPath() string {
  */
 func (a *App) CreateVault(vaultName string, password string, language string) (string, error) {
 	log.Printf("[密码库] 开始创建密码库: %s", vaultName)
+	// 20251021 陈凤庆 评估登录密码强度，弱密码记录警告（不阻止创建，由前端提示用户）
+	a.warnWeakPassword("创建密码库", services.EstimatePasswordStrength(password, []string{vaultName}))
 
 	// 20251002 陈凤庆 使用跨平台路径工具函数获取默认密码库路径
 	vaultPath, err := utils.GetDefaultVaultPath(vaultName)
@@ -791,6 +885,16 @@ func (a *App) OpenVault(vaultPath string, password string) error {
 	}
 	logger.Info("[密码库] 加密管理器设置验证成功")
 
+	// 20251021 陈凤庆 按订阅的规则文件同步密码规则，失败不影响打开密码库
+	if a.passwordRuleApp != nil {
+		if _, err := a.passwordRuleApp.SyncRuleSubscription(a.ctx); err != nil {
+			logger.Error("[密码库] 同步订阅的密码规则文件失败: %v", err)
+		}
+	}
+
+	// 20251021 陈凤庆 解锁后检查即将到期和已过期的密码
+	a.remindExpiringPasswords()
+
 	// 20251004 陈凤庆 启动锁定服务
 	if a.lockService != nil {
 		// 重置锁定触发标志（登录后重置）
@@ -877,6 +981,39 @@ func (a *App) CreateGroup(name string) (models.Group, error) {
 	return a.groupService.CreateGroup(name)
 }
 
+/**
+ * CreateChildGroup 在指定父分组下创建子分组
+ * @param name 分组名称
+ * @param parentID 父分组ID，空字符串表示顶级分组
+ * @return models.Group 创建的分组
+ * @return error 错误信息
+ * @author 20251020 陈凤庆 新增嵌套分组API
+ */
+func (a *App) CreateChildGroup(name string, parentID string) (models.Group, error) {
+	return a.groupService.CreateChildGroup(name, parentID)
+}
+
+/**
+ * MoveGroup 移动分组到新的父分组下
+ * @param id 分组ID
+ * @param newParentID 新父分组ID，空字符串表示移动为顶级分组
+ * @return error 错误信息
+ * @author 20251020 陈凤庆 新增嵌套分组移动API
+ */
+func (a *App) MoveGroup(id string, newParentID string) error {
+	return a.groupService.MoveGroup(id, newParentID)
+}
+
+/**
+ * GetGroupTree 获取分组树（含递归账号数）
+ * @return []models.GroupTreeNode 顶级分组节点列表
+ * @return error 错误信息
+ * @author 20251020 陈凤庆 新增嵌套分组树API
+ */
+func (a *App) GetGroupTree() ([]models.GroupTreeNode, error) {
+	return a.groupService.GetGroupTree()
+}
+
 /**
  * RenameGroup 重命名分组
  * @param id 分组ID
@@ -1119,9 +1256,23 @@ func (a *App) GetAccountsByConditions(conditions string) ([]models.AccountDecryp
  * @return models.AccountDecrypted 创建的账号
  * @modify 20251002 陈凤庆 CreatePasswordItem改名为CreateAccount，账号改为账号
  * @modify 20251003 陈凤庆 添加inputMethod参数和详细日志记录
+ * @modify 20251021 陈凤庆 密码为空时使用类型或分组绑定的默认密码规则自动生成
  */
 func (a *App) CreateAccount(title, username, password, url, typeID, notes string, inputMethod int) (models.AccountDecrypted, error) {
 	logger.LogAPICall("CreateAccount", fmt.Sprintf("title=%s, typeID=%s, inputMethod=%d", title, typeID, inputMethod), "开始处理")
+	// 20251021 陈凤庆 密码为空且类型或分组绑定了默认规则时自动生成密码
+	if password == "" && typeID != "" && a.passwordRuleApp != nil {
+		generated, err := a.passwordRuleApp.GeneratePasswordForType(a.ctx, typeID)
+		if err != nil {
+			logger.Error("[API] CreateAccount自动生成密码失败，标题: %s, 错误: %v", title, err)
+			return models.AccountDecrypted{}, err
+		}
+		password = generated
+	}
+	// 20251021 陈凤庆 评估账号密码强度，弱密码记录警告
+	if password != "" && !services.HasReference(password) {
+		a.warnWeakPassword("创建账号", services.EstimatePasswordStrength(password, []string{title, username, url}))
+	}
 
 	// 20251019 陈凤庆 修复问题 004：增加详细的参数验证和调试日志
 	logger.Info("[CreateAccount] 🔍 详细参数检查:")
@@ -1160,6 +1311,7 @@ func (a *App) CreateAccount(title, username, password, url, typeID, notes string
  * @date 20251003
  * @description 为安全考虑，只在需要时查询敏感信息，查询后立即返回，不在内存中长期保存
  * @modify 20251004 陈凤庆 添加数据验证和错误处理，防止返回损坏的数据
+ * @modify 20251020 陈凤庆 读取时解析字段引用，复制和自动输入均使用引用后的实际值
  */
 func (a *App) GetAccountCredentials(accountID string) (string, string, int, error) {
 	// 验证账号ID
@@ -1180,6 +1332,13 @@ func (a *App) GetAccountCredentials(accountID string) (string, string, int, erro
 		return "", "", 0, fmt.Errorf("账号不存在")
 	}
 
+	// 20251020 陈凤庆 解析字段引用，如 {REF:P@I:<账号ID>}
+	account, err = a.resolveAccountReferences(account)
+	if err != nil {
+		logger.Error("[获取凭据] 解析字段引用失败，账号ID: %s, 错误: %v", accountID, err)
+		return "", "", 0, fmt.Errorf("解析字段引用失败: %w", err)
+	}
+
 	// 验证用户名和密码
 	if account.Username == "" {
 		logger.Error("[获取凭据] 用户名为空，账号ID: %s", accountID)
@@ -1342,8 +1501,15 @@ func (a *App) GetAccountPassword(accountID string) (string, error) {
 		return "", err
 	}
 
+	// 20251020 陈凤庆 解析密码中的字段引用
+	password, err := a.resolveFieldReferences(account.Password)
+	if err != nil {
+		logger.LogAPICall("GetAccountPassword", fmt.Sprintf("accountID=%s", accountID), fmt.Sprintf("失败: %v", err))
+		return "", err
+	}
+
 	logger.LogAPICall("GetAccountPassword", fmt.Sprintf("accountID=%s", accountID), "成功")
-	return account.Password, nil
+	return password, nil
 }
 
 /**
@@ -1404,7 +1570,12 @@ func (a *App) CopyAccountNotes(accountID string) error {
 	if err != nil {
 		return err
 	}
-	return a.copyToClipboardWithTimeout(account.Notes, "备注")
+	// 20251020 陈凤庆 解析备注中的字段引用
+	notes, err := a.resolveFieldReferences(account.Notes)
+	if err != nil {
+		return err
+	}
+	return a.copyToClipboardWithTimeout(notes, "备注")
 }
 
 /**
@@ -1431,8 +1602,71 @@ func (a *App) GetAccountNotes(accountID string) (string, error) {
 		return "", err
 	}
 
+	// 20251020 陈凤庆 解析备注中的字段引用，与复制备注保持一致
+	notes, err := a.resolveFieldReferences(account.Notes)
+	if err != nil {
+		logger.LogAPICall("GetAccountNotes", fmt.Sprintf("accountID=%s", accountID), fmt.Sprintf("失败: %v", err))
+		return "", err
+	}
+
 	logger.LogAPICall("GetAccountNotes", fmt.Sprintf("accountID=%s", accountID), "成功")
-	return account.Notes, nil
+	return notes, nil
+}
+
+/**
+ * GetAccountReferencedBy 反查引用了指定账号字段的账号（"被引用"）
+ * @param accountID 被引用的账号ID
+ * @return []services.ReferenceUsage 引用情况列表
+ * @return error 错误信息
+ * @author 陈凤庆
+ * @date 20251020
+ * @description 修改源账号前可用于提示哪些账号会随之变化
+ */
+func (a *App) GetAccountReferencedBy(accountID string) ([]services.ReferenceUsage, error) {
+	logger.LogAPICall("GetAccountReferencedBy", fmt.Sprintf("accountID=%s", accountID), "开始处理")
+
+	if a.fieldReferenceService == nil {
+		return nil, fmt.Errorf("字段引用服务未初始化")
+	}
+
+	result, err := a.fieldReferenceService.GetReferencedBy(accountID)
+	if err != nil {
+		logger.LogAPICall("GetAccountReferencedBy", fmt.Sprintf("accountID=%s", accountID), fmt.Sprintf("失败: %v", err))
+		return nil, err
+	}
+
+	logger.LogAPICall("GetAccountReferencedBy", fmt.Sprintf("accountID=%s", accountID), fmt.Sprintf("成功，%d处引用", len(result)))
+	return result, nil
+}
+
+/**
+ * resolveAccountReferences 解析账号各字段中的字段引用
+ * @param account 已解密的账号
+ * @return *models.AccountDecrypted 解析后的账号
+ * @return error 错误信息
+ * @author 陈凤庆
+ * @date 20251020
+ */
+func (a *App) resolveAccountReferences(account *models.AccountDecrypted) (*models.AccountDecrypted, error) {
+	if a.fieldReferenceService == nil {
+		return account, nil
+	}
+	return a.fieldReferenceService.ResolveAccount(account)
+}
+
+/**
+ * resolveFieldReferences 解析单个字段值中的字段引用
+ * @param value 字段值
+ * @return string 解析后的值
+ * @return error 错误信息
+ * @author 陈凤庆
+ * @date 20251020
+ */
+func (a *App) resolveFieldReferences(value string) (string, error) {
+	if a.fieldReferenceService == nil {
+		return value, nil
+	}
+	return a.fieldReferenceService.ResolveValue(value)
 }
 
 /**
@@ -1492,6 +1726,10 @@ func (a *App) safeWriteToClipboard(content string) error {
  */
 func (a *App) UpdateAccount(account models.AccountDecrypted) error {
 	logger.Info("[API] UpdateAccount - 参数: accountID=%s, title=%s - 结果: 开始处理", account.ID, account.Title)
+	// 20251021 陈凤庆 评估账号密码强度，弱密码记录警告
+	if account.Password != "" && !services.HasReference(account.Password) {
+		a.warnWeakPassword("更新账号", services.EstimatePasswordStrength(account.Password, []string{account.Title, account.Username, account.URL}))
+	}
 	err := a.accountService.UpdateAccount(account)
 	if err != nil {
 		logger.Error("[API] UpdateAccount - 参数: accountID=%s - 结果: 失败，错误: %v", account.ID, err)
@@ -1501,6 +1739,62 @@ func (a *App) UpdateAccount(account models.AccountDecrypted) error {
 	return nil
 }
 
+/**
+ * EstimatePasswordStrength 评估密码强度
+ * @param password 密码
+ * @param userInputs 与密码相关的信息（如标题、用户名、网址），密码中包含这些信息时会降低评分
+ * @return services.PasswordStrength 评估结果：评分、估计猜测次数、破解时间和改进建议
+ * @author 陈凤庆
+ * @date 20251021
+ */
+func (a *App) EstimatePasswordStrength(password string, userInputs []string) services.PasswordStrength {
+	return services.EstimatePasswordStrength(password, userInputs)
+}
+
+/**
+ * EstimateAccountPasswordStrength 评估账号密码强度
+ * @param title 标题
+ * @param username 用户名
+ * @param url 网址
+ * @param password 密码
+ * @return services.PasswordStrength 评估结果
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 用于创建/编辑账号时的密码框，标题、用户名、网址作为账号相关信息参与评估
+ */
+func (a *App) EstimateAccountPasswordStrength(title, username, url, password string) services.PasswordStrength {
+	return services.EstimatePasswordStrength(password, []string{title, username, url})
+}
+
+/**
+ * EstimateLoginPasswordStrength 评估登录密码强度
+ * @param vaultName 密码库名称（可为空）
+ * @param password 登录密码
+ * @return services.PasswordStrength 评估结果
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 用于创建密码库和修改登录密码时的密码框
+ */
+func (a *App) EstimateLoginPasswordStrength(vaultName, password string) services.PasswordStrength {
+	var userInputs []string
+	if vaultName != "" {
+		userInputs = append(userInputs, vaultName)
+	}
+	return services.EstimatePasswordStrength(password, userInputs)
+}
+
+/**
+ * warnWeakPassword 弱密码记录警告日志（不记录密码内容）
+ * @param scene 场景
+ * @param strength 评估结果
+ */
+func (a *App) warnWeakPassword(scene string, strength services.PasswordStrength) {
+	if strength.Score >= 2 {
+		return
+	}
+	logger.Info("[密码强度] ⚠️ %s: 密码强度%s(评分%d)，%s", scene, strength.Label, strength.Score, strength.Warning)
+}
+
 /**
  * DeleteAccount 删除账号
  * @param accountID 账号ID
@@ -1536,6 +1830,17 @@ func (a *App) SearchAccounts(keyword string) ([]models.AccountDecrypted, error)
 	return a.accountService.SearchAccounts(keyword)
 }
 
+/**
+ * SearchAccountsInGroup 在指定分组（含全部子分组）范围内搜索账号
+ * @param keyword 搜索关键词
+ * @param groupID 分组ID，为空时搜索全部账号
+ * @return []models.AccountDecrypted 搜索结果
+ * @author 20251020 陈凤庆 新增嵌套分组递归范围搜索API
+ */
+func (a *App) SearchAccountsInGroup(keyword string, groupID string) ([]models.AccountDecrypted, error) {
+	return a.accountService.SearchAccountsInGroup(keyword, groupID)
+}
+
 /**
  * UpdateAccountUsage 更新账号使用次数
  * @param accountId 账号ID
@@ -1805,6 +2110,8 @@ func (a *App) VerifyOldPassword(oldPassword string) error {
  */
 func (a *App) ChangeLoginPassword(oldPassword, newPassword string) error {
 	logger.Info("[修改密码] 开始修改登录密码")
+	// 20251021 陈凤庆 评估新登录密码强度，弱密码记录警告
+	a.warnWeakPassword("修改登录密码", services.EstimatePasswordStrength(newPassword, nil))
 
 	// 检查密码库服务是否已初始化
 	if a.vaultService == nil {
@@ -2100,231 +2407,1905 @@ func (a *App) ImportVault(importPath, backupPassword string) (services.ImportRes
 }
 
 /**
- * initGlobalHotkeyService 初始化全局快捷键服务
+ * SelectKeePassFile 选择要导入的KeePass数据库文件
+ * @return string 选择的文件路径，取消选择时返回空字符串
  * @author 陈凤庆
- * @date 20251014
- * @description 初始化全局快捷键服务，支持显示/隐藏窗口功能
+ * @date 20251021
  */
-func (a *App) initGlobalHotkeyService() {
-	logger.Info("[全局快捷键] 初始化全局快捷键服务...")
-
-	// 创建全局快捷键服务
-	hotkeyService, err := services.NewGlobalHotkeyService(a.configManager, a)
+func (a *App) SelectKeePassFile() string {
+	selection, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "选择KeePass数据库",
+		Filters: []runtime.FileFilter{
+			{
+				DisplayName: "KeePass数据库 (*.kdbx)",
+				Pattern:     "*.kdbx",
+			},
+			{
+				DisplayName: "所有文件 (*.*)",
+				Pattern:     "*.*",
+			},
+		},
+	})
 	if err != nil {
-		logger.Error("[全局快捷键] 初始化失败: %v", err)
-		return
-	}
-
-	a.globalHotkeyService = hotkeyService
-
-	// 启动快捷键监听
-	if err := a.globalHotkeyService.Start(); err != nil {
-		logger.Error("[全局快捷键] 启动监听失败: %v", err)
-		return
+		log.Printf("KeePass数据库选择对话框错误: %v", err)
+		return ""
 	}
 
-	logger.Info("[全局快捷键] 全局快捷键服务初始化完成")
+	return selection
 }
 
 /**
- * initPasswordRuleApp 初始化密码规则应用服务
+ * SelectKeePassKeyFile 选择KeePass密钥文件
+ * @return string 选择的文件路径，取消选择时返回空字符串
  * @author 陈凤庆
- * @date 20251017
- * @description 初始化密码规则应用服务，提供密码规则管理功能
+ * @date 20251021
  */
-func (a *App) initPasswordRuleApp() {
-	logger.Info("[密码规则] 初始化密码规则应用服务...")
-
-	// 创建密码规则服务
-	passwordRuleService := services.NewPasswordRuleService(a.dbManager)
-
-	// 创建密码规则应用服务
-	a.passwordRuleApp = NewPasswordRuleApp(passwordRuleService)
+func (a *App) SelectKeePassKeyFile() string {
+	selection, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "选择密钥文件",
+		Filters: []runtime.FileFilter{
+			{
+				DisplayName: "密钥文件 (*.keyx;*.key)",
+				Pattern:     "*.keyx;*.key",
+			},
+			{
+				DisplayName: "所有文件 (*.*)",
+				Pattern:     "*.*",
+			},
+		},
+	})
+	if err != nil {
+		log.Printf("密钥文件选择对话框错误: %v", err)
+		return ""
+	}
 
-	logger.Info("[密码规则] 密码规则应用服务初始化完成")
+	return selection
 }
 
 /**
- * initUsernameHistoryApp 初始化用户名历史记录应用服务
+ * ImportKeePassVault 导入KeePass KDBX 4数据库
+ * @param importPath KDBX文件路径
+ * @param password 数据库密码，仅使用密钥文件时为空
+ * @param keyFilePath 密钥文件路径，未使用密钥文件时为空
+ * @return services.ImportResult 导入结果，EntryResults中包含每个条目的结果
+ * @return error 错误信息
  * @author 陈凤庆
- * @date 20251017
- * @description 初始化用户名历史记录应用服务，提供用户名历史记录管理功能
+ * @date 20251021
  */
-func (a *App) initUsernameHistoryApp() {
-	logger.Info("[用户名历史] 初始化用户名历史记录应用服务...")
+func (a *App) ImportKeePassVault(importPath, password, keyFilePath string) (services.ImportResult, error) {
+	logger.Info("[导入] 开始导入KeePass数据库")
 
-	// 创建用户名历史记录服务
-	usernameHistoryService := services.NewUsernameHistoryService(a.dbManager)
+	var result services.ImportResult
+	if a.importService == nil {
+		logger.Error("[导入] 导入服务未初始化")
+		return result, fmt.Errorf("导入服务未初始化")
+	}
 
-	// 创建用户名历史记录应用服务
-	a.usernameHistoryApp = NewUsernameHistoryApp(usernameHistoryService)
+	result, err := a.importService.ImportKeePass(services.KeePassImportOptions{
+		ImportPath:  importPath,
+		Password:    password,
+		KeyFilePath: keyFilePath,
+	})
+	if err != nil {
+		logger.Error("[导入] KeePass导入失败: %v", err)
+		return result, err
+	}
 
-	logger.Info("[用户名历史] 用户名历史记录应用服务初始化完成")
+	logger.Info("[导入] KeePass导入完成: 成功=%d, 跳过=%d, 错误=%d",
+		result.ImportedAccounts, result.SkippedAccounts, result.ErrorAccounts)
+	return result, nil
 }
 
-// 密码规则管理API
-
 /**
- * GetAllPasswordRules 获取所有密码规则
- * @return []models.PasswordRule 密码规则列表
- * @return error 错误信息
+ * SelectKeePassExportPath 选择KeePass数据库导出路径
+ * @return string 选择的导出路径，取消选择时返回空字符串
+ * @author 陈凤庆
+ * @date 20251021
  */
-func (a *App) GetAllPasswordRules() ([]models.PasswordRule, error) {
-	if a.passwordRuleApp == nil {
-		return nil, fmt.Errorf("密码规则应用服务未初始化")
+func (a *App) SelectKeePassExportPath() string {
+	selection, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "选择导出路径",
+		DefaultFilename: "wepass_export.kdbx",
+		Filters: []runtime.FileFilter{
+			{
+				DisplayName: "KeePass数据库 (*.kdbx)",
+				Pattern:     "*.kdbx",
+			},
+			{
+				DisplayName: "所有文件 (*.*)",
+				Pattern:     "*.*",
+			},
+		},
+	})
+	if err != nil {
+		log.Printf("KeePass导出路径选择对话框错误: %v", err)
+		return ""
 	}
-	return a.passwordRuleApp.GetAllRules(a.ctx)
+
+	return selection
 }
 
 /**
- * GetPasswordRuleByID 根据ID获取密码规则
- * @param id 规则ID
- * @return models.PasswordRule 密码规则
+ * ExportKeePassVault 导出为KeePass KDBX 4数据库
+ * @param loginPassword 登录密码
+ * @param keePassPassword 导出的KeePass数据库密码
+ * @param exportPath 导出路径
+ * @param accountIDs 要导出的账号ID列表（手动选择模式）
+ * @param groupIDs 要导出的分组ID列表（按分组导出模式）
+ * @param typeIDs 要导出的类别ID列表（按类别导出模式）
+ * @param exportAll 是否导出所有账号
  * @return error 错误信息
+ * @author 陈凤庆
+ * @date 20251021
  */
-func (a *App) GetPasswordRuleByID(id string) (models.PasswordRule, error) {
-	if a.passwordRuleApp == nil {
-		return models.PasswordRule{}, fmt.Errorf("密码规则应用服务未初始化")
-	}
-	return a.passwordRuleApp.GetRuleByID(a.ctx, id)
-}
+func (a *App) ExportKeePassVault(loginPassword, keePassPassword, exportPath string, accountIDs []string, groupIDs []string, typeIDs []string, exportAll bool) error {
+	logger.Info("[导出] 开始导出KeePass数据库")
 
-/**
+	if a.exportService == nil {
+		logger.Error("[导出] 导出服务未初始化")
+		return fmt.Errorf("导出服务未初始化")
+	}
+
+	err := a.exportService.ExportKeePass(services.ExportOptions{
+		LoginPassword:  loginPassword,
+		BackupPassword: keePassPassword,
+		ExportPath:     exportPath,
+		AccountIDs:     accountIDs,
+		GroupIDs:       groupIDs,
+		TypeIDs:        typeIDs,
+		ExportAll:      exportAll,
+	})
+	if err != nil {
+		logger.Error("[导出] KeePass导出失败: %v", err)
+		return err
+	}
+
+	logger.Info("[导出] KeePass导出成功: %s", exportPath)
+	return nil
+}
+
+/**
+ * SelectBitwardenFile 选择要导入的Bitwarden JSON文件
+ * @return string 选择的文件路径，取消选择时返回空字符串
+ * @author 陈凤庆
+ * @date 20251021
+ */
+func (a *App) SelectBitwardenFile() string {
+	selection, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "选择Bitwarden导出文件",
+		Filters: []runtime.FileFilter{
+			{
+				DisplayName: "Bitwarden JSON (*.json)",
+				Pattern:     "*.json",
+			},
+			{
+				DisplayName: "所有文件 (*.*)",
+				Pattern:     "*.*",
+			},
+		},
+	})
+	if err != nil {
+		log.Printf("Bitwarden文件选择对话框错误: %v", err)
+		return ""
+	}
+
+	return selection
+}
+
+/**
+ * IsBitwardenFilePasswordProtected 判断Bitwarden导出文件是否受密码保护，用于决定是否需要输入密码
+ * @param importPath JSON文件路径
+ * @return bool 是否受密码保护
+ * @return error 文件无法读取、不是Bitwarden导出文件或使用账号密钥加密时返回错误
+ * @author 陈凤庆
+ * @date 20251021
+ */
+func (a *App) IsBitwardenFilePasswordProtected(importPath string) (bool, error) {
+	data, err := os.ReadFile(importPath)
+	if err != nil {
+		return false, fmt.Errorf("读取导入文件失败: %w", err)
+	}
+	return bitwarden.IsPasswordProtected(data)
+}
+
+/**
+ * ImportBitwardenVault 导入Bitwarden JSON文件
+ * @param importPath JSON文件路径
+ * @param password 导出密码，未加密的文件为空
+ * @return services.ImportResult 导入结果，EntryResults中包含每个条目的结果
+ * @return error 错误信息
+ * @author 陈凤庆
+ * @date 20251021
+ */
+func (a *App) ImportBitwardenVault(importPath, password string) (services.ImportResult, error) {
+	logger.Info("[导入] 开始导入Bitwarden文件")
+
+	var result services.ImportResult
+	if a.importService == nil {
+		logger.Error("[导入] 导入服务未初始化")
+		return result, fmt.Errorf("导入服务未初始化")
+	}
+
+	result, err := a.importService.ImportBitwarden(services.BitwardenImportOptions{
+		ImportPath: importPath,
+		Password:   password,
+	})
+	if err != nil {
+		logger.Error("[导入] Bitwarden导入失败: %v", err)
+		return result, err
+	}
+
+	logger.Info("[导入] Bitwarden导入完成: 成功=%d, 跳过=%d, 错误=%d",
+		result.ImportedAccounts, result.SkippedAccounts, result.ErrorAccounts)
+	return result, nil
+}
+
+/**
+ * SelectBitwardenExportPath 选择Bitwarden JSON导出路径
+ * @return string 选择的导出路径，取消选择时返回空字符串
+ * @author 陈凤庆
+ * @date 20251021
+ */
+func (a *App) SelectBitwardenExportPath() string {
+	selection, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "选择导出路径",
+		DefaultFilename: "wepass_bitwarden_export.json",
+		Filters: []runtime.FileFilter{
+			{
+				DisplayName: "Bitwarden JSON (*.json)",
+				Pattern:     "*.json",
+			},
+			{
+				DisplayName: "所有文件 (*.*)",
+				Pattern:     "*.*",
+			},
+		},
+	})
+	if err != nil {
+		log.Printf("Bitwarden导出路径选择对话框错误: %v", err)
+		return ""
+	}
+
+	return selection
+}
+
+/**
+ * ExportBitwardenVault 导出为Bitwarden JSON文件
+ * @param loginPassword 登录密码
+ * @param exportPassword 导出密码，设置时生成受密码保护的加密文件，为空时生成未加密文件
+ * @param exportPath 导出路径
+ * @param accountIDs 要导出的账号ID列表（手动选择模式）
+ * @param groupIDs 要导出的分组ID列表（按分组导出模式）
+ * @param typeIDs 要导出的类别ID列表（按类别导出模式）
+ * @param exportAll 是否导出所有账号
+ * @return error 错误信息
+ * @author 陈凤庆
+ * @date 20251021
+ */
+func (a *App) ExportBitwardenVault(loginPassword, exportPassword, exportPath string, accountIDs []string, groupIDs []string, typeIDs []string, exportAll bool) error {
+	logger.Info("[导出] 开始导出Bitwarden文件")
+
+	if a.exportService == nil {
+		logger.Error("[导出] 导出服务未初始化")
+		return fmt.Errorf("导出服务未初始化")
+	}
+
+	err := a.exportService.ExportBitwarden(services.ExportOptions{
+		LoginPassword:  loginPassword,
+		BackupPassword: exportPassword,
+		ExportPath:     exportPath,
+		AccountIDs:     accountIDs,
+		GroupIDs:       groupIDs,
+		TypeIDs:        typeIDs,
+		ExportAll:      exportAll,
+	})
+	if err != nil {
+		logger.Error("[导出] Bitwarden导出失败: %v", err)
+		return err
+	}
+
+	logger.Info("[导出] Bitwarden导出成功: %s", exportPath)
+	return nil
+}
+
+/**
+ * SelectCSVImportFile 选择要导入的CSV文件
+ * @return string 选择的文件路径，取消选择时返回空字符串
+ * @author 陈凤庆
+ * @date 20251021
+ */
+func (a *App) SelectCSVImportFile() string {
+	selection, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "选择CSV文件",
+		Filters: []runtime.FileFilter{
+			{
+				DisplayName: "CSV文件 (*.csv)",
+				Pattern:     "*.csv",
+			},
+			{
+				DisplayName: "所有文件 (*.*)",
+				Pattern:     "*.*",
+			},
+		},
+	})
+	if err != nil {
+		log.Printf("CSV文件选择对话框错误: %v", err)
+		return ""
+	}
+
+	return selection
+}
+
+/**
+ * GetCSVImportProfiles 获取内置的CSV格式（Chrome、Edge、Firefox、LastPass、KeePassXC、1Password）
+ * @return []services.CSVProfile 格式列表
+ * @author 陈凤庆
+ * @date 20251021
+ */
+func (a *App) GetCSVImportProfiles() []services.CSVProfile {
+	return services.GetCSVProfiles()
+}
+
+/**
+ * PreviewCSVImport 预览CSV导入，返回识别的格式、列映射和前20行的转换结果
+ * @param options 导入选项，Profile为空时自动识别，custom时使用Mapping
+ * @return services.CSVPreview 预览结果
+ * @return error 错误信息
+ * @author 陈凤庆
+ * @date 20251021
+ */
+func (a *App) PreviewCSVImport(options services.CSVImportOptions) (services.CSVPreview, error) {
+	if a.importService == nil {
+		return services.CSVPreview{}, fmt.Errorf("导入服务未初始化")
+	}
+	return a.importService.PreviewCSV(options)
+}
+
+/**
+ * ImportCSVVault 导入CSV文件到指定类型
+ * @param options 导入选项，TypeID为导入到的类型
+ * @return services.ImportResult 导入结果，EntryResults中包含每行的结果
+ * @return error 错误信息
+ * @author 陈凤庆
+ * @date 20251021
+ */
+func (a *App) ImportCSVVault(options services.CSVImportOptions) (services.ImportResult, error) {
+	logger.Info("[导入] 开始导入CSV文件")
+
+	var result services.ImportResult
+	if a.importService == nil {
+		logger.Error("[导入] 导入服务未初始化")
+		return result, fmt.Errorf("导入服务未初始化")
+	}
+
+	result, err := a.importService.ImportCSV(options)
+	if err != nil {
+		logger.Error("[导入] CSV导入失败: %v", err)
+		return result, err
+	}
+
+	logger.Info("[导入] CSV导入完成: 成功=%d, 跳过=%d, 错误=%d",
+		result.ImportedAccounts, result.SkippedAccounts, result.ErrorAccounts)
+	return result, nil
+}
+
+/**
+ * GetAccountAttachments 获取账号的附件列表
+ * @param accountID 账号ID
+ * @return []models.AccountAttachment 附件列表（不含内容）
+ * @return error 错误信息
+ * @author 陈凤庆
+ * @date 20251021
+ */
+func (a *App) GetAccountAttachments(accountID string) ([]models.AccountAttachment, error) {
+	if a.accountAttachmentService == nil {
+		return nil, fmt.Errorf("账号附件服务未初始化")
+	}
+	return a.accountAttachmentService.GetAttachments(accountID)
+}
+
+/**
+ * SaveAccountAttachment 将附件保存到本地文件
+ * @param attachmentID 附件ID
+ * @param savePath 保存路径，为空时弹出保存对话框
+ * @return string 保存的文件路径，取消保存时为空
+ * @return error 错误信息
+ * @author 陈凤庆
+ * @date 20251021
+ */
+func (a *App) SaveAccountAttachment(attachmentID, savePath string) (string, error) {
+	if a.accountAttachmentService == nil {
+		return "", fmt.Errorf("账号附件服务未初始化")
+	}
+
+	attachment, data, err := a.accountAttachmentService.GetAttachmentData(attachmentID)
+	if err != nil {
+		return "", err
+	}
+
+	if savePath == "" {
+		selection, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+			Title:           "保存附件",
+			DefaultFilename: filepath.Base(attachment.Name),
+		})
+		if err != nil {
+			return "", fmt.Errorf("选择保存路径失败: %w", err)
+		}
+		if selection == "" {
+			return "", nil
+		}
+		savePath = selection
+	}
+
+	if err := os.WriteFile(savePath, data, 0600); err != nil {
+		return "", fmt.Errorf("保存附件失败: %w", err)
+	}
+	return savePath, nil
+}
+
+/**
+ * DeleteAccountAttachment 删除账号附件
+ * @param attachmentID 附件ID
+ * @return error 错误信息
+ * @author 陈凤庆
+ * @date 20251021
+ */
+func (a *App) DeleteAccountAttachment(attachmentID string) error {
+	if a.accountAttachmentService == nil {
+		return fmt.Errorf("账号附件服务未初始化")
+	}
+	return a.accountAttachmentService.DeleteAttachment(attachmentID)
+}
+
+/**
+ * initGlobalHotkeyService 初始化全局快捷键服务
+ * @author 陈凤庆
+ * @date 20251014
+ * @description 初始化全局快捷键服务，支持显示/隐藏窗口功能
+ */
+func (a *App) initGlobalHotkeyService() {
+	logger.Info("[全局快捷键] 初始化全局快捷键服务...")
+
+	// 创建全局快捷键服务
+	hotkeyService, err := services.NewGlobalHotkeyService(a.configManager, a)
+	if err != nil {
+		logger.Error("[全局快捷键] 初始化失败: %v", err)
+		return
+	}
+
+	a.globalHotkeyService = hotkeyService
+
+	// 启动快捷键监听
+	if err := a.globalHotkeyService.Start(); err != nil {
+		logger.Error("[全局快捷键] 启动监听失败: %v", err)
+		return
+	}
+
+	logger.Info("[全局快捷键] 全局快捷键服务初始化完成")
+}
+
+/**
+ * initPasswordRuleApp 初始化密码规则应用服务
+ * @author 陈凤庆
+ * @date 20251017
+ * @description 初始化密码规则应用服务，提供密码规则管理功能
+ */
+func (a *App) initPasswordRuleApp() {
+	logger.Info("[密码规则] 初始化密码规则应用服务...")
+
+	// 创建密码规则服务
+	passwordRuleService := services.NewPasswordRuleService(a.dbManager)
+	// 20251021 陈凤庆 应用规则强度策略
+	if a.configManager != nil {
+		passwordRuleService.SetPolicyConfig(a.configManager.GetPasswordPolicyConfig())
+	}
+
+	// 创建密码规则应用服务
+	a.passwordRuleApp = NewPasswordRuleApp(passwordRuleService)
+
+	logger.Info("[密码规则] 密码规则应用服务初始化完成")
+}
+
+/**
+ * initSSHAgentApp 初始化SSH代理应用服务
+ * @author 陈凤庆
+ * @date 20251020
+ * @description 创建SSH代理服务，设置私钥使用确认对话框，并在锁定触发时移除全部私钥
+ */
+func (a *App) initSSHAgentApp() {
+	logger.Info("[SSH代理] 初始化SSH代理应用服务...")
+
+	sshAgentService := services.NewSSHAgentService()
+	sshAgentService.SetConfirmFunc(a.confirmSSHKeyUse)
+
+	a.sshAgentApp = NewSSHAgentApp(sshAgentService, a.accountService, a.fieldReferenceService)
+
+	// 锁定服务触发锁定时立即移除私钥，不等待前端检测
+	if a.lockService != nil {
+		a.lockService.AddLockListener(func(reason string) {
+			logger.Info("[SSH代理] 检测到锁定（%s），移除全部私钥", reason)
+			a.clearSSHAgentKeys()
+		})
+	}
+
+	logger.Info("[SSH代理] SSH代理应用服务初始化完成")
+}
+
+/**
+ * confirmSSHKeyUse 弹出对话框确认私钥使用
+ * @param key 即将使用的私钥信息
+ * @return bool 用户是否允许
+ * @author 陈凤庆
+ * @date 20251020
+ */
+func (a *App) confirmSSHKeyUse(key services.SSHAgentKey) bool {
+	if a.ctx == nil {
+		return false
+	}
+
+	result, err := runtime.MessageDialog(a.ctx, runtime.MessageDialogOptions{
+		Type:          runtime.QuestionDialog,
+		Title:         "SSH私钥使用确认",
+		Message:       fmt.Sprintf("是否允许使用私钥 %s ？\n%s", key.Comment, key.Fingerprint),
+		Buttons:       []string{"Yes", "No"},
+		DefaultButton: "No",
+		CancelButton:  "No",
+	})
+	if err != nil {
+		logger.Error("[SSH代理] 显示确认对话框失败: %v", err)
+		return false
+	}
+
+	return result == "Yes"
+}
+
+/**
+ * clearSSHAgentKeys 移除SSH代理中的全部私钥
+ * @author 陈凤庆
+ * @date 20251020
+ */
+func (a *App) clearSSHAgentKeys() {
+	if a.sshAgentApp == nil {
+		return
+	}
+	if err := a.sshAgentApp.RemoveAllKeys(a.ctx); err != nil {
+		logger.Error("[SSH代理] 移除私钥失败: %v", err)
+	}
+}
+
+/**
+ * initUsernameHistoryApp 初始化用户名历史记录应用服务
+ * @author 陈凤庆
+ * @date 20251017
+ * @description 初始化用户名历史记录应用服务，提供用户名历史记录管理功能
+ */
+func (a *App) initUsernameHistoryApp() {
+	logger.Info("[用户名历史] 初始化用户名历史记录应用服务...")
+
+	// 创建用户名历史记录服务
+	usernameHistoryService := services.NewUsernameHistoryService(a.dbManager)
+
+	// 创建用户名历史记录应用服务
+	a.usernameHistoryApp = NewUsernameHistoryApp(usernameHistoryService)
+
+	logger.Info("[用户名历史] 用户名历史记录应用服务初始化完成")
+}
+
+// 密码规则管理API
+
+/**
+ * GetAllPasswordRules 获取所有密码规则
+ * @return []models.PasswordRule 密码规则列表
+ * @return error 错误信息
+ */
+func (a *App) GetAllPasswordRules() ([]models.PasswordRule, error) {
+	if a.passwordRuleApp == nil {
+		return nil, fmt.Errorf("密码规则应用服务未初始化")
+	}
+	return a.passwordRuleApp.GetAllRules(a.ctx)
+}
+
+/**
+ * GetPasswordRuleByID 根据ID获取密码规则
+ * @param id 规则ID
+ * @return models.PasswordRule 密码规则
+ * @return error 错误信息
+ */
+func (a *App) GetPasswordRuleByID(id string) (models.PasswordRule, error) {
+	if a.passwordRuleApp == nil {
+		return models.PasswordRule{}, fmt.Errorf("密码规则应用服务未初始化")
+	}
+	return a.passwordRuleApp.GetRuleByID(a.ctx, id)
+}
+
+/**
  * GetDefaultPasswordRule 获取默认密码规则
  * @return models.PasswordRule 默认密码规则
  * @return error 错误信息
  */
-func (a *App) GetDefaultPasswordRule() (models.PasswordRule, error) {
-	if a.passwordRuleApp == nil {
-		return models.PasswordRule{}, fmt.Errorf("密码规则应用服务未初始化")
+func (a *App) GetDefaultPasswordRule() (models.PasswordRule, error) {
+	if a.passwordRuleApp == nil {
+		return models.PasswordRule{}, fmt.Errorf("密码规则应用服务未初始化")
+	}
+	return a.passwordRuleApp.GetDefaultRule(a.ctx)
+}
+
+/**
+ * CreateGeneralPasswordRule 创建通用密码规则
+ * @param name 规则名称
+ * @param description 规则描述
+ * @param config 通用规则配置
+ * @return models.PasswordRule 创建的密码规则
+ * @return error 错误信息
+ */
+func (a *App) CreateGeneralPasswordRule(name, description string, config models.GeneralRuleConfig) (models.PasswordRule, error) {
+	if a.passwordRuleApp == nil {
+		return models.PasswordRule{}, fmt.Errorf("密码规则应用服务未初始化")
+	}
+	return a.passwordRuleApp.CreateGeneralRule(a.ctx, name, description, config)
+}
+
+/**
+ * CreateCustomPasswordRule 创建自定义密码规则
+ * @param name 规则名称
+ * @param description 规则描述
+ * @param config 自定义规则配置
+ * @return models.PasswordRule 创建的密码规则
+ * @return error 错误信息
+ */
+func (a *App) CreateCustomPasswordRule(name, description string, config models.CustomRuleConfig) (models.PasswordRule, error) {
+	if a.passwordRuleApp == nil {
+		return models.PasswordRule{}, fmt.Errorf("密码规则应用服务未初始化")
+	}
+	return a.passwordRuleApp.CreateCustomRule(a.ctx, name, description, config)
+}
+
+/**
+ * CreatePassphrasePasswordRule 创建口令短语规则
+ * @param name 规则名称
+ * @param description 规则描述
+ * @param config 口令短语规则配置
+ * @return models.PasswordRule 创建的密码规则
+ * @return error 错误信息
+ */
+func (a *App) CreatePassphrasePasswordRule(name, description string, config models.PassphraseRuleConfig) (models.PasswordRule, error) {
+	if a.passwordRuleApp == nil {
+		return models.PasswordRule{}, fmt.Errorf("密码规则应用服务未初始化")
+	}
+	return a.passwordRuleApp.CreatePassphraseRule(a.ctx, name, description, config)
+}
+
+/**
+ * CreatePronounceablePasswordRule 创建可发音密码规则
+ * @param name 规则名称
+ * @param description 规则描述
+ * @param config 可发音密码规则配置
+ * @return models.PasswordRule 创建的密码规则
+ * @return error 错误信息
+ */
+func (a *App) CreatePronounceablePasswordRule(name, description string, config models.PronounceableRuleConfig) (models.PasswordRule, error) {
+	if a.passwordRuleApp == nil {
+		return models.PasswordRule{}, fmt.Errorf("密码规则应用服务未初始化")
+	}
+	return a.passwordRuleApp.CreatePronounceableRule(a.ctx, name, description, config)
+}
+
+/**
+ * UpdateGeneralPasswordRule 更新通用密码规则
+ * @param id 规则ID
+ * @param name 规则名称
+ * @param description 规则描述
+ * @param config 通用规则配置
+ * @return models.PasswordRule 更新后的密码规则
+ * @return error 错误信息
+ */
+func (a *App) UpdateGeneralPasswordRule(id, name, description string, config models.GeneralRuleConfig) (models.PasswordRule, error) {
+	if a.passwordRuleApp == nil {
+		return models.PasswordRule{}, fmt.Errorf("密码规则应用服务未初始化")
+	}
+	return a.passwordRuleApp.UpdateGeneralRule(a.ctx, id, name, description, config)
+}
+
+/**
+ * UpdateCustomPasswordRule 更新自定义密码规则
+ * @param id 规则ID
+ * @param name 规则名称
+ * @param description 规则描述
+ * @param config 自定义规则配置
+ * @return models.PasswordRule 更新后的密码规则
+ * @return error 错误信息
+ */
+func (a *App) UpdateCustomPasswordRule(id, name, description string, config models.CustomRuleConfig) (models.PasswordRule, error) {
+	if a.passwordRuleApp == nil {
+		return models.PasswordRule{}, fmt.Errorf("密码规则应用服务未初始化")
+	}
+	return a.passwordRuleApp.UpdateCustomRule(a.ctx, id, name, description, config)
+}
+
+/**
+ * UpdatePassphrasePasswordRule 更新口令短语规则
+ * @param id 规则ID
+ * @param name 规则名称
+ * @param description 规则描述
+ * @param config 口令短语规则配置
+ * @return models.PasswordRule 更新后的密码规则
+ * @return error 错误信息
+ */
+func (a *App) UpdatePassphrasePasswordRule(id, name, description string, config models.PassphraseRuleConfig) (models.PasswordRule, error) {
+	if a.passwordRuleApp == nil {
+		return models.PasswordRule{}, fmt.Errorf("密码规则应用服务未初始化")
+	}
+	return a.passwordRuleApp.UpdatePassphraseRule(a.ctx, id, name, description, config)
+}
+
+/**
+ * UpdatePronounceablePasswordRule 更新可发音密码规则
+ * @param id 规则ID
+ * @param name 规则名称
+ * @param description 规则描述
+ * @param config 可发音密码规则配置
+ * @return models.PasswordRule 更新后的密码规则
+ * @return error 错误信息
+ */
+func (a *App) UpdatePronounceablePasswordRule(id, name, description string, config models.PronounceableRuleConfig) (models.PasswordRule, error) {
+	if a.passwordRuleApp == nil {
+		return models.PasswordRule{}, fmt.Errorf("密码规则应用服务未初始化")
+	}
+	return a.passwordRuleApp.UpdatePronounceableRule(a.ctx, id, name, description, config)
+}
+
+/**
+ * DeletePasswordRule 删除密码规则
+ * @param id 规则ID
+ * @return error 错误信息
+ */
+func (a *App) DeletePasswordRule(id string) error {
+	if a.passwordRuleApp == nil {
+		return fmt.Errorf("密码规则应用服务未初始化")
+	}
+	return a.passwordRuleApp.DeleteRule(a.ctx, id)
+}
+
+/**
+ * GeneratePasswordByRule 根据规则生成密码
+ * @param ruleID 规则ID
+ * @return string 生成的密码
+ * @return error 错误信息
+ */
+func (a *App) GeneratePasswordByRule(ruleID string) (string, error) {
+	if a.passwordRuleApp == nil {
+		return "", fmt.Errorf("密码规则应用服务未初始化")
+	}
+	return a.passwordRuleApp.GeneratePassword(a.ctx, ruleID)
+}
+
+/**
+ * GeneratePasswordByGeneralConfig 根据通用配置生成密码
+ * @param config 通用规则配置
+ * @return string 生成的密码
+ * @return error 错误信息
+ */
+func (a *App) GeneratePasswordByGeneralConfig(config models.GeneralRuleConfig) (string, error) {
+	if a.passwordRuleApp == nil {
+		return "", fmt.Errorf("密码规则应用服务未初始化")
+	}
+	return a.passwordRuleApp.GeneratePasswordByGeneralConfig(a.ctx, config)
+}
+
+/**
+ * GeneratePasswordByCustomConfig 根据自定义配置生成密码
+ * @param config 自定义规则配置
+ * @return string 生成的密码
+ * @return error 错误信息
+ */
+func (a *App) GeneratePasswordByCustomConfig(config models.CustomRuleConfig) (string, error) {
+	if a.passwordRuleApp == nil {
+		return "", fmt.Errorf("密码规则应用服务未初始化")
+	}
+	return a.passwordRuleApp.GeneratePasswordByCustomConfig(a.ctx, config)
+}
+
+/**
+ * GeneratePasswordByPassphraseConfig 根据口令短语配置生成密码
+ * @param config 口令短语规则配置
+ * @return string 生成的口令短语
+ * @return error 错误信息
+ */
+func (a *App) GeneratePasswordByPassphraseConfig(config models.PassphraseRuleConfig) (string, error) {
+	if a.passwordRuleApp == nil {
+		return "", fmt.Errorf("密码规则应用服务未初始化")
+	}
+	return a.passwordRuleApp.GeneratePasswordByPassphraseConfig(a.ctx, config)
+}
+
+/**
+ * GeneratePasswordByPronounceableConfig 根据可发音密码配置生成密码
+ * @param config 可发音密码规则配置
+ * @return string 生成的可发音密码
+ * @return error 错误信息
+ */
+func (a *App) GeneratePasswordByPronounceableConfig(config models.PronounceableRuleConfig) (string, error) {
+	if a.passwordRuleApp == nil {
+		return "", fmt.Errorf("密码规则应用服务未初始化")
+	}
+	return a.passwordRuleApp.GeneratePasswordByPronounceableConfig(a.ctx, config)
+}
+
+/**
+ * CalculatePasswordRuleEntropy 计算已保存密码规则的熵值
+ * @param ruleID 规则ID
+ * @return models.PasswordRuleEntropy 熵值信息
+ * @return error 错误信息
+ */
+func (a *App) CalculatePasswordRuleEntropy(ruleID string) (models.PasswordRuleEntropy, error) {
+	if a.passwordRuleApp == nil {
+		return models.PasswordRuleEntropy{}, fmt.Errorf("密码规则应用服务未初始化")
+	}
+	return a.passwordRuleApp.CalculateRuleEntropy(a.ctx, ruleID)
+}
+
+/**
+ * CalculateGeneralConfigEntropy 计算通用规则配置的熵值
+ * @param config 通用规则配置
+ * @return models.PasswordRuleEntropy 熵值信息
+ * @return error 错误信息
+ */
+func (a *App) CalculateGeneralConfigEntropy(config models.GeneralRuleConfig) (models.PasswordRuleEntropy, error) {
+	if a.passwordRuleApp == nil {
+		return models.PasswordRuleEntropy{}, fmt.Errorf("密码规则应用服务未初始化")
+	}
+	return a.passwordRuleApp.CalculateConfigEntropy(a.ctx, "general", config)
+}
+
+/**
+ * CalculateCustomConfigEntropy 计算自定义规则配置的熵值
+ * @param config 自定义规则配置
+ * @return models.PasswordRuleEntropy 熵值信息
+ * @return error 错误信息
+ */
+func (a *App) CalculateCustomConfigEntropy(config models.CustomRuleConfig) (models.PasswordRuleEntropy, error) {
+	if a.passwordRuleApp == nil {
+		return models.PasswordRuleEntropy{}, fmt.Errorf("密码规则应用服务未初始化")
+	}
+	return a.passwordRuleApp.CalculateConfigEntropy(a.ctx, "custom", config)
+}
+
+/**
+ * CalculatePassphraseConfigEntropy 计算口令短语规则配置的熵值
+ * @param config 口令短语规则配置
+ * @return models.PasswordRuleEntropy 熵值信息
+ * @return error 错误信息
+ */
+func (a *App) CalculatePassphraseConfigEntropy(config models.PassphraseRuleConfig) (models.PasswordRuleEntropy, error) {
+	if a.passwordRuleApp == nil {
+		return models.PasswordRuleEntropy{}, fmt.Errorf("密码规则应用服务未初始化")
+	}
+	return a.passwordRuleApp.CalculateConfigEntropy(a.ctx, "passphrase", config)
+}
+
+/**
+ * CalculatePronounceableConfigEntropy 计算可发音密码规则配置的熵值
+ * @param config 可发音密码规则配置
+ * @return models.PasswordRuleEntropy 熵值信息
+ * @return error 错误信息
+ */
+func (a *App) CalculatePronounceableConfigEntropy(config models.PronounceableRuleConfig) (models.PasswordRuleEntropy, error) {
+	if a.passwordRuleApp == nil {
+		return models.PasswordRuleEntropy{}, fmt.Errorf("密码规则应用服务未初始化")
+	}
+	return a.passwordRuleApp.CalculateConfigEntropy(a.ctx, "pronounceable", config)
+}
+
+/**
+ * SetPasswordRuleAsDefault 设置密码规则为默认规则
+ * @param ruleID 规则ID
+ * @param isDefault 是否设为默认
+ * @return error 错误信息
+ */
+func (a *App) SetPasswordRuleAsDefault(ruleID string, isDefault bool) error {
+	if a.passwordRuleApp == nil {
+		return fmt.Errorf("密码规则应用服务未初始化")
+	}
+	return a.passwordRuleApp.SetRuleAsDefault(a.ctx, ruleID, isDefault)
+}
+
+/**
+ * SetGroupPasswordRule 设置分组的默认密码规则
+ * @param groupID 分组ID
+ * @param ruleID 规则ID，空字符串表示解除绑定
+ * @return error 错误信息
+ * @author 陈凤庆
+ * @date 20251021
+ */
+func (a *App) SetGroupPasswordRule(groupID, ruleID string) error {
+	if a.passwordRuleApp == nil {
+		return fmt.Errorf("密码规则应用服务未初始化")
+	}
+	return a.passwordRuleApp.SetGroupRule(a.ctx, groupID, ruleID)
+}
+
+/**
+ * SetTypePasswordRule 设置类型的默认密码规则
+ * @param typeID 类型ID
+ * @param ruleID 规则ID，空字符串表示解除绑定（使用所属分组的规则）
+ * @return error 错误信息
+ * @author 陈凤庆
+ * @date 20251021
+ */
+func (a *App) SetTypePasswordRule(typeID, ruleID string) error {
+	if a.passwordRuleApp == nil {
+		return fmt.Errorf("密码规则应用服务未初始化")
+	}
+	return a.passwordRuleApp.SetTypeRule(a.ctx, typeID, ruleID)
+}
+
+/**
+ * GetEffectivePasswordRuleID 获取类型实际生效的默认密码规则（类型绑定优先，其次为最近的上级分组）
+ * @param typeID 类型ID
+ * @return string 规则ID，未绑定时为空字符串
+ * @return error 错误信息
+ * @author 陈凤庆
+ * @date 20251021
+ */
+func (a *App) GetEffectivePasswordRuleID(typeID string) (string, error) {
+	if a.passwordRuleApp == nil {
+		return "", fmt.Errorf("密码规则应用服务未初始化")
+	}
+	return a.passwordRuleApp.ResolveRuleIDForType(a.ctx, typeID)
+}
+
+/**
+ * GeneratePasswordForType 使用类型绑定的默认规则生成密码，用于新建账号表单
+ * @param typeID 类型ID
+ * @return string 生成的密码，未绑定规则时为空字符串
+ * @return error 错误信息
+ * @author 陈凤庆
+ * @date 20251021
+ */
+func (a *App) GeneratePasswordForType(typeID string) (string, error) {
+	if a.passwordRuleApp == nil {
+		return "", fmt.Errorf("密码规则应用服务未初始化")
+	}
+	return a.passwordRuleApp.GeneratePasswordForType(a.ctx, typeID)
+}
+
+/**
+ * GenerateSitePassword 确定性生成站点密码（不保存，相同参数可随时重新生成）
+ * @param masterPassword 主密码
+ * @param profile 站点密码参数（站点、登录名、计数器、通用规则ID）
+ * @return string 生成的密码
+ * @return error 错误信息
+ * @author 陈凤庆
+ * @date 20251021
+ */
+func (a *App) GenerateSitePassword(masterPassword string, profile models.SitePasswordProfile) (string, error) {
+	if a.passwordRuleApp == nil {
+		return "", fmt.Errorf("密码规则应用服务未初始化")
+	}
+	return a.passwordRuleApp.GenerateSitePassword(a.ctx, masterPassword, profile)
+}
+
+/**
+ * RotateSitePassword 计数器加1后重新生成站点密码，前端需保存返回的新计数器
+ * @param masterPassword 主密码
+ * @param profile 当前站点密码参数
+ * @return *models.SitePasswordResult 新密码及新的站点密码参数
+ * @return error 错误信息
+ * @author 陈凤庆
+ * @date 20251021
+ */
+func (a *App) RotateSitePassword(masterPassword string, profile models.SitePasswordProfile) (*models.SitePasswordResult, error) {
+	if a.passwordRuleApp == nil {
+		return nil, fmt.Errorf("密码规则应用服务未初始化")
+	}
+	return a.passwordRuleApp.RotateSitePassword(a.ctx, masterPassword, profile)
+}
+
+/**
+ * GenerateBatchPasswords 按规则批量生成互不重复的密码（只返回，不保存）
+ * @param request 批量生成请求
+ * @return []models.BatchPasswordEntry 记录列表
+ * @return error 错误信息
+ * @author 陈凤庆
+ * @date 20251021
+ */
+func (a *App) GenerateBatchPasswords(request models.BatchPasswordRequest) ([]models.BatchPasswordEntry, error) {
+	if a.passwordRuleApp == nil {
+		return nil, fmt.Errorf("密码规则应用服务未初始化")
+	}
+
+	exclude, err := a.batchExcludeSet(request)
+	if err != nil {
+		return nil, err
+	}
+	return a.passwordRuleApp.GenerateBatchEntries(a.ctx, request, exclude)
+}
+
+/**
+ * ExportBatchPasswordsCSV 批量生成密码并导出为CSV文件
+ * @param request 批量生成请求
+ * @param exportPath 导出路径，为空时弹出保存对话框
+ * @return int 导出的记录数，取消保存时为0
+ * @return error 错误信息
+ * @author 陈凤庆
+ * @date 20251021
+ */
+func (a *App) ExportBatchPasswordsCSV(request models.BatchPasswordRequest, exportPath string) (int, error) {
+	if a.passwordRuleApp == nil {
+		return 0, fmt.Errorf("密码规则应用服务未初始化")
+	}
+
+	if exportPath == "" {
+		selection, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+			Title:           "选择导出路径",
+			DefaultFilename: "wepass_batch_passwords.csv",
+			Filters: []runtime.FileFilter{
+				{
+					DisplayName: "CSV文件 (*.csv)",
+					Pattern:     "*.csv",
+				},
+			},
+		})
+		if err != nil {
+			return 0, fmt.Errorf("选择导出路径失败: %w", err)
+		}
+		if selection == "" {
+			return 0, nil
+		}
+		exportPath = selection
+	}
+
+	entries, err := a.GenerateBatchPasswords(request)
+	if err != nil {
+		return 0, err
+	}
+	if err := a.passwordRuleApp.ExportBatchCSV(a.ctx, entries, exportPath); err != nil {
+		return 0, err
+	}
+	return len(entries), nil
+}
+
+/**
+ * CreateBatchAccounts 批量生成密码并直接创建为指定类型下的账号
+ * @param request 批量生成请求（TypeID必填）
+ * @return []models.AccountDecrypted 创建的账号列表
+ * @return error 错误信息，任一账号创建失败时删除本次已创建的账号
+ * @author 陈凤庆
+ * @date 20251021
+ */
+func (a *App) CreateBatchAccounts(request models.BatchPasswordRequest) ([]models.AccountDecrypted, error) {
+	if request.TypeID == "" {
+		return nil, fmt.Errorf("类型ID不能为空")
+	}
+
+	entries, err := a.GenerateBatchPasswords(request)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := make([]models.AccountDecrypted, 0, len(entries))
+	for _, entry := range entries {
+		account, err := a.accountService.CreateAccount(entry.Title, entry.Username, entry.Password, entry.URL, request.TypeID, request.Notes, request.InputMethod)
+		if err != nil {
+			logger.Error("[批量创建账号] 创建第%d个账号失败，回滚已创建的%d个账号: %v", entry.Index, len(accounts), err)
+			for _, created := range accounts {
+				if deleteErr := a.accountService.DeleteAccount(created.ID); deleteErr != nil {
+					logger.Error("[批量创建账号] 回滚删除账号失败，账号ID: %s, 错误: %v", created.ID, deleteErr)
+				}
+			}
+			return nil, fmt.Errorf("创建账号“%s”失败: %w", entry.Title, err)
+		}
+		accounts = append(accounts, account)
+	}
+
+	logger.Info("[批量创建账号] 成功在类型 %s 下创建 %d 个账号", request.TypeID, len(accounts))
+	return accounts, nil
+}
+
+/**
+ * batchExcludeSet 获取批量生成时需要排除的密码库已有密码
+ * @param request 批量生成请求
+ * @return map[string]bool 已有密码集合，不排除时为nil
+ * @return error 错误信息
+ */
+func (a *App) batchExcludeSet(request models.BatchPasswordRequest) (map[string]bool, error) {
+	if !request.ExcludeVault {
+		return nil, nil
+	}
+
+	accounts, err := a.accountService.GetAllAccounts()
+	if err != nil {
+		return nil, fmt.Errorf("读取密码库已有密码失败: %w", err)
+	}
+
+	exclude := make(map[string]bool, len(accounts))
+	for _, account := range accounts {
+		exclude[account.Password] = true
+	}
+	return exclude, nil
+}
+
+/**
+ * ExportPasswordRules 导出密码规则到JSON文件
+ * @param ruleIDs 要导出的规则ID，为空时导出全部规则
+ * @param revision 规则集修订号
+ * @param exportPath 导出路径，为空时弹出保存对话框
+ * @return string 实际导出路径，取消保存时为空字符串
+ * @return error 错误信息
+ * @author 陈凤庆
+ * @date 20251021
+ */
+func (a *App) ExportPasswordRules(ruleIDs []string, revision int, exportPath string) (string, error) {
+	if a.passwordRuleApp == nil {
+		return "", fmt.Errorf("密码规则应用服务未初始化")
+	}
+
+	if exportPath == "" {
+		selection, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+			Title:           "选择导出路径",
+			DefaultFilename: "wepass_password_rules.json",
+			Filters:         passwordRuleFileFilters(),
+		})
+		if err != nil {
+			return "", fmt.Errorf("选择导出路径失败: %w", err)
+		}
+		if selection == "" {
+			return "", nil
+		}
+		exportPath = selection
+	}
+
+	if err := a.passwordRuleApp.ExportRules(a.ctx, ruleIDs, revision, exportPath); err != nil {
+		return "", err
+	}
+	return exportPath, nil
+}
+
+/**
+ * ImportPasswordRules 从JSON文件导入密码规则
+ * @param importPath 规则文件路径，为空时弹出选择对话框
+ * @param conflict 名称冲突处理方式：skip(默认)、overwrite、rename
+ * @return models.PasswordRuleImportResult 导入结果，取消选择时为空
+ * @return error 错误信息
+ * @author 陈凤庆
+ * @date 20251021
+ */
+func (a *App) ImportPasswordRules(importPath, conflict string) (models.PasswordRuleImportResult, error) {
+	if a.passwordRuleApp == nil {
+		return models.PasswordRuleImportResult{}, fmt.Errorf("密码规则应用服务未初始化")
+	}
+
+	importPath, err := a.selectPasswordRuleFile(importPath)
+	if err != nil || importPath == "" {
+		return models.PasswordRuleImportResult{}, err
+	}
+	return a.passwordRuleApp.ImportRules(a.ctx, importPath, conflict)
+}
+
+/**
+ * GetPasswordRuleSubscription 获取当前密码库订阅的规则文件
+ * @return *models.PasswordRuleSubscription 订阅信息，未订阅时为nil
+ * @return error 错误信息
+ * @author 陈凤庆
+ * @date 20251021
+ */
+func (a *App) GetPasswordRuleSubscription() (*models.PasswordRuleSubscription, error) {
+	if a.passwordRuleApp == nil {
+		return nil, fmt.Errorf("密码规则应用服务未初始化")
+	}
+	return a.passwordRuleApp.GetRuleSubscription(a.ctx)
+}
+
+/**
+ * SubscribePasswordRuleFile 当前密码库订阅规则文件，打开密码库时自动同步
+ * @param path 规则文件路径，为空时弹出选择对话框
+ * @return models.PasswordRuleImportResult 首次同步结果，取消选择时为空
+ * @return error 错误信息
+ * @author 陈凤庆
+ * @date 20251021
+ */
+func (a *App) SubscribePasswordRuleFile(path string) (models.PasswordRuleImportResult, error) {
+	if a.passwordRuleApp == nil {
+		return models.PasswordRuleImportResult{}, fmt.Errorf("密码规则应用服务未初始化")
+	}
+
+	path, err := a.selectPasswordRuleFile(path)
+	if err != nil || path == "" {
+		return models.PasswordRuleImportResult{}, err
+	}
+	return a.passwordRuleApp.SubscribeRuleFile(a.ctx, path)
+}
+
+/**
+ * UnsubscribePasswordRuleFile 取消订阅规则文件，已同步的规则保留为本地规则
+ * @return error 错误信息
+ * @author 陈凤庆
+ * @date 20251021
+ */
+func (a *App) UnsubscribePasswordRuleFile() error {
+	if a.passwordRuleApp == nil {
+		return fmt.Errorf("密码规则应用服务未初始化")
+	}
+	return a.passwordRuleApp.UnsubscribeRuleFile(a.ctx)
+}
+
+/**
+ * SyncPasswordRuleFile 立即按订阅的规则文件同步规则
+ * @return models.PasswordRuleImportResult 同步结果
+ * @return error 错误信息
+ * @author 陈凤庆
+ * @date 20251021
+ */
+func (a *App) SyncPasswordRuleFile() (models.PasswordRuleImportResult, error) {
+	if a.passwordRuleApp == nil {
+		return models.PasswordRuleImportResult{}, fmt.Errorf("密码规则应用服务未初始化")
+	}
+	return a.passwordRuleApp.SyncRuleSubscription(a.ctx)
+}
+
+/**
+ * selectPasswordRuleFile 路径为空时弹出规则文件选择对话框
+ * @param path 规则文件路径
+ * @return string 规则文件路径，取消选择时为空字符串
+ * @return error 错误信息
+ */
+func (a *App) selectPasswordRuleFile(path string) (string, error) {
+	if path != "" {
+		return path, nil
+	}
+
+	selection, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title:   "选择密码规则文件",
+		Filters: passwordRuleFileFilters(),
+	})
+	if err != nil {
+		return "", fmt.Errorf("选择规则文件失败: %w", err)
+	}
+	return selection, nil
+}
+
+/**
+ * passwordRuleFileFilters 密码规则文件对话框的文件类型过滤
+ * @return []runtime.FileFilter 文件类型过滤
+ */
+func passwordRuleFileFilters() []runtime.FileFilter {
+	return []runtime.FileFilter{
+		{
+			DisplayName: "密码规则文件 (*.json)",
+			Pattern:     "*.json",
+		},
+		{
+			DisplayName: "所有文件 (*.*)",
+			Pattern:     "*.*",
+		},
+	}
+}
+
+/**
+ * GetCompliancePresets 获取内置合规预设列表
+ * @return []models.CompliancePreset 合规预设列表
+ * @author 陈凤庆
+ * @date 20251021
+ */
+func (a *App) GetCompliancePresets() ([]models.CompliancePreset, error) {
+	if a.passwordRuleApp == nil {
+		return nil, fmt.Errorf("密码规则应用服务未初始化")
+	}
+	return a.passwordRuleApp.GetCompliancePresets(a.ctx), nil
+}
+
+/**
+ * InstallCompliancePreset 将合规预设的生成规则安装为密码规则
+ * @param presetID 预设标识
+ * @return models.PasswordRule 安装的规则，同名规则已存在时返回已有规则
+ * @return error 错误信息
+ * @author 陈凤庆
+ * @date 20251021
+ */
+func (a *App) InstallCompliancePreset(presetID string) (models.PasswordRule, error) {
+	if a.passwordRuleApp == nil {
+		return models.PasswordRule{}, fmt.Errorf("密码规则应用服务未初始化")
+	}
+	return a.passwordRuleApp.InstallCompliancePreset(a.ctx, presetID)
+}
+
+/**
+ * CheckAccountCompliance 按合规预设检查密码库中全部账号的密码
+ * @param presetID 预设标识
+ * @param bannedWords 额外禁止包含的词，如公司名称
+ * @return models.ComplianceReport 检查报告，只列出不合规的账号及问题
+ * @return error 错误信息
+ * @author 陈凤庆
+ * @date 20251021
+ */
+func (a *App) CheckAccountCompliance(presetID string, bannedWords []string) (models.ComplianceReport, error) {
+	if a.passwordRuleApp == nil {
+		return models.ComplianceReport{}, fmt.Errorf("密码规则应用服务未初始化")
+	}
+
+	accounts, err := a.accountService.GetAllAccounts()
+	if err != nil {
+		return models.ComplianceReport{}, fmt.Errorf("读取账号失败: %w", err)
+	}
+	// 20251021 陈凤庆 密码使用天数按记录的密码修改时间计算
+	changedAt, err := a.passwordExpiryService.GetPasswordChangedTimes()
+	if err != nil {
+		return models.ComplianceReport{}, fmt.Errorf("读取密码修改时间失败: %w", err)
+	}
+	return a.passwordRuleApp.CheckCompliance(a.ctx, presetID, accounts, bannedWords, changedAt)
+}
+
+/**
+ * GetVaultHealthReport 生成密码库健康报告
+ * @param options 报告选项
+ * @return models.HealthReport 健康报告，包含各账号的问题和密码库得分
+ * @return error 错误信息
+ * @author 陈凤庆
+ * @date 20251021
+ * @modify 20251021 陈凤庆 配置了离线泄露数据时标记已泄露的密码
+ */
+func (a *App) GetVaultHealthReport(options models.HealthReportOptions) (models.HealthReport, error) {
+	if a.accountService == nil {
+		return models.HealthReport{}, fmt.Errorf("账号服务未初始化")
 	}
-	return a.passwordRuleApp.GetDefaultRule(a.ctx)
+
+	accounts, err := a.accountService.GetAllAccounts()
+	if err != nil {
+		return models.HealthReport{}, fmt.Errorf("读取账号失败: %w", err)
+	}
+	// 20251021 陈凤庆 密码使用天数按记录的密码修改时间计算
+	changedAt, err := a.passwordExpiryService.GetPasswordChangedTimes()
+	if err != nil {
+		return models.HealthReport{}, fmt.Errorf("读取密码修改时间失败: %w", err)
+	}
+
+	// 20251021 陈凤庆 配置了离线泄露数据时同时检查密码是否已泄露
+	var breaches services.BreachLookup
+	if dataPath := a.configManager.GetBreachCheckConfig().DataPath; dataPath != "" {
+		checker, err := services.OpenBreachChecker(dataPath)
+		if err != nil {
+			return models.HealthReport{}, fmt.Errorf("打开离线泄露数据失败: %w", err)
+		}
+		defer checker.Close()
+		breaches = checker
+	}
+
+	report, err := services.BuildHealthReport(accounts, changedAt, options, breaches, time.Now())
+	if err != nil {
+		logger.Error("[健康报告] 生成健康报告失败: %v", err)
+		return models.HealthReport{}, fmt.Errorf("生成健康报告失败: %w", err)
+	}
+
+	logger.Info("[健康报告] 检查%d个账号，%d个存在问题，得分%d", report.AccountCount, len(report.Accounts), report.Score)
+	return report, nil
 }
 
 /**
- * CreateGeneralPasswordRule 创建通用密码规则
- * @param name 规则名称
- * @param description 规则描述
- * @param config 通用规则配置
- * @return models.PasswordRule 创建的密码规则
+ * GetBreachCheckConfig 获取离线泄露检查配置
+ * @return models.BreachCheckConfig 离线泄露检查配置
+ * @author 陈凤庆
+ * @date 20251021
+ */
+func (a *App) GetBreachCheckConfig() models.BreachCheckConfig {
+	return a.configManager.GetBreachCheckConfig()
+}
+
+/**
+ * SetBreachCheckConfig 设置离线泄露检查配置
+ * @param breachConfig 离线泄露检查配置，数据路径为空表示不检查
+ * @return models.BreachDataInfo 泄露数据信息，不检查时为空
+ * @return error 数据路径无效或保存失败时返回错误
+ * @author 陈凤庆
+ * @date 20251021
+ */
+func (a *App) SetBreachCheckConfig(breachConfig models.BreachCheckConfig) (models.BreachDataInfo, error) {
+	logger.Info("[泄露检查] 设置离线泄露数据: %s", breachConfig.DataPath)
+
+	var info models.BreachDataInfo
+	if breachConfig.DataPath != "" {
+		checker, err := services.OpenBreachChecker(breachConfig.DataPath)
+		if err != nil {
+			logger.Error("[泄露检查] 离线泄露数据无效: %v", err)
+			return models.BreachDataInfo{}, err
+		}
+		info = checker.Info()
+		checker.Close()
+	}
+
+	if err := a.configManager.SetBreachCheckConfig(breachConfig); err != nil {
+		logger.Error("[泄露检查] 保存配置失败: %v", err)
+		return models.BreachDataInfo{}, fmt.Errorf("保存离线泄露检查配置失败: %w", err)
+	}
+	return info, nil
+}
+
+/**
+ * CheckPasswordBreach 使用离线泄露数据检查单个密码
+ * @param password 密码
+ * @return int 密码在泄露数据中出现的次数，未泄露时为0
+ * @return error 未配置离线泄露数据或查询失败时返回错误
+ * @author 陈凤庆
+ * @date 20251021
+ */
+func (a *App) CheckPasswordBreach(password string) (int, error) {
+	dataPath := a.configManager.GetBreachCheckConfig().DataPath
+	if dataPath == "" {
+		return 0, fmt.Errorf("未配置离线泄露数据")
+	}
+
+	checker, err := services.OpenBreachChecker(dataPath)
+	if err != nil {
+		return 0, fmt.Errorf("打开离线泄露数据失败: %w", err)
+	}
+	defer checker.Close()
+
+	return checker.BreachCount(password)
+}
+
+/**
+ * GetAccountExpiry 获取账号的密码到期设置
+ * @param accountID 账号ID
+ * @return models.AccountExpiry 到期设置
  * @return error 错误信息
+ * @author 陈凤庆
+ * @date 20251021
  */
-func (a *App) CreateGeneralPasswordRule(name, description string, config models.GeneralRuleConfig) (models.PasswordRule, error) {
-	if a.passwordRuleApp == nil {
-		return models.PasswordRule{}, fmt.Errorf("密码规则应用服务未初始化")
+func (a *App) GetAccountExpiry(accountID string) (models.AccountExpiry, error) {
+	if a.passwordExpiryService == nil {
+		return models.AccountExpiry{}, fmt.Errorf("密码到期提醒服务未初始化")
 	}
-	return a.passwordRuleApp.CreateGeneralRule(a.ctx, name, description, config)
+	return a.passwordExpiryService.GetAccountExpiry(accountID)
 }
 
 /**
- * CreateCustomPasswordRule 创建自定义密码规则
- * @param name 规则名称
- * @param description 规则描述
- * @param config 自定义规则配置
- * @return models.PasswordRule 创建的密码规则
+ * SetAccountExpiry 设置账号的密码到期日期和轮换周期
+ * @param accountID 账号ID
+ * @param expiresAt 到期日期，为空表示不设置
+ * @param rotationDays 轮换周期（天），0表示不设置
  * @return error 错误信息
+ * @author 陈凤庆
+ * @date 20251021
  */
-func (a *App) CreateCustomPasswordRule(name, description string, config models.CustomRuleConfig) (models.PasswordRule, error) {
+func (a *App) SetAccountExpiry(accountID string, expiresAt *time.Time, rotationDays int) error {
+	if a.passwordExpiryService == nil {
+		return fmt.Errorf("密码到期提醒服务未初始化")
+	}
+
+	if err := a.passwordExpiryService.SetAccountExpiry(accountID, expiresAt, rotationDays); err != nil {
+		logger.Error("[密码到期] 设置账号到期失败，账号ID: %s, 错误: %v", accountID, err)
+		return err
+	}
+	logger.Info("[密码到期] 设置账号到期成功，账号ID: %s, 轮换周期: %d天", accountID, rotationDays)
+	return nil
+}
+
+/**
+ * GetExpirySummary 获取即将到期和已过期的账号
+ * @return models.ExpirySummary 到期汇总，提前提醒天数取自到期提醒配置
+ * @return error 错误信息
+ * @author 陈凤庆
+ * @date 20251021
+ */
+func (a *App) GetExpirySummary() (models.ExpirySummary, error) {
+	if a.passwordExpiryService == nil {
+		return models.ExpirySummary{}, fmt.Errorf("密码到期提醒服务未初始化")
+	}
+	reminderDays := a.configManager.GetExpiryReminderConfig().ReminderDays
+	return a.passwordExpiryService.GetExpirySummary(time.Now(), reminderDays)
+}
+
+/**
+ * GetExpiryReminderConfig 获取密码到期提醒配置
+ * @return models.ExpiryReminderConfig 密码到期提醒配置
+ * @author 陈凤庆
+ * @date 20251021
+ */
+func (a *App) GetExpiryReminderConfig() models.ExpiryReminderConfig {
+	return a.configManager.GetExpiryReminderConfig()
+}
+
+/**
+ * SetExpiryReminderConfig 设置密码到期提醒配置
+ * @param reminderConfig 密码到期提醒配置
+ * @return error 错误信息
+ * @author 陈凤庆
+ * @date 20251021
+ */
+func (a *App) SetExpiryReminderConfig(reminderConfig models.ExpiryReminderConfig) error {
+	if reminderConfig.ReminderDays < 0 {
+		return fmt.Errorf("提前提醒天数不能为负数")
+	}
+	if err := a.configManager.SetExpiryReminderConfig(reminderConfig); err != nil {
+		logger.Error("[密码到期] 保存配置失败: %v", err)
+		return fmt.Errorf("保存密码到期提醒配置失败: %w", err)
+	}
+	return nil
+}
+
+/**
+ * remindExpiringPasswords 解锁密码库后检查即将到期和已过期的密码
+ * @description 有需要修改的密码时记录日志，并按配置在后台发送桌面通知，失败不影响解锁
+ */
+func (a *App) remindExpiringPasswords() {
+	summary, err := a.GetExpirySummary()
+	if err != nil {
+		logger.Error("[密码到期] 检查密码到期失败: %v", err)
+		return
+	}
+	if len(summary.Items) == 0 {
+		return
+	}
+	logger.Info("[密码到期] %d个密码已过期，%d个密码即将到期", summary.OverdueCount, summary.DueCount)
+
+	if !a.configManager.GetExpiryReminderConfig().EnableDesktopNotification {
+		return
+	}
+
+	notification := services.DesktopNotification{
+		Summary:   "密码到期提醒",
+		Body:      fmt.Sprintf("%d个密码已过期，%d个密码即将到期，最早的是“%s”", summary.OverdueCount, summary.DueCount, summary.Items[0].Title),
+		Urgency:   services.NotificationUrgencyNormal,
+		TimeoutMs: -1,
+	}
+	if summary.OverdueCount > 0 {
+		notification.Urgency = services.NotificationUrgencyCritical
+	}
+	go func() {
+		if _, err := services.SendDesktopNotification(notification); err != nil {
+			logger.Debug("[密码到期] 发送桌面通知失败: %v", err)
+		}
+	}()
+}
+
+/**
+ * RegenerateAccountPassword 使用账号所属类型绑定的规则重新生成密码
+ * @param accountID 账号ID
+ * @return string 新生成的密码（只返回，不保存，由前端确认后通过UpdateAccount保存）
+ * @return error 错误信息
+ * @author 陈凤庆
+ * @date 20251021
+ */
+func (a *App) RegenerateAccountPassword(accountID string) (string, error) {
 	if a.passwordRuleApp == nil {
-		return models.PasswordRule{}, fmt.Errorf("密码规则应用服务未初始化")
+		return "", fmt.Errorf("密码规则应用服务未初始化")
 	}
-	return a.passwordRuleApp.CreateCustomRule(a.ctx, name, description, config)
+	if accountID == "" {
+		return "", fmt.Errorf("账号ID不能为空")
+	}
+
+	account, err := a.accountService.GetAccountByID(accountID)
+	if err != nil {
+		return "", fmt.Errorf("获取账号失败: %w", err)
+	}
+	if account == nil {
+		return "", fmt.Errorf("账号不存在")
+	}
+
+	password, err := a.passwordRuleApp.GeneratePasswordForType(a.ctx, account.TypeID)
+	if err != nil {
+		return "", err
+	}
+	if password == "" {
+		return "", fmt.Errorf("账号所属类型及其分组均未绑定默认密码规则")
+	}
+	return password, nil
 }
 
 /**
- * UpdateGeneralPasswordRule 更新通用密码规则
- * @param id 规则ID
- * @param name 规则名称
- * @param description 规则描述
- * @param config 通用规则配置
- * @return models.PasswordRule 更新后的密码规则
+ * StartPasswordRotation 开始账号的密码轮换
+ * @param accountID 账号ID
+ * @param password 新密码，为空时使用账号所属类型绑定的规则生成
+ * @return models.PasswordRotation 轮换状态，提交前账号仍使用旧密码
+ * @return error 错误信息
+ * @author 陈凤庆
+ * @date 20251021
+ */
+func (a *App) StartPasswordRotation(accountID, password string) (models.PasswordRotation, error) {
+	if a.passwordRotationService == nil {
+		return models.PasswordRotation{}, fmt.Errorf("密码轮换服务未初始化")
+	}
+
+	ruleID := ""
+	if password == "" {
+		account, err := a.accountService.GetAccountByID(accountID)
+		if err != nil {
+			return models.PasswordRotation{}, fmt.Errorf("获取账号失败: %w", err)
+		}
+		ruleID, err = a.GetEffectivePasswordRuleID(account.TypeID)
+		if err != nil {
+			return models.PasswordRotation{}, err
+		}
+		password, err = a.RegenerateAccountPassword(accountID)
+		if err != nil {
+			return models.PasswordRotation{}, err
+		}
+	}
+
+	rotation, err := a.passwordRotationService.StartRotation(accountID, password, ruleID)
+	if err != nil {
+		logger.Error("[密码轮换] 开始轮换失败，账号ID: %s, 错误: %v", accountID, err)
+		return models.PasswordRotation{}, err
+	}
+	logger.Info("[密码轮换] 开始轮换，账号ID: %s, 规则ID: %s", accountID, ruleID)
+	return rotation, nil
+}
+
+/**
+ * GetPasswordRotation 获取账号进行中的密码轮换
+ * @param accountID 账号ID
+ * @return *models.PasswordRotation 轮换状态，没有进行中的轮换时为nil
+ * @return error 错误信息
+ * @author 陈凤庆
+ * @date 20251021
+ */
+func (a *App) GetPasswordRotation(accountID string) (*models.PasswordRotation, error) {
+	if a.passwordRotationService == nil {
+		return nil, fmt.Errorf("密码轮换服务未初始化")
+	}
+	return a.passwordRotationService.GetRotation(accountID)
+}
+
+/**
+ * GetPendingPasswordRotations 获取所有进行中的密码轮换，用于重启后继续未完成的轮换
+ * @return []models.PasswordRotation 轮换状态列表
+ * @return error 错误信息
+ * @author 陈凤庆
+ * @date 20251021
+ */
+func (a *App) GetPendingPasswordRotations() ([]models.PasswordRotation, error) {
+	if a.passwordRotationService == nil {
+		return nil, fmt.Errorf("密码轮换服务未初始化")
+	}
+	return a.passwordRotationService.GetPendingRotations()
+}
+
+/**
+ * CopyPendingPassword 复制轮换中的新密码到剪贴板（10秒后自动清理）
+ * @param accountID 账号ID
+ * @return error 错误信息
+ * @author 陈凤庆
+ * @date 20251021
+ */
+func (a *App) CopyPendingPassword(accountID string) error {
+	rotation, err := a.GetPasswordRotation(accountID)
+	if err != nil {
+		return err
+	}
+	if rotation == nil {
+		return fmt.Errorf("账号没有进行中的密码轮换")
+	}
+	return a.copyToClipboardWithTimeout(rotation.PendingPassword, "新密码")
+}
+
+/**
+ * SimulatePendingPassword 模拟输入轮换中的新密码，用于在网站的修改密码页面填写
+ * @param accountID 账号ID
+ * @return error 错误信息
+ * @author 陈凤庆
+ * @date 20251021
+ */
+func (a *App) SimulatePendingPassword(accountID string) error {
+	if a.keyboardService == nil {
+		return fmt.Errorf("键盘服务未初始化")
+	}
+
+	rotation, err := a.GetPasswordRotation(accountID)
+	if err != nil {
+		return err
+	}
+	if rotation == nil {
+		return fmt.Errorf("账号没有进行中的密码轮换")
+	}
+	account, err := a.accountService.GetAccountByID(accountID)
+	if err != nil {
+		return fmt.Errorf("获取账号失败: %w", err)
+	}
+
+	if err := a.simulateTextByMethod(account.InputMethod, rotation.PendingPassword); err != nil {
+		logger.Error("[密码轮换] 输入新密码失败，账号ID: %s, 输入方式: %d, 错误: %v", accountID, account.InputMethod, err)
+		return err
+	}
+	return nil
+}
+
+/**
+ * CommitPasswordRotation 提交密码轮换，新密码写入账号，旧密码移入历史密码
+ * @param accountID 账号ID
+ * @return error 错误信息
+ * @author 陈凤庆
+ * @date 20251021
+ */
+func (a *App) CommitPasswordRotation(accountID string) error {
+	if a.passwordRotationService == nil {
+		return fmt.Errorf("密码轮换服务未初始化")
+	}
+	if err := a.passwordRotationService.CommitRotation(accountID); err != nil {
+		logger.Error("[密码轮换] 提交轮换失败，账号ID: %s, 错误: %v", accountID, err)
+		return err
+	}
+	logger.Info("[密码轮换] 提交轮换成功，账号ID: %s", accountID)
+	return nil
+}
+
+/**
+ * RollbackPasswordRotation 回滚密码轮换，丢弃新密码，账号继续使用旧密码
+ * @param accountID 账号ID
+ * @return error 错误信息
+ * @author 陈凤庆
+ * @date 20251021
+ */
+func (a *App) RollbackPasswordRotation(accountID string) error {
+	if a.passwordRotationService == nil {
+		return fmt.Errorf("密码轮换服务未初始化")
+	}
+	if err := a.passwordRotationService.RollbackRotation(accountID); err != nil {
+		logger.Error("[密码轮换] 回滚轮换失败，账号ID: %s, 错误: %v", accountID, err)
+		return err
+	}
+	logger.Info("[密码轮换] 回滚轮换成功，账号ID: %s", accountID)
+	return nil
+}
+
+/**
+ * GetPasswordHistory 获取账号的历史密码
+ * @param accountID 账号ID
+ * @return []models.PasswordHistoryEntry 历史密码，最近替换的在前
+ * @return error 错误信息
+ * @author 陈凤庆
+ * @date 20251021
+ */
+func (a *App) GetPasswordHistory(accountID string) ([]models.PasswordHistoryEntry, error) {
+	if a.passwordRotationService == nil {
+		return nil, fmt.Errorf("密码轮换服务未初始化")
+	}
+	return a.passwordRotationService.GetPasswordHistory(accountID)
+}
+
+/**
+ * GetRotationHook 获取账号的密码轮换钩子
+ * @param accountID 账号ID
+ * @return *models.RotationHook 轮换钩子，未设置时为nil
+ * @return error 错误信息
+ * @author 陈凤庆
+ * @date 20251021
+ */
+func (a *App) GetRotationHook(accountID string) (*models.RotationHook, error) {
+	if a.rotationHookService == nil {
+		return nil, fmt.Errorf("密码轮换钩子服务未初始化")
+	}
+	return a.rotationHookService.GetHook(accountID)
+}
+
+/**
+ * GetRotationHooks 获取所有密码轮换钩子
+ * @return []models.RotationHook 轮换钩子列表
+ * @return error 错误信息
+ * @author 陈凤庆
+ * @date 20251021
+ */
+func (a *App) GetRotationHooks() ([]models.RotationHook, error) {
+	if a.rotationHookService == nil {
+		return nil, fmt.Errorf("密码轮换钩子服务未初始化")
+	}
+	return a.rotationHookService.GetHooks()
+}
+
+/**
+ * SetRotationHook 设置账号的密码轮换钩子
+ * @param hook 轮换钩子：可执行文件路径、参数、超时时间（秒）和是否启用
+ * @return models.RotationHook 保存后的轮换钩子
  * @return error 错误信息
+ * @author 陈凤庆
+ * @date 20251021
  */
-func (a *App) UpdateGeneralPasswordRule(id, name, description string, config models.GeneralRuleConfig) (models.PasswordRule, error) {
-	if a.passwordRuleApp == nil {
-		return models.PasswordRule{}, fmt.Errorf("密码规则应用服务未初始化")
+func (a *App) SetRotationHook(hook models.RotationHook) (models.RotationHook, error) {
+	if a.rotationHookService == nil {
+		return models.RotationHook{}, fmt.Errorf("密码轮换钩子服务未初始化")
 	}
-	return a.passwordRuleApp.UpdateGeneralRule(a.ctx, id, name, description, config)
+
+	saved, err := a.rotationHookService.SetHook(hook)
+	if err != nil {
+		logger.Error("[轮换钩子] 设置钩子失败，账号ID: %s, 错误: %v", hook.AccountID, err)
+		return models.RotationHook{}, err
+	}
+	logger.Info("[轮换钩子] 设置钩子成功，账号ID: %s, 命令: %s", saved.AccountID, saved.Command)
+	return saved, nil
 }
 
 /**
- * UpdateCustomPasswordRule 更新自定义密码规则
- * @param id 规则ID
- * @param name 规则名称
- * @param description 规则描述
- * @param config 自定义规则配置
- * @return models.PasswordRule 更新后的密码规则
+ * DeleteRotationHook 删除账号的密码轮换钩子
+ * @param accountID 账号ID
  * @return error 错误信息
+ * @author 陈凤庆
+ * @date 20251021
  */
-func (a *App) UpdateCustomPasswordRule(id, name, description string, config models.CustomRuleConfig) (models.PasswordRule, error) {
-	if a.passwordRuleApp == nil {
-		return models.PasswordRule{}, fmt.Errorf("密码规则应用服务未初始化")
+func (a *App) DeleteRotationHook(accountID string) error {
+	if a.rotationHookService == nil {
+		return fmt.Errorf("密码轮换钩子服务未初始化")
 	}
-	return a.passwordRuleApp.UpdateCustomRule(a.ctx, id, name, description, config)
+	return a.rotationHookService.DeleteHook(accountID)
 }
 
 /**
- * DeletePasswordRule 删除密码规则
- * @param id 规则ID
+ * GetRotationHookRuns 获取轮换钩子的执行记录
+ * @param accountID 账号ID，为空时返回所有账号的记录
+ * @param limit 最多返回的记录数，0表示不限制
+ * @return []models.RotationHookRun 执行记录，最近的在前
  * @return error 错误信息
+ * @author 陈凤庆
+ * @date 20251021
  */
-func (a *App) DeletePasswordRule(id string) error {
-	if a.passwordRuleApp == nil {
-		return fmt.Errorf("密码规则应用服务未初始化")
+func (a *App) GetRotationHookRuns(accountID string, limit int) ([]models.RotationHookRun, error) {
+	if a.rotationHookService == nil {
+		return nil, fmt.Errorf("密码轮换钩子服务未初始化")
 	}
-	return a.passwordRuleApp.DeleteRule(a.ctx, id)
+	return a.rotationHookService.GetHookRuns(accountID, limit)
 }
 
 /**
- * GeneratePasswordByRule 根据规则生成密码
- * @param ruleID 规则ID
- * @return string 生成的密码
+ * ScheduleBulkRotation 安排批量轮换
+ * @param accountIDs 需要轮换的账号ID，为空时轮换所有启用钩子的账号
+ * @param runAt 计划执行时间，早于当前时间时立即执行
+ * @param intervalSeconds 账号之间的间隔（秒）
+ * @return models.BulkRotationJob 任务
  * @return error 错误信息
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 新密码使用账号所属类型绑定的规则生成，任务只在应用运行且密码库未锁定期间有效
  */
-func (a *App) GeneratePasswordByRule(ruleID string) (string, error) {
+func (a *App) ScheduleBulkRotation(accountIDs []string, runAt time.Time, intervalSeconds int) (models.BulkRotationJob, error) {
+	if a.bulkRotationScheduler == nil {
+		return models.BulkRotationJob{}, fmt.Errorf("批量轮换调度器未初始化")
+	}
 	if a.passwordRuleApp == nil {
-		return "", fmt.Errorf("密码规则应用服务未初始化")
+		return models.BulkRotationJob{}, fmt.Errorf("密码规则应用服务未初始化")
 	}
-	return a.passwordRuleApp.GeneratePassword(a.ctx, ruleID)
+
+	job, err := a.bulkRotationScheduler.Schedule(accountIDs, runAt, intervalSeconds, a.passwordRuleApp.passwordRuleService.GeneratePasswordForType)
+	if err != nil {
+		logger.Error("[批量轮换] 安排任务失败: %v", err)
+		return models.BulkRotationJob{}, err
+	}
+	logger.Info("[批量轮换] 已安排任务 %s，共%d个账号，计划时间: %s", job.ID, len(job.AccountIDs), job.RunAt.Format("2006-01-02 15:04:05"))
+	return job, nil
 }
 
 /**
- * GeneratePasswordByGeneralConfig 根据通用配置生成密码
- * @param config 通用规则配置
- * @return string 生成的密码
- * @return error 错误信息
+ * GetBulkRotationJob 获取最近一次批量轮换任务及其结果
+ * @return *models.BulkRotationJob 任务，没有任务时为nil
+ * @author 陈凤庆
+ * @date 20251021
  */
-func (a *App) GeneratePasswordByGeneralConfig(config models.GeneralRuleConfig) (string, error) {
-	if a.passwordRuleApp == nil {
-		return "", fmt.Errorf("密码规则应用服务未初始化")
+func (a *App) GetBulkRotationJob() *models.BulkRotationJob {
+	if a.bulkRotationScheduler == nil {
+		return nil
 	}
-	return a.passwordRuleApp.GeneratePasswordByGeneralConfig(a.ctx, config)
+	return a.bulkRotationScheduler.GetJob()
 }
 
 /**
- * GeneratePasswordByCustomConfig 根据自定义配置生成密码
- * @param config 自定义规则配置
- * @return string 生成的密码
+ * CancelBulkRotation 取消未结束的批量轮换任务
  * @return error 错误信息
+ * @author 陈凤庆
+ * @date 20251021
  */
-func (a *App) GeneratePasswordByCustomConfig(config models.CustomRuleConfig) (string, error) {
-	if a.passwordRuleApp == nil {
-		return "", fmt.Errorf("密码规则应用服务未初始化")
+func (a *App) CancelBulkRotation() error {
+	if a.bulkRotationScheduler == nil {
+		return fmt.Errorf("批量轮换调度器未初始化")
 	}
-	return a.passwordRuleApp.GeneratePasswordByCustomConfig(a.ctx, config)
+	return a.bulkRotationScheduler.Cancel()
 }
 
 /**
- * SetPasswordRuleAsDefault 设置密码规则为默认规则
- * @param ruleID 规则ID
- * @param isDefault 是否设为默认
- * @return error 错误信息
+ * cancelBulkRotation 锁定或关闭密码库时取消未结束的批量轮换任务
  */
-func (a *App) SetPasswordRuleAsDefault(ruleID string, isDefault bool) error {
-	if a.passwordRuleApp == nil {
-		return fmt.Errorf("密码规则应用服务未初始化")
+func (a *App) cancelBulkRotation() {
+	if a.bulkRotationScheduler == nil {
+		return
+	}
+	if err := a.bulkRotationScheduler.Cancel(); err == nil {
+		logger.Info("[批量轮换] 密码库已关闭，取消未结束的批量轮换任务")
 	}
-	return a.passwordRuleApp.SetRuleAsDefault(a.ctx, ruleID, isDefault)
 }
 
 /**
@@ -2396,3 +4377,404 @@ func (a *App) GetCurrentPassword() string {
 // 20251003 陈凤庆 键盘服务相关方法已移动到平台特定文件中
 // app_darwin.go - macOS平台实现
 // app_windows.go - Windows平台实现
+
+// SSH代理管理API
+
+/**
+ * StartSSHAgent 启动内置SSH代理
+ * @param socketPath 套接字路径，为空时使用默认路径
+ * @return string 实际使用的套接字路径（可设置为 SSH_AUTH_SOCK）
+ * @return error 错误信息
+ * @author 陈凤庆
+ * @date 20251020
+ */
+func (a *App) StartSSHAgent(socketPath string) (string, error) {
+	if a.sshAgentApp == nil {
+		return "", fmt.Errorf("SSH代理应用服务未初始化")
+	}
+	return a.sshAgentApp.Start(a.ctx, socketPath)
+}
+
+/**
+ * StopSSHAgent 停止内置SSH代理（同时移除全部私钥）
+ * @return error 错误信息
+ * @author 陈凤庆
+ * @date 20251020
+ */
+func (a *App) StopSSHAgent() error {
+	if a.sshAgentApp == nil {
+		return fmt.Errorf("SSH代理应用服务未初始化")
+	}
+	return a.sshAgentApp.Stop(a.ctx)
+}
+
+/**
+ * GetSSHAgentStatus 获取SSH代理状态
+ * @return map[string]interface{} 状态信息
+ * @author 陈凤庆
+ * @date 20251020
+ */
+func (a *App) GetSSHAgentStatus() map[string]interface{} {
+	if a.sshAgentApp == nil {
+		return map[string]interface{}{"running": false}
+	}
+	return a.sshAgentApp.GetStatus(a.ctx)
+}
+
+/**
+ * AddSSHKeyFromAccount 将账号备注中的私钥加入SSH代理
+ * @param accountID 账号ID
+ * @param confirmBeforeUse 每次使用前是否需要确认
+ * @param lifetimeSecs 有效期（秒），0表示直到锁定
+ * @return services.SSHAgentKey 添加的私钥信息
+ * @return error 错误信息
+ * @author 陈凤庆
+ * @date 20251020
+ */
+func (a *App) AddSSHKeyFromAccount(accountID string, confirmBeforeUse bool, lifetimeSecs int) (services.SSHAgentKey, error) {
+	if a.sshAgentApp == nil {
+		return services.SSHAgentKey{}, fmt.Errorf("SSH代理应用服务未初始化")
+	}
+	if lifetimeSecs < 0 {
+		return services.SSHAgentKey{}, fmt.Errorf("有效期不能为负数")
+	}
+	return a.sshAgentApp.AddKeyFromAccount(a.ctx, accountID, services.SSHKeyConstraints{
+		ConfirmBeforeUse: confirmBeforeUse,
+		LifetimeSecs:     uint32(lifetimeSecs),
+	})
+}
+
+/**
+ * RemoveSSHKey 按指纹从SSH代理移除私钥
+ * @param fingerprint SHA256指纹
+ * @return error 错误信息
+ * @author 陈凤庆
+ * @date 20251020
+ */
+func (a *App) RemoveSSHKey(fingerprint string) error {
+	if a.sshAgentApp == nil {
+		return fmt.Errorf("SSH代理应用服务未初始化")
+	}
+	return a.sshAgentApp.RemoveKey(a.ctx, fingerprint)
+}
+
+/**
+ * GetSSHAgentKeys 获取SSH代理中的私钥列表
+ * @return []services.SSHAgentKey 私钥信息列表
+ * @author 陈凤庆
+ * @date 20251020
+ */
+func (a *App) GetSSHAgentKeys() []services.SSHAgentKey {
+	if a.sshAgentApp == nil {
+		return []services.SSHAgentKey{}
+	}
+	return a.sshAgentApp.ListKeys(a.ctx)
+}
+
+/**
+ * primaryVaultInfo 获取主密码库的会话信息
+ */
+func (a *App) primaryVaultInfo() models.VaultSessionInfo {
+	path := a.vaultService.GetCurrentVaultPath()
+	return models.VaultSessionInfo{
+		ID:        services.PrimaryVaultID,
+		Name:      services.VaultNameFromPath(path),
+		Path:      path,
+		IsPrimary: true,
+		IsLocked:  !a.vaultService.IsOpened(),
+	}
+}
+
+/**
+ * OpenAdditionalVault 在主密码库之外再打开一个密码库
+ * @param vaultPath 密码库文件路径
+ * @param password 该密码库的登录密码
+ * @param autoLockMinutes 空闲自动锁定分钟数，0表示不自动锁定
+ * @return models.VaultSessionInfo 会话信息
+ * @return error 错误信息
+ * @author 陈凤庆
+ * @date 20251020
+ */
+func (a *App) OpenAdditionalVault(vaultPath string, password string, autoLockMinutes int) (models.VaultSessionInfo, error) {
+	logger.Info("[多密码库] 打开附加密码库: %s", vaultPath)
+
+	if a.vaultSessionService == nil {
+		return models.VaultSessionInfo{}, fmt.Errorf("多密码库会话服务未初始化")
+	}
+	if a.vaultService != nil && services.SameVaultPath(a.vaultService.GetCurrentVaultPath(), vaultPath) {
+		return models.VaultSessionInfo{}, fmt.Errorf("该密码库已作为主密码库打开")
+	}
+
+	info, err := a.vaultSessionService.OpenSession(vaultPath, password, autoLockMinutes)
+	if err != nil {
+		logger.Error("[多密码库] 打开附加密码库失败: %v", err)
+		return models.VaultSessionInfo{}, err
+	}
+	return info, nil
+}
+
+/**
+ * GetOpenVaults 获取全部已打开的密码库（主密码库在前）
+ * @return []models.VaultSessionInfo 会话信息列表
+ * @author 陈凤庆
+ * @date 20251020
+ */
+func (a *App) GetOpenVaults() []models.VaultSessionInfo {
+	vaults := make([]models.VaultSessionInfo, 0)
+	if a.vaultService != nil && a.vaultService.IsOpened() {
+		vaults = append(vaults, a.primaryVaultInfo())
+	}
+	if a.vaultSessionService != nil {
+		vaults = append(vaults, a.vaultSessionService.ListSessions()...)
+	}
+	return vaults
+}
+
+/**
+ * LockAdditionalVault 锁定附加密码库
+ * @param vaultID 会话ID
+ * @return error 错误信息
+ * @author 陈凤庆
+ * @date 20251020
+ */
+func (a *App) LockAdditionalVault(vaultID string) error {
+	if a.vaultSessionService == nil {
+		return fmt.Errorf("多密码库会话服务未初始化")
+	}
+	return a.vaultSessionService.LockSession(vaultID)
+}
+
+/**
+ * UnlockAdditionalVault 解锁附加密码库
+ * @param vaultID 会话ID
+ * @param password 该密码库的登录密码
+ * @return error 错误信息
+ * @author 陈凤庆
+ * @date 20251020
+ */
+func (a *App) UnlockAdditionalVault(vaultID string, password string) error {
+	if a.vaultSessionService == nil {
+		return fmt.Errorf("多密码库会话服务未初始化")
+	}
+	return a.vaultSessionService.UnlockSession(vaultID, password)
+}
+
+/**
+ * CloseAdditionalVault 关闭附加密码库
+ * @param vaultID 会话ID
+ * @return error 错误信息
+ * @author 陈凤庆
+ * @date 20251020
+ */
+func (a *App) CloseAdditionalVault(vaultID string) error {
+	if a.vaultSessionService == nil {
+		return fmt.Errorf("多密码库会话服务未初始化")
+	}
+	return a.vaultSessionService.CloseSession(vaultID)
+}
+
+/**
+ * SetAdditionalVaultAutoLock 设置附加密码库的空闲自动锁定时间
+ * @param vaultID 会话ID
+ * @param minutes 分钟数，0表示不自动锁定
+ * @return error 错误信息
+ * @author 陈凤庆
+ * @date 20251020
+ */
+func (a *App) SetAdditionalVaultAutoLock(vaultID string, minutes int) error {
+	if a.vaultSessionService == nil {
+		return fmt.Errorf("多密码库会话服务未初始化")
+	}
+	return a.vaultSessionService.SetAutoLock(vaultID, minutes)
+}
+
+/**
+ * GetAllVaultAccounts 获取全部已解锁密码库的账号列表，每个账号标记来源密码库
+ * @return []models.VaultAccount 带来源标记的账号列表
+ * @return error 错误信息
+ * @author 陈凤庆
+ * @date 20251020
+ */
+func (a *App) GetAllVaultAccounts() ([]models.VaultAccount, error) {
+	result := make([]models.VaultAccount, 0)
+
+	if a.vaultService != nil && a.vaultService.IsOpened() {
+		accounts, err := a.accountService.GetAccountsByConditions("{}")
+		if err != nil {
+			return nil, fmt.Errorf("获取主密码库账号失败: %w", err)
+		}
+		result = append(result, services.TagVaultAccounts(accounts, a.primaryVaultInfo())...)
+	}
+
+	if a.vaultSessionService != nil {
+		accounts, err := a.vaultSessionService.ListAllAccounts()
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, accounts...)
+	}
+
+	logger.LogAPICall("GetAllVaultAccounts", "", fmt.Sprintf("成功，共 %d 个账号", len(result)))
+	return result, nil
+}
+
+/**
+ * SearchAllVaults 在全部已解锁密码库中统一搜索账号，每个结果标记来源密码库
+ * @param keyword 搜索关键词
+ * @return []models.VaultAccount 带来源标记的搜索结果
+ * @return error 错误信息
+ * @author 陈凤庆
+ * @date 20251020
+ */
+func (a *App) SearchAllVaults(keyword string) ([]models.VaultAccount, error) {
+	result := make([]models.VaultAccount, 0)
+
+	if a.vaultService != nil && a.vaultService.IsOpened() {
+		accounts, err := a.accountService.SearchAccounts(keyword)
+		if err != nil {
+			return nil, fmt.Errorf("搜索主密码库失败: %w", err)
+		}
+		result = append(result, services.TagVaultAccounts(accounts, a.primaryVaultInfo())...)
+	}
+
+	if a.vaultSessionService != nil {
+		accounts, err := a.vaultSessionService.SearchAllAccounts(keyword)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, accounts...)
+	}
+
+	logger.LogAPICall("SearchAllVaults", fmt.Sprintf("keyword=%s", keyword), fmt.Sprintf("成功，共 %d 个结果", len(result)))
+	return result, nil
+}
+
+/**
+ * GetVaultAccountCredentials 获取指定密码库中账号的用户名和密码（用于复制和输入操作）
+ * @param vaultID 会话ID，主密码库为 primary
+ * @param accountID 账号ID
+ * @return username 用户名
+ * @return password 密码
+ * @return inputMethod 输入方式
+ * @return error 错误信息
+ * @author 陈凤庆
+ * @date 20251020
+ */
+func (a *App) GetVaultAccountCredentials(vaultID string, accountID string) (string, string, int, error) {
+	if vaultID == "" || vaultID == services.PrimaryVaultID {
+		return a.GetAccountCredentials(accountID)
+	}
+	if a.vaultSessionService == nil {
+		return "", "", 0, fmt.Errorf("多密码库会话服务未初始化")
+	}
+
+	session, release, err := a.vaultSessionService.GetSession(vaultID)
+	if err != nil {
+		return "", "", 0, err
+	}
+	defer release()
+	account, err := session.AccountService().GetAccountByID(accountID)
+	if err != nil {
+		return "", "", 0, fmt.Errorf("获取账号失败: %w", err)
+	}
+	account, err = services.NewFieldReferenceService(session.AccountService()).ResolveAccount(account)
+	if err != nil {
+		return "", "", 0, fmt.Errorf("解析字段引用失败: %w", err)
+	}
+	return account.Username, account.Password, account.InputMethod, nil
+}
+
+/**
+ * vaultEndpoint 获取已打开密码库的服务集合
+ * @param vaultID 会话ID，主密码库为 primary
+ * @return func() 释放函数，使用完成后必须调用（附加密码库在释放前不会被自动锁定关闭）
+ */
+func (a *App) vaultEndpoint(vaultID string) (services.VaultEndpoint, func(), error) {
+	if vaultID == "" || vaultID == services.PrimaryVaultID {
+		if a.vaultService == nil || !a.vaultService.IsOpened() {
+			return services.VaultEndpoint{}, nil, fmt.Errorf("主密码库未打开")
+		}
+		return services.VaultEndpoint{
+			Name:           a.primaryVaultInfo().Name,
+			AccountService: a.accountService,
+			GroupService:   a.groupService,
+			TypeService:    a.typeService,
+		}, func() {}, nil
+	}
+	if a.vaultSessionService == nil {
+		return services.VaultEndpoint{}, nil, fmt.Errorf("多密码库会话服务未初始化")
+	}
+	session, release, err := a.vaultSessionService.GetSession(vaultID)
+	if err != nil {
+		return services.VaultEndpoint{}, nil, err
+	}
+	return session.Endpoint(), release, nil
+}
+
+/**
+ * TransferAccountsToVault 将账号复制或移动到另一个密码库文件
+ * @param sourceVaultID 源密码库会话ID，主密码库为 primary
+ * @param accountIDs 账号ID列表
+ * @param targetVaultPath 目标密码库文件路径
+ * @param targetPassword 目标密码库的登录密码（目标库已打开时可为空）
+ * @param move 是否移动（写入目标库后删除源账号）
+ * @param conflictStrategy 冲突处理策略：skip、duplicate、overwrite
+ * @return services.VaultTransferResult 处理结果（含新建的分组类型和冲突列表）
+ * @return error 错误信息
+ * @author 陈凤庆
+ * @date 20251020
+ * @description 目标库未打开时用其自身的登录密码临时打开，处理完成后关闭
+ */
+func (a *App) TransferAccountsToVault(sourceVaultID string, accountIDs []string, targetVaultPath string, targetPassword string, move bool, conflictStrategy string) (services.VaultTransferResult, error) {
+	logger.Info("[跨库迁移] 源=%s, 目标=%s, 账号数=%d, 移动=%t", sourceVaultID, targetVaultPath, len(accountIDs), move)
+
+	if a.vaultSessionService == nil {
+		return services.VaultTransferResult{}, fmt.Errorf("多密码库会话服务未初始化")
+	}
+
+	source, releaseSource, err := a.vaultEndpoint(sourceVaultID)
+	if err != nil {
+		return services.VaultTransferResult{}, fmt.Errorf("源密码库不可用: %w", err)
+	}
+	defer releaseSource()
+
+	// 确定目标库：主密码库、已打开的附加密码库，或用目标库密码临时打开
+	targetVaultID := ""
+	if a.vaultService != nil && a.vaultService.IsOpened() && services.SameVaultPath(a.vaultService.GetCurrentVaultPath(), targetVaultPath) {
+		targetVaultID = services.PrimaryVaultID
+	} else if id, found := a.vaultSessionService.FindSessionByPath(targetVaultPath); found {
+		targetVaultID = id
+		if targetPassword != "" {
+			if err := a.vaultSessionService.UnlockSession(id, targetPassword); err != nil {
+				return services.VaultTransferResult{}, err
+			}
+		}
+	} else {
+		info, err := a.vaultSessionService.OpenSession(targetVaultPath, targetPassword, 0)
+		if err != nil {
+			return services.VaultTransferResult{}, fmt.Errorf("打开目标密码库失败: %w", err)
+		}
+		targetVaultID = info.ID
+		defer a.vaultSessionService.CloseSession(info.ID)
+	}
+
+	if targetVaultID == sourceVaultID || (sourceVaultID == "" && targetVaultID == services.PrimaryVaultID) {
+		return services.VaultTransferResult{}, fmt.Errorf("源密码库和目标密码库不能相同")
+	}
+
+	target, releaseTarget, err := a.vaultEndpoint(targetVaultID)
+	if err != nil {
+		return services.VaultTransferResult{}, fmt.Errorf("目标密码库不可用: %w", err)
+	}
+	defer releaseTarget()
+
+	result, err := services.NewVaultTransferService(source, target).Transfer(accountIDs, move, conflictStrategy)
+	if err != nil {
+		logger.Error("[跨库迁移] 处理失败: %v", err)
+		return result, err
+	}
+
+	logger.LogAPICall("TransferAccountsToVault", fmt.Sprintf("target=%s, count=%d", targetVaultPath, len(accountIDs)),
+		fmt.Sprintf("新建=%d, 覆盖=%d, 跳过=%d, 失败=%d", result.Transferred, result.Overwritten, result.Skipped, result.Failed))
+	return result, nil
+}
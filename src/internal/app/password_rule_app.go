@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"os"
+	"time"
 
 	"wepassword/internal/logger"
 	"wepassword/internal/models"
@@ -140,6 +142,50 @@ func (pra *PasswordRuleApp) CreateCustomRule(ctx context.Context, name, descript
 	return rule, nil
 }
 
+/**
+ * CreatePassphraseRule 创建口令短语规则
+ * @param ctx 上下文
+ * @param name 规则名称
+ * @param description 规则描述
+ * @param config 口令短语规则配置
+ * @return models.PasswordRule 创建的密码规则
+ * @return error 错误信息
+ */
+func (pra *PasswordRuleApp) CreatePassphraseRule(ctx context.Context, name, description string, config models.PassphraseRuleConfig) (models.PasswordRule, error) {
+	logger.Info("[密码规则应用] 创建口令短语规则: %s", name)
+
+	rule, err := pra.passwordRuleService.CreateRule(name, description, "passphrase", config)
+	if err != nil {
+		logger.Error("[密码规则应用] 创建口令短语规则失败: %v", err)
+		return models.PasswordRule{}, fmt.Errorf("创建口令短语规则失败: %w", err)
+	}
+
+	logger.Info("[密码规则应用] 成功创建口令短语规则: %s", rule.Name)
+	return rule, nil
+}
+
+/**
+ * CreatePronounceableRule 创建可发音密码规则
+ * @param ctx 上下文
+ * @param name 规则名称
+ * @param description 规则描述
+ * @param config 可发音密码规则配置
+ * @return models.PasswordRule 创建的密码规则
+ * @return error 错误信息
+ */
+func (pra *PasswordRuleApp) CreatePronounceableRule(ctx context.Context, name, description string, config models.PronounceableRuleConfig) (models.PasswordRule, error) {
+	logger.Info("[密码规则应用] 创建可发音密码规则: %s", name)
+
+	rule, err := pra.passwordRuleService.CreateRule(name, description, "pronounceable", config)
+	if err != nil {
+		logger.Error("[密码规则应用] 创建可发音密码规则失败: %v", err)
+		return models.PasswordRule{}, fmt.Errorf("创建可发音密码规则失败: %w", err)
+	}
+
+	logger.Info("[密码规则应用] 成功创建可发音密码规则: %s", rule.Name)
+	return rule, nil
+}
+
 /**
  * UpdateGeneralRule 更新通用密码规则
  * @param ctx 上下文
@@ -186,6 +232,52 @@ func (pra *PasswordRuleApp) UpdateCustomRule(ctx context.Context, id, name, desc
 	return rule, nil
 }
 
+/**
+ * UpdatePassphraseRule 更新口令短语规则
+ * @param ctx 上下文
+ * @param id 规则ID
+ * @param name 规则名称
+ * @param description 规则描述
+ * @param config 口令短语规则配置
+ * @return models.PasswordRule 更新后的密码规则
+ * @return error 错误信息
+ */
+func (pra *PasswordRuleApp) UpdatePassphraseRule(ctx context.Context, id, name, description string, config models.PassphraseRuleConfig) (models.PasswordRule, error) {
+	logger.Info("[密码规则应用] 更新口令短语规则: %s", id)
+
+	rule, err := pra.passwordRuleService.UpdateRule(id, name, description, config)
+	if err != nil {
+		logger.Error("[密码规则应用] 更新口令短语规则失败: %v", err)
+		return models.PasswordRule{}, fmt.Errorf("更新口令短语规则失败: %w", err)
+	}
+
+	logger.Info("[密码规则应用] 成功更新口令短语规则: %s", rule.Name)
+	return rule, nil
+}
+
+/**
+ * UpdatePronounceableRule 更新可发音密码规则
+ * @param ctx 上下文
+ * @param id 规则ID
+ * @param name 规则名称
+ * @param description 规则描述
+ * @param config 可发音密码规则配置
+ * @return models.PasswordRule 更新后的密码规则
+ * @return error 错误信息
+ */
+func (pra *PasswordRuleApp) UpdatePronounceableRule(ctx context.Context, id, name, description string, config models.PronounceableRuleConfig) (models.PasswordRule, error) {
+	logger.Info("[密码规则应用] 更新可发音密码规则: %s", id)
+
+	rule, err := pra.passwordRuleService.UpdateRule(id, name, description, config)
+	if err != nil {
+		logger.Error("[密码规则应用] 更新可发音密码规则失败: %v", err)
+		return models.PasswordRule{}, fmt.Errorf("更新可发音密码规则失败: %w", err)
+	}
+
+	logger.Info("[密码规则应用] 成功更新可发音密码规则: %s", rule.Name)
+	return rule, nil
+}
+
 /**
  * DeleteRule 删除密码规则
  * @param ctx 上下文
@@ -273,6 +365,415 @@ func (pra *PasswordRuleApp) GeneratePasswordByCustomConfig(ctx context.Context,
 	return password, nil
 }
 
+/**
+ * GeneratePasswordByPassphraseConfig 根据口令短语配置生成密码
+ * @param ctx 上下文
+ * @param config 口令短语规则配置
+ * @return string 生成的口令短语
+ * @return error 错误信息
+ */
+func (pra *PasswordRuleApp) GeneratePasswordByPassphraseConfig(ctx context.Context, config models.PassphraseRuleConfig) (string, error) {
+	logger.Info("[密码规则应用] 根据口令短语配置生成密码")
+
+	password, err := pra.passwordRuleService.GeneratePasswordByConfig("passphrase", config)
+	if err != nil {
+		logger.Error("[密码规则应用] 根据口令短语配置生成密码失败: %v", err)
+		return "", fmt.Errorf("根据口令短语配置生成密码失败: %w", err)
+	}
+
+	logger.Info("[密码规则应用] 成功根据口令短语配置生成密码，长度: %d", len(password))
+	return password, nil
+}
+
+/**
+ * GeneratePasswordByPronounceableConfig 根据可发音密码配置生成密码
+ * @param ctx 上下文
+ * @param config 可发音密码规则配置
+ * @return string 生成的可发音密码
+ * @return error 错误信息
+ */
+func (pra *PasswordRuleApp) GeneratePasswordByPronounceableConfig(ctx context.Context, config models.PronounceableRuleConfig) (string, error) {
+	logger.Info("[密码规则应用] 根据可发音密码配置生成密码")
+
+	password, err := pra.passwordRuleService.GeneratePasswordByConfig("pronounceable", config)
+	if err != nil {
+		logger.Error("[密码规则应用] 根据可发音密码配置生成密码失败: %v", err)
+		return "", fmt.Errorf("根据可发音密码配置生成密码失败: %w", err)
+	}
+
+	logger.Info("[密码规则应用] 成功根据可发音密码配置生成密码，长度: %d", len(password))
+	return password, nil
+}
+
+/**
+ * CalculateRuleEntropy 计算已保存规则的熵值
+ * @param ctx 上下文
+ * @param ruleID 规则ID
+ * @return models.PasswordRuleEntropy 熵值信息
+ * @return error 错误信息
+ */
+func (pra *PasswordRuleApp) CalculateRuleEntropy(ctx context.Context, ruleID string) (models.PasswordRuleEntropy, error) {
+	if ruleID == "" {
+		return models.PasswordRuleEntropy{}, fmt.Errorf("规则ID不能为空")
+	}
+
+	entropy, err := pra.passwordRuleService.CalculateRuleEntropy(ruleID)
+	if err != nil {
+		logger.Error("[密码规则应用] 计算规则熵值失败: %v", err)
+		return models.PasswordRuleEntropy{}, fmt.Errorf("计算规则熵值失败: %w", err)
+	}
+
+	logger.Info("[密码规则应用] 规则熵值: %.2f~%.2f位, 等级: %s", entropy.EntropyLowerBits, entropy.EntropyUpperBits, entropy.Level)
+	return entropy, nil
+}
+
+/**
+ * CalculateConfigEntropy 根据配置计算规则熵值
+ * @param ctx 上下文
+ * @param ruleType 规则类型
+ * @param config 规则配置
+ * @return models.PasswordRuleEntropy 熵值信息
+ * @return error 错误信息
+ */
+func (pra *PasswordRuleApp) CalculateConfigEntropy(ctx context.Context, ruleType string, config interface{}) (models.PasswordRuleEntropy, error) {
+	entropy, err := pra.passwordRuleService.CalculateEntropyByConfig(ruleType, config)
+	if err != nil {
+		logger.Error("[密码规则应用] 计算规则熵值失败: %v", err)
+		return models.PasswordRuleEntropy{}, fmt.Errorf("计算规则熵值失败: %w", err)
+	}
+	return entropy, nil
+}
+
+/**
+ * SetPolicyConfig 设置规则强度策略
+ * @param config 规则强度策略配置
+ */
+func (pra *PasswordRuleApp) SetPolicyConfig(config models.PasswordPolicyConfig) {
+	pra.passwordRuleService.SetPolicyConfig(config)
+}
+
+/**
+ * SetGroupRule 设置分组的默认密码规则
+ * @param ctx 上下文
+ * @param groupID 分组ID
+ * @param ruleID 规则ID，空字符串表示解除绑定
+ * @return error 错误信息
+ */
+func (pra *PasswordRuleApp) SetGroupRule(ctx context.Context, groupID, ruleID string) error {
+	logger.Info("[密码规则应用] 设置分组默认密码规则: %s -> %s", groupID, ruleID)
+
+	if err := pra.passwordRuleService.SetGroupRule(groupID, ruleID); err != nil {
+		logger.Error("[密码规则应用] 设置分组默认密码规则失败: %v", err)
+		return fmt.Errorf("设置分组默认密码规则失败: %w", err)
+	}
+	return nil
+}
+
+/**
+ * SetTypeRule 设置类型的默认密码规则
+ * @param ctx 上下文
+ * @param typeID 类型ID
+ * @param ruleID 规则ID，空字符串表示解除绑定
+ * @return error 错误信息
+ */
+func (pra *PasswordRuleApp) SetTypeRule(ctx context.Context, typeID, ruleID string) error {
+	logger.Info("[密码规则应用] 设置类型默认密码规则: %s -> %s", typeID, ruleID)
+
+	if err := pra.passwordRuleService.SetTypeRule(typeID, ruleID); err != nil {
+		logger.Error("[密码规则应用] 设置类型默认密码规则失败: %v", err)
+		return fmt.Errorf("设置类型默认密码规则失败: %w", err)
+	}
+	return nil
+}
+
+/**
+ * ResolveRuleIDForType 查找类型最近绑定的密码规则
+ * @param ctx 上下文
+ * @param typeID 类型ID
+ * @return string 规则ID，未绑定时为空字符串
+ * @return error 错误信息
+ */
+func (pra *PasswordRuleApp) ResolveRuleIDForType(ctx context.Context, typeID string) (string, error) {
+	ruleID, err := pra.passwordRuleService.ResolveRuleIDForType(typeID)
+	if err != nil {
+		logger.Error("[密码规则应用] 查找类型绑定的密码规则失败: %v", err)
+		return "", fmt.Errorf("查找类型绑定的密码规则失败: %w", err)
+	}
+	return ruleID, nil
+}
+
+/**
+ * GeneratePasswordForType 使用类型最近绑定的规则生成密码
+ * @param ctx 上下文
+ * @param typeID 类型ID
+ * @return string 生成的密码，未绑定规则时为空字符串
+ * @return error 错误信息
+ */
+func (pra *PasswordRuleApp) GeneratePasswordForType(ctx context.Context, typeID string) (string, error) {
+	password, ruleID, err := pra.passwordRuleService.GeneratePasswordForType(typeID)
+	if err != nil {
+		logger.Error("[密码规则应用] 使用绑定规则生成密码失败: %v", err)
+		return "", fmt.Errorf("使用绑定规则生成密码失败: %w", err)
+	}
+	if ruleID != "" {
+		logger.Info("[密码规则应用] 使用类型 %s 绑定的规则 %s 生成密码，长度: %d", typeID, ruleID, len(password))
+	}
+	return password, nil
+}
+
+/**
+ * GenerateSitePassword 根据主密码、站点、登录名和计数器确定性生成站点密码
+ * @param ctx 上下文
+ * @param masterPassword 主密码
+ * @param profile 站点密码参数
+ * @return string 生成的密码
+ * @return error 错误信息
+ */
+func (pra *PasswordRuleApp) GenerateSitePassword(ctx context.Context, masterPassword string, profile models.SitePasswordProfile) (string, error) {
+	password, err := pra.passwordRuleService.GenerateSitePassword(masterPassword, profile)
+	if err != nil {
+		logger.Error("[密码规则应用] 生成站点密码失败: %v", err)
+		return "", fmt.Errorf("生成站点密码失败: %w", err)
+	}
+	logger.Info("[密码规则应用] 生成站点密码成功，站点: %s，计数器: %d", profile.Site, profile.Counter)
+	return password, nil
+}
+
+/**
+ * RotateSitePassword 计数器加1后重新生成站点密码
+ * @param ctx 上下文
+ * @param masterPassword 主密码
+ * @param profile 当前站点密码参数
+ * @return *models.SitePasswordResult 新密码及计数器加1后的参数
+ * @return error 错误信息
+ */
+func (pra *PasswordRuleApp) RotateSitePassword(ctx context.Context, masterPassword string, profile models.SitePasswordProfile) (*models.SitePasswordResult, error) {
+	password, rotated, err := pra.passwordRuleService.RotateSitePassword(masterPassword, profile)
+	if err != nil {
+		logger.Error("[密码规则应用] 轮换站点密码失败: %v", err)
+		return nil, fmt.Errorf("轮换站点密码失败: %w", err)
+	}
+	logger.Info("[密码规则应用] 轮换站点密码成功，站点: %s，新计数器: %d", rotated.Site, rotated.Counter)
+	return &models.SitePasswordResult{Password: password, Profile: rotated}, nil
+}
+
+/**
+ * GenerateBatch 根据规则批量生成互不重复的密码
+ * @param ctx 上下文
+ * @param ruleID 规则ID
+ * @param count 生成数量
+ * @param exclude 需要排除的已有密码，可为nil
+ * @return []string 生成的密码列表
+ * @return error 错误信息
+ */
+func (pra *PasswordRuleApp) GenerateBatch(ctx context.Context, ruleID string, count int, exclude map[string]bool) ([]string, error) {
+	logger.Info("[密码规则应用] 批量生成密码，规则ID: %s，数量: %d，排除已有密码: %d", ruleID, count, len(exclude))
+
+	passwords, err := pra.passwordRuleService.GenerateBatch(ruleID, count, exclude)
+	if err != nil {
+		logger.Error("[密码规则应用] 批量生成密码失败: %v", err)
+		return nil, fmt.Errorf("批量生成密码失败: %w", err)
+	}
+
+	logger.Info("[密码规则应用] 成功批量生成 %d 个密码", len(passwords))
+	return passwords, nil
+}
+
+/**
+ * GenerateBatchEntries 批量生成密码并按模板组装标题和用户名
+ * @param ctx 上下文
+ * @param request 批量生成请求
+ * @param exclude 需要排除的已有密码，可为nil
+ * @return []models.BatchPasswordEntry 记录列表
+ * @return error 错误信息
+ */
+func (pra *PasswordRuleApp) GenerateBatchEntries(ctx context.Context, request models.BatchPasswordRequest, exclude map[string]bool) ([]models.BatchPasswordEntry, error) {
+	passwords, err := pra.GenerateBatch(ctx, request.RuleID, request.Count, exclude)
+	if err != nil {
+		return nil, err
+	}
+	return services.BuildBatchEntries(request, passwords)
+}
+
+/**
+ * ExportBatchCSV 将批量生成的记录导出为CSV文件
+ * @param ctx 上下文
+ * @param entries 记录列表
+ * @param exportPath 导出路径
+ * @return error 错误信息
+ * @description 文件包含明文密码，只允许当前用户读写
+ */
+func (pra *PasswordRuleApp) ExportBatchCSV(ctx context.Context, entries []models.BatchPasswordEntry, exportPath string) error {
+	if exportPath == "" {
+		return fmt.Errorf("导出路径不能为空")
+	}
+
+	file, err := os.OpenFile(exportPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("创建导出文件失败: %w", err)
+	}
+
+	if err := services.WriteBatchCSV(file, entries); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("保存导出文件失败: %w", err)
+	}
+
+	logger.Info("[密码规则应用] 成功导出 %d 条批量生成的密码: %s", len(entries), exportPath)
+	return nil
+}
+
+/**
+ * ExportRules 导出密码规则到JSON文件
+ * @param ctx 上下文
+ * @param ruleIDs 要导出的规则ID，为空时导出全部规则
+ * @param revision 规则集修订号
+ * @param exportPath 导出路径
+ * @return error 错误信息
+ */
+func (pra *PasswordRuleApp) ExportRules(ctx context.Context, ruleIDs []string, revision int, exportPath string) error {
+	if exportPath == "" {
+		return fmt.Errorf("导出路径不能为空")
+	}
+
+	data, err := pra.passwordRuleService.ExportRules(ruleIDs, revision)
+	if err != nil {
+		logger.Error("[密码规则应用] 导出密码规则失败: %v", err)
+		return fmt.Errorf("导出密码规则失败: %w", err)
+	}
+
+	if err := os.WriteFile(exportPath, data, 0644); err != nil {
+		return fmt.Errorf("写入规则文件失败: %w", err)
+	}
+
+	logger.Info("[密码规则应用] 成功导出密码规则: %s", exportPath)
+	return nil
+}
+
+/**
+ * ImportRules 从JSON文件导入密码规则
+ * @param ctx 上下文
+ * @param importPath 规则文件路径
+ * @param conflict 名称冲突处理方式：skip(默认)、overwrite、rename
+ * @return models.PasswordRuleImportResult 导入结果
+ * @return error 错误信息
+ */
+func (pra *PasswordRuleApp) ImportRules(ctx context.Context, importPath, conflict string) (models.PasswordRuleImportResult, error) {
+	data, err := os.ReadFile(importPath)
+	if err != nil {
+		return models.PasswordRuleImportResult{}, fmt.Errorf("读取规则文件失败: %w", err)
+	}
+
+	result, err := pra.passwordRuleService.ImportRules(data, conflict)
+	if err != nil {
+		logger.Error("[密码规则应用] 导入密码规则失败: %v", err)
+		return models.PasswordRuleImportResult{}, fmt.Errorf("导入密码规则失败: %w", err)
+	}
+	return result, nil
+}
+
+/**
+ * GetRuleSubscription 获取密码库订阅的规则文件
+ * @param ctx 上下文
+ * @return *models.PasswordRuleSubscription 订阅信息，未订阅时为nil
+ * @return error 错误信息
+ */
+func (pra *PasswordRuleApp) GetRuleSubscription(ctx context.Context) (*models.PasswordRuleSubscription, error) {
+	return pra.passwordRuleService.GetRuleSubscription()
+}
+
+/**
+ * SubscribeRuleFile 订阅规则文件并立即同步
+ * @param ctx 上下文
+ * @param path 规则文件路径
+ * @return models.PasswordRuleImportResult 同步结果
+ * @return error 错误信息
+ */
+func (pra *PasswordRuleApp) SubscribeRuleFile(ctx context.Context, path string) (models.PasswordRuleImportResult, error) {
+	logger.Info("[密码规则应用] 订阅规则文件: %s", path)
+
+	result, err := pra.passwordRuleService.SubscribeRuleFile(path)
+	if err != nil {
+		logger.Error("[密码规则应用] 订阅规则文件失败: %v", err)
+		return models.PasswordRuleImportResult{}, fmt.Errorf("订阅规则文件失败: %w", err)
+	}
+	return result, nil
+}
+
+/**
+ * UnsubscribeRuleFile 取消订阅规则文件，已同步的规则保留为本地规则
+ * @param ctx 上下文
+ * @return error 错误信息
+ */
+func (pra *PasswordRuleApp) UnsubscribeRuleFile(ctx context.Context) error {
+	return pra.passwordRuleService.UnsubscribeRuleFile()
+}
+
+/**
+ * SyncRuleSubscription 按订阅的规则文件同步规则
+ * @param ctx 上下文
+ * @return models.PasswordRuleImportResult 同步结果
+ * @return error 错误信息
+ */
+func (pra *PasswordRuleApp) SyncRuleSubscription(ctx context.Context) (models.PasswordRuleImportResult, error) {
+	result, err := pra.passwordRuleService.SyncRuleSubscription()
+	if err != nil {
+		logger.Error("[密码规则应用] 同步规则文件失败: %v", err)
+		return models.PasswordRuleImportResult{}, fmt.Errorf("同步规则文件失败: %w", err)
+	}
+	return result, nil
+}
+
+/**
+ * GetCompliancePresets 获取内置合规预设列表
+ * @param ctx 上下文
+ * @return []models.CompliancePreset 合规预设列表
+ */
+func (pra *PasswordRuleApp) GetCompliancePresets(ctx context.Context) []models.CompliancePreset {
+	return services.GetCompliancePresets()
+}
+
+/**
+ * InstallCompliancePreset 将合规预设的生成规则安装为密码规则
+ * @param ctx 上下文
+ * @param presetID 预设标识
+ * @return models.PasswordRule 安装的规则
+ * @return error 错误信息
+ */
+func (pra *PasswordRuleApp) InstallCompliancePreset(ctx context.Context, presetID string) (models.PasswordRule, error) {
+	logger.Info("[密码规则应用] 安装合规预设: %s", presetID)
+
+	rule, err := pra.passwordRuleService.InstallCompliancePreset(presetID)
+	if err != nil {
+		logger.Error("[密码规则应用] 安装合规预设失败: %v", err)
+		return models.PasswordRule{}, fmt.Errorf("安装合规预设失败: %w", err)
+	}
+	return rule, nil
+}
+
+/**
+ * CheckCompliance 按合规预设检查账号密码
+ * @param ctx 上下文
+ * @param presetID 预设标识
+ * @param accounts 解密后的账号列表
+ * @param bannedWords 额外禁止包含的词
+ * @param changedAt 账号ID到最近一次修改密码时间的映射
+ * @return models.ComplianceReport 检查报告
+ * @return error 错误信息
+ */
+func (pra *PasswordRuleApp) CheckCompliance(ctx context.Context, presetID string, accounts []models.AccountDecrypted, bannedWords []string, changedAt map[string]time.Time) (models.ComplianceReport, error) {
+	report, err := services.CheckCompliance(presetID, accounts, bannedWords, changedAt, time.Now())
+	if err != nil {
+		logger.Error("[密码规则应用] 合规检查失败: %v", err)
+		return models.ComplianceReport{}, fmt.Errorf("合规检查失败: %w", err)
+	}
+
+	logger.Info("[密码规则应用] 合规检查完成，预设: %s，检查%d个账号，%d个不合规", presetID, report.CheckedCount, len(report.Accounts))
+	return report, nil
+}
+
 /**
  * ForceInitializeDefaultRules 强制初始化默认密码规则
  * @param ctx 上下文
@@ -334,6 +835,20 @@ func (pra *PasswordRuleApp) ParseRuleConfig(rule models.PasswordRule) (interface
 			return nil, fmt.Errorf("解析自定义规则配置失败: %w", err)
 		}
 		return config, nil
+	case "passphrase":
+		var config models.PassphraseRuleConfig
+		err := json.Unmarshal([]byte(rule.Config), &config)
+		if err != nil {
+			return nil, fmt.Errorf("解析口令短语规则配置失败: %w", err)
+		}
+		return config, nil
+	case "pronounceable":
+		var config models.PronounceableRuleConfig
+		err := json.Unmarshal([]byte(rule.Config), &config)
+		if err != nil {
+			return nil, fmt.Errorf("解析可发音密码规则配置失败: %w", err)
+		}
+		return config, nil
 	default:
 		return nil, fmt.Errorf("不支持的规则类型: %s", rule.RuleType)
 	}
@@ -0,0 +1,185 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"wepassword/internal/logger"
+	"wepassword/internal/services"
+	"wepassword/internal/utils"
+)
+
+/**
+ * SSH代理应用服务
+ * @author 陈凤庆
+ * @date 20251020
+ * @description 为前端提供内置SSH代理的API接口，私钥来自账号备注中的PEM私钥，私钥口令取账号密码
+ */
+
+/**
+ * SSHAgentApp SSH代理应用服务
+ */
+type SSHAgentApp struct {
+	sshAgentService       *services.SSHAgentService
+	accountService        *services.AccountService
+	fieldReferenceService *services.FieldReferenceService
+}
+
+/**
+ * NewSSHAgentApp 创建SSH代理应用服务实例
+ * @param sshAgentService SSH代理服务
+ * @param accountService 账号服务
+ * @param fieldReferenceService 字段引用服务
+ * @return *SSHAgentApp SSH代理应用服务实例
+ */
+func NewSSHAgentApp(sshAgentService *services.SSHAgentService, accountService *services.AccountService, fieldReferenceService *services.FieldReferenceService) *SSHAgentApp {
+	return &SSHAgentApp{
+		sshAgentService:       sshAgentService,
+		accountService:        accountService,
+		fieldReferenceService: fieldReferenceService,
+	}
+}
+
+/**
+ * DefaultSocketPath 获取默认的SSH代理套接字路径
+ * @return string 套接字路径
+ * @return error 错误信息
+ */
+func (saa *SSHAgentApp) DefaultSocketPath() (string, error) {
+	appDataDir, err := utils.GetAppDataDir()
+	if err != nil {
+		return "", fmt.Errorf("获取应用数据目录失败: %w", err)
+	}
+	return filepath.Join(appDataDir, "ssh-agent.sock"), nil
+}
+
+/**
+ * Start 启动SSH代理
+ * @param ctx 上下文
+ * @param socketPath 套接字路径，为空时使用默认路径
+ * @return string 实际使用的套接字路径（可设置为 SSH_AUTH_SOCK）
+ * @return error 错误信息
+ */
+func (saa *SSHAgentApp) Start(ctx context.Context, socketPath string) (string, error) {
+	logger.Info("[SSH代理应用] 启动SSH代理")
+
+	if socketPath == "" {
+		defaultPath, err := saa.DefaultSocketPath()
+		if err != nil {
+			return "", err
+		}
+		socketPath = defaultPath
+	}
+
+	if err := saa.sshAgentService.Start(socketPath); err != nil {
+		logger.Error("[SSH代理应用] 启动SSH代理失败: %v", err)
+		return "", fmt.Errorf("启动SSH代理失败: %w", err)
+	}
+
+	return socketPath, nil
+}
+
+/**
+ * Stop 停止SSH代理
+ * @param ctx 上下文
+ * @return error 错误信息
+ */
+func (saa *SSHAgentApp) Stop(ctx context.Context) error {
+	logger.Info("[SSH代理应用] 停止SSH代理")
+
+	if err := saa.sshAgentService.Stop(); err != nil {
+		logger.Error("[SSH代理应用] 停止SSH代理失败: %v", err)
+		return fmt.Errorf("停止SSH代理失败: %w", err)
+	}
+	return nil
+}
+
+/**
+ * GetStatus 获取SSH代理状态
+ * @param ctx 上下文
+ * @return map[string]interface{} 状态信息
+ */
+func (saa *SSHAgentApp) GetStatus(ctx context.Context) map[string]interface{} {
+	return map[string]interface{}{
+		"running":     saa.sshAgentService.IsRunning(),
+		"socket_path": saa.sshAgentService.GetSocketPath(),
+		"key_count":   len(saa.sshAgentService.ListKeys()),
+	}
+}
+
+/**
+ * AddKeyFromAccount 将账号中保存的私钥加入SSH代理
+ * @param ctx 上下文
+ * @param accountID 账号ID
+ * @param constraints 私钥约束（确认、有效期）
+ * @return services.SSHAgentKey 添加的私钥信息
+ * @return error 错误信息
+ */
+func (saa *SSHAgentApp) AddKeyFromAccount(ctx context.Context, accountID string, constraints services.SSHKeyConstraints) (services.SSHAgentKey, error) {
+	logger.Info("[SSH代理应用] 从账号添加私钥: %s", accountID)
+
+	if accountID == "" {
+		return services.SSHAgentKey{}, fmt.Errorf("账号ID不能为空")
+	}
+
+	account, err := saa.accountService.GetAccountByID(accountID)
+	if err != nil {
+		logger.Error("[SSH代理应用] 获取账号失败: %v", err)
+		return services.SSHAgentKey{}, fmt.Errorf("获取账号失败: %w", err)
+	}
+
+	account, err = saa.fieldReferenceService.ResolveAccount(account)
+	if err != nil {
+		return services.SSHAgentKey{}, fmt.Errorf("解析字段引用失败: %w", err)
+	}
+
+	privateKeyPEM := services.ExtractPrivateKeyPEM(account.Notes)
+	if privateKeyPEM == "" {
+		return services.SSHAgentKey{}, fmt.Errorf("账号备注中未找到私钥")
+	}
+
+	key, err := saa.sshAgentService.AddVaultKey(account.ID, account.Title, privateKeyPEM, account.Password, constraints)
+	if err != nil {
+		logger.Error("[SSH代理应用] 添加私钥失败: %v", err)
+		return services.SSHAgentKey{}, fmt.Errorf("添加私钥失败: %w", err)
+	}
+
+	return key, nil
+}
+
+/**
+ * RemoveKey 按指纹移除私钥
+ * @param ctx 上下文
+ * @param fingerprint SHA256指纹
+ * @return error 错误信息
+ */
+func (saa *SSHAgentApp) RemoveKey(ctx context.Context, fingerprint string) error {
+	logger.Info("[SSH代理应用] 移除私钥: %s", fingerprint)
+
+	if err := saa.sshAgentService.RemoveByFingerprint(fingerprint); err != nil {
+		return fmt.Errorf("移除私钥失败: %w", err)
+	}
+	return nil
+}
+
+/**
+ * RemoveAllKeys 移除全部私钥
+ * @param ctx 上下文
+ * @return error 错误信息
+ */
+func (saa *SSHAgentApp) RemoveAllKeys(ctx context.Context) error {
+	if err := saa.sshAgentService.RemoveAll(); err != nil {
+		return fmt.Errorf("移除全部私钥失败: %w", err)
+	}
+	return nil
+}
+
+/**
+ * ListKeys 获取SSH代理中的私钥列表
+ * @param ctx 上下文
+ * @return []services.SSHAgentKey 私钥信息列表
+ */
+func (saa *SSHAgentApp) ListKeys(ctx context.Context) []services.SSHAgentKey {
+	return saa.sshAgentService.ListKeys()
+}
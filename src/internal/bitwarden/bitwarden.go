@@ -0,0 +1,199 @@
+package bitwarden
+
+import (
+	"errors"
+	"time"
+)
+
+/**
+ * Bitwarden JSON 导出格式
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 读写Bitwarden密码库导出的JSON文件，支持未加密格式和"受密码保护"的加密格式：
+ *              加密格式使用导出密码经PBKDF2-SHA256或Argon2id派生密钥，再用HKDF扩展为加密密钥和MAC密钥，
+ *              数据以AES-256-CBC + HMAC-SHA256加密（Bitwarden EncString类型2）。
+ *              使用账号密钥加密的导出文件（"账号限制"格式）无法在Bitwarden之外解密，不支持导入
+ */
+
+// 错误定义
+var (
+	ErrInvalidFormat      = errors.New("不是Bitwarden导出的JSON文件")
+	ErrAccountRestricted  = errors.New("该文件使用Bitwarden账号密钥加密，无法导入，请在Bitwarden中选择\"受密码保护\"或未加密的JSON格式重新导出")
+	ErrPasswordRequired   = errors.New("该文件受密码保护，请输入导出时设置的密码")
+	ErrInvalidCredentials = errors.New("密码不正确")
+	ErrCorrupted          = errors.New("导出文件已损坏")
+)
+
+// 条目类型
+const (
+	ItemTypeLogin      = 1
+	ItemTypeSecureNote = 2
+	ItemTypeCard       = 3
+	ItemTypeIdentity   = 4
+	ItemTypeSSHKey     = 5
+)
+
+// 自定义字段类型
+const (
+	FieldTypeText    = 0
+	FieldTypeHidden  = 1
+	FieldTypeBoolean = 2
+	FieldTypeLinked  = 3
+)
+
+// 密钥派生算法
+const (
+	KdfPBKDF2   = 0
+	KdfArgon2id = 1
+)
+
+/**
+ * Export 未加密的导出内容
+ */
+type Export struct {
+	Encrypted   bool         `json:"encrypted"`
+	Folders     []Folder     `json:"folders"`
+	Collections []Collection `json:"collections,omitempty"` // 组织导出时的集合
+	Items       []Item       `json:"items"`
+}
+
+/**
+ * Folder 文件夹，名称中的"/"表示嵌套
+ */
+type Folder struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+/**
+ * Collection 组织集合
+ */
+type Collection struct {
+	ID             string `json:"id"`
+	OrganizationID string `json:"organizationId"`
+	Name           string `json:"name"`
+}
+
+/**
+ * Item 密码库条目，按Type只有login、secureNote、card、identity、sshKey中的一个有值
+ */
+type Item struct {
+	ID              string            `json:"id"`
+	OrganizationID  *string           `json:"organizationId"`
+	FolderID        *string           `json:"folderId"`
+	Type            int               `json:"type"`
+	Reprompt        int               `json:"reprompt"`
+	Name            string            `json:"name"`
+	Notes           *string           `json:"notes"`
+	Favorite        bool              `json:"favorite"`
+	Fields          []Field           `json:"fields,omitempty"`
+	Login           *Login            `json:"login,omitempty"`
+	SecureNote      *SecureNote       `json:"secureNote,omitempty"`
+	Card            *Card             `json:"card,omitempty"`
+	Identity        *Identity         `json:"identity,omitempty"`
+	SSHKey          *SSHKey           `json:"sshKey,omitempty"`
+	PasswordHistory []PasswordHistory `json:"passwordHistory"`
+	CollectionIDs   []string          `json:"collectionIds"`
+	RevisionDate    time.Time         `json:"revisionDate"`
+	CreationDate    time.Time         `json:"creationDate"`
+	DeletedDate     *time.Time        `json:"deletedDate"`
+}
+
+/**
+ * GetNotes 条目备注，为空时返回空字符串
+ */
+func (i Item) GetNotes() string {
+	if i.Notes == nil {
+		return ""
+	}
+	return *i.Notes
+}
+
+/**
+ * Field 自定义字段，关联字段（FieldTypeLinked）只有LinkedID没有值
+ */
+type Field struct {
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Type     int    `json:"type"`
+	LinkedID *int   `json:"linkedId"`
+}
+
+/**
+ * Login 登录信息
+ */
+type Login struct {
+	URIs                 []URI      `json:"uris,omitempty"`
+	Username             string     `json:"username"`
+	Password             string     `json:"password"`
+	TOTP                 string     `json:"totp"` // otpauth://链接、steam://密钥或Base32密钥
+	PasswordRevisionDate *time.Time `json:"passwordRevisionDate"`
+}
+
+/**
+ * URI 登录网址
+ */
+type URI struct {
+	Match *int   `json:"match"`
+	URI   string `json:"uri"`
+}
+
+/**
+ * SecureNote 安全笔记，内容保存在条目备注中
+ */
+type SecureNote struct {
+	Type int `json:"type"`
+}
+
+/**
+ * Card 支付卡
+ */
+type Card struct {
+	CardholderName string `json:"cardholderName"`
+	Brand          string `json:"brand"`
+	Number         string `json:"number"`
+	ExpMonth       string `json:"expMonth"`
+	ExpYear        string `json:"expYear"`
+	Code           string `json:"code"`
+}
+
+/**
+ * Identity 身份信息
+ */
+type Identity struct {
+	Title          string `json:"title"`
+	FirstName      string `json:"firstName"`
+	MiddleName     string `json:"middleName"`
+	LastName       string `json:"lastName"`
+	Address1       string `json:"address1"`
+	Address2       string `json:"address2"`
+	Address3       string `json:"address3"`
+	City           string `json:"city"`
+	State          string `json:"state"`
+	PostalCode     string `json:"postalCode"`
+	Country        string `json:"country"`
+	Company        string `json:"company"`
+	Email          string `json:"email"`
+	Phone          string `json:"phone"`
+	SSN            string `json:"ssn"`
+	Username       string `json:"username"`
+	PassportNumber string `json:"passportNumber"`
+	LicenseNumber  string `json:"licenseNumber"`
+}
+
+/**
+ * SSHKey SSH密钥
+ */
+type SSHKey struct {
+	PrivateKey     string `json:"privateKey"`
+	PublicKey      string `json:"publicKey"`
+	KeyFingerprint string `json:"keyFingerprint"`
+}
+
+/**
+ * PasswordHistory 历史密码，LastUsedDate为该密码被替换的时间
+ */
+type PasswordHistory struct {
+	LastUsedDate time.Time `json:"lastUsedDate"`
+	Password     string    `json:"password"`
+}
@@ -0,0 +1,175 @@
+package bitwarden
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+/**
+ * Bitwarden导出格式测试
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 测试未加密格式的解析、受密码保护格式的加解密往返、密钥派生以及错误密码和账号限制格式的处理
+ */
+
+const testUnencryptedExport = `{
+  "encrypted": false,
+  "folders": [{"id": "d7a3c1f0-0000-4000-8000-000000000001", "name": "工作/邮件"}],
+  "items": [
+    {
+      "id": "5f0c8a3e-1111-4111-8111-111111111111",
+      "organizationId": null,
+      "folderId": "d7a3c1f0-0000-4000-8000-000000000001",
+      "type": 1,
+      "reprompt": 0,
+      "name": "邮箱",
+      "notes": null,
+      "favorite": true,
+      "fields": [{"name": "PIN", "value": "1234", "type": 1, "linkedId": null}],
+      "login": {
+        "uris": [{"match": null, "uri": "https://mail.example.com"}],
+        "username": "alice",
+        "password": "pw",
+        "totp": "otpauth://totp/Mail:alice?secret=JBSWY3DPEHPK3PXP",
+        "passwordRevisionDate": null
+      },
+      "collectionIds": null,
+      "passwordHistory": [{"lastUsedDate": "2024-01-02T03:04:05.000Z", "password": "old"}],
+      "revisionDate": "2024-06-01T08:00:00.000Z",
+      "creationDate": "2023-05-01T08:00:00.000Z",
+      "deletedDate": null
+    }
+  ]
+}`
+
+func TestParse_Unencrypted(t *testing.T) {
+	export, err := Parse([]byte(testUnencryptedExport), "")
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	if len(export.Folders) != 1 || export.Folders[0].Name != "工作/邮件" || len(export.Items) != 1 {
+		t.Fatalf("解析结果错误: %+v", export)
+	}
+
+	item := export.Items[0]
+	if item.Type != ItemTypeLogin || item.Login == nil || item.Login.Username != "alice" || !item.Favorite || item.GetNotes() != "" {
+		t.Errorf("条目解析错误: %+v", item)
+	}
+	if item.FolderID == nil || *item.FolderID != export.Folders[0].ID {
+		t.Errorf("文件夹ID错误: %v", item.FolderID)
+	}
+	if len(item.PasswordHistory) != 1 || !item.PasswordHistory[0].LastUsedDate.Equal(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Errorf("历史密码解析错误: %+v", item.PasswordHistory)
+	}
+	if !item.CreationDate.Equal(time.Date(2023, 5, 1, 8, 0, 0, 0, time.UTC)) {
+		t.Errorf("创建时间解析错误: %v", item.CreationDate)
+	}
+}
+
+func TestMarshal_PasswordProtectedRoundTrip(t *testing.T) {
+	source, err := Parse([]byte(testUnencryptedExport), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name string
+		opts EncryptOptions
+	}{
+		{"PBKDF2", EncryptOptions{KdfType: KdfPBKDF2, Iterations: 1000}},
+		{"Argon2id", EncryptOptions{KdfType: KdfArgon2id, Iterations: 2, Memory: 1, Parallelism: 1}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := Marshal(source, "export-pw", tc.opts)
+			if err != nil {
+				t.Fatalf("加密失败: %v", err)
+			}
+			if strings.Contains(string(data), "alice") {
+				t.Fatal("加密后的文件中不应出现明文")
+			}
+			if protected, err := IsPasswordProtected(data); err != nil || !protected {
+				t.Fatalf("应识别为受密码保护: %v, %v", protected, err)
+			}
+
+			parsed, err := Parse(data, "export-pw")
+			if err != nil {
+				t.Fatalf("解密失败: %v", err)
+			}
+			if len(parsed.Items) != 1 || parsed.Items[0].Login.Password != "pw" || parsed.Items[0].Fields[0].Value != "1234" {
+				t.Errorf("往返结果错误: %+v", parsed.Items)
+			}
+
+			if _, err := Parse(data, "wrong"); !errors.Is(err, ErrInvalidCredentials) {
+				t.Errorf("错误密码应返回ErrInvalidCredentials，实际: %v", err)
+			}
+			if _, err := Parse(data, ""); !errors.Is(err, ErrPasswordRequired) {
+				t.Errorf("未提供密码应返回ErrPasswordRequired，实际: %v", err)
+			}
+		})
+	}
+}
+
+func TestParse_TamperedData(t *testing.T) {
+	source, _ := Parse([]byte(testUnencryptedExport), "")
+	data, err := Marshal(source, "pw", EncryptOptions{KdfType: KdfPBKDF2, Iterations: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var file passwordProtectedFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(file.Data, "|")
+	parts[2] = base64.StdEncoding.EncodeToString(make([]byte, 32))
+	file.Data = strings.Join(parts, "|")
+	tampered, _ := json.Marshal(file)
+
+	if _, err := Parse(tampered, "pw"); err == nil {
+		t.Error("数据被篡改时应返回错误")
+	}
+}
+
+func TestParse_AccountRestricted(t *testing.T) {
+	data := `{"encrypted": true, "encKeyValidation_DO_NOT_EDIT": "2.xx|yy|zz", "folders": [], "items": []}`
+	if _, err := Parse([]byte(data), "pw"); !errors.Is(err, ErrAccountRestricted) {
+		t.Errorf("账号限制格式应返回ErrAccountRestricted，实际: %v", err)
+	}
+	if _, err := Parse([]byte(`{"items": []}`), ""); !errors.Is(err, ErrInvalidFormat) {
+		t.Errorf("缺少encrypted字段应返回ErrInvalidFormat，实际: %v", err)
+	}
+}
+
+func TestDeriveKey_PBKDF2(t *testing.T) {
+	encKey, macKey, err := deriveKey("correct horse", "c2FsdHNhbHRzYWx0c2FsdA==", EncryptOptions{KdfType: KdfPBKDF2, Iterations: 5000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// PBKDF2-SHA256后HKDF-Expand，期望值由独立实现计算
+	if got := hex.EncodeToString(encKey); got != "23103285ccabb9f816910dbb00e478aac61071f4d3a9bdf9a22e92fa6e740ade" {
+		t.Errorf("加密密钥错误: %s", got)
+	}
+	if got := hex.EncodeToString(macKey); got != "737c300b947d346edbd890112923d9fbe9779e1da5ae61384842c195d4d149c5" {
+		t.Errorf("MAC密钥错误: %s", got)
+	}
+}
+
+func TestDeriveKey_Limits(t *testing.T) {
+	cases := map[string]EncryptOptions{
+		"PBKDF2迭代次数超限":     {KdfType: KdfPBKDF2, Iterations: 1 << 31},
+		"Argon2迭代次数超限":     {KdfType: KdfArgon2id, Iterations: maxArgon2Iterations + 1, Memory: 64, Parallelism: 4},
+		"Argon2内存超过uint32": {KdfType: KdfArgon2id, Iterations: 3, Memory: 1 << 22, Parallelism: 4},
+		"Argon2并行度超限":      {KdfType: KdfArgon2id, Iterations: 3, Memory: 64, Parallelism: maxArgon2Parallelism + 1},
+	}
+	for name, opts := range cases {
+		if _, _, err := deriveKey("pw", "salt", opts); !errors.Is(err, ErrCorrupted) {
+			t.Errorf("%s: 应返回ErrCorrupted，实际: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,300 @@
+package bitwarden
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/hkdf"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// encTypeAESCBC256HMAC Bitwarden EncString类型2：AES-256-CBC + HMAC-SHA256
+const encTypeAESCBC256HMAC = "2"
+
+// 20251021 陈凤庆 密钥派生参数上限，与Bitwarden客户端允许设置的最大值一致，
+// 防止构造的文件让导入长时间无响应或耗尽内存
+const (
+	maxPBKDF2Iterations  = 2000000
+	maxArgon2Iterations  = 10
+	maxArgon2Memory      = 1024 // MB
+	maxArgon2Parallelism = 16
+)
+
+/**
+ * passwordProtectedFile 受密码保护的导出文件
+ */
+type passwordProtectedFile struct {
+	Encrypted         bool   `json:"encrypted"`
+	PasswordProtected bool   `json:"passwordProtected"`
+	Salt              string `json:"salt"`
+	KdfType           int    `json:"kdfType"`
+	KdfIterations     int    `json:"kdfIterations"`
+	KdfMemory         int    `json:"kdfMemory,omitempty"`      // Argon2内存大小（MB）
+	KdfParallelism    int    `json:"kdfParallelism,omitempty"` // Argon2并行度
+	EncKeyValidation  string `json:"encKeyValidation_DO_NOT_EDIT"`
+	Data              string `json:"data"`
+}
+
+/**
+ * EncryptOptions 受密码保护导出的密钥派生参数
+ */
+type EncryptOptions struct {
+	KdfType     int // KdfPBKDF2 或 KdfArgon2id
+	Iterations  int // PBKDF2迭代次数或Argon2迭代次数
+	Memory      int // Argon2内存大小（MB）
+	Parallelism int // Argon2并行度
+}
+
+/**
+ * DefaultEncryptOptions 默认参数：PBKDF2-SHA256 600000次迭代，与Bitwarden默认值一致
+ * @return EncryptOptions 密钥派生参数
+ */
+func DefaultEncryptOptions() EncryptOptions {
+	return EncryptOptions{KdfType: KdfPBKDF2, Iterations: 600000}
+}
+
+/**
+ * IsPasswordProtected 判断导出文件是否受密码保护
+ * @param data 文件内容
+ * @return bool 是否受密码保护
+ * @return error 不是Bitwarden导出文件或使用账号密钥加密时返回错误
+ */
+func IsPasswordProtected(data []byte) (bool, error) {
+	var head struct {
+		Encrypted         *bool `json:"encrypted"`
+		PasswordProtected bool  `json:"passwordProtected"`
+	}
+	if err := json.Unmarshal(data, &head); err != nil || head.Encrypted == nil {
+		return false, ErrInvalidFormat
+	}
+	if *head.Encrypted && !head.PasswordProtected {
+		return false, ErrAccountRestricted
+	}
+	return *head.Encrypted, nil
+}
+
+/**
+ * Parse 解析导出文件，受密码保护时先解密
+ * @param data 文件内容
+ * @param password 导出密码，未加密的文件忽略
+ * @return *Export 导出内容
+ * @return error 错误信息
+ */
+func Parse(data []byte, password string) (*Export, error) {
+	protected, err := IsPasswordProtected(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if protected {
+		if password == "" {
+			return nil, ErrPasswordRequired
+		}
+		var file passwordProtectedFile
+		if err := json.Unmarshal(data, &file); err != nil {
+			return nil, ErrInvalidFormat
+		}
+		encKey, macKey, err := deriveKey(password, file.Salt, EncryptOptions{
+			KdfType:     file.KdfType,
+			Iterations:  file.KdfIterations,
+			Memory:      file.KdfMemory,
+			Parallelism: file.KdfParallelism,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := decryptString(file.EncKeyValidation, encKey, macKey); err != nil {
+			return nil, ErrInvalidCredentials
+		}
+		if data, err = decryptString(file.Data, encKey, macKey); err != nil {
+			return nil, err
+		}
+	}
+
+	var export Export
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFormat, err)
+	}
+	return &export, nil
+}
+
+/**
+ * Marshal 生成导出文件
+ * @param export 导出内容
+ * @param password 导出密码，为空时生成未加密的文件
+ * @param opts 密钥派生参数，仅加密时使用，零值使用默认参数
+ * @return []byte 文件内容
+ * @return error 错误信息
+ */
+func Marshal(export *Export, password string, opts EncryptOptions) ([]byte, error) {
+	plain := *export
+	plain.Encrypted = false
+	data, err := json.MarshalIndent(plain, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("生成JSON失败: %w", err)
+	}
+	if password == "" {
+		return data, nil
+	}
+	if opts == (EncryptOptions{}) {
+		opts = DefaultEncryptOptions()
+	}
+
+	salt := base64.StdEncoding.EncodeToString(randomBytes(16))
+	encKey, macKey, err := deriveKey(password, salt, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	file := passwordProtectedFile{
+		Encrypted:         true,
+		PasswordProtected: true,
+		Salt:              salt,
+		KdfType:           opts.KdfType,
+		KdfIterations:     opts.Iterations,
+		EncKeyValidation:  encryptString([]byte(newGUID()), encKey, macKey),
+		Data:              encryptString(data, encKey, macKey),
+	}
+	if opts.KdfType == KdfArgon2id {
+		file.KdfMemory, file.KdfParallelism = opts.Memory, opts.Parallelism
+	}
+	return json.MarshalIndent(file, "", "  ")
+}
+
+/**
+ * deriveKey 由导出密码派生加密密钥和MAC密钥
+ * @param password 导出密码
+ * @param salt 盐值字符串（Base64文本本身作为盐值）
+ * @param opts 密钥派生参数
+ * @return []byte 加密密钥
+ * @return []byte MAC密钥
+ * @return error 参数无效或超出上限时返回错误
+ * @description PBKDF2直接使用盐值；Argon2id使用盐值的SHA-256。派生的32字节密钥再经HKDF-Expand（SHA-256）
+ *              分别以"enc"和"mac"扩展为两个32字节密钥
+ */
+func deriveKey(password, salt string, opts EncryptOptions) ([]byte, []byte, error) {
+	if opts.Iterations <= 0 {
+		return nil, nil, fmt.Errorf("%w: 无效的迭代次数", ErrCorrupted)
+	}
+
+	var key []byte
+	switch opts.KdfType {
+	case KdfPBKDF2:
+		if opts.Iterations > maxPBKDF2Iterations {
+			return nil, nil, fmt.Errorf("%w: PBKDF2迭代次数超出上限（%d次）", ErrCorrupted, opts.Iterations)
+		}
+		key = pbkdf2.Key([]byte(password), []byte(salt), opts.Iterations, 32, sha256.New)
+	case KdfArgon2id:
+		if opts.Memory <= 0 || opts.Parallelism <= 0 {
+			return nil, nil, fmt.Errorf("%w: 无效的Argon2参数", ErrCorrupted)
+		}
+		if opts.Iterations > maxArgon2Iterations || opts.Memory > maxArgon2Memory || opts.Parallelism > maxArgon2Parallelism {
+			return nil, nil, fmt.Errorf("%w: Argon2参数超出上限（迭代%d次，内存%dMB，并行度%d）", ErrCorrupted, opts.Iterations, opts.Memory, opts.Parallelism)
+		}
+		saltHash := sha256.Sum256([]byte(salt))
+		key = argon2.IDKey([]byte(password), saltHash[:], uint32(opts.Iterations), uint32(opts.Memory)*1024, uint8(opts.Parallelism), 32)
+	default:
+		return nil, nil, fmt.Errorf("不支持的密钥派生算法: %d", opts.KdfType)
+	}
+
+	encKey, macKey := make([]byte, 32), make([]byte, 32)
+	if _, err := io.ReadFull(hkdf.Expand(sha256.New, key, []byte("enc")), encKey); err != nil {
+		return nil, nil, err
+	}
+	if _, err := io.ReadFull(hkdf.Expand(sha256.New, key, []byte("mac")), macKey); err != nil {
+		return nil, nil, err
+	}
+	return encKey, macKey, nil
+}
+
+/**
+ * encryptString 加密为EncString："2.<IV>|<密文>|<MAC>"，各部分Base64编码
+ */
+func encryptString(plaintext, encKey, macKey []byte) string {
+	iv := randomBytes(aes.BlockSize)
+	block, _ := aes.NewCipher(encKey)
+	padding := aes.BlockSize - len(plaintext)%aes.BlockSize
+	padded := append(append([]byte(nil), plaintext...), bytes.Repeat([]byte{byte(padding)}, padding)...)
+	ciphertext := make([]byte, len(padded))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(ciphertext, padded)
+
+	return encTypeAESCBC256HMAC + "." + base64.StdEncoding.EncodeToString(iv) + "|" +
+		base64.StdEncoding.EncodeToString(ciphertext) + "|" +
+		base64.StdEncoding.EncodeToString(computeMAC(macKey, iv, ciphertext))
+}
+
+/**
+ * decryptString 校验MAC并解密EncString
+ * @return []byte 明文
+ * @return error MAC不匹配时返回ErrInvalidCredentials，格式错误时返回ErrCorrupted
+ */
+func decryptString(encString string, encKey, macKey []byte) ([]byte, error) {
+	encType, rest, ok := strings.Cut(encString, ".")
+	if !ok || encType != encTypeAESCBC256HMAC {
+		return nil, fmt.Errorf("%w: 不支持的加密类型", ErrCorrupted)
+	}
+	parts := strings.Split(rest, "|")
+	if len(parts) != 3 {
+		return nil, ErrCorrupted
+	}
+	decoded := make([][]byte, len(parts))
+	for i, part := range parts {
+		b, err := base64.StdEncoding.DecodeString(part)
+		if err != nil {
+			return nil, ErrCorrupted
+		}
+		decoded[i] = b
+	}
+	iv, ciphertext, mac := decoded[0], decoded[1], decoded[2]
+
+	if !hmac.Equal(mac, computeMAC(macKey, iv, ciphertext)) {
+		return nil, ErrInvalidCredentials
+	}
+	if len(iv) != aes.BlockSize || len(ciphertext) == 0 || len(ciphertext)%aes.BlockSize != 0 {
+		return nil, ErrCorrupted
+	}
+
+	block, _ := aes.NewCipher(encKey)
+	plaintext := make([]byte, len(ciphertext))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plaintext, ciphertext)
+	padding := int(plaintext[len(plaintext)-1])
+	if padding == 0 || padding > aes.BlockSize {
+		return nil, ErrCorrupted
+	}
+	return plaintext[:len(plaintext)-padding], nil
+}
+
+func computeMAC(macKey, iv, ciphertext []byte) []byte {
+	mac := hmac.New(sha256.New, macKey)
+	mac.Write(iv)
+	mac.Write(ciphertext)
+	return mac.Sum(nil)
+}
+
+func randomBytes(n int) []byte {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic("bitwarden: 生成随机数失败: " + err.Error())
+	}
+	return b
+}
+
+// newGUID 生成小写GUID，用于密钥校验
+func newGUID() string {
+	b := randomBytes(16)
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	s := hex.EncodeToString(b)
+	return s[0:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:]
+}
@@ -76,6 +76,16 @@ func NewConfigManager() *ConfigManager {
 				ShowHideHotkey:       "Ctrl+Alt+H", // 默认快捷键为Ctrl+Alt+H
 				EnableShowHideHotkey: true,         // 默认启用显示/隐藏快捷键
 			},
+			// 20251021 陈凤庆 添加密码规则强度策略默认值
+			PasswordPolicyConfig: models.PasswordPolicyConfig{
+				WarnEntropyBits: 60, // 低于60位时提示
+				MinEntropyBits:  0,  // 默认不拒绝保存
+			},
+			// 20251021 陈凤庆 添加密码到期提醒配置默认值
+			ExpiryReminderConfig: models.ExpiryReminderConfig{
+				ReminderDays:              7,    // 提前7天提醒
+				EnableDesktopNotification: true, // 默认发送桌面通知
+			},
 		},
 	}
 
@@ -337,3 +347,69 @@ func (cm *ConfigManager) SetHotkeyConfig(config models.HotkeyConfig) error {
 	cm.config.HotkeyConfig = config
 	return cm.SaveConfig()
 }
+
+/**
+ * GetPasswordPolicyConfig 获取密码规则强度策略配置
+ * @return models.PasswordPolicyConfig 密码规则强度策略配置
+ * @author 陈凤庆
+ * @date 20251021
+ */
+func (cm *ConfigManager) GetPasswordPolicyConfig() models.PasswordPolicyConfig {
+	return cm.config.PasswordPolicyConfig
+}
+
+/**
+ * SetPasswordPolicyConfig 设置密码规则强度策略配置
+ * @param config 密码规则强度策略配置
+ * @return error 错误信息
+ * @author 陈凤庆
+ * @date 20251021
+ */
+func (cm *ConfigManager) SetPasswordPolicyConfig(config models.PasswordPolicyConfig) error {
+	cm.config.PasswordPolicyConfig = config
+	return cm.SaveConfig()
+}
+
+/**
+ * GetBreachCheckConfig 获取离线泄露检查配置
+ * @return models.BreachCheckConfig 离线泄露检查配置
+ * @author 陈凤庆
+ * @date 20251021
+ */
+func (cm *ConfigManager) GetBreachCheckConfig() models.BreachCheckConfig {
+	return cm.config.BreachCheckConfig
+}
+
+/**
+ * SetBreachCheckConfig 设置离线泄露检查配置
+ * @param config 离线泄露检查配置
+ * @return error 错误信息
+ * @author 陈凤庆
+ * @date 20251021
+ */
+func (cm *ConfigManager) SetBreachCheckConfig(config models.BreachCheckConfig) error {
+	cm.config.BreachCheckConfig = config
+	return cm.SaveConfig()
+}
+
+/**
+ * GetExpiryReminderConfig 获取密码到期提醒配置
+ * @return models.ExpiryReminderConfig 密码到期提醒配置
+ * @author 陈凤庆
+ * @date 20251021
+ */
+func (cm *ConfigManager) GetExpiryReminderConfig() models.ExpiryReminderConfig {
+	return cm.config.ExpiryReminderConfig
+}
+
+/**
+ * SetExpiryReminderConfig 设置密码到期提醒配置
+ * @param config 密码到期提醒配置
+ * @return error 错误信息
+ * @author 陈凤庆
+ * @date 20251021
+ */
+func (cm *ConfigManager) SetExpiryReminderConfig(config models.ExpiryReminderConfig) error {
+	cm.config.ExpiryReminderConfig = config
+	return cm.SaveConfig()
+}
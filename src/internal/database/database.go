@@ -2,6 +2,7 @@ package database
 
 import (
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
@@ -96,7 +97,16 @@ const (
 	// 20251005 陈凤庆 版本10: 扩展input_method字段支持第四种输入方式：4-键盘助手输入（删除原第4种底层键盘API）
 	// 20251017 陈凤庆 版本11: 添加password_rules表，支持密码规则管理
 	// 20251017 陈凤庆 版本12: 添加username_history表，支持用户名历史记录管理
-	CurrentDatabaseVersion = 12
+	// 20251020 陈凤庆 版本13: 为groups表恢复parent_id字段，支持任意层级的嵌套分组
+	// 20251021 陈凤庆 版本14: password_rules表rule_type支持passphrase(口令短语)规则类型
+	// 20251021 陈凤庆 版本15: 为groups表和types表添加password_rule_id字段，支持绑定默认密码规则
+	// 20251021 陈凤庆 版本16: password_rules表rule_type支持pronounceable(可发音密码)规则类型
+	// 20251021 陈凤庆 版本17: 添加account_expiry表，支持账号密码到期日期和轮换周期
+	// 20251021 陈凤庆 版本18: 添加account_rotation表和password_history表，支持密码轮换流程和历史密码
+	// 20251021 陈凤庆 版本19: 添加rotation_hooks表和rotation_hook_runs表，支持密码轮换钩子及其执行记录
+	// 20251021 陈凤庆 版本20: 添加account_attachments表，支持账号附件（如KeePass导入的附件）
+	// 20251021 陈凤庆 版本21: 为password_rules表添加origin字段，区分本地规则和由订阅的规则文件创建的规则
+	CurrentDatabaseVersion = 21
 )
 
 /**
@@ -250,11 +260,15 @@ func (dm *DatabaseManager) createInitialSchema(language string) error {
 
 	// 3. 创建分组表(使用GUID)
 	// 20251002 陈凤庆 删除parent_id字段，不需要层级结构
+	// 20251020 陈凤庆 恢复parent_id字段，支持嵌套分组，空字符串表示顶级分组
+	// 20251021 陈凤庆 添加password_rule_id字段，绑定分组的默认密码规则
 	groupsSQL := `
 	CREATE TABLE IF NOT EXISTS groups (
 		id TEXT PRIMARY KEY,
 		name TEXT NOT NULL,
 		icon TEXT DEFAULT '',
+		parent_id TEXT DEFAULT '',
+		password_rule_id TEXT DEFAULT '',
 		sort_order INTEGER DEFAULT 0,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
@@ -262,6 +276,7 @@ func (dm *DatabaseManager) createInitialSchema(language string) error {
 
 	// 4. 创建类型表(使用GUID)
 	// 20251002 陈凤庆 group_id不设置默认值，必须由前端传递
+	// 20251021 陈凤庆 添加password_rule_id字段，绑定类型的默认密码规则
 	typesSQL := `
 	CREATE TABLE IF NOT EXISTS types (
 		id TEXT PRIMARY KEY,
@@ -269,6 +284,7 @@ func (dm *DatabaseManager) createInitialSchema(language string) error {
 		icon TEXT DEFAULT '',
 		filter TEXT DEFAULT '',
 		group_id TEXT NOT NULL,
+		password_rule_id TEXT DEFAULT '',
 		sort_order INTEGER DEFAULT 0,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
@@ -299,14 +315,17 @@ func (dm *DatabaseManager) createInitialSchema(language string) error {
 
 	// 6. 创建密码规则表(使用GUID)
 	// 20251017 陈凤庆 添加密码规则表，支持密码规则管理
+	// 20251021 陈凤庆 rule_type增加passphrase(口令短语)、pronounceable(可发音密码)
+	// 20251021 陈凤庆 添加origin字段，由订阅的规则文件创建的规则为subscription
 	passwordRulesSQL := `
 	CREATE TABLE IF NOT EXISTS password_rules (
 		id TEXT PRIMARY KEY,
 		name TEXT NOT NULL UNIQUE,
 		description TEXT DEFAULT '',
-		rule_type TEXT NOT NULL CHECK (rule_type IN ('general', 'custom')),
+		rule_type TEXT NOT NULL CHECK (rule_type IN ('general', 'custom', 'passphrase', 'pronounceable')),
 		config TEXT NOT NULL,
 		is_default BOOLEAN DEFAULT FALSE,
+		origin TEXT DEFAULT '',
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	);`
@@ -320,8 +339,80 @@ func (dm *DatabaseManager) createInitialSchema(language string) error {
 		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	);`
 
+	// 8. 创建账号密码到期表
+	// 20251021 陈凤庆 添加账号密码到期表，记录到期日期、轮换周期和密码修改时间
+	accountExpirySQL := `
+	CREATE TABLE IF NOT EXISTS account_expiry (
+		account_id TEXT PRIMARY KEY,
+		expires_at DATETIME,
+		rotation_days INTEGER DEFAULT 0,
+		password_changed_at DATETIME,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);`
+
+	// 9. 创建密码轮换表
+	// 20251021 陈凤庆 添加密码轮换表，保存轮换中待提交的新密码（加密存储）
+	accountRotationSQL := `
+	CREATE TABLE IF NOT EXISTS account_rotation (
+		account_id TEXT PRIMARY KEY,
+		pending_password TEXT NOT NULL,
+		rule_id TEXT DEFAULT '',
+		started_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);`
+
+	// 10. 创建历史密码表
+	// 20251021 陈凤庆 添加历史密码表，提交轮换时保存旧密码（加密存储）
+	passwordHistorySQL := `
+	CREATE TABLE IF NOT EXISTS password_history (
+		id TEXT PRIMARY KEY,
+		account_id TEXT NOT NULL,
+		password TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);`
+
+	// 11. 创建密码轮换钩子表
+	// 20251021 陈凤庆 添加密码轮换钩子表，修改密码时执行本地命令同步修改凭据
+	rotationHooksSQL := `
+	CREATE TABLE IF NOT EXISTS rotation_hooks (
+		account_id TEXT PRIMARY KEY,
+		command TEXT NOT NULL,
+		args TEXT DEFAULT '[]',
+		timeout_seconds INTEGER DEFAULT 30,
+		enabled BOOLEAN DEFAULT TRUE,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);`
+
+	// 12. 创建轮换钩子执行记录表
+	// 20251021 陈凤庆 添加轮换钩子执行记录表，作为审计记录保存退出码和输出
+	rotationHookRunsSQL := `
+	CREATE TABLE IF NOT EXISTS rotation_hook_runs (
+		id TEXT PRIMARY KEY,
+		account_id TEXT NOT NULL,
+		title TEXT DEFAULT '',
+		command TEXT NOT NULL,
+		started_at DATETIME NOT NULL,
+		duration_ms INTEGER DEFAULT 0,
+		exit_code INTEGER DEFAULT -1,
+		success BOOLEAN DEFAULT FALSE,
+		timed_out BOOLEAN DEFAULT FALSE,
+		output TEXT DEFAULT '',
+		error TEXT DEFAULT ''
+	);`
+
+	// 13. 创建账号附件表
+	// 20251021 陈凤庆 添加账号附件表，保存从KeePass导入的附件（加密存储）
+	accountAttachmentsSQL := `
+	CREATE TABLE IF NOT EXISTS account_attachments (
+		id TEXT PRIMARY KEY,
+		account_id TEXT NOT NULL,
+		name TEXT NOT NULL,
+		data TEXT NOT NULL,
+		size INTEGER DEFAULT 0,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);`
+
 	// 执行建表语句
-	tables := []string{sysInfoSQL, vaultConfigSQL, groupsSQL, typesSQL, accountsSQL, passwordRulesSQL, usernameHistorySQL}
+	tables := []string{sysInfoSQL, vaultConfigSQL, groupsSQL, typesSQL, accountsSQL, passwordRulesSQL, usernameHistorySQL, accountExpirySQL, accountRotationSQL, passwordHistorySQL, rotationHooksSQL, rotationHookRunsSQL, accountAttachmentsSQL}
 	for _, tableSQL := range tables {
 		if _, err := dm.db.Exec(tableSQL); err != nil {
 			return fmt.Errorf("创建数据库表失败: %w", err)
@@ -371,9 +462,36 @@ func (dm *DatabaseManager) upgradeDatabase(fromVersion int, toVersion int) error
 		case 12:
 			// 20251017 陈凤庆 版本12: 添加username_history表
 			err = dm.dbUpgrade_v12(upgradeUtils)
+		case 13:
+			// 20251020 陈凤庆 版本13: 为groups表添加parent_id字段
+			err = dm.dbUpgrade_v13(upgradeUtils)
+		case 14:
+			// 20251021 陈凤庆 版本14: password_rules表支持passphrase规则类型
+			err = dm.dbUpgrade_v14(upgradeUtils)
+		case 15:
+			// 20251021 陈凤庆 版本15: 为groups表和types表添加password_rule_id字段
+			err = dm.dbUpgrade_v15(upgradeUtils)
+		case 16:
+			// 20251021 陈凤庆 版本16: password_rules表支持pronounceable规则类型
+			err = dm.dbUpgrade_v16(upgradeUtils)
+		case 17:
+			// 20251021 陈凤庆 版本17: 添加account_expiry表
+			err = dm.dbUpgrade_v17(upgradeUtils)
+		case 18:
+			// 20251021 陈凤庆 版本18: 添加account_rotation表和password_history表
+			err = dm.dbUpgrade_v18(upgradeUtils)
+		case 19:
+			// 20251021 陈凤庆 版本19: 添加rotation_hooks表和rotation_hook_runs表
+			err = dm.dbUpgrade_v19(upgradeUtils)
+		case 20:
+			// 20251021 陈凤庆 版本20: 添加account_attachments表
+			err = dm.dbUpgrade_v20(upgradeUtils)
+		case 21:
+			// 20251021 陈凤庆 版本21: 为password_rules表添加origin字段
+			err = dm.dbUpgrade_v21(upgradeUtils)
 		// 未来版本在这里添加
-		// case 13:
-		//     err = dm.dbUpgrade_v13(upgradeUtils)
+		// case 22:
+		//     err = dm.dbUpgrade_v22(upgradeUtils)
 		default:
 			// 20251002 陈凤庆 不再支持v7之前的版本升级
 			return fmt.Errorf("不支持从版本 %d 升级，请使用最新版本创建新的数据库", version-1)
@@ -905,6 +1023,292 @@ func (dm *DatabaseManager) dbUpgrade_v12(utils *UpgradeUtils) error {
 	return nil
 }
 
+/**
+ * dbUpgrade_v13 升级到版本13
+ * @param utils 升级工具
+ * @return error 错误信息
+ * @description 为groups表添加parent_id字段，支持任意层级的嵌套分组，现有分组全部作为顶级分组
+ * @author 陈凤庆
+ * @date 20251020
+ */
+func (dm *DatabaseManager) dbUpgrade_v13(utils *UpgradeUtils) error {
+	log.Println("开始执行版本13升级: 为groups表添加parent_id字段")
+
+	if err := utils.AddColumn("groups", "parent_id", "TEXT DEFAULT ''"); err != nil {
+		return fmt.Errorf("添加parent_id字段失败: %w", err)
+	}
+
+	if err := utils.ExecuteSQL("UPDATE groups SET parent_id = '' WHERE parent_id IS NULL"); err != nil {
+		return fmt.Errorf("初始化parent_id字段失败: %w", err)
+	}
+
+	log.Println("版本13升级完成: parent_id字段添加完成")
+	return nil
+}
+
+/**
+ * dbUpgrade_v14 升级到版本14
+ * @param utils 升级工具
+ * @return error 错误信息
+ * @description 重建password_rules表，rule_type的CHECK约束增加passphrase(口令短语)类型，保留现有规则
+ * @author 陈凤庆
+ * @date 20251021
+ */
+func (dm *DatabaseManager) dbUpgrade_v14(utils *UpgradeUtils) error {
+	log.Println("开始执行版本14升级: password_rules表支持passphrase规则类型")
+
+	passwordRulesSQL := `
+	CREATE TABLE password_rules (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL UNIQUE,
+		description TEXT DEFAULT '',
+		rule_type TEXT NOT NULL CHECK (rule_type IN ('general', 'custom', 'passphrase')),
+		config TEXT NOT NULL,
+		is_default BOOLEAN DEFAULT FALSE,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);`
+
+	if err := utils.RebuildTable("password_rules", passwordRulesSQL); err != nil {
+		return fmt.Errorf("重建password_rules表失败: %w", err)
+	}
+
+	log.Println("版本14升级完成: password_rules表已支持passphrase规则类型")
+	return nil
+}
+
+/**
+ * dbUpgrade_v15 升级到版本15
+ * @param utils 升级工具
+ * @return error 错误信息
+ * @description 为groups表和types表添加password_rule_id字段，支持为分组和类型绑定默认密码规则
+ * @author 陈凤庆
+ * @date 20251021
+ */
+func (dm *DatabaseManager) dbUpgrade_v15(utils *UpgradeUtils) error {
+	log.Println("开始执行版本15升级: 为groups表和types表添加password_rule_id字段")
+
+	if err := utils.AddColumn("groups", "password_rule_id", "TEXT DEFAULT ''"); err != nil {
+		return fmt.Errorf("为groups表添加password_rule_id字段失败: %w", err)
+	}
+
+	if err := utils.AddColumn("types", "password_rule_id", "TEXT DEFAULT ''"); err != nil {
+		return fmt.Errorf("为types表添加password_rule_id字段失败: %w", err)
+	}
+
+	log.Println("版本15升级完成: password_rule_id字段添加完成")
+	return nil
+}
+
+/**
+ * dbUpgrade_v16 升级到版本16
+ * @param utils 升级工具
+ * @return error 错误信息
+ * @description 重建password_rules表，rule_type的CHECK约束增加pronounceable(可发音密码)类型，保留现有规则
+ * @author 陈凤庆
+ * @date 20251021
+ */
+func (dm *DatabaseManager) dbUpgrade_v16(utils *UpgradeUtils) error {
+	log.Println("开始执行版本16升级: password_rules表支持pronounceable规则类型")
+
+	passwordRulesSQL := `
+	CREATE TABLE password_rules (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL UNIQUE,
+		description TEXT DEFAULT '',
+		rule_type TEXT NOT NULL CHECK (rule_type IN ('general', 'custom', 'passphrase', 'pronounceable')),
+		config TEXT NOT NULL,
+		is_default BOOLEAN DEFAULT FALSE,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);`
+
+	if err := utils.RebuildTable("password_rules", passwordRulesSQL); err != nil {
+		return fmt.Errorf("重建password_rules表失败: %w", err)
+	}
+
+	log.Println("版本16升级完成: password_rules表已支持pronounceable规则类型")
+	return nil
+}
+
+/**
+ * dbUpgrade_v17 升级到版本17
+ * @param utils 升级工具
+ * @return error 错误信息
+ * @description 添加account_expiry表，支持账号密码到期日期、轮换周期以及记录密码修改时间
+ * @author 陈凤庆
+ * @date 20251021
+ */
+func (dm *DatabaseManager) dbUpgrade_v17(utils *UpgradeUtils) error {
+	log.Println("开始执行版本17升级: 添加account_expiry表")
+
+	accountExpirySQL := `
+	CREATE TABLE IF NOT EXISTS account_expiry (
+		account_id TEXT PRIMARY KEY,
+		expires_at DATETIME,
+		rotation_days INTEGER DEFAULT 0,
+		password_changed_at DATETIME,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);`
+
+	if err := utils.CreateTable("account_expiry", accountExpirySQL); err != nil {
+		return fmt.Errorf("创建account_expiry表失败: %w", err)
+	}
+
+	log.Println("版本17升级完成: account_expiry表创建成功")
+	return nil
+}
+
+/**
+ * dbUpgrade_v18 升级到版本18
+ * @param utils 升级工具
+ * @return error 错误信息
+ * @description 添加account_rotation表保存轮换中的新密码，添加password_history表保存提交轮换后的旧密码
+ * @author 陈凤庆
+ * @date 20251021
+ */
+func (dm *DatabaseManager) dbUpgrade_v18(utils *UpgradeUtils) error {
+	log.Println("开始执行版本18升级: 添加account_rotation表和password_history表")
+
+	accountRotationSQL := `
+	CREATE TABLE IF NOT EXISTS account_rotation (
+		account_id TEXT PRIMARY KEY,
+		pending_password TEXT NOT NULL,
+		rule_id TEXT DEFAULT '',
+		started_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);`
+
+	passwordHistorySQL := `
+	CREATE TABLE IF NOT EXISTS password_history (
+		id TEXT PRIMARY KEY,
+		account_id TEXT NOT NULL,
+		password TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);`
+
+	if err := utils.CreateTable("account_rotation", accountRotationSQL); err != nil {
+		return fmt.Errorf("创建account_rotation表失败: %w", err)
+	}
+	if err := utils.CreateTable("password_history", passwordHistorySQL); err != nil {
+		return fmt.Errorf("创建password_history表失败: %w", err)
+	}
+
+	log.Println("版本18升级完成: account_rotation表和password_history表创建成功")
+	return nil
+}
+
+/**
+ * dbUpgrade_v19 升级到版本19
+ * @param utils 升级工具
+ * @return error 错误信息
+ * @description 添加rotation_hooks表保存账号的轮换钩子，添加rotation_hook_runs表保存钩子的执行记录
+ * @author 陈凤庆
+ * @date 20251021
+ */
+func (dm *DatabaseManager) dbUpgrade_v19(utils *UpgradeUtils) error {
+	log.Println("开始执行版本19升级: 添加rotation_hooks表和rotation_hook_runs表")
+
+	rotationHooksSQL := `
+	CREATE TABLE IF NOT EXISTS rotation_hooks (
+		account_id TEXT PRIMARY KEY,
+		command TEXT NOT NULL,
+		args TEXT DEFAULT '[]',
+		timeout_seconds INTEGER DEFAULT 30,
+		enabled BOOLEAN DEFAULT TRUE,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);`
+
+	rotationHookRunsSQL := `
+	CREATE TABLE IF NOT EXISTS rotation_hook_runs (
+		id TEXT PRIMARY KEY,
+		account_id TEXT NOT NULL,
+		title TEXT DEFAULT '',
+		command TEXT NOT NULL,
+		started_at DATETIME NOT NULL,
+		duration_ms INTEGER DEFAULT 0,
+		exit_code INTEGER DEFAULT -1,
+		success BOOLEAN DEFAULT FALSE,
+		timed_out BOOLEAN DEFAULT FALSE,
+		output TEXT DEFAULT '',
+		error TEXT DEFAULT ''
+	);`
+
+	if err := utils.CreateTable("rotation_hooks", rotationHooksSQL); err != nil {
+		return fmt.Errorf("创建rotation_hooks表失败: %w", err)
+	}
+	if err := utils.CreateTable("rotation_hook_runs", rotationHookRunsSQL); err != nil {
+		return fmt.Errorf("创建rotation_hook_runs表失败: %w", err)
+	}
+
+	log.Println("版本19升级完成: rotation_hooks表和rotation_hook_runs表创建成功")
+	return nil
+}
+
+/**
+ * dbUpgrade_v20 升级到版本20
+ * @param utils 升级工具
+ * @return error 错误信息
+ * @description 添加account_attachments表保存账号附件，附件内容加密后以Base64保存
+ * @author 陈凤庆
+ * @date 20251021
+ */
+func (dm *DatabaseManager) dbUpgrade_v20(utils *UpgradeUtils) error {
+	log.Println("开始执行版本20升级: 添加account_attachments表")
+
+	accountAttachmentsSQL := `
+	CREATE TABLE IF NOT EXISTS account_attachments (
+		id TEXT PRIMARY KEY,
+		account_id TEXT NOT NULL,
+		name TEXT NOT NULL,
+		data TEXT NOT NULL,
+		size INTEGER DEFAULT 0,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);`
+
+	if err := utils.CreateTable("account_attachments", accountAttachmentsSQL); err != nil {
+		return fmt.Errorf("创建account_attachments表失败: %w", err)
+	}
+
+	log.Println("版本20升级完成: account_attachments表创建成功")
+	return nil
+}
+
+/**
+ * dbUpgrade_v21 升级到版本21
+ * @param utils 升级工具
+ * @return error 错误信息
+ * @description 为password_rules表添加origin字段，订阅同步只修改和删除origin为subscription的规则。
+ *              已有订阅记录中的规则标记为subscription，其余规则均为本地规则
+ * @author 陈凤庆
+ * @date 20251021
+ */
+func (dm *DatabaseManager) dbUpgrade_v21(utils *UpgradeUtils) error {
+	log.Println("开始执行版本21升级: 为password_rules表添加origin字段")
+
+	if err := utils.AddColumn("password_rules", "origin", "TEXT DEFAULT ''"); err != nil {
+		return fmt.Errorf("为password_rules表添加origin字段失败: %w", err)
+	}
+
+	value, err := NewSysInfoManager(dm.db).GetValue("password_rule_subscription")
+	if err != nil {
+		return err
+	}
+	if value != "" {
+		var subscription models.PasswordRuleSubscription
+		if err := json.Unmarshal([]byte(value), &subscription); err != nil {
+			log.Printf("解析规则文件订阅失败，已同步的规则保留为本地规则: %v", err)
+		}
+		for _, id := range subscription.RuleIDs {
+			if _, err := dm.db.Exec("UPDATE password_rules SET origin = 'subscription' WHERE id = ?", id); err != nil {
+				return fmt.Errorf("标记已同步的规则失败: %w", err)
+			}
+		}
+	}
+
+	log.Println("版本21升级完成: origin字段添加完成")
+	return nil
+}
+
 /**
  * renameTableWithDataMigration 重命名表并进行数据迁移
  * @param oldTableName 旧表名
@@ -6,6 +6,7 @@ import (
 	"log"
 	"math/rand"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -256,6 +257,113 @@ func (u *UpgradeUtils) ExecuteSQL(sql string) error {
 	return nil
 }
 
+/**
+ * RebuildTable 按新的建表语句重建表并保留数据
+ * @param tableName 表名
+ * @param createSQL 新的建表SQL语句(表名须与tableName一致)
+ * @return error 错误信息
+ * @description SQLite不支持修改CHECK等约束，通过重命名旧表、创建新表、复制同名字段数据、删除旧表的方式完成，整个过程在事务中执行
+ * @author 陈凤庆
+ * @date 20251021
+ */
+func (u *UpgradeUtils) RebuildTable(tableName string, createSQL string) error {
+	exists, err := u.TableExists(tableName)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		_, err = u.db.Exec(createSQL)
+		if err != nil {
+			return fmt.Errorf("创建表 %s 失败: %w", tableName, err)
+		}
+		log.Printf("表 %s 不存在,已直接创建", tableName)
+		return nil
+	}
+
+	// 记录旧表字段，重建后只复制新旧表共有的字段
+	oldColumns, err := u.tableColumns(tableName)
+	if err != nil {
+		return err
+	}
+
+	backupTableName := fmt.Sprintf("%s_%s_%s", tableName, time.Now().Format("20060102"), generateRandomString(5))
+
+	tx, err := u.db.Begin()
+	if err != nil {
+		return fmt.Errorf("开始事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.Exec(fmt.Sprintf("ALTER TABLE %s RENAME TO %s", tableName, backupTableName)); err != nil {
+		return fmt.Errorf("重命名表 %s 失败: %w", tableName, err)
+	}
+
+	if _, err = tx.Exec(createSQL); err != nil {
+		return fmt.Errorf("创建表 %s 失败: %w", tableName, err)
+	}
+
+	rows, err := tx.Query("SELECT name FROM pragma_table_info(?)", tableName)
+	if err != nil {
+		return fmt.Errorf("获取表 %s 字段失败: %w", tableName, err)
+	}
+	var common []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return fmt.Errorf("扫描字段失败: %w", err)
+		}
+		if oldColumns[name] {
+			common = append(common, name)
+		}
+	}
+	rows.Close()
+
+	if len(common) > 0 {
+		columnList := strings.Join(common, ", ")
+		copySQL := fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s", tableName, columnList, columnList, backupTableName)
+		if _, err = tx.Exec(copySQL); err != nil {
+			return fmt.Errorf("迁移表 %s 数据失败: %w", tableName, err)
+		}
+	}
+
+	if _, err = tx.Exec(fmt.Sprintf("DROP TABLE %s", backupTableName)); err != nil {
+		return fmt.Errorf("删除旧表 %s 失败: %w", backupTableName, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("提交事务失败: %w", err)
+	}
+
+	log.Printf("表 %s 重建成功,迁移字段: %s", tableName, strings.Join(common, ", "))
+	return nil
+}
+
+/**
+ * tableColumns 获取表的全部字段名
+ * @param tableName 表名
+ * @return map[string]bool 字段名集合
+ * @return error 错误信息
+ */
+func (u *UpgradeUtils) tableColumns(tableName string) (map[string]bool, error) {
+	rows, err := u.db.Query("SELECT name FROM pragma_table_info(?)", tableName)
+	if err != nil {
+		return nil, fmt.Errorf("获取表 %s 字段失败: %w", tableName, err)
+	}
+	defer rows.Close()
+
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("扫描字段失败: %w", err)
+		}
+		columns[name] = true
+	}
+	return columns, nil
+}
+
 /**
  * GetColumnType 获取字段类型
  * @param tableName 表名
@@ -316,3 +316,77 @@ func TestUpgradeUtils_ChangeColumnType(t *testing.T) {
 		t.Errorf("字段类型不正确，期望: INTEGER, 实际: %s", columnType)
 	}
 }
+
+/**
+ * TestUpgradeUtils_RebuildTable 测试按新建表语句重建表
+ */
+func TestUpgradeUtils_RebuildTable(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test_utils.db")
+
+	dm := NewDatabaseManager()
+	err := dm.OpenDatabase(dbPath)
+	if err != nil {
+		t.Fatalf("打开数据库失败: %v", err)
+	}
+	defer dm.Close()
+
+	utils := NewUpgradeUtils(dm.GetDB())
+
+	// 创建带CHECK约束的测试表
+	_, err = dm.GetDB().Exec(`
+		CREATE TABLE test_table (
+			id INTEGER PRIMARY KEY,
+			kind TEXT NOT NULL CHECK (kind IN ('a', 'b')),
+			legacy TEXT
+		)
+	`)
+	if err != nil {
+		t.Fatalf("创建测试表失败: %v", err)
+	}
+
+	_, err = dm.GetDB().Exec("INSERT INTO test_table (id, kind, legacy) VALUES (1, 'a', 'x'), (2, 'b', 'y')")
+	if err != nil {
+		t.Fatalf("插入测试数据失败: %v", err)
+	}
+
+	// 放宽CHECK约束并删除legacy字段
+	err = utils.RebuildTable("test_table", `
+		CREATE TABLE test_table (
+			id INTEGER PRIMARY KEY,
+			kind TEXT NOT NULL CHECK (kind IN ('a', 'b', 'c'))
+		)
+	`)
+	if err != nil {
+		t.Fatalf("重建表失败: %v", err)
+	}
+
+	var count int
+	if err := dm.GetDB().QueryRow("SELECT COUNT(*) FROM test_table").Scan(&count); err != nil {
+		t.Fatalf("查询数据失败: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("数据条数不正确，期望: 2, 实际: %d", count)
+	}
+
+	if _, err := dm.GetDB().Exec("INSERT INTO test_table (id, kind) VALUES (3, 'c')"); err != nil {
+		t.Errorf("新的CHECK约束应允许kind='c': %v", err)
+	}
+
+	exists, err := utils.ColumnExists("test_table", "legacy")
+	if err != nil {
+		t.Fatalf("检查字段是否存在失败: %v", err)
+	}
+	if exists {
+		t.Error("legacy字段不应该存在")
+	}
+
+	// 旧表不应残留
+	var tables int
+	if err := dm.GetDB().QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type='table' AND name LIKE 'test_table_%'").Scan(&tables); err != nil {
+		t.Fatalf("查询表失败: %v", err)
+	}
+	if tables != 0 {
+		t.Errorf("备份表应已删除，实际残留 %d 个", tables)
+	}
+}
@@ -0,0 +1,40 @@
+package kdbx
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"golang.org/x/crypto/argon2"
+)
+
+/**
+ * Argon2测试
+ * @author 陈凤庆
+ * @date 20251021
+ * @description Argon2d使用RFC 9106的测试向量，Argon2id与golang.org/x/crypto/argon2的结果比对
+ */
+
+func TestArgon2d_RFC9106(t *testing.T) {
+	password := bytes.Repeat([]byte{0x01}, 32)
+	salt := bytes.Repeat([]byte{0x02}, 16)
+	secret := bytes.Repeat([]byte{0x03}, 8)
+	data := bytes.Repeat([]byte{0x04}, 12)
+
+	tag := argon2Key(argon2d, password, salt, secret, data, 3, 32, 4, 32)
+	expected := "512b391b6f1162975371d30919734294f868e3be3984f3c1a13a4db9fabe4acb"
+	if hex.EncodeToString(tag) != expected {
+		t.Errorf("Argon2d结果错误: %x", tag)
+	}
+}
+
+func TestArgon2id_MatchesXCrypto(t *testing.T) {
+	password, salt := []byte("Test246!Asd"), []byte("0123456789abcdef")
+	for _, threads := range []uint8{1, 2, 4} {
+		got := argon2Key(argon2id, password, salt, nil, nil, 2, 256, threads, 32)
+		want := argon2.IDKey(password, salt, 2, 256, threads, 32)
+		if !bytes.Equal(got, want) {
+			t.Errorf("并行度%d时Argon2id结果与x/crypto不一致", threads)
+		}
+	}
+}
@@ -0,0 +1,348 @@
+package kdbx
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+/**
+ * KeePass KDBX 4 数据库格式
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 读写KeePass 2.x / KeePassXC 使用的KDBX 4（4.0、4.1）文件，写入时生成KDBX 4.0：
+ *              外层加密支持AES-256-CBC和ChaCha20，密钥派生支持Argon2d、Argon2id和AES-KDF，
+ *              主密钥由密码和密钥文件组合而成。内层受保护字段使用ChaCha20流加密，附件保存在内层头部。
+ *              格式说明见 https://keepass.info/help/kb/kdbx_4.html
+ */
+
+// 错误定义
+var (
+	ErrInvalidSignature   = errors.New("不是KeePass数据库文件")
+	ErrUnsupportedVersion = errors.New("仅支持KDBX 4格式的数据库，请先用KeePass或KeePassXC另存为KDBX 4")
+	ErrInvalidCredentials = errors.New("密码或密钥文件不正确")
+	ErrCorrupted          = errors.New("数据库文件已损坏")
+)
+
+const (
+	signature1   uint32 = 0x9AA2D903
+	signature2   uint32 = 0xB54BFB67
+	majorVersion uint16 = 4
+)
+
+// 外层加密算法UUID
+var (
+	CipherAES256   = mustUUID("31c1f2e6bf714350be5805216afc5aff")
+	CipherChaCha20 = mustUUID("d6038a2b8b6f4cb5a524339a31dbb59a")
+)
+
+// 密钥派生算法UUID
+var (
+	KdfAES      = mustUUID("c9d9f39a628a4460bf740d08c18a4fea")
+	KdfArgon2d  = mustUUID("ef636ddf8c29444b91f7a9a403e30a0c")
+	KdfArgon2id = mustUUID("9e298b1956db4773b23dfc3ec6f0a1e6")
+)
+
+// 条目标准字段名
+const (
+	FieldTitle    = "Title"
+	FieldUserName = "UserName"
+	FieldPassword = "Password"
+	FieldURL      = "URL"
+	FieldNotes    = "Notes"
+)
+
+/**
+ * UUID KeePass对象标识，XML中以Base64保存
+ */
+type UUID [16]byte
+
+func mustUUID(s string) UUID {
+	var u UUID
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) != len(u) {
+		panic("kdbx: 无效的UUID " + s)
+	}
+	copy(u[:], b)
+	return u
+}
+
+/**
+ * IsZero 是否为全零UUID
+ */
+func (u UUID) IsZero() bool {
+	return u == UUID{}
+}
+
+/**
+ * String 按GUID格式输出，如 xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx
+ */
+func (u UUID) String() string {
+	return fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:16])
+}
+
+func (u UUID) MarshalText() ([]byte, error) {
+	return []byte(base64.StdEncoding.EncodeToString(u[:])), nil
+}
+
+func (u *UUID) UnmarshalText(text []byte) error {
+	s := strings.TrimSpace(string(text))
+	if s == "" {
+		*u = UUID{}
+		return nil
+	}
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil || len(b) != len(u) {
+		return fmt.Errorf("无效的UUID: %q", s)
+	}
+	copy(u[:], b)
+	return nil
+}
+
+/**
+ * Database 解密后的数据库
+ */
+type Database struct {
+	Meta     Meta
+	Root     Group    // 根分组
+	Binaries []Binary // 内层头部中的附件，条目通过序号引用
+}
+
+/**
+ * Meta 数据库元数据（只保留导入导出需要的字段）
+ */
+type Meta struct {
+	Generator           string `xml:"Generator"`
+	DatabaseName        string `xml:"DatabaseName"`
+	DatabaseDescription string `xml:"DatabaseDescription"`
+	DefaultUserName     string `xml:"DefaultUserName"`
+	RecycleBinEnabled   Bool   `xml:"RecycleBinEnabled"`
+	RecycleBinUUID      UUID   `xml:"RecycleBinUUID"`
+}
+
+/**
+ * Group 分组
+ */
+type Group struct {
+	UUID    UUID    `xml:"UUID"`
+	Name    string  `xml:"Name"`
+	Notes   string  `xml:"Notes"`
+	IconID  int     `xml:"IconID"`
+	Times   Times   `xml:"Times"`
+	Entries []Entry `xml:"Entry"`
+	Groups  []Group `xml:"Group"`
+}
+
+/**
+ * Entry 条目
+ */
+type Entry struct {
+	UUID     UUID          `xml:"UUID"`
+	IconID   int           `xml:"IconID"`
+	Tags     string        `xml:"Tags"`
+	Times    Times         `xml:"Times"`
+	Strings  []StringField `xml:"String"`
+	Binaries []BinaryRef   `xml:"Binary"`
+	History  []Entry       `xml:"History>Entry"`
+}
+
+/**
+ * Get 获取字符串字段的值，字段不存在时返回空字符串
+ * @param key 字段名
+ * @return string 字段值
+ */
+func (e Entry) Get(key string) string {
+	for _, field := range e.Strings {
+		if field.Key == key {
+			return field.Value
+		}
+	}
+	return ""
+}
+
+/**
+ * TagList 获取标签列表，KeePass使用逗号或分号分隔标签
+ * @return []string 标签
+ */
+func (e Entry) TagList() []string {
+	tags := make([]string, 0)
+	for _, tag := range strings.FieldsFunc(e.Tags, func(r rune) bool { return r == ',' || r == ';' }) {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+/**
+ * StringField 条目的字符串字段，标准字段和自定义字段都使用此结构
+ */
+type StringField struct {
+	Key       string
+	Value     string
+	Protected bool // 是否为受保护字段（内存保护/隐藏显示）
+}
+
+type xmlStringField struct {
+	Key   string `xml:"Key"`
+	Value struct {
+		Protected string `xml:"Protected,attr,omitempty"`
+		Text      string `xml:",chardata"`
+	} `xml:"Value"`
+}
+
+func (f StringField) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	var x xmlStringField
+	x.Key, x.Value.Text = f.Key, f.Value
+	if f.Protected {
+		x.Value.Protected = "True"
+	}
+	return e.EncodeElement(x, start)
+}
+
+func (f *StringField) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var x xmlStringField
+	if err := d.DecodeElement(&x, &start); err != nil {
+		return err
+	}
+	f.Key, f.Value = x.Key, x.Value.Text
+	f.Protected = strings.EqualFold(x.Value.Protected, "True")
+	return nil
+}
+
+/**
+ * BinaryRef 条目对附件的引用
+ */
+type BinaryRef struct {
+	Key   string `xml:"Key"` // 附件文件名
+	Value struct {
+		Ref int `xml:"Ref,attr"` // 附件在Database.Binaries中的序号
+	} `xml:"Value"`
+}
+
+/**
+ * Binary 附件内容
+ */
+type Binary struct {
+	Protected bool
+	Data      []byte
+}
+
+/**
+ * Attachment 获取条目引用的附件内容
+ * @param ref 附件引用
+ * @return []byte 附件内容
+ * @return bool 引用是否有效
+ */
+func (db *Database) Attachment(ref BinaryRef) ([]byte, bool) {
+	if ref.Value.Ref < 0 || ref.Value.Ref >= len(db.Binaries) {
+		return nil, false
+	}
+	return db.Binaries[ref.Value.Ref].Data, true
+}
+
+/**
+ * Times 分组和条目的时间信息
+ */
+type Times struct {
+	CreationTime         time.Time
+	LastModificationTime time.Time
+	LastAccessTime       time.Time
+	ExpiryTime           time.Time
+	Expires              bool
+	UsageCount           int
+	LocationChanged      time.Time
+}
+
+type xmlTimes struct {
+	CreationTime         Time `xml:"CreationTime"`
+	LastModificationTime Time `xml:"LastModificationTime"`
+	LastAccessTime       Time `xml:"LastAccessTime"`
+	ExpiryTime           Time `xml:"ExpiryTime"`
+	Expires              Bool `xml:"Expires"`
+	UsageCount           int  `xml:"UsageCount"`
+	LocationChanged      Time `xml:"LocationChanged"`
+}
+
+func (t Times) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return e.EncodeElement(xmlTimes{
+		CreationTime:         Time(t.CreationTime),
+		LastModificationTime: Time(t.LastModificationTime),
+		LastAccessTime:       Time(t.LastAccessTime),
+		ExpiryTime:           Time(t.ExpiryTime),
+		Expires:              Bool(t.Expires),
+		UsageCount:           t.UsageCount,
+		LocationChanged:      Time(t.LocationChanged),
+	}, start)
+}
+
+func (t *Times) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var x xmlTimes
+	if err := d.DecodeElement(&x, &start); err != nil {
+		return err
+	}
+	*t = Times{
+		CreationTime:         time.Time(x.CreationTime),
+		LastModificationTime: time.Time(x.LastModificationTime),
+		LastAccessTime:       time.Time(x.LastAccessTime),
+		ExpiryTime:           time.Time(x.ExpiryTime),
+		Expires:              bool(x.Expires),
+		UsageCount:           x.UsageCount,
+		LocationChanged:      time.Time(x.LocationChanged),
+	}
+	return nil
+}
+
+// kdbxEpochOffset 0001-01-01到1970-01-01的秒数
+const kdbxEpochOffset = 62135596800
+
+/**
+ * Time KDBX 4中的时间：自0001-01-01起的秒数（int64小端序）的Base64，读取时也兼容ISO 8601格式
+ */
+type Time time.Time
+
+func (t Time) MarshalText() ([]byte, error) {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], uint64(time.Time(t).Unix()+kdbxEpochOffset))
+	return []byte(base64.StdEncoding.EncodeToString(b[:])), nil
+}
+
+func (t *Time) UnmarshalText(text []byte) error {
+	s := strings.TrimSpace(string(text))
+	if s == "" {
+		*t = Time{}
+		return nil
+	}
+	if parsed, err := time.Parse(time.RFC3339, s); err == nil {
+		*t = Time(parsed)
+		return nil
+	}
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil || len(b) != 8 {
+		return fmt.Errorf("无效的时间: %q", s)
+	}
+	*t = Time(time.Unix(int64(binary.LittleEndian.Uint64(b))-kdbxEpochOffset, 0).UTC())
+	return nil
+}
+
+/**
+ * Bool KeePass的布尔值，写入True/False，读取时不区分大小写
+ */
+type Bool bool
+
+func (b Bool) MarshalText() ([]byte, error) {
+	if b {
+		return []byte("True"), nil
+	}
+	return []byte("False"), nil
+}
+
+func (b *Bool) UnmarshalText(text []byte) error {
+	*b = Bool(strings.EqualFold(strings.TrimSpace(string(text)), "True"))
+	return nil
+}
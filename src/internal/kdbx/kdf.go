@@ -0,0 +1,242 @@
+package kdbx
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+/**
+ * 密钥派生参数（VariantDictionary）
+ * @description KDBX 4头部中的KDF参数以VariantDictionary保存：2字节版本号，
+ *              之后每项为 类型(1字节) + 名称长度(4字节) + 名称 + 值长度(4字节) + 值，类型0表示结束
+ */
+
+const variantDictionaryVersion uint16 = 0x0100
+
+// VariantDictionary值类型
+const (
+	variantUInt32    byte = 0x04
+	variantUInt64    byte = 0x05
+	variantBool      byte = 0x08
+	variantInt32     byte = 0x0C
+	variantInt64     byte = 0x0D
+	variantString    byte = 0x18
+	variantByteArray byte = 0x42
+)
+
+// Argon2和AES-KDF参数名
+const (
+	kdfParamUUID        = "$UUID"
+	kdfParamSalt        = "S" // Argon2盐 / AES-KDF种子
+	kdfParamParallelism = "P"
+	kdfParamMemory      = "M" // 内存大小（字节）
+	kdfParamIterations  = "I"
+	kdfParamVersion     = "V"
+	kdfParamSecret      = "K"
+	kdfParamAssocData   = "A"
+	kdfParamRounds      = "R" // AES-KDF轮数
+)
+
+// 20251021 陈凤庆 KDF参数上限，防止构造的文件让打开数据库耗尽内存或长时间无响应。
+// 上限远高于KeePass和KeePassXC可设置的常用值
+const (
+	maxArgon2Memory      = 1 << 30 // Argon2内存上限（字节），1 GiB
+	maxArgon2Iterations  = 1 << 16 // Argon2迭代次数上限
+	maxArgon2Parallelism = 128     // Argon2并行度上限，与KeePassXC可设置的最大线程数相同
+	maxArgon2Cost        = 1 << 26 // 内存（KiB）与迭代次数之积的上限，相当于1 GiB内存迭代64次
+	maxAESRounds         = 1 << 28 // AES-KDF轮数上限
+)
+
+/**
+ * variantValue VariantDictionary中的一项
+ */
+type variantValue struct {
+	Type  byte
+	Value []byte
+}
+
+/**
+ * variantDictionary 保持写入顺序的VariantDictionary
+ */
+type variantDictionary struct {
+	keys   []string
+	values map[string]variantValue
+}
+
+func newVariantDictionary() *variantDictionary {
+	return &variantDictionary{values: make(map[string]variantValue)}
+}
+
+func (vd *variantDictionary) set(key string, typ byte, value []byte) {
+	if _, ok := vd.values[key]; !ok {
+		vd.keys = append(vd.keys, key)
+	}
+	vd.values[key] = variantValue{Type: typ, Value: value}
+}
+
+func (vd *variantDictionary) setUInt32(key string, v uint32) {
+	b := make([]byte, 4)
+	binary.LittleEndian.PutUint32(b, v)
+	vd.set(key, variantUInt32, b)
+}
+
+func (vd *variantDictionary) setUInt64(key string, v uint64) {
+	b := make([]byte, 8)
+	binary.LittleEndian.PutUint64(b, v)
+	vd.set(key, variantUInt64, b)
+}
+
+func (vd *variantDictionary) setBytes(key string, v []byte) {
+	vd.set(key, variantByteArray, v)
+}
+
+func (vd *variantDictionary) bytes(key string) ([]byte, bool) {
+	v, ok := vd.values[key]
+	return v.Value, ok
+}
+
+// uint 读取无符号整数参数（UInt32或UInt64）
+func (vd *variantDictionary) uint(key string) (uint64, bool) {
+	v, ok := vd.values[key]
+	if !ok {
+		return 0, false
+	}
+	switch {
+	case v.Type == variantUInt32 && len(v.Value) == 4:
+		return uint64(binary.LittleEndian.Uint32(v.Value)), true
+	case v.Type == variantUInt64 && len(v.Value) == 8:
+		return binary.LittleEndian.Uint64(v.Value), true
+	}
+	return 0, false
+}
+
+/**
+ * parseVariantDictionary 解析VariantDictionary
+ * @param data 原始数据
+ * @return *variantDictionary 参数
+ * @return error 错误信息
+ */
+func parseVariantDictionary(data []byte) (*variantDictionary, error) {
+	r := bytes.NewReader(data)
+	var version uint16
+	if err := binary.Read(r, binary.LittleEndian, &version); err != nil || version>>8 != variantDictionaryVersion>>8 {
+		return nil, fmt.Errorf("%w: 不支持的KDF参数版本", ErrCorrupted)
+	}
+
+	vd := newVariantDictionary()
+	for {
+		typ, err := r.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("%w: KDF参数不完整", ErrCorrupted)
+		}
+		if typ == 0 {
+			return vd, nil
+		}
+		key, err := readVariantField(r)
+		if err != nil {
+			return nil, err
+		}
+		value, err := readVariantField(r)
+		if err != nil {
+			return nil, err
+		}
+		vd.set(string(key), typ, value)
+	}
+}
+
+func readVariantField(r *bytes.Reader) ([]byte, error) {
+	var length int32
+	if err := binary.Read(r, binary.LittleEndian, &length); err != nil || length < 0 || int(length) > r.Len() {
+		return nil, fmt.Errorf("%w: KDF参数不完整", ErrCorrupted)
+	}
+	field := make([]byte, length)
+	io.ReadFull(r, field)
+	return field, nil
+}
+
+/**
+ * marshal 序列化VariantDictionary
+ * @return []byte 序列化结果
+ */
+func (vd *variantDictionary) marshal() []byte {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, variantDictionaryVersion)
+	for _, key := range vd.keys {
+		v := vd.values[key]
+		buf.WriteByte(v.Type)
+		binary.Write(&buf, binary.LittleEndian, int32(len(key)))
+		buf.WriteString(key)
+		binary.Write(&buf, binary.LittleEndian, int32(len(v.Value)))
+		buf.Write(v.Value)
+	}
+	buf.WriteByte(0)
+	return buf.Bytes()
+}
+
+/**
+ * transformKey 使用KDF参数派生密钥
+ * @param params KDF参数
+ * @param compositeHash 主密钥哈希
+ * @return []byte 32字节派生密钥
+ * @return error 不支持的KDF、参数不正确或超出上限时返回错误
+ */
+func transformKey(params *variantDictionary, compositeHash []byte) ([]byte, error) {
+	uuidBytes, _ := params.bytes(kdfParamUUID)
+	var kdf UUID
+	if len(uuidBytes) != len(kdf) {
+		return nil, fmt.Errorf("%w: 缺少KDF类型", ErrCorrupted)
+	}
+	copy(kdf[:], uuidBytes)
+
+	switch kdf {
+	case KdfArgon2d, KdfArgon2id:
+		salt, _ := params.bytes(kdfParamSalt)
+		parallelism, okP := params.uint(kdfParamParallelism)
+		memory, okM := params.uint(kdfParamMemory)
+		iterations, okI := params.uint(kdfParamIterations)
+		version, _ := params.uint(kdfParamVersion)
+		if !okP || !okM || !okI || parallelism == 0 || iterations == 0 || memory < 8*1024*parallelism {
+			return nil, fmt.Errorf("%w: Argon2参数不正确", ErrCorrupted)
+		}
+		if memory > maxArgon2Memory || iterations > maxArgon2Iterations || parallelism > maxArgon2Parallelism ||
+			memory/1024*iterations > maxArgon2Cost {
+			return nil, fmt.Errorf("%w: Argon2参数超出上限（内存%d字节，迭代%d次，并行度%d）", ErrCorrupted, memory, iterations, parallelism)
+		}
+		if version != argon2Version {
+			return nil, fmt.Errorf("不支持的Argon2版本: 0x%x", version)
+		}
+		secret, _ := params.bytes(kdfParamSecret)
+		assocData, _ := params.bytes(kdfParamAssocData)
+		mode := argon2d
+		if kdf == KdfArgon2id {
+			mode = argon2id
+		}
+		return argon2Key(mode, compositeHash, salt, secret, assocData, uint32(iterations), uint32(memory/1024), uint8(parallelism), 32), nil
+
+	case KdfAES:
+		seed, _ := params.bytes(kdfParamSalt)
+		rounds, ok := params.uint(kdfParamRounds)
+		if !ok || len(seed) != 32 {
+			return nil, fmt.Errorf("%w: AES-KDF参数不正确", ErrCorrupted)
+		}
+		if rounds > maxAESRounds {
+			return nil, fmt.Errorf("%w: AES-KDF轮数超出上限（%d轮）", ErrCorrupted, rounds)
+		}
+		block, err := aes.NewCipher(seed)
+		if err != nil {
+			return nil, err
+		}
+		key := append([]byte(nil), compositeHash...)
+		for i := uint64(0); i < rounds; i++ {
+			block.Encrypt(key[0:16], key[0:16])
+			block.Encrypt(key[16:32], key[16:32])
+		}
+		sum := sha256.Sum256(key)
+		return sum[:], nil
+	}
+	return nil, fmt.Errorf("不支持的密钥派生算法: %s", kdf)
+}
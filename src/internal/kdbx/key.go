@@ -0,0 +1,123 @@
+package kdbx
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+/**
+ * CompositeKey 主密钥，由密码和密钥文件组合而成
+ * @description 各组成部分先分别做SHA-256，再将结果依次拼接后做一次SHA-256
+ */
+type CompositeKey struct {
+	parts [][]byte
+}
+
+/**
+ * NewCompositeKey 创建主密钥
+ * @param password 数据库密码，为空表示不使用密码
+ * @param keyFile 密钥文件内容，为nil表示不使用密钥文件
+ * @return *CompositeKey 主密钥
+ * @return error 密码和密钥文件都未提供或密钥文件格式不正确时返回错误
+ */
+func NewCompositeKey(password string, keyFile []byte) (*CompositeKey, error) {
+	key := &CompositeKey{}
+	if password != "" {
+		sum := sha256.Sum256([]byte(password))
+		key.parts = append(key.parts, sum[:])
+	}
+	if keyFile != nil {
+		data, err := parseKeyFile(keyFile)
+		if err != nil {
+			return nil, err
+		}
+		key.parts = append(key.parts, data)
+	}
+	if len(key.parts) == 0 {
+		return nil, errors.New("请提供数据库密码或密钥文件")
+	}
+	return key, nil
+}
+
+/**
+ * hash 计算主密钥的哈希，作为密钥派生的输入
+ */
+func (k *CompositeKey) hash() []byte {
+	h := sha256.New()
+	for _, part := range k.parts {
+		h.Write(part)
+	}
+	return h.Sum(nil)
+}
+
+// xmlKeyFile KeePass的XML密钥文件（1.0和2.0版本）
+type xmlKeyFile struct {
+	XMLName xml.Name `xml:"KeyFile"`
+	Meta    struct {
+		Version string `xml:"Version"`
+	} `xml:"Meta"`
+	Key struct {
+		Data struct {
+			Hash string `xml:"Hash,attr"`
+			Text string `xml:",chardata"`
+		} `xml:"Data"`
+	} `xml:"Key"`
+}
+
+/**
+ * parseKeyFile 解析密钥文件
+ * @param data 密钥文件内容
+ * @return []byte 32字节的密钥
+ * @return error 错误信息
+ * @description 依次识别：XML密钥文件（1.0为Base64，2.0为带校验的十六进制）、32字节二进制、64个十六进制字符，
+ *              其他任意文件取SHA-256
+ */
+func parseKeyFile(data []byte) ([]byte, error) {
+	trimmed := bytes.TrimSpace(data)
+	if bytes.HasPrefix(trimmed, []byte("<?xml")) || bytes.HasPrefix(trimmed, []byte("<KeyFile")) {
+		var keyFile xmlKeyFile
+		if err := xml.Unmarshal(trimmed, &keyFile); err == nil {
+			return parseXMLKeyFile(keyFile)
+		}
+	}
+
+	if len(data) == 32 {
+		return data, nil
+	}
+	if len(data) == 64 {
+		if key, err := hex.DecodeString(string(data)); err == nil {
+			return key, nil
+		}
+	}
+	sum := sha256.Sum256(data)
+	return sum[:], nil
+}
+
+func parseXMLKeyFile(keyFile xmlKeyFile) ([]byte, error) {
+	text := strings.Join(strings.Fields(keyFile.Key.Data.Text), "")
+	if strings.HasPrefix(keyFile.Meta.Version, "2.") {
+		key, err := hex.DecodeString(text)
+		if err != nil || len(key) != 32 {
+			return nil, fmt.Errorf("密钥文件格式不正确")
+		}
+		if keyFile.Key.Data.Hash != "" {
+			sum := sha256.Sum256(key)
+			if !strings.EqualFold(hex.EncodeToString(sum[:4]), keyFile.Key.Data.Hash) {
+				return nil, fmt.Errorf("密钥文件校验失败")
+			}
+		}
+		return key, nil
+	}
+
+	key, err := base64.StdEncoding.DecodeString(text)
+	if err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("密钥文件格式不正确")
+	}
+	return key, nil
+}
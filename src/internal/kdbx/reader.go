@@ -0,0 +1,476 @@
+package kdbx
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/crypto/chacha20"
+)
+
+// 外层头部字段
+const (
+	headerEndOfHeader   byte = 0
+	headerCipherID      byte = 2
+	headerCompression   byte = 3
+	headerMasterSeed    byte = 4
+	headerEncryptionIV  byte = 7
+	headerKdfParameters byte = 11
+)
+
+// 内层头部字段
+const (
+	innerHeaderEnd       byte = 0
+	innerHeaderStreamID  byte = 1
+	innerHeaderStreamKey byte = 2
+	innerHeaderBinary    byte = 3
+	innerStreamChaCha20       = 3
+	compressionGzip           = 1
+	binaryFlagProtected  byte = 0x01
+	headerHMACBlockIndex      = ^uint64(0)
+	maxPayloadSize            = 512 << 20 // 20251021 陈凤庆 解压后内层数据的上限，防止压缩炸弹耗尽内存
+)
+
+/**
+ * header 外层头部
+ */
+type header struct {
+	cipherID     UUID
+	compression  uint32
+	masterSeed   []byte
+	encryptionIV []byte
+	kdfParams    *variantDictionary
+}
+
+/**
+ * Open 读取并解密KDBX 4数据库
+ * @param r 数据库文件内容
+ * @param key 主密钥
+ * @return *Database 解密后的数据库
+ * @return error 格式不支持、密钥错误或文件损坏时返回错误
+ */
+func Open(r io.Reader, key *CompositeKey) (*Database, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("读取数据库文件失败: %w", err)
+	}
+
+	h, headerLength, err := parseHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < headerLength+64 {
+		return nil, ErrCorrupted
+	}
+	headerData := data[:headerLength]
+	headerHash := data[headerLength : headerLength+32]
+	headerHMAC := data[headerLength+32 : headerLength+64]
+	if sum := sha256.Sum256(headerData); !hmac.Equal(sum[:], headerHash) {
+		return nil, fmt.Errorf("%w: 头部校验失败", ErrCorrupted)
+	}
+
+	encryptionKey, hmacKey, err := deriveKeys(h, key)
+	if err != nil {
+		return nil, err
+	}
+	if !hmac.Equal(computeBlockHMAC(hmacKey, headerHMACBlockIndex, headerData), headerHMAC) {
+		return nil, ErrInvalidCredentials
+	}
+
+	ciphertext, err := readHMACBlocks(data[headerLength+64:], hmacKey)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := decryptPayload(h, encryptionKey, ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	if h.compression == compressionGzip {
+		gz, err := gzip.NewReader(bytes.NewReader(payload))
+		if err != nil {
+			return nil, fmt.Errorf("%w: 解压失败", ErrCorrupted)
+		}
+		payload, err = io.ReadAll(io.LimitReader(gz, maxPayloadSize+1))
+		if err != nil {
+			return nil, fmt.Errorf("%w: 解压失败", ErrCorrupted)
+		}
+		if len(payload) > maxPayloadSize {
+			return nil, fmt.Errorf("%w: 解压后的数据超过%dMB", ErrCorrupted, maxPayloadSize>>20)
+		}
+	}
+
+	db := &Database{}
+	stream, xmlData, err := parseInnerHeader(payload, db)
+	if err != nil {
+		return nil, err
+	}
+	xmlData, err = transformProtectedValues(xmlData, stream, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var file xmlKeePassFile
+	if err := xml.Unmarshal(xmlData, &file); err != nil {
+		return nil, fmt.Errorf("%w: 解析XML失败: %v", ErrCorrupted, err)
+	}
+	db.Meta, db.Root = file.Meta, file.Root.Group
+	return db, nil
+}
+
+/**
+ * xmlKeePassFile 内层XML文档
+ */
+type xmlKeePassFile struct {
+	XMLName xml.Name `xml:"KeePassFile"`
+	Meta    Meta     `xml:"Meta"`
+	Root    struct {
+		Group Group `xml:"Group"`
+	} `xml:"Root"`
+}
+
+/**
+ * parseHeader 解析外层头部
+ * @param data 文件内容
+ * @return header 头部
+ * @return int 头部长度（含签名和版本）
+ * @return error 错误信息
+ */
+func parseHeader(data []byte) (header, int, error) {
+	var h header
+	if len(data) < 12 || binary.LittleEndian.Uint32(data[0:4]) != signature1 || binary.LittleEndian.Uint32(data[4:8]) != signature2 {
+		return h, 0, ErrInvalidSignature
+	}
+	if binary.LittleEndian.Uint16(data[10:12]) != majorVersion {
+		return h, 0, ErrUnsupportedVersion
+	}
+
+	offset := 12
+	for {
+		if offset+5 > len(data) {
+			return h, 0, fmt.Errorf("%w: 头部不完整", ErrCorrupted)
+		}
+		id := data[offset]
+		size := int(binary.LittleEndian.Uint32(data[offset+1 : offset+5]))
+		offset += 5
+		if size < 0 || offset+size > len(data) {
+			return h, 0, fmt.Errorf("%w: 头部不完整", ErrCorrupted)
+		}
+		value := data[offset : offset+size]
+		offset += size
+
+		switch id {
+		case headerEndOfHeader:
+			if h.kdfParams == nil || len(h.masterSeed) != 32 || h.encryptionIV == nil {
+				return h, 0, fmt.Errorf("%w: 头部缺少必要字段", ErrCorrupted)
+			}
+			return h, offset, nil
+		case headerCipherID:
+			if len(value) != len(h.cipherID) {
+				return h, 0, fmt.Errorf("%w: 加密算法标识不正确", ErrCorrupted)
+			}
+			copy(h.cipherID[:], value)
+		case headerCompression:
+			if len(value) != 4 {
+				return h, 0, fmt.Errorf("%w: 压缩标志不正确", ErrCorrupted)
+			}
+			h.compression = binary.LittleEndian.Uint32(value)
+		case headerMasterSeed:
+			h.masterSeed = value
+		case headerEncryptionIV:
+			h.encryptionIV = value
+		case headerKdfParameters:
+			params, err := parseVariantDictionary(value)
+			if err != nil {
+				return h, 0, err
+			}
+			h.kdfParams = params
+		}
+	}
+}
+
+/**
+ * deriveKeys 计算加密密钥和HMAC密钥
+ * @param h 外层头部
+ * @param key 主密钥
+ * @return []byte 加密密钥 SHA-256(主种子 || 派生密钥)
+ * @return []byte HMAC密钥 SHA-512(主种子 || 派生密钥 || 0x01)
+ * @return error 错误信息
+ */
+func deriveKeys(h header, key *CompositeKey) ([]byte, []byte, error) {
+	transformed, err := transformKey(h.kdfParams, key.hash())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	encryptionKey := sha256.New()
+	encryptionKey.Write(h.masterSeed)
+	encryptionKey.Write(transformed)
+
+	hmacKey := sha512.New()
+	hmacKey.Write(h.masterSeed)
+	hmacKey.Write(transformed)
+	hmacKey.Write([]byte{0x01})
+	return encryptionKey.Sum(nil), hmacKey.Sum(nil), nil
+}
+
+/**
+ * computeBlockHMAC 计算数据块的HMAC-SHA256
+ * @param hmacKey HMAC密钥
+ * @param index 块序号，头部使用0xFFFFFFFFFFFFFFFF
+ * @param data 参与计算的数据
+ * @return []byte HMAC值
+ */
+func computeBlockHMAC(hmacKey []byte, index uint64, data []byte) []byte {
+	var indexBytes [8]byte
+	binary.LittleEndian.PutUint64(indexBytes[:], index)
+	blockKey := sha512.New()
+	blockKey.Write(indexBytes[:])
+	blockKey.Write(hmacKey)
+
+	mac := hmac.New(sha256.New, blockKey.Sum(nil))
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+/**
+ * readHMACBlocks 读取并校验HMAC分块数据
+ * @param data 头部之后的数据
+ * @param hmacKey HMAC密钥
+ * @return []byte 拼接后的密文
+ * @return error 校验失败时返回错误
+ * @description 每块为 HMAC(32字节) + 长度(4字节) + 数据，长度为0的块表示结束；
+ *              HMAC计算内容为 块序号(8字节) + 长度 + 数据
+ */
+func readHMACBlocks(data, hmacKey []byte) ([]byte, error) {
+	var out bytes.Buffer
+	for index := uint64(0); ; index++ {
+		if len(data) < 36 {
+			return nil, fmt.Errorf("%w: 数据块不完整", ErrCorrupted)
+		}
+		mac, sizeBytes := data[:32], data[32:36]
+		size := int(int32(binary.LittleEndian.Uint32(sizeBytes)))
+		if size < 0 || 36+size > len(data) {
+			return nil, fmt.Errorf("%w: 数据块不完整", ErrCorrupted)
+		}
+		block := data[36 : 36+size]
+		if !hmac.Equal(blockHMAC(hmacKey, index, sizeBytes, block), mac) {
+			return nil, fmt.Errorf("%w: 第%d个数据块校验失败", ErrCorrupted, index)
+		}
+		if size == 0 {
+			return out.Bytes(), nil
+		}
+		out.Write(block)
+		data = data[36+size:]
+	}
+}
+
+// blockHMAC 计算数据块的HMAC（块序号 + 长度 + 数据）
+func blockHMAC(hmacKey []byte, index uint64, sizeBytes, block []byte) []byte {
+	var indexBytes [8]byte
+	binary.LittleEndian.PutUint64(indexBytes[:], index)
+	signed := make([]byte, 0, 12+len(block))
+	signed = append(signed, indexBytes[:]...)
+	signed = append(signed, sizeBytes...)
+	signed = append(signed, block...)
+	return computeBlockHMAC(hmacKey, index, signed)
+}
+
+/**
+ * decryptPayload 解密外层数据
+ * @param h 外层头部
+ * @param key 加密密钥
+ * @param ciphertext 密文
+ * @return []byte 明文（内层头部 + XML，可能经过gzip压缩）
+ * @return error 错误信息
+ */
+func decryptPayload(h header, key, ciphertext []byte) ([]byte, error) {
+	switch h.cipherID {
+	case CipherAES256:
+		if len(h.encryptionIV) != aes.BlockSize || len(ciphertext) == 0 || len(ciphertext)%aes.BlockSize != 0 {
+			return nil, fmt.Errorf("%w: 密文长度不正确", ErrCorrupted)
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		plaintext := make([]byte, len(ciphertext))
+		cipher.NewCBCDecrypter(block, h.encryptionIV).CryptBlocks(plaintext, ciphertext)
+		padding := int(plaintext[len(plaintext)-1])
+		if padding == 0 || padding > aes.BlockSize || padding > len(plaintext) {
+			return nil, fmt.Errorf("%w: 填充不正确", ErrCorrupted)
+		}
+		return plaintext[:len(plaintext)-padding], nil
+
+	case CipherChaCha20:
+		stream, err := chacha20.NewUnauthenticatedCipher(key, h.encryptionIV)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrCorrupted, err)
+		}
+		plaintext := make([]byte, len(ciphertext))
+		stream.XORKeyStream(plaintext, ciphertext)
+		return plaintext, nil
+	}
+	return nil, fmt.Errorf("不支持的加密算法: %s", h.cipherID)
+}
+
+/**
+ * parseInnerHeader 解析内层头部，读取附件并创建受保护字段的解密流
+ * @param payload 解密（并解压）后的数据
+ * @param db 数据库，附件写入db.Binaries
+ * @return *chacha20.Cipher 受保护字段的流密码
+ * @return []byte 内层头部之后的XML
+ * @return error 错误信息
+ */
+func parseInnerHeader(payload []byte, db *Database) (*chacha20.Cipher, []byte, error) {
+	var streamID uint32
+	var streamKey []byte
+	offset := 0
+	for {
+		if offset+5 > len(payload) {
+			return nil, nil, fmt.Errorf("%w: 内层头部不完整", ErrCorrupted)
+		}
+		id := payload[offset]
+		size := int(binary.LittleEndian.Uint32(payload[offset+1 : offset+5]))
+		offset += 5
+		if size < 0 || offset+size > len(payload) {
+			return nil, nil, fmt.Errorf("%w: 内层头部不完整", ErrCorrupted)
+		}
+		value := payload[offset : offset+size]
+		offset += size
+
+		switch id {
+		case innerHeaderEnd:
+			if streamID != innerStreamChaCha20 {
+				return nil, nil, fmt.Errorf("不支持的受保护字段加密方式: %d", streamID)
+			}
+			stream, err := newInnerStream(streamKey)
+			if err != nil {
+				return nil, nil, err
+			}
+			return stream, payload[offset:], nil
+		case innerHeaderStreamID:
+			if len(value) != 4 {
+				return nil, nil, fmt.Errorf("%w: 内层头部不正确", ErrCorrupted)
+			}
+			streamID = binary.LittleEndian.Uint32(value)
+		case innerHeaderStreamKey:
+			streamKey = value
+		case innerHeaderBinary:
+			if len(value) == 0 {
+				return nil, nil, fmt.Errorf("%w: 附件数据不正确", ErrCorrupted)
+			}
+			db.Binaries = append(db.Binaries, Binary{
+				Protected: value[0]&binaryFlagProtected != 0,
+				Data:      append([]byte(nil), value[1:]...),
+			})
+		}
+	}
+}
+
+/**
+ * newInnerStream 创建受保护字段的ChaCha20流：密钥和随机数取自内层密钥的SHA-512
+ * @param streamKey 内层密钥
+ * @return *chacha20.Cipher 流密码
+ * @return error 错误信息
+ */
+func newInnerStream(streamKey []byte) (*chacha20.Cipher, error) {
+	sum := sha512.Sum512(streamKey)
+	return chacha20.NewUnauthenticatedCipher(sum[:32], sum[32:44])
+}
+
+/**
+ * transformProtectedValues 按文档顺序加密或解密所有Protected="True"的Value
+ * @param xmlData XML文档
+ * @param stream 受保护字段的流密码，所有受保护值共用同一个密钥流
+ * @param protect true为加密（明文 -> Base64密文），false为解密（Base64密文 -> 明文）
+ * @return []byte 转换后的XML
+ * @return error 错误信息
+ */
+func transformProtectedValues(xmlData []byte, stream *chacha20.Cipher, protect bool) ([]byte, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(xmlData))
+	var out bytes.Buffer
+	encoder := xml.NewEncoder(&out)
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: 解析XML失败: %v", ErrCorrupted, err)
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "Value" || !isProtected(start) {
+			if _, isProcInst := token.(xml.ProcInst); isProcInst {
+				continue
+			}
+			if err := encoder.EncodeToken(xml.CopyToken(token)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		var text strings.Builder
+		for {
+			inner, err := decoder.Token()
+			if err != nil {
+				return nil, fmt.Errorf("%w: 解析XML失败: %v", ErrCorrupted, err)
+			}
+			if data, ok := inner.(xml.CharData); ok {
+				text.Write(data)
+				continue
+			}
+			if _, ok := inner.(xml.EndElement); ok {
+				break
+			}
+		}
+
+		value, err := transformValue(text.String(), stream, protect)
+		if err != nil {
+			return nil, err
+		}
+		if err := encoder.EncodeElement(value, start.Copy()); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := encoder.Flush(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+func isProtected(start xml.StartElement) bool {
+	for _, attr := range start.Attr {
+		if attr.Name.Local == "Protected" && strings.EqualFold(attr.Value, "True") {
+			return true
+		}
+	}
+	return false
+}
+
+func transformValue(text string, stream *chacha20.Cipher, protect bool) (string, error) {
+	if protect {
+		data := []byte(text)
+		stream.XORKeyStream(data, data)
+		return base64.StdEncoding.EncodeToString(data), nil
+	}
+
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(text))
+	if err != nil {
+		return "", fmt.Errorf("%w: 受保护字段格式不正确", ErrCorrupted)
+	}
+	stream.XORKeyStream(data, data)
+	return string(data), nil
+}
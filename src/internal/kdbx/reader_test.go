@@ -0,0 +1,370 @@
+package kdbx
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/chacha20"
+)
+
+/**
+ * KDBX读取测试
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 测试用编码器按KDBX 4格式构造数据库文件，覆盖AES/ChaCha20、Argon2d/Argon2id/AES-KDF、
+ *              密钥文件、受保护字段、附件以及错误密码
+ */
+
+const testXML = `<?xml version="1.0" encoding="utf-8" standalone="yes"?>
+<KeePassFile>
+	<Meta>
+		<Generator>KeePassXC</Generator>
+		<DatabaseName>测试库</DatabaseName>
+		<RecycleBinEnabled>True</RecycleBinEnabled>
+		<RecycleBinUUID>AAAAAAAAAAAAAAAAAAAAAA==</RecycleBinUUID>
+	</Meta>
+	<Root>
+		<Group>
+			<UUID>AQIDBAUGBwgJCgsMDQ4PEA==</UUID>
+			<Name>Root</Name>
+			<Group>
+				<UUID>EQIDBAUGBwgJCgsMDQ4PEA==</UUID>
+				<Name>工作</Name>
+				<Entry>
+					<UUID>IQIDBAUGBwgJCgsMDQ4PEA==</UUID>
+					<Tags>favorite;work</Tags>
+					<Times>
+						<CreationTime>AJbJ3A4AAAA=</CreationTime>
+						<ExpiryTime>2030-01-02T03:04:05Z</ExpiryTime>
+						<Expires>True</Expires>
+						<UsageCount>7</UsageCount>
+					</Times>
+					<String><Key>Title</Key><Value>邮箱</Value></String>
+					<String><Key>UserName</Key><Value>alice</Value></String>
+					<String><Key>Password</Key><Value Protected="True">s3cr3t&amp;密码</Value></String>
+					<String><Key>Empty</Key><Value Protected="True"></Value></String>
+					<String><Key>PIN</Key><Value Protected="True">1234</Value></String>
+					<Binary><Key>readme.txt</Key><Value Ref="0"/></Binary>
+					<History>
+						<Entry>
+							<UUID>IQIDBAUGBwgJCgsMDQ4PEA==</UUID>
+							<String><Key>Password</Key><Value Protected="True">old-password</Value></String>
+						</Entry>
+					</History>
+				</Entry>
+			</Group>
+		</Group>
+	</Root>
+</KeePassFile>`
+
+/**
+ * testEncodeOptions 测试用编码参数
+ */
+type testEncodeOptions struct {
+	cipherID UUID
+	kdf      UUID
+	compress bool
+	binaries []Binary
+}
+
+/**
+ * encodeTestDatabase 测试用编码器：将XML（受保护值为明文）加密为KDBX 4文件
+ */
+func encodeTestDatabase(t *testing.T, xmlData string, key *CompositeKey, opts testEncodeOptions) []byte {
+	t.Helper()
+
+	params := newVariantDictionary()
+	params.setBytes(kdfParamUUID, opts.kdf[:])
+	if opts.kdf == KdfAES {
+		params.setBytes(kdfParamSalt, bytes.Repeat([]byte{0x05}, 32))
+		params.setUInt64(kdfParamRounds, 100)
+	} else {
+		params.setBytes(kdfParamSalt, bytes.Repeat([]byte{0x05}, 32))
+		params.setUInt32(kdfParamParallelism, 2)
+		params.setUInt64(kdfParamMemory, 64*1024)
+		params.setUInt64(kdfParamIterations, 2)
+		params.setUInt32(kdfParamVersion, argon2Version)
+	}
+
+	iv := bytes.Repeat([]byte{0x07}, 16)
+	if opts.cipherID == CipherChaCha20 {
+		iv = iv[:12]
+	}
+	h := header{
+		cipherID:     opts.cipherID,
+		masterSeed:   bytes.Repeat([]byte{0x04}, 32),
+		encryptionIV: iv,
+		kdfParams:    params,
+	}
+	if opts.compress {
+		h.compression = compressionGzip
+	}
+
+	var headerBuf bytes.Buffer
+	binary.Write(&headerBuf, binary.LittleEndian, signature1)
+	binary.Write(&headerBuf, binary.LittleEndian, signature2)
+	binary.Write(&headerBuf, binary.LittleEndian, uint16(1))
+	binary.Write(&headerBuf, binary.LittleEndian, majorVersion)
+	compression := make([]byte, 4)
+	binary.LittleEndian.PutUint32(compression, h.compression)
+	writeTestField(&headerBuf, headerCipherID, h.cipherID[:])
+	writeTestField(&headerBuf, headerCompression, compression)
+	writeTestField(&headerBuf, headerMasterSeed, h.masterSeed)
+	writeTestField(&headerBuf, headerEncryptionIV, h.encryptionIV)
+	writeTestField(&headerBuf, headerKdfParameters, params.marshal())
+	writeTestField(&headerBuf, headerEndOfHeader, []byte{0x0D, 0x0A, 0x0D, 0x0A})
+	headerData := headerBuf.Bytes()
+
+	encryptionKey, hmacKey, err := deriveKeys(h, key)
+	if err != nil {
+		t.Fatalf("派生密钥失败: %v", err)
+	}
+
+	// 内层头部 + 受保护字段加密后的XML
+	streamKey := bytes.Repeat([]byte{0x09}, 64)
+	stream, err := newInnerStream(streamKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	protectedXML, err := transformProtectedValues([]byte(xmlData), stream, true)
+	if err != nil {
+		t.Fatalf("加密受保护字段失败: %v", err)
+	}
+	var inner bytes.Buffer
+	streamID := make([]byte, 4)
+	binary.LittleEndian.PutUint32(streamID, innerStreamChaCha20)
+	writeTestField(&inner, innerHeaderStreamID, streamID)
+	writeTestField(&inner, innerHeaderStreamKey, streamKey)
+	for _, b := range opts.binaries {
+		flag := byte(0)
+		if b.Protected {
+			flag = binaryFlagProtected
+		}
+		writeTestField(&inner, innerHeaderBinary, append([]byte{flag}, b.Data...))
+	}
+	writeTestField(&inner, innerHeaderEnd, nil)
+	inner.Write(protectedXML)
+
+	payload := inner.Bytes()
+	if opts.compress {
+		var gz bytes.Buffer
+		w := gzip.NewWriter(&gz)
+		w.Write(payload)
+		w.Close()
+		payload = gz.Bytes()
+	}
+
+	var ciphertext []byte
+	if opts.cipherID == CipherAES256 {
+		padding := aes.BlockSize - len(payload)%aes.BlockSize
+		payload = append(payload, bytes.Repeat([]byte{byte(padding)}, padding)...)
+		block, _ := aes.NewCipher(encryptionKey)
+		ciphertext = make([]byte, len(payload))
+		cipher.NewCBCEncrypter(block, h.encryptionIV).CryptBlocks(ciphertext, payload)
+	} else {
+		c, _ := chacha20.NewUnauthenticatedCipher(encryptionKey, h.encryptionIV)
+		ciphertext = make([]byte, len(payload))
+		c.XORKeyStream(ciphertext, payload)
+	}
+
+	var out bytes.Buffer
+	out.Write(headerData)
+	headerHash := sha256.Sum256(headerData)
+	out.Write(headerHash[:])
+	out.Write(computeBlockHMAC(hmacKey, headerHMACBlockIndex, headerData))
+
+	// 拆成多个数据块以覆盖块序号
+	blocks := [][]byte{ciphertext[:len(ciphertext)/2], ciphertext[len(ciphertext)/2:], nil}
+	for i, block := range blocks {
+		size := make([]byte, 4)
+		binary.LittleEndian.PutUint32(size, uint32(len(block)))
+		out.Write(blockHMAC(hmacKey, uint64(i), size, block))
+		out.Write(size)
+		out.Write(block)
+	}
+	return out.Bytes()
+}
+
+func writeTestField(buf *bytes.Buffer, id byte, value []byte) {
+	buf.WriteByte(id)
+	binary.Write(buf, binary.LittleEndian, uint32(len(value)))
+	buf.Write(value)
+}
+
+func TestOpen_CiphersAndKdfs(t *testing.T) {
+	key, err := NewCompositeKey("correct horse", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name string
+		opts testEncodeOptions
+	}{
+		{"AES+Argon2d+gzip", testEncodeOptions{cipherID: CipherAES256, kdf: KdfArgon2d, compress: true}},
+		{"ChaCha20+Argon2id+无压缩", testEncodeOptions{cipherID: CipherChaCha20, kdf: KdfArgon2id}},
+		{"AES+AES-KDF", testEncodeOptions{cipherID: CipherAES256, kdf: KdfAES}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.opts.binaries = []Binary{{Protected: true, Data: []byte("附件内容")}}
+			data := encodeTestDatabase(t, testXML, key, tc.opts)
+
+			db, err := Open(bytes.NewReader(data), key)
+			if err != nil {
+				t.Fatalf("打开数据库失败: %v", err)
+			}
+			if db.Meta.DatabaseName != "测试库" || !bool(db.Meta.RecycleBinEnabled) {
+				t.Errorf("元数据错误: %+v", db.Meta)
+			}
+			if len(db.Root.Groups) != 1 || db.Root.Groups[0].Name != "工作" {
+				t.Fatalf("分组解析错误: %+v", db.Root)
+			}
+
+			entry := db.Root.Groups[0].Entries[0]
+			if entry.UUID.String() != "21020304-0506-0708-090a-0b0c0d0e0f10" {
+				t.Errorf("UUID错误: %s", entry.UUID)
+			}
+			if entry.Get(FieldTitle) != "邮箱" || entry.Get(FieldUserName) != "alice" {
+				t.Errorf("标准字段错误: %+v", entry.Strings)
+			}
+			if entry.Get(FieldPassword) != "s3cr3t&密码" || entry.Get("PIN") != "1234" || entry.Get("Empty") != "" {
+				t.Errorf("受保护字段解密错误: %+v", entry.Strings)
+			}
+			if len(entry.History) != 1 || entry.History[0].Get(FieldPassword) != "old-password" {
+				t.Errorf("历史记录错误: %+v", entry.History)
+			}
+			if tags := entry.TagList(); len(tags) != 2 || tags[0] != "favorite" {
+				t.Errorf("标签错误: %v", tags)
+			}
+
+			times := entry.Times
+			if !times.Expires || times.UsageCount != 7 || !times.ExpiryTime.Equal(time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)) {
+				t.Errorf("时间信息错误: %+v", times)
+			}
+			if times.CreationTime.IsZero() {
+				t.Error("创建时间未解析")
+			}
+
+			attachment, ok := db.Attachment(entry.Binaries[0])
+			if !ok || string(attachment) != "附件内容" || entry.Binaries[0].Key != "readme.txt" {
+				t.Errorf("附件错误: %q", attachment)
+			}
+		})
+	}
+}
+
+func TestOpen_KeyFile(t *testing.T) {
+	keyFile := []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<KeyFile>
+	<Meta><Version>2.0</Version></Meta>
+	<Key>
+		<Data Hash="EEF1BE10">
+			A2C4E6F8 0A1C3E5F 7091B2D3 F4051627
+			38495A6B 7C8D9EAF B0C1D2E3 F4A5B6C7
+		</Data>
+	</Key>
+</KeyFile>`)
+	key, err := NewCompositeKey("pw", keyFile)
+	if err != nil {
+		t.Fatalf("解析密钥文件失败: %v", err)
+	}
+	data := encodeTestDatabase(t, testXML, key, testEncodeOptions{cipherID: CipherAES256, kdf: KdfAES})
+
+	if _, err := Open(bytes.NewReader(data), key); err != nil {
+		t.Fatalf("使用密钥文件打开失败: %v", err)
+	}
+
+	passwordOnly, _ := NewCompositeKey("pw", nil)
+	if _, err := Open(bytes.NewReader(data), passwordOnly); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("缺少密钥文件时应返回ErrInvalidCredentials，实际: %v", err)
+	}
+}
+
+func TestNewCompositeKey_KeyFileFormats(t *testing.T) {
+	raw := bytes.Repeat([]byte{0xAB}, 32)
+	hexKey := []byte(strings.Repeat("ab", 32))
+	a, _ := NewCompositeKey("", raw)
+	b, _ := NewCompositeKey("", hexKey)
+	if !bytes.Equal(a.hash(), b.hash()) {
+		t.Error("32字节二进制和64位十六进制密钥文件应得到相同的密钥")
+	}
+
+	if _, err := NewCompositeKey("", nil); err == nil {
+		t.Error("未提供密码和密钥文件时应返回错误")
+	}
+	badHash := []byte(`<KeyFile><Meta><Version>2.0</Version></Meta><Key><Data Hash="00000000">` + strings.Repeat("ab", 32) + `</Data></Key></KeyFile>`)
+	if _, err := NewCompositeKey("", badHash); err == nil {
+		t.Error("密钥文件校验值错误时应返回错误")
+	}
+}
+
+func TestOpen_Errors(t *testing.T) {
+	key, _ := NewCompositeKey("right", nil)
+	data := encodeTestDatabase(t, testXML, key, testEncodeOptions{cipherID: CipherChaCha20, kdf: KdfAES})
+
+	wrong, _ := NewCompositeKey("wrong", nil)
+	if _, err := Open(bytes.NewReader(data), wrong); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("错误密码应返回ErrInvalidCredentials，实际: %v", err)
+	}
+
+	if _, err := Open(strings.NewReader("not a database"), key); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("非数据库文件应返回ErrInvalidSignature，实际: %v", err)
+	}
+
+	kdbx3 := append([]byte(nil), data...)
+	binary.LittleEndian.PutUint16(kdbx3[10:12], 3)
+	if _, err := Open(bytes.NewReader(kdbx3), key); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("KDBX 3应返回ErrUnsupportedVersion，实际: %v", err)
+	}
+
+	tampered := append([]byte(nil), data...)
+	tampered[len(tampered)-50] ^= 0xFF
+	if _, err := Open(bytes.NewReader(tampered), key); !errors.Is(err, ErrCorrupted) {
+		t.Errorf("数据被篡改应返回ErrCorrupted，实际: %v", err)
+	}
+}
+
+func TestTransformKey_Limits(t *testing.T) {
+	argon2 := func(memory, iterations, parallelism uint64) *variantDictionary {
+		params := newVariantDictionary()
+		params.setBytes(kdfParamUUID, KdfArgon2id[:])
+		params.setBytes(kdfParamSalt, make([]byte, 32))
+		params.setUInt32(kdfParamParallelism, uint32(parallelism))
+		params.setUInt64(kdfParamMemory, memory)
+		params.setUInt64(kdfParamIterations, iterations)
+		params.setUInt32(kdfParamVersion, argon2Version)
+		return params
+	}
+	aesKdf := newVariantDictionary()
+	aesKdf.setBytes(kdfParamUUID, KdfAES[:])
+	aesKdf.setBytes(kdfParamSalt, make([]byte, 32))
+	aesKdf.setUInt64(kdfParamRounds, maxAESRounds+1)
+
+	cases := map[string]*variantDictionary{
+		"内存超过uint32":  argon2(1<<42, 1, 1),
+		"内存超出上限":      argon2(maxArgon2Memory+1024, 1, 1),
+		"迭代次数超出上限":    argon2(64*1024, maxArgon2Iterations+1, 1),
+		"内存与迭代之积超限":   argon2(maxArgon2Memory, 65, 1),
+		"并行度超出上限":     argon2(1<<20, 1, maxArgon2Parallelism+1),
+		"内存少于并行度要求":   argon2(8*1024, 1, 2),
+		"AES-KDF轮数超限": aesKdf,
+	}
+	for name, params := range cases {
+		if _, err := transformKey(params, make([]byte, 32)); !errors.Is(err, ErrCorrupted) {
+			t.Errorf("%s: 应返回ErrCorrupted，实际: %v", name, err)
+		}
+	}
+
+	if _, err := transformKey(argon2(64*1024, 2, 2), make([]byte, 32)); err != nil {
+		t.Errorf("正常参数不应返回错误: %v", err)
+	}
+}
@@ -0,0 +1,289 @@
+package kdbx
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/xml"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/chacha20"
+)
+
+const (
+	minorVersion  uint16 = 0       // 写入KDBX 4.0，KeePass 2.35+和KeePassXC 2.3+均可读取
+	hmacBlockSize        = 1 << 20 // 数据块大小1MB，与KeePass一致
+)
+
+/**
+ * WriteOptions 写入参数
+ */
+type WriteOptions struct {
+	Cipher      UUID   // 外层加密算法，默认AES-256
+	Kdf         UUID   // 密钥派生算法，默认Argon2d
+	Iterations  uint64 // Argon2迭代次数或AES-KDF轮数
+	Memory      uint64 // Argon2内存大小（字节）
+	Parallelism uint32 // Argon2并行度
+}
+
+/**
+ * DefaultWriteOptions 默认写入参数：AES-256 + Argon2d（64MB内存、2次迭代、2个并行度）
+ * @return WriteOptions 写入参数
+ */
+func DefaultWriteOptions() WriteOptions {
+	return WriteOptions{
+		Cipher:      CipherAES256,
+		Kdf:         KdfArgon2d,
+		Iterations:  2,
+		Memory:      64 * 1024 * 1024,
+		Parallelism: 2,
+	}
+}
+
+/**
+ * Write 加密并写入KDBX 4数据库
+ * @param w 输出
+ * @param db 数据库，受保护字段以明文提供
+ * @param key 主密钥
+ * @param opts 写入参数，未设置的字段使用默认值
+ * @return error 错误信息
+ */
+func Write(w io.Writer, db *Database, key *CompositeKey, opts WriteOptions) error {
+	var file xmlKeePassFile
+	file.Meta, file.Root.Group = db.Meta, db.Root
+	xmlData, err := xml.MarshalIndent(file, "", "\t")
+	if err != nil {
+		return fmt.Errorf("生成XML失败: %w", err)
+	}
+
+	data, err := encrypt(xmlData, db.Binaries, key, opts)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("写入数据库文件失败: %w", err)
+	}
+	return nil
+}
+
+/**
+ * encrypt 将XML文档加密为KDBX 4文件
+ * @param xmlData XML文档，受保护字段为明文
+ * @param binaries 附件，写入内层头部
+ * @param key 主密钥
+ * @param opts 写入参数
+ * @return []byte 文件内容
+ * @return error 错误信息
+ */
+func encrypt(xmlData []byte, binaries []Binary, key *CompositeKey, opts WriteOptions) ([]byte, error) {
+	opts = withDefaults(opts)
+
+	h := header{
+		cipherID:    opts.Cipher,
+		compression: compressionGzip,
+		masterSeed:  randomBytes(32),
+		kdfParams:   newVariantDictionary(),
+	}
+	switch opts.Cipher {
+	case CipherAES256:
+		h.encryptionIV = randomBytes(aes.BlockSize)
+	case CipherChaCha20:
+		h.encryptionIV = randomBytes(chacha20.NonceSize)
+	default:
+		return nil, fmt.Errorf("不支持的加密算法: %s", opts.Cipher)
+	}
+
+	h.kdfParams.setBytes(kdfParamUUID, opts.Kdf[:])
+	switch opts.Kdf {
+	case KdfArgon2d, KdfArgon2id:
+		h.kdfParams.setBytes(kdfParamSalt, randomBytes(32))
+		h.kdfParams.setUInt32(kdfParamParallelism, opts.Parallelism)
+		h.kdfParams.setUInt64(kdfParamMemory, opts.Memory)
+		h.kdfParams.setUInt64(kdfParamIterations, opts.Iterations)
+		h.kdfParams.setUInt32(kdfParamVersion, argon2Version)
+	case KdfAES:
+		h.kdfParams.setBytes(kdfParamSalt, randomBytes(32))
+		h.kdfParams.setUInt64(kdfParamRounds, opts.Iterations)
+	default:
+		return nil, fmt.Errorf("不支持的密钥派生算法: %s", opts.Kdf)
+	}
+
+	headerData := marshalHeader(h)
+	encryptionKey, hmacKey, err := deriveKeys(h, key)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := marshalPayload(xmlData, binaries)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := encryptPayload(h, encryptionKey, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	out.Write(headerData)
+	headerHash := sha256.Sum256(headerData)
+	out.Write(headerHash[:])
+	out.Write(computeBlockHMAC(hmacKey, headerHMACBlockIndex, headerData))
+	writeHMACBlocks(&out, ciphertext, hmacKey)
+	return out.Bytes(), nil
+}
+
+func withDefaults(opts WriteOptions) WriteOptions {
+	defaults := DefaultWriteOptions()
+	if opts.Cipher.IsZero() {
+		opts.Cipher = defaults.Cipher
+	}
+	if opts.Kdf.IsZero() {
+		opts.Kdf = defaults.Kdf
+	}
+	if opts.Iterations == 0 {
+		opts.Iterations = defaults.Iterations
+		if opts.Kdf == KdfAES {
+			opts.Iterations = 100000
+		}
+	}
+	if opts.Memory == 0 {
+		opts.Memory = defaults.Memory
+	}
+	if opts.Parallelism == 0 {
+		opts.Parallelism = defaults.Parallelism
+	}
+	return opts
+}
+
+func randomBytes(n int) []byte {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic("kdbx: 生成随机数失败: " + err.Error())
+	}
+	return b
+}
+
+/**
+ * marshalHeader 序列化外层头部（含签名和版本）
+ */
+func marshalHeader(h header) []byte {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, signature1)
+	binary.Write(&buf, binary.LittleEndian, signature2)
+	binary.Write(&buf, binary.LittleEndian, minorVersion)
+	binary.Write(&buf, binary.LittleEndian, majorVersion)
+
+	compression := make([]byte, 4)
+	binary.LittleEndian.PutUint32(compression, h.compression)
+	writeHeaderField(&buf, headerCipherID, h.cipherID[:])
+	writeHeaderField(&buf, headerCompression, compression)
+	writeHeaderField(&buf, headerMasterSeed, h.masterSeed)
+	writeHeaderField(&buf, headerEncryptionIV, h.encryptionIV)
+	writeHeaderField(&buf, headerKdfParameters, h.kdfParams.marshal())
+	writeHeaderField(&buf, headerEndOfHeader, []byte{'\r', '\n', '\r', '\n'})
+	return buf.Bytes()
+}
+
+func writeHeaderField(buf *bytes.Buffer, id byte, value []byte) {
+	buf.WriteByte(id)
+	binary.Write(buf, binary.LittleEndian, uint32(len(value)))
+	buf.Write(value)
+}
+
+/**
+ * marshalPayload 生成内层头部 + XML并压缩
+ * @param xmlData XML文档，受保护字段为明文
+ * @param binaries 附件
+ * @return []byte gzip压缩后的数据
+ * @return error 错误信息
+ */
+func marshalPayload(xmlData []byte, binaries []Binary) ([]byte, error) {
+	streamKey := randomBytes(64)
+	stream, err := newInnerStream(streamKey)
+	if err != nil {
+		return nil, err
+	}
+	protected, err := transformProtectedValues(xmlData, stream, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var inner bytes.Buffer
+	streamID := make([]byte, 4)
+	binary.LittleEndian.PutUint32(streamID, innerStreamChaCha20)
+	writeHeaderField(&inner, innerHeaderStreamID, streamID)
+	writeHeaderField(&inner, innerHeaderStreamKey, streamKey)
+	for _, b := range binaries {
+		flag := byte(0)
+		if b.Protected {
+			flag = binaryFlagProtected
+		}
+		writeHeaderField(&inner, innerHeaderBinary, append([]byte{flag}, b.Data...))
+	}
+	writeHeaderField(&inner, innerHeaderEnd, nil)
+	inner.WriteString(xml.Header)
+	inner.Write(protected)
+
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	if _, err := gz.Write(inner.Bytes()); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return compressed.Bytes(), nil
+}
+
+/**
+ * encryptPayload 加密外层数据（AES-256-CBC使用PKCS#7填充）
+ */
+func encryptPayload(h header, key, payload []byte) ([]byte, error) {
+	if h.cipherID == CipherChaCha20 {
+		stream, err := chacha20.NewUnauthenticatedCipher(key, h.encryptionIV)
+		if err != nil {
+			return nil, err
+		}
+		ciphertext := make([]byte, len(payload))
+		stream.XORKeyStream(ciphertext, payload)
+		return ciphertext, nil
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	padding := aes.BlockSize - len(payload)%aes.BlockSize
+	padded := append(append([]byte(nil), payload...), bytes.Repeat([]byte{byte(padding)}, padding)...)
+	ciphertext := make([]byte, len(padded))
+	cipher.NewCBCEncrypter(block, h.encryptionIV).CryptBlocks(ciphertext, padded)
+	return ciphertext, nil
+}
+
+/**
+ * writeHMACBlocks 按1MB分块写入密文，最后写入长度为0的结束块
+ */
+func writeHMACBlocks(out *bytes.Buffer, ciphertext, hmacKey []byte) {
+	for index := uint64(0); ; index++ {
+		n := len(ciphertext)
+		if n > hmacBlockSize {
+			n = hmacBlockSize
+		}
+		block := ciphertext[:n]
+		ciphertext = ciphertext[n:]
+
+		size := make([]byte, 4)
+		binary.LittleEndian.PutUint32(size, uint32(n))
+		out.Write(blockHMAC(hmacKey, index, size, block))
+		out.Write(size)
+		out.Write(block)
+		if n == 0 {
+			return
+		}
+	}
+}
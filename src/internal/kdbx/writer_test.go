@@ -0,0 +1,116 @@
+package kdbx
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+/**
+ * KDBX写入测试
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 写入后再读取，检查分组、条目、受保护字段、附件、历史记录和时间信息是否完整保留
+ */
+
+func TestWrite_RoundTrip(t *testing.T) {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	modified := created.Add(48 * time.Hour)
+
+	entry := Entry{
+		UUID: mustUUID("0102030405060708090a0b0c0d0e0f10"),
+		Tags: "favorite",
+		Times: Times{
+			CreationTime:         created,
+			LastModificationTime: modified,
+			LastAccessTime:       modified,
+			ExpiryTime:           modified.AddDate(1, 0, 0),
+			Expires:              true,
+			UsageCount:           3,
+		},
+		Strings: []StringField{
+			{Key: FieldTitle, Value: "邮箱"},
+			{Key: FieldUserName, Value: "alice"},
+			{Key: FieldPassword, Value: "<p&ss>\"密码\"", Protected: true},
+			{Key: FieldURL, Value: "https://mail.example.com"},
+			{Key: FieldNotes, Value: "第一行\n第二行"},
+		},
+		Binaries: []BinaryRef{{Key: "cert.pem"}},
+		History: []Entry{{
+			UUID:    mustUUID("0102030405060708090a0b0c0d0e0f10"),
+			Times:   Times{LastModificationTime: created},
+			Strings: []StringField{{Key: FieldPassword, Value: "old", Protected: true}},
+		}},
+	}
+	db := &Database{
+		Meta: Meta{Generator: "WePass", DatabaseName: "导出"},
+		Root: Group{
+			UUID:   mustUUID("11111111111111111111111111111111"),
+			Name:   "WePass",
+			Groups: []Group{{UUID: mustUUID("22222222222222222222222222222222"), Name: "工作", Entries: []Entry{entry}}},
+		},
+		Binaries: []Binary{{Data: bytes.Repeat([]byte("证书"), 1000)}},
+	}
+
+	key, _ := NewCompositeKey("export-password", nil)
+	for _, opts := range []WriteOptions{
+		{Memory: 64 * 1024},
+		{Cipher: CipherChaCha20, Kdf: KdfArgon2id, Memory: 64 * 1024},
+		{Kdf: KdfAES, Iterations: 100},
+	} {
+		var buf bytes.Buffer
+		if err := Write(&buf, db, key, opts); err != nil {
+			t.Fatalf("写入失败: %v", err)
+		}
+
+		read, err := Open(bytes.NewReader(buf.Bytes()), key)
+		if err != nil {
+			t.Fatalf("读取写入的数据库失败: %v", err)
+		}
+		if read.Meta.DatabaseName != "导出" || read.Root.UUID != db.Root.UUID || len(read.Root.Groups) != 1 {
+			t.Fatalf("数据库结构错误: %+v", read)
+		}
+
+		got := read.Root.Groups[0].Entries[0]
+		for _, field := range entry.Strings {
+			if got.Get(field.Key) != field.Value {
+				t.Errorf("字段%s错误: %q", field.Key, got.Get(field.Key))
+			}
+		}
+		if got.UUID != entry.UUID || got.Tags != "favorite" {
+			t.Errorf("UUID或标签错误: %s %q", got.UUID, got.Tags)
+		}
+		if !got.Times.CreationTime.Equal(created) || !got.Times.LastModificationTime.Equal(modified) || !got.Times.Expires || got.Times.UsageCount != 3 {
+			t.Errorf("时间信息错误: %+v", got.Times)
+		}
+		if len(got.History) != 1 || got.History[0].Get(FieldPassword) != "old" {
+			t.Errorf("历史记录错误: %+v", got.History)
+		}
+		if data, ok := read.Attachment(got.Binaries[0]); !ok || !bytes.Equal(data, db.Binaries[0].Data) {
+			t.Error("附件内容错误")
+		}
+
+		wrong, _ := NewCompositeKey("wrong", nil)
+		if _, err := Open(bytes.NewReader(buf.Bytes()), wrong); !errors.Is(err, ErrInvalidCredentials) {
+			t.Errorf("错误密码应返回ErrInvalidCredentials，实际: %v", err)
+		}
+	}
+}
+
+func TestWrite_UsesRandomSeeds(t *testing.T) {
+	db := &Database{Root: Group{UUID: mustUUID("11111111111111111111111111111111"), Name: "Root"}}
+	key, _ := NewCompositeKey("pw", nil)
+
+	var a, b bytes.Buffer
+	opts := WriteOptions{Kdf: KdfAES, Iterations: 10}
+	if err := Write(&a, db, key, opts); err != nil {
+		t.Fatal(err)
+	}
+	if err := Write(&b, db, key, opts); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(a.Bytes(), b.Bytes()) {
+		t.Error("两次写入的结果不应相同（主种子、IV和盐应随机生成）")
+	}
+}
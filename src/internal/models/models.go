@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 )
 
@@ -14,14 +15,33 @@ import (
  * Group 分组模型
  * @modify 20251002 陈凤庆 ID字段改为string类型，避免Wails传输时JavaScript精度丢失
  * @modify 20251002 陈凤庆 删除parent_id字段，不需要层级结构
+ * @modify 20251020 陈凤庆 恢复ParentID字段，支持任意层级的嵌套分组
+ * @modify 20251021 陈凤庆 添加PasswordRuleID字段，绑定分组的默认密码规则
  */
 type Group struct {
-	ID        string    `json:"id" db:"id"`
-	Name      string    `json:"name" db:"name"`
-	Icon      string    `json:"icon" db:"icon"`
-	SortOrder int       `json:"sort_order" db:"sort_order"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	ID             string    `json:"id" db:"id"`
+	Name           string    `json:"name" db:"name"`
+	Icon           string    `json:"icon" db:"icon"`
+	ParentID       string    `json:"parent_id" db:"parent_id"`               // 父分组ID，空字符串表示顶级分组
+	PasswordRuleID string    `json:"password_rule_id" db:"password_rule_id"` // 默认密码规则ID，空字符串表示未绑定
+	SortOrder      int       `json:"sort_order" db:"sort_order"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
+}
+
+/**
+ * GroupTreeNode 分组树节点
+ * @author 陈凤庆
+ * @date 20251020
+ * @description 用于前端展示嵌套分组，包含本级账号数和含全部子分组的递归账号数
+ */
+type GroupTreeNode struct {
+	Group
+	Path              string          `json:"path"`                // 完整路径，如 客户/ACME/生产
+	Depth             int             `json:"depth"`               // 层级深度，顶级为0
+	AccountCount      int             `json:"account_count"`       // 本分组直属账号数
+	TotalAccountCount int             `json:"total_account_count"` // 含全部子分组的账号数
+	Children          []GroupTreeNode `json:"children"`            // 子分组
 }
 
 /**
@@ -101,16 +121,18 @@ type VaultConfig struct {
  * Type 类型模型（原Tab）
  * @modify 20251001 陈凤庆 ID字段改为string类型，避免JavaScript精度丢失
  * @modify 20251001 陈凤庆 Tab改名为Type，删除Type字段，删除created_by和updated_by字段
+ * @modify 20251021 陈凤庆 添加PasswordRuleID字段，绑定类型的默认密码规则
  */
 type Type struct {
-	ID        string    `json:"id" db:"id"`
-	Name      string    `json:"name" db:"name"`
-	Icon      string    `json:"icon" db:"icon"`
-	Filter    string    `json:"filter" db:"filter"`     // 筛选规则（JSON格式）
-	GroupID   string    `json:"group_id" db:"group_id"` // 所属分组ID
-	SortOrder int       `json:"sort_order" db:"sort_order"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	ID             string    `json:"id" db:"id"`
+	Name           string    `json:"name" db:"name"`
+	Icon           string    `json:"icon" db:"icon"`
+	Filter         string    `json:"filter" db:"filter"`                     // 筛选规则（JSON格式）
+	GroupID        string    `json:"group_id" db:"group_id"`                 // 所属分组ID
+	PasswordRuleID string    `json:"password_rule_id" db:"password_rule_id"` // 默认密码规则ID，空字符串表示未绑定，此时使用所属分组的规则
+	SortOrder      int       `json:"sort_order" db:"sort_order"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
 }
 
 /**
@@ -126,11 +148,11 @@ type Tab = Type
  */
 type PasswordRule struct {
 	ID          string    `json:"id" db:"id"`
-	Name        string    `json:"name" db:"name"`                 // 规则名称
-	Description string    `json:"description" db:"description"`   // 规则描述
-	RuleType    string    `json:"rule_type" db:"rule_type"`       // 规则类型：general(通用规则)、custom(自定义规则)
-	Config      string    `json:"config" db:"config"`             // 规则配置（JSON格式）
-	IsDefault   bool      `json:"is_default" db:"is_default"`     // 是否为默认规则
+	Name        string    `json:"name" db:"name"`               // 规则名称
+	Description string    `json:"description" db:"description"` // 规则描述
+	RuleType    string    `json:"rule_type" db:"rule_type"`     // 规则类型：general(通用规则)、custom(自定义规则)、passphrase(口令短语)、pronounceable(可发音密码)
+	Config      string    `json:"config" db:"config"`           // 规则配置（JSON格式）
+	IsDefault   bool      `json:"is_default" db:"is_default"`   // 是否为默认规则
 	CreatedAt   time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
 }
@@ -141,18 +163,48 @@ type PasswordRule struct {
  * @description 通用密码规则的具体配置参数
  */
 type GeneralRuleConfig struct {
-	IncludeUppercase     bool   `json:"include_uppercase"`      // 包含大写字母
-	IncludeLowercase     bool   `json:"include_lowercase"`      // 包含小写字母
-	IncludeNumbers       bool   `json:"include_numbers"`        // 包含数字
-	IncludeSpecialChars  bool   `json:"include_special_chars"`  // 包含特殊字符
-	IncludeCustomChars   bool   `json:"include_custom_chars"`   // 包含自定义特殊字符
-	MinUppercase         int    `json:"min_uppercase"`          // 大写字母最小位数
-	MinLowercase         int    `json:"min_lowercase"`          // 小写字母最小位数
-	MinNumbers           int    `json:"min_numbers"`            // 数字最小位数
-	MinSpecialChars      int    `json:"min_special_chars"`      // 特殊字符最小位数
-	MinCustomChars       int    `json:"min_custom_chars"`       // 自定义特殊字符最小位数
-	Length               int    `json:"length"`                 // 密码总长度
-	CustomSpecialChars   string `json:"custom_special_chars"`   // 自定义特殊字符集
+	IncludeUppercase    bool   `json:"include_uppercase"`     // 包含大写字母
+	IncludeLowercase    bool   `json:"include_lowercase"`     // 包含小写字母
+	IncludeNumbers      bool   `json:"include_numbers"`       // 包含数字
+	IncludeSpecialChars bool   `json:"include_special_chars"` // 包含特殊字符
+	IncludeCustomChars  bool   `json:"include_custom_chars"`  // 包含自定义特殊字符
+	MinUppercase        int    `json:"min_uppercase"`         // 大写字母最小位数
+	MinLowercase        int    `json:"min_lowercase"`         // 小写字母最小位数
+	MinNumbers          int    `json:"min_numbers"`           // 数字最小位数
+	MinSpecialChars     int    `json:"min_special_chars"`     // 特殊字符最小位数
+	MinCustomChars      int    `json:"min_custom_chars"`      // 自定义特殊字符最小位数
+	Length              int    `json:"length"`                // 密码总长度
+	CustomSpecialChars  string `json:"custom_special_chars"`  // 自定义特殊字符集
+
+	// 20251021 陈凤庆 字符约束，兼容不接受易混淆字符、连续重复或顺序字符的旧系统
+	ExcludeChars     string `json:"exclude_chars"`     // 排除的字符集
+	ExcludeAmbiguous bool   `json:"exclude_ambiguous"` // 排除易混淆字符 0O1lI
+	MaxConsecutive   int    `json:"max_consecutive"`   // 相同字符最多连续出现次数，0表示不限制
+	ForbidSequences  bool   `json:"forbid_sequences"`  // 禁止3位及以上的升序或降序字符（如abc、321）
+}
+
+/**
+ * SitePasswordProfile 确定性站点密码参数
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 站点密码由主密码、站点、登录名和计数器确定性派生，不需要保存密码本身；
+ *              轮换密码时将计数器加1
+ */
+type SitePasswordProfile struct {
+	Site    string `json:"site"`    // 站点（域名或网址，网址只取主机名）
+	Login   string `json:"login"`   // 登录名
+	Counter int    `json:"counter"` // 计数器，从1开始
+	RuleID  string `json:"rule_id"` // 使用的通用密码规则ID
+}
+
+/**
+ * SitePasswordResult 站点密码轮换结果
+ * @author 陈凤庆
+ * @date 20251021
+ */
+type SitePasswordResult struct {
+	Password string              `json:"password"` // 新密码
+	Profile  SitePasswordProfile `json:"profile"`  // 计数器加1后的参数
 }
 
 /**
@@ -165,6 +217,422 @@ type CustomRuleConfig struct {
 	Description string `json:"description"` // 规则说明
 }
 
+/**
+ * PassphraseRuleConfig 口令短语规则配置
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 基于词表随机抽词组成口令短语（Diceware），可配置词数、分隔符、大小写以及插入的数字和符号
+ */
+type PassphraseRuleConfig struct {
+	Wordlist       string `json:"wordlist"`       // 词表：eff_large(默认)、eff_short、pinyin
+	WordCount      int    `json:"word_count"`     // 单词数量
+	Separator      string `json:"separator"`      // 单词分隔符
+	Capitalization string `json:"capitalization"` // 大小写：lower(默认)、upper、title(首字母大写)、random(随机单词首字母大写)
+	DigitCount     int    `json:"digit_count"`    // 插入的数字个数
+	SymbolCount    int    `json:"symbol_count"`   // 插入的符号个数
+	Symbols        string `json:"symbols"`        // 可插入的符号集，为空时使用默认符号集
+}
+
+/**
+ * PronounceableRuleConfig 可发音密码规则配置
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 按音节模型（辅音声母+元音韵腹，末尾可带辅音韵尾）生成易读易记的密码，可配置长度、大小写以及插入的数字和符号
+ */
+type PronounceableRuleConfig struct {
+	Length         int    `json:"length"`         // 密码总长度（含插入的数字和符号）
+	Capitalization string `json:"capitalization"` // 大小写：lower(默认)、upper、title(首字母大写)、syllable(每个音节首字母大写)、random(随机音节首字母大写)
+	DigitCount     int    `json:"digit_count"`    // 插入的数字个数
+	SymbolCount    int    `json:"symbol_count"`   // 插入的符号个数
+	Symbols        string `json:"symbols"`        // 可插入的符号集，为空时使用默认符号集
+}
+
+/**
+ * BatchPasswordRequest 批量生成密码请求
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 按同一规则批量生成互不重复的密码，可导出CSV或直接创建为指定类型下的账号。
+ *              标题和用户名模板中的 {n} 会替换为从1开始的序号
+ */
+type BatchPasswordRequest struct {
+	RuleID           string `json:"rule_id"`           // 密码规则ID
+	Count            int    `json:"count"`             // 生成数量
+	ExcludeVault     bool   `json:"exclude_vault"`     // 是否排除密码库中已有的密码
+	TypeID           string `json:"typeid"`            // 创建账号时使用的类型ID
+	TitleTemplate    string `json:"title_template"`    // 标题模板，如 svc-{n}
+	UsernameTemplate string `json:"username_template"` // 用户名模板，为空时与标题相同
+	URL              string `json:"url"`               // 网址
+	Notes            string `json:"notes"`             // 备注
+	InputMethod      int    `json:"input_method"`      // 输入方式
+}
+
+/**
+ * BatchPasswordEntry 批量生成的单条记录
+ * @author 陈凤庆
+ * @date 20251021
+ */
+type BatchPasswordEntry struct {
+	Index    int    `json:"index"`    // 序号，从1开始
+	Title    string `json:"title"`    // 标题
+	Username string `json:"username"` // 用户名
+	Password string `json:"password"` // 密码
+	URL      string `json:"url"`      // 网址
+}
+
+/**
+ * PasswordRuleFile 密码规则导入导出文件
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 团队共享的规则文件。FormatVersion为文件格式版本，Revision为规则集修订号，
+ *              订阅同步时不接受修订号低于已同步版本的文件
+ */
+type PasswordRuleFile struct {
+	Format        string                 `json:"format"`         // 文件格式标识，固定为 wepassword-password-rules
+	FormatVersion int                    `json:"format_version"` // 文件格式版本
+	Revision      int                    `json:"revision"`       // 规则集修订号，由维护者修改规则后递增
+	ExportedAt    time.Time              `json:"exported_at"`    // 导出时间
+	Rules         []PasswordRuleFileItem `json:"rules"`          // 规则列表
+}
+
+/**
+ * PasswordRuleFileItem 规则文件中的单条规则
+ * @author 陈凤庆
+ * @date 20251021
+ */
+type PasswordRuleFileItem struct {
+	Name        string          `json:"name"`        // 规则名称，导入时按名称匹配
+	Description string          `json:"description"` // 规则描述
+	RuleType    string          `json:"rule_type"`   // 规则类型
+	Config      json.RawMessage `json:"config"`      // 规则配置
+}
+
+/**
+ * PasswordRuleImportResult 规则导入或同步结果
+ * @author 陈凤庆
+ * @date 20251021
+ */
+type PasswordRuleImportResult struct {
+	Created   []string `json:"created"`   // 新建的规则名称
+	Updated   []string `json:"updated"`   // 覆盖更新的规则名称
+	Renamed   []string `json:"renamed"`   // 因名称冲突改名后新建的规则名称
+	Skipped   []string `json:"skipped"`   // 因名称冲突或内容相同而跳过的规则名称
+	Deleted   []string `json:"deleted"`   // 同步时因文件中已删除而删除的规则名称
+	Conflicts []string `json:"conflicts"` // 同步时与本地规则同名而未同步的规则名称
+}
+
+/**
+ * PasswordRuleSubscription 密码库订阅的规则文件
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 保存在密码库的sysinfo表中，RuleIDs为由该文件同步而来的规则
+ */
+type PasswordRuleSubscription struct {
+	Path         string    `json:"path"`           // 规则文件路径
+	Revision     int       `json:"revision"`       // 最近同步的修订号
+	Hash         string    `json:"hash"`           // 最近同步的文件内容SHA-256
+	LastSyncedAt time.Time `json:"last_synced_at"` // 最近同步时间
+	RuleIDs      []string  `json:"rule_ids"`       // 由该文件同步而来的规则ID
+}
+
+/**
+ * CompliancePolicy 合规密码策略
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 检查已有账号密码是否符合合规要求，数值为0或false表示不检查该项
+ */
+type CompliancePolicy struct {
+	MinLength          int      `json:"min_length"`           // 最小长度
+	MaxLength          int      `json:"max_length"`           // 最大长度，超过时视为不合规（部分系统会截断）
+	MinCharClasses     int      `json:"min_char_classes"`     // 大写、小写、数字、特殊字符中至少包含的种类数
+	RequireLetters     bool     `json:"require_letters"`      // 必须包含字母
+	RequireNumbers     bool     `json:"require_numbers"`      // 必须包含数字
+	BanCommonPasswords bool     `json:"ban_common_passwords"` // 禁止常见密码
+	BanContextWords    bool     `json:"ban_context_words"`    // 禁止包含账号标题、用户名、网址中的词
+	BannedWords        []string `json:"banned_words"`         // 禁止包含的词（不区分大小写）
+	MaxAgeDays         int      `json:"max_age_days"`         // 密码最长使用天数
+}
+
+/**
+ * CompliancePreset 合规预设
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 合规预设同时包含用于生成密码的通用规则和用于检查已有密码的策略
+ */
+type CompliancePreset struct {
+	ID          string            `json:"id"`          // 预设标识，如 nist-800-63b
+	Name        string            `json:"name"`        // 预设名称，安装为密码规则时用作规则名称
+	Description string            `json:"description"` // 描述
+	Rule        GeneralRuleConfig `json:"rule"`        // 生成密码使用的通用规则
+	Policy      CompliancePolicy  `json:"policy"`      // 检查已有密码的策略
+}
+
+/**
+ * ComplianceViolation 合规检查发现的问题
+ * @author 陈凤庆
+ * @date 20251021
+ */
+type ComplianceViolation struct {
+	Code    string `json:"code"`    // 问题代码，如 min_length、max_age
+	Message string `json:"message"` // 问题说明
+}
+
+/**
+ * AccountComplianceResult 单个账号的合规检查结果
+ * @author 陈凤庆
+ * @date 20251021
+ */
+type AccountComplianceResult struct {
+	AccountID  string                `json:"account_id"` // 账号ID
+	Title      string                `json:"title"`      // 账号标题
+	Violations []ComplianceViolation `json:"violations"` // 发现的问题
+}
+
+/**
+ * ComplianceReport 合规检查报告
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 只列出存在问题的账号，不包含密码明文
+ */
+type ComplianceReport struct {
+	PresetID       string                    `json:"preset_id"`       // 预设标识
+	PresetName     string                    `json:"preset_name"`     // 预设名称
+	CheckedCount   int                       `json:"checked_count"`   // 检查的账号数
+	CompliantCount int                       `json:"compliant_count"` // 合规的账号数
+	Accounts       []AccountComplianceResult `json:"accounts"`        // 不合规的账号及问题
+	CheckedAt      time.Time                 `json:"checked_at"`      // 检查时间
+}
+
+/**
+ * BreachCheckConfig 离线泄露检查配置
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 使用本地下载的 Have I Been Pwned SHA-1 密码数据检查密码是否已泄露，不访问网络
+ */
+type BreachCheckConfig struct {
+	DataPath string `json:"data_path"` // 按哈希排序的单个数据文件，或按5位哈希前缀分文件的范围目录；为空表示不检查
+}
+
+/**
+ * BreachDataInfo 离线泄露数据信息
+ * @author 陈凤庆
+ * @date 20251021
+ */
+type BreachDataInfo struct {
+	Path   string `json:"path"`   // 数据路径
+	Layout string `json:"layout"` // 数据格式：file(单个文件)、range_dir(范围目录)
+	Size   int64  `json:"size"`   // 单个文件的大小（字节），范围目录为0
+}
+
+/**
+ * HealthReportOptions 密码库健康报告选项
+ * @author 陈凤庆
+ * @date 20251021
+ */
+type HealthReportOptions struct {
+	MaxAgeDays       int `json:"max_age_days"`       // 密码超过该天数未修改视为过旧，0表示使用默认值365
+	MinStrengthScore int `json:"min_strength_score"` // 强度评分（0-4）低于该值视为弱密码，0表示使用默认值3
+}
+
+/**
+ * HealthFinding 健康检查发现的问题
+ * @author 陈凤庆
+ * @date 20251021
+ */
+type HealthFinding struct {
+	Code     string `json:"code"`     // 问题代码，如 weak、reused
+	Severity string `json:"severity"` // 严重程度：high、medium、low
+	Message  string `json:"message"`  // 问题说明
+}
+
+/**
+ * AccountHealth 单个账号的健康检查结果
+ * @author 陈凤庆
+ * @date 20251021
+ */
+type AccountHealth struct {
+	AccountID  string          `json:"account_id"`  // 账号ID
+	Title      string          `json:"title"`       // 账号标题
+	Score      int             `json:"score"`       // 账号得分（0-100）
+	Findings   []HealthFinding `json:"findings"`    // 发现的问题
+	ReusedWith []string        `json:"reused_with"` // 使用相同密码的其他账号ID
+}
+
+/**
+ * HealthReport 密码库健康报告
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 只列出存在问题的账号，不包含密码明文和密码哈希
+ */
+type HealthReport struct {
+	Score            int             `json:"score"`              // 密码库得分（0-100），为各账号得分的平均值
+	AccountCount     int             `json:"account_count"`      // 检查的账号数
+	WeakCount        int             `json:"weak_count"`         // 弱密码账号数
+	ReusedCount      int             `json:"reused_count"`       // 重复使用密码的账号数
+	OldCount         int             `json:"old_count"`          // 密码过旧的账号数
+	InsecureURLCount int             `json:"insecure_url_count"` // 使用http地址的账号数
+	EmptyCount       int             `json:"empty_count"`        // 密码为空的账号数
+	No2FACount       int             `json:"no_2fa_count"`       // 未配置两步验证的账号数
+	BreachedCount    int             `json:"breached_count"`     // 密码已泄露的账号数
+	BreachChecked    bool            `json:"breach_checked"`     // 是否进行了离线泄露检查
+	Accounts         []AccountHealth `json:"accounts"`           // 存在问题的账号
+	GeneratedAt      time.Time       `json:"generated_at"`       // 生成时间
+}
+
+/**
+ * AccountExpiry 账号密码到期设置
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 到期日期和轮换周期可以只设置其一，同时设置时以先到者为准
+ */
+type AccountExpiry struct {
+	AccountID         string     `json:"account_id"`          // 账号ID
+	ExpiresAt         *time.Time `json:"expires_at"`          // 到期日期，为空表示不设置
+	RotationDays      int        `json:"rotation_days"`       // 轮换周期（天），0表示不设置
+	PasswordChangedAt *time.Time `json:"password_changed_at"` // 最近一次修改密码的时间，为空时按账号最后修改时间计算
+}
+
+/**
+ * ExpiryItem 即将到期或已过期的账号
+ * @author 陈凤庆
+ * @date 20251021
+ */
+type ExpiryItem struct {
+	AccountID string    `json:"account_id"` // 账号ID
+	Title     string    `json:"title"`      // 账号标题
+	DueAt     time.Time `json:"due_at"`     // 需要修改密码的时间
+	DaysLeft  int       `json:"days_left"`  // 剩余天数，已过期时为负数
+	Status    string    `json:"status"`     // 状态：due(即将到期)、overdue(已过期)
+	Reason    string    `json:"reason"`     // 原因：expiry(到期日期)、rotation(轮换周期)
+}
+
+/**
+ * ExpirySummary 密码到期汇总
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 启动或解锁密码库时展示，按需要修改密码的时间排序
+ */
+type ExpirySummary struct {
+	OverdueCount int          `json:"overdue_count"` // 已过期的账号数
+	DueCount     int          `json:"due_count"`     // 即将到期的账号数
+	Items        []ExpiryItem `json:"items"`         // 即将到期和已过期的账号
+	CheckedAt    time.Time    `json:"checked_at"`    // 检查时间
+}
+
+/**
+ * ExpiryReminderConfig 密码到期提醒配置
+ * @author 陈凤庆
+ * @date 20251021
+ */
+type ExpiryReminderConfig struct {
+	ReminderDays              int  `json:"reminder_days"`               // 提前提醒天数
+	EnableDesktopNotification bool `json:"enable_desktop_notification"` // 解锁密码库时发送桌面通知（目前仅支持Linux）
+}
+
+/**
+ * PasswordRotation 账号的密码轮换状态
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 轮换开始后新密码保存在轮换状态中，提交前账号仍使用旧密码，重启应用后可继续提交或回滚
+ */
+type PasswordRotation struct {
+	AccountID       string    `json:"account_id"`       // 账号ID
+	Title           string    `json:"title"`            // 账号标题
+	PendingPassword string    `json:"pending_password"` // 待提交的新密码（解密后）
+	RuleID          string    `json:"rule_id"`          // 生成新密码使用的密码规则ID，手动输入时为空
+	StartedAt       time.Time `json:"started_at"`       // 开始轮换的时间
+}
+
+/**
+ * PasswordHistoryEntry 历史密码
+ * @author 陈凤庆
+ * @date 20251021
+ */
+type PasswordHistoryEntry struct {
+	ID        string    `json:"id"`         // 历史记录ID
+	AccountID string    `json:"account_id"` // 账号ID
+	Password  string    `json:"password"`   // 旧密码（解密后）
+	CreatedAt time.Time `json:"created_at"` // 被替换的时间
+}
+
+/**
+ * RotationHook 账号的密码轮换钩子
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 修改账号密码时执行的本地命令，标准输入依次为旧密码和新密码（各占一行），
+ *              退出码为0时才保存新密码
+ */
+type RotationHook struct {
+	AccountID      string    `json:"account_id"`      // 账号ID
+	Command        string    `json:"command"`         // 可执行文件路径
+	Args           []string  `json:"args"`            // 命令参数
+	TimeoutSeconds int       `json:"timeout_seconds"` // 超时时间（秒）
+	Enabled        bool      `json:"enabled"`         // 是否启用
+	UpdatedAt      time.Time `json:"updated_at"`      // 修改时间
+}
+
+/**
+ * RotationHookRun 轮换钩子的执行记录（审计记录）
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 输出中出现的旧密码和新密码会被替换为星号后再保存
+ */
+type RotationHookRun struct {
+	ID         string    `json:"id"`          // 记录ID
+	AccountID  string    `json:"account_id"`  // 账号ID
+	Title      string    `json:"title"`       // 执行时的账号标题，账号删除后仍可查看
+	Command    string    `json:"command"`     // 执行的命令
+	StartedAt  time.Time `json:"started_at"`  // 开始时间
+	DurationMs int64     `json:"duration_ms"` // 耗时（毫秒）
+	ExitCode   int       `json:"exit_code"`   // 退出码，未能启动或超时时为-1
+	Success    bool      `json:"success"`     // 是否成功
+	TimedOut   bool      `json:"timed_out"`   // 是否超时
+	Output     string    `json:"output"`      // 标准输出和标准错误
+	Error      string    `json:"error"`       // 错误信息
+}
+
+/**
+ * BulkRotationResult 批量轮换中单个账号的结果
+ * @author 陈凤庆
+ * @date 20251021
+ */
+type BulkRotationResult struct {
+	AccountID string `json:"account_id"` // 账号ID
+	Title     string `json:"title"`      // 账号标题
+	Success   bool   `json:"success"`    // 是否已提交新密码
+	Error     string `json:"error"`      // 失败原因，失败时轮换状态保留，可手动提交或回滚
+}
+
+/**
+ * BulkRotationJob 批量轮换任务
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 到达计划时间后逐个账号生成新密码、执行轮换钩子并提交，账号之间按间隔等待
+ */
+type BulkRotationJob struct {
+	ID              string               `json:"id"`               // 任务ID
+	AccountIDs      []string             `json:"account_ids"`      // 需要轮换的账号ID
+	RunAt           time.Time            `json:"run_at"`           // 计划执行时间
+	IntervalSeconds int                  `json:"interval_seconds"` // 账号之间的间隔（秒）
+	Status          string               `json:"status"`           // 状态：scheduled、running、completed、cancelled
+	Results         []BulkRotationResult `json:"results"`          // 已处理账号的结果
+	StartedAt       *time.Time           `json:"started_at"`       // 开始执行时间
+	FinishedAt      *time.Time           `json:"finished_at"`      // 结束时间
+}
+
+/**
+ * AccountAttachment 账号附件
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 附件内容加密后单独保存，列表中不包含内容
+ */
+type AccountAttachment struct {
+	ID        string    `json:"id"`         // 附件ID
+	AccountID string    `json:"account_id"` // 账号ID
+	Name      string    `json:"name"`       // 文件名
+	Size      int64     `json:"size"`       // 文件大小（字节）
+	CreatedAt time.Time `json:"created_at"` // 添加时间
+}
+
 /**
  * LogConfig 日志配置模型
  * @author 陈凤庆
@@ -203,11 +671,37 @@ type HotkeyConfig struct {
 	EnableShowHideHotkey bool   `json:"enable_show_hide_hotkey"` // 是否启用显示/隐藏快捷键（独立开关）
 }
 
+/**
+ * PasswordPolicyConfig 密码规则强度策略配置
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 保存密码规则时按规则熵值进行检查：低于警告阈值时提示，低于最小值时拒绝保存
+ */
+type PasswordPolicyConfig struct {
+	WarnEntropyBits float64 `json:"warn_entropy_bits"` // 警告阈值（位），熵值下界低于该值时提示规则较弱
+	MinEntropyBits  float64 `json:"min_entropy_bits"`  // 最小熵值（位），熵值下界低于该值时拒绝保存规则，0表示不限制
+}
+
+/**
+ * PasswordRuleEntropy 密码规则熵值
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 按生成算法计算的规则输出熵值的上下界，用于评估规则生成密码的强度
+ */
+type PasswordRuleEntropy struct {
+	EntropyLowerBits float64 `json:"entropy_lower_bits"` // 熵值下界（位），强度等级、警告和最小值检查按下界计算
+	EntropyUpperBits float64 `json:"entropy_upper_bits"` // 熵值上界（位）
+	Level            string  `json:"level"`              // 强度等级：weak(弱)、fair(一般)、strong(强)、very_strong(很强)
+	Warning          string  `json:"warning"`            // 警告信息，熵值下界不低于警告阈值时为空
+	BelowMinimum     bool    `json:"below_minimum"`      // 熵值下界是否低于配置的最小熵值
+}
+
 /**
  * AppConfig 应用配置模型
  * @modify 20251003 陈凤庆 添加日志配置
  * @modify 20251004 陈凤庆 添加锁定配置
  * @modify 20251014 陈凤庆 添加快捷键配置
+ * @modify 20251021 陈凤庆 添加密码规则强度策略配置
  */
 type AppConfig struct {
 	CurrentVaultPath string       `json:"current_vault_path"`
@@ -219,6 +713,12 @@ type AppConfig struct {
 	LogConfig        LogConfig    `json:"log_config"`    // 20251003 陈凤庆 添加日志配置
 	LockConfig       LockConfig   `json:"lock_config"`   // 20251004 陈凤庆 添加锁定配置
 	HotkeyConfig     HotkeyConfig `json:"hotkey_config"` // 20251014 陈凤庆 添加快捷键配置
+	// 20251021 陈凤庆 添加密码规则强度策略配置
+	PasswordPolicyConfig PasswordPolicyConfig `json:"password_policy_config"`
+	// 20251021 陈凤庆 添加离线泄露检查配置
+	BreachCheckConfig BreachCheckConfig `json:"breach_check_config"`
+	// 20251021 陈凤庆 添加密码到期提醒配置
+	ExpiryReminderConfig ExpiryReminderConfig `json:"expiry_reminder_config"`
 }
 
 /**
@@ -230,3 +730,32 @@ type SearchResult struct {
 	Groups   []Group            `json:"groups"`
 	Accounts []AccountDecrypted `json:"accounts"`
 }
+
+/**
+ * VaultSessionInfo 已打开密码库会话信息
+ * @author 陈凤庆
+ * @date 20251020
+ * @description 同时打开多个密码库时，描述每个密码库的来源和独立的锁定状态
+ */
+type VaultSessionInfo struct {
+	ID              string    `json:"id"`                // 会话ID，主密码库固定为 primary
+	Name            string    `json:"name"`              // 密码库名称（文件名，不含后缀）
+	Path            string    `json:"path"`              // 密码库文件路径
+	IsPrimary       bool      `json:"is_primary"`        // 是否为登录时打开的主密码库
+	IsLocked        bool      `json:"is_locked"`         // 是否已锁定
+	AutoLockMinutes int       `json:"auto_lock_minutes"` // 空闲自动锁定分钟数，0表示不自动锁定
+	OpenedAt        time.Time `json:"opened_at"`         // 打开时间
+}
+
+/**
+ * VaultAccount 带来源密码库标记的账号
+ * @author 陈凤庆
+ * @date 20251020
+ * @description 跨密码库统一列表和搜索的结果，不包含用户名和密码明文
+ */
+type VaultAccount struct {
+	AccountDecrypted
+	VaultID   string `json:"vault_id"`   // 来源密码库会话ID
+	VaultName string `json:"vault_name"` // 来源密码库名称
+	VaultPath string `json:"vault_path"` // 来源密码库文件路径
+}
@@ -0,0 +1,218 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"wepassword/internal/crypto"
+	"wepassword/internal/database"
+	"wepassword/internal/models"
+	"wepassword/internal/utils"
+)
+
+/**
+ * 账号附件服务
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 管理账号的附件（如KeePass条目中的证书、密钥文件），
+ *              附件内容Base64编码后用当前密码库的加密管理器加密保存
+ */
+
+const maxAttachmentSize = 10 * 1024 * 1024 // 单个附件最大10MB
+
+/**
+ * AccountAttachmentService 账号附件服务
+ */
+type AccountAttachmentService struct {
+	dbManager      *database.DatabaseManager
+	accountService *AccountService
+}
+
+/**
+ * NewAccountAttachmentService 创建账号附件服务
+ * @param dbManager 数据库管理器
+ * @param accountService 账号服务，用于加解密附件内容
+ * @return *AccountAttachmentService 账号附件服务实例
+ */
+func NewAccountAttachmentService(dbManager *database.DatabaseManager, accountService *AccountService) *AccountAttachmentService {
+	return &AccountAttachmentService{
+		dbManager:      dbManager,
+		accountService: accountService,
+	}
+}
+
+/**
+ * checkReady 检查数据库和加密管理器是否可用
+ * @return error 错误信息
+ */
+func (aas *AccountAttachmentService) checkReady() error {
+	if !aas.dbManager.IsOpened() {
+		return fmt.Errorf("数据库未打开")
+	}
+	if aas.accountService.cryptoManager == nil {
+		return fmt.Errorf("加密管理器未设置")
+	}
+	return nil
+}
+
+/**
+ * GetAttachments 获取账号的附件列表（不含内容）
+ * @param accountID 账号ID
+ * @return []models.AccountAttachment 附件列表，按添加时间排序
+ * @return error 错误信息
+ */
+func (aas *AccountAttachmentService) GetAttachments(accountID string) ([]models.AccountAttachment, error) {
+	if !aas.dbManager.IsOpened() {
+		return nil, fmt.Errorf("数据库未打开")
+	}
+
+	rows, err := aas.dbManager.GetDB().Query(`
+		SELECT id, account_id, name, size, created_at
+		FROM account_attachments
+		WHERE account_id = ?
+		ORDER BY created_at, name
+	`, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("查询附件失败: %w", err)
+	}
+	defer rows.Close()
+
+	attachments := make([]models.AccountAttachment, 0)
+	for rows.Next() {
+		var attachment models.AccountAttachment
+		if err := rows.Scan(&attachment.ID, &attachment.AccountID, &attachment.Name, &attachment.Size, &attachment.CreatedAt); err != nil {
+			return nil, fmt.Errorf("扫描附件失败: %w", err)
+		}
+		attachments = append(attachments, attachment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("读取附件失败: %w", err)
+	}
+	return attachments, nil
+}
+
+/**
+ * GetAttachmentData 获取附件内容
+ * @param attachmentID 附件ID
+ * @return models.AccountAttachment 附件信息
+ * @return []byte 解密后的附件内容
+ * @return error 错误信息
+ */
+func (aas *AccountAttachmentService) GetAttachmentData(attachmentID string) (models.AccountAttachment, []byte, error) {
+	var attachment models.AccountAttachment
+	if err := aas.checkReady(); err != nil {
+		return attachment, nil, err
+	}
+
+	var encrypted string
+	err := aas.dbManager.GetDB().QueryRow(`
+		SELECT id, account_id, name, size, created_at, data
+		FROM account_attachments
+		WHERE id = ?
+	`, attachmentID).Scan(&attachment.ID, &attachment.AccountID, &attachment.Name, &attachment.Size, &attachment.CreatedAt, &encrypted)
+	if err == sql.ErrNoRows {
+		return attachment, nil, fmt.Errorf("附件不存在: %s", attachmentID)
+	}
+	if err != nil {
+		return attachment, nil, fmt.Errorf("查询附件失败: %w", err)
+	}
+
+	encoded, err := aas.accountService.cryptoManager.Decrypt(encrypted)
+	if err != nil {
+		return attachment, nil, fmt.Errorf("解密附件失败: %w", err)
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return attachment, nil, fmt.Errorf("附件内容格式不正确: %w", err)
+	}
+	return attachment, data, nil
+}
+
+/**
+ * AddAttachment 为账号添加附件
+ * @param accountID 账号ID
+ * @param name 文件名
+ * @param data 附件内容
+ * @return models.AccountAttachment 附件信息
+ * @return error 错误信息
+ */
+func (aas *AccountAttachmentService) AddAttachment(accountID, name string, data []byte) (models.AccountAttachment, error) {
+	if err := aas.checkReady(); err != nil {
+		return models.AccountAttachment{}, err
+	}
+
+	db := aas.dbManager.GetDB()
+	var exists int
+	if err := db.QueryRow("SELECT COUNT(*) FROM accounts WHERE id = ?", accountID).Scan(&exists); err != nil {
+		return models.AccountAttachment{}, fmt.Errorf("查询账号失败: %w", err)
+	}
+	if exists == 0 {
+		return models.AccountAttachment{}, fmt.Errorf("账号不存在: %s", accountID)
+	}
+	return addAccountAttachment(db, aas.accountService.cryptoManager, accountID, name, data, time.Now())
+}
+
+/**
+ * DeleteAttachment 删除附件
+ * @param attachmentID 附件ID
+ * @return error 错误信息
+ */
+func (aas *AccountAttachmentService) DeleteAttachment(attachmentID string) error {
+	if !aas.dbManager.IsOpened() {
+		return fmt.Errorf("数据库未打开")
+	}
+
+	result, err := aas.dbManager.GetDB().Exec("DELETE FROM account_attachments WHERE id = ?", attachmentID)
+	if err != nil {
+		return fmt.Errorf("删除附件失败: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("附件不存在: %s", attachmentID)
+	}
+	return nil
+}
+
+/**
+ * addAccountAttachment 加密保存一个附件
+ * @param db 数据库连接
+ * @param cryptoManager 加密管理器
+ * @param accountID 账号ID
+ * @param name 文件名
+ * @param data 附件内容
+ * @param createdAt 添加时间
+ * @return models.AccountAttachment 附件信息
+ * @return error 错误信息
+ */
+func addAccountAttachment(db *sql.DB, cryptoManager *crypto.CryptoManager, accountID, name string, data []byte, createdAt time.Time) (models.AccountAttachment, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return models.AccountAttachment{}, fmt.Errorf("附件名称不能为空")
+	}
+	if len(data) > maxAttachmentSize {
+		return models.AccountAttachment{}, fmt.Errorf("附件%s超过%dMB", name, maxAttachmentSize/1024/1024)
+	}
+
+	encrypted, err := cryptoManager.Encrypt(base64.StdEncoding.EncodeToString(data))
+	if err != nil {
+		return models.AccountAttachment{}, fmt.Errorf("加密附件失败: %w", err)
+	}
+
+	attachment := models.AccountAttachment{
+		ID:        utils.GenerateGUID(),
+		AccountID: accountID,
+		Name:      name,
+		Size:      int64(len(data)),
+		CreatedAt: createdAt,
+	}
+	_, err = db.Exec(`
+		INSERT INTO account_attachments (id, account_id, name, data, size, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, attachment.ID, attachment.AccountID, attachment.Name, encrypted, attachment.Size, attachment.CreatedAt)
+	if err != nil {
+		return models.AccountAttachment{}, fmt.Errorf("保存附件失败: %w", err)
+	}
+	return attachment, nil
+}
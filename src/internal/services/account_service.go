@@ -101,10 +101,11 @@ func (as *AccountService) maskUsername(username string) string {
 
 /**
  * GetAccountsByConditions 根据查询条件获取账号列表
- * @param conditions 查询条件JSON字符串，格式：{"group_id":"xxx","type_id":"xxx"}
+ * @param conditions 查询条件JSON字符串，格式：{"group_id":"xxx","type_id":"xxx","recursive":true}
  * @return []models.AccountDecrypted 解密后的账号列表
  * @return error 错误信息
  * @author 20251003 陈凤庆 统一账号查询方法，支持多种查询条件
+ * @modify 20251020 陈凤庆 支持recursive条件，按分组查询时包含全部子分组的账号
  */
 func (as *AccountService) GetAccountsByConditions(conditions string) ([]models.AccountDecrypted, error) {
 	logger.Debug("[账号服务] GetAccountsByConditions 被调用，条件: %s", conditions)
@@ -136,7 +137,11 @@ func (as *AccountService) GetAccountsByConditions(conditions string) ([]models.A
 
 	// 根据条件动态添加WHERE子句
 	if groupID, exists := conditionsMap["group_id"]; exists && groupID != "" {
-		sqlQuery += " AND t.group_id = ?"
+		if recursive, _ := conditionsMap["recursive"].(bool); recursive {
+			sqlQuery += " AND t.group_id IN (" + groupSubtreeSQL + ")"
+		} else {
+			sqlQuery += " AND t.group_id = ?"
+		}
 		args = append(args, groupID)
 	}
 
@@ -412,6 +417,16 @@ func (as *AccountService) CreateAccount(title, username, password, url, typeID,
  * @modify 20251002 陈凤庆 UpdatePasswordItem改名为UpdateAccount
  */
 func (as *AccountService) UpdateAccount(account models.AccountDecrypted) error {
+	return as.updateAccount(account, true)
+}
+
+/**
+ * updateAccount 更新账号
+ * @param account 账号信息（解密后）
+ * @param runHook 密码发生变化时是否执行账号的轮换钩子，跨密码库复制时为false
+ * @return error 错误信息
+ */
+func (as *AccountService) updateAccount(account models.AccountDecrypted, runHook bool) error {
 	if !as.dbManager.IsOpened() {
 		return fmt.Errorf("数据库未打开")
 	}
@@ -426,6 +441,22 @@ func (as *AccountService) UpdateAccount(account models.AccountDecrypted) error {
 		return err
 	}
 
+	// 20251021 陈凤庆 比较更新前的密码，密码发生变化时记录修改时间，用于计算轮换周期
+	passwordChanged := false
+	oldPassword := ""
+	if existing, rawErr := as.GetAccountRaw(account.ID); rawErr == nil {
+		oldPassword = as.decryptField(existing.Password)
+		passwordChanged = oldPassword != account.Password
+	}
+
+	// 20251021 陈凤庆 密码发生变化时先执行账号的轮换钩子，钩子失败则不保存新密码
+	staged := false
+	if passwordChanged && runHook {
+		if staged, err = runAccountRotationHook(as.dbManager.GetDB(), as.cryptoManager, account, oldPassword); err != nil {
+			return err
+		}
+	}
+
 	// 转换为加密的账号对象
 	// 20251003 陈凤庆 添加InputMethod字段，修复更新时input_method字段丢失问题
 	encryptedAccount := models.Account{
@@ -467,6 +498,19 @@ func (as *AccountService) UpdateAccount(account models.AccountDecrypted) error {
 		return fmt.Errorf("更新账号失败: %w", updateErr)
 	}
 
+	// 20251021 陈凤庆 新密码已写入账号，删除钩子执行前保存的待提交轮换
+	if staged {
+		if _, err := db.Exec("DELETE FROM account_rotation WHERE account_id = ?", account.ID); err != nil {
+			logger.Error("[账号服务] 删除密码轮换失败，账号ID: %s, 错误: %v", account.ID, err)
+		}
+	}
+
+	if passwordChanged {
+		if err := recordPasswordChanged(db, account.ID, encryptedAccount.UpdatedAt); err != nil {
+			logger.Error("[账号服务] 账号ID: %s, %v", account.ID, err)
+		}
+	}
+
 	return nil
 }
 
@@ -489,6 +533,20 @@ func (as *AccountService) DeleteAccount(id string) error {
 		return fmt.Errorf("删除账号失败: %w", deleteErr)
 	}
 
+	// 20251021 陈凤庆 同时删除账号的到期设置
+	if _, err := db.Exec("DELETE FROM account_expiry WHERE account_id = ?", id); err != nil {
+		logger.Error("[账号服务] 删除账号到期设置失败，账号ID: %s, 错误: %v", id, err)
+	}
+
+	// 20251021 陈凤庆 同时删除账号的密码轮换状态和历史密码
+	// 20251021 陈凤庆 同时删除账号的轮换钩子，钩子执行记录作为审计记录保留
+	// 20251021 陈凤庆 同时删除账号的附件
+	for _, table := range []string{"account_rotation", "password_history", "rotation_hooks", "account_attachments"} {
+		if _, err := db.Exec("DELETE FROM "+table+" WHERE account_id = ?", id); err != nil {
+			logger.Error("[账号服务] 删除%s失败，账号ID: %s, 错误: %v", table, id, err)
+		}
+	}
+
 	return nil
 }
 
@@ -500,6 +558,18 @@ func (as *AccountService) DeleteAccount(id string) error {
  * @modify 20251002 陈凤庆 SearchPasswords改名为SearchAccounts
  */
 func (as *AccountService) SearchAccounts(keyword string) ([]models.AccountDecrypted, error) {
+	return as.SearchAccountsInGroup(keyword, "")
+}
+
+/**
+ * SearchAccountsInGroup 在指定分组（含全部子分组）范围内搜索账号
+ * @param keyword 搜索关键词
+ * @param groupID 分组ID，为空时搜索全部账号
+ * @return []models.AccountDecrypted 搜索结果
+ * @return error 错误信息
+ * @author 20251020 陈凤庆 新增嵌套分组递归范围搜索
+ */
+func (as *AccountService) SearchAccountsInGroup(keyword string, groupID string) ([]models.AccountDecrypted, error) {
 	if !as.dbManager.IsOpened() {
 		return nil, fmt.Errorf("数据库未打开")
 	}
@@ -507,13 +577,21 @@ func (as *AccountService) SearchAccounts(keyword string) ([]models.AccountDecryp
 	db := as.dbManager.GetDB()
 	// 20251002 陈凤庆 查询accounts表，删除group_id字段
 	// 20251003 陈凤庆 添加input_method字段查询
-	rows, err := db.Query(`
+	sqlQuery := `
 		SELECT id, title, username, password, url, typeid, notes, icon,
 			   is_favorite, use_count, last_used_at, created_at, updated_at, input_method
 		FROM accounts
-		WHERE title LIKE ? OR url LIKE ?
-		ORDER BY is_favorite DESC, use_count DESC, title
-	`, "%"+keyword+"%", "%"+keyword+"%")
+		WHERE (title LIKE ? OR url LIKE ?)`
+	args := []interface{}{"%" + keyword + "%", "%" + keyword + "%"}
+
+	if groupID != "" {
+		sqlQuery += " AND typeid IN (SELECT id FROM types WHERE group_id IN (" + groupSubtreeSQL + "))"
+		args = append(args, groupID)
+	}
+
+	sqlQuery += " ORDER BY is_favorite DESC, use_count DESC, title"
+
+	rows, err := db.Query(sqlQuery, args...)
 	if err != nil {
 		return nil, fmt.Errorf("搜索账号失败: %w", err)
 	}
@@ -640,7 +718,8 @@ func (as *AccountService) decryptAccount(account models.Account) (models.Account
 	}
 
 	// 验证解密后的用户名
-	if decryptedAccount.Username == "" {
+	// 20251021 陈凤庆 用户名为空的账号（如导入的只有备注或附件的KeePass条目）保存为空串，只有密文非空而解密结果为空时才视为异常
+	if decryptedAccount.Username == "" && account.Username != "" {
 		logger.Error("[解密] 解密后用户名为空，账号ID: %s", account.ID)
 		return models.AccountDecrypted{}, fmt.Errorf("解密后用户名为空")
 	}
@@ -670,7 +749,8 @@ func (as *AccountService) decryptAccount(account models.Account) (models.Account
 	}
 
 	// 验证解密后的密码
-	if decryptedAccount.Password == "" {
+	// 20251021 陈凤庆 密码为空的账号（如导入的只有备注或附件的KeePass条目）保存为空串，只有密文非空而解密结果为空时才视为异常
+	if decryptedAccount.Password == "" && account.Password != "" {
 		logger.Error("[解密] 解密后密码为空，账号ID: %s", account.ID)
 		return models.AccountDecrypted{}, fmt.Errorf("解密后密码为空")
 	}
@@ -0,0 +1,197 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"wepassword/internal/bitwarden"
+	"wepassword/internal/logger"
+	"wepassword/internal/models"
+)
+
+/**
+ * Bitwarden导出
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 将选中的账号导出为Bitwarden JSON文件，可在Bitwarden中通过"导入数据 - Bitwarden (json)"导入：
+ *              设置导出密码时生成"受密码保护"的加密文件，否则生成未加密文件；
+ *              每个类型对应一个文件夹，名称为"分组路径/类型名称"（顶级分组下的同名类型只保留一级），
+ *              账号导出为登录条目（没有用户名、密码和网址的导出为安全笔记），账号ID作为条目ID，
+ *              备注中的自定义字段、两步验证和其他网址还原为对应的字段，收藏、时间和历史密码一并导出。
+ *              Bitwarden JSON不包含附件，附件不导出
+ */
+
+/**
+ * ExportBitwarden 导出为Bitwarden JSON文件
+ * @param options 导出选项，导出范围与ExportVault相同，BackupPassword作为导出密码，为空时生成未加密文件
+ * @return error 错误信息
+ */
+func (es *ExportService) ExportBitwarden(options ExportOptions) error {
+	logger.Info("[导出] 开始导出Bitwarden文件，导出路径: %s", options.ExportPath)
+
+	if err := es.verifyLoginPassword(options.LoginPassword); err != nil {
+		return fmt.Errorf("登录密码验证失败: %w", err)
+	}
+
+	selected, err := es.getAccountsToExport(options)
+	if err != nil {
+		return fmt.Errorf("获取导出账号列表失败: %w", err)
+	}
+	// 按类型查询的列表不含密码等字段，按ID重新获取完整账号
+	accounts, err := es.getAccountsByIDs(es.extractAccountIDs(selected))
+	if err != nil {
+		return fmt.Errorf("获取导出账号列表失败: %w", err)
+	}
+	logger.Info("[导出] 获取到 %d 个账号需要导出", len(accounts))
+
+	groups, types, err := es.getRelatedGroupsAndTypes(accounts)
+	if err != nil {
+		return fmt.Errorf("获取相关分组和类型失败: %w", err)
+	}
+
+	export, err := es.buildBitwardenExport(accounts, groups, types)
+	if err != nil {
+		return err
+	}
+
+	data, err := bitwarden.Marshal(export, options.BackupPassword, bitwarden.DefaultEncryptOptions())
+	if err != nil {
+		return fmt.Errorf("生成Bitwarden文件失败: %w", err)
+	}
+	if err := os.WriteFile(options.ExportPath, data, 0600); err != nil {
+		return fmt.Errorf("写入导出文件失败: %w", err)
+	}
+
+	logger.Info("[导出] 🎉 Bitwarden导出完成: %s", options.ExportPath)
+	return nil
+}
+
+/**
+ * buildBitwardenExport 将账号、分组和类型转换为Bitwarden导出内容
+ * @param accounts 账号列表（含密码）
+ * @param groups 分组列表（含祖先分组）
+ * @param types 类型列表
+ * @return *bitwarden.Export 导出内容
+ * @return error 错误信息
+ */
+func (es *ExportService) buildBitwardenExport(accounts []models.AccountDecrypted, groups []models.Group, types []models.Type) (*bitwarden.Export, error) {
+	groupByID := make(map[string]models.Group, len(groups))
+	for _, group := range groups {
+		groupByID[group.ID] = group
+	}
+
+	export := &bitwarden.Export{Folders: make([]bitwarden.Folder, 0), Items: make([]bitwarden.Item, 0)}
+	folderIDs := make(map[string]bool)
+	for _, t := range types {
+		group, ok := groupByID[t.GroupID]
+		if !ok {
+			continue
+		}
+		name := keepassGroupPath(group, groupByID)
+		if group.ParentID != "" || group.Name != t.Name {
+			name += "/" + t.Name
+		}
+		export.Folders = append(export.Folders, bitwarden.Folder{ID: t.ID, Name: name})
+		folderIDs[t.ID] = true
+	}
+	sort.SliceStable(export.Folders, func(i, j int) bool {
+		return export.Folders[i].Name < export.Folders[j].Name
+	})
+
+	rotation := NewPasswordRotationService(es.dbManager, es.accountService)
+	for _, account := range accounts {
+		history, err := rotation.GetPasswordHistory(account.ID)
+		if err != nil {
+			return nil, err
+		}
+		item := buildBitwardenItem(account, history)
+		if folderIDs[account.TypeID] {
+			folderID := account.TypeID
+			item.FolderID = &folderID
+		}
+		export.Items = append(export.Items, item)
+	}
+	return export, nil
+}
+
+/**
+ * buildBitwardenItem 将账号转换为Bitwarden条目
+ * @param account 账号
+ * @param history 历史密码，按时间倒序
+ * @return bitwarden.Item 条目
+ */
+func buildBitwardenItem(account models.AccountDecrypted, history []models.PasswordHistoryEntry) bitwarden.Item {
+	notes, fields, totp, extraURLs := splitAccountNotes(account.Notes)
+
+	item := bitwarden.Item{
+		ID:              account.ID,
+		Type:            bitwarden.ItemTypeLogin,
+		Name:            account.Title,
+		Favorite:        account.IsFavorite,
+		Fields:          fields,
+		PasswordHistory: make([]bitwarden.PasswordHistory, 0, len(history)),
+		CreationDate:    account.CreatedAt.UTC(),
+		RevisionDate:    account.UpdatedAt.UTC(),
+	}
+	if notes != "" {
+		item.Notes = &notes
+	}
+	for _, entry := range history {
+		item.PasswordHistory = append(item.PasswordHistory, bitwarden.PasswordHistory{
+			LastUsedDate: entry.CreatedAt.UTC(),
+			Password:     entry.Password,
+		})
+	}
+
+	if account.Username == "" && account.Password == "" && account.URL == "" && totp == "" && len(extraURLs) == 0 {
+		item.Type = bitwarden.ItemTypeSecureNote
+		item.SecureNote = &bitwarden.SecureNote{}
+		return item
+	}
+
+	login := &bitwarden.Login{Username: account.Username, Password: account.Password, TOTP: totp}
+	for _, uri := range append([]string{account.URL}, extraURLs...) {
+		if uri != "" {
+			login.URIs = append(login.URIs, bitwarden.URI{URI: uri})
+		}
+	}
+	if len(history) > 0 {
+		revised := history[0].CreatedAt.UTC()
+		login.PasswordRevisionDate = &revised
+	}
+	item.Login = login
+	return item
+}
+
+/**
+ * splitAccountNotes 从备注中拆出导入时写入的自定义字段、两步验证和其他网址
+ * @param notes 账号备注
+ * @return string 其余备注
+ * @return []bitwarden.Field 自定义字段
+ * @return string 两步验证链接
+ * @return []string 其他网址
+ */
+func splitAccountNotes(notes string) (string, []bitwarden.Field, string, []string) {
+	var fields []bitwarden.Field
+	var totp string
+	var extraURLs []string
+
+	rest := make([]string, 0)
+	for _, section := range strings.Split(notes, "\n\n") {
+		switch {
+		case strings.HasPrefix(section, notesCustomFieldsHeader):
+			for _, line := range strings.Split(strings.TrimPrefix(section, notesCustomFieldsHeader), "\n") {
+				fields = append(fields, parseNotesField(line))
+			}
+		case strings.HasPrefix(section, notesOTPPrefix) && !strings.Contains(section, "\n"):
+			totp = strings.TrimPrefix(section, notesOTPPrefix)
+		case strings.HasPrefix(section, notesExtraURLsHeader):
+			extraURLs = append(extraURLs, strings.Split(strings.TrimPrefix(section, notesExtraURLsHeader), "\n")...)
+		default:
+			rest = append(rest, section)
+		}
+	}
+	return strings.Join(rest, "\n\n"), fields, totp, extraURLs
+}
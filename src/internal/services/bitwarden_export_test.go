@@ -0,0 +1,230 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"wepassword/internal/bitwarden"
+	"wepassword/internal/kdbx"
+)
+
+/**
+ * Bitwarden导出测试
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 分别以未加密和受密码保护格式导出，检查文件夹和条目，再导入到另一个密码库检查往返结果；
+ *              KeePass和CSV导入的自定义字段导出后保持类型和多行内容
+ */
+
+func TestExportBitwarden_RoundTrip(t *testing.T) {
+	source := newTestImportService(t)
+
+	parent, err := source.groupService.CreateGroup("客户")
+	if err != nil {
+		t.Fatal(err)
+	}
+	child, err := source.groupService.CreateChildGroup("ACME", parent.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prodType, err := source.typeService.CreateType("生产", child.ID, "fa-folder")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	notes := "第一段\n\n第二段\n\n" + notesCustomFieldsHeader + "PIN: 1234\n\n" + notesOTPPrefix + "otpauth://totp/DB:dba?secret=JBSWY3DPEHPK3PXP"
+	db, err := source.accountService.CreateAccount("数据库", "dba", "new-pass", "https://db.example.com", prodType.ID, notes, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.IsFavorite = true
+	if err := source.accountService.UpdateAccount(db); err != nil {
+		t.Fatal(err)
+	}
+	replacedAt := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	if _, err := addPasswordHistory(source.dbManager.GetDB(), source.cryptoManager, db.ID, "old-pass", replacedAt); err != nil {
+		t.Fatal(err)
+	}
+	note, err := source.accountService.CreateAccount("笔记", "", "", "", prodType.ID, "只有备注", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exportService := NewExportService(source.dbManager, source.accountService, source.groupService, source.typeService)
+	for _, password := range []string{"", "export-pw"} {
+		exportPath := filepath.Join(t.TempDir(), "bitwarden.json")
+		err := exportService.ExportBitwarden(ExportOptions{
+			LoginPassword:  "Test246!Asd",
+			BackupPassword: password,
+			ExportPath:     exportPath,
+			ExportAll:      true,
+		})
+		if err != nil {
+			t.Fatalf("导出失败: %v", err)
+		}
+
+		data, err := os.ReadFile(exportPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if protected, _ := bitwarden.IsPasswordProtected(data); protected != (password != "") || (password != "" && strings.Contains(string(data), "db.example.com")) {
+			t.Fatalf("导出格式错误，密码=%q", password)
+		}
+		export, err := bitwarden.Parse(data, password)
+		if err != nil {
+			t.Fatalf("解析导出文件失败: %v", err)
+		}
+		if len(export.Folders) != 1 || export.Folders[0].Name != "客户/ACME/生产" {
+			t.Fatalf("文件夹错误: %+v", export.Folders)
+		}
+		for _, item := range export.Items {
+			switch item.ID {
+			case db.ID:
+				if item.Login == nil || item.Login.TOTP == "" || len(item.Fields) != 1 || item.Fields[0].Value != "1234" || item.GetNotes() != "第一段\n\n第二段" {
+					t.Errorf("登录条目错误: %+v", item)
+				}
+			case note.ID:
+				if item.Type != bitwarden.ItemTypeSecureNote {
+					t.Errorf("只有备注的账号应导出为安全笔记: %+v", item)
+				}
+			}
+		}
+
+		target := newTestImportService(t)
+		result, err := target.ImportBitwarden(BitwardenImportOptions{ImportPath: exportPath, Password: password})
+		if err != nil || result.ImportedAccounts != 2 {
+			t.Fatalf("导入失败: %+v, %v", result, err)
+		}
+		imported, err := target.accountService.GetAccountByID(db.ID)
+		if err != nil {
+			t.Fatalf("导入后账号ID应保持不变: %v", err)
+		}
+		if imported.Password != "new-pass" || imported.Notes != notes || !imported.IsFavorite {
+			t.Errorf("往返后账号不一致: %+v", imported)
+		}
+		history, err := NewPasswordRotationService(target.dbManager, target.accountService).GetPasswordHistory(db.ID)
+		if err != nil || len(history) != 1 || !history[0].CreatedAt.Equal(replacedAt) {
+			t.Errorf("历史密码未保留: %+v, %v", history, err)
+		}
+		typeInfo, _ := target.typeService.GetTypeByID(imported.TypeID)
+		if typeInfo == nil || typeInfo.Name != "生产" {
+			t.Errorf("类型未保留: %+v", typeInfo)
+		}
+	}
+}
+
+func TestSplitAccountNotes(t *testing.T) {
+	notes := "备注\n\n" + notesExtraURLsHeader + "https://a\nhttps://b\n\n" + notesCustomFieldsHeader + "A: 1\nB: x: y"
+	rest, fields, totp, urls := splitAccountNotes(notes)
+	if rest != "备注" || totp != "" || len(urls) != 2 {
+		t.Errorf("拆分结果错误: %q, %q, %v", rest, totp, urls)
+	}
+	if len(fields) != 2 || fields[1].Name != "B" || fields[1].Value != "x: y" {
+		t.Errorf("自定义字段拆分错误: %+v", fields)
+	}
+}
+
+func TestNotesFields_RoundTrip(t *testing.T) {
+	fields := []bitwarden.Field{
+		{Name: "PIN", Value: "1234", Type: bitwarden.FieldTypeHidden},
+		{Name: "恢复码", Value: "line1\n\nline2", Type: bitwarden.FieldTypeText},
+		{Name: "key: part", Value: "\"quoted\"", Type: bitwarden.FieldTypeText},
+		{Name: "[x]", Value: "true", Type: bitwarden.FieldTypeBoolean},
+	}
+	lines := make([]string, 0, len(fields))
+	for _, field := range fields {
+		lines = append(lines, formatNotesField(field))
+	}
+	notes := "备注\n\n" + notesCustomFieldsHeader + strings.Join(lines, "\n")
+	rest, parsed, _, _ := splitAccountNotes(notes)
+	if rest != "备注" {
+		t.Errorf("其余备注错误: %q", rest)
+	}
+	if len(parsed) != len(fields) {
+		t.Fatalf("自定义字段数量错误: %+v", parsed)
+	}
+	for i, field := range fields {
+		if parsed[i].Name != field.Name || parsed[i].Value != field.Value || parsed[i].Type != field.Type {
+			t.Errorf("字段%d未还原: %+v, 期望 %+v", i, parsed[i], field)
+		}
+	}
+}
+
+// exportBitwardenItem 导出未加密的Bitwarden文件并返回指定账号的条目
+func exportBitwardenItem(t *testing.T, is *ImportService, accountID string) bitwarden.Item {
+	t.Helper()
+	exportPath := filepath.Join(t.TempDir(), "bitwarden.json")
+	exportService := NewExportService(is.dbManager, is.accountService, is.groupService, is.typeService)
+	if err := exportService.ExportBitwarden(ExportOptions{LoginPassword: "Test246!Asd", ExportPath: exportPath, ExportAll: true}); err != nil {
+		t.Fatalf("导出失败: %v", err)
+	}
+	data, err := os.ReadFile(exportPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	export, err := bitwarden.Parse(data, "")
+	if err != nil {
+		t.Fatalf("解析导出文件失败: %v", err)
+	}
+	for _, item := range export.Items {
+		if item.ID == accountID {
+			return item
+		}
+	}
+	t.Fatalf("导出文件中没有账号%s", accountID)
+	return bitwarden.Item{}
+}
+
+func TestExportBitwarden_KeePassFields(t *testing.T) {
+	is := newTestImportService(t)
+	entry := keepassTestEntry(0x55, "服务器", "pw",
+		kdbx.StringField{Key: kdbx.FieldNotes, Value: "原备注"},
+		kdbx.StringField{Key: "恢复码", Value: "AAAA\n\nBBBB\nCCCC", Protected: true},
+		kdbx.StringField{Key: "说明", Value: "\"带引号\"的值"},
+	)
+	db := &kdbx.Database{Root: kdbx.Group{Name: "Root", Groups: []kdbx.Group{{Name: "运维", Entries: []kdbx.Entry{entry}}}}}
+	if _, err := is.importKeePassDatabase(db, ImportResult{}); err != nil {
+		t.Fatalf("导入失败: %v", err)
+	}
+
+	item := exportBitwardenItem(t, is, keepassTestUUID(0x55).String())
+	want := []bitwarden.Field{
+		{Name: "恢复码", Value: "AAAA\n\nBBBB\nCCCC", Type: bitwarden.FieldTypeHidden},
+		{Name: "说明", Value: "\"带引号\"的值", Type: bitwarden.FieldTypeText},
+	}
+	if item.GetNotes() != "原备注" || len(item.Fields) != len(want) {
+		t.Fatalf("导出条目错误: %q, %+v", item.GetNotes(), item.Fields)
+	}
+	for i, field := range want {
+		if item.Fields[i] != field {
+			t.Errorf("字段%d错误: %+v, 期望 %+v", i, item.Fields[i], field)
+		}
+	}
+}
+
+func TestExportBitwarden_CSVFields(t *testing.T) {
+	is := newTestImportService(t)
+	group, _ := is.groupService.CreateGroup("CSV")
+	target, err := is.typeService.CreateType("导入", group.ID, "fa-folder")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := writeTestCSV(t, "标题,用户名,密码,机房\n服务器,root,secret,\"A区\n\n3号柜\"\n")
+	result, err := is.ImportCSV(CSVImportOptions{
+		ImportPath: path,
+		Profile:    CSVProfileCustom,
+		Mapping:    map[string]int{CSVFieldTitle: 0, CSVFieldUsername: 1, CSVFieldPassword: 2},
+		TypeID:     target.ID,
+	})
+	if err != nil || result.ImportedAccounts != 1 {
+		t.Fatalf("导入失败: %+v, %v", result, err)
+	}
+
+	item := exportBitwardenItem(t, is, result.EntryResults[0].AccountID)
+	if item.GetNotes() != "" || len(item.Fields) != 1 || item.Fields[0].Name != "机房" || item.Fields[0].Value != "A区\n\n3号柜" {
+		t.Errorf("导出条目错误: %q, %+v", item.GetNotes(), item.Fields)
+	}
+}
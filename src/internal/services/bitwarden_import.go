@@ -0,0 +1,448 @@
+package services
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"wepassword/internal/bitwarden"
+	"wepassword/internal/logger"
+	"wepassword/internal/models"
+	"wepassword/internal/utils"
+)
+
+/**
+ * Bitwarden导入
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 读取Bitwarden导出的JSON文件（未加密或受密码保护）并导入当前密码库：
+ *              文件夹名称按"/"拆分为路径，最后一级作为类型、前面各级作为嵌套分组，只有一级时分组和类型同名；
+ *              没有文件夹的条目使用所属集合，仍没有时放在"Bitwarden"分组中。
+ *              登录、支付卡、身份、安全笔记和SSH密钥条目都映射为账号，条目ID作为账号ID，已存在的账号跳过；
+ *              各类型特有的信息、自定义字段和两步验证（otpauth://链接）写入备注，历史密码写入历史密码，
+ *              已删除的条目不导入
+ */
+
+// 备注中各部分的标题，导出时据此还原自定义字段、两步验证和其他网址
+const (
+	notesCustomFieldsHeader = "自定义字段:\n"
+	notesOTPPrefix          = "两步验证: "
+	notesExtraURLsHeader    = "其他网址:\n"
+)
+
+// 自定义字段行首的类型标记，文本字段不加标记
+const (
+	notesHiddenFieldMarker  = "[隐藏] "
+	notesBooleanFieldMarker = "[布尔] "
+)
+
+// bitwardenDefaultFolder 没有文件夹和集合的条目所在的分组
+const bitwardenDefaultFolder = "Bitwarden"
+
+/**
+ * BitwardenImportOptions Bitwarden导入选项
+ */
+type BitwardenImportOptions struct {
+	ImportPath string `json:"import_path"` // JSON文件路径
+	Password   string `json:"password"`    // 导出密码，未加密的文件为空
+}
+
+/**
+ * bitwardenImport 一次Bitwarden导入的上下文
+ */
+type bitwardenImport struct {
+	*importTargets
+	folders     map[string]string // 文件夹ID -> 名称
+	collections map[string]string // 集合ID -> 名称
+}
+
+/**
+ * ImportBitwarden 导入Bitwarden JSON文件
+ * @param options 导入选项
+ * @return ImportResult 导入结果，EntryResults中包含每个条目的结果
+ * @return error 文件无法读取或解密失败时返回错误
+ */
+func (is *ImportService) ImportBitwarden(options BitwardenImportOptions) (ImportResult, error) {
+	logger.Info("[导入] 开始导入Bitwarden文件，导入路径: %s", options.ImportPath)
+
+	result := ImportResult{
+		SkippedAccountDetails: make([]SkippedAccountInfo, 0),
+		EntryResults:          make([]ImportEntryResult, 0),
+	}
+	if !is.dbManager.IsOpened() {
+		result.ErrorMessage = "数据库未打开"
+		return result, fmt.Errorf("数据库未打开")
+	}
+	if is.accountService.cryptoManager == nil {
+		result.ErrorMessage = "加密管理器未设置"
+		return result, fmt.Errorf("加密管理器未设置")
+	}
+
+	data, err := os.ReadFile(options.ImportPath)
+	if err != nil {
+		result.ErrorMessage = fmt.Sprintf("读取导入文件失败: %v", err)
+		return result, fmt.Errorf("读取导入文件失败: %w", err)
+	}
+	export, err := bitwarden.Parse(data, options.Password)
+	if err != nil {
+		result.ErrorMessage = err.Error()
+		return result, err
+	}
+	logger.Info("[导入] ✅ Bitwarden文件解析成功，共 %d 个条目", len(export.Items))
+
+	return is.importBitwardenExport(export, result)
+}
+
+/**
+ * importBitwardenExport 将解析后的Bitwarden导出内容导入当前密码库
+ * @param export 导出内容
+ * @param result 导入结果
+ * @return ImportResult 导入结果
+ * @return error 错误信息
+ */
+func (is *ImportService) importBitwardenExport(export *bitwarden.Export, result ImportResult) (ImportResult, error) {
+	targets, err := is.newImportTargets(&result)
+	if err != nil {
+		return result, err
+	}
+
+	bi := &bitwardenImport{
+		importTargets: targets,
+		folders:       make(map[string]string),
+		collections:   make(map[string]string),
+	}
+	for _, folder := range export.Folders {
+		bi.folders[folder.ID] = folder.Name
+	}
+	for _, collection := range export.Collections {
+		bi.collections[collection.ID] = collection.Name
+	}
+
+	for _, item := range export.Items {
+		if item.DeletedDate != nil {
+			logger.Info("[导入] 跳过已删除的Bitwarden条目: %s", item.Name)
+			continue
+		}
+		bi.importItem(item)
+	}
+
+	result.Success = true
+	logger.Info("[导入] 🎉 Bitwarden导入完成: 总数=%d, 导入=%d, 跳过=%d, 错误=%d",
+		result.TotalAccounts, result.ImportedAccounts, result.SkippedAccounts, result.ErrorAccounts)
+	return result, nil
+}
+
+/**
+ * folderPath 条目所在的文件夹（或集合）名称及拆分后的路径
+ */
+func (bi *bitwardenImport) folderPath(item bitwarden.Item) (string, []string) {
+	name := ""
+	if item.FolderID != nil {
+		name = bi.folders[*item.FolderID]
+	}
+	if name == "" && len(item.CollectionIDs) > 0 {
+		name = bi.collections[item.CollectionIDs[0]]
+	}
+
+	path := make([]string, 0)
+	for _, part := range strings.Split(name, "/") {
+		if part = strings.TrimSpace(part); part != "" {
+			path = append(path, part)
+		}
+	}
+	if len(path) == 0 {
+		path = []string{bitwardenDefaultFolder}
+	}
+	return name, path
+}
+
+/**
+ * importItem 导入单个条目
+ * @param item Bitwarden条目
+ */
+func (bi *bitwardenImport) importItem(item bitwarden.Item) {
+	bi.result.TotalAccounts++
+
+	folder, path := bi.folderPath(item)
+	entryResult := ImportEntryResult{
+		Path:      folder,
+		Title:     item.Name,
+		AccountID: bitwardenAccountID(item),
+		Warnings:  make([]string, 0),
+	}
+
+	typeID, err := bi.resolvePath(path)
+	if err != nil {
+		bi.fail(&entryResult, fmt.Sprintf("创建分组或类型失败: %v", err))
+		return
+	}
+
+	account, warnings := convertBitwardenItem(item, entryResult.AccountID, typeID)
+	entryResult.Title = account.Title
+	entryResult.Warnings = append(entryResult.Warnings, warnings...)
+	if bi.skipExisting(&entryResult, account.Username) {
+		return
+	}
+	if err := bi.is.createAccountWithID(account); err != nil {
+		logger.Error("[导入] 创建账号失败: ID=%s, Title=%s, 错误=%v", account.ID, account.Title, err)
+		bi.fail(&entryResult, err.Error())
+		return
+	}
+
+	db := bi.is.dbManager.GetDB()
+	for _, history := range item.PasswordHistory {
+		if history.Password == "" {
+			continue
+		}
+		replacedAt := history.LastUsedDate
+		if replacedAt.IsZero() {
+			replacedAt = account.UpdatedAt
+		}
+		if _, err := addPasswordHistory(db, bi.is.accountService.cryptoManager, account.ID, history.Password, replacedAt); err != nil {
+			entryResult.Warnings = append(entryResult.Warnings, fmt.Sprintf("历史密码未导入: %v", err))
+			break
+		}
+	}
+
+	bi.imported(&entryResult)
+}
+
+/**
+ * convertBitwardenItem 将Bitwarden条目转换为账号
+ * @param item Bitwarden条目
+ * @param accountID 账号ID
+ * @param typeID 类型ID
+ * @return models.AccountDecrypted 账号
+ * @return []string 未能保留的内容
+ */
+func convertBitwardenItem(item bitwarden.Item, accountID, typeID string) (models.AccountDecrypted, []string) {
+	title := strings.TrimSpace(item.Name)
+	if title == "" {
+		title = "未命名条目"
+	}
+
+	account := models.AccountDecrypted{
+		ID:          accountID,
+		Title:       title,
+		TypeID:      typeID,
+		IsFavorite:  item.Favorite,
+		CreatedAt:   item.CreationDate,
+		UpdatedAt:   item.RevisionDate,
+		InputMethod: 1,
+	}
+	if account.CreatedAt.IsZero() {
+		account.CreatedAt = account.UpdatedAt
+	}
+	if account.CreatedAt.IsZero() {
+		account.CreatedAt = time.Now()
+	}
+	if account.UpdatedAt.IsZero() {
+		account.UpdatedAt = account.CreatedAt
+	}
+
+	notes := []string{strings.TrimRight(item.GetNotes(), "\n")}
+	otpURI := ""
+	switch {
+	case item.Login != nil:
+		account.Username = item.Login.Username
+		account.Password = item.Login.Password
+		extraURLs := make([]string, 0)
+		for _, uri := range item.Login.URIs {
+			if uri.URI == "" {
+				continue
+			}
+			if account.URL == "" {
+				account.URL = uri.URI
+			} else {
+				extraURLs = append(extraURLs, uri.URI)
+			}
+		}
+		if len(extraURLs) > 0 {
+			notes = append(notes, notesExtraURLsHeader+strings.Join(extraURLs, "\n"))
+		}
+		otpURI = normalizeOTP(item.Login.TOTP, title, account.Username)
+	case item.Card != nil:
+		account.Username = item.Card.CardholderName
+		account.Password = item.Card.Number
+		expiry := ""
+		if item.Card.ExpMonth != "" || item.Card.ExpYear != "" {
+			expiry = item.Card.ExpMonth + "/" + item.Card.ExpYear
+		}
+		notes = append(notes, labeledLines("卡片信息:",
+			"品牌", item.Card.Brand,
+			"有效期", expiry,
+			"安全码", item.Card.Code,
+		))
+	case item.Identity != nil:
+		id := item.Identity
+		account.Username = id.Username
+		if account.Username == "" {
+			account.Username = id.Email
+		}
+		notes = append(notes, labeledLines("身份信息:",
+			"称谓", id.Title,
+			"姓名", joinNonEmpty(" ", id.FirstName, id.MiddleName, id.LastName),
+			"公司", id.Company,
+			"邮箱", id.Email,
+			"电话", id.Phone,
+			"地址", joinNonEmpty(", ", id.Address1, id.Address2, id.Address3, id.City, id.State, id.PostalCode, id.Country),
+			"社会保障号", id.SSN,
+			"护照号", id.PassportNumber,
+			"驾照号", id.LicenseNumber,
+			"用户名", id.Username,
+		))
+	case item.SSHKey != nil:
+		account.Password = item.SSHKey.PrivateKey
+		notes = append(notes, labeledLines("SSH密钥:",
+			"公钥", item.SSHKey.PublicKey,
+			"指纹", item.SSHKey.KeyFingerprint,
+		))
+	}
+
+	warnings := make([]string, 0)
+	fields := make([]string, 0)
+	for _, field := range item.Fields {
+		if field.Type == bitwarden.FieldTypeLinked {
+			warnings = append(warnings, fmt.Sprintf("关联字段%s未导入", field.Name))
+			continue
+		}
+		if field.Name == "" && field.Value == "" {
+			continue
+		}
+		fields = append(fields, formatNotesField(field))
+	}
+	if len(fields) > 0 {
+		notes = append(notes, notesCustomFieldsHeader+strings.Join(fields, "\n"))
+	}
+	if otpURI != "" {
+		notes = append(notes, notesOTPPrefix+otpURI)
+	}
+
+	parts := make([]string, 0, len(notes))
+	for _, part := range notes {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	account.Notes = strings.Join(parts, "\n\n")
+	return account, warnings
+}
+
+/**
+ * bitwardenAccountID Bitwarden条目ID作为账号ID，不是GUID格式时生成新ID
+ */
+func bitwardenAccountID(item bitwarden.Item) string {
+	if utils.IsValidGUID(item.ID) {
+		return strings.ToLower(item.ID)
+	}
+	return utils.GenerateGUID()
+}
+
+/**
+ * normalizeOTP 将两步验证设置转换为otpauth://链接
+ * @param totp otpauth://链接、steam://密钥或Base32密钥
+ * @param title 账号标题，作为链接中的发行方
+ * @param username 用户名
+ * @return string 链接，未设置时为空
+ */
+func normalizeOTP(totp, title, username string) string {
+	totp = strings.TrimSpace(totp)
+	lower := strings.ToLower(totp)
+	if totp == "" || strings.HasPrefix(lower, "otpauth://") || strings.HasPrefix(lower, "steam://") {
+		return totp
+	}
+
+	params := url.Values{}
+	params.Set("secret", strings.ToUpper(strings.ReplaceAll(totp, " ", "")))
+	params.Set("issuer", title)
+	label := url.PathEscape(title)
+	if username != "" {
+		label += ":" + url.PathEscape(username)
+	}
+	return "otpauth://totp/" + label + "?" + params.Encode()
+}
+
+/**
+ * labeledLines 生成带标题的"名称: 值"文本，跳过空值，全部为空时返回空字符串
+ * @param header 标题
+ * @param pairs 名称和值交替排列
+ */
+func labeledLines(header string, pairs ...string) string {
+	lines := make([]string, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if pairs[i+1] != "" {
+			lines = append(lines, pairs[i]+": "+pairs[i+1])
+		}
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return header + "\n" + strings.Join(lines, "\n")
+}
+
+/**
+ * formatNotesField 把自定义字段写成备注中的一行"[类型] 名称: 值"
+ * @param field 自定义字段
+ * @return string 备注行
+ * @description 隐藏和布尔字段加类型标记；名称含": "、换行或以引号、方括号开头时加引号转义，
+ *              值含换行或以引号开头时加引号转义，保证每个字段只占一行且导出时能原样还原
+ */
+func formatNotesField(field bitwarden.Field) string {
+	marker := ""
+	switch field.Type {
+	case bitwarden.FieldTypeHidden:
+		marker = notesHiddenFieldMarker
+	case bitwarden.FieldTypeBoolean:
+		marker = notesBooleanFieldMarker
+	}
+	name := field.Name
+	if strings.Contains(name, ": ") || strings.ContainsAny(name, "\r\n") || strings.HasPrefix(name, "\"") || strings.HasPrefix(name, "[") {
+		name = strconv.Quote(name)
+	}
+	value := field.Value
+	if strings.ContainsAny(value, "\r\n") || strings.HasPrefix(value, "\"") {
+		value = strconv.Quote(value)
+	}
+	return marker + name + ": " + value
+}
+
+/**
+ * parseNotesField 解析formatNotesField生成的备注行，兼容旧版本写入的"名称: 值"
+ * @param line 备注行
+ * @return bitwarden.Field 自定义字段
+ */
+func parseNotesField(line string) bitwarden.Field {
+	field := bitwarden.Field{Type: bitwarden.FieldTypeText}
+	if rest, ok := strings.CutPrefix(line, notesHiddenFieldMarker); ok {
+		field.Type, line = bitwarden.FieldTypeHidden, rest
+	} else if rest, ok := strings.CutPrefix(line, notesBooleanFieldMarker); ok {
+		field.Type, line = bitwarden.FieldTypeBoolean, rest
+	}
+
+	if quoted, err := strconv.QuotedPrefix(line); err == nil && strings.HasPrefix(line[len(quoted):], ": ") {
+		field.Name, _ = strconv.Unquote(quoted)
+		line = line[len(quoted)+2:]
+	} else {
+		field.Name, line, _ = strings.Cut(line, ": ")
+	}
+	field.Value = line
+	if strings.HasPrefix(line, "\"") {
+		if value, err := strconv.Unquote(line); err == nil {
+			field.Value = value
+		}
+	}
+	return field
+}
+
+func joinNonEmpty(sep string, values ...string) string {
+	parts := make([]string, 0, len(values))
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			parts = append(parts, value)
+		}
+	}
+	return strings.Join(parts, sep)
+}
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"wepassword/internal/bitwarden"
+)
+
+/**
+ * Bitwarden导入测试
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 测试文件夹映射、各类型条目的转换、自定义字段、两步验证、历史密码以及重复导入时的跳过
+ */
+
+func TestImportBitwardenExport(t *testing.T) {
+	is := newTestImportService(t)
+
+	folderID := "aaaaaaaa-0000-4000-8000-000000000001"
+	collectionID := "cccccccc-0000-4000-8000-000000000001"
+	notes := "原备注"
+	created := time.Date(2023, 5, 1, 8, 0, 0, 0, time.UTC)
+	replaced := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	deleted := time.Now()
+
+	export := &bitwarden.Export{
+		Folders:     []bitwarden.Folder{{ID: folderID, Name: "工作/邮件"}},
+		Collections: []bitwarden.Collection{{ID: collectionID, Name: "团队"}},
+		Items: []bitwarden.Item{
+			{
+				ID: "11111111-1111-4111-8111-111111111111", FolderID: &folderID, Type: bitwarden.ItemTypeLogin,
+				Name: "邮箱", Notes: &notes, Favorite: true, CreationDate: created, RevisionDate: replaced,
+				Fields: []bitwarden.Field{
+					{Name: "PIN", Value: "1234", Type: bitwarden.FieldTypeHidden},
+					{Name: "恢复码: 主", Value: "AAAA-BBBB\n\nCCCC-DDDD", Type: bitwarden.FieldTypeHidden},
+					{Name: "用户名副本", Type: bitwarden.FieldTypeLinked},
+				},
+				Login: &bitwarden.Login{
+					Username: "alice", Password: "new-pass", TOTP: "JBSW Y3DP EHPK 3PXP",
+					URIs: []bitwarden.URI{{URI: "https://mail.example.com"}, {URI: "https://webmail.example.com"}},
+				},
+				PasswordHistory: []bitwarden.PasswordHistory{{LastUsedDate: replaced, Password: "old-pass"}},
+			},
+			{
+				ID: "22222222-2222-4222-8222-222222222222", CollectionIDs: []string{collectionID}, Type: bitwarden.ItemTypeCard,
+				Name: "信用卡", Card: &bitwarden.Card{CardholderName: "ALICE", Brand: "Visa", Number: "4111111111111111", ExpMonth: "12", ExpYear: "2030", Code: "123"},
+			},
+			{
+				ID: "33333333-3333-4333-8333-333333333333", Type: bitwarden.ItemTypeIdentity, Name: "身份",
+				Identity: &bitwarden.Identity{FirstName: "Alice", LastName: "Smith", Email: "alice@example.com", City: "Berlin"},
+			},
+			{ID: "not-a-guid", Type: bitwarden.ItemTypeSecureNote, Name: "笔记", Notes: &notes, SecureNote: &bitwarden.SecureNote{}},
+			{ID: "44444444-4444-4444-8444-444444444444", Type: bitwarden.ItemTypeLogin, Name: "已删除", Login: &bitwarden.Login{}, DeletedDate: &deleted},
+		},
+	}
+
+	result, err := is.importBitwardenExport(export, ImportResult{})
+	if err != nil {
+		t.Fatalf("导入失败: %v", err)
+	}
+	if result.TotalAccounts != 4 || result.ImportedAccounts != 4 || len(result.EntryResults) != 4 {
+		t.Fatalf("导入统计错误: %+v", result)
+	}
+
+	// 文件夹"工作/邮件" -> 分组"工作"下的类型"邮件"
+	mail, err := is.accountService.GetAccountByID("11111111-1111-4111-8111-111111111111")
+	if err != nil {
+		t.Fatalf("获取导入的账号失败: %v", err)
+	}
+	typeInfo, _ := is.typeService.GetTypeByID(mail.TypeID)
+	if typeInfo == nil || typeInfo.Name != "邮件" {
+		t.Fatalf("类型映射错误: %+v", typeInfo)
+	}
+	if group, _ := is.groupService.GetGroupByID(typeInfo.GroupID); group == nil || group.Name != "工作" {
+		t.Fatalf("分组映射错误: %+v", group)
+	}
+	if mail.Username != "alice" || mail.Password != "new-pass" || mail.URL != "https://mail.example.com" || !mail.IsFavorite || !mail.CreatedAt.Equal(created) {
+		t.Errorf("登录条目字段错误: %+v", mail)
+	}
+	for _, want := range []string{"原备注", "其他网址:\nhttps://webmail.example.com", "PIN: 1234", "两步验证: otpauth://totp/", "secret=JBSWY3DPEHPK3PXP"} {
+		if !strings.Contains(mail.Notes, want) {
+			t.Errorf("备注中缺少%q: %s", want, mail.Notes)
+		}
+	}
+	_, fields, _, _ := splitAccountNotes(mail.Notes)
+	if len(fields) != 2 || fields[0] != export.Items[0].Fields[0] || fields[1] != export.Items[0].Fields[1] {
+		t.Errorf("自定义字段未能从备注还原: %+v", fields)
+	}
+	history, err := NewPasswordRotationService(is.dbManager, is.accountService).GetPasswordHistory(mail.ID)
+	if err != nil || len(history) != 1 || history[0].Password != "old-pass" || !history[0].CreatedAt.Equal(replaced) {
+		t.Errorf("历史密码错误: %+v, %v", history, err)
+	}
+	for _, entryResult := range result.EntryResults {
+		if entryResult.AccountID == mail.ID && (entryResult.Path != "工作/邮件" || len(entryResult.Warnings) != 1) {
+			t.Errorf("条目结果错误: %+v", entryResult)
+		}
+	}
+
+	// 没有文件夹的条目使用集合名称
+	card, err := is.accountService.GetAccountByID("22222222-2222-4222-8222-222222222222")
+	if err != nil || card.Username != "ALICE" || card.Password != "4111111111111111" || !strings.Contains(card.Notes, "有效期: 12/2030") {
+		t.Fatalf("支付卡条目错误: %+v, %v", card, err)
+	}
+	if cardType, _ := is.typeService.GetTypeByID(card.TypeID); cardType == nil || cardType.Name != "团队" {
+		t.Errorf("集合映射错误: %+v", cardType)
+	}
+
+	identity, err := is.accountService.GetAccountByID("33333333-3333-4333-8333-333333333333")
+	if err != nil || identity.Username != "alice@example.com" || !strings.Contains(identity.Notes, "姓名: Alice Smith") {
+		t.Errorf("身份条目错误: %+v, %v", identity, err)
+	}
+	if identityType, _ := is.typeService.GetTypeByID(identity.TypeID); identityType == nil || identityType.Name != bitwardenDefaultFolder {
+		t.Errorf("没有文件夹的条目应放在默认分组: %+v", identityType)
+	}
+
+	// 再次导入时已存在的账号跳过（ID无效的条目每次生成新ID，会再次导入）
+	again, err := is.importBitwardenExport(export, ImportResult{})
+	if err != nil {
+		t.Fatalf("重复导入失败: %v", err)
+	}
+	if again.SkippedAccounts != 3 || again.ImportedAccounts != 1 || again.ImportedGroups != 0 || again.ImportedTypes != 0 {
+		t.Errorf("重复导入统计错误: %+v", again)
+	}
+}
+
+func TestNormalizeOTP(t *testing.T) {
+	if got := normalizeOTP("steam://ABCDEF", "Steam", "alice"); got != "steam://ABCDEF" {
+		t.Errorf("steam密钥应保持不变: %s", got)
+	}
+	uri := normalizeOTP("jbswy3dpehpk3pxp", "GitHub", "alice")
+	for _, want := range []string{"otpauth://totp/GitHub:alice?", "secret=JBSWY3DPEHPK3PXP", "issuer=GitHub"} {
+		if !strings.Contains(uri, want) {
+			t.Errorf("otpauth链接中缺少%q: %s", want, uri)
+		}
+	}
+	if normalizeOTP("  ", "x", "") != "" {
+		t.Error("未设置两步验证时应返回空字符串")
+	}
+}
@@ -0,0 +1,309 @@
+package services
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"wepassword/internal/models"
+)
+
+/**
+ * 离线泄露检查
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 使用本地下载的 Have I Been Pwned SHA-1 密码数据检查密码是否已泄露，全程不访问网络。
+ *              支持两种格式：
+ *              1. 单个文件：每行“40位SHA-1:次数”，按哈希排序，在文件中按字节偏移二分查找，不需要读入内存
+ *              2. 范围目录：按k-匿名接口下载的目录，每个5位哈希前缀一个文件（如 00000.txt），
+ *                 每行“35位哈希后缀:次数”，查找时只读取对应前缀的文件并二分查找
+ *              已排序的数据本身就是索引，打开时只校验格式，不额外生成索引文件
+ */
+
+// 泄露数据格式
+const (
+	BreachLayoutFile     = "file"      // 单个文件
+	BreachLayoutRangeDir = "range_dir" // 范围目录
+)
+
+const (
+	breachHashLength   = 40 // SHA-1十六进制长度
+	breachPrefixLength = 5  // 范围目录的哈希前缀长度
+)
+
+var (
+	breachFileLinePattern  = regexp.MustCompile(`^[0-9A-Fa-f]{40}:\d+$`)
+	breachRangeLinePattern = regexp.MustCompile(`^[0-9A-Fa-f]{35}:\d+$`)
+)
+
+/**
+ * BreachLookup 密码泄露查询接口
+ */
+type BreachLookup interface {
+	// BreachCount 返回密码在泄露数据中出现的次数，未泄露时为0
+	BreachCount(password string) (int, error)
+}
+
+/**
+ * BreachChecker 离线泄露检查器
+ */
+type BreachChecker struct {
+	path   string
+	layout string
+	file   *os.File // 单个文件格式时打开的数据文件
+	size   int64
+	mu     sync.Mutex
+}
+
+/**
+ * OpenBreachChecker 打开离线泄露数据
+ * @param path 单个数据文件或范围目录的路径
+ * @return *BreachChecker 泄露检查器，使用完毕后需调用Close
+ * @return error 路径不存在或格式不正确时返回错误
+ */
+func OpenBreachChecker(path string) (*BreachChecker, error) {
+	if path == "" {
+		return nil, fmt.Errorf("泄露数据路径不能为空")
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取泄露数据失败: %w", err)
+	}
+
+	if info.IsDir() {
+		// 校验第一个范围文件的格式
+		lines, err := readBreachRangeFile(filepath.Join(path, strings.Repeat("0", breachPrefixLength)+".txt"))
+		if err != nil {
+			return nil, fmt.Errorf("范围目录格式不正确: %w", err)
+		}
+		if len(lines) == 0 || !breachRangeLinePattern.MatchString(lines[0]) {
+			return nil, fmt.Errorf("范围目录格式不正确: 范围文件内容应为“哈希后缀:次数”")
+		}
+		return &BreachChecker{path: path, layout: BreachLayoutRangeDir}, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开泄露数据文件失败: %w", err)
+	}
+	first, _, err := readBreachLine(file, info.Size(), 0)
+	if err != nil || !breachFileLinePattern.MatchString(first) {
+		file.Close()
+		return nil, fmt.Errorf("泄露数据文件格式不正确: 每行应为“SHA-1哈希:次数”并按哈希排序")
+	}
+
+	return &BreachChecker{path: path, layout: BreachLayoutFile, file: file, size: info.Size()}, nil
+}
+
+/**
+ * Info 获取泄露数据信息
+ * @return models.BreachDataInfo 泄露数据信息
+ */
+func (bc *BreachChecker) Info() models.BreachDataInfo {
+	return models.BreachDataInfo{Path: bc.path, Layout: bc.layout, Size: bc.size}
+}
+
+/**
+ * Close 关闭泄露数据
+ * @return error 错误信息
+ */
+func (bc *BreachChecker) Close() error {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	if bc.file == nil {
+		return nil
+	}
+	err := bc.file.Close()
+	bc.file = nil
+	return err
+}
+
+/**
+ * BreachCount 查询密码在泄露数据中出现的次数
+ * @param password 密码
+ * @return int 出现次数，未泄露时为0
+ * @return error 错误信息
+ */
+func (bc *BreachChecker) BreachCount(password string) (int, error) {
+	sum := sha1.Sum([]byte(password))
+	return bc.HashCount(strings.ToUpper(hex.EncodeToString(sum[:])))
+}
+
+/**
+ * HashCount 查询SHA-1哈希在泄露数据中出现的次数
+ * @param hash 40位十六进制SHA-1哈希
+ * @return int 出现次数，未泄露时为0
+ * @return error 错误信息
+ */
+func (bc *BreachChecker) HashCount(hash string) (int, error) {
+	hash = strings.ToUpper(hash)
+	if len(hash) != breachHashLength {
+		return 0, fmt.Errorf("SHA-1哈希长度应为%d位", breachHashLength)
+	}
+
+	if bc.layout == BreachLayoutRangeDir {
+		return bc.rangeDirCount(hash)
+	}
+	return bc.fileCount(hash)
+}
+
+/**
+ * fileCount 在单个数据文件中二分查找哈希
+ * @param hash 大写的SHA-1哈希
+ * @return int 出现次数
+ * @return error 错误信息
+ * @description 在字节偏移区间[lo, hi)内查找目标所在行的起始位置：取中点后的第一行比较，
+ *              该行小于目标时目标在该行之后，否则目标所在行一定从中点之前开始
+ */
+func (bc *BreachChecker) fileCount(hash string) (int, error) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	if bc.file == nil {
+		return 0, fmt.Errorf("泄露数据已关闭")
+	}
+
+	lo, hi := int64(0), bc.size
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		line, next, err := readBreachLine(bc.file, bc.size, mid)
+		if err == io.EOF {
+			hi = mid
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		lineHash, count, err := parseBreachLine(line, breachHashLength)
+		if err != nil {
+			return 0, err
+		}
+		switch {
+		case lineHash == hash:
+			return count, nil
+		case lineHash < hash:
+			lo = next
+		default:
+			hi = mid
+		}
+	}
+	return 0, nil
+}
+
+/**
+ * rangeDirCount 在范围目录对应前缀的文件中二分查找哈希后缀
+ * @param hash 大写的SHA-1哈希
+ * @return int 出现次数
+ * @return error 错误信息
+ */
+func (bc *BreachChecker) rangeDirCount(hash string) (int, error) {
+	prefix, suffix := hash[:breachPrefixLength], hash[breachPrefixLength:]
+	lines, err := readBreachRangeFile(filepath.Join(bc.path, prefix+".txt"))
+	if err != nil {
+		return 0, fmt.Errorf("读取范围文件%s失败: %w", prefix, err)
+	}
+
+	index := sort.Search(len(lines), func(i int) bool {
+		key := lines[i]
+		if len(key) > len(suffix) {
+			key = key[:len(suffix)]
+		}
+		return strings.ToUpper(key) >= suffix
+	})
+	if index == len(lines) {
+		return 0, nil
+	}
+	lineSuffix, count, err := parseBreachLine(lines[index], len(suffix))
+	if err != nil || lineSuffix != suffix {
+		return 0, err
+	}
+	return count, nil
+}
+
+/**
+ * readBreachLine 读取从offset开始（含）的第一个完整行
+ * @param file 数据文件
+ * @param size 文件大小
+ * @param offset 字节偏移
+ * @return string 行内容（不含换行符）
+ * @return int64 下一行的起始偏移
+ * @return error offset之后没有完整行时返回io.EOF
+ */
+func readBreachLine(file *os.File, size, offset int64) (string, int64, error) {
+	start := offset
+	if offset > 0 {
+		// 从前一个字节开始读，若前一个字节是换行符则offset本身就是行首
+		start = offset - 1
+	}
+	reader := bufio.NewReader(io.NewSectionReader(file, start, size-start))
+
+	if offset > 0 {
+		skipped, err := reader.ReadString('\n')
+		if err != nil {
+			return "", 0, io.EOF
+		}
+		start += int64(len(skipped))
+	}
+
+	line, err := reader.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", 0, fmt.Errorf("读取泄露数据失败: %w", err)
+	}
+	if line == "" {
+		return "", 0, io.EOF
+	}
+	return strings.TrimRight(line, "\r\n"), start + int64(len(line)), nil
+}
+
+/**
+ * readBreachRangeFile 读取范围文件的全部行
+ * @param path 范围文件路径
+ * @return []string 非空行
+ * @return error 错误信息
+ */
+func readBreachRangeFile(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, nil
+}
+
+/**
+ * parseBreachLine 解析“哈希:次数”行
+ * @param line 行内容
+ * @param hashLength 哈希部分的长度
+ * @return string 大写的哈希部分
+ * @return int 次数
+ * @return error 格式不正确时返回错误
+ */
+func parseBreachLine(line string, hashLength int) (string, int, error) {
+	if len(line) < hashLength+2 || line[hashLength] != ':' {
+		return "", 0, fmt.Errorf("泄露数据格式不正确: %q", line)
+	}
+	count, err := strconv.Atoi(line[hashLength+1:])
+	if err != nil {
+		return "", 0, fmt.Errorf("泄露数据格式不正确: %q", line)
+	}
+	return strings.ToUpper(line[:hashLength]), count, nil
+}
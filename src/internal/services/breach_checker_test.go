@@ -0,0 +1,146 @@
+package services
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"wepassword/internal/models"
+)
+
+/**
+ * 离线泄露检查测试
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 测试单个文件和范围目录两种格式的查找、格式校验以及健康报告中的泄露标记
+ */
+
+func sha1Upper(password string) string {
+	sum := sha1.Sum([]byte(password))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// breachTestData 生成测试用的泄露数据：密码 -> 次数
+func breachTestData() map[string]int {
+	data := make(map[string]int)
+	for i := 0; i < 300; i++ {
+		data[fmt.Sprintf("leaked-%d", i)] = i + 1
+	}
+	return data
+}
+
+func writeBreachFile(t *testing.T, data map[string]int) string {
+	t.Helper()
+	lines := make([]string, 0, len(data))
+	for password, count := range data {
+		lines = append(lines, fmt.Sprintf("%s:%d", sha1Upper(password), count))
+	}
+	sort.Strings(lines)
+
+	// 官方数据使用CRLF换行
+	path := filepath.Join(t.TempDir(), "pwned-passwords-sha1-ordered-by-hash.txt")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\r\n")+"\r\n"), 0600); err != nil {
+		t.Fatalf("写入泄露数据失败: %v", err)
+	}
+	return path
+}
+
+func writeBreachRangeDir(t *testing.T, data map[string]int) string {
+	t.Helper()
+	ranges := map[string][]string{"00000": {"0005AD76BD555C1D6D771DE417A4B87E4B4:10"}}
+	for password, count := range data {
+		hash := sha1Upper(password)
+		ranges[hash[:5]] = append(ranges[hash[:5]], fmt.Sprintf("%s:%d", hash[5:], count))
+	}
+
+	dir := t.TempDir()
+	for prefix, lines := range ranges {
+		sort.Strings(lines)
+		if err := os.WriteFile(filepath.Join(dir, prefix+".txt"), []byte(strings.Join(lines, "\r\n")), 0600); err != nil {
+			t.Fatalf("写入范围文件失败: %v", err)
+		}
+	}
+	return dir
+}
+
+func TestBreachChecker(t *testing.T) {
+	data := breachTestData()
+
+	layouts := map[string]string{
+		BreachLayoutFile:     writeBreachFile(t, data),
+		BreachLayoutRangeDir: writeBreachRangeDir(t, data),
+	}
+	for layout, path := range layouts {
+		checker, err := OpenBreachChecker(path)
+		if err != nil {
+			t.Fatalf("%s: 打开泄露数据失败: %v", layout, err)
+		}
+		if checker.Info().Layout != layout {
+			t.Errorf("期望格式%s，实际%s", layout, checker.Info().Layout)
+		}
+
+		for password, expected := range data {
+			count, err := checker.BreachCount(password)
+			if err != nil {
+				t.Fatalf("%s: 查询失败: %v", layout, err)
+			}
+			if count != expected {
+				t.Errorf("%s: %s 期望%d次，实际%d次", layout, password, expected, count)
+			}
+		}
+
+		// 范围目录中不存在对应前缀文件时返回错误，单个文件中查不到时返回0
+		count, err := checker.BreachCount("Zr8@pWn4#Lq6!Yd2")
+		if layout == BreachLayoutFile && (err != nil || count != 0) {
+			t.Errorf("%s: 未泄露的密码应返回0: %d, %v", layout, count, err)
+		}
+		if layout == BreachLayoutRangeDir && err == nil {
+			t.Errorf("%s: 缺少范围文件时应返回错误", layout)
+		}
+		checker.Close()
+	}
+}
+
+func TestOpenBreachChecker_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	invalid := filepath.Join(dir, "invalid.txt")
+	os.WriteFile(invalid, []byte("not a hash list\n"), 0600)
+
+	for _, path := range []string{"", filepath.Join(dir, "missing.txt"), invalid, dir} {
+		if _, err := OpenBreachChecker(path); err == nil {
+			t.Errorf("无效的泄露数据应返回错误: %q", path)
+		}
+	}
+}
+
+func TestBuildHealthReport_Breached(t *testing.T) {
+	checker, err := OpenBreachChecker(writeBreachFile(t, breachTestData()))
+	if err != nil {
+		t.Fatalf("打开泄露数据失败: %v", err)
+	}
+	defer checker.Close()
+
+	now := time.Now()
+	accounts := []models.AccountDecrypted{
+		{ID: "leaked", Title: "已泄露", Password: "leaked-41", UpdatedAt: now},
+		{ID: "safe", Title: "未泄露", Password: "Zr8@pWn4#Lq6!Yd2", UpdatedAt: now},
+	}
+	report, err := BuildHealthReport(accounts, nil, models.HealthReportOptions{}, checker, now)
+	if err != nil {
+		t.Fatalf("生成健康报告失败: %v", err)
+	}
+	if !report.BreachChecked || report.BreachedCount != 1 {
+		t.Fatalf("泄露统计错误: %+v", report)
+	}
+	for _, health := range report.Accounts {
+		if healthCodes(health)[HealthBreached] != (health.AccountID == "leaked") {
+			t.Errorf("账号%s泄露标记错误: %+v", health.AccountID, health.Findings)
+		}
+	}
+}
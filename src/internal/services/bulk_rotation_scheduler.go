@@ -0,0 +1,319 @@
+package services
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"wepassword/internal/logger"
+	"wepassword/internal/models"
+	"wepassword/internal/utils"
+)
+
+/**
+ * 批量轮换调度
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 到达计划时间后逐个账号执行引导式轮换：生成新密码并开始轮换，再提交轮换，
+ *              提交时由UpdateAccount执行账号的轮换钩子。某个账号失败时保留其轮换状态，继续处理下一个账号。
+ *              20251021 陈凤庆 只轮换启用了钩子的账号；账号已有进行中的轮换时不做处理，由用户提交或回滚
+ *              同一时间只有一个任务，任务保存在内存中，应用退出或密码库锁定后需要重新安排
+ */
+
+// 批量轮换任务状态
+const (
+	BulkRotationScheduled = "scheduled"
+	BulkRotationRunning   = "running"
+	BulkRotationCompleted = "completed"
+	BulkRotationCancelled = "cancelled"
+)
+
+/**
+ * PasswordGenerator 按类型生成新密码
+ * @param typeID 类型ID
+ * @return string 新密码，类型未绑定规则时为空字符串
+ * @return string 使用的规则ID
+ * @return error 错误信息
+ */
+type PasswordGenerator func(typeID string) (string, string, error)
+
+/**
+ * BulkRotationScheduler 批量轮换调度器
+ */
+type BulkRotationScheduler struct {
+	accountService  *AccountService
+	rotationService *PasswordRotationService
+	hookService     *RotationHookService
+
+	mu     sync.Mutex
+	job    *models.BulkRotationJob
+	cancel chan struct{}
+}
+
+/**
+ * NewBulkRotationScheduler 创建批量轮换调度器
+ * @param accountService 账号服务
+ * @param rotationService 密码轮换服务
+ * @param hookService 轮换钩子服务，未指定账号时轮换所有启用钩子的账号
+ * @return *BulkRotationScheduler 批量轮换调度器实例
+ */
+func NewBulkRotationScheduler(accountService *AccountService, rotationService *PasswordRotationService, hookService *RotationHookService) *BulkRotationScheduler {
+	return &BulkRotationScheduler{
+		accountService:  accountService,
+		rotationService: rotationService,
+		hookService:     hookService,
+	}
+}
+
+/**
+ * Schedule 安排批量轮换
+ * @param accountIDs 需要轮换的账号ID，为空时轮换所有启用钩子的账号
+ * @param runAt 计划执行时间，早于当前时间时立即执行
+ * @param intervalSeconds 账号之间的间隔（秒）
+ * @param generate 生成新密码的方法
+ * @return models.BulkRotationJob 任务
+ * @return error 已有未结束的任务或指定的账号未启用轮换钩子时返回错误
+ */
+func (brs *BulkRotationScheduler) Schedule(accountIDs []string, runAt time.Time, intervalSeconds int, generate PasswordGenerator) (models.BulkRotationJob, error) {
+	if intervalSeconds < 0 {
+		return models.BulkRotationJob{}, fmt.Errorf("间隔时间不能为负数")
+	}
+	if generate == nil {
+		return models.BulkRotationJob{}, fmt.Errorf("未指定密码生成方法")
+	}
+
+	if len(accountIDs) == 0 {
+		hooks, err := brs.hookService.GetHooks()
+		if err != nil {
+			return models.BulkRotationJob{}, err
+		}
+		for _, hook := range hooks {
+			if hook.Enabled {
+				accountIDs = append(accountIDs, hook.AccountID)
+			}
+		}
+		if len(accountIDs) == 0 {
+			return models.BulkRotationJob{}, fmt.Errorf("没有启用轮换钩子的账号")
+		}
+	} else {
+		for _, accountID := range accountIDs {
+			if err := brs.checkHookEnabled(accountID); err != nil {
+				return models.BulkRotationJob{}, err
+			}
+		}
+	}
+
+	brs.mu.Lock()
+	defer brs.mu.Unlock()
+
+	if brs.job != nil && (brs.job.Status == BulkRotationScheduled || brs.job.Status == BulkRotationRunning) {
+		return models.BulkRotationJob{}, fmt.Errorf("已有未结束的批量轮换任务")
+	}
+
+	job := &models.BulkRotationJob{
+		ID:              utils.GenerateGUID(),
+		AccountIDs:      append([]string(nil), accountIDs...),
+		RunAt:           runAt,
+		IntervalSeconds: intervalSeconds,
+		Status:          BulkRotationScheduled,
+		Results:         make([]models.BulkRotationResult, 0, len(accountIDs)),
+	}
+	cancel := make(chan struct{})
+	brs.job, brs.cancel = job, cancel
+
+	go brs.run(job, cancel, generate)
+	return copyBulkRotationJob(job), nil
+}
+
+/**
+ * GetJob 获取最近一次批量轮换任务
+ * @return *models.BulkRotationJob 任务副本，没有任务时为nil
+ */
+func (brs *BulkRotationScheduler) GetJob() *models.BulkRotationJob {
+	brs.mu.Lock()
+	defer brs.mu.Unlock()
+
+	if brs.job == nil {
+		return nil
+	}
+	job := copyBulkRotationJob(brs.job)
+	return &job
+}
+
+/**
+ * Cancel 取消未结束的批量轮换任务，正在处理的账号会处理完毕
+ * @return error 没有未结束的任务时返回错误
+ */
+func (brs *BulkRotationScheduler) Cancel() error {
+	brs.mu.Lock()
+	defer brs.mu.Unlock()
+
+	if brs.job == nil || (brs.job.Status != BulkRotationScheduled && brs.job.Status != BulkRotationRunning) {
+		return fmt.Errorf("没有未结束的批量轮换任务")
+	}
+	close(brs.cancel)
+	brs.finish(brs.job, BulkRotationCancelled)
+	return nil
+}
+
+/**
+ * run 等待计划时间后逐个轮换账号
+ * @param job 任务
+ * @param cancel 取消信号
+ * @param generate 生成新密码的方法
+ */
+func (brs *BulkRotationScheduler) run(job *models.BulkRotationJob, cancel chan struct{}, generate PasswordGenerator) {
+	if !brs.wait(time.Until(job.RunAt), cancel) {
+		return
+	}
+
+	brs.mu.Lock()
+	if job.Status != BulkRotationScheduled {
+		brs.mu.Unlock()
+		return
+	}
+	now := time.Now()
+	job.Status, job.StartedAt = BulkRotationRunning, &now
+	brs.mu.Unlock()
+	logger.Info("[批量轮换] 开始执行任务 %s，共%d个账号", job.ID, len(job.AccountIDs))
+
+	for i, accountID := range job.AccountIDs {
+		if i > 0 && !brs.wait(time.Duration(job.IntervalSeconds)*time.Second, cancel) {
+			return
+		}
+
+		result := brs.rotateAccount(accountID, generate)
+		if !result.Success {
+			logger.Error("[批量轮换] 账号 %s 轮换失败: %s", accountID, result.Error)
+		}
+
+		brs.mu.Lock()
+		job.Results = append(job.Results, result)
+		brs.mu.Unlock()
+	}
+
+	brs.mu.Lock()
+	defer brs.mu.Unlock()
+	if job.Status == BulkRotationRunning {
+		brs.finish(job, BulkRotationCompleted)
+		logger.Info("[批量轮换] 任务 %s 执行完成", job.ID)
+	}
+}
+
+/**
+ * checkHookEnabled 检查账号是否启用了轮换钩子
+ * @param accountID 账号ID
+ * @return error 未设置或未启用钩子时返回错误
+ */
+func (brs *BulkRotationScheduler) checkHookEnabled(accountID string) error {
+	hook, err := brs.hookService.GetHook(accountID)
+	if err != nil {
+		return err
+	}
+	if hook == nil || !hook.Enabled {
+		return fmt.Errorf("账号未启用轮换钩子: %s", accountID)
+	}
+	return nil
+}
+
+/**
+ * rotateAccount 轮换单个账号
+ * @param accountID 账号ID
+ * @param generate 生成新密码的方法
+ * @return models.BulkRotationResult 轮换结果，账号未启用钩子或已有进行中的轮换时失败
+ */
+func (brs *BulkRotationScheduler) rotateAccount(accountID string, generate PasswordGenerator) models.BulkRotationResult {
+	result := models.BulkRotationResult{AccountID: accountID}
+
+	account, err := brs.accountService.GetAccountByID(accountID)
+	if err != nil {
+		result.Error = fmt.Sprintf("获取账号失败: %v", err)
+		return result
+	}
+	result.Title = account.Title
+
+	// 钩子可能在安排任务后被禁用，执行时再检查一次
+	if err := brs.checkHookEnabled(accountID); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	rotation, err := brs.rotationService.GetRotation(accountID)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	if rotation != nil {
+		result.Error = "账号已有未完成的密码轮换，请先提交或回滚"
+		return result
+	}
+
+	password, ruleID, err := generate(account.TypeID)
+	if err != nil {
+		result.Error = fmt.Sprintf("生成新密码失败: %v", err)
+		return result
+	}
+	if password == "" {
+		result.Error = "账号所属类型及其分组均未绑定默认密码规则"
+		return result
+	}
+	if _, err := brs.rotationService.StartRotation(accountID, password, ruleID); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	if err := brs.rotationService.CommitRotation(accountID); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Success = true
+	return result
+}
+
+/**
+ * wait 等待指定时间
+ * @param d 等待时间
+ * @param cancel 取消信号
+ * @return bool 是否等待完毕，取消时返回false
+ */
+func (brs *BulkRotationScheduler) wait(d time.Duration, cancel chan struct{}) bool {
+	if d <= 0 {
+		select {
+		case <-cancel:
+			return false
+		default:
+			return true
+		}
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-cancel:
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+/**
+ * finish 结束任务，调用方需持有锁
+ * @param job 任务
+ * @param status 结束状态
+ */
+func (brs *BulkRotationScheduler) finish(job *models.BulkRotationJob, status string) {
+	now := time.Now()
+	job.Status, job.FinishedAt = status, &now
+}
+
+/**
+ * copyBulkRotationJob 复制任务，避免调用方读取时与执行中的任务产生数据竞争
+ * @param job 任务
+ * @return models.BulkRotationJob 任务副本
+ */
+func copyBulkRotationJob(job *models.BulkRotationJob) models.BulkRotationJob {
+	copied := *job
+	copied.AccountIDs = append([]string(nil), job.AccountIDs...)
+	copied.Results = append([]models.BulkRotationResult(nil), job.Results...)
+	return copied
+}
@@ -0,0 +1,648 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"wepassword/internal/bitwarden"
+	"wepassword/internal/logger"
+	"wepassword/internal/models"
+	"wepassword/internal/utils"
+)
+
+/**
+ * CSV导入
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 导入浏览器和其他密码管理器导出的CSV文件：内置Chrome、Edge、Firefox、LastPass、KeePassXC和1Password
+ *              的列格式，可按标题行自动识别；未知格式可手动指定每个字段对应的列。导入前可预览转换结果，
+ *              所有条目导入到指定的类型中，同一类型下标题、用户名和网址都相同的账号视为重复并跳过。
+ *              未映射的列作为自定义字段、原分组和标签写入备注，两步验证密钥转换为otpauth://链接
+ */
+
+// CSV字段
+const (
+	CSVFieldTitle     = "title"
+	CSVFieldUsername  = "username"
+	CSVFieldPassword  = "password"
+	CSVFieldURL       = "url"
+	CSVFieldNotes     = "notes"
+	CSVFieldOTP       = "totp"
+	CSVFieldGroup     = "group"
+	CSVFieldTags      = "tags"
+	CSVFieldFavorite  = "favorite"
+	CSVFieldCreatedAt = "created_at"
+	CSVFieldUpdatedAt = "updated_at"
+)
+
+// CSVProfileCustom 手动指定列映射
+const CSVProfileCustom = "custom"
+
+// csvPreviewRows 预览的行数
+const csvPreviewRows = 20
+
+// lastPassSecureNoteURL LastPass安全笔记的网址
+const lastPassSecureNoteURL = "http://sn"
+
+/**
+ * CSVProfile 内置的CSV格式
+ */
+type CSVProfile struct {
+	ID       string            `json:"id"`       // 格式ID
+	Name     string            `json:"name"`     // 显示名称
+	Columns  map[string]string `json:"columns"`  // 字段 -> 列标题
+	Optional []string          `json:"optional"` // 旧版本导出中可能没有的列，自动识别时不要求存在
+	Ignore   []string          `json:"ignore"`   // 不导入的列
+}
+
+// csvProfiles 内置格式，按自动识别的顺序排列：列较多的格式在前，避免被列较少的格式误识别
+var csvProfiles = []CSVProfile{
+	{
+		ID:   "keepassxc",
+		Name: "KeePassXC",
+		Columns: map[string]string{
+			CSVFieldGroup: "Group", CSVFieldTitle: "Title", CSVFieldUsername: "Username", CSVFieldPassword: "Password",
+			CSVFieldURL: "URL", CSVFieldNotes: "Notes", CSVFieldOTP: "TOTP",
+			CSVFieldUpdatedAt: "Last Modified", CSVFieldCreatedAt: "Created",
+		},
+		Optional: []string{"TOTP", "Last Modified", "Created"},
+		Ignore:   []string{"Icon"},
+	},
+	{
+		ID:   "lastpass",
+		Name: "LastPass",
+		Columns: map[string]string{
+			CSVFieldURL: "url", CSVFieldUsername: "username", CSVFieldPassword: "password", CSVFieldOTP: "totp",
+			CSVFieldNotes: "extra", CSVFieldTitle: "name", CSVFieldGroup: "grouping", CSVFieldFavorite: "fav",
+		},
+		Optional: []string{"totp"},
+	},
+	{
+		ID:   "1password",
+		Name: "1Password",
+		Columns: map[string]string{
+			CSVFieldTitle: "Title", CSVFieldURL: "Url", CSVFieldUsername: "Username", CSVFieldPassword: "Password",
+			CSVFieldOTP: "OTPAuth", CSVFieldFavorite: "Favorite", CSVFieldTags: "Tags", CSVFieldNotes: "Notes",
+		},
+		Optional: []string{"OTPAuth", "Favorite", "Tags"},
+		Ignore:   []string{"Archived"},
+	},
+	{
+		ID:   "firefox",
+		Name: "Firefox",
+		Columns: map[string]string{
+			CSVFieldURL: "url", CSVFieldUsername: "username", CSVFieldPassword: "password",
+			CSVFieldCreatedAt: "timeCreated", CSVFieldUpdatedAt: "timePasswordChanged",
+		},
+		Ignore: []string{"httpRealm", "formActionOrigin", "guid", "timeLastUsed"},
+	},
+	{
+		ID:   "chrome",
+		Name: "Chrome",
+		Columns: map[string]string{
+			CSVFieldTitle: "name", CSVFieldURL: "url", CSVFieldUsername: "username", CSVFieldPassword: "password", CSVFieldNotes: "note",
+		},
+		Optional: []string{"note"},
+	},
+	{
+		// Edge与Chrome的导出格式相同，自动识别时识别为Chrome
+		ID:   "edge",
+		Name: "Edge",
+		Columns: map[string]string{
+			CSVFieldTitle: "name", CSVFieldURL: "url", CSVFieldUsername: "username", CSVFieldPassword: "password", CSVFieldNotes: "note",
+		},
+		Optional: []string{"note"},
+	},
+}
+
+/**
+ * CSVImportOptions CSV导入选项
+ */
+type CSVImportOptions struct {
+	ImportPath string         `json:"import_path"` // CSV文件路径
+	Profile    string         `json:"profile"`     // 格式ID，为空时按标题行自动识别，custom时使用Mapping
+	Mapping    map[string]int `json:"mapping"`     // 手动映射：字段 -> 列序号（从0开始）
+	NoHeader   bool           `json:"no_header"`   // 手动映射时文件没有标题行
+	TypeID     string         `json:"type_id"`     // 导入到的类型，预览时可为空
+}
+
+/**
+ * CSVPreview CSV导入预览
+ */
+type CSVPreview struct {
+	Profile   string          `json:"profile"`    // 使用的格式ID
+	Headers   []string        `json:"headers"`    // 列标题，没有标题行时为"列1"、"列2"……
+	Mapping   map[string]int  `json:"mapping"`    // 实际使用的列映射
+	Rows      []CSVPreviewRow `json:"rows"`       // 前20行的转换结果
+	TotalRows int             `json:"total_rows"` // 数据行总数
+}
+
+/**
+ * CSVPreviewRow 预览行，不包含密码明文
+ */
+type CSVPreviewRow struct {
+	Line        int    `json:"line"`         // 在文件中的行号
+	Title       string `json:"title"`        // 标题
+	Username    string `json:"username"`     // 用户名
+	URL         string `json:"url"`          // 网址
+	HasPassword bool   `json:"has_password"` // 是否有密码
+	Notes       string `json:"notes"`        // 备注
+	Error       string `json:"error"`        // 无法导入的原因
+}
+
+/**
+ * csvSource 解析后的CSV文件
+ */
+type csvSource struct {
+	profile string
+	headers []string
+	mapping map[string]int
+	ignore  map[int]bool
+	rows    [][]string
+	lines   []int // 每个数据行在文件中的行号
+}
+
+/**
+ * GetCSVProfiles 获取内置的CSV格式
+ * @return []CSVProfile 格式列表
+ */
+func GetCSVProfiles() []CSVProfile {
+	return append([]CSVProfile(nil), csvProfiles...)
+}
+
+/**
+ * PreviewCSV 预览CSV导入
+ * @param options 导入选项，不需要TypeID
+ * @return CSVPreview 预览结果
+ * @return error 文件无法读取、格式无法识别或列映射无效时返回错误
+ */
+func (is *ImportService) PreviewCSV(options CSVImportOptions) (CSVPreview, error) {
+	source, err := loadCSVSource(options)
+	if err != nil {
+		return CSVPreview{}, err
+	}
+
+	preview := CSVPreview{
+		Profile:   source.profile,
+		Headers:   source.headers,
+		Mapping:   source.mapping,
+		Rows:      make([]CSVPreviewRow, 0, csvPreviewRows),
+		TotalRows: len(source.rows),
+	}
+	for i, record := range source.rows {
+		if i >= csvPreviewRows {
+			break
+		}
+		row := CSVPreviewRow{Line: source.lines[i]}
+		account, _, err := source.convertRow(record)
+		if err != nil {
+			row.Error = err.Error()
+		} else {
+			row.Title, row.Username, row.URL = account.Title, account.Username, account.URL
+			row.HasPassword = account.Password != ""
+			row.Notes = account.Notes
+		}
+		preview.Rows = append(preview.Rows, row)
+	}
+	return preview, nil
+}
+
+/**
+ * ImportCSV 导入CSV文件到指定类型
+ * @param options 导入选项
+ * @return ImportResult 导入结果，EntryResults中包含每行的结果
+ * @return error 文件无法读取、格式无法识别、列映射无效或类型不存在时返回错误
+ */
+func (is *ImportService) ImportCSV(options CSVImportOptions) (ImportResult, error) {
+	logger.Info("[导入] 开始导入CSV文件，导入路径: %s, 格式: %s", options.ImportPath, options.Profile)
+
+	result := ImportResult{
+		SkippedAccountDetails: make([]SkippedAccountInfo, 0),
+		EntryResults:          make([]ImportEntryResult, 0),
+	}
+	if !is.dbManager.IsOpened() {
+		result.ErrorMessage = "数据库未打开"
+		return result, fmt.Errorf("数据库未打开")
+	}
+	if is.accountService.cryptoManager == nil {
+		result.ErrorMessage = "加密管理器未设置"
+		return result, fmt.Errorf("加密管理器未设置")
+	}
+	if _, err := is.typeService.GetTypeByID(options.TypeID); err != nil {
+		result.ErrorMessage = "请选择导入到的类型"
+		return result, fmt.Errorf("导入目标类型不存在: %w", err)
+	}
+
+	source, err := loadCSVSource(options)
+	if err != nil {
+		result.ErrorMessage = err.Error()
+		return result, err
+	}
+	logger.Info("[导入] ✅ CSV文件解析成功，格式: %s, 共 %d 行", source.profile, len(source.rows))
+
+	return is.importCSVSource(source, options.TypeID, result)
+}
+
+/**
+ * importCSVSource 将解析后的CSV导入指定类型
+ * @param source 解析后的CSV文件
+ * @param typeID 类型ID
+ * @param result 导入结果
+ * @return ImportResult 导入结果
+ * @return error 错误信息
+ */
+func (is *ImportService) importCSVSource(source *csvSource, typeID string, result ImportResult) (ImportResult, error) {
+	targets, err := is.newImportTargets(&result)
+	if err != nil {
+		return result, err
+	}
+
+	existing, err := is.accountService.GetAllAccounts()
+	if err != nil {
+		result.ErrorMessage = fmt.Sprintf("获取账号失败: %v", err)
+		return result, fmt.Errorf("获取账号失败: %w", err)
+	}
+	seen := make(map[string]string)
+	for _, account := range existing {
+		if account.TypeID == typeID {
+			seen[csvDuplicateKey(account)] = account.ID
+		}
+	}
+
+	for i, record := range source.rows {
+		result.TotalAccounts++
+		account, group, err := source.convertRow(record)
+		entryResult := ImportEntryResult{
+			Path:     group,
+			Title:    account.Title,
+			Warnings: make([]string, 0),
+		}
+		if err != nil {
+			targets.fail(&entryResult, fmt.Sprintf("第%d行: %v", source.lines[i], err))
+			continue
+		}
+
+		if id, ok := seen[csvDuplicateKey(account)]; ok {
+			entryResult.AccountID = id
+			targets.skip(&entryResult, account.Username, fmt.Sprintf("第%d行: 账号已存在", source.lines[i]))
+			continue
+		}
+
+		account.ID = utils.GenerateGUID()
+		account.TypeID = typeID
+		entryResult.AccountID = account.ID
+		if err := is.createAccountWithID(account); err != nil {
+			logger.Error("[导入] 创建账号失败: 第%d行, Title=%s, 错误=%v", source.lines[i], account.Title, err)
+			targets.fail(&entryResult, fmt.Sprintf("第%d行: %v", source.lines[i], err))
+			continue
+		}
+		seen[csvDuplicateKey(account)] = account.ID
+		targets.imported(&entryResult)
+	}
+
+	result.Success = true
+	logger.Info("[导入] 🎉 CSV导入完成: 总数=%d, 导入=%d, 跳过=%d, 错误=%d",
+		result.TotalAccounts, result.ImportedAccounts, result.SkippedAccounts, result.ErrorAccounts)
+	return result, nil
+}
+
+func csvDuplicateKey(account models.AccountDecrypted) string {
+	return account.Title + "\x00" + account.Username + "\x00" + account.URL
+}
+
+/**
+ * loadCSVSource 读取CSV文件并确定列映射
+ * @param options 导入选项
+ * @return *csvSource 解析后的CSV文件
+ * @return error 错误信息
+ */
+func loadCSVSource(options CSVImportOptions) (*csvSource, error) {
+	data, err := os.ReadFile(options.ImportPath)
+	if err != nil {
+		return nil, fmt.Errorf("读取导入文件失败: %w", err)
+	}
+	records, lines, err := parseCSV(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("CSV文件为空")
+	}
+
+	source := &csvSource{ignore: make(map[int]bool)}
+	if options.Profile == CSVProfileCustom {
+		source.profile = CSVProfileCustom
+		columns := 0
+		for _, record := range records {
+			if len(record) > columns {
+				columns = len(record)
+			}
+		}
+		if options.NoHeader {
+			source.headers = make([]string, columns)
+			for i := range source.headers {
+				source.headers[i] = fmt.Sprintf("列%d", i+1)
+			}
+			source.rows, source.lines = records, lines
+		} else {
+			source.headers = records[0]
+			source.rows, source.lines = records[1:], lines[1:]
+		}
+
+		source.mapping = make(map[string]int)
+		for field, index := range options.Mapping {
+			if !isCSVField(field) {
+				return nil, fmt.Errorf("未知的字段: %s", field)
+			}
+			if index < 0 {
+				continue
+			}
+			if index >= columns {
+				return nil, fmt.Errorf("字段%s对应的列%d不存在", field, index+1)
+			}
+			source.mapping[field] = index
+		}
+		if len(source.mapping) == 0 {
+			return nil, fmt.Errorf("请至少为一个字段指定对应的列")
+		}
+		return source, nil
+	}
+
+	source.headers = records[0]
+	source.rows, source.lines = records[1:], lines[1:]
+	profile, ok := findCSVProfile(options.Profile, source.headers)
+	if !ok {
+		if options.Profile != "" {
+			return nil, fmt.Errorf("未知的CSV格式: %s", options.Profile)
+		}
+		return nil, fmt.Errorf("无法识别CSV格式，请选择格式或手动指定列映射")
+	}
+
+	source.profile = profile.ID
+	source.mapping = make(map[string]int)
+	for field, header := range profile.Columns {
+		if index := csvHeaderIndex(source.headers, header); index >= 0 {
+			source.mapping[field] = index
+		}
+	}
+	if len(source.mapping) == 0 {
+		return nil, fmt.Errorf("CSV标题行与%s格式不符", profile.Name)
+	}
+	for _, header := range profile.Ignore {
+		if index := csvHeaderIndex(source.headers, header); index >= 0 {
+			source.ignore[index] = true
+		}
+	}
+	return source, nil
+}
+
+/**
+ * parseCSV 解析CSV内容，自动识别逗号、分号和制表符分隔，忽略UTF-8 BOM和空行
+ * @param data 文件内容
+ * @return [][]string 记录
+ * @return []int 每条记录在文件中的行号
+ * @return error 错误信息
+ */
+func parseCSV(data []byte) ([][]string, []int, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	firstLine := data
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		firstLine = data[:i]
+	}
+	delimiter := ','
+	for _, candidate := range []rune{';', '\t'} {
+		if bytes.Count(firstLine, []byte(string(candidate))) > bytes.Count(firstLine, []byte(string(delimiter))) {
+			delimiter = candidate
+		}
+	}
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	records := make([][]string, 0)
+	lines := make([]int, 0)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("解析CSV文件失败: %w", err)
+		}
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+		line, _ := reader.FieldPos(0)
+		records = append(records, record)
+		lines = append(lines, line)
+	}
+	return records, lines, nil
+}
+
+/**
+ * findCSVProfile 按ID查找格式，ID为空时按标题行自动识别
+ */
+func findCSVProfile(id string, headers []string) (CSVProfile, bool) {
+	for _, profile := range csvProfiles {
+		if id != "" {
+			if profile.ID == id {
+				return profile, true
+			}
+			continue
+		}
+		if matchesCSVProfile(profile, headers) {
+			return profile, true
+		}
+	}
+	return CSVProfile{}, false
+}
+
+/**
+ * matchesCSVProfile 标题行是否包含格式的全部必需列
+ */
+func matchesCSVProfile(profile CSVProfile, headers []string) bool {
+	for _, header := range profile.Columns {
+		if csvHeaderIndex(headers, header) >= 0 {
+			continue
+		}
+		optional := false
+		for _, name := range profile.Optional {
+			optional = optional || name == header
+		}
+		if !optional {
+			return false
+		}
+	}
+	return true
+}
+
+func csvHeaderIndex(headers []string, name string) int {
+	for i, header := range headers {
+		if strings.EqualFold(strings.TrimSpace(header), name) {
+			return i
+		}
+	}
+	return -1
+}
+
+func isCSVField(field string) bool {
+	switch field {
+	case CSVFieldTitle, CSVFieldUsername, CSVFieldPassword, CSVFieldURL, CSVFieldNotes, CSVFieldOTP,
+		CSVFieldGroup, CSVFieldTags, CSVFieldFavorite, CSVFieldCreatedAt, CSVFieldUpdatedAt:
+		return true
+	}
+	return false
+}
+
+/**
+ * convertRow 将一行转换为账号（不含ID和类型）
+ * @param record 行数据
+ * @return models.AccountDecrypted 账号
+ * @return string 原分组
+ * @return error 没有可导入的内容时返回错误
+ */
+func (s *csvSource) convertRow(record []string) (models.AccountDecrypted, string, error) {
+	get := func(field string) string {
+		index, ok := s.mapping[field]
+		if !ok || index >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[index])
+	}
+
+	account := models.AccountDecrypted{
+		Title:       get(CSVFieldTitle),
+		Username:    get(CSVFieldUsername),
+		Password:    get(CSVFieldPassword),
+		URL:         get(CSVFieldURL),
+		IsFavorite:  parseCSVBool(get(CSVFieldFavorite)),
+		CreatedAt:   parseCSVTime(get(CSVFieldCreatedAt)),
+		UpdatedAt:   parseCSVTime(get(CSVFieldUpdatedAt)),
+		InputMethod: 1,
+	}
+	// 密码保留首尾空格
+	if index, ok := s.mapping[CSVFieldPassword]; ok && index < len(record) {
+		account.Password = record[index]
+	}
+	if account.URL == lastPassSecureNoteURL {
+		account.URL = ""
+	}
+	notes := strings.TrimRight(get(CSVFieldNotes), "\n")
+	group := get(CSVFieldGroup)
+
+	if account.Title == "" && account.Username == "" && account.Password == "" && account.URL == "" && notes == "" {
+		return account, group, fmt.Errorf("没有可导入的内容")
+	}
+	if account.Title == "" {
+		account.Title = csvTitleFromURL(account.URL)
+	}
+	if account.Title == "" {
+		account.Title = account.Username
+	}
+	if account.Title == "" {
+		account.Title = "未命名条目"
+	}
+
+	now := time.Now()
+	if account.CreatedAt.IsZero() {
+		account.CreatedAt = account.UpdatedAt
+	}
+	if account.CreatedAt.IsZero() {
+		account.CreatedAt = now
+	}
+	if account.UpdatedAt.IsZero() {
+		account.UpdatedAt = account.CreatedAt
+	}
+
+	mapped := make(map[int]bool, len(s.mapping))
+	for _, index := range s.mapping {
+		mapped[index] = true
+	}
+	fields := make([]string, 0)
+	for i, value := range record {
+		value = strings.TrimSpace(value)
+		if mapped[i] || s.ignore[i] || value == "" {
+			continue
+		}
+		name := fmt.Sprintf("列%d", i+1)
+		if i < len(s.headers) && strings.TrimSpace(s.headers[i]) != "" {
+			name = strings.TrimSpace(s.headers[i])
+		}
+		fields = append(fields, formatNotesField(bitwarden.Field{Name: name, Value: value, Type: bitwarden.FieldTypeText}))
+	}
+
+	sections := []string{notes}
+	if len(fields) > 0 {
+		sections = append(sections, notesCustomFieldsHeader+strings.Join(fields, "\n"))
+	}
+	if tags := get(CSVFieldTags); tags != "" {
+		sections = append(sections, "标签: "+tags)
+	}
+	if group != "" {
+		sections = append(sections, "原分组: "+group)
+	}
+	if otp := normalizeOTP(get(CSVFieldOTP), account.Title, account.Username); otp != "" {
+		sections = append(sections, notesOTPPrefix+otp)
+	}
+	parts := make([]string, 0, len(sections))
+	for _, section := range sections {
+		if section != "" {
+			parts = append(parts, section)
+		}
+	}
+	account.Notes = strings.Join(parts, "\n\n")
+	return account, group, nil
+}
+
+/**
+ * csvTitleFromURL 没有标题时使用网址的主机名，去掉www.前缀
+ */
+func csvTitleFromURL(rawURL string) string {
+	if rawURL == "" {
+		return ""
+	}
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Hostname() == "" {
+		return ""
+	}
+	return strings.TrimPrefix(parsed.Hostname(), "www.")
+}
+
+func parseCSVBool(value string) bool {
+	switch strings.ToLower(value) {
+	case "1", "true", "yes", "y", "是":
+		return true
+	}
+	return false
+}
+
+/**
+ * parseCSVTime 解析时间：Unix时间戳（秒或毫秒）或常见的日期时间格式，无法解析时返回零值
+ */
+func parseCSVTime(value string) time.Time {
+	if value == "" {
+		return time.Time{}
+	}
+	if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if n > 1e11 {
+			return time.UnixMilli(n)
+		}
+		return time.Unix(n, 0)
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,167 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+/**
+ * CSV导入测试
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 测试内置格式的自动识别、各格式的字段转换、手动列映射、预览以及重复导入时的跳过
+ */
+
+func writeTestCSV(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "import.csv")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestFindCSVProfile_Detect(t *testing.T) {
+	cases := []struct {
+		header string
+		want   string
+	}{
+		{"name,url,username,password,note", "chrome"},
+		{"name,url,username,password", "chrome"},
+		{"url,username,password,httpRealm,formActionOrigin,guid,timeCreated,timeLastUsed,timePasswordChanged", "firefox"},
+		{"url,username,password,totp,extra,name,grouping,fav", "lastpass"},
+		{"url,username,password,extra,name,grouping,fav", "lastpass"},
+		{"Group,Title,Username,Password,URL,Notes,TOTP,Icon,Last Modified,Created", "keepassxc"},
+		{"Group,Title,Username,Password,URL,Notes", "keepassxc"},
+		{"Title,Url,Username,Password,OTPAuth,Favorite,Archived,Tags,Notes", "1password"},
+	}
+	for _, tc := range cases {
+		profile, ok := findCSVProfile("", strings.Split(tc.header, ","))
+		if !ok || profile.ID != tc.want {
+			t.Errorf("%s: 识别为%q，期望%q", tc.header, profile.ID, tc.want)
+		}
+	}
+	if _, ok := findCSVProfile("", []string{"a", "b"}); ok {
+		t.Error("未知格式不应被识别")
+	}
+}
+
+func TestImportCSV_Profiles(t *testing.T) {
+	is := newTestImportService(t)
+	group, err := is.groupService.CreateGroup("迁移")
+	if err != nil {
+		t.Fatal(err)
+	}
+	target, err := is.typeService.CreateType("浏览器", group.ID, "fa-folder")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chrome := writeTestCSV(t, "\xef\xbb\xbfname,url,username,password,note\n"+
+		"GitHub,https://github.com/login,alice,\" pw with spaces \",\"多行\n备注\"\n"+
+		",https://www.example.com/,bob,pw2,\n"+
+		",,,,\n")
+	result, err := is.ImportCSV(CSVImportOptions{ImportPath: chrome, TypeID: target.ID})
+	if err != nil {
+		t.Fatalf("导入失败: %v", err)
+	}
+	if result.TotalAccounts != 2 || result.ImportedAccounts != 2 || len(result.EntryResults) != 2 {
+		t.Fatalf("导入统计错误: %+v", result)
+	}
+	github, err := is.accountService.GetAccountByID(result.EntryResults[0].AccountID)
+	if err != nil || github.TypeID != target.ID || github.Password != " pw with spaces " || github.Notes != "多行\n备注" {
+		t.Errorf("Chrome条目错误: %+v, %v", github, err)
+	}
+	if example, _ := is.accountService.GetAccountByID(result.EntryResults[1].AccountID); example == nil || example.Title != "example.com" {
+		t.Errorf("没有标题时应使用网址主机名: %+v", example)
+	}
+
+	// 再次导入时同一类型下相同的账号跳过
+	again, err := is.ImportCSV(CSVImportOptions{ImportPath: chrome, TypeID: target.ID})
+	if err != nil || again.SkippedAccounts != 2 || again.ImportedAccounts != 0 {
+		t.Errorf("重复导入统计错误: %+v, %v", again, err)
+	}
+
+	lastpass := writeTestCSV(t, "url,username,password,totp,extra,name,grouping,fav\n"+
+		"https://mail.example.com,carol,pw3,JBSWY3DPEHPK3PXP,备注,邮箱,工作\\邮件,1\n"+
+		"http://sn,,,,笔记内容,安全笔记,,0\n")
+	result, err = is.ImportCSV(CSVImportOptions{ImportPath: lastpass, Profile: "lastpass", TypeID: target.ID})
+	if err != nil || result.ImportedAccounts != 2 {
+		t.Fatalf("LastPass导入失败: %+v, %v", result, err)
+	}
+	mail, err := is.accountService.GetAccountByID(result.EntryResults[0].AccountID)
+	if err != nil || !mail.IsFavorite || result.EntryResults[0].Path != "工作\\邮件" {
+		t.Fatalf("LastPass条目错误: %+v, %v", mail, err)
+	}
+	for _, want := range []string{"备注", "原分组: 工作\\邮件", "两步验证: otpauth://totp/", "secret=JBSWY3DPEHPK3PXP"} {
+		if !strings.Contains(mail.Notes, want) {
+			t.Errorf("备注中缺少%q: %s", want, mail.Notes)
+		}
+	}
+	if note, _ := is.accountService.GetAccountByID(result.EntryResults[1].AccountID); note == nil || note.URL != "" || note.Notes != "笔记内容" {
+		t.Errorf("LastPass安全笔记错误: %+v", note)
+	}
+
+	firefox := writeTestCSV(t, "url,username,password,httpRealm,formActionOrigin,guid,timeCreated,timeLastUsed,timePasswordChanged\n"+
+		"https://shop.example.com,dave,pw4,,https://shop.example.com,{abc},1700000000000,1700000000000,1710000000000\n")
+	result, err = is.ImportCSV(CSVImportOptions{ImportPath: firefox, TypeID: target.ID})
+	if err != nil || result.ImportedAccounts != 1 {
+		t.Fatalf("Firefox导入失败: %+v, %v", result, err)
+	}
+	shop, _ := is.accountService.GetAccountByID(result.EntryResults[0].AccountID)
+	if shop == nil || shop.Notes != "" || !shop.CreatedAt.Equal(time.UnixMilli(1700000000000)) {
+		t.Errorf("Firefox条目错误（忽略的列不应写入备注）: %+v", shop)
+	}
+}
+
+func TestImportCSV_CustomMapping(t *testing.T) {
+	is := newTestImportService(t)
+	group, _ := is.groupService.CreateGroup("手动")
+	target, err := is.typeService.CreateType("映射", group.ID, "fa-folder")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := writeTestCSV(t, "服务器;root;secret;10.0.0.1;机房A\n数据库;dba;secret2;10.0.0.2;\n")
+	options := CSVImportOptions{
+		ImportPath: path,
+		Profile:    CSVProfileCustom,
+		NoHeader:   true,
+		Mapping:    map[string]int{CSVFieldTitle: 0, CSVFieldUsername: 1, CSVFieldPassword: 2, CSVFieldURL: 3},
+		TypeID:     target.ID,
+	}
+
+	preview, err := is.PreviewCSV(options)
+	if err != nil {
+		t.Fatalf("预览失败: %v", err)
+	}
+	if preview.TotalRows != 2 || len(preview.Headers) != 5 || preview.Headers[4] != "列5" {
+		t.Fatalf("预览结果错误: %+v", preview)
+	}
+	if row := preview.Rows[0]; row.Line != 1 || row.Title != "服务器" || !row.HasPassword || row.Notes != notesCustomFieldsHeader+"列5: 机房A" {
+		t.Errorf("预览行错误: %+v", row)
+	}
+
+	result, err := is.ImportCSV(options)
+	if err != nil || result.ImportedAccounts != 2 {
+		t.Fatalf("导入失败: %+v, %v", result, err)
+	}
+	server, _ := is.accountService.GetAccountByID(result.EntryResults[0].AccountID)
+	if server == nil || server.Username != "root" || server.URL != "10.0.0.1" {
+		t.Errorf("手动映射条目错误: %+v", server)
+	}
+
+	options.Mapping = map[string]int{CSVFieldTitle: 9}
+	if _, err := is.PreviewCSV(options); err == nil {
+		t.Error("映射到不存在的列时应返回错误")
+	}
+	if _, err := is.PreviewCSV(CSVImportOptions{ImportPath: path}); err == nil {
+		t.Error("无法识别的格式应返回错误")
+	}
+	if _, err := is.ImportCSV(CSVImportOptions{ImportPath: path, Profile: CSVProfileCustom, Mapping: map[string]int{CSVFieldTitle: 0}}); err == nil {
+		t.Error("未指定类型时应返回错误")
+	}
+}
@@ -0,0 +1,422 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+)
+
+/**
+ * 自定义规则模式解析器
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 将自定义规则模式解析为语法树，按字符（rune）处理，支持完整Unicode。语法：
+ *   标识符      d、l、u、A、s 等，见 getCharsetForIdentifier
+ *   字符集      [abc]、[a-z0-9]、[αβγ]，支持范围和 \ 转义（\] \- \\）
+ *   转义        \x 表示字面字符x
+ *   引号字面值  "text" 或 'text'，内部可用 \" \' 转义
+ *   分组/选择   (abc|xyz|d{4})，等概率选择其中一个分支
+ *   打乱        <...> 生成内部内容后随机打乱字符顺序
+ *   重复        {n}、{m,n}（次数在m到n之间随机）、?（可选，等同于{0,1}），嵌套重复展开后整个模式最多生成1024个字符
+ *   其他字符    按字面值输出
+ */
+
+// 模式节点类型
+const (
+	patternNodeIdentifier = iota // 字符标识符
+	patternNodeCharset           // 自定义字符集 [...]
+	patternNodeLiteral           // 字面值（普通字符、转义、引号）
+	patternNodeGroup             // 选择分组 (a|b)
+	patternNodePermute           // 打乱分组 <...>
+)
+
+// 重复次数上限，防止生成过长的密码
+const maxPatternRepeat = 256
+
+// 20251021 陈凤庆 整个模式最多可生成的字符数上限：嵌套重复的次数会相乘，单独限制每个节点的次数不足以防止生成过长的密码
+const maxPatternLength = 1024
+
+/**
+ * patternNode 模式语法树节点
+ */
+type patternNode struct {
+	kind         int
+	pos          int             // 节点在模式中的位置（从1开始，按字符计）
+	ident        rune            // 标识符
+	charset      []rune          // 字符集
+	literal      []rune          // 字面值
+	alternatives [][]patternNode // 分组的各个分支
+	children     []patternNode   // 打乱分组的内容
+	quantified   bool            // 是否带重复次数
+	exactCount   bool            // 是否为固定次数 {n}
+	min          int             // 最少次数
+	max          int             // 最多次数
+}
+
+/**
+ * PatternError 模式解析错误
+ */
+type PatternError struct {
+	Pos int    // 出错位置（从1开始，按字符计）
+	Msg string // 错误说明
+}
+
+/**
+ * Error 实现error接口
+ */
+func (e *PatternError) Error() string {
+	return fmt.Sprintf("第%d个字符处: %s", e.Pos, e.Msg)
+}
+
+/**
+ * patternParser 模式解析器状态
+ */
+type patternParser struct {
+	runes []rune
+	pos   int
+}
+
+/**
+ * parsePatternTree 解析自定义规则模式
+ * @param pattern 自定义规则模式
+ * @return []patternNode 语法树
+ * @return error 错误信息（*PatternError）
+ */
+func parsePatternTree(pattern string) ([]patternNode, error) {
+	p := &patternParser{runes: []rune(pattern)}
+	nodes, err := p.parseSequence(0)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.runes) {
+		return nil, p.errorf(p.pos, "多余的'%c'", p.runes[p.pos])
+	}
+	return nodes, nil
+}
+
+/**
+ * errorf 构造解析错误
+ * @param index 出错的字符下标（从0开始）
+ */
+func (p *patternParser) errorf(index int, format string, args ...interface{}) error {
+	return &PatternError{Pos: index + 1, Msg: fmt.Sprintf(format, args...)}
+}
+
+/**
+ * parseSequence 解析一个节点序列，遇到结束符时返回
+ * @param terminator 结束符：0表示模式结尾，')' 表示分组，'>' 表示打乱分组
+ */
+func (p *patternParser) parseSequence(terminator rune) ([]patternNode, error) {
+	var nodes []patternNode
+	for p.pos < len(p.runes) {
+		r := p.runes[p.pos]
+		if terminator != 0 && (r == terminator || (terminator == ')' && r == '|')) {
+			break
+		}
+		if terminator == 0 && (r == ')' || r == '|') {
+			return nil, p.errorf(p.pos, "未匹配的'%c'", r)
+		}
+
+		node, err := p.parseAtom()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.parseQuantifier(&node); err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+
+		if patternNodesMaxLength(nodes) > maxPatternLength {
+			return nil, p.errorf(node.pos-1, "模式最多可生成的字符数超过%d", maxPatternLength)
+		}
+	}
+	return nodes, nil
+}
+
+/**
+ * patternNodesMaxLength 计算节点序列最多生成的字符数
+ * @param nodes 语法树节点
+ * @return int 最多字符数，超过 maxPatternLength 时返回 maxPatternLength+1
+ */
+func patternNodesMaxLength(nodes []patternNode) int {
+	total := 0
+	for _, node := range nodes {
+		total += patternNodeMaxLength(node)
+		if total > maxPatternLength {
+			return maxPatternLength + 1
+		}
+	}
+	return total
+}
+
+/**
+ * patternNodeMaxLength 计算节点（含重复次数）最多生成的字符数
+ * @param node 语法树节点
+ * @return int 最多字符数，超过 maxPatternLength 时返回 maxPatternLength+1
+ */
+func patternNodeMaxLength(node patternNode) int {
+	per := 1
+	switch node.kind {
+	case patternNodeLiteral:
+		per = len(node.literal)
+	case patternNodeGroup:
+		per = 0
+		for _, alternative := range node.alternatives {
+			if length := patternNodesMaxLength(alternative); length > per {
+				per = length
+			}
+		}
+	case patternNodePermute:
+		per = patternNodesMaxLength(node.children)
+	}
+
+	if per > 0 && node.max > maxPatternLength/per {
+		return maxPatternLength + 1
+	}
+	return per * node.max
+}
+
+/**
+ * parseAtom 解析单个原子
+ */
+func (p *patternParser) parseAtom() (patternNode, error) {
+	start := p.pos
+	r := p.runes[p.pos]
+
+	switch r {
+	case '\\':
+		if p.pos+1 >= len(p.runes) {
+			return patternNode{}, p.errorf(p.pos, "转义符'\\'后缺少字符")
+		}
+		p.pos += 2
+		return patternNode{kind: patternNodeLiteral, pos: start + 1, literal: []rune{p.runes[start+1]}}, nil
+
+	case '"', '\'':
+		return p.parseQuoted(r)
+
+	case '[':
+		return p.parseCharset()
+
+	case '(':
+		p.pos++
+		var alternatives [][]patternNode
+		for {
+			seq, err := p.parseSequence(')')
+			if err != nil {
+				return patternNode{}, err
+			}
+			alternatives = append(alternatives, seq)
+			if p.pos >= len(p.runes) {
+				return patternNode{}, p.errorf(start, "未找到匹配的')'")
+			}
+			if p.runes[p.pos] == ')' {
+				p.pos++
+				break
+			}
+			p.pos++ // 跳过 '|'
+		}
+		return patternNode{kind: patternNodeGroup, pos: start + 1, alternatives: alternatives}, nil
+
+	case '<':
+		p.pos++
+		children, err := p.parseSequence('>')
+		if err != nil {
+			return patternNode{}, err
+		}
+		if p.pos >= len(p.runes) {
+			return patternNode{}, p.errorf(start, "未找到匹配的'>'")
+		}
+		p.pos++
+		if len(children) == 0 {
+			return patternNode{}, p.errorf(start, "打乱分组不能为空")
+		}
+		return patternNode{kind: patternNodePermute, pos: start + 1, children: children}, nil
+
+	case '{':
+		return patternNode{}, p.errorf(p.pos, "重复次数'{'前缺少可重复的内容")
+
+	case '?':
+		return patternNode{}, p.errorf(p.pos, "'?'前缺少可选的内容")
+	}
+
+	p.pos++
+	if r < 128 {
+		if _, err := (&PasswordRuleService{}).getCharsetForIdentifier(byte(r)); err == nil {
+			return patternNode{kind: patternNodeIdentifier, pos: start + 1, ident: r}, nil
+		}
+	}
+	return patternNode{kind: patternNodeLiteral, pos: start + 1, literal: []rune{r}}, nil
+}
+
+/**
+ * parseQuoted 解析引号字面值
+ */
+func (p *patternParser) parseQuoted(quote rune) (patternNode, error) {
+	start := p.pos
+	p.pos++
+	var literal []rune
+	for p.pos < len(p.runes) {
+		r := p.runes[p.pos]
+		if r == '\\' && p.pos+1 < len(p.runes) {
+			literal = append(literal, p.runes[p.pos+1])
+			p.pos += 2
+			continue
+		}
+		if r == quote {
+			p.pos++
+			return patternNode{kind: patternNodeLiteral, pos: start + 1, literal: literal}, nil
+		}
+		literal = append(literal, r)
+		p.pos++
+	}
+	return patternNode{}, p.errorf(start, "未找到匹配的引号%c", quote)
+}
+
+/**
+ * parseCharset 解析自定义字符集 [...]
+ */
+func (p *patternParser) parseCharset() (patternNode, error) {
+	start := p.pos
+	p.pos++
+
+	// 先读取原始元素，记录是否为转义，便于识别范围
+	type element struct {
+		r       rune
+		escaped bool
+		index   int
+	}
+	var elements []element
+	closed := false
+	for p.pos < len(p.runes) {
+		r := p.runes[p.pos]
+		if r == '\\' {
+			if p.pos+1 >= len(p.runes) {
+				return patternNode{}, p.errorf(p.pos, "转义符'\\'后缺少字符")
+			}
+			elements = append(elements, element{r: p.runes[p.pos+1], escaped: true, index: p.pos})
+			p.pos += 2
+			continue
+		}
+		if r == ']' {
+			p.pos++
+			closed = true
+			break
+		}
+		elements = append(elements, element{r: r, index: p.pos})
+		p.pos++
+	}
+	if !closed {
+		return patternNode{}, p.errorf(start, "未找到匹配的']'")
+	}
+	if len(elements) == 0 {
+		return patternNode{}, p.errorf(start, "自定义字符集不能为空")
+	}
+
+	var charset []rune
+	for i := 0; i < len(elements); i++ {
+		e := elements[i]
+		// 范围 a-z：'-' 未转义且两侧都有字符
+		if i+2 < len(elements) && elements[i+1].r == '-' && !elements[i+1].escaped {
+			to := elements[i+2].r
+			if to < e.r {
+				return patternNode{}, p.errorf(e.index, "无效的字符范围%c-%c", e.r, to)
+			}
+			if int(to-e.r) >= 0x10000 {
+				return patternNode{}, p.errorf(e.index, "字符范围%c-%c过大", e.r, to)
+			}
+			for c := e.r; c <= to; c++ {
+				charset = append(charset, c)
+			}
+			i += 2
+			continue
+		}
+		charset = append(charset, e.r)
+	}
+
+	return patternNode{kind: patternNodeCharset, pos: start + 1, charset: charset}, nil
+}
+
+/**
+ * parseQuantifier 解析原子后的重复次数 {n}、{m,n} 或 ?
+ */
+func (p *patternParser) parseQuantifier(node *patternNode) error {
+	node.min, node.max = 1, 1
+	if p.pos >= len(p.runes) {
+		return nil
+	}
+
+	switch p.runes[p.pos] {
+	case '?':
+		node.quantified = true
+		node.min, node.max = 0, 1
+		p.pos++
+		return nil
+	case '{':
+	default:
+		return nil
+	}
+
+	start := p.pos
+	end := -1
+	for i := p.pos + 1; i < len(p.runes); i++ {
+		if p.runes[i] == '}' {
+			end = i
+			break
+		}
+	}
+	if end == -1 {
+		return p.errorf(start, "未找到匹配的'}'")
+	}
+
+	body := string(p.runes[start+1 : end])
+	parseCount := func(s string, offset int) (int, error) {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			return 0, p.errorf(offset, "无效的重复次数: %s", s)
+		}
+		if n > maxPatternRepeat {
+			return 0, p.errorf(offset, "重复次数不能超过%d", maxPatternRepeat)
+		}
+		return n, nil
+	}
+
+	comma := -1
+	for i, r := range []rune(body) {
+		if r == ',' {
+			comma = i
+			break
+		}
+	}
+
+	if comma == -1 {
+		n, err := parseCount(body, start+1)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return p.errorf(start+1, "重复次数必须大于0")
+		}
+		node.min, node.max = n, n
+		node.exactCount = true
+	} else {
+		bodyRunes := []rune(body)
+		minCount, err := parseCount(string(bodyRunes[:comma]), start+1)
+		if err != nil {
+			return err
+		}
+		maxCount, err := parseCount(string(bodyRunes[comma+1:]), start+comma+2)
+		if err != nil {
+			return err
+		}
+		if maxCount < minCount {
+			return p.errorf(start+1, "重复次数范围无效: 最大值%d小于最小值%d", maxCount, minCount)
+		}
+		if maxCount == 0 {
+			return p.errorf(start+1, "重复次数最大值必须大于0")
+		}
+		node.min, node.max = minCount, maxCount
+	}
+
+	node.quantified = true
+	p.pos = end + 1
+	return nil
+}
@@ -0,0 +1,39 @@
+package services
+
+/**
+ * 桌面通知
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 发送系统桌面通知，用于密码到期提醒等后台提示。
+ *              各平台的实现见 desktop_notifier_<平台>.go，目前仅Linux通过freedesktop通知接口实现
+ */
+
+// 通知紧急程度，与freedesktop通知规范一致
+const (
+	NotificationUrgencyLow      = 0
+	NotificationUrgencyNormal   = 1
+	NotificationUrgencyCritical = 2
+)
+
+// desktopNotificationAppName 通知中显示的应用名称
+const desktopNotificationAppName = "WePass"
+
+/**
+ * DesktopNotification 桌面通知内容
+ */
+type DesktopNotification struct {
+	Summary   string // 标题
+	Body      string // 正文
+	Urgency   int    // 紧急程度
+	TimeoutMs int32  // 显示时长（毫秒），-1表示由通知服务决定
+}
+
+/**
+ * SendDesktopNotification 发送桌面通知
+ * @param notification 通知内容
+ * @return uint32 通知服务返回的通知ID
+ * @return error 当前平台不支持或发送失败时返回错误
+ */
+func SendDesktopNotification(notification DesktopNotification) (uint32, error) {
+	return sendPlatformNotification(notification)
+}
@@ -0,0 +1,15 @@
+//go:build darwin
+
+package services
+
+import "fmt"
+
+/**
+ * sendPlatformNotification macOS平台暂不支持桌面通知
+ * @param notification 通知内容
+ * @return uint32 通知ID
+ * @return error 错误信息
+ */
+func sendPlatformNotification(notification DesktopNotification) (uint32, error) {
+	return 0, fmt.Errorf("macOS平台暂不支持桌面通知")
+}
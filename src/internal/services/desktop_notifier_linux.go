@@ -0,0 +1,58 @@
+//go:build linux
+
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+/**
+ * Linux桌面通知
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 通过会话总线调用 org.freedesktop.Notifications 接口发送通知，
+ *              GNOME、KDE等桌面环境的通知服务均实现了该接口
+ */
+
+const (
+	notificationsBusName    = "org.freedesktop.Notifications"
+	notificationsObjectPath = "/org/freedesktop/Notifications"
+	notificationsNotify     = notificationsBusName + ".Notify"
+
+	// notificationCallTimeout 调用通知服务的超时时间，避免通知服务无响应时阻塞
+	notificationCallTimeout = 5 * time.Second
+)
+
+/**
+ * sendPlatformNotification 通过D-Bus发送桌面通知
+ * @param notification 通知内容
+ * @return uint32 通知ID
+ * @return error 错误信息
+ */
+func sendPlatformNotification(notification DesktopNotification) (uint32, error) {
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return 0, fmt.Errorf("连接会话总线失败: %w", err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), notificationCallTimeout)
+	defer cancel()
+
+	hints := map[string]dbus.Variant{
+		"urgency": dbus.MakeVariant(byte(notification.Urgency)),
+	}
+	call := conn.Object(notificationsBusName, notificationsObjectPath).CallWithContext(ctx, notificationsNotify, 0,
+		desktopNotificationAppName, uint32(0), "", notification.Summary, notification.Body,
+		[]string{}, hints, notification.TimeoutMs)
+
+	var id uint32
+	if err := call.Store(&id); err != nil {
+		return 0, fmt.Errorf("发送桌面通知失败: %w", err)
+	}
+	return id, nil
+}
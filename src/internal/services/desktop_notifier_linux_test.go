@@ -0,0 +1,69 @@
+//go:build linux
+
+package services
+
+import (
+	"os"
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+)
+
+/**
+ * Linux桌面通知测试
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 在会话总线上注册模拟的通知服务，验证发送的通知内容。
+ *              需要会话总线，可使用 dbus-run-session -- go test ./internal/services/ 运行
+ */
+
+type fakeNotificationServer struct {
+	received chan []interface{}
+}
+
+func (f *fakeNotificationServer) Notify(appName string, replacesID uint32, icon, summary, body string,
+	actions []string, hints map[string]dbus.Variant, timeout int32) (uint32, *dbus.Error) {
+	f.received <- []interface{}{appName, summary, body, hints["urgency"].Value(), timeout}
+	return 42, nil
+}
+
+func TestSendDesktopNotification(t *testing.T) {
+	if os.Getenv("DBUS_SESSION_BUS_ADDRESS") == "" {
+		t.Skip("没有会话总线，跳过桌面通知测试")
+	}
+
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		t.Skipf("连接会话总线失败: %v", err)
+	}
+	defer conn.Close()
+
+	reply, err := conn.RequestName(notificationsBusName, dbus.NameFlagDoNotQueue)
+	if err != nil || reply != dbus.RequestNameReplyPrimaryOwner {
+		t.Skip("会话总线上已有通知服务，跳过桌面通知测试")
+	}
+
+	server := &fakeNotificationServer{received: make(chan []interface{}, 1)}
+	if err := conn.Export(server, notificationsObjectPath, notificationsBusName); err != nil {
+		t.Fatalf("注册模拟通知服务失败: %v", err)
+	}
+
+	id, err := SendDesktopNotification(DesktopNotification{
+		Summary:   "密码即将到期",
+		Body:      "VPN 还有3天到期",
+		Urgency:   NotificationUrgencyCritical,
+		TimeoutMs: -1,
+	})
+	if err != nil {
+		t.Fatalf("发送桌面通知失败: %v", err)
+	}
+	if id != 42 {
+		t.Errorf("期望通知ID为42，实际%d", id)
+	}
+
+	args := <-server.received
+	if args[0] != desktopNotificationAppName || args[1] != "密码即将到期" || args[2] != "VPN 还有3天到期" ||
+		args[3] != byte(NotificationUrgencyCritical) || args[4] != int32(-1) {
+		t.Errorf("通知内容错误: %v", args)
+	}
+}
@@ -258,18 +258,28 @@ func (es *ExportService) getAccountsToExport(options ExportOptions) ([]models.Ac
  * @param groupIDs 分组ID列表
  * @return []models.AccountDecrypted 账号列表
  * @return error 错误信息
+ * @modify 20251020 陈凤庆 递归包含子分组的账号，并按ID重新加载完整账号数据
  */
 func (es *ExportService) getAccountsByGroups(groupIDs []string) ([]models.AccountDecrypted, error) {
-	var allAccounts []models.AccountDecrypted
+	var accountIDs []string
 
 	for _, groupID := range groupIDs {
-		logger.Info("[导出] 获取分组账号，分组ID: %s", groupID)
-		accounts, err := es.accountService.GetAccountsByGroup(groupID)
+		logger.Info("[导出] 获取分组账号（含子分组），分组ID: %s", groupID)
+		conditions := fmt.Sprintf(`{"group_id":"%s","recursive":true}`, groupID)
+		accounts, err := es.accountService.GetAccountsByConditions(conditions)
 		if err != nil {
 			logger.Error("[导出] 获取分组账号失败，分组ID: %s, 错误: %v", groupID, err)
 			continue // 跳过获取失败的分组，继续处理其他分组
 		}
-		allAccounts = append(allAccounts, accounts...)
+		for _, account := range accounts {
+			accountIDs = append(accountIDs, account.ID)
+		}
+	}
+
+	// 列表查询不含密码等字段，按ID获取完整账号
+	allAccounts, err := es.getAccountsByIDs(accountIDs)
+	if err != nil {
+		return nil, err
 	}
 
 	// 去重处理（防止同一账号在多个分组中重复）
@@ -377,6 +387,7 @@ func (es *ExportService) getRelatedGroupsAndTypes(accounts []models.AccountDecry
 	}
 
 	// 获取分组信息
+	// 20251020 陈凤庆 同时导出祖先分组，保留嵌套层级
 	var groups []models.Group
 	for groupID := range groupIDSet {
 		group, err := es.groupService.GetGroupByID(groupID)
@@ -389,6 +400,22 @@ func (es *ExportService) getRelatedGroupsAndTypes(accounts []models.AccountDecry
 		}
 	}
 
+	ancestorSet := make(map[string]bool)
+	for _, group := range groups {
+		ancestors, err := es.groupService.GetGroupAncestors(group.ID)
+		if err != nil {
+			logger.Error("[导出] 获取祖先分组失败，ID: %s, 错误: %v", group.ID, err)
+			continue
+		}
+		for _, ancestor := range ancestors {
+			if groupIDSet[ancestor.ID] || ancestorSet[ancestor.ID] {
+				continue
+			}
+			ancestorSet[ancestor.ID] = true
+			groups = append(groups, ancestor)
+		}
+	}
+
 	return groups, types, nil
 }
 
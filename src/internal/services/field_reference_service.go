@@ -0,0 +1,290 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"wepassword/internal/logger"
+	"wepassword/internal/models"
+)
+
+/**
+ * 字段引用服务
+ * @author 陈凤庆
+ * @date 20251020
+ * @description 支持KeePass风格的账号字段引用，例如 {REF:P@I:<账号ID>}，
+ *              在读取时解析为被引用账号的实际字段值，修改源账号后引用方自动生效
+ */
+
+// 字段引用相关常量
+const (
+	MaxReferenceDepth = 10 // 引用解析最大深度，防止过深的引用链
+)
+
+// fieldReferencePattern 字段引用正则：{REF:<字段>@I:<账号ID>}
+// 字段代码：T-标题、U-用户名、P-密码、A-地址、N-备注；目前仅支持按ID(I)查找
+var fieldReferencePattern = regexp.MustCompile(`(?i)\{REF:([TUPAN])@I:([^{}\s]+)\}`)
+
+/**
+ * FieldReference 字段引用
+ */
+type FieldReference struct {
+	Field     string `json:"field"`      // 被引用的字段代码（T/U/P/A/N）
+	AccountID string `json:"account_id"` // 被引用的账号ID
+}
+
+/**
+ * ReferenceUsage 引用使用情况（"被引用"反查结果）
+ */
+type ReferenceUsage struct {
+	AccountID    string `json:"account_id"`    // 引用方账号ID
+	Title        string `json:"title"`         // 引用方账号标题
+	Field        string `json:"field"`         // 引用方中包含引用的字段（username/password/url/notes）
+	ReferencedAs string `json:"referenced_as"` // 被引用的字段代码（T/U/P/A/N）
+}
+
+/**
+ * FieldReferenceService 字段引用服务
+ */
+type FieldReferenceService struct {
+	accountService *AccountService
+}
+
+/**
+ * NewFieldReferenceService 创建字段引用服务
+ * @param accountService 账号服务
+ * @return *FieldReferenceService 字段引用服务实例
+ */
+func NewFieldReferenceService(accountService *AccountService) *FieldReferenceService {
+	return &FieldReferenceService{
+		accountService: accountService,
+	}
+}
+
+/**
+ * HasReference 判断字段值中是否包含字段引用
+ * @param value 字段值
+ * @return bool 是否包含引用
+ */
+func HasReference(value string) bool {
+	return fieldReferencePattern.MatchString(value)
+}
+
+/**
+ * ParseReferences 解析字段值中的所有字段引用
+ * @param value 字段值
+ * @return []FieldReference 引用列表
+ */
+func ParseReferences(value string) []FieldReference {
+	matches := fieldReferencePattern.FindAllStringSubmatch(value, -1)
+	refs := make([]FieldReference, 0, len(matches))
+	for _, m := range matches {
+		refs = append(refs, FieldReference{
+			Field:     strings.ToUpper(m[1]),
+			AccountID: m[2],
+		})
+	}
+	return refs
+}
+
+/**
+ * ResolveAccount 解析账号中用户名、密码、地址、备注字段的引用
+ * @param account 已解密的账号（不会被修改）
+ * @return *models.AccountDecrypted 解析后的账号副本
+ * @return error 错误信息（引用的账号不存在、循环引用等）
+ */
+func (frs *FieldReferenceService) ResolveAccount(account *models.AccountDecrypted) (*models.AccountDecrypted, error) {
+	if account == nil {
+		return nil, fmt.Errorf("账号不能为空")
+	}
+
+	resolved := *account
+	cache := map[string]*models.AccountDecrypted{account.ID: account}
+
+	fields := []struct {
+		code  string
+		value *string
+	}{
+		{"U", &resolved.Username},
+		{"P", &resolved.Password},
+		{"A", &resolved.URL},
+		{"N", &resolved.Notes},
+	}
+
+	for _, f := range fields {
+		if !HasReference(*f.value) {
+			continue
+		}
+		visiting := map[string]bool{account.ID + "/" + f.code: true}
+		value, err := frs.resolveValue(*f.value, visiting, cache, 1)
+		if err != nil {
+			logger.Error("[字段引用] 解析账号字段引用失败，账号ID: %s, 字段: %s, 错误: %v", account.ID, f.code, err)
+			return nil, err
+		}
+		*f.value = value
+	}
+
+	return &resolved, nil
+}
+
+/**
+ * ResolveValue 解析单个字段值中的引用
+ * @param value 字段值
+ * @return string 解析后的值
+ * @return error 错误信息
+ */
+func (frs *FieldReferenceService) ResolveValue(value string) (string, error) {
+	if !HasReference(value) {
+		return value, nil
+	}
+	return frs.resolveValue(value, map[string]bool{}, map[string]*models.AccountDecrypted{}, 1)
+}
+
+/**
+ * resolveValue 递归解析字段值中的引用
+ * @param value 字段值
+ * @param visiting 当前解析路径上的 账号ID/字段 集合，用于循环检测
+ * @param cache 已加载的账号缓存
+ * @param depth 当前解析深度
+ * @return string 解析后的值
+ * @return error 错误信息
+ */
+func (frs *FieldReferenceService) resolveValue(value string, visiting map[string]bool, cache map[string]*models.AccountDecrypted, depth int) (string, error) {
+	if depth > MaxReferenceDepth {
+		return "", fmt.Errorf("字段引用层级过深（超过%d层）", MaxReferenceDepth)
+	}
+
+	var resolveErr error
+	result := fieldReferencePattern.ReplaceAllStringFunc(value, func(match string) string {
+		if resolveErr != nil {
+			return match
+		}
+
+		sub := fieldReferencePattern.FindStringSubmatch(match)
+		field := strings.ToUpper(sub[1])
+		accountID := sub[2]
+
+		key := accountID + "/" + field
+		if visiting[key] {
+			resolveErr = fmt.Errorf("检测到循环引用: %s", match)
+			return match
+		}
+
+		target, err := frs.loadAccount(accountID, cache)
+		if err != nil {
+			resolveErr = err
+			return match
+		}
+
+		raw := referenceFieldValue(target, field)
+		if !HasReference(raw) {
+			return raw
+		}
+
+		visiting[key] = true
+		resolved, err := frs.resolveValue(raw, visiting, cache, depth+1)
+		delete(visiting, key)
+		if err != nil {
+			resolveErr = err
+			return match
+		}
+		return resolved
+	})
+
+	if resolveErr != nil {
+		return "", resolveErr
+	}
+	return result, nil
+}
+
+/**
+ * loadAccount 加载被引用的账号（带缓存）
+ * @param accountID 账号ID
+ * @param cache 账号缓存
+ * @return *models.AccountDecrypted 账号
+ * @return error 错误信息
+ */
+func (frs *FieldReferenceService) loadAccount(accountID string, cache map[string]*models.AccountDecrypted) (*models.AccountDecrypted, error) {
+	if account, ok := cache[accountID]; ok {
+		return account, nil
+	}
+
+	account, err := frs.accountService.GetAccountByID(accountID)
+	if err != nil || account == nil {
+		return nil, fmt.Errorf("引用的账号不存在: %s", accountID)
+	}
+
+	cache[accountID] = account
+	return account, nil
+}
+
+/**
+ * referenceFieldValue 根据字段代码获取账号字段值
+ * @param account 账号
+ * @param field 字段代码（T/U/P/A/N）
+ * @return string 字段值
+ */
+func referenceFieldValue(account *models.AccountDecrypted, field string) string {
+	switch field {
+	case "T":
+		return account.Title
+	case "U":
+		return account.Username
+	case "P":
+		return account.Password
+	case "A":
+		return account.URL
+	case "N":
+		return account.Notes
+	default:
+		return ""
+	}
+}
+
+/**
+ * GetReferencedBy 反查引用了指定账号的所有账号字段（"被引用"）
+ * @param accountID 被引用的账号ID
+ * @return []ReferenceUsage 引用情况列表
+ * @return error 错误信息
+ */
+func (frs *FieldReferenceService) GetReferencedBy(accountID string) ([]ReferenceUsage, error) {
+	if accountID == "" {
+		return nil, fmt.Errorf("账号ID不能为空")
+	}
+
+	accounts, err := frs.accountService.GetAllAccounts()
+	if err != nil {
+		return nil, fmt.Errorf("获取账号列表失败: %w", err)
+	}
+
+	usages := make([]ReferenceUsage, 0)
+	for _, account := range accounts {
+		fields := []struct {
+			name  string
+			value string
+		}{
+			{"username", account.Username},
+			{"password", account.Password},
+			{"url", account.URL},
+			{"notes", account.Notes},
+		}
+
+		for _, f := range fields {
+			for _, ref := range ParseReferences(f.value) {
+				if ref.AccountID != accountID {
+					continue
+				}
+				usages = append(usages, ReferenceUsage{
+					AccountID:    account.ID,
+					Title:        account.Title,
+					Field:        f.name,
+					ReferencedAs: ref.Field,
+				})
+			}
+		}
+	}
+
+	logger.Info("[字段引用] 账号 %s 被 %d 处字段引用", accountID, len(usages))
+	return usages, nil
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"wepassword/internal/config"
+	"wepassword/internal/database"
+)
+
+/**
+ * 字段引用服务测试
+ * @author 陈凤庆
+ * @date 20251020
+ * @description 测试字段引用的解析、循环检测和被引用反查
+ */
+
+/**
+ * newTestAccountService 创建带临时密码库的账号服务（测试辅助）
+ * @return *AccountService 账号服务
+ * @return string 默认分组下的第一个类型ID
+ */
+func newTestAccountService(t *testing.T) (*AccountService, string) {
+	t.Helper()
+
+	vaultPath := filepath.Join(t.TempDir(), "test_vault.db")
+	dbManager := database.NewDatabaseManager()
+	vaultService := NewVaultService(dbManager, config.NewConfigManager())
+	if err := vaultService.CreateVault(vaultPath, "Test246!Asd", "zh-CN"); err != nil {
+		t.Fatalf("创建密码库失败: %v", err)
+	}
+	t.Cleanup(vaultService.CloseVault)
+
+	accountService := NewAccountService(dbManager)
+	accountService.SetCryptoManager(vaultService.GetCryptoManager())
+
+	var typeID string
+	err := dbManager.GetDB().QueryRow("SELECT id FROM types ORDER BY sort_order LIMIT 1").Scan(&typeID)
+	if err != nil {
+		t.Fatalf("获取类型失败: %v", err)
+	}
+
+	return accountService, typeID
+}
+
+func TestParseReferences(t *testing.T) {
+	refs := ParseReferences("pre-{REF:P@I:abc-1}-{ref:u@i:def-2}")
+	if len(refs) != 2 {
+		t.Fatalf("应解析出2个引用，实际: %d", len(refs))
+	}
+	if refs[0].Field != "P" || refs[0].AccountID != "abc-1" {
+		t.Errorf("第一个引用解析错误: %+v", refs[0])
+	}
+	if refs[1].Field != "U" || refs[1].AccountID != "def-2" {
+		t.Errorf("第二个引用解析错误: %+v", refs[1])
+	}
+
+	if HasReference("{REF:X@I:abc}") {
+		t.Error("不支持的字段代码不应被识别为引用")
+	}
+}
+
+func TestFieldReferenceService_ResolveAndReferencedBy(t *testing.T) {
+	accountService, typeID := newTestAccountService(t)
+	frs := NewFieldReferenceService(accountService)
+
+	source, err := accountService.CreateAccount("AD", "domain\\user", "Ad#Pass2025", "", typeID, "", 1)
+	if err != nil {
+		t.Fatalf("创建源账号失败: %v", err)
+	}
+
+	ref, err := accountService.CreateAccount("VPN", "vpn-user", "{REF:P@I:"+source.ID+"}", "", typeID, "", 1)
+	if err != nil {
+		t.Fatalf("创建引用账号失败: %v", err)
+	}
+
+	account, err := accountService.GetAccountByID(ref.ID)
+	if err != nil {
+		t.Fatalf("获取引用账号失败: %v", err)
+	}
+	resolved, err := frs.ResolveAccount(account)
+	if err != nil {
+		t.Fatalf("解析引用失败: %v", err)
+	}
+	if resolved.Password != "Ad#Pass2025" {
+		t.Errorf("引用密码解析错误，期望: Ad#Pass2025，实际: %s", resolved.Password)
+	}
+	if account.Password == resolved.Password {
+		t.Error("ResolveAccount不应修改原账号")
+	}
+
+	usages, err := frs.GetReferencedBy(source.ID)
+	if err != nil {
+		t.Fatalf("反查引用失败: %v", err)
+	}
+	if len(usages) != 1 || usages[0].AccountID != ref.ID || usages[0].Field != "password" {
+		t.Errorf("反查结果错误: %+v", usages)
+	}
+
+	t.Log("✅ 字段引用解析和反查测试通过")
+}
+
+func TestFieldReferenceService_CycleDetection(t *testing.T) {
+	accountService, typeID := newTestAccountService(t)
+	frs := NewFieldReferenceService(accountService)
+
+	a, err := accountService.CreateAccount("A", "user-a", "placeholder-a", "", typeID, "", 1)
+	if err != nil {
+		t.Fatalf("创建账号A失败: %v", err)
+	}
+	b, err := accountService.CreateAccount("B", "user-b", "{REF:P@I:"+a.ID+"}", "", typeID, "", 1)
+	if err != nil {
+		t.Fatalf("创建账号B失败: %v", err)
+	}
+
+	a.Password = "{REF:P@I:" + b.ID + "}"
+	if err := accountService.UpdateAccount(a); err != nil {
+		t.Fatalf("更新账号A失败: %v", err)
+	}
+
+	account, err := accountService.GetAccountByID(a.ID)
+	if err != nil {
+		t.Fatalf("获取账号A失败: %v", err)
+	}
+	_, err = frs.ResolveAccount(account)
+	if err == nil || !strings.Contains(err.Error(), "循环引用") {
+		t.Errorf("应检测到循环引用，实际错误: %v", err)
+	}
+
+	if _, err := frs.ResolveValue("{REF:P@I:not-exists}"); err == nil {
+		t.Error("引用不存在的账号应返回错误")
+	}
+
+	t.Log("✅ 循环引用检测测试通过")
+}
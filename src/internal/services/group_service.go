@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"wepassword/internal/database"
+	"wepassword/internal/logger"
 	"wepassword/internal/models"
 	"wepassword/internal/utils"
 )
@@ -46,8 +47,9 @@ func (gs *GroupService) GetAllGroups() ([]models.Group, error) {
 	db := gs.dbManager.GetDB()
 	// 20251001 陈凤庆 删除created_by和updated_by字段
 	// 20251002 陈凤庆 删除parent_id字段，修复ORDER BY语句
+	// 20251020 陈凤庆 恢复parent_id字段，支持嵌套分组
 	rows, err := db.Query(`
-		SELECT id, name, icon, sort_order, created_at, updated_at
+		SELECT id, name, icon, COALESCE(parent_id, ''), COALESCE(password_rule_id, ''), sort_order, created_at, updated_at
 		FROM groups
 		ORDER BY sort_order, name
 	`)
@@ -61,7 +63,7 @@ func (gs *GroupService) GetAllGroups() ([]models.Group, error) {
 	for rows.Next() {
 		var group models.Group
 		err := rows.Scan(
-			&group.ID, &group.Name, &group.Icon, &group.SortOrder,
+			&group.ID, &group.Name, &group.Icon, &group.ParentID, &group.PasswordRuleID, &group.SortOrder,
 			&group.CreatedAt, &group.UpdatedAt,
 		)
 		if err != nil {
@@ -89,12 +91,13 @@ func (gs *GroupService) GetGroupByID(id string) (*models.Group, error) {
 	group := &models.Group{}
 	// 20251001 陈凤庆 删除created_by和updated_by字段
 	// 20251002 陈凤庆 删除parent_id字段
+	// 20251020 陈凤庆 恢复parent_id字段，支持嵌套分组
 	err := db.QueryRow(`
-		SELECT id, name, icon, sort_order, created_at, updated_at
+		SELECT id, name, icon, COALESCE(parent_id, ''), COALESCE(password_rule_id, ''), sort_order, created_at, updated_at
 		FROM groups
 		WHERE id = ?
 	`, id).Scan(
-		&group.ID, &group.Name, &group.Icon, &group.SortOrder,
+		&group.ID, &group.Name, &group.Icon, &group.ParentID, &group.PasswordRuleID, &group.SortOrder,
 		&group.CreatedAt, &group.UpdatedAt,
 	)
 
@@ -111,8 +114,21 @@ func (gs *GroupService) GetGroupByID(id string) (*models.Group, error) {
  * @return models.Group 创建的分组
  * @return error 错误信息
  * @modify 20251002 陈凤庆 删除parentID参数，不需要层级结构
+ * @modify 20251020 陈凤庆 委托CreateChildGroup创建顶级分组
  */
 func (gs *GroupService) CreateGroup(name string) (models.Group, error) {
+	return gs.CreateChildGroup(name, "")
+}
+
+/**
+ * CreateChildGroup 在指定父分组下创建子分组
+ * @param name 分组名称
+ * @param parentID 父分组ID，空字符串表示创建顶级分组
+ * @return models.Group 创建的分组
+ * @return error 错误信息
+ * @author 20251020 陈凤庆 新增嵌套分组支持
+ */
+func (gs *GroupService) CreateChildGroup(name string, parentID string) (models.Group, error) {
 	if !gs.dbManager.IsOpened() {
 		return models.Group{}, fmt.Errorf("数据库未打开")
 	}
@@ -121,15 +137,22 @@ func (gs *GroupService) CreateGroup(name string) (models.Group, error) {
 		return models.Group{}, fmt.Errorf("分组名称不能为空")
 	}
 
+	if parentID != "" {
+		if _, err := gs.GetGroupByID(parentID); err != nil {
+			return models.Group{}, fmt.Errorf("父分组不存在: %w", err)
+		}
+	}
+
 	db := gs.dbManager.GetDB()
 	now := time.Now()
 
-	// 获取最大排序号
+	// 获取同级分组的最大排序号
 	var maxSortOrder int
 	err := db.QueryRow(`
 		SELECT COALESCE(MAX(sort_order), 0)
 		FROM groups
-	`).Scan(&maxSortOrder)
+		WHERE COALESCE(parent_id, '') = ?
+	`, parentID).Scan(&maxSortOrder)
 	if err != nil {
 		return models.Group{}, fmt.Errorf("获取排序号失败: %w", err)
 	}
@@ -139,9 +162,9 @@ func (gs *GroupService) CreateGroup(name string) (models.Group, error) {
 
 	// 插入新分组
 	_, err = db.Exec(`
-		INSERT INTO groups (id, name, icon, sort_order, created_at, updated_at)
-		VALUES (?, ?, 'fa-folder', ?, ?, ?)
-	`, newID, name, maxSortOrder+1, now, now)
+		INSERT INTO groups (id, name, icon, parent_id, sort_order, created_at, updated_at)
+		VALUES (?, ?, 'fa-folder', ?, ?, ?, ?)
+	`, newID, name, parentID, maxSortOrder+1, now, now)
 	if err != nil {
 		return models.Group{}, fmt.Errorf("创建分组失败: %w", err)
 	}
@@ -156,6 +179,7 @@ func (gs *GroupService) CreateGroup(name string) (models.Group, error) {
 		ID:        newID,
 		Name:      name,
 		Icon:      "fa-folder",
+		ParentID:  parentID,
 		SortOrder: maxSortOrder + 1,
 		CreatedAt: now,
 		UpdatedAt: now,
@@ -216,6 +240,17 @@ func (gs *GroupService) DeleteGroup(id string) error {
 
 	db := gs.dbManager.GetDB()
 
+	// 20251020 陈凤庆 删除前验证：检查分组下是否有子分组
+	var childCount int
+	err = db.QueryRow("SELECT COUNT(*) FROM groups WHERE parent_id = ?", id).Scan(&childCount)
+	if err != nil {
+		return fmt.Errorf("检查子分组失败: %w", err)
+	}
+
+	if childCount > 0 {
+		return fmt.Errorf("该分组下还有 %d 个子分组，请先删除或移走子分组", childCount)
+	}
+
 	// 20251002 陈凤庆 删除前验证：检查分组下是否有类别
 	var typeCount int
 	err = db.QueryRow("SELECT COUNT(*) FROM types WHERE group_id = ?", id).Scan(&typeCount)
@@ -262,8 +297,9 @@ func (gs *GroupService) SearchGroups(keyword string) ([]models.Group, error) {
 
 	db := gs.dbManager.GetDB()
 	// 20251002 陈凤庆 删除parent_id字段
+	// 20251020 陈凤庆 恢复parent_id字段，支持嵌套分组
 	rows, err := db.Query(`
-		SELECT id, name, icon, sort_order, created_at, updated_at
+		SELECT id, name, icon, COALESCE(parent_id, ''), COALESCE(password_rule_id, ''), sort_order, created_at, updated_at
 		FROM groups
 		WHERE name LIKE ?
 		ORDER BY sort_order, name
@@ -278,7 +314,7 @@ func (gs *GroupService) SearchGroups(keyword string) ([]models.Group, error) {
 	for rows.Next() {
 		var group models.Group
 		err := rows.Scan(
-			&group.ID, &group.Name, &group.Icon, &group.SortOrder,
+			&group.ID, &group.Name, &group.Icon, &group.ParentID, &group.PasswordRuleID, &group.SortOrder,
 			&group.CreatedAt, &group.UpdatedAt,
 		)
 		if err != nil {
@@ -355,13 +391,15 @@ func (gs *GroupService) MoveGroupLeft(id string) error {
 
 	db := gs.dbManager.GetDB()
 
-	// 获取当前分组的排序号
+	// 获取当前分组的排序号和父分组
+	// 20251020 陈凤庆 只在同级分组之间移动
 	var currentSortOrder int
+	var parentID string
 	err := db.QueryRow(`
-		SELECT sort_order
+		SELECT sort_order, COALESCE(parent_id, '')
 		FROM groups
 		WHERE id = ?
-	`, id).Scan(&currentSortOrder)
+	`, id).Scan(&currentSortOrder, &parentID)
 	if err != nil {
 		return fmt.Errorf("获取分组排序号失败: %w", err)
 	}
@@ -372,10 +410,10 @@ func (gs *GroupService) MoveGroupLeft(id string) error {
 	err = db.QueryRow(`
 		SELECT id, sort_order
 		FROM groups
-		WHERE sort_order < ?
+		WHERE sort_order < ? AND COALESCE(parent_id, '') = ?
 		ORDER BY sort_order DESC
 		LIMIT 1
-	`, currentSortOrder).Scan(&leftGroupID, &leftSortOrder)
+	`, currentSortOrder, parentID).Scan(&leftGroupID, &leftSortOrder)
 	if err != nil {
 		// 没有找到左边的分组，说明已经是最左边了
 		return fmt.Errorf("分组已经在最左边，无法继续左移")
@@ -435,13 +473,15 @@ func (gs *GroupService) MoveGroupRight(id string) error {
 
 	db := gs.dbManager.GetDB()
 
-	// 获取当前分组的排序号
+	// 获取当前分组的排序号和父分组
+	// 20251020 陈凤庆 只在同级分组之间移动
 	var currentSortOrder int
+	var parentID string
 	err := db.QueryRow(`
-		SELECT sort_order
+		SELECT sort_order, COALESCE(parent_id, '')
 		FROM groups
 		WHERE id = ?
-	`, id).Scan(&currentSortOrder)
+	`, id).Scan(&currentSortOrder, &parentID)
 	if err != nil {
 		return fmt.Errorf("获取分组排序号失败: %w", err)
 	}
@@ -452,10 +492,10 @@ func (gs *GroupService) MoveGroupRight(id string) error {
 	err = db.QueryRow(`
 		SELECT id, sort_order
 		FROM groups
-		WHERE sort_order > ?
+		WHERE sort_order > ? AND COALESCE(parent_id, '') = ?
 		ORDER BY sort_order ASC
 		LIMIT 1
-	`, currentSortOrder).Scan(&rightGroupID, &rightSortOrder)
+	`, currentSortOrder, parentID).Scan(&rightGroupID, &rightSortOrder)
 	if err != nil {
 		// 没有找到右边的分组，说明已经是最右边了
 		return fmt.Errorf("分组已经在最右边，无法继续右移")
@@ -531,3 +571,251 @@ func (gs *GroupService) UpdateGroupSortOrder(groupID string, newSortOrder int) e
 
 	return nil
 }
+
+// groupSubtreeSQL 递归查询指定分组及其全部子孙分组ID
+// 20251020 陈凤庆 使用UNION而非UNION ALL，即使数据中存在环也能终止
+const groupSubtreeSQL = `
+	WITH RECURSIVE subtree(id) AS (
+		SELECT ?
+		UNION
+		SELECT g.id FROM groups g INNER JOIN subtree s ON g.parent_id = s.id
+	)
+	SELECT id FROM subtree`
+
+/**
+ * GetDescendantGroupIDs 获取分组及其全部子孙分组ID
+ * @param id 分组ID
+ * @return []string 分组ID列表（包含自身）
+ * @return error 错误信息
+ * @author 20251020 陈凤庆 新增嵌套分组递归范围查询
+ */
+func (gs *GroupService) GetDescendantGroupIDs(id string) ([]string, error) {
+	if !gs.dbManager.IsOpened() {
+		return nil, fmt.Errorf("数据库未打开")
+	}
+
+	if id == "" {
+		return nil, fmt.Errorf("分组ID不能为空")
+	}
+
+	rows, err := gs.dbManager.GetDB().Query(groupSubtreeSQL, id)
+	if err != nil {
+		return nil, fmt.Errorf("查询子分组失败: %w", err)
+	}
+	defer rows.Close()
+
+	ids := make([]string, 0)
+	for rows.Next() {
+		var groupID string
+		if err := rows.Scan(&groupID); err != nil {
+			return nil, fmt.Errorf("扫描子分组失败: %w", err)
+		}
+		ids = append(ids, groupID)
+	}
+
+	return ids, nil
+}
+
+/**
+ * MoveGroup 移动分组到新的父分组下（调整层级）
+ * @param id 分组ID
+ * @param newParentID 新父分组ID，空字符串表示移动为顶级分组
+ * @return error 错误信息
+ * @author 20251020 陈凤庆 新增嵌套分组移动功能，禁止移动到自身或子孙分组下
+ */
+func (gs *GroupService) MoveGroup(id string, newParentID string) error {
+	if !gs.dbManager.IsOpened() {
+		return fmt.Errorf("数据库未打开")
+	}
+
+	if id == "" {
+		return fmt.Errorf("分组ID不能为空")
+	}
+
+	group, err := gs.GetGroupByID(id)
+	if err != nil {
+		return fmt.Errorf("分组不存在: %w", err)
+	}
+
+	if group.ParentID == newParentID {
+		return nil
+	}
+
+	if newParentID != "" {
+		if _, err := gs.GetGroupByID(newParentID); err != nil {
+			return fmt.Errorf("目标父分组不存在: %w", err)
+		}
+
+		// 循环检测：新父分组不能是自身或自身的子孙分组
+		descendants, err := gs.GetDescendantGroupIDs(id)
+		if err != nil {
+			return err
+		}
+		for _, descendantID := range descendants {
+			if descendantID == newParentID {
+				return fmt.Errorf("不能将分组移动到自身或其子分组下")
+			}
+		}
+	}
+
+	db := gs.dbManager.GetDB()
+
+	// 放到新父分组下的最后
+	var maxSortOrder int
+	err = db.QueryRow(`
+		SELECT COALESCE(MAX(sort_order), 0)
+		FROM groups
+		WHERE COALESCE(parent_id, '') = ?
+	`, newParentID).Scan(&maxSortOrder)
+	if err != nil {
+		return fmt.Errorf("获取排序号失败: %w", err)
+	}
+
+	_, err = db.Exec(`
+		UPDATE groups
+		SET parent_id = ?, sort_order = ?, updated_at = ?
+		WHERE id = ?
+	`, newParentID, maxSortOrder+1, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("移动分组失败: %w", err)
+	}
+
+	return nil
+}
+
+/**
+ * GetGroupTree 获取分组树（含递归账号数）
+ * @return []models.GroupTreeNode 顶级分组节点列表
+ * @return error 错误信息
+ * @author 20251020 陈凤庆 新增嵌套分组树查询
+ */
+func (gs *GroupService) GetGroupTree() ([]models.GroupTreeNode, error) {
+	groups, err := gs.GetAllGroups()
+	if err != nil {
+		return nil, err
+	}
+
+	// 统计每个分组直属的账号数
+	rows, err := gs.dbManager.GetDB().Query(`
+		SELECT t.group_id, COUNT(a.id)
+		FROM accounts a
+		INNER JOIN types t ON a.typeid = t.id
+		GROUP BY t.group_id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("统计分组账号数失败: %w", err)
+	}
+	defer rows.Close()
+
+	directCounts := make(map[string]int)
+	for rows.Next() {
+		var groupID string
+		var count int
+		if err := rows.Scan(&groupID, &count); err != nil {
+			return nil, fmt.Errorf("扫描分组账号数失败: %w", err)
+		}
+		directCounts[groupID] = count
+	}
+
+	return buildGroupTree(groups, directCounts), nil
+}
+
+/**
+ * buildGroupTree 根据扁平分组列表构建分组树
+ * @param groups 分组列表（已按排序号排序）
+ * @param directCounts 分组ID -> 直属账号数
+ * @return []models.GroupTreeNode 顶级分组节点列表
+ * @description 父分组不存在的分组视为顶级分组；已访问的分组不会重复展开，避免异常数据造成死循环；
+ *              父子关系成环的分组从根节点无法到达，记录日志后作为顶级分组展示，避免被静默丢弃
+ */
+func buildGroupTree(groups []models.Group, directCounts map[string]int) []models.GroupTreeNode {
+	exists := make(map[string]bool, len(groups))
+	for _, group := range groups {
+		exists[group.ID] = true
+	}
+
+	children := make(map[string][]models.Group)
+	for _, group := range groups {
+		parentID := group.ParentID
+		if parentID == group.ID || !exists[parentID] {
+			parentID = ""
+		}
+		children[parentID] = append(children[parentID], group)
+	}
+
+	visited := make(map[string]bool, len(groups))
+	var buildNode func(group models.Group, parentPath string, depth int) models.GroupTreeNode
+	var build func(parentID, parentPath string, depth int) []models.GroupTreeNode
+	buildNode = func(group models.Group, parentPath string, depth int) models.GroupTreeNode {
+		visited[group.ID] = true
+
+		path := group.Name
+		if parentPath != "" {
+			path = parentPath + "/" + group.Name
+		}
+
+		node := models.GroupTreeNode{
+			Group:        group,
+			Path:         path,
+			Depth:        depth,
+			AccountCount: directCounts[group.ID],
+		}
+		node.Children = build(group.ID, path, depth+1)
+
+		node.TotalAccountCount = node.AccountCount
+		for _, child := range node.Children {
+			node.TotalAccountCount += child.TotalAccountCount
+		}
+		return node
+	}
+	build = func(parentID, parentPath string, depth int) []models.GroupTreeNode {
+		nodes := make([]models.GroupTreeNode, 0)
+		for _, group := range children[parentID] {
+			if visited[group.ID] {
+				continue
+			}
+			nodes = append(nodes, buildNode(group, parentPath, depth))
+		}
+		return nodes
+	}
+
+	roots := build("", "", 0)
+	for _, group := range groups {
+		if visited[group.ID] {
+			continue
+		}
+		logger.Error("[分组] 分组父子关系成环，作为顶级分组展示: ID=%s, 名称=%s, 父分组ID=%s", group.ID, group.Name, group.ParentID)
+		roots = append(roots, buildNode(group, "", 0))
+	}
+	return roots
+}
+
+/**
+ * GetGroupAncestors 获取分组的全部祖先分组（由近到远）
+ * @param id 分组ID
+ * @return []models.Group 祖先分组列表
+ * @return error 错误信息
+ * @author 20251020 陈凤庆 用于导出时保留分组层级
+ */
+func (gs *GroupService) GetGroupAncestors(id string) ([]models.Group, error) {
+	ancestors := make([]models.Group, 0)
+	visited := map[string]bool{id: true}
+
+	group, err := gs.GetGroupByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	for group.ParentID != "" && !visited[group.ParentID] {
+		visited[group.ParentID] = true
+		parent, err := gs.GetGroupByID(group.ParentID)
+		if err != nil {
+			// 父分组已不存在，视为顶级分组
+			break
+		}
+		ancestors = append(ancestors, *parent)
+		group = parent
+	}
+
+	return ancestors, nil
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"testing"
+
+	"wepassword/internal/models"
+)
+
+/**
+ * 嵌套分组测试
+ * @author 陈凤庆
+ * @date 20251020
+ * @description 测试嵌套分组的创建、移动、循环检测、递归账号数和递归搜索
+ */
+
+func TestGroupService_NestedGroups(t *testing.T) {
+	accountService, _ := newTestAccountService(t)
+	gs := NewGroupService(accountService.dbManager)
+	ts := NewTypeService(accountService.dbManager)
+
+	customers, err := gs.CreateGroup("客户")
+	if err != nil {
+		t.Fatalf("创建分组失败: %v", err)
+	}
+	acme, err := gs.CreateChildGroup("ACME", customers.ID)
+	if err != nil {
+		t.Fatalf("创建子分组失败: %v", err)
+	}
+	prod, err := gs.CreateChildGroup("生产", acme.ID)
+	if err != nil {
+		t.Fatalf("创建子分组失败: %v", err)
+	}
+
+	// 循环检测
+	if err := gs.MoveGroup(customers.ID, prod.ID); err == nil {
+		t.Error("移动到子孙分组下应失败")
+	}
+	if err := gs.MoveGroup(acme.ID, acme.ID); err == nil {
+		t.Error("移动到自身下应失败")
+	}
+
+	// 在最深层分组下创建账号
+	prodType, err := ts.CreateType("服务器", prod.ID, "fa-server")
+	if err != nil {
+		t.Fatalf("创建类型失败: %v", err)
+	}
+	if _, err := accountService.CreateAccount("db-01", "root", "Db#01Pass", "", prodType.ID, "", 1); err != nil {
+		t.Fatalf("创建账号失败: %v", err)
+	}
+
+	tree, err := gs.GetGroupTree()
+	if err != nil {
+		t.Fatalf("获取分组树失败: %v", err)
+	}
+	var customersNode *models.GroupTreeNode
+	for i := range tree {
+		if tree[i].ID == customers.ID {
+			customersNode = &tree[i]
+		}
+	}
+	if customersNode == nil {
+		t.Fatal("分组树中应包含顶级分组 客户")
+	}
+	if customersNode.AccountCount != 0 || customersNode.TotalAccountCount != 1 {
+		t.Errorf("递归账号数错误: 直属=%d, 递归=%d", customersNode.AccountCount, customersNode.TotalAccountCount)
+	}
+	if len(customersNode.Children) != 1 || customersNode.Children[0].Children[0].Path != "客户/ACME/生产" {
+		t.Errorf("分组树结构错误: %+v", customersNode.Children)
+	}
+
+	// 递归范围搜索
+	results, err := accountService.SearchAccountsInGroup("db", customers.ID)
+	if err != nil || len(results) != 1 {
+		t.Errorf("递归搜索应找到1个账号，实际: %d, 错误: %v", len(results), err)
+	}
+
+	// 有子分组时不能删除
+	if err := gs.DeleteGroup(acme.ID); err == nil {
+		t.Error("存在子分组时删除应失败")
+	}
+
+	// 移动为顶级分组
+	if err := gs.MoveGroup(prod.ID, ""); err != nil {
+		t.Fatalf("移动分组失败: %v", err)
+	}
+	results, _ = accountService.SearchAccountsInGroup("db", customers.ID)
+	if len(results) != 0 {
+		t.Error("移走子分组后原分组范围内不应再搜到账号")
+	}
+
+	t.Log("✅ 嵌套分组测试通过")
+}
+
+func TestBuildGroupTree_Cycle(t *testing.T) {
+	groups := []models.Group{
+		{ID: "root", Name: "根"},
+		{ID: "a", Name: "甲", ParentID: "b"},
+		{ID: "b", Name: "乙", ParentID: "a"},
+	}
+	tree := buildGroupTree(groups, map[string]int{"a": 1, "b": 2})
+	if len(tree) != 2 || tree[0].ID != "root" || tree[1].ID != "a" {
+		t.Fatalf("成环的分组应作为顶级分组展示: %+v", tree)
+	}
+	if len(tree[1].Children) != 1 || tree[1].Children[0].ID != "b" || tree[1].TotalAccountCount != 3 {
+		t.Errorf("成环分组的子树错误: %+v", tree[1])
+	}
+}
@@ -44,6 +44,7 @@ type ImportOptions struct {
 
 /**
  * ImportResult 导入结果
+ * @modify 20251021 陈凤庆 添加EntryResults字段，KeePass导入时返回每个条目的导入结果
  */
 type ImportResult struct {
 	Success               bool                 `json:"success"`                 // 是否成功
@@ -58,6 +59,7 @@ type ImportResult struct {
 	ImportedTypes         int                  `json:"imported_types"`          // 成功导入的类型数
 	SkippedTypes          int                  `json:"skipped_types"`           // 跳过的类型数
 	SkippedAccountDetails []SkippedAccountInfo `json:"skipped_account_details"` // 跳过的账号详情
+	EntryResults          []ImportEntryResult  `json:"entry_results"`           // 每个条目的导入结果（KeePass、Bitwarden、CSV导入）
 	ErrorMessage          string               `json:"error_message"`           // 错误信息
 }
 
@@ -70,6 +72,25 @@ type SkippedAccountInfo struct {
 	Name  string `json:"name"`  // 账号名称（用户名）
 }
 
+// 条目导入状态
+const (
+	ImportEntryImported = "imported"
+	ImportEntrySkipped  = "skipped"
+	ImportEntryError    = "error"
+)
+
+/**
+ * ImportEntryResult 单个条目的导入结果
+ */
+type ImportEntryResult struct {
+	Path      string   `json:"path"`       // 条目在源文件中的分组或文件夹路径，如 工作/邮箱
+	Title     string   `json:"title"`      // 条目标题
+	AccountID string   `json:"account_id"` // 账号ID
+	Status    string   `json:"status"`     // 导入状态：imported、skipped、error
+	Message   string   `json:"message"`    // 跳过或失败原因
+	Warnings  []string `json:"warnings"`   // 已导入但未完整保留的内容，如无效的附件引用
+}
+
 /**
  * NewImportService 创建导入服务
  * @param dbManager 数据库管理器
@@ -541,10 +562,11 @@ func (is *ImportService) createGroupWithID(group models.Group) error {
 	db := is.dbManager.GetDB()
 
 	// 直接插入分组，使用原有ID
+	// 20251020 陈凤庆 保留父分组ID，支持嵌套分组
 	_, err := db.Exec(`
-		INSERT INTO groups (id, name, icon, sort_order, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?)
-	`, group.ID, group.Name, group.Icon, group.SortOrder, group.CreatedAt, group.UpdatedAt)
+		INSERT INTO groups (id, name, icon, parent_id, sort_order, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, group.ID, group.Name, group.Icon, group.ParentID, group.SortOrder, group.CreatedAt, group.UpdatedAt)
 
 	if err != nil {
 		return fmt.Errorf("插入分组失败: %w", err)
@@ -649,6 +671,7 @@ func (is *ImportService) normalizeImportData(exportData *ExportData) error {
  * normalizeGroupIDs 统一分组ID
  * @param exportData 导出数据指针
  * @return error 错误信息
+ * @modify 20251020 陈凤庆 支持嵌套分组：按"父分组+名称"匹配已有分组，父分组先于子分组处理
  */
 func (is *ImportService) normalizeGroupIDs(exportData *ExportData) error {
 	logger.Info("[数据梳理] 开始统一分组ID")
@@ -659,19 +682,32 @@ func (is *ImportService) normalizeGroupIDs(exportData *ExportData) error {
 		return fmt.Errorf("获取数据库分组失败: %w", err)
 	}
 
-	// 创建分组名称到ID的映射
-	dbGroupNameToID := make(map[string]string)
+	// 创建"父分组ID:分组名称"到ID的映射
+	dbGroupKeyToID := make(map[string]string)
+	dbGroupIDs := make(map[string]bool)
 	for _, group := range dbGroups {
-		dbGroupNameToID[group.Name] = group.ID
+		dbGroupKeyToID[group.ParentID+":"+group.Name] = group.ID
+		dbGroupIDs[group.ID] = true
 	}
 
 	// 创建导入分组ID的映射关系（旧ID -> 新ID）
 	groupIDMapping := make(map[string]string)
 
-	// 遍历导入数据中的分组
-	for i, importGroup := range exportData.Groups {
-		if dbGroupID, exists := dbGroupNameToID[importGroup.Name]; exists {
-			// 分组名称已存在，使用数据库中的分组ID
+	// 遍历导入数据中的分组（父分组优先）
+	for _, i := range parentFirstGroupOrder(exportData.Groups) {
+		importGroup := exportData.Groups[i]
+
+		// 先将父分组ID映射到数据库中的ID，父分组不存在时作为顶级分组
+		parentID := importGroup.ParentID
+		if mappedParentID, exists := groupIDMapping[parentID]; exists {
+			parentID = mappedParentID
+		} else if !dbGroupIDs[parentID] {
+			parentID = ""
+		}
+		exportData.Groups[i].ParentID = parentID
+
+		if dbGroupID, exists := dbGroupKeyToID[parentID+":"+importGroup.Name]; exists {
+			// 同一父分组下分组名称已存在，使用数据库中的分组ID
 			oldID := importGroup.ID
 			newID := dbGroupID
 			groupIDMapping[oldID] = newID
@@ -701,6 +737,46 @@ func (is *ImportService) normalizeGroupIDs(exportData *ExportData) error {
 	return nil
 }
 
+/**
+ * parentFirstGroupOrder 计算父分组优先的处理顺序
+ * @param groups 分组列表
+ * @return []int 分组下标顺序
+ * @description 20251020 陈凤庆 父分组不在列表中的视为顶级分组；存在环的分组放在最后
+ */
+func parentFirstGroupOrder(groups []models.Group) []int {
+	inList := make(map[string]bool, len(groups))
+	for _, group := range groups {
+		inList[group.ID] = true
+	}
+
+	order := make([]int, 0, len(groups))
+	done := make(map[string]bool, len(groups))
+	placed := make([]bool, len(groups))
+
+	for progress := true; progress; {
+		progress = false
+		for i, group := range groups {
+			if placed[i] {
+				continue
+			}
+			if group.ParentID == "" || !inList[group.ParentID] || done[group.ParentID] {
+				order = append(order, i)
+				placed[i] = true
+				done[group.ID] = true
+				progress = true
+			}
+		}
+	}
+
+	for i := range groups {
+		if !placed[i] {
+			order = append(order, i)
+		}
+	}
+
+	return order
+}
+
 /**
  * normalizeTypeIDs 统一分类ID
  * @param exportData 导出数据指针
@@ -762,3 +838,192 @@ func (is *ImportService) normalizeTypeIDs(exportData *ExportData) error {
 func (is *ImportService) SetCryptoManager(cryptoManager *crypto.CryptoManager) {
 	is.cryptoManager = cryptoManager
 }
+
+/**
+ * importTargets 第三方格式导入时按路径查找或创建分组和类型，并记录每个条目的导入结果
+ */
+type importTargets struct {
+	is     *ImportService
+	result *ImportResult
+	groups []models.Group    // 当前密码库的分组，用于按"父分组+名称"复用已有分组
+	types  map[string]string // 分组ID + "/" + 类型名称 -> 类型ID
+	seen   map[string]bool   // 本次导入已统计过的分组和类型
+}
+
+/**
+ * newImportTargets 创建导入目标
+ * @param result 导入结果，分组、类型和条目的统计写入其中
+ * @return *importTargets 导入目标
+ * @return error 获取分组失败时返回错误
+ */
+func (is *ImportService) newImportTargets(result *ImportResult) (*importTargets, error) {
+	groups, err := is.groupService.GetAllGroups()
+	if err != nil {
+		result.ErrorMessage = fmt.Sprintf("获取分组失败: %v", err)
+		return nil, fmt.Errorf("获取分组失败: %w", err)
+	}
+	return &importTargets{
+		is:     is,
+		result: result,
+		groups: groups,
+		types:  make(map[string]string),
+		seen:   make(map[string]bool),
+	}, nil
+}
+
+/**
+ * resolvePath 获取（必要时创建）路径对应的wepass类型
+ * @param path 分组路径，不能为空
+ * @return string 类型ID
+ * @return error 错误信息
+ * @description 路径最后一级作为类型，前面各级作为嵌套分组；只有一级时分组和类型同名
+ */
+func (it *importTargets) resolvePath(path []string) (string, error) {
+	groupPath, typeName := path[:len(path)-1], path[len(path)-1]
+	if len(groupPath) == 0 {
+		groupPath = path
+	}
+
+	parentID := ""
+	for _, name := range groupPath {
+		groupID, err := it.resolveGroup(name, parentID)
+		if err != nil {
+			return "", err
+		}
+		parentID = groupID
+	}
+	return it.resolveTypeInGroup(typeName, parentID)
+}
+
+/**
+ * resolveGroup 按"父分组+名称"查找分组，不存在时创建
+ */
+func (it *importTargets) resolveGroup(name, parentID string) (string, error) {
+	for _, group := range it.groups {
+		if group.Name == name && group.ParentID == parentID {
+			it.countGroup(group.ID, false)
+			return group.ID, nil
+		}
+	}
+
+	group, err := it.is.groupService.CreateChildGroup(name, parentID)
+	if err != nil {
+		return "", err
+	}
+	it.groups = append(it.groups, group)
+	it.countGroup(group.ID, true)
+	logger.Info("[导入] 创建分组: ID=%s, Name=%s", group.ID, group.Name)
+	return group.ID, nil
+}
+
+/**
+ * resolveTypeInGroup 按名称查找分组下的类型，不存在时创建
+ */
+func (it *importTargets) resolveTypeInGroup(name, groupID string) (string, error) {
+	key := groupID + "/" + name
+	if typeID, ok := it.types[key]; ok {
+		return typeID, nil
+	}
+
+	types, err := it.is.typeService.GetTypesByGroup(groupID)
+	if err != nil {
+		return "", err
+	}
+	for _, t := range types {
+		if t.Name == name {
+			it.types[key] = t.ID
+			it.countType(t.ID, false)
+			return t.ID, nil
+		}
+	}
+
+	created, err := it.is.typeService.CreateType(name, groupID, "fa-folder")
+	if err != nil {
+		return "", err
+	}
+	it.types[key] = created.ID
+	it.countType(created.ID, true)
+	logger.Info("[导入] 创建类型: ID=%s, Name=%s", created.ID, created.Name)
+	return created.ID, nil
+}
+
+func (it *importTargets) countGroup(id string, created bool) {
+	if it.seen["group:"+id] {
+		return
+	}
+	it.seen["group:"+id] = true
+	it.result.TotalGroups++
+	if created {
+		it.result.ImportedGroups++
+	} else {
+		it.result.SkippedGroups++
+	}
+}
+
+func (it *importTargets) countType(id string, created bool) {
+	if it.seen["type:"+id] {
+		return
+	}
+	it.seen["type:"+id] = true
+	it.result.TotalTypes++
+	if created {
+		it.result.ImportedTypes++
+	} else {
+		it.result.SkippedTypes++
+	}
+}
+
+/**
+ * skipExisting 账号ID已存在时记录为跳过
+ * @param entryResult 条目结果
+ * @param username 用户名，写入跳过的账号详情
+ * @return bool 是否已跳过
+ */
+func (it *importTargets) skipExisting(entryResult *ImportEntryResult, username string) bool {
+	existing, err := it.is.accountService.GetAccountByID(entryResult.AccountID)
+	if err != nil || existing == nil {
+		return false
+	}
+
+	it.skip(entryResult, username, "账号已存在")
+	return true
+}
+
+/**
+ * skip 记录跳过的条目
+ * @param entryResult 条目结果
+ * @param username 用户名，写入跳过的账号详情
+ * @param message 跳过原因
+ */
+func (it *importTargets) skip(entryResult *ImportEntryResult, username, message string) {
+	logger.Info("[导入] %s，跳过: ID=%s, Title=%s", message, entryResult.AccountID, entryResult.Title)
+	it.result.SkippedAccounts++
+	it.result.SkippedAccountDetails = append(it.result.SkippedAccountDetails, SkippedAccountInfo{
+		ID:    entryResult.AccountID,
+		Title: entryResult.Title,
+		Name:  username,
+	})
+	entryResult.Status = ImportEntrySkipped
+	entryResult.Message = message
+	it.result.EntryResults = append(it.result.EntryResults, *entryResult)
+}
+
+/**
+ * imported 记录导入成功的条目
+ */
+func (it *importTargets) imported(entryResult *ImportEntryResult) {
+	logger.Info("[导入] 账号导入成功: ID=%s, Title=%s", entryResult.AccountID, entryResult.Title)
+	it.result.ImportedAccounts++
+	entryResult.Status = ImportEntryImported
+	it.result.EntryResults = append(it.result.EntryResults, *entryResult)
+}
+
+/**
+ * fail 记录导入失败的条目
+ */
+func (it *importTargets) fail(entryResult *ImportEntryResult, message string) {
+	it.result.ErrorAccounts++
+	entryResult.Status = ImportEntryError
+	entryResult.Message = message
+	it.result.EntryResults = append(it.result.EntryResults, *entryResult)
+}
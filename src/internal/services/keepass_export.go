@@ -0,0 +1,294 @@
+package services
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"wepassword/internal/kdbx"
+	"wepassword/internal/logger"
+	"wepassword/internal/models"
+)
+
+/**
+ * KeePass导出
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 将选中的账号导出为KDBX 4数据库（AES-256 + Argon2d），可直接用KeePass或KeePassXC打开：
+ *              根分组下每个wepass分组对应一个KeePass分组（嵌套分组使用完整路径命名），其下每个类型对应一个子分组，
+ *              账号ID、分组ID和类型ID转换为KeePass的UUID，重新导入时可识别已存在的账号；
+ *              收藏导出为favorite标签，创建、修改、使用时间和使用次数导出到条目时间信息，
+ *              到期时间、历史密码和附件一并导出
+ */
+
+const keepassExportRootName = "WePass"
+
+// wepassReferencePattern wepass字段引用，账号ID为GUID格式
+var wepassReferencePattern = regexp.MustCompile(`(?i)(\{REF:[TUPAN]@I:)([0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12})\}`)
+
+/**
+ * ExportKeePass 导出为KeePass KDBX 4数据库
+ * @param options 导出选项，导出范围与ExportVault相同，BackupPassword作为KeePass数据库密码
+ * @return error 错误信息
+ */
+func (es *ExportService) ExportKeePass(options ExportOptions) error {
+	logger.Info("[导出] 开始导出KeePass数据库，导出路径: %s", options.ExportPath)
+
+	if err := es.verifyLoginPassword(options.LoginPassword); err != nil {
+		return fmt.Errorf("登录密码验证失败: %w", err)
+	}
+	key, err := kdbx.NewCompositeKey(options.BackupPassword, nil)
+	if err != nil {
+		return err
+	}
+
+	selected, err := es.getAccountsToExport(options)
+	if err != nil {
+		return fmt.Errorf("获取导出账号列表失败: %w", err)
+	}
+	// 按类型查询的列表不含密码等字段，按ID重新获取完整账号
+	accounts, err := es.getAccountsByIDs(es.extractAccountIDs(selected))
+	if err != nil {
+		return fmt.Errorf("获取导出账号列表失败: %w", err)
+	}
+	logger.Info("[导出] 获取到 %d 个账号需要导出", len(accounts))
+
+	groups, types, err := es.getRelatedGroupsAndTypes(accounts)
+	if err != nil {
+		return fmt.Errorf("获取相关分组和类型失败: %w", err)
+	}
+
+	db, err := es.buildKeePassDatabase(accounts, groups, types)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := kdbx.Write(&buf, db, key, kdbx.DefaultWriteOptions()); err != nil {
+		return fmt.Errorf("生成KeePass数据库失败: %w", err)
+	}
+	if err := os.WriteFile(options.ExportPath, buf.Bytes(), 0600); err != nil {
+		return fmt.Errorf("写入导出文件失败: %w", err)
+	}
+
+	logger.Info("[导出] 🎉 KeePass导出完成: %s", options.ExportPath)
+	return nil
+}
+
+/**
+ * buildKeePassDatabase 将账号、分组和类型组织为KeePass数据库
+ * @param accounts 账号列表（含密码）
+ * @param groups 分组列表（含祖先分组）
+ * @param types 类型列表
+ * @return *kdbx.Database KeePass数据库
+ * @return error 错误信息
+ * @description 20251021 陈凤庆 类型或其分组不在列表中的账号（如类型已被删除）直接放在根分组下，不会遗漏
+ */
+func (es *ExportService) buildKeePassDatabase(accounts []models.AccountDecrypted, groups []models.Group, types []models.Type) (*kdbx.Database, error) {
+	groupByID := make(map[string]models.Group, len(groups))
+	for _, group := range groups {
+		groupByID[group.ID] = group
+	}
+
+	accountsByType := make(map[string][]models.AccountDecrypted)
+	for _, account := range accounts {
+		accountsByType[account.TypeID] = append(accountsByType[account.TypeID], account)
+	}
+
+	sort.SliceStable(types, func(i, j int) bool {
+		if types[i].SortOrder != types[j].SortOrder {
+			return types[i].SortOrder < types[j].SortOrder
+		}
+		return types[i].Name < types[j].Name
+	})
+
+	db := &kdbx.Database{
+		Meta: kdbx.Meta{Generator: keepassExportRootName, DatabaseName: keepassExportRootName},
+		Root: kdbx.Group{UUID: keepassUUID("root"), Name: keepassExportRootName},
+	}
+
+	// 类型按排序号挂到所属分组下，分组最后按完整路径排序
+	groupIndex := make(map[string]int)
+	exportedTypes := make(map[string]bool)
+	for _, t := range types {
+		if len(accountsByType[t.ID]) == 0 {
+			continue
+		}
+		group, ok := groupByID[t.GroupID]
+		if !ok {
+			continue
+		}
+		exportedTypes[t.ID] = true
+		if _, ok := groupIndex[group.ID]; !ok {
+			groupIndex[group.ID] = len(db.Root.Groups)
+			db.Root.Groups = append(db.Root.Groups, kdbx.Group{
+				UUID:  keepassUUID(group.ID),
+				Name:  keepassGroupPath(group, groupByID),
+				Times: kdbx.Times{CreationTime: group.CreatedAt, LastModificationTime: group.UpdatedAt},
+			})
+		}
+
+		typeGroup := kdbx.Group{
+			UUID:  keepassUUID(t.ID),
+			Name:  t.Name,
+			Times: kdbx.Times{CreationTime: t.CreatedAt, LastModificationTime: t.UpdatedAt},
+		}
+		for _, account := range accountsByType[t.ID] {
+			entry, err := es.buildKeePassEntry(account, db)
+			if err != nil {
+				return nil, err
+			}
+			typeGroup.Entries = append(typeGroup.Entries, entry)
+		}
+		parent := &db.Root.Groups[groupIndex[group.ID]]
+		parent.Groups = append(parent.Groups, typeGroup)
+	}
+
+	sort.SliceStable(db.Root.Groups, func(i, j int) bool {
+		return db.Root.Groups[i].Name < db.Root.Groups[j].Name
+	})
+
+	for _, account := range accounts {
+		if exportedTypes[account.TypeID] {
+			continue
+		}
+		logger.Info("[导出] 账号 %s 的类型或分组不存在，放在根分组下", account.Title)
+		entry, err := es.buildKeePassEntry(account, db)
+		if err != nil {
+			return nil, err
+		}
+		db.Root.Entries = append(db.Root.Entries, entry)
+	}
+	return db, nil
+}
+
+/**
+ * buildKeePassEntry 将账号转换为KeePass条目，附件写入数据库的附件列表
+ * @param account 账号
+ * @param db KeePass数据库
+ * @return kdbx.Entry 条目
+ * @return error 错误信息
+ */
+func (es *ExportService) buildKeePassEntry(account models.AccountDecrypted, db *kdbx.Database) (kdbx.Entry, error) {
+	lastAccess := account.LastUsedAt
+	if lastAccess.IsZero() {
+		lastAccess = account.UpdatedAt
+	}
+
+	entry := kdbx.Entry{
+		UUID: keepassUUID(account.ID),
+		Times: kdbx.Times{
+			CreationTime:         account.CreatedAt,
+			LastModificationTime: account.UpdatedAt,
+			LastAccessTime:       lastAccess,
+			LocationChanged:      account.UpdatedAt,
+			UsageCount:           account.UseCount,
+		},
+		Strings: []kdbx.StringField{
+			{Key: kdbx.FieldTitle, Value: account.Title},
+			{Key: kdbx.FieldUserName, Value: exportKeePassReferences(account.Username)},
+			{Key: kdbx.FieldPassword, Value: exportKeePassReferences(account.Password), Protected: true},
+			{Key: kdbx.FieldURL, Value: exportKeePassReferences(account.URL)},
+			{Key: kdbx.FieldNotes, Value: account.Notes},
+		},
+	}
+	if account.IsFavorite {
+		entry.Tags = keepassFavoriteTags[0]
+	}
+
+	expiry, err := NewPasswordExpiryService(es.dbManager).GetAccountExpiry(account.ID)
+	if err != nil {
+		return entry, err
+	}
+	if expiry.ExpiresAt != nil {
+		entry.Times.Expires = true
+		entry.Times.ExpiryTime = *expiry.ExpiresAt
+	}
+
+	// 历史密码导出为历史版本：每个版本的修改时间为上一个密码被替换的时间
+	history, err := NewPasswordRotationService(es.dbManager, es.accountService).GetPasswordHistory(account.ID)
+	if err != nil {
+		return entry, err
+	}
+	versionTime := account.CreatedAt
+	for i := len(history) - 1; i >= 0; i-- {
+		entry.History = append(entry.History, kdbx.Entry{
+			UUID:  entry.UUID,
+			Times: kdbx.Times{CreationTime: account.CreatedAt, LastModificationTime: versionTime, LastAccessTime: versionTime},
+			Strings: []kdbx.StringField{
+				{Key: kdbx.FieldTitle, Value: account.Title},
+				{Key: kdbx.FieldUserName, Value: exportKeePassReferences(account.Username)},
+				{Key: kdbx.FieldPassword, Value: exportKeePassReferences(history[i].Password), Protected: true},
+			},
+		})
+		versionTime = history[i].CreatedAt
+	}
+
+	attachmentService := NewAccountAttachmentService(es.dbManager, es.accountService)
+	attachments, err := attachmentService.GetAttachments(account.ID)
+	if err != nil {
+		return entry, err
+	}
+	for _, attachment := range attachments {
+		_, data, err := attachmentService.GetAttachmentData(attachment.ID)
+		if err != nil {
+			return entry, err
+		}
+		ref := kdbx.BinaryRef{Key: attachment.Name}
+		ref.Value.Ref = len(db.Binaries)
+		db.Binaries = append(db.Binaries, kdbx.Binary{Data: data})
+		entry.Binaries = append(entry.Binaries, ref)
+	}
+	return entry, nil
+}
+
+/**
+ * keepassGroupPath 分组的完整路径，如 客户/ACME/生产
+ */
+func keepassGroupPath(group models.Group, groupByID map[string]models.Group) string {
+	names := []string{group.Name}
+	seen := map[string]bool{group.ID: true}
+	for parentID := group.ParentID; parentID != "" && !seen[parentID]; {
+		parent, ok := groupByID[parentID]
+		if !ok {
+			break
+		}
+		seen[parentID] = true
+		names = append([]string{parent.Name}, names...)
+		parentID = parent.ParentID
+	}
+	return strings.Join(names, "/")
+}
+
+/**
+ * keepassUUID 将wepass的GUID转换为KeePass的UUID，非GUID格式的ID取SHA-256的前16字节
+ * @param id wepass ID
+ * @return kdbx.UUID KeePass UUID，同一ID每次导出结果相同
+ */
+func keepassUUID(id string) kdbx.UUID {
+	var u kdbx.UUID
+	if raw, err := hex.DecodeString(strings.ReplaceAll(id, "-", "")); err == nil && len(raw) == len(u) {
+		copy(u[:], raw)
+		return u
+	}
+	sum := sha256.Sum256([]byte(id))
+	copy(u[:], sum[:])
+	return u
+}
+
+/**
+ * exportKeePassReferences 将wepass字段引用转换为KeePass格式（32位大写十六进制UUID）
+ * @param value 字段值
+ * @return string 转换后的字段值，与convertKeePassReferences互逆
+ */
+func exportKeePassReferences(value string) string {
+	return wepassReferencePattern.ReplaceAllStringFunc(value, func(match string) string {
+		parts := wepassReferencePattern.FindStringSubmatch(match)
+		return strings.ToUpper(parts[1]) + strings.ToUpper(strings.ReplaceAll(parts[2], "-", "")) + "}"
+	})
+}
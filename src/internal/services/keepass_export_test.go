@@ -0,0 +1,173 @@
+package services
+
+import (
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"wepassword/internal/kdbx"
+	"wepassword/internal/models"
+)
+
+/**
+ * KeePass导出测试
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 导出为KDBX后检查两级分组结构，再导入到另一个密码库，检查账号、收藏、时间、历史密码和附件是否保留；
+ *              类型或分组缺失的账号放在根分组下
+ */
+
+func TestExportKeePass_RoundTrip(t *testing.T) {
+	source := newTestImportService(t)
+	dbManager := source.dbManager
+
+	parent, err := source.groupService.CreateGroup("客户")
+	if err != nil {
+		t.Fatal(err)
+	}
+	child, err := source.groupService.CreateChildGroup("ACME", parent.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prodType, err := source.typeService.CreateType("生产", child.ID, "fa-folder")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := source.accountService.CreateAccount("数据库", "dba", "old-pass", "https://db.example.com", prodType.ID, "备注", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Password = "new-pass"
+	db.IsFavorite = true
+	if err := source.accountService.UpdateAccount(db); err != nil {
+		t.Fatal(err)
+	}
+	replacedAt := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	if _, err := addPasswordHistory(dbManager.GetDB(), source.cryptoManager, db.ID, "old-pass", replacedAt); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := addAccountAttachment(dbManager.GetDB(), source.cryptoManager, db.ID, "id_rsa", []byte("PRIVATE KEY"), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	expiresAt := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := NewPasswordExpiryService(dbManager).SetAccountExpiry(db.ID, &expiresAt, 0); err != nil {
+		t.Fatal(err)
+	}
+	ref, err := source.accountService.CreateAccount("引用", "u", "{REF:P@I:"+db.ID+"}", "", prodType.ID, "", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exportPath := filepath.Join(t.TempDir(), "export.kdbx")
+	exportService := NewExportService(dbManager, source.accountService, source.groupService, source.typeService)
+	err = exportService.ExportKeePass(ExportOptions{
+		LoginPassword:  "Test246!Asd",
+		BackupPassword: "keepass-pw",
+		ExportPath:     exportPath,
+		TypeIDs:        []string{prodType.ID},
+	})
+	if err != nil {
+		t.Fatalf("导出失败: %v", err)
+	}
+
+	// 两级结构：根分组 -> 分组（完整路径）-> 类型 -> 条目
+	file, err := os.Open(exportPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	key, _ := kdbx.NewCompositeKey("keepass-pw", nil)
+	kp, err := kdbx.Open(file, key)
+	if err != nil {
+		t.Fatalf("读取导出的KDBX失败: %v", err)
+	}
+	if len(kp.Root.Groups) != 1 || kp.Root.Groups[0].Name != "客户/ACME" || len(kp.Root.Groups[0].Groups) != 1 {
+		t.Fatalf("分组结构错误: %+v", kp.Root.Groups)
+	}
+	typeGroup := kp.Root.Groups[0].Groups[0]
+	if typeGroup.Name != "生产" || len(typeGroup.Entries) != 2 {
+		t.Fatalf("类型分组错误: %s, %d个条目", typeGroup.Name, len(typeGroup.Entries))
+	}
+	for _, entry := range typeGroup.Entries {
+		if entry.Get(kdbx.FieldTitle) == "引用" && entry.Get(kdbx.FieldPassword) != "{REF:P@I:"+keepassUUIDHex(db.ID)+"}" {
+			t.Errorf("字段引用应转换为KeePass格式: %s", entry.Get(kdbx.FieldPassword))
+		}
+	}
+
+	// 导入到另一个密码库
+	target := newTestImportService(t)
+	result, err := target.ImportKeePass(KeePassImportOptions{ImportPath: exportPath, Password: "keepass-pw"})
+	if err != nil {
+		t.Fatalf("导入失败: %v", err)
+	}
+	if result.ImportedAccounts != 2 {
+		t.Fatalf("导入统计错误: %+v", result)
+	}
+
+	imported, err := target.accountService.GetAccountByID(db.ID)
+	if err != nil {
+		t.Fatalf("导入后账号ID应保持不变: %v", err)
+	}
+	if imported.Password != "new-pass" || imported.URL != "https://db.example.com" || imported.Notes != "备注" || !imported.IsFavorite {
+		t.Errorf("账号字段未保留: %+v", imported)
+	}
+	if !imported.CreatedAt.Equal(db.CreatedAt.Truncate(time.Second)) {
+		t.Errorf("创建时间未保留: %v, 原始: %v", imported.CreatedAt, db.CreatedAt)
+	}
+
+	history, err := NewPasswordRotationService(target.dbManager, target.accountService).GetPasswordHistory(db.ID)
+	if err != nil || len(history) != 1 || history[0].Password != "old-pass" {
+		t.Errorf("历史密码未保留: %+v, %v", history, err)
+	}
+	attachments, err := NewAccountAttachmentService(target.dbManager, target.accountService).GetAttachments(db.ID)
+	if err != nil || len(attachments) != 1 || attachments[0].Name != "id_rsa" {
+		t.Errorf("附件未保留: %+v, %v", attachments, err)
+	}
+	expiry, err := NewPasswordExpiryService(target.dbManager).GetAccountExpiry(db.ID)
+	if err != nil || expiry.ExpiresAt == nil || !expiry.ExpiresAt.Equal(expiresAt) {
+		t.Errorf("到期时间未保留: %+v, %v", expiry, err)
+	}
+
+	refAccount, err := target.accountService.GetAccountByID(ref.ID)
+	if err != nil || refAccount.Password != "{REF:P@I:"+db.ID+"}" {
+		t.Errorf("字段引用往返后应恢复为wepass格式: %+v, %v", refAccount, err)
+	}
+}
+
+func TestExportKeePass_WrongLoginPassword(t *testing.T) {
+	is := newTestImportService(t)
+	exportService := NewExportService(is.dbManager, is.accountService, is.groupService, is.typeService)
+	err := exportService.ExportKeePass(ExportOptions{
+		LoginPassword:  "wrong",
+		BackupPassword: "pw",
+		ExportPath:     filepath.Join(t.TempDir(), "export.kdbx"),
+		ExportAll:      true,
+	})
+	if err == nil {
+		t.Error("登录密码错误时应返回错误")
+	}
+}
+
+func keepassUUIDHex(id string) string {
+	u := keepassUUID(id)
+	return strings.ToUpper(hex.EncodeToString(u[:]))
+}
+
+func TestBuildKeePassDatabase_OrphanAccounts(t *testing.T) {
+	is := newTestImportService(t)
+	exportService := NewExportService(is.dbManager, is.accountService, is.groupService, is.typeService)
+
+	// 类型不在列表中的账号放在根分组下，不会被丢弃
+	orphan := models.AccountDecrypted{ID: "orphan", Title: "孤立账号", Password: "p", TypeID: "missing-type"}
+	db, err := exportService.buildKeePassDatabase([]models.AccountDecrypted{orphan}, nil, nil)
+	if err != nil {
+		t.Fatalf("生成KeePass数据库失败: %v", err)
+	}
+	if len(db.Root.Groups) != 0 || len(db.Root.Entries) != 1 || db.Root.Entries[0].Get(kdbx.FieldTitle) != "孤立账号" {
+		t.Errorf("孤立账号应放在根分组下: %+v", db.Root)
+	}
+}
@@ -0,0 +1,506 @@
+package services
+
+import (
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"wepassword/internal/bitwarden"
+	"wepassword/internal/kdbx"
+	"wepassword/internal/logger"
+	"wepassword/internal/models"
+	"wepassword/internal/utils"
+)
+
+/**
+ * KeePass导入
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 读取KeePass 2.x / KeePassXC的KDBX 4数据库并导入当前密码库：
+ *              分组映射为wepass的分组和类型——条目所在的KeePass分组成为类型，其上级分组成为wepass分组
+ *              （顶级分组的条目放在同名分组的同名类型下，根分组的条目放在以数据库名命名的分组中）；
+ *              条目映射为账号，UUID作为账号ID，已存在的账号跳过；自定义字段、标签和两步验证（otpauth://链接）写入备注，
+ *              历史版本中的旧密码写入历史密码，附件加密保存到账号附件，回收站中的条目不导入
+ */
+
+// KeePass中表示收藏的标签
+var keepassFavoriteTags = []string{"favorite", "favourite", "收藏"}
+
+// keepassReferencePattern KeePass字段引用，账号ID为32位十六进制UUID
+var keepassReferencePattern = regexp.MustCompile(`(?i)(\{REF:[TUPAN]@I:)([0-9a-f]{32})\}`)
+
+/**
+ * KeePassImportOptions KeePass导入选项
+ */
+type KeePassImportOptions struct {
+	ImportPath  string `json:"import_path"`   // KDBX文件路径
+	Password    string `json:"password"`      // 数据库密码，仅使用密钥文件时为空
+	KeyFilePath string `json:"key_file_path"` // 密钥文件路径，未使用密钥文件时为空
+}
+
+/**
+ * keepassImport 一次KeePass导入的上下文
+ */
+type keepassImport struct {
+	*importTargets
+	db     *kdbx.Database
+	expiry *PasswordExpiryService
+}
+
+/**
+ * ImportKeePass 导入KeePass KDBX 4数据库
+ * @param options 导入选项
+ * @return ImportResult 导入结果，EntryResults中包含每个条目的结果
+ * @return error 文件无法打开或解密失败时返回错误
+ */
+func (is *ImportService) ImportKeePass(options KeePassImportOptions) (ImportResult, error) {
+	logger.Info("[导入] 开始导入KeePass数据库，导入路径: %s", options.ImportPath)
+
+	result := ImportResult{
+		SkippedAccountDetails: make([]SkippedAccountInfo, 0),
+		EntryResults:          make([]ImportEntryResult, 0),
+	}
+	if !is.dbManager.IsOpened() {
+		result.ErrorMessage = "数据库未打开"
+		return result, fmt.Errorf("数据库未打开")
+	}
+	if is.accountService.cryptoManager == nil {
+		result.ErrorMessage = "加密管理器未设置"
+		return result, fmt.Errorf("加密管理器未设置")
+	}
+
+	db, err := openKeePassDatabase(options)
+	if err != nil {
+		result.ErrorMessage = err.Error()
+		return result, err
+	}
+	logger.Info("[导入] ✅ KeePass数据库解密成功")
+
+	return is.importKeePassDatabase(db, result)
+}
+
+/**
+ * importKeePassDatabase 将解密后的KeePass数据库导入当前密码库
+ * @param db 解密后的KeePass数据库
+ * @param result 导入结果
+ * @return ImportResult 导入结果
+ * @return error 错误信息
+ */
+func (is *ImportService) importKeePassDatabase(db *kdbx.Database, result ImportResult) (ImportResult, error) {
+	targets, err := is.newImportTargets(&result)
+	if err != nil {
+		return result, err
+	}
+
+	ki := &keepassImport{
+		importTargets: targets,
+		db:            db,
+		expiry:        NewPasswordExpiryService(is.dbManager),
+	}
+	ki.importGroup(db.Root, nil)
+
+	result.Success = true
+	logger.Info("[导入] 🎉 KeePass导入完成: 总数=%d, 导入=%d, 跳过=%d, 错误=%d",
+		result.TotalAccounts, result.ImportedAccounts, result.SkippedAccounts, result.ErrorAccounts)
+	return result, nil
+}
+
+/**
+ * openKeePassDatabase 读取密钥文件并解密KDBX数据库
+ * @param options 导入选项
+ * @return *kdbx.Database 解密后的数据库
+ * @return error 错误信息
+ */
+func openKeePassDatabase(options KeePassImportOptions) (*kdbx.Database, error) {
+	var keyFile []byte
+	if options.KeyFilePath != "" {
+		data, err := os.ReadFile(options.KeyFilePath)
+		if err != nil {
+			return nil, fmt.Errorf("读取密钥文件失败: %w", err)
+		}
+		keyFile = data
+	}
+	key, err := kdbx.NewCompositeKey(options.Password, keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(options.ImportPath)
+	if err != nil {
+		return nil, fmt.Errorf("打开KeePass数据库失败: %w", err)
+	}
+	defer file.Close()
+
+	db, err := kdbx.Open(file, key)
+	if err != nil {
+		return nil, fmt.Errorf("解密KeePass数据库失败: %w", err)
+	}
+	return db, nil
+}
+
+/**
+ * importGroup 递归导入KeePass分组中的条目
+ * @param group KeePass分组
+ * @param path 分组路径（不含根分组）
+ */
+func (ki *keepassImport) importGroup(group kdbx.Group, path []string) {
+	if ki.isRecycleBin(group) {
+		logger.Info("[导入] 跳过KeePass回收站: %s", group.Name)
+		return
+	}
+
+	if len(group.Entries) > 0 {
+		typeID, err := ki.resolveType(path)
+		for _, entry := range group.Entries {
+			entryResult := ImportEntryResult{
+				Path:      strings.Join(path, "/"),
+				Title:     entry.Get(kdbx.FieldTitle),
+				AccountID: keepassAccountID(entry),
+				Warnings:  make([]string, 0),
+			}
+			if err != nil {
+				ki.result.TotalAccounts++
+				ki.fail(&entryResult, fmt.Sprintf("创建分组或类型失败: %v", err))
+				continue
+			}
+			ki.importEntry(entry, typeID, &entryResult)
+		}
+	}
+
+	for _, child := range group.Groups {
+		childPath := append(append([]string(nil), path...), keepassGroupName(child.Name))
+		ki.importGroup(child, childPath)
+	}
+}
+
+/**
+ * isRecycleBin 是否为KeePass回收站分组
+ */
+func (ki *keepassImport) isRecycleBin(group kdbx.Group) bool {
+	recycleBin := ki.db.Meta.RecycleBinUUID
+	return bool(ki.db.Meta.RecycleBinEnabled) && !recycleBin.IsZero() && group.UUID == recycleBin
+}
+
+/**
+ * resolveType 获取（必要时创建）KeePass分组对应的wepass类型
+ * @param path KeePass分组路径（不含根分组）
+ * @return string 类型ID
+ * @return error 错误信息
+ * @description 根分组的条目放在以数据库名命名的分组和类型中，其余按resolvePath映射
+ */
+func (ki *keepassImport) resolveType(path []string) (string, error) {
+	if len(path) == 0 {
+		name := keepassGroupName(ki.db.Meta.DatabaseName)
+		if strings.TrimSpace(ki.db.Meta.DatabaseName) == "" {
+			name = "KeePass"
+		}
+		path = []string{name}
+	}
+
+	return ki.resolvePath(path)
+}
+
+/**
+ * importEntry 导入单个条目
+ * @param entry KeePass条目
+ * @param typeID 目标类型ID
+ * @param entryResult 条目结果
+ */
+func (ki *keepassImport) importEntry(entry kdbx.Entry, typeID string, entryResult *ImportEntryResult) {
+	ki.result.TotalAccounts++
+
+	if ki.skipExisting(entryResult, entry.Get(kdbx.FieldUserName)) {
+		return
+	}
+
+	account := convertKeePassEntry(entry, entryResult.AccountID, typeID)
+	entryResult.Title = account.Title
+	if err := ki.is.createAccountWithID(account); err != nil {
+		logger.Error("[导入] 创建账号失败: ID=%s, Title=%s, 错误=%v", account.ID, account.Title, err)
+		ki.fail(entryResult, err.Error())
+		return
+	}
+
+	ki.importExtras(entry, account, entryResult)
+
+	ki.imported(entryResult)
+}
+
+/**
+ * importExtras 导入账号之外的内容：到期时间、历史密码和附件，失败时记为警告
+ * @param entry KeePass条目
+ * @param account 已导入的账号
+ * @param entryResult 条目结果
+ */
+func (ki *keepassImport) importExtras(entry kdbx.Entry, account models.AccountDecrypted, entryResult *ImportEntryResult) {
+	db := ki.is.dbManager.GetDB()
+	cryptoManager := ki.is.accountService.cryptoManager
+
+	if entry.Times.Expires && !entry.Times.ExpiryTime.IsZero() {
+		expiresAt := entry.Times.ExpiryTime
+		if err := ki.expiry.SetAccountExpiry(account.ID, &expiresAt, 0); err != nil {
+			entryResult.Warnings = append(entryResult.Warnings, fmt.Sprintf("到期时间未导入: %v", err))
+		}
+	}
+
+	for _, history := range keepassPasswordHistory(entry) {
+		if _, err := addPasswordHistory(db, cryptoManager, account.ID, history.Password, history.CreatedAt); err != nil {
+			entryResult.Warnings = append(entryResult.Warnings, fmt.Sprintf("历史密码未导入: %v", err))
+			break
+		}
+	}
+
+	for _, ref := range entry.Binaries {
+		data, ok := ki.db.Attachment(ref)
+		if !ok {
+			entryResult.Warnings = append(entryResult.Warnings, fmt.Sprintf("附件%s的引用无效", ref.Key))
+			continue
+		}
+		if _, err := addAccountAttachment(db, cryptoManager, account.ID, ref.Key, data, account.CreatedAt); err != nil {
+			entryResult.Warnings = append(entryResult.Warnings, fmt.Sprintf("附件未导入: %v", err))
+		}
+	}
+}
+
+/**
+ * convertKeePassEntry 将KeePass条目转换为账号
+ * @param entry KeePass条目
+ * @param accountID 账号ID
+ * @param typeID 类型ID
+ * @return models.AccountDecrypted 账号
+ */
+func convertKeePassEntry(entry kdbx.Entry, accountID, typeID string) models.AccountDecrypted {
+	now := time.Now()
+	title := strings.TrimSpace(entry.Get(kdbx.FieldTitle))
+	if title == "" {
+		title = "未命名条目"
+	}
+
+	favorite := false
+	tags := make([]string, 0)
+	for _, tag := range entry.TagList() {
+		if isKeePassFavoriteTag(tag) {
+			favorite = true
+			continue
+		}
+		tags = append(tags, tag)
+	}
+
+	otpURI, otpKeys := keepassOTP(entry, title)
+	notes := []string{strings.TrimRight(convertKeePassReferences(entry.Get(kdbx.FieldNotes)), "\n")}
+	if custom := keepassCustomFields(entry, otpKeys); custom != "" {
+		notes = append(notes, notesCustomFieldsHeader+custom)
+	}
+	if len(tags) > 0 {
+		notes = append(notes, "标签: "+strings.Join(tags, ", "))
+	}
+	if otpURI != "" {
+		notes = append(notes, notesOTPPrefix+otpURI)
+	}
+	if notes[0] == "" {
+		notes = notes[1:]
+	}
+
+	account := models.AccountDecrypted{
+		ID:          accountID,
+		Title:       title,
+		Username:    convertKeePassReferences(entry.Get(kdbx.FieldUserName)),
+		Password:    convertKeePassReferences(entry.Get(kdbx.FieldPassword)),
+		URL:         convertKeePassReferences(entry.Get(kdbx.FieldURL)),
+		TypeID:      typeID,
+		Notes:       strings.Join(notes, "\n\n"),
+		IsFavorite:  favorite,
+		UseCount:    entry.Times.UsageCount,
+		LastUsedAt:  entry.Times.LastAccessTime,
+		CreatedAt:   entry.Times.CreationTime,
+		UpdatedAt:   entry.Times.LastModificationTime,
+		InputMethod: 1,
+	}
+	if account.CreatedAt.IsZero() {
+		account.CreatedAt = now
+	}
+	if account.UpdatedAt.IsZero() {
+		account.UpdatedAt = account.CreatedAt
+	}
+	return account
+}
+
+/**
+ * keepassAccountID KeePass条目UUID转换为账号ID，UUID为空时生成新ID
+ */
+func keepassAccountID(entry kdbx.Entry) string {
+	if entry.UUID.IsZero() {
+		return utils.GenerateGUID()
+	}
+	return entry.UUID.String()
+}
+
+/**
+ * keepassGroupName KeePass分组名称，去掉首尾空格，名称为空时使用默认名称
+ */
+func keepassGroupName(name string) string {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "未命名分组"
+	}
+	return name
+}
+
+func isKeePassFavoriteTag(tag string) bool {
+	for _, favorite := range keepassFavoriteTags {
+		if strings.EqualFold(tag, favorite) {
+			return true
+		}
+	}
+	return false
+}
+
+/**
+ * convertKeePassReferences 将KeePass字段引用中的32位十六进制UUID转换为wepass的账号ID格式
+ * @param value 字段值
+ * @return string 转换后的字段值，如 {REF:P@I:46C9B1FF...} -> {REF:P@I:46c9b1ff-...}
+ */
+func convertKeePassReferences(value string) string {
+	return keepassReferencePattern.ReplaceAllStringFunc(value, func(match string) string {
+		parts := keepassReferencePattern.FindStringSubmatch(match)
+		raw, err := hex.DecodeString(parts[2])
+		if err != nil {
+			return match
+		}
+		var id kdbx.UUID
+		copy(id[:], raw)
+		return strings.ToUpper(parts[1]) + id.String() + "}"
+	})
+}
+
+/**
+ * keepassCustomFields 格式化自定义字段，每行一个字段，格式同Bitwarden导入（见formatNotesField）
+ * @param entry KeePass条目
+ * @param exclude 不需要输出的字段（两步验证相关字段）
+ * @return string 自定义字段文本
+ * @description 受保护的字段作为隐藏字段，导出到Bitwarden时保持隐藏
+ */
+func keepassCustomFields(entry kdbx.Entry, exclude map[string]bool) string {
+	lines := make([]string, 0)
+	for _, field := range entry.Strings {
+		switch field.Key {
+		case kdbx.FieldTitle, kdbx.FieldUserName, kdbx.FieldPassword, kdbx.FieldURL, kdbx.FieldNotes:
+			continue
+		}
+		if exclude[field.Key] || field.Value == "" {
+			continue
+		}
+		custom := bitwarden.Field{Name: field.Key, Value: convertKeePassReferences(field.Value), Type: bitwarden.FieldTypeText}
+		if field.Protected {
+			custom.Type = bitwarden.FieldTypeHidden
+		}
+		lines = append(lines, formatNotesField(custom))
+	}
+	return strings.Join(lines, "\n")
+}
+
+/**
+ * keepassOTP 读取条目的两步验证设置并转换为otpauth://链接
+ * @param entry KeePass条目
+ * @param title 账号标题，作为链接中的发行方
+ * @return string otpauth://链接，没有两步验证时为空
+ * @return map[string]bool 已转换的字段，不再作为自定义字段输出
+ * @description 支持KeePassXC的otp字段、KeePass 2.47+的TimeOtp-*字段以及旧版KeePassXC的TOTP Seed/TOTP Settings字段
+ */
+func keepassOTP(entry kdbx.Entry, title string) (string, map[string]bool) {
+	used := make(map[string]bool)
+
+	if uri := strings.TrimSpace(entry.Get("otp")); strings.HasPrefix(strings.ToLower(uri), "otpauth://") {
+		used["otp"] = true
+		return uri, used
+	}
+
+	params := url.Values{}
+	var secret string
+	for _, field := range entry.Strings {
+		value := strings.TrimSpace(field.Value)
+		switch field.Key {
+		case "TimeOtp-Secret-Base32", "TOTP Seed":
+			secret = strings.ToUpper(strings.ReplaceAll(value, " ", ""))
+		case "TimeOtp-Secret":
+			secret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte(field.Value))
+		case "TimeOtp-Secret-Hex":
+			if raw, err := hex.DecodeString(strings.ReplaceAll(value, " ", "")); err == nil {
+				secret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(raw)
+			}
+		case "TimeOtp-Secret-Base64":
+			if raw, err := base64.StdEncoding.DecodeString(value); err == nil {
+				secret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(raw)
+			}
+		case "TimeOtp-Length":
+			params.Set("digits", value)
+		case "TimeOtp-Period":
+			params.Set("period", value)
+		case "TimeOtp-Algorithm":
+			params.Set("algorithm", strings.TrimPrefix(strings.ToUpper(strings.ReplaceAll(value, "-", "")), "HMAC"))
+		case "TOTP Settings":
+			// 旧版KeePassXC格式：周期;位数，位数为S表示Steam令牌
+			parts := strings.SplitN(value, ";", 2)
+			params.Set("period", parts[0])
+			if len(parts) == 2 && parts[1] != "S" {
+				params.Set("digits", parts[1])
+			}
+		default:
+			continue
+		}
+		used[field.Key] = true
+	}
+	if secret == "" {
+		return "", used
+	}
+
+	params.Set("secret", strings.TrimRight(secret, "="))
+	params.Set("issuer", title)
+	label := url.PathEscape(title)
+	if username := entry.Get(kdbx.FieldUserName); username != "" {
+		label += ":" + url.PathEscape(username)
+	}
+	return "otpauth://totp/" + label + "?" + params.Encode(), used
+}
+
+/**
+ * keepassHistoryPassword 条目历史版本中被替换的密码
+ */
+type keepassHistoryPassword struct {
+	Password  string
+	CreatedAt time.Time // 被替换的时间，即下一个版本的修改时间
+}
+
+/**
+ * keepassPasswordHistory 从条目的历史版本中提取被替换的密码
+ * @param entry KeePass条目
+ * @return []keepassHistoryPassword 按时间排序的历史密码，只保留与下一个版本不同的密码
+ */
+func keepassPasswordHistory(entry kdbx.Entry) []keepassHistoryPassword {
+	versions := append([]kdbx.Entry(nil), entry.History...)
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versions[i].Times.LastModificationTime.Before(versions[j].Times.LastModificationTime)
+	})
+	versions = append(versions, entry)
+
+	history := make([]keepassHistoryPassword, 0)
+	for i := 0; i < len(versions)-1; i++ {
+		password, next := versions[i].Get(kdbx.FieldPassword), versions[i+1]
+		if password == "" || password == next.Get(kdbx.FieldPassword) {
+			continue
+		}
+		replacedAt := next.Times.LastModificationTime
+		if replacedAt.IsZero() {
+			replacedAt = time.Now()
+		}
+		history = append(history, keepassHistoryPassword{Password: password, CreatedAt: replacedAt})
+	}
+	return history
+}
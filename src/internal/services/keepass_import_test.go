@@ -0,0 +1,216 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"wepassword/internal/kdbx"
+)
+
+/**
+ * KeePass导入测试
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 测试分组映射、条目转换、历史密码、附件、两步验证以及重复导入时的跳过
+ */
+
+func newTestImportService(t *testing.T) *ImportService {
+	t.Helper()
+	accountService, _ := newTestAccountService(t)
+	dbManager := accountService.dbManager
+	return NewImportService(dbManager, accountService, NewGroupService(dbManager), NewTypeService(dbManager), accountService.cryptoManager)
+}
+
+func keepassTestUUID(b byte) kdbx.UUID {
+	var u kdbx.UUID
+	for i := range u {
+		u[i] = b
+	}
+	return u
+}
+
+func keepassTestEntry(id byte, title, password string, extra ...kdbx.StringField) kdbx.Entry {
+	fields := []kdbx.StringField{
+		{Key: kdbx.FieldTitle, Value: title},
+		{Key: kdbx.FieldUserName, Value: "alice"},
+		{Key: kdbx.FieldPassword, Value: password, Protected: true},
+	}
+	return kdbx.Entry{UUID: keepassTestUUID(id), Strings: append(fields, extra...)}
+}
+
+func TestImportKeePassDatabase(t *testing.T) {
+	is := newTestImportService(t)
+
+	created := time.Date(2023, 5, 1, 8, 0, 0, 0, time.UTC)
+	changed := time.Date(2024, 6, 1, 8, 0, 0, 0, time.UTC)
+	mail := keepassTestEntry(0x11, "邮箱", "new-pass",
+		kdbx.StringField{Key: kdbx.FieldURL, Value: "https://mail.example.com"},
+		kdbx.StringField{Key: kdbx.FieldNotes, Value: "原备注"},
+		kdbx.StringField{Key: "PIN", Value: "1234", Protected: true},
+		kdbx.StringField{Key: "otp", Value: "otpauth://totp/Mail:alice?secret=JBSWY3DPEHPK3PXP"},
+	)
+	mail.Tags = "favorite;work"
+	mail.Times = kdbx.Times{CreationTime: created, LastModificationTime: changed, Expires: true, ExpiryTime: changed.AddDate(1, 0, 0), UsageCount: 5}
+	mail.Binaries = []kdbx.BinaryRef{{Key: "cert.pem"}, {Key: "missing.bin"}}
+	mail.Binaries[1].Value.Ref = 9
+	old := keepassTestEntry(0x11, "邮箱", "old-pass")
+	old.Times.LastModificationTime = created
+	mail.History = []kdbx.Entry{old}
+
+	vpn := keepassTestEntry(0x22, "VPN", "{REF:P@I:11111111111111111111111111111111}")
+	rootEntry := keepassTestEntry(0x33, "", "root-pass")
+	trash := keepassTestEntry(0x44, "已删除", "deleted")
+
+	db := &kdbx.Database{
+		Meta: kdbx.Meta{DatabaseName: "团队库", RecycleBinEnabled: true, RecycleBinUUID: keepassTestUUID(0x99)},
+		Root: kdbx.Group{
+			Name:    "Root",
+			Entries: []kdbx.Entry{rootEntry},
+			Groups: []kdbx.Group{
+				{Name: "工作", Groups: []kdbx.Group{{Name: "邮件", Entries: []kdbx.Entry{mail}}}},
+				{Name: "网络", Entries: []kdbx.Entry{vpn}},
+				{UUID: keepassTestUUID(0x99), Name: "回收站", Entries: []kdbx.Entry{trash}},
+			},
+		},
+		Binaries: []kdbx.Binary{{Data: []byte("-----BEGIN CERTIFICATE-----")}},
+	}
+
+	result, err := is.importKeePassDatabase(db, ImportResult{})
+	if err != nil {
+		t.Fatalf("导入失败: %v", err)
+	}
+	if result.TotalAccounts != 3 || result.ImportedAccounts != 3 || len(result.EntryResults) != 3 {
+		t.Fatalf("导入统计错误: %+v", result)
+	}
+
+	// 分组映射：工作/邮件 -> 分组"工作"下的类型"邮件"；网络 -> 分组"网络"下的类型"网络"；根条目 -> 分组"团队库"
+	mailID := keepassTestUUID(0x11).String()
+	account, err := is.accountService.GetAccountByID(mailID)
+	if err != nil {
+		t.Fatalf("获取导入的账号失败: %v", err)
+	}
+	typeInfo, err := is.typeService.GetTypeByID(account.TypeID)
+	if err != nil || typeInfo.Name != "邮件" {
+		t.Fatalf("类型映射错误: %+v, %v", typeInfo, err)
+	}
+	group, err := is.groupService.GetGroupByID(typeInfo.GroupID)
+	if err != nil || group.Name != "工作" {
+		t.Fatalf("分组映射错误: %+v, %v", group, err)
+	}
+
+	if account.Password != "new-pass" || account.URL != "https://mail.example.com" || !account.IsFavorite || account.UseCount != 5 {
+		t.Errorf("账号字段错误: %+v", account)
+	}
+	if !account.CreatedAt.Equal(created) {
+		t.Errorf("创建时间错误: %v", account.CreatedAt)
+	}
+	for _, want := range []string{"原备注", "PIN: 1234", "标签: work", "两步验证: otpauth://totp/Mail:alice"} {
+		if !strings.Contains(account.Notes, want) {
+			t.Errorf("备注中缺少%q: %s", want, account.Notes)
+		}
+	}
+
+	rotation := NewPasswordRotationService(is.dbManager, is.accountService)
+	history, err := rotation.GetPasswordHistory(mailID)
+	if err != nil || len(history) != 1 || history[0].Password != "old-pass" || !history[0].CreatedAt.Equal(changed) {
+		t.Errorf("历史密码错误: %+v, %v", history, err)
+	}
+
+	attachments := NewAccountAttachmentService(is.dbManager, is.accountService)
+	list, err := attachments.GetAttachments(mailID)
+	if err != nil || len(list) != 1 || list[0].Name != "cert.pem" {
+		t.Fatalf("附件错误: %+v, %v", list, err)
+	}
+	if _, data, err := attachments.GetAttachmentData(list[0].ID); err != nil || string(data) != "-----BEGIN CERTIFICATE-----" {
+		t.Errorf("附件内容错误: %q, %v", data, err)
+	}
+
+	expiry, err := NewPasswordExpiryService(is.dbManager).GetAccountExpiry(mailID)
+	if err != nil || expiry.ExpiresAt == nil {
+		t.Errorf("到期时间未导入: %+v, %v", expiry, err)
+	}
+
+	for _, entryResult := range result.EntryResults {
+		if entryResult.AccountID == mailID && (entryResult.Path != "工作/邮件" || len(entryResult.Warnings) != 1) {
+			t.Errorf("条目结果错误: %+v", entryResult)
+		}
+	}
+
+	vpnAccount, err := is.accountService.GetAccountByID(keepassTestUUID(0x22).String())
+	if err != nil || vpnAccount.Password != "{REF:P@I:"+keepassTestUUID(0x11).String()+"}" {
+		t.Errorf("字段引用未转换: %+v, %v", vpnAccount, err)
+	}
+
+	rootAccount, err := is.accountService.GetAccountByID(keepassTestUUID(0x33).String())
+	if err != nil || rootAccount.Title != "未命名条目" {
+		t.Fatalf("根分组条目导入错误: %+v, %v", rootAccount, err)
+	}
+	if rootType, _ := is.typeService.GetTypeByID(rootAccount.TypeID); rootType == nil || rootType.Name != "团队库" {
+		t.Errorf("根分组条目应放在以数据库名命名的类型中: %+v", rootType)
+	}
+
+	if _, err := is.accountService.GetAccountByID(keepassTestUUID(0x44).String()); err == nil {
+		t.Error("回收站中的条目不应导入")
+	}
+
+	// 再次导入时复用分组和类型，已存在的账号跳过
+	again, err := is.importKeePassDatabase(db, ImportResult{})
+	if err != nil {
+		t.Fatalf("重复导入失败: %v", err)
+	}
+	if again.SkippedAccounts != 3 || again.ImportedAccounts != 0 || again.ImportedGroups != 0 || again.ImportedTypes != 0 {
+		t.Errorf("重复导入统计错误: %+v", again)
+	}
+	for _, entryResult := range again.EntryResults {
+		if entryResult.Status != ImportEntrySkipped {
+			t.Errorf("重复导入的条目应跳过: %+v", entryResult)
+		}
+	}
+}
+
+func TestImportKeePassDatabase_EmptyCredentials(t *testing.T) {
+	is := newTestImportService(t)
+
+	// 只有备注的条目：没有用户名和密码
+	note := kdbx.Entry{UUID: keepassTestUUID(0x55), Strings: []kdbx.StringField{
+		{Key: kdbx.FieldTitle, Value: "安全笔记"},
+		{Key: kdbx.FieldNotes, Value: "恢复码: 1234-5678"},
+	}}
+	db := &kdbx.Database{Root: kdbx.Group{Name: "Root", Groups: []kdbx.Group{{Name: "笔记", Entries: []kdbx.Entry{note}}}}}
+
+	result, err := is.importKeePassDatabase(db, ImportResult{})
+	if err != nil || result.ImportedAccounts != 1 {
+		t.Fatalf("导入失败: %+v, %v", result, err)
+	}
+	account, err := is.accountService.GetAccountByID(keepassTestUUID(0x55).String())
+	if err != nil {
+		t.Fatalf("没有用户名和密码的账号应能读取: %v", err)
+	}
+	if account.Username != "" || account.Password != "" || account.Notes != "恢复码: 1234-5678" {
+		t.Errorf("账号字段错误: %+v", account)
+	}
+
+	again, err := is.importKeePassDatabase(db, ImportResult{})
+	if err != nil || again.SkippedAccounts != 1 || again.ErrorAccounts != 0 {
+		t.Errorf("重复导入应跳过: %+v, %v", again, err)
+	}
+}
+
+func TestKeePassOTP_TimeOtpFields(t *testing.T) {
+	entry := keepassTestEntry(0x01, "GitHub", "pw",
+		kdbx.StringField{Key: "TimeOtp-Secret-Hex", Value: "48656c6c6f21deadbeef"},
+		kdbx.StringField{Key: "TimeOtp-Length", Value: "8"},
+		kdbx.StringField{Key: "TimeOtp-Algorithm", Value: "HMAC-SHA-256"},
+	)
+
+	uri, used := keepassOTP(entry, "GitHub")
+	for _, want := range []string{"otpauth://totp/GitHub:alice?", "secret=JBSWY3DPEHPK3PXP", "digits=8", "algorithm=SHA256", "issuer=GitHub"} {
+		if !strings.Contains(uri, want) {
+			t.Errorf("otpauth链接中缺少%q: %s", want, uri)
+		}
+	}
+	if !used["TimeOtp-Length"] || keepassCustomFields(entry, used) != "" {
+		t.Error("两步验证字段不应再作为自定义字段输出")
+	}
+}
@@ -40,8 +40,9 @@ type LockService struct {
 	ctx              context.Context
 	cancel           context.CancelFunc
 	running          bool
-	lastActivityTime time.Time // 最后活动时间
-	lockTriggered    bool      // 锁定触发标志（供前端查询）
+	lastActivityTime time.Time             // 最后活动时间
+	lockTriggered    bool                  // 锁定触发标志（供前端查询）
+	lockListeners    []func(reason string) // 20251020 陈凤庆 锁定触发时的回调（如清空SSH代理私钥）
 }
 
 // 默认配置常量
@@ -97,6 +98,17 @@ func (ls *LockService) IsLockTriggered() bool {
 	return ls.lockTriggered
 }
 
+/**
+ * AddLockListener 注册锁定触发回调
+ * @param listener 回调函数，参数为锁定原因
+ * @description 20251020 陈凤庆 定时器或最小化触发锁定时依次调用，用于及时清理内存中的敏感数据
+ */
+func (ls *LockService) AddLockListener(listener func(reason string)) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	ls.lockListeners = append(ls.lockListeners, listener)
+}
+
 /**
  * ResetLockTrigger 重置锁定触发标志（登录后调用）
  */
@@ -416,8 +428,14 @@ func (ls *LockService) triggerLock(reason string) {
 		log.Println("[锁定服务] 系统定时器已停止")
 	}
 	ls.running = false // 停止锁定服务
+	listeners := append([]func(reason string){}, ls.lockListeners...)
 	ls.mu.Unlock()
 
+	// 20251020 陈凤庆 通知锁定回调（在锁外调用，避免回调中访问锁定服务导致死锁）
+	for _, listener := range listeners {
+		listener(reason)
+	}
+
 	log.Println("[锁定服务] 锁定触发完成，前端将检测到此状态")
 	logger.Info("[锁定服务] 锁定触发完成，前端将检测到此状态")
 }
@@ -0,0 +1,235 @@
+package services
+
+import (
+	"bufio"
+	"crypto/rand"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+
+	"wepassword/internal/models"
+)
+
+/**
+ * 口令短语生成器
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 基于内置词表（EFF长词表、EFF短词表、汉语拼音音节表）随机抽词生成Diceware口令短语
+ */
+
+//go:embed wordlists/*.txt
+var wordlistFS embed.FS
+
+// 内置词表标识
+const (
+	WordlistEFFLarge = "eff_large" // EFF长词表，7776词，每词约12.9位熵
+	WordlistEFFShort = "eff_short" // EFF短词表，1296词，每词约10.3位熵
+	WordlistPinyin   = "pinyin"    // 汉语拼音音节表（不含声调）
+
+	// DefaultPassphraseSymbols 默认可插入的符号集
+	DefaultPassphraseSymbols = "!@#$%^&*-_=+?"
+
+	// 口令短语单词数量上限
+	maxPassphraseWords = 20
+)
+
+// 词表文件映射
+var wordlistFiles = map[string]string{
+	WordlistEFFLarge: "wordlists/eff_large_wordlist.txt",
+	WordlistEFFShort: "wordlists/eff_small_wordlist.txt",
+	WordlistPinyin:   "wordlists/pinyin_wordlist.txt",
+}
+
+var (
+	wordlistCache   = make(map[string][]string)
+	wordlistCacheMu sync.Mutex
+)
+
+/**
+ * loadWordlist 加载内置词表
+ * @param name 词表标识，为空时使用EFF长词表
+ * @return []string 单词列表
+ * @return error 错误信息
+ */
+func loadWordlist(name string) ([]string, error) {
+	if name == "" {
+		name = WordlistEFFLarge
+	}
+
+	path, ok := wordlistFiles[name]
+	if !ok {
+		return nil, fmt.Errorf("未知的词表: %s", name)
+	}
+
+	return readEmbeddedWords(path)
+}
+
+/**
+ * readEmbeddedWords 读取内置词表文件
+ * @param path 词表文件在内置文件系统中的路径
+ * @return []string 单词列表（保持文件中的顺序）
+ * @return error 错误信息
+ * @description EFF词表每行为“骰子编号<Tab>单词”，只取最后一列；读取结果会被缓存
+ */
+func readEmbeddedWords(path string) ([]string, error) {
+	wordlistCacheMu.Lock()
+	defer wordlistCacheMu.Unlock()
+
+	if words, ok := wordlistCache[path]; ok {
+		return words, nil
+	}
+
+	file, err := wordlistFS.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开词表失败: %w", err)
+	}
+	defer file.Close()
+
+	var words []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		words = append(words, fields[len(fields)-1])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取词表失败: %w", err)
+	}
+	if len(words) == 0 {
+		return nil, fmt.Errorf("词表为空: %s", path)
+	}
+
+	wordlistCache[path] = words
+	return words, nil
+}
+
+/**
+ * generatePassphrase 生成口令短语规则密码
+ * @param configJSON 配置JSON字符串
+ * @return string 生成的口令短语
+ * @return error 错误信息
+ */
+func (prs *PasswordRuleService) generatePassphrase(configJSON string) (string, error) {
+	var config models.PassphraseRuleConfig
+	err := json.Unmarshal([]byte(configJSON), &config)
+	if err != nil {
+		return "", fmt.Errorf("解析口令短语规则配置失败: %w", err)
+	}
+
+	if err := prs.validatePassphraseConfig(config); err != nil {
+		return "", err
+	}
+
+	words, err := loadWordlist(config.Wordlist)
+	if err != nil {
+		return "", err
+	}
+
+	// 抽取单词
+	parts := make([]string, config.WordCount)
+	for i := range parts {
+		index, err := rand.Int(rand.Reader, big.NewInt(int64(len(words))))
+		if err != nil {
+			return "", fmt.Errorf("生成随机索引失败: %w", err)
+		}
+		word, err := prs.capitalizeWord(words[index.Int64()], config.Capitalization)
+		if err != nil {
+			return "", err
+		}
+		parts[i] = word
+	}
+
+	// 插入数字和符号：每个字符随机追加到某个单词的开头或结尾
+	symbols := config.Symbols
+	if symbols == "" {
+		symbols = DefaultPassphraseSymbols
+	}
+	extras := make([]string, 0, config.DigitCount+config.SymbolCount)
+	for i := 0; i < config.DigitCount; i++ {
+		char, err := prs.getRandomChar(Digits)
+		if err != nil {
+			return "", err
+		}
+		extras = append(extras, string(char))
+	}
+	for i := 0; i < config.SymbolCount; i++ {
+		char, err := prs.getRandomChar(symbols)
+		if err != nil {
+			return "", err
+		}
+		extras = append(extras, string(char))
+	}
+	for _, extra := range extras {
+		index, err := rand.Int(rand.Reader, big.NewInt(int64(len(parts)*2)))
+		if err != nil {
+			return "", fmt.Errorf("生成随机位置失败: %w", err)
+		}
+		pos := int(index.Int64())
+		if pos%2 == 0 {
+			parts[pos/2] = extra + parts[pos/2]
+		} else {
+			parts[pos/2] = parts[pos/2] + extra
+		}
+	}
+
+	return strings.Join(parts, config.Separator), nil
+}
+
+/**
+ * validatePassphraseConfig 验证口令短语规则配置
+ * @param config 口令短语规则配置
+ * @return error 错误信息
+ */
+func (prs *PasswordRuleService) validatePassphraseConfig(config models.PassphraseRuleConfig) error {
+	if config.WordCount <= 0 {
+		return fmt.Errorf("单词数量必须大于0")
+	}
+
+	if config.WordCount > maxPassphraseWords {
+		return fmt.Errorf("单词数量不能超过%d", maxPassphraseWords)
+	}
+
+	if config.DigitCount < 0 || config.SymbolCount < 0 {
+		return fmt.Errorf("插入的数字和符号个数不能为负数")
+	}
+
+	if config.DigitCount+config.SymbolCount > config.WordCount*4 {
+		return fmt.Errorf("插入的数字和符号总数(%d)过多，不能超过单词数量的4倍", config.DigitCount+config.SymbolCount)
+	}
+
+	return nil
+}
+
+/**
+ * capitalizeWord 按配置转换单词大小写
+ * @param word 单词
+ * @param mode 大小写模式
+ * @return string 转换后的单词
+ * @return error 错误信息
+ */
+func (prs *PasswordRuleService) capitalizeWord(word, mode string) (string, error) {
+	switch mode {
+	case "", "lower":
+		return strings.ToLower(word), nil
+	case "upper":
+		return strings.ToUpper(word), nil
+	case "title":
+		return strings.ToUpper(word[:1]) + strings.ToLower(word[1:]), nil
+	case "random":
+		flip, err := rand.Int(rand.Reader, big.NewInt(2))
+		if err != nil {
+			return "", fmt.Errorf("生成随机数失败: %w", err)
+		}
+		if flip.Int64() == 1 {
+			return strings.ToUpper(word[:1]) + strings.ToLower(word[1:]), nil
+		}
+		return strings.ToLower(word), nil
+	default:
+		return "", fmt.Errorf("不支持的大小写模式: %s", mode)
+	}
+}
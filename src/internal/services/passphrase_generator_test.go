@@ -0,0 +1,112 @@
+package services
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"unicode"
+
+	"wepassword/internal/models"
+)
+
+/**
+ * TestLoadWordlist 测试内置词表加载
+ * 预期：EFF长词表7776词、短词表1296词，拼音表非空，未知词表报错
+ */
+func TestLoadWordlist(t *testing.T) {
+	cases := map[string]int{
+		WordlistEFFLarge: 7776,
+		WordlistEFFShort: 1296,
+	}
+	for name, expected := range cases {
+		words, err := loadWordlist(name)
+		if err != nil {
+			t.Fatalf("加载词表%s失败: %v", name, err)
+		}
+		if len(words) != expected {
+			t.Errorf("词表%s期望%d词，实际%d词", name, expected, len(words))
+		}
+		if strings.ContainsAny(words[0], "0123456789\t") {
+			t.Errorf("词表%s未去除骰子编号: %q", name, words[0])
+		}
+	}
+
+	pinyin, err := loadWordlist(WordlistPinyin)
+	if err != nil || len(pinyin) == 0 {
+		t.Fatalf("加载拼音词表失败: %v", err)
+	}
+
+	if _, err := loadWordlist("unknown"); err == nil {
+		t.Error("未知词表应返回错误")
+	}
+}
+
+/**
+ * TestGeneratePassphrase 测试口令短语生成
+ * 预期：单词数量、分隔符、首字母大写以及插入的数字和符号个数均符合配置
+ */
+func TestGeneratePassphrase(t *testing.T) {
+	prs := &PasswordRuleService{}
+
+	config := models.PassphraseRuleConfig{
+		Wordlist:       WordlistEFFLarge,
+		WordCount:      5,
+		Separator:      "~",
+		Capitalization: "title",
+		DigitCount:     2,
+		SymbolCount:    1,
+		Symbols:        "!",
+	}
+	configJSON, _ := json.Marshal(config)
+
+	for i := 0; i < 10; i++ {
+		passphrase, err := prs.generatePassphrase(string(configJSON))
+		if err != nil {
+			t.Fatalf("生成口令短语失败: %v", err)
+		}
+
+		parts := strings.Split(passphrase, "~")
+		if len(parts) != 5 {
+			t.Fatalf("期望5个单词，实际%d个: %s", len(parts), passphrase)
+		}
+
+		digits, symbols := 0, 0
+		for _, part := range parts {
+			word := strings.TrimFunc(part, func(r rune) bool { return unicode.IsDigit(r) || r == '!' })
+			if word == "" || !unicode.IsUpper(rune(word[0])) {
+				t.Errorf("单词首字母应大写: %s", part)
+			}
+			for _, r := range part {
+				if unicode.IsDigit(r) {
+					digits++
+				} else if r == '!' {
+					symbols++
+				}
+			}
+		}
+		if digits != 2 || symbols != 1 {
+			t.Errorf("期望2个数字1个符号，实际%d个数字%d个符号: %s", digits, symbols, passphrase)
+		}
+	}
+}
+
+/**
+ * TestGeneratePassphrase_InvalidConfig 测试无效的口令短语配置
+ */
+func TestGeneratePassphrase_InvalidConfig(t *testing.T) {
+	prs := &PasswordRuleService{}
+
+	invalid := []models.PassphraseRuleConfig{
+		{WordCount: 0},
+		{WordCount: maxPassphraseWords + 1},
+		{WordCount: 4, DigitCount: -1},
+		{WordCount: 4, Wordlist: "unknown"},
+		{WordCount: 4, Capitalization: "camel"},
+	}
+	for _, config := range invalid {
+		configJSON, _ := json.Marshal(config)
+		if _, err := prs.generatePassphrase(string(configJSON)); err == nil {
+			t.Errorf("配置%+v应返回错误", config)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package services
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"wepassword/internal/models"
+)
+
+/**
+ * 批量生成密码
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 按同一规则批量生成互不重复的密码，可同时排除密码库中已有的密码；
+ *              生成前根据规则熵值检查可能的密码数量是否足够，避免无法凑齐时反复重试
+ */
+
+const (
+	// maxBatchCount 单次批量生成的数量上限
+	maxBatchCount = 10000
+
+	// maxBatchDuplicateAttempts 单个密码连续生成重复值的默认次数上限
+	maxBatchDuplicateAttempts = 100
+
+	// maxBatchTotalAttempts 规则可选范围较小时单个密码连续重复次数的最大上限
+	maxBatchTotalAttempts = 1000000
+
+	// batchIndexPlaceholder 标题和用户名模板中的序号占位符
+	batchIndexPlaceholder = "{n}"
+)
+
+/**
+ * GenerateBatch 根据规则批量生成互不重复的密码
+ * @param ruleID 规则ID
+ * @param count 生成数量
+ * @param exclude 需要排除的已有密码，可为nil
+ * @return []string 生成的密码列表
+ * @return error 错误信息
+ */
+func (prs *PasswordRuleService) GenerateBatch(ruleID string, count int, exclude map[string]bool) ([]string, error) {
+	rule, err := prs.GetRuleByID(ruleID)
+	if err != nil {
+		return nil, fmt.Errorf("获取密码规则失败: %w", err)
+	}
+	return prs.generateUniqueBatch(rule.RuleType, rule.Config, count, exclude)
+}
+
+/**
+ * GenerateBatchByConfig 根据配置批量生成互不重复的密码
+ * @param ruleType 规则类型
+ * @param config 规则配置
+ * @param count 生成数量
+ * @param exclude 需要排除的已有密码，可为nil
+ * @return []string 生成的密码列表
+ * @return error 错误信息
+ */
+func (prs *PasswordRuleService) GenerateBatchByConfig(ruleType string, config interface{}, count int, exclude map[string]bool) ([]string, error) {
+	configJSON, err := json.Marshal(config)
+	if err != nil {
+		return nil, fmt.Errorf("序列化配置失败: %w", err)
+	}
+	return prs.generateUniqueBatch(ruleType, string(configJSON), count, exclude)
+}
+
+/**
+ * generateUniqueBatch 批量生成互不重复且不在排除集合中的密码
+ * @param ruleType 规则类型
+ * @param configJSON 配置JSON字符串
+ * @param count 生成数量
+ * @param exclude 需要排除的已有密码
+ * @return []string 生成的密码列表
+ * @return error 错误信息
+ */
+func (prs *PasswordRuleService) generateUniqueBatch(ruleType, configJSON string, count int, exclude map[string]bool) ([]string, error) {
+	if count <= 0 {
+		return nil, fmt.Errorf("生成数量必须大于0")
+	}
+	if count > maxBatchCount {
+		return nil, fmt.Errorf("生成数量不能超过%d", maxBatchCount)
+	}
+
+	// 规则可能生成的密码数量不足时直接报错：熵值上界对应最多能生成的密码数量；
+	// 生成并不均匀时重复更多，重复次数上限按下界放宽
+	lower, upper, err := prs.ruleEntropyBounds(ruleType, configJSON)
+	if err != nil {
+		return nil, err
+	}
+	if upper < math.Log2(float64(count)) {
+		return nil, fmt.Errorf("规则最多约能生成%.0f个不同的密码，不足以生成%d个互不重复的密码", math.Exp2(upper), count)
+	}
+
+	seen := make(map[string]bool, count)
+	passwords := make([]string, 0, count)
+	for len(passwords) < count {
+		limit := batchDuplicateLimit(lower, len(passwords)+len(exclude))
+		duplicates := 0
+		for {
+			password, err := prs.generatePasswordFromJSON(ruleType, configJSON)
+			if err != nil {
+				return nil, err
+			}
+			if !seen[password] && !exclude[password] {
+				seen[password] = true
+				passwords = append(passwords, password)
+				break
+			}
+
+			duplicates++
+			if duplicates >= limit {
+				return nil, fmt.Errorf("已生成%d个密码后连续%d次重复，规则的可选范围过小，请增加长度或字符种类", len(passwords), limit)
+			}
+		}
+	}
+
+	return passwords, nil
+}
+
+/**
+ * batchDuplicateLimit 计算生成下一个密码时允许的连续重复次数
+ * @param bits 规则熵值下界（位）
+ * @param used 已生成及需要排除的数量
+ * @return int 允许的连续重复次数
+ * @description 剩余可选密码越少，抽中新密码的概率越低。按剩余比例放宽上限，
+ *              使规则可选范围恰好够用时也能以极高概率凑齐
+ */
+func batchDuplicateLimit(bits float64, used int) int {
+	limit := float64(maxBatchDuplicateAttempts)
+	if bits < 40 {
+		capacity := math.Exp2(bits)
+		remaining := capacity - float64(used)
+		if remaining < 1 {
+			remaining = 1
+		}
+		limit = math.Max(limit, math.Ceil(20*capacity/remaining))
+	}
+	return int(math.Min(limit, maxBatchTotalAttempts))
+}
+
+/**
+ * BuildBatchEntries 按请求中的模板为批量生成的密码组装记录
+ * @param request 批量生成请求
+ * @param passwords 生成的密码列表
+ * @return []models.BatchPasswordEntry 记录列表
+ * @return error 错误信息
+ * @description 标题模板不含 {n} 时在末尾追加“-序号”，保证标题各不相同；用户名模板为空时使用标题
+ */
+func BuildBatchEntries(request models.BatchPasswordRequest, passwords []string) ([]models.BatchPasswordEntry, error) {
+	titleTemplate := strings.TrimSpace(request.TitleTemplate)
+	if titleTemplate == "" {
+		return nil, fmt.Errorf("标题模板不能为空")
+	}
+	if !strings.Contains(titleTemplate, batchIndexPlaceholder) {
+		titleTemplate += "-" + batchIndexPlaceholder
+	}
+
+	entries := make([]models.BatchPasswordEntry, len(passwords))
+	for i, password := range passwords {
+		index := strconv.Itoa(i + 1)
+		title := strings.ReplaceAll(titleTemplate, batchIndexPlaceholder, index)
+		username := title
+		if request.UsernameTemplate != "" {
+			username = strings.ReplaceAll(request.UsernameTemplate, batchIndexPlaceholder, index)
+		}
+
+		entries[i] = models.BatchPasswordEntry{
+			Index:    i + 1,
+			Title:    title,
+			Username: username,
+			Password: password,
+			URL:      request.URL,
+		}
+	}
+
+	return entries, nil
+}
+
+/**
+ * WriteBatchCSV 将批量生成的记录写为CSV
+ * @param w 输出
+ * @param entries 记录列表
+ * @return error 错误信息
+ * @description 列为 title,username,password,url，与常见密码管理器的CSV导入格式一致
+ */
+func WriteBatchCSV(w io.Writer, entries []models.BatchPasswordEntry) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"title", "username", "password", "url"}); err != nil {
+		return fmt.Errorf("写入CSV表头失败: %w", err)
+	}
+	for _, entry := range entries {
+		if err := writer.Write([]string{entry.Title, entry.Username, entry.Password, entry.URL}); err != nil {
+			return fmt.Errorf("写入CSV记录失败: %w", err)
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("写入CSV失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"testing"
+
+	"wepassword/internal/models"
+)
+
+/**
+ * 批量生成密码测试
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 测试批量生成的唯一性、排除已有密码、数量不足时报错以及记录组装和CSV导出
+ */
+
+func TestGenerateBatchByConfig_Unique(t *testing.T) {
+	prs := &PasswordRuleService{}
+	config := models.CustomRuleConfig{Pattern: "d{2}"}
+
+	// 两位数字共100种，恰好可以全部生成
+	passwords, err := prs.GenerateBatchByConfig("custom", config, 100, nil)
+	if err != nil {
+		t.Fatalf("批量生成失败: %v", err)
+	}
+	seen := make(map[string]bool)
+	for _, password := range passwords {
+		if seen[password] {
+			t.Fatalf("密码重复: %s", password)
+		}
+		seen[password] = true
+	}
+	if len(seen) != 100 {
+		t.Errorf("期望100个不同的密码，实际%d个", len(seen))
+	}
+
+	if _, err := prs.GenerateBatchByConfig("custom", config, 101, nil); err == nil {
+		t.Error("超过规则可选范围时应返回错误")
+	}
+	if _, err := prs.GenerateBatchByConfig("custom", config, 0, nil); err == nil {
+		t.Error("数量为0时应返回错误")
+	}
+}
+
+func TestGenerateBatchByConfig_Exclude(t *testing.T) {
+	prs := &PasswordRuleService{}
+	config := models.CustomRuleConfig{Pattern: "d{2}"}
+
+	exclude := make(map[string]bool)
+	for i := 0; i < 50; i++ {
+		exclude[fmt.Sprintf("%02d", i)] = true
+	}
+
+	passwords, err := prs.GenerateBatchByConfig("custom", config, 50, exclude)
+	if err != nil {
+		t.Fatalf("批量生成失败: %v", err)
+	}
+	for _, password := range passwords {
+		if exclude[password] {
+			t.Errorf("生成了已排除的密码: %s", password)
+		}
+	}
+
+	if _, err := prs.GenerateBatchByConfig("custom", config, 51, exclude); err == nil {
+		t.Error("排除后剩余密码不足时应返回错误")
+	}
+}
+
+func TestBuildBatchEntriesAndCSV(t *testing.T) {
+	request := models.BatchPasswordRequest{
+		TitleTemplate:    "svc",
+		UsernameTemplate: "svc_{n}@corp",
+		URL:              "https://db.example.com",
+	}
+
+	entries, err := BuildBatchEntries(request, []string{"p1", "p,2"})
+	if err != nil {
+		t.Fatalf("组装记录失败: %v", err)
+	}
+	if entries[1].Title != "svc-2" || entries[1].Username != "svc_2@corp" || entries[1].Index != 2 {
+		t.Errorf("记录组装错误: %+v", entries[1])
+	}
+
+	var buf bytes.Buffer
+	if err := WriteBatchCSV(&buf, entries); err != nil {
+		t.Fatalf("写入CSV失败: %v", err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("读取CSV失败: %v", err)
+	}
+	if len(records) != 3 || records[2][2] != "p,2" || records[0][2] != "password" {
+		t.Errorf("CSV内容错误: %v", records)
+	}
+
+	if _, err := BuildBatchEntries(models.BatchPasswordRequest{}, []string{"p"}); err == nil {
+		t.Error("标题模板为空时应返回错误")
+	}
+}
@@ -0,0 +1,317 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"wepassword/internal/models"
+)
+
+/**
+ * 合规预设
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 内置常见合规标准的预设，每个预设包含生成密码的通用规则和检查已有密码的策略。
+ *              检查只针对密码本身以及账号的标题、用户名、网址，不记录也不返回密码明文。
+ *              最长使用天数按 account_expiry 中记录的密码修改时间计算，没有记录时按账号最后修改时间计算。
+ *              20251021 陈凤庆 各预设默认禁止密码包含产品名称和常见场景词，检查时与调用方传入的禁用词合并
+ */
+
+// 合规检查问题代码
+const (
+	ComplianceEmptyPassword  = "empty_password"  // 密码为空
+	ComplianceMinLength      = "min_length"      // 长度不足
+	ComplianceMaxLength      = "max_length"      // 长度超出
+	ComplianceCharClasses    = "char_classes"    // 字符种类不足
+	ComplianceLetters        = "letters"         // 不含字母
+	ComplianceNumbers        = "numbers"         // 不含数字
+	ComplianceCommonPassword = "common_password" // 常见密码
+	ComplianceContextWord    = "context_word"    // 包含账号相关信息
+	ComplianceBannedWord     = "banned_word"     // 包含禁用词
+	ComplianceMaxAge         = "max_age"         // 超过最长使用天数
+)
+
+// contextWordMinLength 视为账号相关信息的词的最小长度，避免 com、www 等短词造成误报
+const contextWordMinLength = 4
+
+// contextWordIgnored 网址中不作为账号相关信息的词
+var contextWordIgnored = map[string]bool{"http": true, "https": true}
+
+// complianceBannedWords 预设默认禁止包含的词：产品名称以及密码中常见的场景词
+var complianceBannedWords = []string{"wepassword", "password", "passwd", "admin", "welcome", "letmein", "qwerty", "mima"}
+
+// compliancePresets 内置合规预设
+var compliancePresets = []models.CompliancePreset{
+	{
+		ID:          "nist-800-63b",
+		Name:        "NIST SP 800-63B",
+		Description: "美国NIST数字身份指南：单因素认证时至少15位，不强制字符组合和定期更换，禁止常见密码和与账号相关的词",
+		Rule: models.GeneralRuleConfig{
+			IncludeUppercase:    true,
+			IncludeLowercase:    true,
+			IncludeNumbers:      true,
+			IncludeSpecialChars: true,
+			Length:              20,
+		},
+		Policy: models.CompliancePolicy{
+			MinLength:          15,
+			BanCommonPasswords: true,
+			BanContextWords:    true,
+			BannedWords:        complianceBannedWords,
+		},
+	},
+	{
+		ID:          "pci-dss-4",
+		Name:        "PCI DSS 4.0",
+		Description: "支付卡行业数据安全标准：至少12位，同时包含字母和数字，仅使用密码认证时每90天更换",
+		Rule: models.GeneralRuleConfig{
+			IncludeUppercase:    true,
+			IncludeLowercase:    true,
+			IncludeNumbers:      true,
+			IncludeSpecialChars: true,
+			MinUppercase:        1,
+			MinLowercase:        1,
+			MinNumbers:          1,
+			MinSpecialChars:     1,
+			Length:              16,
+		},
+		Policy: models.CompliancePolicy{
+			MinLength:      12,
+			RequireLetters: true,
+			RequireNumbers: true,
+			BannedWords:    complianceBannedWords,
+			MaxAgeDays:     90,
+		},
+	},
+	{
+		ID:          "mlps-2.0",
+		Name:        "等保2.0",
+		Description: "网络安全等级保护2.0：至少8位，包含大写字母、小写字母、数字、特殊字符中的三种，定期更换",
+		Rule: models.GeneralRuleConfig{
+			IncludeUppercase:    true,
+			IncludeLowercase:    true,
+			IncludeNumbers:      true,
+			IncludeSpecialChars: true,
+			MinUppercase:        1,
+			MinLowercase:        1,
+			MinNumbers:          1,
+			MinSpecialChars:     1,
+			Length:              12,
+		},
+		Policy: models.CompliancePolicy{
+			MinLength:          8,
+			MinCharClasses:     3,
+			BanCommonPasswords: true,
+			BanContextWords:    true,
+			BannedWords:        complianceBannedWords,
+			MaxAgeDays:         90,
+		},
+	},
+}
+
+/**
+ * GetCompliancePresets 获取内置合规预设列表
+ * @return []models.CompliancePreset 合规预设列表
+ */
+func GetCompliancePresets() []models.CompliancePreset {
+	presets := make([]models.CompliancePreset, len(compliancePresets))
+	copy(presets, compliancePresets)
+	return presets
+}
+
+/**
+ * GetCompliancePreset 根据标识获取合规预设
+ * @param presetID 预设标识
+ * @return models.CompliancePreset 合规预设
+ * @return error 预设不存在时返回错误
+ */
+func GetCompliancePreset(presetID string) (models.CompliancePreset, error) {
+	for _, preset := range compliancePresets {
+		if preset.ID == presetID {
+			return preset, nil
+		}
+	}
+	return models.CompliancePreset{}, fmt.Errorf("合规预设不存在: %s", presetID)
+}
+
+/**
+ * InstallCompliancePreset 将合规预设的生成规则安装为密码规则
+ * @param presetID 预设标识
+ * @return models.PasswordRule 安装的规则；同名规则已存在时返回已有规则
+ * @return error 错误信息
+ */
+func (prs *PasswordRuleService) InstallCompliancePreset(presetID string) (models.PasswordRule, error) {
+	preset, err := GetCompliancePreset(presetID)
+	if err != nil {
+		return models.PasswordRule{}, err
+	}
+
+	existing, found, err := prs.getRuleByName(preset.Name)
+	if err != nil {
+		return models.PasswordRule{}, err
+	}
+	if found {
+		return existing, nil
+	}
+
+	return prs.CreateRule(preset.Name, preset.Description, "general", preset.Rule)
+}
+
+/**
+ * CheckCompliance 按合规预设检查账号密码
+ * @param presetID 预设标识
+ * @param accounts 解密后的账号列表
+ * @param bannedWords 额外禁止包含的词，与预设中的禁用词合并
+ * @param changedAt 账号ID到最近一次修改密码时间的映射，没有记录的账号按最后修改时间计算
+ * @param now 检查时间，用于计算密码使用天数
+ * @return models.ComplianceReport 检查报告
+ * @return error 错误信息
+ */
+func CheckCompliance(presetID string, accounts []models.AccountDecrypted, bannedWords []string, changedAt map[string]time.Time, now time.Time) (models.ComplianceReport, error) {
+	preset, err := GetCompliancePreset(presetID)
+	if err != nil {
+		return models.ComplianceReport{}, err
+	}
+
+	policy := preset.Policy
+	policy.BannedWords = append(append([]string(nil), policy.BannedWords...), bannedWords...)
+
+	report := models.ComplianceReport{
+		PresetID:     preset.ID,
+		PresetName:   preset.Name,
+		CheckedCount: len(accounts),
+		Accounts:     make([]models.AccountComplianceResult, 0),
+		CheckedAt:    now,
+	}
+	for _, account := range accounts {
+		violations := checkAccountCompliance(policy, account, passwordChangedAt(account, changedAt), now)
+		if len(violations) == 0 {
+			report.CompliantCount++
+			continue
+		}
+		report.Accounts = append(report.Accounts, models.AccountComplianceResult{
+			AccountID:  account.ID,
+			Title:      account.Title,
+			Violations: violations,
+		})
+	}
+
+	return report, nil
+}
+
+/**
+ * checkAccountCompliance 按策略检查单个账号
+ * @param policy 合规策略
+ * @param account 解密后的账号
+ * @param changedAt 最近一次修改密码的时间
+ * @param now 检查时间
+ * @return []models.ComplianceViolation 发现的问题，合规时为空
+ */
+func checkAccountCompliance(policy models.CompliancePolicy, account models.AccountDecrypted, changedAt, now time.Time) []models.ComplianceViolation {
+	var violations []models.ComplianceViolation
+	add := func(code, format string, args ...interface{}) {
+		violations = append(violations, models.ComplianceViolation{Code: code, Message: fmt.Sprintf(format, args...)})
+	}
+
+	password := account.Password
+	if password == "" {
+		add(ComplianceEmptyPassword, "密码为空")
+		return violations
+	}
+
+	length := len([]rune(password))
+	if policy.MinLength > 0 && length < policy.MinLength {
+		add(ComplianceMinLength, "密码长度%d位，少于%d位", length, policy.MinLength)
+	}
+	if policy.MaxLength > 0 && length > policy.MaxLength {
+		add(ComplianceMaxLength, "密码长度%d位，超过%d位", length, policy.MaxLength)
+	}
+
+	var hasUpper, hasLower, hasNumber, hasSpecial bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasNumber = true
+		default:
+			hasSpecial = true
+		}
+	}
+	if policy.MinCharClasses > 0 {
+		classes := 0
+		for _, has := range []bool{hasUpper, hasLower, hasNumber, hasSpecial} {
+			if has {
+				classes++
+			}
+		}
+		if classes < policy.MinCharClasses {
+			add(ComplianceCharClasses, "密码包含%d种字符，少于%d种", classes, policy.MinCharClasses)
+		}
+	}
+	if policy.RequireLetters && !hasUpper && !hasLower {
+		add(ComplianceLetters, "密码不包含字母")
+	}
+	if policy.RequireNumbers && !hasNumber {
+		add(ComplianceNumbers, "密码不包含数字")
+	}
+
+	lower := strings.ToLower(password)
+	if policy.BanCommonPasswords && isCommonPassword(lower) {
+		add(ComplianceCommonPassword, "密码是常见密码")
+	}
+	if policy.BanContextWords {
+		for _, word := range contextWords(account) {
+			if strings.Contains(lower, word) {
+				add(ComplianceContextWord, "密码包含账号相关信息")
+				break
+			}
+		}
+	}
+	for _, word := range policy.BannedWords {
+		word = strings.ToLower(strings.TrimSpace(word))
+		if word != "" && strings.Contains(lower, word) {
+			add(ComplianceBannedWord, "密码包含禁用词")
+			break
+		}
+	}
+
+	if policy.MaxAgeDays > 0 && !changedAt.IsZero() {
+		days := int(now.Sub(changedAt).Hours() / 24)
+		if days > policy.MaxAgeDays {
+			add(ComplianceMaxAge, "密码已使用%d天，超过%d天", days, policy.MaxAgeDays)
+		}
+	}
+
+	return violations
+}
+
+/**
+ * isCommonPassword 判断密码是否在常见密码表中
+ * @param lower 小写的密码
+ * @return bool 是否为常见密码
+ */
+func isCommonPassword(lower string) bool {
+	strengthDictOnce.Do(initStrengthData)
+	_, found := strengthRankedDicts[strengthDictPasswords][lower]
+	return found
+}
+
+/**
+ * contextWords 提取账号标题、用户名、网址中可能被用于密码的词
+ * @param account 解密后的账号
+ * @return []string 小写的词
+ */
+func contextWords(account models.AccountDecrypted) []string {
+	var words []string
+	for word := range buildUserInputDict([]string{account.Title, account.Username, account.URL}) {
+		if len([]rune(word)) >= contextWordMinLength && !contextWordIgnored[word] {
+			words = append(words, word)
+		}
+	}
+	return words
+}
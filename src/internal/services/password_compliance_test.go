@@ -0,0 +1,108 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"wepassword/internal/models"
+)
+
+/**
+ * 合规预设测试
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 测试合规检查发现的问题、预设规则生成的密码自身合规以及安装预设规则
+ */
+
+func violationCodes(result models.AccountComplianceResult) map[string]bool {
+	codes := make(map[string]bool)
+	for _, violation := range result.Violations {
+		codes[violation.Code] = true
+	}
+	return codes
+}
+
+func TestCheckCompliance(t *testing.T) {
+	now := time.Date(2025, 10, 21, 0, 0, 0, 0, time.UTC)
+	accounts := []models.AccountDecrypted{
+		{ID: "1", Title: "合规", Password: "Xk9#mPq2$vLw", UpdatedAt: now.AddDate(0, 0, -10)},
+		{ID: "2", Title: "过短且过期", Password: "Ab1!", UpdatedAt: now.AddDate(0, 0, -120)},
+		{ID: "3", Title: "常见密码", Password: "Password1"},
+		{ID: "4", Title: "Gitlab", Username: "zhangsan@corp.com", Password: "Zhangsan#2025x"},
+		{ID: "5", Title: "空密码"},
+		{ID: "6", Title: "禁用词", Password: "Wepass!2025abc"},
+		{ID: "7", Title: "默认禁用词", Password: "Tz8#Admin$kQ"},
+	}
+
+	// 账号2最后修改时间较早，但记录的密码修改时间在有效期内
+	changedAt := map[string]time.Time{"2": now.AddDate(0, 0, -10), "4": now.AddDate(0, 0, -100)}
+	report, err := CheckCompliance("mlps-2.0", accounts, []string{"wepass"}, changedAt, now)
+	if err != nil {
+		t.Fatalf("合规检查失败: %v", err)
+	}
+	if report.CheckedCount != 7 || report.CompliantCount != 1 {
+		t.Fatalf("检查数量错误: %+v", report)
+	}
+
+	expected := map[string][]string{
+		"2": {ComplianceMinLength},
+		"3": {ComplianceCommonPassword, ComplianceBannedWord},
+		"4": {ComplianceContextWord, ComplianceMaxAge},
+		"5": {ComplianceEmptyPassword},
+		"6": {ComplianceBannedWord},
+		"7": {ComplianceBannedWord},
+	}
+	for _, result := range report.Accounts {
+		codes := violationCodes(result)
+		for _, code := range expected[result.AccountID] {
+			if !codes[code] {
+				t.Errorf("账号%s应包含问题%s: %+v", result.AccountID, code, result.Violations)
+			}
+		}
+		if len(codes) != len(expected[result.AccountID]) {
+			t.Errorf("账号%s问题数量错误: %+v", result.AccountID, result.Violations)
+		}
+	}
+
+	if _, err := CheckCompliance("unknown", accounts, nil, nil, now); err == nil {
+		t.Error("预设不存在时应返回错误")
+	}
+}
+
+func TestCompliancePresets_RuleSatisfiesPolicy(t *testing.T) {
+	prs := &PasswordRuleService{}
+	now := time.Now()
+
+	for _, preset := range GetCompliancePresets() {
+		for i := 0; i < 20; i++ {
+			password, err := prs.GeneratePasswordByConfig("general", preset.Rule)
+			if err != nil {
+				t.Fatalf("%s: 生成密码失败: %v", preset.ID, err)
+			}
+			account := models.AccountDecrypted{Title: "test", Password: password, UpdatedAt: now}
+			if violations := checkAccountCompliance(preset.Policy, account, now, now); len(violations) != 0 {
+				t.Errorf("%s: 预设规则生成的密码不合规 %+v", preset.ID, violations)
+			}
+		}
+	}
+}
+
+func TestInstallCompliancePreset(t *testing.T) {
+	prs := newTestRuleService(t)
+
+	rule, err := prs.InstallCompliancePreset("pci-dss-4")
+	if err != nil {
+		t.Fatalf("安装合规预设失败: %v", err)
+	}
+	if rule.Name != "PCI DSS 4.0" || rule.RuleType != "general" {
+		t.Errorf("安装的规则错误: %+v", rule)
+	}
+
+	again, err := prs.InstallCompliancePreset("pci-dss-4")
+	if err != nil {
+		t.Fatalf("重复安装合规预设失败: %v", err)
+	}
+	if again.ID != rule.ID {
+		t.Errorf("重复安装应返回已有规则")
+	}
+}
@@ -0,0 +1,297 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"wepassword/internal/database"
+	"wepassword/internal/models"
+)
+
+/**
+ * 密码到期提醒服务
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 为账号设置到期日期或轮换周期，计算即将到期和已过期的账号。
+ *              轮换周期从最近一次修改密码的时间起算，账号没有记录密码修改时间时按账号最后修改时间计算
+ */
+
+// 到期状态
+const (
+	ExpiryStatusDue     = "due"     // 即将到期
+	ExpiryStatusOverdue = "overdue" // 已过期
+)
+
+// 到期原因
+const (
+	ExpiryReasonExpiry   = "expiry"   // 到期日期
+	ExpiryReasonRotation = "rotation" // 轮换周期
+)
+
+/**
+ * PasswordExpiryService 密码到期提醒服务
+ */
+type PasswordExpiryService struct {
+	dbManager *database.DatabaseManager
+}
+
+/**
+ * NewPasswordExpiryService 创建密码到期提醒服务
+ * @param dbManager 数据库管理器
+ * @return *PasswordExpiryService 密码到期提醒服务实例
+ */
+func NewPasswordExpiryService(dbManager *database.DatabaseManager) *PasswordExpiryService {
+	return &PasswordExpiryService{
+		dbManager: dbManager,
+	}
+}
+
+/**
+ * GetAccountExpiry 获取账号的到期设置
+ * @param accountID 账号ID
+ * @return models.AccountExpiry 到期设置，未设置时各项为空
+ * @return error 错误信息
+ */
+func (pes *PasswordExpiryService) GetAccountExpiry(accountID string) (models.AccountExpiry, error) {
+	if !pes.dbManager.IsOpened() {
+		return models.AccountExpiry{}, fmt.Errorf("数据库未打开")
+	}
+
+	expiry := models.AccountExpiry{AccountID: accountID}
+	var expiresAt, changedAt sql.NullTime
+	err := pes.dbManager.GetDB().QueryRow(`
+		SELECT expires_at, rotation_days, password_changed_at
+		FROM account_expiry
+		WHERE account_id = ?
+	`, accountID).Scan(&expiresAt, &expiry.RotationDays, &changedAt)
+	if err == sql.ErrNoRows {
+		return expiry, nil
+	}
+	if err != nil {
+		return models.AccountExpiry{}, fmt.Errorf("查询账号到期设置失败: %w", err)
+	}
+
+	expiry.ExpiresAt = nullTimePtr(expiresAt)
+	expiry.PasswordChangedAt = nullTimePtr(changedAt)
+	return expiry, nil
+}
+
+/**
+ * SetAccountExpiry 设置账号的到期日期和轮换周期
+ * @param accountID 账号ID
+ * @param expiresAt 到期日期，为nil表示不设置
+ * @param rotationDays 轮换周期（天），0表示不设置
+ * @return error 错误信息
+ */
+func (pes *PasswordExpiryService) SetAccountExpiry(accountID string, expiresAt *time.Time, rotationDays int) error {
+	if !pes.dbManager.IsOpened() {
+		return fmt.Errorf("数据库未打开")
+	}
+	if rotationDays < 0 {
+		return fmt.Errorf("轮换周期不能为负数")
+	}
+
+	db := pes.dbManager.GetDB()
+	var exists int
+	if err := db.QueryRow("SELECT COUNT(*) FROM accounts WHERE id = ?", accountID).Scan(&exists); err != nil {
+		return fmt.Errorf("查询账号失败: %w", err)
+	}
+	if exists == 0 {
+		return fmt.Errorf("账号不存在: %s", accountID)
+	}
+
+	var expires interface{}
+	if expiresAt != nil {
+		expires = *expiresAt
+	}
+	_, err := db.Exec(`
+		INSERT INTO account_expiry (account_id, expires_at, rotation_days, updated_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(account_id) DO UPDATE SET expires_at = excluded.expires_at, rotation_days = excluded.rotation_days, updated_at = excluded.updated_at
+	`, accountID, expires, rotationDays, time.Now())
+	if err != nil {
+		return fmt.Errorf("保存账号到期设置失败: %w", err)
+	}
+	return nil
+}
+
+/**
+ * GetPasswordChangedTimes 获取记录了密码修改时间的账号及其最近一次修改密码的时间
+ * @return map[string]time.Time 账号ID到密码修改时间的映射
+ * @return error 错误信息
+ * @author 20251021 陈凤庆 供合规检查和健康报告计算密码使用天数
+ */
+func (pes *PasswordExpiryService) GetPasswordChangedTimes() (map[string]time.Time, error) {
+	if !pes.dbManager.IsOpened() {
+		return nil, fmt.Errorf("数据库未打开")
+	}
+
+	rows, err := pes.dbManager.GetDB().Query(`
+		SELECT account_id, password_changed_at FROM account_expiry WHERE password_changed_at IS NOT NULL
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("查询密码修改时间失败: %w", err)
+	}
+	defer rows.Close()
+
+	changedAt := make(map[string]time.Time)
+	for rows.Next() {
+		var accountID string
+		var value time.Time
+		if err := rows.Scan(&accountID, &value); err != nil {
+			return nil, fmt.Errorf("扫描密码修改时间失败: %w", err)
+		}
+		changedAt[accountID] = value
+	}
+	return changedAt, rows.Err()
+}
+
+/**
+ * passwordChangedAt 获取账号最近一次修改密码的时间
+ * @param account 账号
+ * @param changedAt 账号ID到密码修改时间的映射
+ * @return time.Time 密码修改时间，没有记录时为账号最后修改时间
+ */
+func passwordChangedAt(account models.AccountDecrypted, changedAt map[string]time.Time) time.Time {
+	if t, ok := changedAt[account.ID]; ok {
+		return t
+	}
+	return account.UpdatedAt
+}
+
+/**
+ * GetExpirySummary 获取即将到期和已过期的账号
+ * @param now 检查时间
+ * @param reminderDays 提前提醒天数
+ * @return models.ExpirySummary 到期汇总
+ * @return error 错误信息
+ */
+func (pes *PasswordExpiryService) GetExpirySummary(now time.Time, reminderDays int) (models.ExpirySummary, error) {
+	if !pes.dbManager.IsOpened() {
+		return models.ExpirySummary{}, fmt.Errorf("数据库未打开")
+	}
+
+	rows, err := pes.dbManager.GetDB().Query(`
+		SELECT a.id, a.title, a.updated_at, e.expires_at, e.rotation_days, e.password_changed_at
+		FROM account_expiry e
+		JOIN accounts a ON a.id = e.account_id
+		WHERE e.expires_at IS NOT NULL OR e.rotation_days > 0
+	`)
+	if err != nil {
+		return models.ExpirySummary{}, fmt.Errorf("查询账号到期设置失败: %w", err)
+	}
+	defer rows.Close()
+
+	summary := models.ExpirySummary{Items: make([]models.ExpiryItem, 0), CheckedAt: now}
+	for rows.Next() {
+		var id, title string
+		var updatedAt time.Time
+		var expiresAt, changedAt sql.NullTime
+		expiry := models.AccountExpiry{}
+		if err := rows.Scan(&id, &title, &updatedAt, &expiresAt, &expiry.RotationDays, &changedAt); err != nil {
+			return models.ExpirySummary{}, fmt.Errorf("扫描账号到期设置失败: %w", err)
+		}
+		expiry.AccountID = id
+		expiry.ExpiresAt = nullTimePtr(expiresAt)
+		expiry.PasswordChangedAt = nullTimePtr(changedAt)
+
+		item, ok := computeExpiryItem(expiry, updatedAt, now, reminderDays)
+		if !ok {
+			continue
+		}
+		item.Title = title
+		if item.Status == ExpiryStatusOverdue {
+			summary.OverdueCount++
+		} else {
+			summary.DueCount++
+		}
+		summary.Items = append(summary.Items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return models.ExpirySummary{}, fmt.Errorf("读取账号到期设置失败: %w", err)
+	}
+
+	sort.Slice(summary.Items, func(i, j int) bool {
+		return summary.Items[i].DueAt.Before(summary.Items[j].DueAt)
+	})
+	return summary, nil
+}
+
+/**
+ * computeExpiryItem 计算账号需要修改密码的时间和状态
+ * @param expiry 到期设置
+ * @param updatedAt 账号最后修改时间，没有密码修改时间时作为轮换起点
+ * @param now 检查时间
+ * @param reminderDays 提前提醒天数
+ * @return models.ExpiryItem 到期信息
+ * @return bool 是否即将到期或已过期
+ */
+func computeExpiryItem(expiry models.AccountExpiry, updatedAt, now time.Time, reminderDays int) (models.ExpiryItem, bool) {
+	item := models.ExpiryItem{AccountID: expiry.AccountID}
+	found := false
+
+	if expiry.ExpiresAt != nil {
+		item.DueAt, item.Reason = *expiry.ExpiresAt, ExpiryReasonExpiry
+		found = true
+	}
+	if expiry.RotationDays > 0 {
+		changedAt := updatedAt
+		if expiry.PasswordChangedAt != nil {
+			changedAt = *expiry.PasswordChangedAt
+		}
+		rotationDue := changedAt.AddDate(0, 0, expiry.RotationDays)
+		if !found || rotationDue.Before(item.DueAt) {
+			item.DueAt, item.Reason = rotationDue, ExpiryReasonRotation
+		}
+		found = true
+	}
+	if !found {
+		return models.ExpiryItem{}, false
+	}
+
+	item.DaysLeft = int(math.Floor(item.DueAt.Sub(now).Hours() / 24))
+	switch {
+	case !item.DueAt.After(now):
+		item.Status = ExpiryStatusOverdue
+	case item.DueAt.Sub(now) <= time.Duration(reminderDays)*24*time.Hour:
+		item.Status = ExpiryStatusDue
+	default:
+		return models.ExpiryItem{}, false
+	}
+	return item, true
+}
+
+/**
+ * recordPasswordChanged 记录账号修改密码的时间
+ * @param db 数据库连接
+ * @param accountID 账号ID
+ * @param changedAt 修改时间
+ * @return error 错误信息
+ */
+func recordPasswordChanged(db *sql.DB, accountID string, changedAt time.Time) error {
+	_, err := db.Exec(`
+		INSERT INTO account_expiry (account_id, password_changed_at, updated_at)
+		VALUES (?, ?, ?)
+		ON CONFLICT(account_id) DO UPDATE SET password_changed_at = excluded.password_changed_at, updated_at = excluded.updated_at
+	`, accountID, changedAt, changedAt)
+	if err != nil {
+		return fmt.Errorf("记录密码修改时间失败: %w", err)
+	}
+	return nil
+}
+
+/**
+ * nullTimePtr 将可为空的时间转换为指针
+ * @param value 可为空的时间
+ * @return *time.Time 为空时返回nil
+ */
+func nullTimePtr(value sql.NullTime) *time.Time {
+	if !value.Valid {
+		return nil
+	}
+	t := value.Time
+	return &t
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"wepassword/internal/models"
+)
+
+/**
+ * 密码到期提醒测试
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 测试到期时间的计算、修改密码后重新起算轮换周期以及删除账号时清理到期设置
+ */
+
+func TestComputeExpiryItem(t *testing.T) {
+	now := time.Date(2025, 10, 21, 12, 0, 0, 0, time.UTC)
+	updatedAt := now.AddDate(0, 0, -85)
+	expiresSoon := now.AddDate(0, 0, 3)
+	expiredAt := now.AddDate(0, 0, -1)
+
+	cases := []struct {
+		name     string
+		expiry   models.AccountExpiry
+		ok       bool
+		status   string
+		reason   string
+		daysLeft int
+	}{
+		{"轮换周期即将到期", models.AccountExpiry{RotationDays: 90}, true, ExpiryStatusDue, ExpiryReasonRotation, 5},
+		{"轮换周期未到提醒时间", models.AccountExpiry{RotationDays: 120}, false, "", "", 0},
+		{"到期日期已过", models.AccountExpiry{ExpiresAt: &expiredAt}, true, ExpiryStatusOverdue, ExpiryReasonExpiry, -1},
+		{"两者取先到者", models.AccountExpiry{ExpiresAt: &expiresSoon, RotationDays: 90}, true, ExpiryStatusDue, ExpiryReasonExpiry, 3},
+		{"未设置", models.AccountExpiry{}, false, "", "", 0},
+	}
+
+	for _, c := range cases {
+		item, ok := computeExpiryItem(c.expiry, updatedAt, now, 7)
+		if ok != c.ok {
+			t.Errorf("%s: 期望%v，实际%v", c.name, c.ok, ok)
+			continue
+		}
+		if ok && (item.Status != c.status || item.Reason != c.reason || item.DaysLeft != c.daysLeft) {
+			t.Errorf("%s: 结果错误 %+v", c.name, item)
+		}
+	}
+}
+
+func TestPasswordExpiryService(t *testing.T) {
+	accountService, typeID := newTestAccountService(t)
+	pes := NewPasswordExpiryService(accountService.dbManager)
+
+	account, err := accountService.CreateAccount("VPN", "ops", "Old#Pass2024", "", typeID, "", 1)
+	if err != nil {
+		t.Fatalf("创建账号失败: %v", err)
+	}
+	if err := pes.SetAccountExpiry(account.ID, nil, 90); err != nil {
+		t.Fatalf("设置到期失败: %v", err)
+	}
+	if err := pes.SetAccountExpiry("missing", nil, 90); err == nil {
+		t.Error("账号不存在时应返回错误")
+	}
+
+	// 按账号最后修改时间计算，100天后已过期
+	summary, err := pes.GetExpirySummary(time.Now().AddDate(0, 0, 100), 7)
+	if err != nil {
+		t.Fatalf("获取到期汇总失败: %v", err)
+	}
+	if summary.OverdueCount != 1 || summary.Items[0].Title != "VPN" {
+		t.Fatalf("到期汇总错误: %+v", summary)
+	}
+
+	// 只修改标题不重新起算
+	account.Title = "VPN网关"
+	if err := accountService.UpdateAccount(account); err != nil {
+		t.Fatalf("更新账号失败: %v", err)
+	}
+	expiry, _ := pes.GetAccountExpiry(account.ID)
+	if expiry.PasswordChangedAt != nil || expiry.RotationDays != 90 {
+		t.Errorf("未修改密码时不应记录修改时间: %+v", expiry)
+	}
+
+	// 修改密码后记录修改时间
+	account.Password = "New#Pass2025"
+	if err := accountService.UpdateAccount(account); err != nil {
+		t.Fatalf("更新账号失败: %v", err)
+	}
+	expiry, _ = pes.GetAccountExpiry(account.ID)
+	if expiry.PasswordChangedAt == nil || time.Since(*expiry.PasswordChangedAt) > time.Minute {
+		t.Errorf("修改密码后应记录修改时间: %+v", expiry)
+	}
+
+	if err := accountService.DeleteAccount(account.ID); err != nil {
+		t.Fatalf("删除账号失败: %v", err)
+	}
+	expiry, _ = pes.GetAccountExpiry(account.ID)
+	if expiry.RotationDays != 0 {
+		t.Errorf("删除账号后应清理到期设置: %+v", expiry)
+	}
+}
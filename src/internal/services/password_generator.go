@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"math/big"
-	"strconv"
 	"strings"
 
 	"wepassword/internal/logger"
@@ -48,6 +47,25 @@ const (
 	Latin1Supplement  = "¡¢£¤¥¦§¨©ª«¬­®¯°±²³´µ¶·¸¹º»¼½¾¿ÀÁÂÃÄÅÆÇÈÉÊËÌÍÎÏÐÑÒÓÔÕÖ×ØÙÚÛÜÝÞßàáâãäåæçèéêëìíîïðñòóôõö÷øùúûüýþÿ"
 )
 
+// 20251021 陈凤庆 字符约束相关定义
+const (
+	AmbiguousChars = "0O1lI" // 易混淆字符
+
+	// 被禁止的顺序字符的最小长度，如 abc、321
+	forbiddenSequenceLength = 3
+
+	// 生成满足字符约束的密码时的最大尝试次数
+	maxConstraintAttempts = 1000
+)
+
+/**
+ * passwordCharConstraints 生成密码时的字符约束
+ */
+type passwordCharConstraints struct {
+	maxConsecutive  int  // 相同字符最多连续出现次数，0表示不限制
+	forbidSequences bool // 是否禁止升序或降序字符
+}
+
 /**
  * generateGeneralPassword 生成通用规则密码
  * @param configJSON 配置JSON字符串
@@ -67,6 +85,46 @@ func (prs *PasswordRuleService) generateGeneralPassword(configJSON string) (stri
 	}
 
 	// 构建字符集和最小要求
+	charSets, minCounts, err := prs.buildGeneralCharSets(config)
+	if err != nil {
+		return "", err
+	}
+
+	constraints := passwordCharConstraints{
+		maxConsecutive:  config.MaxConsecutive,
+		forbidSequences: config.ForbidSequences,
+	}
+
+	// 生成密码
+	password, err := prs.generatePasswordWithConstraints(charSets, minCounts, config.Length, constraints)
+	if err != nil {
+		return "", fmt.Errorf("生成密码失败: %w", err)
+	}
+
+	// 验证生成的密码是否符合要求
+	if err := prs.validateGeneratedPassword(password, config); err != nil {
+		logger.Error("[密码生成器] 生成的密码不符合要求，重新生成: %v", err)
+		// 重试一次
+		password, err = prs.generatePasswordWithConstraints(charSets, minCounts, config.Length, constraints)
+		if err != nil {
+			return "", fmt.Errorf("重新生成密码失败: %w", err)
+		}
+	}
+
+	return password, nil
+}
+
+/**
+ * buildGeneralCharSets 根据通用规则配置构建字符集和最小数量列表
+ * @param config 通用规则配置
+ * @return []string 字符集列表（已去除排除字符）
+ * @return []int 各字符集的最小数量
+ * @return error 错误信息
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 从 generateGeneralPassword 中提取，供随机生成和确定性生成共用
+ */
+func (prs *PasswordRuleService) buildGeneralCharSets(config models.GeneralRuleConfig) ([]string, []int, error) {
 	var charSets []string
 	var minCounts []int
 	var labels []string
@@ -101,27 +159,27 @@ func (prs *PasswordRuleService) generateGeneralPassword(configJSON string) (stri
 		labels = append(labels, "自定义字符")
 	}
 
-	if len(charSets) == 0 {
-		return "", fmt.Errorf("至少需要选择一种字符类型")
-	}
-
-	// 生成密码
-	password, err := prs.generatePasswordWithConstraints(charSets, minCounts, config.Length)
-	if err != nil {
-		return "", fmt.Errorf("生成密码失败: %w", err)
+	// 20251021 陈凤庆 去除排除字符和易混淆字符，排除后为空的类别不再参与生成
+	var filteredSets []string
+	var filteredMins []int
+	for i, charset := range charSets {
+		filtered := prs.filterExcludedChars(charset, config)
+		if filtered == "" {
+			if minCounts[i] > 0 {
+				return nil, nil, fmt.Errorf("%s在排除字符后为空，无法满足最小位数%d", labels[i], minCounts[i])
+			}
+			continue
+		}
+		filteredSets = append(filteredSets, filtered)
+		filteredMins = append(filteredMins, minCounts[i])
 	}
+	charSets, minCounts = filteredSets, filteredMins
 
-	// 验证生成的密码是否符合要求
-	if err := prs.validateGeneratedPassword(password, config); err != nil {
-		logger.Error("[密码生成器] 生成的密码不符合要求，重新生成: %v", err)
-		// 重试一次
-		password, err = prs.generatePasswordWithConstraints(charSets, minCounts, config.Length)
-		if err != nil {
-			return "", fmt.Errorf("重新生成密码失败: %w", err)
-		}
+	if len(charSets) == 0 {
+		return nil, nil, fmt.Errorf("至少需要选择一种字符类型")
 	}
 
-	return password, nil
+	return charSets, minCounts, nil
 }
 
 /**
@@ -185,22 +243,75 @@ func (prs *PasswordRuleService) validateGeneralConfig(config models.GeneralRuleC
 		return fmt.Errorf("最小位数总和(%d)不能大于密码长度(%d)", minTotal, config.Length)
 	}
 
+	if config.MaxConsecutive < 0 {
+		return fmt.Errorf("相同字符最多连续次数不能为负数")
+	}
+
 	return nil
 }
 
+/**
+ * filterExcludedChars 从字符集中去除排除字符和易混淆字符
+ * @param charset 字符集
+ * @param config 通用规则配置
+ * @return string 去除后的字符集
+ */
+func (prs *PasswordRuleService) filterExcludedChars(charset string, config models.GeneralRuleConfig) string {
+	excluded := config.ExcludeChars
+	if config.ExcludeAmbiguous {
+		excluded += AmbiguousChars
+	}
+	if excluded == "" {
+		return charset
+	}
+
+	var result strings.Builder
+	for _, char := range charset {
+		if !strings.ContainsRune(excluded, char) {
+			result.WriteRune(char)
+		}
+	}
+	return result.String()
+}
+
 /**
  * generatePasswordWithConstraints 根据约束生成密码
  * @param charSets 字符集列表
  * @param minCounts 最小数量列表
  * @param totalLength 总长度
+ * @param constraints 字符约束（连续重复、顺序字符）
  * @return string 生成的密码
  * @return error 错误信息
+ * @modify 20251021 陈凤庆 增加字符约束，不满足时整体重新生成，保证结果在满足约束的密码中均匀分布
  */
-func (prs *PasswordRuleService) generatePasswordWithConstraints(charSets []string, minCounts []int, totalLength int) (string, error) {
+func (prs *PasswordRuleService) generatePasswordWithConstraints(charSets []string, minCounts []int, totalLength int, constraints passwordCharConstraints) (string, error) {
 	if len(charSets) != len(minCounts) {
 		return "", fmt.Errorf("字符集和最小数量列表长度不匹配")
 	}
 
+	for attempt := 0; attempt < maxConstraintAttempts; attempt++ {
+		password, err := prs.generateShuffledPassword(charSets, minCounts, totalLength)
+		if err != nil {
+			return "", err
+		}
+		if checkCharConstraints(password, constraints) == nil {
+			return password, nil
+		}
+	}
+
+	return "", fmt.Errorf("尝试%d次仍无法生成满足字符约束的密码，请放宽连续重复或顺序字符限制", maxConstraintAttempts)
+}
+
+/**
+ * generateShuffledPassword 先满足各字符集最小数量，再随机填充并打乱顺序
+ * @param charSets 字符集列表
+ * @param minCounts 最小数量列表
+ * @param totalLength 总长度
+ * @return string 生成的密码
+ * @return error 错误信息
+ */
+func (prs *PasswordRuleService) generateShuffledPassword(charSets []string, minCounts []int, totalLength int) (string, error) {
+
 	var password []rune
 	allChars := ""
 
@@ -312,9 +423,100 @@ func (prs *PasswordRuleService) validateGeneratedPassword(password string, confi
 		return fmt.Errorf("自定义字符数量不足: 需要%d，实际%d", config.MinCustomChars, customCount)
 	}
 
+	// 20251021 陈凤庆 验证字符约束
+	for _, char := range password {
+		if prs.filterExcludedChars(string(char), config) == "" {
+			return fmt.Errorf("密码包含被排除的字符: %c", char)
+		}
+	}
+
+	return checkCharConstraints(password, passwordCharConstraints{
+		maxConsecutive:  config.MaxConsecutive,
+		forbidSequences: config.ForbidSequences,
+	})
+}
+
+/**
+ * checkCharConstraints 检查密码是否满足连续重复和顺序字符约束
+ * @param password 密码
+ * @param constraints 字符约束
+ * @return error 不满足时返回错误
+ */
+func checkCharConstraints(password string, constraints passwordCharConstraints) error {
+	chars := []rune(password)
+
+	if constraints.maxConsecutive > 0 {
+		run := 1
+		for i := 1; i < len(chars); i++ {
+			if chars[i] == chars[i-1] {
+				run++
+				if run > constraints.maxConsecutive {
+					return fmt.Errorf("字符%c连续出现超过%d次", chars[i], constraints.maxConsecutive)
+				}
+			} else {
+				run = 1
+			}
+		}
+	}
+
+	if constraints.forbidSequences {
+		// step 为当前顺序的方向（+1升序，-1降序），run 为当前顺序长度
+		run, step := 1, 0
+		for i := 1; i < len(chars); i++ {
+			diff, ok := sequenceStep(chars[i-1], chars[i])
+			if ok && run > 1 && diff == step {
+				run++
+			} else if ok {
+				run, step = 2, diff
+			} else {
+				run, step = 1, 0
+			}
+			if run >= forbiddenSequenceLength {
+				return fmt.Errorf("密码包含顺序字符: %s", string(chars[i-run+1:i+1]))
+			}
+		}
+	}
+
 	return nil
 }
 
+/**
+ * sequenceStep 判断两个相邻字符是否构成顺序
+ * @param prev 前一个字符
+ * @param next 后一个字符
+ * @return int 方向：1为升序，-1为降序
+ * @return bool 是否构成顺序；字母不区分大小写，只在字母或数字内部比较
+ */
+func sequenceStep(prev, next rune) (int, bool) {
+	normalize := func(r rune) (rune, int) {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r, 1
+		case r >= 'A' && r <= 'Z':
+			return r - 'A' + 'a', 1
+		case r >= '0' && r <= '9':
+			return r, 2
+		default:
+			return r, 0
+		}
+	}
+
+	p, pKind := normalize(prev)
+	n, nKind := normalize(next)
+	if pKind == 0 || pKind != nKind {
+		return 0, false
+	}
+
+	switch n - p {
+	case 1:
+		return 1, true
+	case -1:
+		return -1, true
+	default:
+		return 0, false
+	}
+}
+
 /**
  * analyzeCharsetTypes 分析字符集中包含的字符类型
  * @param charset 字符集
@@ -574,148 +776,149 @@ func (prs *PasswordRuleService) generateMixedAlphanumericChars(count int) ([]run
  * @param pattern 自定义规则模式
  * @return string 生成的密码
  * @return error 错误信息
+ * @modify 20251021 陈凤庆 改为先解析语法树再生成，支持{m,n}、分组选择、可选、打乱、引号字面值和Unicode字符集
  */
 func (prs *PasswordRuleService) parseCustomPattern(pattern string) (string, error) {
-	var result strings.Builder
-	i := 0
-
-	for i < len(pattern) {
-		char := pattern[i]
-
-		// 处理转义字符
-		if char == '\\' && i+1 < len(pattern) {
-			nextChar := pattern[i+1]
-			switch nextChar {
-			case 'a':
-				result.WriteRune('a')
-			case 'b':
-				result.WriteRune('b')
-			case 'c':
-				result.WriteRune('c')
-			default:
-				result.WriteByte(nextChar)
-			}
-			i += 2
-			continue
-		}
-
-		// 处理自定义字符集 [...]
-		if char == '[' {
-			end := strings.Index(pattern[i:], "]")
-			if end == -1 {
-				return "", fmt.Errorf("未找到匹配的']'")
-			}
-
-			customSet := pattern[i+1 : i+end]
-			if customSet == "" {
-				return "", fmt.Errorf("自定义字符集不能为空")
-			}
-
-			// 检查是否有重复次数 {n}
-			nextPos := i + end + 1
-			if nextPos < len(pattern) && pattern[nextPos] == '{' {
-				// 查找重复次数
-				countEnd := strings.Index(pattern[nextPos:], "}")
-				if countEnd == -1 {
-					return "", fmt.Errorf("未找到匹配的'}'")
-				}
+	nodes, err := parsePatternTree(pattern)
+	if err != nil {
+		return "", err
+	}
 
-				countStr := pattern[nextPos+1 : nextPos+countEnd]
-				count, err := strconv.Atoi(countStr)
-				if err != nil {
-					return "", fmt.Errorf("无效的重复次数: %s", countStr)
-				}
+	result, err := prs.generatePatternNodes(nodes)
+	if err != nil {
+		return "", err
+	}
 
-				if count <= 0 {
-					return "", fmt.Errorf("重复次数必须大于0")
-				}
+	return string(result), nil
+}
 
-				// 使用约束生成函数生成混合字符
-				chars, err := prs.generateMixedCharsWithConstraints(customSet, count)
-				if err != nil {
-					return "", fmt.Errorf("生成混合字符失败: %w", err)
-				}
-				for _, c := range chars {
-					result.WriteRune(c)
-				}
+/**
+ * generatePatternNodes 按顺序生成节点序列
+ * @param nodes 语法树节点
+ * @return []rune 生成的字符
+ * @return error 错误信息
+ */
+func (prs *PasswordRuleService) generatePatternNodes(nodes []patternNode) ([]rune, error) {
+	var result []rune
+	for _, node := range nodes {
+		chars, err := prs.generatePatternNode(node)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, chars...)
+	}
+	return result, nil
+}
 
-				i = nextPos + countEnd + 1
-			} else {
-				// 没有重复次数，生成一个字符
-				randomChar, err := prs.getRandomChar(customSet)
-				if err != nil {
-					return "", fmt.Errorf("从自定义字符集生成字符失败: %w", err)
-				}
-				result.WriteRune(randomChar)
-				i += end + 1
-			}
-			continue
+/**
+ * generatePatternNode 生成单个节点（含重复次数）
+ * @param node 语法树节点
+ * @return []rune 生成的字符
+ * @return error 错误信息
+ */
+func (prs *PasswordRuleService) generatePatternNode(node patternNode) ([]rune, error) {
+	count := node.min
+	if node.max > node.min {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(node.max-node.min+1)))
+		if err != nil {
+			return nil, fmt.Errorf("生成随机数失败: %w", err)
 		}
+		count += int(n.Int64())
+	}
+	if count == 0 {
+		return nil, nil
+	}
 
-		// 处理重复次数 {n}
-		if i+1 < len(pattern) && pattern[i+1] == '{' {
-			// 查找重复次数
-			end := strings.Index(pattern[i+1:], "}")
-			if end == -1 {
-				return "", fmt.Errorf("未找到匹配的'}'")
+	switch node.kind {
+	case patternNodeIdentifier:
+		// 特殊处理 A{n} 规则（混合字母数字），单个 A 仍为大写字母
+		if node.ident == 'A' && node.quantified {
+			chars, err := prs.generateMixedAlphanumericChars(count)
+			if err != nil {
+				return nil, fmt.Errorf("生成混合字母数字字符失败: %w", err)
 			}
+			return chars, nil
+		}
+		charset, err := prs.getCharsetForIdentifier(byte(node.ident))
+		if err != nil {
+			return nil, err
+		}
+		return prs.generateRandomChars(charset, count)
 
-			countStr := pattern[i+2 : i+1+end]
-			count, err := strconv.Atoi(countStr)
+	case patternNodeCharset:
+		if node.quantified {
+			// 带重复次数时按字符类型约束生成，确保各类字符都能出现
+			chars, err := prs.generateMixedCharsWithConstraints(string(node.charset), count)
 			if err != nil {
-				return "", fmt.Errorf("无效的重复次数: %s", countStr)
+				return nil, fmt.Errorf("生成混合字符失败: %w", err)
 			}
+			return chars, nil
+		}
+		return prs.generateRandomChars(string(node.charset), count)
+	}
 
-			if count <= 0 {
-				return "", fmt.Errorf("重复次数必须大于0")
+	var result []rune
+	for i := 0; i < count; i++ {
+		var chars []rune
+		var err error
+		switch node.kind {
+		case patternNodeLiteral:
+			chars = node.literal
+		case patternNodeGroup:
+			index, randErr := rand.Int(rand.Reader, big.NewInt(int64(len(node.alternatives))))
+			if randErr != nil {
+				return nil, fmt.Errorf("生成随机数失败: %w", randErr)
 			}
-
-			// 特殊处理 A 规则（混合字母数字）
-			if char == 'A' {
-				chars, err := prs.generateMixedAlphanumericChars(count)
-				if err != nil {
-					return "", fmt.Errorf("生成混合字母数字字符失败: %w", err)
-				}
-				for _, c := range chars {
-					result.WriteRune(c)
-				}
-			} else {
-				// 其他规则的处理
-				charset, err := prs.getCharsetForIdentifier(char)
-				if err != nil {
-					return "", err
-				}
-
-				for j := 0; j < count; j++ {
-					randomChar, err := prs.getRandomChar(charset)
-					if err != nil {
-						return "", fmt.Errorf("生成字符失败: %w", err)
-					}
-					result.WriteRune(randomChar)
-				}
+			chars, err = prs.generatePatternNodes(node.alternatives[index.Int64()])
+		case patternNodePermute:
+			chars, err = prs.generatePatternNodes(node.children)
+			if err == nil {
+				err = shuffleRunes(chars)
 			}
-
-			i += end + 2
-			continue
+		default:
+			err = fmt.Errorf("未知的模式节点类型: %d", node.kind)
 		}
-
-		// 处理单个字符标识符
-		charset, err := prs.getCharsetForIdentifier(char)
 		if err != nil {
-			// 如果不是标识符，直接添加字符
-			result.WriteByte(char)
-		} else {
-			randomChar, err := prs.getRandomChar(charset)
-			if err != nil {
-				return "", fmt.Errorf("生成字符失败: %w", err)
-			}
-			result.WriteRune(randomChar)
+			return nil, err
 		}
+		result = append(result, chars...)
+	}
+	return result, nil
+}
 
-		i++
+/**
+ * generateRandomChars 从字符集中随机生成指定数量的字符
+ * @param charset 字符集
+ * @param count 字符数量
+ * @return []rune 生成的字符
+ * @return error 错误信息
+ */
+func (prs *PasswordRuleService) generateRandomChars(charset string, count int) ([]rune, error) {
+	result := make([]rune, 0, count)
+	for i := 0; i < count; i++ {
+		char, err := prs.getRandomChar(charset)
+		if err != nil {
+			return nil, fmt.Errorf("生成字符失败: %w", err)
+		}
+		result = append(result, char)
 	}
+	return result, nil
+}
 
-	return result.String(), nil
+/**
+ * shuffleRunes 使用加密随机数原地打乱字符顺序
+ * @param chars 字符列表
+ * @return error 错误信息
+ */
+func shuffleRunes(chars []rune) error {
+	for i := len(chars) - 1; i > 0; i-- {
+		j, err := rand.Int(rand.Reader, big.NewInt(int64(i+1)))
+		if err != nil {
+			return fmt.Errorf("生成随机数失败: %w", err)
+		}
+		chars[i], chars[j.Int64()] = chars[j.Int64()], chars[i]
+	}
+	return nil
 }
 
 /**
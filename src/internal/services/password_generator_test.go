@@ -1,8 +1,13 @@
 package services
 
 import (
+	"encoding/json"
+	"errors"
 	"regexp"
+	"strings"
 	"testing"
+
+	"wepassword/internal/models"
 )
 
 /**
@@ -318,3 +323,201 @@ func TestDigitProbability(t *testing.T) {
 		t.Logf("数字几率为%.2f%%，符合预期", digitProbability)
 	}
 }
+
+/**
+ * TestParseCustomPattern_Extended 测试扩展模式语法
+ * 预期：{m,n}范围、分组选择、可选、打乱、引号字面值和Unicode字符集均按语法生成
+ */
+func TestParseCustomPattern_Extended(t *testing.T) {
+	prs := &PasswordRuleService{}
+
+	cases := []struct {
+		pattern string
+		regex   string
+	}{
+		{"d{2,4}", `^[0-9]{2,4}$`},
+		{"(\"abc\"|\"xyz\")-d{2}", `^(abc|xyz)-[0-9]{2}$`},
+		{"\"user_\"d{3}", `^user_[0-9]{3}$`},
+		{"'a(b)|c'", `^a\(b\)\|c$`},
+		{"u-?d", `^[A-Z]-?[0-9]$`},
+		{"[α-γ]{3}", `^[αβγ]{3}$`},
+		{"[中文]", `^[中文]$`},
+		{"\\a\\b\\c", `^abc$`},
+		{"(\"ab\"|d){2}", `^(ab|[0-9]){2}$`},
+	}
+
+	for _, c := range cases {
+		re := regexp.MustCompile(c.regex)
+		for i := 0; i < 20; i++ {
+			password, err := prs.parseCustomPattern(c.pattern)
+			if err != nil {
+				t.Fatalf("解析%s失败: %v", c.pattern, err)
+			}
+			if !re.MatchString(password) {
+				t.Errorf("模式%s生成的密码%s不符合%s", c.pattern, password, c.regex)
+			}
+		}
+	}
+}
+
+/**
+ * TestParseCustomPattern_Permute 测试打乱分组
+ * 预期：<...> 内容的字符集合不变，只改变顺序
+ */
+func TestParseCustomPattern_Permute(t *testing.T) {
+	prs := &PasswordRuleService{}
+
+	seen := make(map[string]bool)
+	for i := 0; i < 50; i++ {
+		password, err := prs.parseCustomPattern("<\"abcdef\">")
+		if err != nil {
+			t.Fatalf("解析失败: %v", err)
+		}
+		if !regexp.MustCompile(`^[a-f]{6}$`).MatchString(password) {
+			t.Fatalf("打乱结果%s不正确", password)
+		}
+		for _, c := range "abcdef" {
+			if !strings.ContainsRune(password, c) {
+				t.Fatalf("打乱结果%s缺少字符%c", password, c)
+			}
+		}
+		seen[password] = true
+	}
+
+	if len(seen) < 2 {
+		t.Error("打乱分组应产生不同的顺序")
+	}
+}
+
+/**
+ * TestParseCustomPattern_Errors 测试无效模式的错误位置
+ */
+func TestParseCustomPattern_Errors(t *testing.T) {
+	prs := &PasswordRuleService{}
+
+	cases := map[string]int{
+		"dd[abc":     3,
+		"d{3":        2,
+		"d{x}":       3,
+		"d{5,2}":     3,
+		"(ab|cd":     1,
+		"ab)":        3,
+		"{2}":        1,
+		"\"abc":      1,
+		"[z-a]":      2,
+		"<>":         1,
+		"d\\":        2,
+		"中文[]":       3,
+		"dd{1,9999}": 6,
+		// 嵌套重复的次数相乘后超过字符数上限
+		"((d{256}){256}){256}": 2,
+		"(d{256}){4}d":         12,
+	}
+
+	for pattern, pos := range cases {
+		_, err := prs.parseCustomPattern(pattern)
+		if err == nil {
+			t.Errorf("模式%s应返回错误", pattern)
+			continue
+		}
+		var patternErr *PatternError
+		if !errors.As(err, &patternErr) {
+			t.Errorf("模式%s的错误类型不正确: %v", pattern, err)
+			continue
+		}
+		if patternErr.Pos != pos {
+			t.Errorf("模式%s错误位置期望%d，实际%d（%v）", pattern, pos, patternErr.Pos, err)
+		}
+	}
+
+	if _, err := prs.parseCustomPattern("(d{256}){4}"); err != nil {
+		t.Errorf("恰好达到字符数上限的模式应能解析: %v", err)
+	}
+}
+
+/**
+ * TestGenerateGeneralPassword_CharConstraints 测试排除字符、易混淆字符、连续重复和顺序字符约束
+ */
+func TestGenerateGeneralPassword_CharConstraints(t *testing.T) {
+	prs := &PasswordRuleService{}
+
+	config := models.GeneralRuleConfig{
+		IncludeUppercase: true,
+		IncludeLowercase: true,
+		IncludeNumbers:   true,
+		MinUppercase:     1,
+		MinLowercase:     1,
+		MinNumbers:       1,
+		Length:           16,
+		ExcludeChars:     "xyzXYZ",
+		ExcludeAmbiguous: true,
+		MaxConsecutive:   1,
+		ForbidSequences:  true,
+	}
+	configJSON, _ := json.Marshal(config)
+
+	for i := 0; i < 200; i++ {
+		password, err := prs.generateGeneralPassword(string(configJSON))
+		if err != nil {
+			t.Fatalf("生成密码失败: %v", err)
+		}
+		if strings.ContainsAny(password, "xyzXYZ"+AmbiguousChars) {
+			t.Fatalf("密码%s包含被排除的字符", password)
+		}
+		if err := prs.validateGeneratedPassword(password, config); err != nil {
+			t.Fatalf("密码%s未通过校验: %v", password, err)
+		}
+	}
+}
+
+/**
+ * TestCheckCharConstraints 测试连续重复和顺序字符检查
+ */
+func TestCheckCharConstraints(t *testing.T) {
+	constraints := passwordCharConstraints{maxConsecutive: 2, forbidSequences: true}
+
+	valid := []string{"aab", "a1b2c3", "acegi", "az9Z", "ab-c", "12a3"}
+	for _, password := range valid {
+		if err := checkCharConstraints(password, constraints); err != nil {
+			t.Errorf("%s 不应违反约束: %v", password, err)
+		}
+	}
+
+	invalid := []string{"aaa", "xabcx", "x321", "aBc", "ZYX", "9a789"}
+	for _, password := range invalid {
+		if err := checkCharConstraints(password, constraints); err == nil {
+			t.Errorf("%s 应违反约束", password)
+		}
+	}
+}
+
+/**
+ * TestGenerateGeneralPassword_ExcludedClassEmpty 测试排除后字符类别为空时的处理
+ */
+func TestGenerateGeneralPassword_ExcludedClassEmpty(t *testing.T) {
+	prs := &PasswordRuleService{}
+
+	config := models.GeneralRuleConfig{
+		IncludeLowercase: true,
+		IncludeNumbers:   true,
+		MinNumbers:       1,
+		Length:           8,
+		ExcludeChars:     "23456789",
+		ExcludeAmbiguous: true,
+	}
+	configJSON, _ := json.Marshal(config)
+	if _, err := prs.generateGeneralPassword(string(configJSON)); err == nil {
+		t.Error("数字全部被排除且最小位数为1时应返回错误")
+	}
+
+	// 最小位数为0时该类别直接忽略
+	config.MinNumbers = 0
+	configJSON, _ = json.Marshal(config)
+	password, err := prs.generateGeneralPassword(string(configJSON))
+	if err != nil {
+		t.Fatalf("生成密码失败: %v", err)
+	}
+	if !regexp.MustCompile(`^[a-km-z]{8}$`).MatchString(password) {
+		t.Errorf("密码%s应只包含排除l后的小写字母", password)
+	}
+}
@@ -0,0 +1,318 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"wepassword/internal/crypto"
+	"wepassword/internal/database"
+	"wepassword/internal/logger"
+	"wepassword/internal/models"
+	"wepassword/internal/utils"
+)
+
+/**
+ * 密码轮换服务
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 引导式修改密码：开始轮换时把新密码保存为账号的待提交密码，账号本身仍使用旧密码；
+ *              在网站上修改成功后提交轮换，旧密码移入历史密码，新密码写入账号；修改失败时回滚，丢弃新密码。
+ *              待提交密码和历史密码都加密保存在数据库中，重启应用后轮换状态仍然有效
+ */
+
+/**
+ * PasswordRotationService 密码轮换服务
+ */
+type PasswordRotationService struct {
+	dbManager      *database.DatabaseManager
+	accountService *AccountService
+}
+
+/**
+ * NewPasswordRotationService 创建密码轮换服务
+ * @param dbManager 数据库管理器
+ * @param accountService 账号服务，用于读取和更新账号以及加解密密码
+ * @return *PasswordRotationService 密码轮换服务实例
+ */
+func NewPasswordRotationService(dbManager *database.DatabaseManager, accountService *AccountService) *PasswordRotationService {
+	return &PasswordRotationService{
+		dbManager:      dbManager,
+		accountService: accountService,
+	}
+}
+
+/**
+ * checkReady 检查数据库和加密管理器是否可用
+ * @return error 错误信息
+ */
+func (prs *PasswordRotationService) checkReady() error {
+	if !prs.dbManager.IsOpened() {
+		return fmt.Errorf("数据库未打开")
+	}
+	if prs.accountService.cryptoManager == nil {
+		return fmt.Errorf("加密管理器未设置")
+	}
+	return nil
+}
+
+/**
+ * StartRotation 开始密码轮换
+ * @param accountID 账号ID
+ * @param pendingPassword 待提交的新密码
+ * @param ruleID 生成新密码使用的规则ID，手动输入时为空
+ * @return models.PasswordRotation 轮换状态
+ * @return error 账号已有未完成的轮换时返回错误
+ */
+func (prs *PasswordRotationService) StartRotation(accountID, pendingPassword, ruleID string) (models.PasswordRotation, error) {
+	if err := prs.checkReady(); err != nil {
+		return models.PasswordRotation{}, err
+	}
+	if pendingPassword == "" {
+		return models.PasswordRotation{}, fmt.Errorf("新密码不能为空")
+	}
+
+	account, err := prs.accountService.GetAccountByID(accountID)
+	if err != nil {
+		return models.PasswordRotation{}, fmt.Errorf("获取账号失败: %w", err)
+	}
+	if account.Password == pendingPassword {
+		return models.PasswordRotation{}, fmt.Errorf("新密码不能与当前密码相同")
+	}
+
+	existing, err := prs.GetRotation(accountID)
+	if err != nil {
+		return models.PasswordRotation{}, err
+	}
+	if existing != nil {
+		return models.PasswordRotation{}, fmt.Errorf("账号已有未完成的密码轮换，请先提交或回滚")
+	}
+
+	encrypted, err := prs.accountService.cryptoManager.Encrypt(pendingPassword)
+	if err != nil {
+		return models.PasswordRotation{}, fmt.Errorf("加密新密码失败: %w", err)
+	}
+
+	rotation := models.PasswordRotation{
+		AccountID:       accountID,
+		Title:           account.Title,
+		PendingPassword: pendingPassword,
+		RuleID:          ruleID,
+		StartedAt:       time.Now(),
+	}
+	_, err = prs.dbManager.GetDB().Exec(`
+		INSERT INTO account_rotation (account_id, pending_password, rule_id, started_at)
+		VALUES (?, ?, ?, ?)
+	`, accountID, encrypted, ruleID, rotation.StartedAt)
+	if err != nil {
+		return models.PasswordRotation{}, fmt.Errorf("保存密码轮换失败: %w", err)
+	}
+	return rotation, nil
+}
+
+/**
+ * GetRotation 获取账号的密码轮换状态
+ * @param accountID 账号ID
+ * @return *models.PasswordRotation 轮换状态，没有进行中的轮换时为nil
+ * @return error 错误信息
+ */
+func (prs *PasswordRotationService) GetRotation(accountID string) (*models.PasswordRotation, error) {
+	if err := prs.checkReady(); err != nil {
+		return nil, err
+	}
+
+	rotations, err := prs.queryRotations("WHERE r.account_id = ?", accountID)
+	if err != nil {
+		return nil, err
+	}
+	if len(rotations) == 0 {
+		return nil, nil
+	}
+	return &rotations[0], nil
+}
+
+/**
+ * GetPendingRotations 获取所有进行中的密码轮换
+ * @return []models.PasswordRotation 轮换状态列表，按开始时间排序
+ * @return error 错误信息
+ */
+func (prs *PasswordRotationService) GetPendingRotations() ([]models.PasswordRotation, error) {
+	if err := prs.checkReady(); err != nil {
+		return nil, err
+	}
+	return prs.queryRotations("")
+}
+
+/**
+ * queryRotations 查询密码轮换状态并解密待提交密码
+ * @param where 查询条件
+ * @param args 查询参数
+ * @return []models.PasswordRotation 轮换状态列表
+ * @return error 错误信息
+ */
+func (prs *PasswordRotationService) queryRotations(where string, args ...interface{}) ([]models.PasswordRotation, error) {
+	rows, err := prs.dbManager.GetDB().Query(`
+		SELECT r.account_id, a.title, r.pending_password, r.rule_id, r.started_at
+		FROM account_rotation r
+		JOIN accounts a ON a.id = r.account_id
+		`+where+`
+		ORDER BY r.started_at
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询密码轮换失败: %w", err)
+	}
+	defer rows.Close()
+
+	rotations := make([]models.PasswordRotation, 0)
+	for rows.Next() {
+		var rotation models.PasswordRotation
+		var encrypted string
+		if err := rows.Scan(&rotation.AccountID, &rotation.Title, &encrypted, &rotation.RuleID, &rotation.StartedAt); err != nil {
+			return nil, fmt.Errorf("扫描密码轮换失败: %w", err)
+		}
+		rotation.PendingPassword, err = prs.accountService.cryptoManager.Decrypt(encrypted)
+		if err != nil {
+			return nil, fmt.Errorf("解密新密码失败: %w", err)
+		}
+		rotations = append(rotations, rotation)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("读取密码轮换失败: %w", err)
+	}
+	return rotations, nil
+}
+
+/**
+ * CommitRotation 提交密码轮换
+ * @param accountID 账号ID
+ * @return error 错误信息
+ * @description 先把旧密码写入历史密码，再把新密码写入账号，最后删除轮换状态。
+ *              更新账号失败时删除刚写入的历史记录，轮换状态保留，可以重新提交或回滚
+ */
+func (prs *PasswordRotationService) CommitRotation(accountID string) error {
+	rotation, err := prs.GetRotation(accountID)
+	if err != nil {
+		return err
+	}
+	if rotation == nil {
+		return fmt.Errorf("账号没有进行中的密码轮换")
+	}
+
+	account, err := prs.accountService.GetAccountByID(accountID)
+	if err != nil {
+		return fmt.Errorf("获取账号失败: %w", err)
+	}
+
+	db := prs.dbManager.GetDB()
+	historyID := ""
+	if account.Password != "" && account.Password != rotation.PendingPassword {
+		historyID, err = addPasswordHistory(db, prs.accountService.cryptoManager, accountID, account.Password, time.Now())
+		if err != nil {
+			return err
+		}
+	}
+
+	account.Password = rotation.PendingPassword
+	if err := prs.accountService.UpdateAccount(*account); err != nil {
+		if historyID != "" {
+			if _, delErr := db.Exec("DELETE FROM password_history WHERE id = ?", historyID); delErr != nil {
+				logger.Error("[密码轮换] 删除历史密码失败，账号ID: %s, 错误: %v", accountID, delErr)
+			}
+		}
+		return fmt.Errorf("更新账号密码失败: %w", err)
+	}
+
+	if _, err := db.Exec("DELETE FROM account_rotation WHERE account_id = ?", accountID); err != nil {
+		return fmt.Errorf("删除密码轮换失败: %w", err)
+	}
+	return nil
+}
+
+/**
+ * RollbackRotation 回滚密码轮换，丢弃待提交的新密码
+ * @param accountID 账号ID
+ * @return error 错误信息
+ */
+func (prs *PasswordRotationService) RollbackRotation(accountID string) error {
+	if !prs.dbManager.IsOpened() {
+		return fmt.Errorf("数据库未打开")
+	}
+
+	result, err := prs.dbManager.GetDB().Exec("DELETE FROM account_rotation WHERE account_id = ?", accountID)
+	if err != nil {
+		return fmt.Errorf("回滚密码轮换失败: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("账号没有进行中的密码轮换")
+	}
+	return nil
+}
+
+/**
+ * GetPasswordHistory 获取账号的历史密码
+ * @param accountID 账号ID
+ * @return []models.PasswordHistoryEntry 历史密码，最近替换的在前
+ * @return error 错误信息
+ */
+func (prs *PasswordRotationService) GetPasswordHistory(accountID string) ([]models.PasswordHistoryEntry, error) {
+	if err := prs.checkReady(); err != nil {
+		return nil, err
+	}
+
+	rows, err := prs.dbManager.GetDB().Query(`
+		SELECT id, account_id, password, created_at
+		FROM password_history
+		WHERE account_id = ?
+		ORDER BY created_at DESC
+	`, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("查询历史密码失败: %w", err)
+	}
+	defer rows.Close()
+
+	history := make([]models.PasswordHistoryEntry, 0)
+	for rows.Next() {
+		var entry models.PasswordHistoryEntry
+		var encrypted string
+		if err := rows.Scan(&entry.ID, &entry.AccountID, &encrypted, &entry.CreatedAt); err != nil {
+			return nil, fmt.Errorf("扫描历史密码失败: %w", err)
+		}
+		entry.Password, err = prs.accountService.cryptoManager.Decrypt(encrypted)
+		if err != nil {
+			return nil, fmt.Errorf("解密历史密码失败: %w", err)
+		}
+		history = append(history, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("读取历史密码失败: %w", err)
+	}
+	return history, nil
+}
+
+/**
+ * addPasswordHistory 加密保存一条历史密码
+ * @param db 数据库连接
+ * @param cryptoManager 加密管理器
+ * @param accountID 账号ID
+ * @param password 旧密码
+ * @param createdAt 旧密码被替换的时间
+ * @return string 历史记录ID
+ * @return error 错误信息
+ * @modify 20251021 陈凤庆 改为包级函数并支持指定时间，供KeePass导入历史记录使用
+ */
+func addPasswordHistory(db *sql.DB, cryptoManager *crypto.CryptoManager, accountID, password string, createdAt time.Time) (string, error) {
+	encrypted, err := cryptoManager.Encrypt(password)
+	if err != nil {
+		return "", fmt.Errorf("加密旧密码失败: %w", err)
+	}
+
+	id := utils.GenerateGUID()
+	_, err = db.Exec(`
+		INSERT INTO password_history (id, account_id, password, created_at)
+		VALUES (?, ?, ?, ?)
+	`, id, accountID, encrypted, createdAt)
+	if err != nil {
+		return "", fmt.Errorf("保存历史密码失败: %w", err)
+	}
+	return id, nil
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"testing"
+)
+
+/**
+ * 密码轮换测试
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 测试开始、提交、回滚密码轮换，以及轮换状态持久保存和删除账号时的清理
+ */
+
+func TestPasswordRotationService(t *testing.T) {
+	accountService, typeID := newTestAccountService(t)
+	prs := NewPasswordRotationService(accountService.dbManager, accountService)
+
+	account, err := accountService.CreateAccount("数据库", "dba", "Old#Pass2024", "", typeID, "", 1)
+	if err != nil {
+		t.Fatalf("创建账号失败: %v", err)
+	}
+
+	if _, err := prs.StartRotation(account.ID, "Old#Pass2024", ""); err == nil {
+		t.Error("新密码与当前密码相同时应返回错误")
+	}
+	if _, err := prs.StartRotation(account.ID, "New#Pass2025", "rule-1"); err != nil {
+		t.Fatalf("开始轮换失败: %v", err)
+	}
+	if _, err := prs.StartRotation(account.ID, "Another#Pass", ""); err == nil {
+		t.Error("已有进行中的轮换时应返回错误")
+	}
+
+	// 提交前账号仍使用旧密码，新建的服务实例能读到轮换状态
+	current, _ := accountService.GetAccountByID(account.ID)
+	if current.Password != "Old#Pass2024" {
+		t.Errorf("提交前不应修改账号密码: %s", current.Password)
+	}
+	pending, err := NewPasswordRotationService(accountService.dbManager, accountService).GetPendingRotations()
+	if err != nil {
+		t.Fatalf("获取进行中的轮换失败: %v", err)
+	}
+	if len(pending) != 1 || pending[0].PendingPassword != "New#Pass2025" || pending[0].RuleID != "rule-1" || pending[0].Title != "数据库" {
+		t.Fatalf("轮换状态错误: %+v", pending)
+	}
+
+	if err := prs.CommitRotation(account.ID); err != nil {
+		t.Fatalf("提交轮换失败: %v", err)
+	}
+	current, _ = accountService.GetAccountByID(account.ID)
+	if current.Password != "New#Pass2025" {
+		t.Errorf("提交后应使用新密码: %s", current.Password)
+	}
+	history, err := prs.GetPasswordHistory(account.ID)
+	if err != nil || len(history) != 1 || history[0].Password != "Old#Pass2024" {
+		t.Fatalf("旧密码应移入历史密码: %+v, %v", history, err)
+	}
+	if rotation, _ := prs.GetRotation(account.ID); rotation != nil {
+		t.Errorf("提交后应删除轮换状态: %+v", rotation)
+	}
+
+	// 回滚只丢弃新密码
+	if _, err := prs.StartRotation(account.ID, "Rollback#Pass", ""); err != nil {
+		t.Fatalf("开始轮换失败: %v", err)
+	}
+	if err := prs.RollbackRotation(account.ID); err != nil {
+		t.Fatalf("回滚轮换失败: %v", err)
+	}
+	if err := prs.RollbackRotation(account.ID); err == nil {
+		t.Error("没有进行中的轮换时回滚应返回错误")
+	}
+	if err := prs.CommitRotation(account.ID); err == nil {
+		t.Error("没有进行中的轮换时提交应返回错误")
+	}
+	current, _ = accountService.GetAccountByID(account.ID)
+	if current.Password != "New#Pass2025" {
+		t.Errorf("回滚后应保持原密码: %s", current.Password)
+	}
+
+	// 删除账号时清理轮换状态和历史密码
+	prs.StartRotation(account.ID, "Deleted#Pass", "")
+	if err := accountService.DeleteAccount(account.ID); err != nil {
+		t.Fatalf("删除账号失败: %v", err)
+	}
+	var count int
+	accountService.dbManager.GetDB().QueryRow("SELECT (SELECT COUNT(*) FROM account_rotation) + (SELECT COUNT(*) FROM password_history)").Scan(&count)
+	if count != 0 {
+		t.Errorf("删除账号后应清理轮换状态和历史密码，剩余%d条", count)
+	}
+}
@@ -0,0 +1,155 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+
+	"wepassword/internal/logger"
+)
+
+/**
+ * 密码规则绑定
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 为分组和类型绑定默认密码规则。账号使用的规则按“类型 → 所属分组 → 上级分组…”的顺序
+ *              查找最近的绑定，均未绑定时不自动生成密码
+ */
+
+// 查找分组规则时允许的最大层级，防止异常数据形成循环
+const maxRuleBindingDepth = 64
+
+/**
+ * SetGroupRule 设置分组的默认密码规则
+ * @param groupID 分组ID
+ * @param ruleID 规则ID，空字符串表示解除绑定
+ * @return error 错误信息
+ */
+func (prs *PasswordRuleService) SetGroupRule(groupID, ruleID string) error {
+	return prs.setBindingRule("groups", "分组", groupID, ruleID)
+}
+
+/**
+ * SetTypeRule 设置类型的默认密码规则
+ * @param typeID 类型ID
+ * @param ruleID 规则ID，空字符串表示解除绑定（使用所属分组的规则）
+ * @return error 错误信息
+ */
+func (prs *PasswordRuleService) SetTypeRule(typeID, ruleID string) error {
+	return prs.setBindingRule("types", "类型", typeID, ruleID)
+}
+
+/**
+ * setBindingRule 更新分组或类型绑定的规则ID
+ * @param table 表名（groups 或 types）
+ * @param label 日志和错误中使用的名称
+ * @param id 分组或类型ID
+ * @param ruleID 规则ID
+ * @return error 错误信息
+ */
+func (prs *PasswordRuleService) setBindingRule(table, label, id, ruleID string) error {
+	if id == "" {
+		return fmt.Errorf("%sID不能为空", label)
+	}
+
+	if ruleID != "" {
+		if _, err := prs.GetRuleByID(ruleID); err != nil {
+			return fmt.Errorf("密码规则不存在: %w", err)
+		}
+	}
+
+	db := prs.dbManager.GetDB()
+	result, err := db.Exec(fmt.Sprintf("UPDATE %s SET password_rule_id = ? WHERE id = ?", table), ruleID, id)
+	if err != nil {
+		return fmt.Errorf("设置%s默认密码规则失败: %w", label, err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("%s不存在: %s", label, id)
+	}
+
+	logger.Info("[密码规则服务] 设置%s默认密码规则成功: %s -> %s", label, id, ruleID)
+	return nil
+}
+
+/**
+ * ResolveRuleIDForType 查找类型最近绑定的密码规则
+ * @param typeID 类型ID
+ * @return string 规则ID，未找到绑定时为空字符串
+ * @return error 错误信息
+ */
+func (prs *PasswordRuleService) ResolveRuleIDForType(typeID string) (string, error) {
+	if typeID == "" {
+		return "", fmt.Errorf("类型ID不能为空")
+	}
+
+	db := prs.dbManager.GetDB()
+
+	var ruleID, groupID string
+	err := db.QueryRow(`
+		SELECT COALESCE(password_rule_id, ''), group_id FROM types WHERE id = ?
+	`, typeID).Scan(&ruleID, &groupID)
+	if err != nil {
+		return "", fmt.Errorf("查询类型失败: %w", err)
+	}
+	if ruleID != "" {
+		return ruleID, nil
+	}
+
+	// 沿分组层级向上查找
+	for depth := 0; groupID != "" && depth < maxRuleBindingDepth; depth++ {
+		var parentID string
+		err := db.QueryRow(`
+			SELECT COALESCE(password_rule_id, ''), COALESCE(parent_id, '') FROM groups WHERE id = ?
+		`, groupID).Scan(&ruleID, &parentID)
+		if err == sql.ErrNoRows {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("查询分组失败: %w", err)
+		}
+		if ruleID != "" {
+			return ruleID, nil
+		}
+		groupID = parentID
+	}
+
+	return "", nil
+}
+
+/**
+ * GeneratePasswordForType 使用类型最近绑定的规则生成密码
+ * @param typeID 类型ID
+ * @return string 生成的密码，未绑定规则时为空字符串
+ * @return string 使用的规则ID，未绑定规则时为空字符串
+ * @return error 错误信息
+ */
+func (prs *PasswordRuleService) GeneratePasswordForType(typeID string) (string, string, error) {
+	ruleID, err := prs.ResolveRuleIDForType(typeID)
+	if err != nil {
+		return "", "", err
+	}
+	if ruleID == "" {
+		return "", "", nil
+	}
+
+	password, err := prs.GeneratePassword(ruleID)
+	if err != nil {
+		return "", ruleID, err
+	}
+	return password, ruleID, nil
+}
+
+/**
+ * clearRuleBindings 解除所有分组和类型对指定规则的绑定
+ * @param q 数据库连接或事务
+ * @param ruleID 规则ID
+ * @return error 错误信息
+ */
+func clearRuleBindings(q ruleExecutor, ruleID string) error {
+	for _, table := range []string{"groups", "types"} {
+		_, err := q.Exec(fmt.Sprintf("UPDATE %s SET password_rule_id = '' WHERE password_rule_id = ?", table), ruleID)
+		if err != nil {
+			return fmt.Errorf("解除规则绑定失败: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"regexp"
+	"testing"
+
+	"wepassword/internal/models"
+)
+
+/**
+ * 密码规则绑定测试
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 测试分组和类型绑定默认密码规则及最近规则的查找
+ */
+
+func TestPasswordRuleBinding_Resolve(t *testing.T) {
+	accountService, _ := newTestAccountService(t)
+	dbManager := accountService.dbManager
+	gs := NewGroupService(dbManager)
+	ts := NewTypeService(dbManager)
+	prs := NewPasswordRuleService(dbManager)
+
+	hexRule, err := prs.CreateRule("数据库", "", "custom", models.CustomRuleConfig{Pattern: "h{32}"})
+	if err != nil {
+		t.Fatalf("创建规则失败: %v", err)
+	}
+	phraseRule, err := prs.CreateRule("网站", "", "passphrase", models.PassphraseRuleConfig{Wordlist: WordlistEFFLarge, WordCount: 5, Separator: "-"})
+	if err != nil {
+		t.Fatalf("创建规则失败: %v", err)
+	}
+
+	prod, err := gs.CreateGroup("生产")
+	if err != nil {
+		t.Fatalf("创建分组失败: %v", err)
+	}
+	dbGroup, err := gs.CreateChildGroup("数据库", prod.ID)
+	if err != nil {
+		t.Fatalf("创建子分组失败: %v", err)
+	}
+	mysql, err := ts.CreateType("MySQL", dbGroup.ID, "fa-database")
+	if err != nil {
+		t.Fatalf("创建类型失败: %v", err)
+	}
+	web, err := ts.CreateType("Web", dbGroup.ID, "fa-globe")
+	if err != nil {
+		t.Fatalf("创建类型失败: %v", err)
+	}
+
+	// 未绑定时不生成
+	password, ruleID, err := prs.GeneratePasswordForType(mysql.ID)
+	if err != nil || password != "" || ruleID != "" {
+		t.Fatalf("未绑定规则时不应生成密码: %q %q %v", password, ruleID, err)
+	}
+
+	// 上级分组的规则对子分组下的类型生效
+	if err := prs.SetGroupRule(prod.ID, hexRule.ID); err != nil {
+		t.Fatalf("绑定分组规则失败: %v", err)
+	}
+	password, ruleID, err = prs.GeneratePasswordForType(mysql.ID)
+	if err != nil {
+		t.Fatalf("生成密码失败: %v", err)
+	}
+	if ruleID != hexRule.ID || !regexp.MustCompile(`^[0-9a-f]{32}$`).MatchString(password) {
+		t.Errorf("应使用上级分组的规则生成32位十六进制密码，实际: %s (%s)", password, ruleID)
+	}
+
+	// 类型绑定优先于分组
+	if err := prs.SetTypeRule(web.ID, phraseRule.ID); err != nil {
+		t.Fatalf("绑定类型规则失败: %v", err)
+	}
+	if ruleID, _ := prs.ResolveRuleIDForType(web.ID); ruleID != phraseRule.ID {
+		t.Errorf("类型绑定应优先，实际规则: %s", ruleID)
+	}
+	if typeItem, _ := ts.GetTypeByID(web.ID); typeItem.PasswordRuleID != phraseRule.ID {
+		t.Errorf("类型应返回绑定的规则ID，实际: %s", typeItem.PasswordRuleID)
+	}
+
+	// 删除规则后解除绑定
+	if err := prs.DeleteRule(phraseRule.ID); err != nil {
+		t.Fatalf("删除规则失败: %v", err)
+	}
+	if ruleID, _ := prs.ResolveRuleIDForType(web.ID); ruleID != hexRule.ID {
+		t.Errorf("删除类型规则后应回退到分组规则，实际: %s", ruleID)
+	}
+
+	if err := prs.SetTypeRule(web.ID, "not-exist"); err == nil {
+		t.Error("绑定不存在的规则应失败")
+	}
+}
@@ -0,0 +1,1058 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+
+	"wepassword/internal/logger"
+	"wepassword/internal/models"
+)
+
+/**
+ * 密码规则熵值计算
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 按各规则类型的生成算法计算输出熵值（位）的上界和下界，最小位数约束、自定义字符集、[...]{n} 等都会计入，
+ *              保存规则时根据强度策略给出警告或拒绝保存。
+ *              20251021 陈凤庆 生成算法并不在所有可能的密码中均匀选择，不同的随机选择也可能生成相同的密码，
+ *              因此无法精确计算熵值：上界为可能的密码数量或随机选择的香农熵（不计重复），
+ *              下界为最小熵（-log2(最可能的密码的概率)），按生成过程中每一步的最大概率保守计算。
+ *              强度等级、警告和最小值检查均按下界进行，避免高估强度的规则通过检查
+ */
+
+// 熵值等级分界（位）
+const (
+	entropyFairBits       = 40
+	entropyStrongBits     = 60
+	entropyVeryStrongBits = 80
+)
+
+/**
+ * SetPolicyConfig 设置密码规则强度策略
+ * @param config 密码规则强度策略配置
+ */
+func (prs *PasswordRuleService) SetPolicyConfig(config models.PasswordPolicyConfig) {
+	prs.policy = config
+}
+
+/**
+ * GetPolicyConfig 获取密码规则强度策略
+ * @return models.PasswordPolicyConfig 密码规则强度策略配置
+ */
+func (prs *PasswordRuleService) GetPolicyConfig() models.PasswordPolicyConfig {
+	return prs.policy
+}
+
+/**
+ * CalculateRuleEntropy 计算已保存规则的熵值
+ * @param ruleID 规则ID
+ * @return models.PasswordRuleEntropy 熵值信息
+ * @return error 错误信息
+ */
+func (prs *PasswordRuleService) CalculateRuleEntropy(ruleID string) (models.PasswordRuleEntropy, error) {
+	rule, err := prs.GetRuleByID(ruleID)
+	if err != nil {
+		return models.PasswordRuleEntropy{}, fmt.Errorf("获取密码规则失败: %w", err)
+	}
+	return prs.calculateEntropyFromJSON(rule.RuleType, rule.Config)
+}
+
+/**
+ * CalculateEntropyByConfig 根据配置计算规则熵值
+ * @param ruleType 规则类型
+ * @param config 规则配置
+ * @return models.PasswordRuleEntropy 熵值信息
+ * @return error 错误信息
+ */
+func (prs *PasswordRuleService) CalculateEntropyByConfig(ruleType string, config interface{}) (models.PasswordRuleEntropy, error) {
+	configJSON, err := json.Marshal(config)
+	if err != nil {
+		return models.PasswordRuleEntropy{}, fmt.Errorf("序列化配置失败: %w", err)
+	}
+	return prs.calculateEntropyFromJSON(ruleType, string(configJSON))
+}
+
+/**
+ * calculateEntropyFromJSON 根据规则类型和配置JSON计算熵值并按策略评估
+ * @param ruleType 规则类型
+ * @param configJSON 配置JSON字符串
+ * @return models.PasswordRuleEntropy 熵值信息
+ * @return error 错误信息
+ */
+func (prs *PasswordRuleService) calculateEntropyFromJSON(ruleType, configJSON string) (models.PasswordRuleEntropy, error) {
+	lower, upper, err := prs.ruleEntropyBounds(ruleType, configJSON)
+	if err != nil {
+		return models.PasswordRuleEntropy{}, err
+	}
+
+	return prs.evaluateEntropy(lower, upper), nil
+}
+
+/**
+ * ruleEntropyBounds 根据规则类型和配置JSON计算未取整的熵值下界和上界
+ * @param ruleType 规则类型
+ * @param configJSON 配置JSON字符串
+ * @return float64 熵值下界（位）
+ * @return float64 熵值上界（位）
+ * @return error 错误信息
+ */
+func (prs *PasswordRuleService) ruleEntropyBounds(ruleType, configJSON string) (float64, float64, error) {
+	var lower, upper float64
+	var err error
+
+	switch ruleType {
+	case "general":
+		var config models.GeneralRuleConfig
+		if err = json.Unmarshal([]byte(configJSON), &config); err != nil {
+			return 0, 0, fmt.Errorf("解析通用规则配置失败: %w", err)
+		}
+		if upper, err = prs.generalRuleEntropy(config); err == nil {
+			lower, err = prs.generalRuleEntropyLowerBound(config)
+		}
+	case "custom":
+		var config models.CustomRuleConfig
+		if err = json.Unmarshal([]byte(configJSON), &config); err != nil {
+			return 0, 0, fmt.Errorf("解析自定义规则配置失败: %w", err)
+		}
+		if upper, err = prs.customPatternEntropy(config.Pattern); err == nil {
+			lower, err = prs.customPatternEntropyLowerBound(config.Pattern)
+		}
+	case "passphrase":
+		var config models.PassphraseRuleConfig
+		if err = json.Unmarshal([]byte(configJSON), &config); err != nil {
+			return 0, 0, fmt.Errorf("解析口令短语规则配置失败: %w", err)
+		}
+		if upper, err = prs.passphraseEntropy(config); err == nil {
+			lower, err = prs.passphraseEntropyLowerBound(config)
+		}
+	case "pronounceable":
+		var config models.PronounceableRuleConfig
+		if err = json.Unmarshal([]byte(configJSON), &config); err != nil {
+			return 0, 0, fmt.Errorf("解析可发音密码规则配置失败: %w", err)
+		}
+		if upper, err = prs.pronounceableEntropy(config); err == nil {
+			lower, err = prs.pronounceableEntropyLowerBound(config)
+		}
+	default:
+		return 0, 0, fmt.Errorf("不支持的规则类型: %s", ruleType)
+	}
+	return lower, math.Max(lower, upper), err
+}
+
+/**
+ * evaluateEntropy 根据强度策略评估熵值
+ * @param lower 熵值下界（位）
+ * @param upper 熵值上界（位）
+ * @return models.PasswordRuleEntropy 熵值信息，强度等级和警告按下界计算
+ */
+func (prs *PasswordRuleService) evaluateEntropy(lower, upper float64) models.PasswordRuleEntropy {
+	// 保留两位小数，避免浮点误差影响展示
+	lower = math.Round(lower*100) / 100
+	upper = math.Round(upper*100) / 100
+
+	result := models.PasswordRuleEntropy{EntropyLowerBits: lower, EntropyUpperBits: upper}
+	bits := lower
+	switch {
+	case bits < entropyFairBits:
+		result.Level = "weak"
+	case bits < entropyStrongBits:
+		result.Level = "fair"
+	case bits < entropyVeryStrongBits:
+		result.Level = "strong"
+	default:
+		result.Level = "very_strong"
+	}
+
+	if prs.policy.MinEntropyBits > 0 && bits < prs.policy.MinEntropyBits {
+		result.BelowMinimum = true
+		result.Warning = fmt.Sprintf("规则熵值下界为%.1f位（上界%.1f位），低于要求的最小值%.0f位", lower, upper, prs.policy.MinEntropyBits)
+	} else if prs.policy.WarnEntropyBits > 0 && bits < prs.policy.WarnEntropyBits {
+		result.Warning = fmt.Sprintf("规则熵值下界为%.1f位（上界%.1f位），低于建议的%.0f位，生成的密码可能较弱", lower, upper, prs.policy.WarnEntropyBits)
+	}
+	return result
+}
+
+/**
+ * checkRuleEntropy 保存规则前检查熵值
+ * @param ruleType 规则类型
+ * @param configJSON 配置JSON字符串
+ * @return error 低于最小熵值时返回错误
+ */
+func (prs *PasswordRuleService) checkRuleEntropy(ruleType, configJSON string) error {
+	entropy, err := prs.calculateEntropyFromJSON(ruleType, configJSON)
+	if err != nil {
+		// 20251021 陈凤庆 熵值无法计算说明配置本身无效，不能绕过最小熵值检查保存
+		logger.Error("[密码规则服务] 计算规则熵值失败: %v", err)
+		return fmt.Errorf("计算规则熵值失败: %w", err)
+	}
+	if entropy.BelowMinimum {
+		return fmt.Errorf("规则强度不足: %s", entropy.Warning)
+	}
+	if entropy.Warning != "" {
+		logger.Info("[密码规则服务] ⚠️ %s", entropy.Warning)
+	}
+	return nil
+}
+
+/**
+ * generalRuleEntropy 计算通用规则的熵值上界
+ * @param config 通用规则配置
+ * @return float64 熵值上界（位）
+ * @return error 错误信息
+ * @description 统计长度为Length、各类字符不少于最小位数的全部密码个数N，上界为log2(N)。
+ *              自定义字符中与前面类别重复的字符按前面的类别计算，与校验逻辑一致。
+ *              排除字符和易混淆字符不计入各类别；生成时先取最小位数再从合并字符集中填充，
+ *              并不在N个密码中均匀选择，连续重复和顺序字符限制也会减少可能的密码，实际熵值低于该值
+ */
+func (prs *PasswordRuleService) generalRuleEntropy(config models.GeneralRuleConfig) (float64, error) {
+	if err := prs.validateGeneralConfig(config); err != nil {
+		return 0, err
+	}
+
+	type charClass struct {
+		size int
+		min  int
+	}
+	var classes []charClass
+	seen := make(map[rune]bool)
+	var emptyErr error
+	addClass := func(include bool, label, charset string, min int) {
+		if !include {
+			return
+		}
+		size := 0
+		for _, r := range prs.filterExcludedChars(charset, config) {
+			if !seen[r] {
+				seen[r] = true
+				size++
+			}
+		}
+		if size == 0 {
+			if min > 0 && emptyErr == nil {
+				emptyErr = fmt.Errorf("%s在排除字符后为空，无法满足最小位数%d", label, min)
+			}
+			return
+		}
+		classes = append(classes, charClass{size: size, min: min})
+	}
+	addClass(config.IncludeUppercase, "大写字母", UppercaseLetters, config.MinUppercase)
+	addClass(config.IncludeLowercase, "小写字母", LowercaseLetters, config.MinLowercase)
+	addClass(config.IncludeNumbers, "数字", Numbers, config.MinNumbers)
+	addClass(config.IncludeSpecialChars, "特殊字符", SpecialChars, config.MinSpecialChars)
+	addClass(config.IncludeCustomChars && config.CustomSpecialChars != "", "自定义字符", config.CustomSpecialChars, config.MinCustomChars)
+	if emptyErr != nil {
+		return 0, emptyErr
+	}
+
+	if len(classes) == 0 {
+		return 0, fmt.Errorf("至少需要选择一种字符类型")
+	}
+
+	// ways[t] 表示已处理的类别恰好占用t个位置的方案数（含位置选择）
+	length := config.Length
+	ways := make([]*big.Int, length+1)
+	for i := range ways {
+		ways[i] = new(big.Int)
+	}
+	ways[0].SetInt64(1)
+	for _, c := range classes {
+		next := make([]*big.Int, length+1)
+		for i := range next {
+			next[i] = new(big.Int)
+		}
+		size := big.NewInt(int64(c.size))
+		for t := 0; t <= length; t++ {
+			if ways[t].Sign() == 0 {
+				continue
+			}
+			power := new(big.Int).Exp(size, big.NewInt(int64(c.min)), nil)
+			for n := c.min; t+n <= length; n++ {
+				term := new(big.Int).Binomial(int64(t+n), int64(n))
+				term.Mul(term, power)
+				term.Mul(term, ways[t])
+				next[t+n].Add(next[t+n], term)
+				power.Mul(power, size)
+			}
+		}
+		ways = next
+	}
+
+	return log2BigInt(ways[length]), nil
+}
+
+/**
+ * entropyDraw 生成通用规则密码时的一组随机取字符
+ */
+type entropyDraw struct {
+	probs   map[rune]float64 // 各字符被取到的概率
+	maxProb float64          // 最大概率
+	count   int              // 取字符的次数
+}
+
+/**
+ * newEntropyDraw 按 getRandomChar 在字符集中均匀取字符（重复字符概率更高）计算概率分布
+ * @param charset 字符集
+ * @param count 取字符的次数
+ * @return entropyDraw 取字符分布
+ */
+func newEntropyDraw(charset string, count int) entropyDraw {
+	draw := entropyDraw{probs: make(map[rune]float64), count: count}
+	chars := []rune(charset)
+	for _, r := range chars {
+		draw.probs[r] += 1 / float64(len(chars))
+	}
+	for _, p := range draw.probs {
+		draw.maxProb = math.Max(draw.maxProb, p)
+	}
+	return draw
+}
+
+/**
+ * generalRuleEntropyLowerBound 计算通用规则的熵值下界
+ * @param config 通用规则配置
+ * @return float64 熵值下界（位）
+ * @return error 错误信息
+ * @description 对应 generateShuffledPassword：先从各类别取最小位数个字符，再从合并字符集中取其余字符，最后打乱。
+ *              任一密码的概率不超过各次取字符的最大概率之积（打乱只会把概率分散到更多密码上），
+ *              因此最小熵不低于 Σ次数×(-log2(最大概率))。
+ *              连续重复和顺序字符限制通过整体重新生成实现，满足限制的密码概率会除以通过概率P，
+ *              下界再减去 -log2(P)；P 不低于 1 - 各位置违反限制的概率之和（见 generalConstraintViolationBound）
+ */
+func (prs *PasswordRuleService) generalRuleEntropyLowerBound(config models.GeneralRuleConfig) (float64, error) {
+	if err := prs.validateGeneralConfig(config); err != nil {
+		return 0, err
+	}
+	charSets, minCounts, err := prs.buildGeneralCharSets(config)
+	if err != nil {
+		return 0, err
+	}
+
+	draws := make([]entropyDraw, 0, len(charSets)+1)
+	allChars := ""
+	fixed := 0
+	for i, charset := range charSets {
+		allChars += charset
+		if minCounts[i] > 0 {
+			draws = append(draws, newEntropyDraw(charset, minCounts[i]))
+			fixed += minCounts[i]
+		}
+	}
+	if config.Length > fixed {
+		draws = append(draws, newEntropyDraw(allChars, config.Length-fixed))
+	}
+
+	bits := 0.0
+	for _, draw := range draws {
+		bits -= float64(draw.count) * math.Log2(draw.maxProb)
+	}
+
+	violation := generalConstraintViolationBound(draws, config.Length, passwordCharConstraints{
+		maxConsecutive:  config.MaxConsecutive,
+		forbidSequences: config.ForbidSequences,
+	})
+	if violation >= 1 {
+		// 无法估计通过概率时不给出任何保证
+		return 0, nil
+	}
+	return math.Max(0, bits+math.Log2(1-violation)), nil
+}
+
+/**
+ * generalConstraintViolationBound 估计打乱后的密码违反连续重复或顺序字符限制的概率上界
+ * @param draws 各组取字符分布
+ * @param length 密码长度
+ * @param constraints 字符约束
+ * @return float64 违反限制的概率上界（各窗口概率之和，可能大于1）
+ * @description 打乱后任意k个相邻位置上是随机选出的k次不同的取字符，窗口违反限制的概率按所有有序组合平均：
+ *              连续重复为 (长度-m)×平均(前两个字符相同的概率)×最大概率^(m-1)；
+ *              顺序字符为 (长度-2)×平均(三个字符构成同一方向顺序的概率)×最大概率^(顺序长度-3)
+ */
+func generalConstraintViolationBound(draws []entropyDraw, length int, constraints passwordCharConstraints) float64 {
+	maxProb := 0.0
+	for _, draw := range draws {
+		maxProb = math.Max(maxProb, draw.maxProb)
+	}
+	// weight 按取字符组合计算有序不重复组合的比例
+	weight := func(indexes ...int) float64 {
+		w := 1.0
+		for i, index := range indexes {
+			available := draws[index].count
+			for _, prev := range indexes[:i] {
+				if prev == index {
+					available--
+				}
+			}
+			if available <= 0 {
+				return 0
+			}
+			w *= float64(available) / float64(length-i)
+		}
+		return w
+	}
+
+	total := 0.0
+	if m := constraints.maxConsecutive; m > 0 && length > m {
+		collision := 0.0
+		for i := range draws {
+			for j := range draws {
+				w := weight(i, j)
+				if w == 0 {
+					continue
+				}
+				same := 0.0
+				for r, p := range draws[i].probs {
+					same += p * draws[j].probs[r]
+				}
+				collision += w * same
+			}
+		}
+		total += float64(length-m) * collision * math.Pow(maxProb, float64(m-1))
+	}
+
+	if constraints.forbidSequences && length >= forbiddenSequenceLength {
+		sequence := 0.0
+		for i := range draws {
+			for j := range draws {
+				for k := range draws {
+					w := weight(i, j, k)
+					if w == 0 {
+						continue
+					}
+					sequence += w * sequenceProbability(draws[i], draws[j], draws[k])
+				}
+			}
+		}
+		total += float64(length-forbiddenSequenceLength+1) * sequence * math.Pow(maxProb, float64(forbiddenSequenceLength-3))
+	}
+	return total
+}
+
+/**
+ * sequenceProbability 计算依次从三组分布中取的字符构成同一方向顺序（见 sequenceStep）的概率
+ */
+func sequenceProbability(first, second, third entropyDraw) float64 {
+	total := 0.0
+	for x, px := range first.probs {
+		for y, py := range second.probs {
+			step, ok := sequenceStep(x, y)
+			if !ok {
+				continue
+			}
+			for z, pz := range third.probs {
+				if next, ok := sequenceStep(y, z); ok && next == step {
+					total += px * py * pz
+				}
+			}
+		}
+	}
+	return total
+}
+
+/**
+ * log2BigInt 计算大整数的以2为底的对数
+ */
+func log2BigInt(x *big.Int) float64 {
+	if x.Sign() <= 0 {
+		return 0
+	}
+	mant := new(big.Float)
+	exp := new(big.Float).SetInt(x).MantExp(mant)
+	m, _ := mant.Float64()
+	return float64(exp) + math.Log2(m)
+}
+
+/**
+ * charsetEntropy 计算从字符集中均匀随机取一个字符的熵值
+ * @param charset 字符集（允许重复字符，重复字符被选中的概率更高）
+ * @return float64 熵值（位）
+ */
+func charsetEntropy(charset string) float64 {
+	counts := make(map[rune]int)
+	total := 0
+	for _, r := range charset {
+		counts[r]++
+		total++
+	}
+	if total == 0 {
+		return 0
+	}
+	entropy := 0.0
+	for _, c := range counts {
+		p := float64(c) / float64(total)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+/**
+ * customPatternEntropy 计算自定义规则模式的熵值上界
+ * @param pattern 自定义规则模式
+ * @return float64 熵值上界（位）
+ * @return error 错误信息
+ * @description 与 parseCustomPattern 使用同一语法树逐节点累加熵值；字面字符和转义字符不产生熵。
+ *              按生成时随机选择的香农熵计算，不同选择可能生成相同的密码，实际熵值不高于该值，见 patternNodeEntropy
+ * @modify 20251021 陈凤庆 改为基于语法树计算，支持{m,n}、分组选择、可选和打乱
+ */
+func (prs *PasswordRuleService) customPatternEntropy(pattern string) (float64, error) {
+	if pattern == "" {
+		return 0, fmt.Errorf("自定义规则模式不能为空")
+	}
+
+	nodes, err := parsePatternTree(pattern)
+	if err != nil {
+		return 0, err
+	}
+
+	return prs.patternNodesEntropy(nodes)
+}
+
+/**
+ * patternNodesEntropy 计算节点序列的熵值
+ * @param nodes 语法树节点
+ * @return float64 熵值（位）
+ * @return error 错误信息
+ */
+func (prs *PasswordRuleService) patternNodesEntropy(nodes []patternNode) (float64, error) {
+	total := 0.0
+	for _, node := range nodes {
+		bits, err := prs.patternNodeEntropy(node)
+		if err != nil {
+			return 0, err
+		}
+		total += bits
+	}
+	return total, nil
+}
+
+/**
+ * patternNodeEntropy 计算单个节点（含重复次数）的熵值
+ * @param node 语法树节点
+ * @return float64 熵值（位）
+ * @return error 错误信息
+ * @description 按生成时的随机选择计算香农熵：次数范围{m,n}的各次数等概率，熵值为 log2(n-m+1) 加上各次数熵值的平均值；
+ *              分组选择同理为 log2(分支数) 加上各分支熵值的平均值。
+ *              这是上界而非 log2(可能的密码数量)：各分支可生成的数量不同时（如 (a|[a-z]{20})），
+ *              log2(各分支数量之和) 会被较大的分支主导而高估强度；不同分支生成相同密码时实际熵值更低
+ */
+func (prs *PasswordRuleService) patternNodeEntropy(node patternNode) (float64, error) {
+	if node.max > node.min && (node.kind == patternNodeGroup || node.kind == patternNodePermute) {
+		// 分组的熵值与次数成正比，只计算一次单次的熵值，避免嵌套重复时逐个次数重复计算子节点
+		unit, err := prs.patternNodeCountEntropy(node, 1)
+		if err != nil {
+			return 0, err
+		}
+		return math.Log2(float64(node.max-node.min+1)) + unit*float64(node.min+node.max)/2, nil
+	}
+	if node.max > node.min {
+		sum := 0.0
+		for count := node.min; count <= node.max; count++ {
+			bits, err := prs.patternNodeCountEntropy(node, count)
+			if err != nil {
+				return 0, err
+			}
+			sum += bits
+		}
+		return math.Log2(float64(node.max-node.min+1)) + sum/float64(node.max-node.min+1), nil
+	}
+	return prs.patternNodeCountEntropy(node, node.min)
+}
+
+/**
+ * patternNodeCountEntropy 计算节点重复指定次数时的熵值
+ * @param node 语法树节点
+ * @param count 重复次数
+ * @return float64 熵值（位）
+ * @return error 错误信息
+ */
+func (prs *PasswordRuleService) patternNodeCountEntropy(node patternNode, count int) (float64, error) {
+	if count == 0 {
+		return 0, nil
+	}
+
+	switch node.kind {
+	case patternNodeIdentifier:
+		if node.ident == 'A' && node.quantified {
+			return mixedAlphanumericEntropy(count), nil
+		}
+		charset, err := prs.getCharsetForIdentifier(byte(node.ident))
+		if err != nil {
+			return 0, err
+		}
+		return float64(count) * charsetEntropy(charset), nil
+
+	case patternNodeCharset:
+		if node.quantified {
+			return prs.mixedCharsEntropy(string(node.charset), count), nil
+		}
+		return float64(count) * charsetEntropy(string(node.charset)), nil
+
+	case patternNodeGroup:
+		sum := 0.0
+		for _, alternative := range node.alternatives {
+			bits, err := prs.patternNodesEntropy(alternative)
+			if err != nil {
+				return 0, err
+			}
+			sum += bits
+		}
+		perGroup := sum / float64(len(node.alternatives))
+		if len(node.alternatives) > 1 {
+			perGroup += math.Log2(float64(len(node.alternatives)))
+		}
+		return float64(count) * perGroup, nil
+
+	case patternNodePermute:
+		bits, err := prs.patternNodesEntropy(node.children)
+		if err != nil {
+			return 0, err
+		}
+		return float64(count) * (bits + permutationEntropy(node.children)), nil
+	}
+
+	return 0, nil
+}
+
+/**
+ * permutationEntropy 计算打乱分组额外带来的熵值上界
+ * @param children 打乱分组的内容
+ * @return float64 熵值（位）
+ * @description 打乱是对全部字符的随机排列，带来的熵值不超过 log2(n!)，n 为最多生成的字符数
+ */
+func permutationEntropy(children []patternNode) float64 {
+	lg, _ := math.Lgamma(float64(patternNodesMaxLength(children) + 1))
+	return lg / math.Ln2
+}
+
+/**
+ * mixedAlphanumericEntropy 计算 A{n} 的熵值
+ * @param count 字符数量
+ * @return float64 熵值（位）
+ * @description 对应 generateMixedAlphanumericChars：n>=3时前三位依次为大写、小写、数字，
+ *              其余每位按数字40%、大写30%、小写30%选择；n=2时三种组合等概率；n=1时三种类型等概率
+ */
+func mixedAlphanumericEntropy(count int) float64 {
+	upper := math.Log2(26)
+	lower := math.Log2(26)
+	digit := math.Log2(10)
+
+	switch {
+	case count >= 3:
+		typeChoice := -(0.4*math.Log2(0.4) + 2*0.3*math.Log2(0.3))
+		perChar := typeChoice + 0.4*digit + 0.3*upper + 0.3*lower
+		return upper + lower + digit + float64(count-3)*perChar
+	case count == 2:
+		return math.Log2(3) + ((upper+lower)+(upper+digit)+(lower+digit))/3
+	default:
+		return math.Log2(3) + (upper+lower+digit)/3
+	}
+}
+
+/**
+ * mixedCharsEntropy 计算 [...]{n} 的熵值
+ * @param charset 自定义字符集
+ * @param count 字符数量
+ * @return float64 熵值（位）
+ * @description 对应 generateMixedCharsWithConstraints：字符集按大写、小写、数字、特殊字符分类，
+ *              先等概率选择类型再在类型内选择字符，各类型的排列顺序按等概率计算；为估算值，
+ *              类型大小不同时与在整个字符集中均匀选择的熵值不同
+ */
+func (prs *PasswordRuleService) mixedCharsEntropy(charset string, count int) float64 {
+	types := prs.analyzeCharsetTypes(charset)
+	typeCount := len(types)
+	if typeCount <= 1 {
+		return float64(count) * charsetEntropy(charset)
+	}
+
+	// 各类型内部的平均熵值
+	avg := 0.0
+	for _, set := range types {
+		avg += charsetEntropy(set)
+	}
+	avg /= float64(typeCount)
+	perChar := math.Log2(float64(typeCount)) + avg
+
+	if typeCount == 2 {
+		if count >= 2 {
+			// 前两位为两种类型各一个（顺序随机），其余每位等概率选择类型
+			return 1 + 2*avg + float64(count-2)*perChar
+		}
+		return perChar
+	}
+
+	switch count {
+	case 2:
+		// 两位类型不同
+		return math.Log2(float64(typeCount*(typeCount-1))) + 2*avg
+	case 3:
+		// 三位为三种不同类型各一个
+		return math.Log2(float64(typeCount*(typeCount-1)*(typeCount-2))) + 3*avg
+	default:
+		return float64(count) * perChar
+	}
+}
+
+/**
+ * maxCharProbability 计算在字符集中均匀取一个字符时最可能的字符的概率
+ * @param charset 字符集（允许重复字符）
+ * @return float64 最大概率，字符集为空时为1
+ */
+func maxCharProbability(charset string) float64 {
+	draw := newEntropyDraw(charset, 1)
+	if draw.maxProb == 0 {
+		return 1
+	}
+	return draw.maxProb
+}
+
+/**
+ * customPatternEntropyLowerBound 计算自定义规则模式的熵值下界
+ * @param pattern 自定义规则模式
+ * @return float64 熵值下界（位）
+ * @return error 错误信息
+ * @description 确定了次数、分支和打乱顺序后各节点生成的字符位置固定，密码的概率不超过每个字符最大概率之积；
+ *              对所有次数、分支取最小值，次数、分支和打乱本身的随机性不计入
+ */
+func (prs *PasswordRuleService) customPatternEntropyLowerBound(pattern string) (float64, error) {
+	if pattern == "" {
+		return 0, fmt.Errorf("自定义规则模式不能为空")
+	}
+
+	nodes, err := parsePatternTree(pattern)
+	if err != nil {
+		return 0, err
+	}
+
+	return prs.patternNodesLowerBound(nodes)
+}
+
+/**
+ * patternNodesLowerBound 计算节点序列的熵值下界
+ * @param nodes 语法树节点
+ * @return float64 熵值下界（位）
+ * @return error 错误信息
+ */
+func (prs *PasswordRuleService) patternNodesLowerBound(nodes []patternNode) (float64, error) {
+	total := 0.0
+	for _, node := range nodes {
+		bits, err := prs.patternNodeLowerBound(node)
+		if err != nil {
+			return 0, err
+		}
+		total += bits
+	}
+	return total, nil
+}
+
+/**
+ * patternNodeLowerBound 计算单个节点（含重复次数）的熵值下界，取各次数中的最小值
+ * @param node 语法树节点
+ * @return float64 熵值下界（位）
+ * @return error 错误信息
+ */
+func (prs *PasswordRuleService) patternNodeLowerBound(node patternNode) (float64, error) {
+	if node.kind == patternNodeGroup || node.kind == patternNodePermute {
+		// 分组的下界与次数成正比，最少次数时最小
+		unit, err := prs.patternNodeCountLowerBound(node, 1)
+		if err != nil {
+			return 0, err
+		}
+		return unit * float64(node.min), nil
+	}
+
+	lowest := math.Inf(1)
+	for count := node.min; count <= node.max; count++ {
+		bits, err := prs.patternNodeCountLowerBound(node, count)
+		if err != nil {
+			return 0, err
+		}
+		lowest = math.Min(lowest, bits)
+	}
+	return lowest, nil
+}
+
+/**
+ * patternNodeCountLowerBound 计算节点重复指定次数时的熵值下界
+ * @param node 语法树节点
+ * @param count 重复次数
+ * @return float64 熵值下界（位）
+ * @return error 错误信息
+ */
+func (prs *PasswordRuleService) patternNodeCountLowerBound(node patternNode, count int) (float64, error) {
+	if count == 0 {
+		return 0, nil
+	}
+
+	switch node.kind {
+	case patternNodeIdentifier:
+		if node.ident == 'A' && node.quantified {
+			return mixedAlphanumericLowerBound(count), nil
+		}
+		charset, err := prs.getCharsetForIdentifier(byte(node.ident))
+		if err != nil {
+			return 0, err
+		}
+		return -float64(count) * math.Log2(maxCharProbability(charset)), nil
+
+	case patternNodeCharset:
+		if node.quantified {
+			return prs.mixedCharsLowerBound(string(node.charset), count), nil
+		}
+		return -float64(count) * math.Log2(maxCharProbability(string(node.charset))), nil
+
+	case patternNodeGroup:
+		lowest := math.Inf(1)
+		for _, alternative := range node.alternatives {
+			bits, err := prs.patternNodesLowerBound(alternative)
+			if err != nil {
+				return 0, err
+			}
+			lowest = math.Min(lowest, bits)
+		}
+		return float64(count) * lowest, nil
+
+	case patternNodePermute:
+		bits, err := prs.patternNodesLowerBound(node.children)
+		if err != nil {
+			return 0, err
+		}
+		return float64(count) * bits, nil
+	}
+
+	return 0, nil
+}
+
+/**
+ * mixedAlphanumericLowerBound 计算 A{n} 的熵值下界
+ * @param count 字符数量
+ * @return float64 熵值下界（位）
+ * @description 对应 generateMixedAlphanumericChars：n>=3时前三位依次为大写、小写、数字，
+ *              其余每位最可能的字符为某个数字（40%÷10）；n=2时三种组合各1/3，最可能的为含数字的组合；n=1时为1/3÷10
+ */
+func mixedAlphanumericLowerBound(count int) float64 {
+	switch {
+	case count >= 3:
+		return 2*math.Log2(26) + math.Log2(10) - float64(count-3)*math.Log2(0.4/10)
+	case count == 2:
+		return math.Log2(3 * 26 * 10)
+	default:
+		return math.Log2(3 * 10)
+	}
+}
+
+/**
+ * mixedCharsLowerBound 计算 [...]{n} 的熵值下界
+ * @param charset 自定义字符集
+ * @param count 字符数量
+ * @return float64 熵值下界（位）
+ * @description 对应 generateMixedCharsWithConstraints：类型固定的位置按各类型中最大的字符概率计算，
+ *              随机选择类型的位置再除以类型数
+ */
+func (prs *PasswordRuleService) mixedCharsLowerBound(charset string, count int) float64 {
+	types := prs.analyzeCharsetTypes(charset)
+	typeCount := len(types)
+	if typeCount <= 1 {
+		return -float64(count) * math.Log2(maxCharProbability(charset))
+	}
+
+	maxProb := 0.0
+	for _, set := range types {
+		maxProb = math.Max(maxProb, maxCharProbability(set))
+	}
+
+	forced := 0
+	switch {
+	case typeCount == 2 && count >= 2:
+		forced = 2
+	case typeCount >= 3 && (count == 2 || count == 3):
+		forced = count
+	}
+	return -float64(forced)*math.Log2(maxProb) - float64(count-forced)*math.Log2(maxProb/float64(typeCount))
+}
+
+/**
+ * passphraseEntropy 计算口令短语规则的熵值上界
+ * @param config 口令短语规则配置
+ * @return float64 熵值上界（位）
+ * @return error 错误信息
+ * @description 单词数×log2(词表大小)，随机首字母大写每词加1位，插入的数字和符号计入字符熵值及插入位置。
+ *              插入位置按可区分的结果计数（见 passphraseExtrasPositionBits）；没有分隔符时不同的单词组合可能拼出相同的密码，
+ *              实际熵值不高于该值
+ */
+func (prs *PasswordRuleService) passphraseEntropy(config models.PassphraseRuleConfig) (float64, error) {
+	if err := prs.validatePassphraseConfig(config); err != nil {
+		return 0, err
+	}
+
+	words, err := loadWordlist(config.Wordlist)
+	if err != nil {
+		return 0, err
+	}
+
+	total := float64(config.WordCount) * math.Log2(float64(len(words)))
+	if config.Capitalization == "random" {
+		total += float64(config.WordCount)
+	}
+
+	symbols := config.Symbols
+	if symbols == "" {
+		symbols = DefaultPassphraseSymbols
+	}
+	total += float64(config.DigitCount)*math.Log2(10) + float64(config.SymbolCount)*charsetEntropy(symbols)
+	total += passphraseExtrasPositionBits(config.WordCount, config.DigitCount, config.SymbolCount, config.Separator != "")
+
+	return total, nil
+}
+
+/**
+ * passphraseEntropyLowerBound 计算口令短语规则的熵值下界
+ * @param config 口令短语规则配置
+ * @return float64 熵值下界（位）
+ * @return error 错误信息
+ * @description 确定了数字和符号的插入位置后，只有单词的边界可能有歧义：
+ *              分隔符中的字符不出现在单词、数字和符号中时边界唯一，否则每个单词最多有C种可能的长度
+ *              （C为词表中互为前缀的单词链的最大长度），概率最多放大C^(单词数-1)倍。
+ *              随机首字母大写和插入位置的随机性不计入
+ */
+func (prs *PasswordRuleService) passphraseEntropyLowerBound(config models.PassphraseRuleConfig) (float64, error) {
+	if err := prs.validatePassphraseConfig(config); err != nil {
+		return 0, err
+	}
+
+	words, err := loadWordlist(config.Wordlist)
+	if err != nil {
+		return 0, err
+	}
+
+	symbols := config.Symbols
+	if symbols == "" {
+		symbols = DefaultPassphraseSymbols
+	}
+
+	counts := make(map[string]int, len(words))
+	maxCount := 0
+	ambiguous := config.Separator == "" || strings.ContainsAny(config.Separator, Digits+symbols)
+	for _, word := range words {
+		counts[word]++
+		maxCount = max(maxCount, counts[word])
+		if strings.ContainsAny(strings.ToLower(word)+strings.ToUpper(word), config.Separator) {
+			ambiguous = true
+		}
+	}
+
+	total := -float64(config.WordCount) * math.Log2(float64(maxCount)/float64(len(words)))
+	if ambiguous {
+		total -= float64(config.WordCount-1) * math.Log2(float64(wordPrefixChainLength(words)))
+	}
+	total += float64(config.DigitCount)*math.Log2(10) - float64(config.SymbolCount)*math.Log2(maxCharProbability(symbols))
+	return math.Max(0, total), nil
+}
+
+/**
+ * wordPrefixChainLength 计算词表中互为前缀的单词链的最大长度（不区分大小写）
+ * @param words 词表
+ * @return int 最大长度，至少为1
+ */
+func wordPrefixChainLength(words []string) int {
+	set := make(map[string]bool, len(words))
+	for _, word := range words {
+		set[strings.ToLower(word)] = true
+	}
+	longest := 1
+	for word := range set {
+		chain := 0
+		for i := 1; i <= len(word); i++ {
+			if set[word[:i]] {
+				chain++
+			}
+		}
+		longest = max(longest, chain)
+	}
+	return longest
+}
+
+/**
+ * passphraseExtrasPositionBits 计算口令短语中数字和符号插入位置的熵值上界
+ * @param wordCount 单词数
+ * @param digitCount 数字个数
+ * @param symbolCount 符号个数
+ * @param separated 单词之间是否有分隔符
+ * @return float64 熵值（位）
+ * @description 每个插入字符追加到某个单词的开头或结尾。有分隔符时共有2×单词数个可区分的间隙，
+ *              没有分隔符时前一个单词的结尾和后一个单词的开头相邻，只有单词数+1个间隙。
+ *              最终结果只取决于各间隙中的字符个数以及数字和符号的先后顺序，
+ *              因此取 log2(间隙分配方案数×数字符号排列数)，不超过逐个字符计算的 插入数×log2(2×单词数)
+ * @author 20251021 陈凤庆 修正插入位置的熵值被重复计算的问题
+ */
+func passphraseExtrasPositionBits(wordCount, digitCount, symbolCount int, separated bool) float64 {
+	extras := digitCount + symbolCount
+	if extras == 0 {
+		return 0
+	}
+
+	gaps := wordCount + 1
+	if separated {
+		gaps = wordCount * 2
+	}
+	// 把extras个字符分配到gaps个间隙中的方案数 C(extras+gaps-1, gaps-1)，再乘以数字和符号的排列数 C(extras, digitCount)
+	positions := new(big.Int).Binomial(int64(extras+gaps-1), int64(gaps-1))
+	positions.Mul(positions, new(big.Int).Binomial(int64(extras), int64(digitCount)))
+	return math.Min(log2BigInt(positions), float64(extras)*math.Log2(float64(wordCount*2)))
+}
+
+/**
+ * pronounceableEntropy 计算可发音密码规则的熵值上界
+ * @param config 可发音密码规则配置
+ * @return float64 熵值上界（位）
+ * @return error 错误信息
+ * @description 音节拆分唯一，可发音部分的熵值为log2(该长度下所有可能的音节组合数)，随机大小写时每个音节按两种写法计数；
+ *              插入的数字和符号计入字符熵值及所在位置的组合数
+ */
+func (prs *PasswordRuleService) pronounceableEntropy(config models.PronounceableRuleConfig) (float64, error) {
+	if err := prs.validatePronounceableConfig(config); err != nil {
+		return 0, err
+	}
+
+	letters := config.Length - config.DigitCount - config.SymbolCount
+	total := log2BigInt(newPronounceableModel(letters, config.Capitalization == "random").total)
+
+	symbols := config.Symbols
+	if symbols == "" {
+		symbols = DefaultPassphraseSymbols
+	}
+	total += float64(config.DigitCount)*math.Log2(10) + float64(config.SymbolCount)*charsetEntropy(symbols)
+
+	// 数字和符号的位置：从总长度中选出插入位置，再在其中区分数字和符号
+	extras := config.DigitCount + config.SymbolCount
+	positions := new(big.Int).Binomial(int64(config.Length), int64(extras))
+	positions.Mul(positions, new(big.Int).Binomial(int64(extras), int64(config.DigitCount)))
+	total += log2BigInt(positions)
+
+	return total, nil
+}
+
+/**
+ * pronounceableEntropyLowerBound 计算可发音密码规则的熵值下界
+ * @param config 可发音密码规则配置
+ * @return float64 熵值下界（位）
+ * @return error 错误信息
+ * @description 可发音部分在所有组合中均匀选择；确定插入位置后数字和符号可以唯一还原，
+ *              因此只计入可发音部分和插入字符本身，插入位置的随机性不计入
+ */
+func (prs *PasswordRuleService) pronounceableEntropyLowerBound(config models.PronounceableRuleConfig) (float64, error) {
+	if err := prs.validatePronounceableConfig(config); err != nil {
+		return 0, err
+	}
+
+	letters := config.Length - config.DigitCount - config.SymbolCount
+	total := log2BigInt(newPronounceableModel(letters, config.Capitalization == "random").total)
+
+	symbols := config.Symbols
+	if symbols == "" {
+		symbols = DefaultPassphraseSymbols
+	}
+	total += float64(config.DigitCount)*math.Log2(10) - float64(config.SymbolCount)*math.Log2(maxCharProbability(symbols))
+	return total, nil
+}
@@ -0,0 +1,248 @@
+package services
+
+import (
+	"math"
+	"testing"
+
+	"wepassword/internal/models"
+)
+
+/**
+ * TestGeneralRuleEntropy 测试通用规则熵值
+ * 预期：无最小位数约束时等于 Length*log2(字符集大小)，最小位数约束会降低熵值
+ */
+func TestGeneralRuleEntropy(t *testing.T) {
+	prs := &PasswordRuleService{}
+
+	config := models.GeneralRuleConfig{
+		IncludeUppercase: true,
+		IncludeLowercase: true,
+		IncludeNumbers:   true,
+		Length:           12,
+	}
+	bits, err := prs.generalRuleEntropy(config)
+	if err != nil {
+		t.Fatalf("计算熵值失败: %v", err)
+	}
+	expected := 12 * math.Log2(62)
+	if math.Abs(bits-expected) > 1e-9 {
+		t.Errorf("期望%.4f位，实际%.4f位", expected, bits)
+	}
+
+	config.MinUppercase, config.MinLowercase, config.MinNumbers = 1, 1, 1
+	constrained, err := prs.generalRuleEntropy(config)
+	if err != nil {
+		t.Fatalf("计算熵值失败: %v", err)
+	}
+	if constrained >= bits {
+		t.Errorf("最小位数约束应降低熵值: %.4f >= %.4f", constrained, bits)
+	}
+
+	// 长度2、大写和数字各至少1位：共 2*26*10 种
+	small := models.GeneralRuleConfig{IncludeUppercase: true, IncludeNumbers: true, MinUppercase: 1, MinNumbers: 1, Length: 2}
+	bits, _ = prs.generalRuleEntropy(small)
+	if math.Abs(bits-math.Log2(520)) > 1e-9 {
+		t.Errorf("期望%.4f位，实际%.4f位", math.Log2(520), bits)
+	}
+
+	// 排除易混淆字符0O1lI后：大写24、小写25、数字8
+	excluded := models.GeneralRuleConfig{IncludeUppercase: true, IncludeLowercase: true, IncludeNumbers: true, Length: 10, ExcludeAmbiguous: true}
+	bits, _ = prs.generalRuleEntropy(excluded)
+	if math.Abs(bits-10*math.Log2(57)) > 1e-9 {
+		t.Errorf("期望%.4f位，实际%.4f位", 10*math.Log2(57), bits)
+	}
+}
+
+/**
+ * TestCustomPatternEntropy 测试自定义规则熵值
+ */
+func TestCustomPatternEntropy(t *testing.T) {
+	prs := &PasswordRuleService{}
+
+	cases := map[string]float64{
+		"d{6}":     6 * math.Log2(10),
+		"u-d":      math.Log2(26) + math.Log2(10),
+		"[abc]{4}": 4 * math.Log2(3),
+		"\\dx":     math.Log2(float64(len([]rune(Latin1Supplement)))),
+		"[aab]":    -(2.0/3*math.Log2(2.0/3) + 1.0/3*math.Log2(1.0/3)),
+		"A{3}":     2*math.Log2(26) + math.Log2(10),
+		"[a1]{2}":  1,
+		"-_!!":     0,
+	}
+	for pattern, expected := range cases {
+		bits, err := prs.customPatternEntropy(pattern)
+		if err != nil {
+			t.Fatalf("计算%s熵值失败: %v", pattern, err)
+		}
+		if math.Abs(bits-expected) > 1e-9 {
+			t.Errorf("%s 期望%.4f位，实际%.4f位", pattern, expected, bits)
+		}
+	}
+
+	if _, err := prs.customPatternEntropy("[abc"); err == nil {
+		t.Error("缺少']'应返回错误")
+	}
+}
+
+/**
+ * TestPassphraseEntropy 测试口令短语规则熵值
+ */
+func TestPassphraseEntropy(t *testing.T) {
+	prs := &PasswordRuleService{}
+
+	bits, err := prs.passphraseEntropy(models.PassphraseRuleConfig{Wordlist: WordlistEFFLarge, WordCount: 6})
+	if err != nil {
+		t.Fatalf("计算熵值失败: %v", err)
+	}
+	if math.Abs(bits-6*math.Log2(7776)) > 1e-9 {
+		t.Errorf("期望%.4f位，实际%.4f位", 6*math.Log2(7776), bits)
+	}
+
+	// 插入位置：有分隔符时1个数字有2×单词数个位置；没有分隔符时相邻位置合并
+	if got := passphraseExtrasPositionBits(4, 1, 0, true); math.Abs(got-3) > 1e-9 {
+		t.Errorf("有分隔符时1个数字的位置熵值应为3位，实际%.4f位", got)
+	}
+	if got := passphraseExtrasPositionBits(4, 1, 0, false); math.Abs(got-math.Log2(5)) > 1e-9 {
+		t.Errorf("没有分隔符时1个数字的位置熵值应为log2(5)位，实际%.4f位", got)
+	}
+	// 2个数字放入8个间隙：C(9,7)=36种，少于逐个计算的8×8=64种
+	if got := passphraseExtrasPositionBits(4, 2, 0, true); math.Abs(got-math.Log2(36)) > 1e-9 {
+		t.Errorf("2个数字的位置熵值应为log2(36)位，实际%.4f位", got)
+	}
+}
+
+/**
+ * TestCheckRuleEntropy_InvalidConfig 测试熵值无法计算的配置不能保存
+ */
+func TestCheckRuleEntropy_InvalidConfig(t *testing.T) {
+	prs := &PasswordRuleService{}
+	prs.SetPolicyConfig(models.PasswordPolicyConfig{MinEntropyBits: 30})
+
+	if err := prs.checkRuleEntropy("passphrase", `{"word_count":0}`); err == nil {
+		t.Error("熵值无法计算时应拒绝保存")
+	}
+}
+
+/**
+ * TestEvaluateEntropy_Policy 测试强度策略
+ * 预期：低于警告阈值给出警告，低于最小值时拒绝保存
+ */
+func TestEvaluateEntropy_Policy(t *testing.T) {
+	prs := &PasswordRuleService{}
+	prs.SetPolicyConfig(models.PasswordPolicyConfig{WarnEntropyBits: 60, MinEntropyBits: 30})
+
+	weak := `{"pattern":"d{6}"}`
+	if err := prs.checkRuleEntropy("custom", weak); err == nil {
+		t.Error("熵值低于最小值时应拒绝保存")
+	}
+
+	fair, err := prs.calculateEntropyFromJSON("custom", `{"pattern":"L{8}"}`)
+	if err != nil {
+		t.Fatalf("计算熵值失败: %v", err)
+	}
+	if fair.BelowMinimum || fair.Warning == "" || fair.Level != "fair" {
+		t.Errorf("45.6位应为fair且有警告: %+v", fair)
+	}
+
+	strong, _ := prs.calculateEntropyFromJSON("custom", `{"pattern":"S{16}"}`)
+	if strong.Warning != "" || strong.Level != "very_strong" {
+		t.Errorf("S{16}应为very_strong且无警告: %+v", strong)
+	}
+}
+
+/**
+ * TestCustomPatternEntropy_Extended 测试扩展模式语法的熵值
+ */
+func TestCustomPatternEntropy_Extended(t *testing.T) {
+	prs := &PasswordRuleService{}
+
+	cases := map[string]float64{
+		"d{1,2}":            1 + (math.Log2(10)+2*math.Log2(10))/2,
+		"(\"abc\"|\"xyz\")": 1,
+		"(d|\"x\")":         1 + math.Log2(10)/2,
+		"\"user\"d?":        1 + math.Log2(10)/2,
+		"[α-δ]":             2,
+		"<\"ab\"\"cd\">":    math.Log2(24),
+	}
+	for pattern, expected := range cases {
+		bits, err := prs.customPatternEntropy(pattern)
+		if err != nil {
+			t.Fatalf("计算%s熵值失败: %v", pattern, err)
+		}
+		if math.Abs(bits-expected) > 1e-9 {
+			t.Errorf("%s 期望%.4f位，实际%.4f位", pattern, expected, bits)
+		}
+	}
+}
+
+/**
+ * TestRuleEntropyLowerBound 测试各规则类型的熵值下界
+ * 预期：下界不高于上界；均匀生成时与上界相同，分支、可选和连续重复限制会降低下界
+ */
+func TestRuleEntropyLowerBound(t *testing.T) {
+	prs := &PasswordRuleService{}
+
+	general := models.GeneralRuleConfig{IncludeUppercase: true, IncludeLowercase: true, IncludeNumbers: true, Length: 12}
+	if bits, err := prs.generalRuleEntropyLowerBound(general); err != nil || math.Abs(bits-12*math.Log2(62)) > 1e-9 {
+		t.Errorf("无约束时下界应为12×log2(62)，实际%.4f位, %v", bits, err)
+	}
+
+	// 4位数字、不允许连续重复：违反概率不超过3×0.1，实际通过概率为0.729
+	digits := models.GeneralRuleConfig{IncludeNumbers: true, Length: 4, MaxConsecutive: 1}
+	if bits, _ := prs.generalRuleEntropyLowerBound(digits); math.Abs(bits-(4*math.Log2(10)+math.Log2(0.7))) > 1e-9 {
+		t.Errorf("连续重复限制的下界错误: %.4f", bits)
+	}
+	// 3位数字、禁止顺序字符：16/1000的组合构成顺序
+	digits = models.GeneralRuleConfig{IncludeNumbers: true, Length: 3, ForbidSequences: true}
+	if bits, _ := prs.generalRuleEntropyLowerBound(digits); math.Abs(bits-(3*math.Log2(10)+math.Log2(0.984))) > 1e-9 {
+		t.Errorf("顺序字符限制的下界错误: %.4f", bits)
+	}
+
+	customCases := map[string]float64{
+		"d{6}":           6 * math.Log2(10),
+		"A{3}":           2*math.Log2(26) + math.Log2(10),
+		"[aab]":          -math.Log2(2.0 / 3),
+		"d{1,2}":         math.Log2(10),
+		"(d|\"x\")":      0,
+		"<\"ab\"\"cd\">": 0,
+		"(d|[a-z]{20})":  math.Log2(10),
+	}
+	for pattern, expected := range customCases {
+		bits, err := prs.customPatternEntropyLowerBound(pattern)
+		if err != nil || math.Abs(bits-expected) > 1e-9 {
+			t.Errorf("%s 下界期望%.4f位，实际%.4f位, %v", pattern, expected, bits, err)
+		}
+	}
+
+	// 分隔符不出现在单词和插入字符中时单词边界唯一；拼音没有分隔符时 an+gao 和 ang+ao 相同，按单词链长度扣减
+	spaced := models.PassphraseRuleConfig{Wordlist: WordlistEFFLarge, WordCount: 6, Separator: " "}
+	if bits, _ := prs.passphraseEntropyLowerBound(spaced); math.Abs(bits-6*math.Log2(7776)) > 1e-9 {
+		t.Errorf("空格分隔的口令短语下界错误: %.4f", bits)
+	}
+	joined := models.PassphraseRuleConfig{Wordlist: WordlistPinyin, WordCount: 6}
+	if bits, _ := prs.passphraseEntropyLowerBound(joined); bits >= 6*math.Log2(410) || bits <= 0 {
+		t.Errorf("单词边界有歧义时下界应降低: %.4f", bits)
+	}
+
+	pronounceable := models.PronounceableRuleConfig{Length: 5, DigitCount: 1}
+	if bits, _ := prs.pronounceableEntropyLowerBound(pronounceable); math.Abs(bits-math.Log2(12167*10)) > 1e-9 {
+		t.Errorf("可发音密码下界错误: %.4f", bits)
+	}
+}
+
+/**
+ * TestEvaluateEntropy_LowerBound 测试最小值检查按下界进行
+ * 预期：(d|[a-z]{20}) 上界约49.7位，但有一半概率只生成一位数字，应被拒绝
+ */
+func TestEvaluateEntropy_LowerBound(t *testing.T) {
+	prs := &PasswordRuleService{}
+	prs.SetPolicyConfig(models.PasswordPolicyConfig{MinEntropyBits: 30})
+
+	entropy, err := prs.calculateEntropyFromJSON("custom", `{"pattern":"(d|[a-z]{20})"}`)
+	if err != nil {
+		t.Fatalf("计算熵值失败: %v", err)
+	}
+	if entropy.EntropyUpperBits < 30 || !entropy.BelowMinimum || entropy.Level != "weak" {
+		t.Errorf("上界达标但下界不足的规则应被拒绝: %+v", entropy)
+	}
+}
@@ -22,6 +22,7 @@ import (
  */
 type PasswordRuleService struct {
 	dbManager *database.DatabaseManager
+	policy    models.PasswordPolicyConfig // 20251021 陈凤庆 规则强度策略，保存规则时检查熵值
 }
 
 /**
@@ -49,7 +50,7 @@ func (prs *PasswordRuleService) CreateRule(name, description, ruleType string, c
 		return models.PasswordRule{}, fmt.Errorf("规则名称不能为空")
 	}
 
-	if ruleType != "general" && ruleType != "custom" {
+	if ruleType != "general" && ruleType != "custom" && ruleType != "passphrase" && ruleType != "pronounceable" {
 		return models.PasswordRule{}, fmt.Errorf("无效的规则类型: %s", ruleType)
 	}
 
@@ -70,6 +71,11 @@ func (prs *PasswordRuleService) CreateRule(name, description, ruleType string, c
 		return models.PasswordRule{}, fmt.Errorf("序列化规则配置失败: %w", err)
 	}
 
+	// 20251021 陈凤庆 检查规则熵值，低于最小熵值时拒绝保存
+	if err := prs.checkRuleEntropy(ruleType, string(configJSON)); err != nil {
+		return models.PasswordRule{}, err
+	}
+
 	// 创建规则
 	rule := models.PasswordRule{
 		ID:          utils.GenerateGUID(),
@@ -133,6 +139,11 @@ func (prs *PasswordRuleService) UpdateRule(id, name, description string, config
 		return models.PasswordRule{}, fmt.Errorf("序列化规则配置失败: %w", err)
 	}
 
+	// 20251021 陈凤庆 检查规则熵值，低于最小熵值时拒绝保存
+	if err := prs.checkRuleEntropy(rule.RuleType, string(configJSON)); err != nil {
+		return models.PasswordRule{}, err
+	}
+
 	// 更新规则
 	rule.Name = name
 	rule.Description = description
@@ -165,10 +176,9 @@ func (prs *PasswordRuleService) DeleteRule(id string) error {
 		return fmt.Errorf("获取密码规则失败: %w", err)
 	}
 
-	db := prs.dbManager.GetDB()
-	_, err = db.Exec("DELETE FROM password_rules WHERE id = ?", id)
-	if err != nil {
-		return fmt.Errorf("删除密码规则失败: %w", err)
+	// 20251021 陈凤庆 解除分组和类型对该规则的绑定
+	if err := deleteRuleWith(prs.dbManager.GetDB(), id); err != nil {
+		return err
 	}
 
 	logger.Info("[密码规则服务] 删除密码规则成功: %s", rule.Name)
@@ -321,14 +331,7 @@ func (prs *PasswordRuleService) GeneratePassword(ruleID string) (string, error)
 		return "", fmt.Errorf("获取密码规则失败: %w", err)
 	}
 
-	switch rule.RuleType {
-	case "general":
-		return prs.generateGeneralPassword(rule.Config)
-	case "custom":
-		return prs.generateCustomPassword(rule.Config)
-	default:
-		return "", fmt.Errorf("不支持的规则类型: %s", rule.RuleType)
-	}
+	return prs.generatePasswordFromJSON(rule.RuleType, rule.Config)
 }
 
 /**
@@ -344,11 +347,26 @@ func (prs *PasswordRuleService) GeneratePasswordByConfig(ruleType string, config
 		return "", fmt.Errorf("序列化配置失败: %w", err)
 	}
 
+	return prs.generatePasswordFromJSON(ruleType, string(configJSON))
+}
+
+/**
+ * generatePasswordFromJSON 根据规则类型和配置JSON生成密码
+ * @param ruleType 规则类型
+ * @param configJSON 配置JSON字符串
+ * @return string 生成的密码
+ * @return error 错误信息
+ */
+func (prs *PasswordRuleService) generatePasswordFromJSON(ruleType, configJSON string) (string, error) {
 	switch ruleType {
 	case "general":
-		return prs.generateGeneralPassword(string(configJSON))
+		return prs.generateGeneralPassword(configJSON)
 	case "custom":
-		return prs.generateCustomPassword(string(configJSON))
+		return prs.generateCustomPassword(configJSON)
+	case "passphrase":
+		return prs.generatePassphrase(configJSON)
+	case "pronounceable":
+		return prs.generatePronounceablePassword(configJSON)
 	default:
 		return "", fmt.Errorf("不支持的规则类型: %s", ruleType)
 	}
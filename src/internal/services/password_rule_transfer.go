@@ -0,0 +1,631 @@
+package services
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"wepassword/internal/database"
+	"wepassword/internal/logger"
+	"wepassword/internal/models"
+	"wepassword/internal/utils"
+)
+
+/**
+ * 密码规则导入导出
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 以JSON文件导入导出密码规则，导入前逐条校验配置（通用规则经 validateGeneralConfig 校验）
+ *              及强度策略，任一规则无效时整个文件都不导入，导入在一个事务中完成。密码库可以订阅磁盘上的规则文件，
+ *              打开密码库或手动同步时按文件内容新建、更新或删除由该文件同步而来的规则。
+ *              20251021 陈凤庆 同步只修改和删除由订阅创建的规则（password_rules.origin），同名的本地规则作为冲突报告，不会被覆盖或删除
+ */
+
+const (
+	// PasswordRuleFileFormat 规则文件格式标识
+	PasswordRuleFileFormat = "wepassword-password-rules"
+
+	// PasswordRuleFileVersion 当前规则文件格式版本
+	PasswordRuleFileVersion = 1
+
+	// 名称冲突处理方式
+	RuleConflictSkip      = "skip"      // 跳过文件中的规则（默认）
+	RuleConflictOverwrite = "overwrite" // 用文件中的规则覆盖现有规则
+	RuleConflictRename    = "rename"    // 改名后作为新规则导入
+
+	// sysinfo表中保存规则文件订阅的键名
+	ruleSubscriptionKey = "password_rule_subscription"
+
+	// password_rules.origin 的取值：本地创建或导入的规则为空，由订阅的规则文件创建的规则为 subscription
+	ruleOriginLocal        = ""
+	ruleOriginSubscription = "subscription"
+)
+
+/**
+ * ruleExecutor 规则读写使用的数据库连接或事务（*sql.DB 和 *sql.Tx 均满足）
+ */
+type ruleExecutor interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+/**
+ * ExportRules 导出密码规则
+ * @param ruleIDs 要导出的规则ID，为空时导出全部规则
+ * @param revision 规则集修订号
+ * @return []byte 规则文件内容（JSON）
+ * @return error 错误信息
+ */
+func (prs *PasswordRuleService) ExportRules(ruleIDs []string, revision int) ([]byte, error) {
+	var rules []models.PasswordRule
+	if len(ruleIDs) == 0 {
+		all, err := prs.GetAllRules()
+		if err != nil {
+			return nil, err
+		}
+		rules = all
+	} else {
+		for _, id := range ruleIDs {
+			rule, err := prs.GetRuleByID(id)
+			if err != nil {
+				return nil, err
+			}
+			rules = append(rules, rule)
+		}
+	}
+
+	file := models.PasswordRuleFile{
+		Format:        PasswordRuleFileFormat,
+		FormatVersion: PasswordRuleFileVersion,
+		Revision:      revision,
+		ExportedAt:    time.Now(),
+		Rules:         make([]models.PasswordRuleFileItem, 0, len(rules)),
+	}
+	for _, rule := range rules {
+		file.Rules = append(file.Rules, models.PasswordRuleFileItem{
+			Name:        rule.Name,
+			Description: rule.Description,
+			RuleType:    rule.RuleType,
+			Config:      json.RawMessage(rule.Config),
+		})
+	}
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("序列化规则文件失败: %w", err)
+	}
+
+	logger.Info("[密码规则服务] 导出 %d 个密码规则，修订号: %d", len(file.Rules), revision)
+	return data, nil
+}
+
+/**
+ * ImportRules 导入密码规则
+ * @param data 规则文件内容（JSON）
+ * @param conflict 名称冲突处理方式：skip(默认)、overwrite、rename
+ * @return models.PasswordRuleImportResult 导入结果
+ * @return error 错误信息
+ */
+func (prs *PasswordRuleService) ImportRules(data []byte, conflict string) (models.PasswordRuleImportResult, error) {
+	var result models.PasswordRuleImportResult
+
+	switch conflict {
+	case "":
+		conflict = RuleConflictSkip
+	case RuleConflictSkip, RuleConflictOverwrite, RuleConflictRename:
+	default:
+		return result, fmt.Errorf("不支持的名称冲突处理方式: %s", conflict)
+	}
+
+	file, err := prs.parseRuleFile(data)
+	if err != nil {
+		return result, err
+	}
+
+	// 20251021 陈凤庆 整个文件在一个事务中导入，中途失败时不留下部分导入的规则
+	tx, err := prs.dbManager.GetDB().Begin()
+	if err != nil {
+		return result, fmt.Errorf("开始事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, item := range file.Rules {
+		existing, found, err := getRuleByName(tx, item.Name)
+		if err != nil {
+			return models.PasswordRuleImportResult{}, err
+		}
+
+		if !found {
+			if _, err := createRuleFromItem(tx, item.Name, item, ruleOriginLocal); err != nil {
+				return models.PasswordRuleImportResult{}, err
+			}
+			result.Created = append(result.Created, item.Name)
+			continue
+		}
+
+		switch conflict {
+		case RuleConflictSkip:
+			result.Skipped = append(result.Skipped, item.Name)
+		case RuleConflictOverwrite:
+			if ruleMatchesItem(existing, item) {
+				result.Skipped = append(result.Skipped, item.Name)
+				continue
+			}
+			if err := updateRuleFromItem(tx, existing, item); err != nil {
+				return models.PasswordRuleImportResult{}, err
+			}
+			result.Updated = append(result.Updated, item.Name)
+		case RuleConflictRename:
+			name, err := availableRuleName(tx, item.Name)
+			if err != nil {
+				return models.PasswordRuleImportResult{}, err
+			}
+			if _, err := createRuleFromItem(tx, name, item, ruleOriginLocal); err != nil {
+				return models.PasswordRuleImportResult{}, err
+			}
+			result.Renamed = append(result.Renamed, name)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.PasswordRuleImportResult{}, fmt.Errorf("提交事务失败: %w", err)
+	}
+
+	logger.Info("[密码规则服务] 导入密码规则完成，新建: %d，更新: %d，改名: %d，跳过: %d",
+		len(result.Created), len(result.Updated), len(result.Renamed), len(result.Skipped))
+	return result, nil
+}
+
+/**
+ * GetRuleSubscription 获取密码库订阅的规则文件
+ * @return *models.PasswordRuleSubscription 订阅信息，未订阅时为nil
+ * @return error 错误信息
+ */
+func (prs *PasswordRuleService) GetRuleSubscription() (*models.PasswordRuleSubscription, error) {
+	value, err := database.NewSysInfoManager(prs.dbManager.GetDB()).GetValue(ruleSubscriptionKey)
+	if err != nil {
+		return nil, err
+	}
+	if value == "" {
+		return nil, nil
+	}
+
+	var subscription models.PasswordRuleSubscription
+	if err := json.Unmarshal([]byte(value), &subscription); err != nil {
+		return nil, fmt.Errorf("解析规则文件订阅失败: %w", err)
+	}
+	return &subscription, nil
+}
+
+/**
+ * SubscribeRuleFile 订阅规则文件并立即同步
+ * @param path 规则文件路径
+ * @return models.PasswordRuleImportResult 同步结果
+ * @return error 错误信息
+ * @description 已订阅其他文件时，原文件同步而来的规则保留为本地规则
+ */
+func (prs *PasswordRuleService) SubscribeRuleFile(path string) (models.PasswordRuleImportResult, error) {
+	if path == "" {
+		return models.PasswordRuleImportResult{}, fmt.Errorf("规则文件路径不能为空")
+	}
+
+	subscription, err := prs.GetRuleSubscription()
+	if err != nil {
+		return models.PasswordRuleImportResult{}, err
+	}
+	if subscription == nil || subscription.Path != path {
+		if subscription != nil {
+			if err := prs.releaseSubscribedRules(); err != nil {
+				return models.PasswordRuleImportResult{}, err
+			}
+		}
+		subscription = &models.PasswordRuleSubscription{Path: path}
+	}
+
+	return prs.syncRuleFile(subscription)
+}
+
+/**
+ * releaseSubscribedRules 将由订阅创建的规则转为本地规则
+ * @return error 错误信息
+ */
+func (prs *PasswordRuleService) releaseSubscribedRules() error {
+	_, err := prs.dbManager.GetDB().Exec("UPDATE password_rules SET origin = ? WHERE origin = ?", ruleOriginLocal, ruleOriginSubscription)
+	if err != nil {
+		return fmt.Errorf("保留已同步的规则失败: %w", err)
+	}
+	return nil
+}
+
+/**
+ * UnsubscribeRuleFile 取消订阅规则文件
+ * @return error 错误信息
+ * @description 已同步的规则保留为本地规则，不再随文件变化
+ */
+func (prs *PasswordRuleService) UnsubscribeRuleFile() error {
+	if err := prs.releaseSubscribedRules(); err != nil {
+		return err
+	}
+	if err := database.NewSysInfoManager(prs.dbManager.GetDB()).DeleteKey(ruleSubscriptionKey); err != nil {
+		return fmt.Errorf("取消订阅规则文件失败: %w", err)
+	}
+	logger.Info("[密码规则服务] 已取消订阅规则文件")
+	return nil
+}
+
+/**
+ * SyncRuleSubscription 按订阅的规则文件同步规则
+ * @return models.PasswordRuleImportResult 同步结果，未订阅或文件未变化时为空
+ * @return error 错误信息
+ */
+func (prs *PasswordRuleService) SyncRuleSubscription() (models.PasswordRuleImportResult, error) {
+	subscription, err := prs.GetRuleSubscription()
+	if err != nil {
+		return models.PasswordRuleImportResult{}, err
+	}
+	if subscription == nil {
+		return models.PasswordRuleImportResult{}, nil
+	}
+	return prs.syncRuleFile(subscription)
+}
+
+/**
+ * syncRuleFile 读取订阅的规则文件并同步
+ * @param subscription 订阅信息
+ * @return models.PasswordRuleImportResult 同步结果
+ * @return error 错误信息
+ * @description 由订阅创建的规则以文件为准：按内容新建或更新，文件中已删除的规则从密码库删除。
+ *              与本地规则同名的文件规则不做修改，记入 Conflicts，由用户改名或删除本地规则后再同步。
+ *              文件内容未变化时不做任何修改；整个同步在一个事务中完成
+ */
+func (prs *PasswordRuleService) syncRuleFile(subscription *models.PasswordRuleSubscription) (models.PasswordRuleImportResult, error) {
+	var result models.PasswordRuleImportResult
+
+	data, err := os.ReadFile(subscription.Path)
+	if err != nil {
+		return result, fmt.Errorf("读取规则文件失败: %w", err)
+	}
+
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	if hash == subscription.Hash {
+		return result, nil
+	}
+
+	file, err := prs.parseRuleFile(data)
+	if err != nil {
+		return result, err
+	}
+	if !subscription.LastSyncedAt.IsZero() && file.Revision < subscription.Revision {
+		return result, fmt.Errorf("规则文件修订号(%d)低于已同步的修订号(%d)，可能是过期的副本", file.Revision, subscription.Revision)
+	}
+
+	tx, err := prs.dbManager.GetDB().Begin()
+	if err != nil {
+		return result, fmt.Errorf("开始事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	// 由订阅创建的规则，按名称索引
+	synced, err := getRulesByOrigin(tx, ruleOriginSubscription)
+	if err != nil {
+		return result, err
+	}
+
+	ruleIDs := make([]string, 0, len(file.Rules))
+	inFile := make(map[string]bool, len(file.Rules))
+	for _, item := range file.Rules {
+		inFile[item.Name] = true
+
+		existing, found := synced[item.Name]
+		if !found {
+			_, local, err := getRuleByName(tx, item.Name)
+			if err != nil {
+				return models.PasswordRuleImportResult{}, err
+			}
+			if local {
+				result.Conflicts = append(result.Conflicts, item.Name)
+				continue
+			}
+		}
+
+		switch {
+		case !found:
+			created, err := createRuleFromItem(tx, item.Name, item, ruleOriginSubscription)
+			if err != nil {
+				return models.PasswordRuleImportResult{}, err
+			}
+			ruleIDs = append(ruleIDs, created.ID)
+			result.Created = append(result.Created, item.Name)
+		case ruleMatchesItem(existing, item):
+			ruleIDs = append(ruleIDs, existing.ID)
+			result.Skipped = append(result.Skipped, item.Name)
+		default:
+			if err := updateRuleFromItem(tx, existing, item); err != nil {
+				return models.PasswordRuleImportResult{}, err
+			}
+			ruleIDs = append(ruleIDs, existing.ID)
+			result.Updated = append(result.Updated, item.Name)
+		}
+	}
+
+	for name, rule := range synced {
+		if inFile[name] {
+			continue
+		}
+		if err := deleteRuleWith(tx, rule.ID); err != nil {
+			return models.PasswordRuleImportResult{}, err
+		}
+		result.Deleted = append(result.Deleted, name)
+	}
+
+	subscription.Revision = file.Revision
+	subscription.Hash = hash
+	if len(result.Conflicts) > 0 {
+		// 有冲突时不记录文件摘要，解决冲突后再次同步时重新处理
+		subscription.Hash = ""
+	}
+	subscription.LastSyncedAt = time.Now()
+	subscription.RuleIDs = ruleIDs
+	value, err := json.Marshal(subscription)
+	if err != nil {
+		return models.PasswordRuleImportResult{}, fmt.Errorf("序列化规则文件订阅失败: %w", err)
+	}
+	_, err = tx.Exec(`
+		INSERT INTO sysinfo (keyname, keyvalue, created_at, updated_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT(keyname) DO UPDATE SET keyvalue = excluded.keyvalue, updated_at = excluded.updated_at
+	`, ruleSubscriptionKey, string(value), subscription.LastSyncedAt, subscription.LastSyncedAt)
+	if err != nil {
+		return models.PasswordRuleImportResult{}, fmt.Errorf("保存规则文件订阅失败: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.PasswordRuleImportResult{}, fmt.Errorf("提交事务失败: %w", err)
+	}
+
+	logger.Info("[密码规则服务] 同步规则文件完成: %s，修订号: %d，新建: %d，更新: %d，删除: %d，冲突: %d",
+		subscription.Path, file.Revision, len(result.Created), len(result.Updated), len(result.Deleted), len(result.Conflicts))
+	return result, nil
+}
+
+/**
+ * parseRuleFile 解析并校验规则文件
+ * @param data 规则文件内容
+ * @return models.PasswordRuleFile 规则文件
+ * @return error 错误信息，任一规则无效时返回错误
+ */
+func (prs *PasswordRuleService) parseRuleFile(data []byte) (models.PasswordRuleFile, error) {
+	var file models.PasswordRuleFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return file, fmt.Errorf("解析规则文件失败: %w", err)
+	}
+
+	if file.Format != PasswordRuleFileFormat {
+		return file, fmt.Errorf("不是密码规则文件: %s", file.Format)
+	}
+	if file.FormatVersion <= 0 || file.FormatVersion > PasswordRuleFileVersion {
+		return file, fmt.Errorf("不支持的规则文件格式版本: %d，请升级程序", file.FormatVersion)
+	}
+
+	names := make(map[string]bool, len(file.Rules))
+	for i, item := range file.Rules {
+		if item.Name == "" {
+			return file, fmt.Errorf("第%d个规则名称为空", i+1)
+		}
+		if names[item.Name] {
+			return file, fmt.Errorf("规则名称重复: %s", item.Name)
+		}
+		names[item.Name] = true
+
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, item.Config); err != nil {
+			return file, fmt.Errorf("规则“%s”配置格式错误: %w", item.Name, err)
+		}
+		file.Rules[i].Config = compact.Bytes()
+
+		if err := prs.validateRuleConfig(item.RuleType, compact.String()); err != nil {
+			return file, fmt.Errorf("规则“%s”无效: %w", item.Name, err)
+		}
+	}
+
+	return file, nil
+}
+
+/**
+ * validateRuleConfig 校验规则配置及强度策略
+ * @param ruleType 规则类型
+ * @param configJSON 配置JSON字符串
+ * @return error 错误信息
+ * @description 各类型的熵值计算会先做配置校验（通用规则即 validateGeneralConfig），
+ *              再按强度策略拒绝低于最小熵值的规则
+ */
+func (prs *PasswordRuleService) validateRuleConfig(ruleType, configJSON string) error {
+	switch ruleType {
+	case "general", "custom", "passphrase", "pronounceable":
+	default:
+		return fmt.Errorf("无效的规则类型: %s", ruleType)
+	}
+
+	entropy, err := prs.calculateEntropyFromJSON(ruleType, configJSON)
+	if err != nil {
+		return err
+	}
+	if entropy.BelowMinimum {
+		return fmt.Errorf("规则强度不足: %s", entropy.Warning)
+	}
+	return nil
+}
+
+/**
+ * getRuleByName 根据名称获取密码规则
+ * @param name 规则名称
+ * @return models.PasswordRule 密码规则
+ * @return bool 是否存在
+ * @return error 错误信息
+ */
+func (prs *PasswordRuleService) getRuleByName(name string) (models.PasswordRule, bool, error) {
+	return getRuleByName(prs.dbManager.GetDB(), name)
+}
+
+/**
+ * getRuleByName 在数据库连接或事务中根据名称获取密码规则
+ * @param q 数据库连接或事务
+ * @param name 规则名称
+ * @return models.PasswordRule 密码规则
+ * @return bool 是否存在
+ * @return error 错误信息
+ */
+func getRuleByName(q ruleExecutor, name string) (models.PasswordRule, bool, error) {
+	var rule models.PasswordRule
+	err := q.QueryRow(`
+		SELECT id, name, description, rule_type, config, is_default, created_at, updated_at
+		FROM password_rules WHERE name = ?
+	`, name).Scan(
+		&rule.ID, &rule.Name, &rule.Description, &rule.RuleType,
+		&rule.Config, &rule.IsDefault, &rule.CreatedAt, &rule.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return models.PasswordRule{}, false, nil
+	}
+	if err != nil {
+		return models.PasswordRule{}, false, fmt.Errorf("查询密码规则失败: %w", err)
+	}
+	return rule, true, nil
+}
+
+/**
+ * getRulesByOrigin 获取指定来源的密码规则
+ * @param q 数据库连接或事务
+ * @param origin 规则来源
+ * @return map[string]models.PasswordRule 按名称索引的规则
+ * @return error 错误信息
+ */
+func getRulesByOrigin(q ruleExecutor, origin string) (map[string]models.PasswordRule, error) {
+	rows, err := q.Query(`
+		SELECT id, name, description, rule_type, config, is_default, created_at, updated_at
+		FROM password_rules WHERE origin = ?
+	`, origin)
+	if err != nil {
+		return nil, fmt.Errorf("查询密码规则失败: %w", err)
+	}
+	defer rows.Close()
+
+	rules := make(map[string]models.PasswordRule)
+	for rows.Next() {
+		var rule models.PasswordRule
+		err := rows.Scan(
+			&rule.ID, &rule.Name, &rule.Description, &rule.RuleType,
+			&rule.Config, &rule.IsDefault, &rule.CreatedAt, &rule.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("扫描密码规则失败: %w", err)
+		}
+		rules[rule.Name] = rule
+	}
+	return rules, rows.Err()
+}
+
+/**
+ * availableRuleName 为冲突的规则名称生成可用的新名称，如“公司规则 (2)”
+ * @param q 数据库连接或事务
+ * @param name 原名称
+ * @return string 可用的名称
+ * @return error 错误信息
+ */
+func availableRuleName(q ruleExecutor, name string) (string, error) {
+	for i := 2; ; i++ {
+		candidate := fmt.Sprintf("%s (%d)", name, i)
+		_, exists, err := getRuleByName(q, candidate)
+		if err != nil {
+			return "", fmt.Errorf("检查规则名称失败: %w", err)
+		}
+		if !exists {
+			return candidate, nil
+		}
+	}
+}
+
+/**
+ * createRuleFromItem 按规则文件中的规则新建规则
+ * @param q 数据库连接或事务
+ * @param name 规则名称
+ * @param item 规则文件中的规则（已校验）
+ * @param origin 规则来源
+ * @return models.PasswordRule 新建的规则
+ * @return error 错误信息
+ */
+func createRuleFromItem(q ruleExecutor, name string, item models.PasswordRuleFileItem, origin string) (models.PasswordRule, error) {
+	now := time.Now()
+	rule := models.PasswordRule{
+		ID:          utils.GenerateGUID(),
+		Name:        name,
+		Description: item.Description,
+		RuleType:    item.RuleType,
+		Config:      string(item.Config),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	_, err := q.Exec(`
+		INSERT INTO password_rules (id, name, description, rule_type, config, is_default, origin, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, rule.ID, rule.Name, rule.Description, rule.RuleType, rule.Config, rule.IsDefault, origin, rule.CreatedAt, rule.UpdatedAt)
+	if err != nil {
+		return models.PasswordRule{}, fmt.Errorf("保存密码规则“%s”失败: %w", name, err)
+	}
+	return rule, nil
+}
+
+/**
+ * updateRuleFromItem 用规则文件中的规则覆盖现有规则，保留规则ID、来源以及分组和类型的绑定
+ * @param q 数据库连接或事务
+ * @param rule 现有规则
+ * @param item 规则文件中的规则（已校验）
+ * @return error 错误信息
+ */
+func updateRuleFromItem(q ruleExecutor, rule models.PasswordRule, item models.PasswordRuleFileItem) error {
+	_, err := q.Exec(`
+		UPDATE password_rules
+		SET description = ?, rule_type = ?, config = ?, updated_at = ?
+		WHERE id = ?
+	`, item.Description, item.RuleType, string(item.Config), time.Now(), rule.ID)
+	if err != nil {
+		return fmt.Errorf("更新密码规则“%s”失败: %w", rule.Name, err)
+	}
+	return nil
+}
+
+/**
+ * deleteRuleWith 删除密码规则并解除分组和类型对它的绑定
+ * @param q 数据库连接或事务
+ * @param ruleID 规则ID
+ * @return error 错误信息
+ */
+func deleteRuleWith(q ruleExecutor, ruleID string) error {
+	if _, err := q.Exec("DELETE FROM password_rules WHERE id = ?", ruleID); err != nil {
+		return fmt.Errorf("删除密码规则失败: %w", err)
+	}
+	return clearRuleBindings(q, ruleID)
+}
+
+/**
+ * ruleMatchesItem 判断现有规则与规则文件中的规则内容是否相同
+ * @param rule 现有规则
+ * @param item 规则文件中的规则
+ * @return bool 是否相同
+ */
+func ruleMatchesItem(rule models.PasswordRule, item models.PasswordRuleFileItem) bool {
+	if rule.Description != item.Description || rule.RuleType != item.RuleType {
+		return false
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, []byte(rule.Config)); err != nil {
+		return false
+	}
+	return bytes.Equal(compact.Bytes(), item.Config)
+}
@@ -0,0 +1,238 @@
+package services
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"wepassword/internal/models"
+)
+
+/**
+ * 密码规则导入导出测试
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 测试规则导出、按冲突方式导入、配置校验以及规则文件订阅同步
+ */
+
+func newTestRuleService(t *testing.T) *PasswordRuleService {
+	t.Helper()
+	accountService, _ := newTestAccountService(t)
+	return NewPasswordRuleService(accountService.dbManager)
+}
+
+func testRuleFile(t *testing.T, revision int, items ...models.PasswordRuleFileItem) []byte {
+	t.Helper()
+	data, err := json.Marshal(models.PasswordRuleFile{
+		Format:        PasswordRuleFileFormat,
+		FormatVersion: PasswordRuleFileVersion,
+		Revision:      revision,
+		Rules:         items,
+	})
+	if err != nil {
+		t.Fatalf("序列化规则文件失败: %v", err)
+	}
+	return data
+}
+
+func TestExportImportRules(t *testing.T) {
+	prs := newTestRuleService(t)
+
+	rule, err := prs.CreateRule("公司规则", "内部系统", "custom", models.CustomRuleConfig{Pattern: "a{20}"})
+	if err != nil {
+		t.Fatalf("创建规则失败: %v", err)
+	}
+
+	data, err := prs.ExportRules([]string{rule.ID}, 3)
+	if err != nil {
+		t.Fatalf("导出规则失败: %v", err)
+	}
+
+	// 同名规则默认跳过
+	result, err := prs.ImportRules(data, "")
+	if err != nil {
+		t.Fatalf("导入规则失败: %v", err)
+	}
+	if len(result.Skipped) != 1 || len(result.Created) != 0 {
+		t.Errorf("同名规则应被跳过: %+v", result)
+	}
+
+	// 改名导入
+	result, err = prs.ImportRules(data, RuleConflictRename)
+	if err != nil {
+		t.Fatalf("导入规则失败: %v", err)
+	}
+	if len(result.Renamed) != 1 || result.Renamed[0] != "公司规则 (2)" {
+		t.Errorf("改名导入结果错误: %+v", result)
+	}
+
+	// 覆盖导入
+	changed := testRuleFile(t, 4, models.PasswordRuleFileItem{
+		Name:        "公司规则",
+		Description: "内部系统（新）",
+		RuleType:    "custom",
+		Config:      json.RawMessage(`{"pattern": "a{24}"}`),
+	})
+	result, err = prs.ImportRules(changed, RuleConflictOverwrite)
+	if err != nil {
+		t.Fatalf("导入规则失败: %v", err)
+	}
+	if len(result.Updated) != 1 {
+		t.Errorf("同名规则应被覆盖: %+v", result)
+	}
+	updated, err := prs.GetRuleByID(rule.ID)
+	if err != nil {
+		t.Fatalf("获取规则失败: %v", err)
+	}
+	if updated.Description != "内部系统（新）" || updated.Config != `{"pattern":"a{24}"}` {
+		t.Errorf("覆盖后的规则错误: %+v", updated)
+	}
+}
+
+func TestImportRules_Invalid(t *testing.T) {
+	prs := newTestRuleService(t)
+
+	cases := map[string][]byte{
+		"格式标识错误": []byte(`{"format":"other","format_version":1,"rules":[]}`),
+		"格式版本过高": []byte(`{"format":"wepassword-password-rules","format_version":99,"rules":[]}`),
+		"最小位数超过长度": testRuleFile(t, 1, models.PasswordRuleFileItem{
+			Name:     "无效通用规则",
+			RuleType: "general",
+			Config:   json.RawMessage(`{"include_uppercase":true,"length":4,"min_uppercase":8}`),
+		}),
+		"规则类型无效": testRuleFile(t, 1, models.PasswordRuleFileItem{
+			Name:     "未知类型",
+			RuleType: "unknown",
+			Config:   json.RawMessage(`{}`),
+		}),
+		"名称重复": testRuleFile(t, 1,
+			models.PasswordRuleFileItem{Name: "重复", RuleType: "custom", Config: json.RawMessage(`{"pattern":"a{20}"}`)},
+			models.PasswordRuleFileItem{Name: "重复", RuleType: "custom", Config: json.RawMessage(`{"pattern":"a{20}"}`)},
+		),
+	}
+
+	for name, data := range cases {
+		if _, err := prs.ImportRules(data, ""); err == nil {
+			t.Errorf("%s: 期望返回错误", name)
+		}
+	}
+
+	// 任一规则无效时整个文件都不导入
+	mixed := testRuleFile(t, 1,
+		models.PasswordRuleFileItem{Name: "有效规则", RuleType: "custom", Config: json.RawMessage(`{"pattern":"a{20}"}`)},
+		models.PasswordRuleFileItem{Name: "无效规则", RuleType: "custom", Config: json.RawMessage(`{"pattern":"[a"}`)},
+	)
+	if _, err := prs.ImportRules(mixed, ""); err == nil {
+		t.Error("包含无效规则时应返回错误")
+	}
+	if _, found, _ := prs.getRuleByName("有效规则"); found {
+		t.Error("包含无效规则时不应导入任何规则")
+	}
+}
+
+func TestRuleSubscription_Sync(t *testing.T) {
+	prs := newTestRuleService(t)
+	path := filepath.Join(t.TempDir(), "rules.json")
+
+	local, err := prs.CreateRule("数据库", "本地", "custom", models.CustomRuleConfig{Pattern: "h{32}"})
+	if err != nil {
+		t.Fatalf("创建规则失败: %v", err)
+	}
+
+	write := func(revision int, items ...models.PasswordRuleFileItem) {
+		if err := os.WriteFile(path, testRuleFile(t, revision, items...), 0600); err != nil {
+			t.Fatalf("写入规则文件失败: %v", err)
+		}
+	}
+	dbRule := models.PasswordRuleFileItem{Name: "数据库", Description: "公司", RuleType: "custom", Config: json.RawMessage(`{"pattern":"h{40}"}`)}
+	web := models.PasswordRuleFileItem{Name: "网站", RuleType: "custom", Config: json.RawMessage(`{"pattern":"a{20}"}`)}
+
+	write(1, dbRule, web)
+	result, err := prs.SubscribeRuleFile(path)
+	if err != nil {
+		t.Fatalf("订阅规则文件失败: %v", err)
+	}
+	if len(result.Created) != 1 || len(result.Updated) != 0 || len(result.Conflicts) != 1 || result.Conflicts[0] != "数据库" {
+		t.Errorf("首次同步结果错误: %+v", result)
+	}
+	kept, _ := prs.GetRuleByID(local.ID)
+	if kept.Config != local.Config || kept.Description != "本地" {
+		t.Errorf("同名本地规则不应被覆盖: %+v", kept)
+	}
+
+	// 有冲突时每次同步都重新报告
+	result, err = prs.SyncRuleSubscription()
+	if err != nil {
+		t.Fatalf("同步失败: %v", err)
+	}
+	if len(result.Conflicts) != 1 || len(result.Skipped) != 1 {
+		t.Errorf("冲突未解决时应再次报告: %+v", result)
+	}
+
+	// 改名本地规则后同步创建文件中的规则
+	if _, err := prs.UpdateRule(local.ID, "数据库（本地）", "本地", models.CustomRuleConfig{Pattern: "h{32}"}); err != nil {
+		t.Fatalf("修改规则失败: %v", err)
+	}
+	result, err = prs.SyncRuleSubscription()
+	if err != nil {
+		t.Fatalf("同步失败: %v", err)
+	}
+	if len(result.Created) != 1 || result.Created[0] != "数据库" || len(result.Conflicts) != 0 {
+		t.Errorf("解决冲突后同步结果错误: %+v", result)
+	}
+
+	// 文件未变化时不做修改
+	result, err = prs.SyncRuleSubscription()
+	if err != nil {
+		t.Fatalf("同步失败: %v", err)
+	}
+	if len(result.Created)+len(result.Updated)+len(result.Deleted)+len(result.Skipped) != 0 {
+		t.Errorf("文件未变化时不应有修改: %+v", result)
+	}
+
+	// 删除文件中的规则后同步删除，本地规则不受影响
+	write(2, dbRule)
+	result, err = prs.SyncRuleSubscription()
+	if err != nil {
+		t.Fatalf("同步失败: %v", err)
+	}
+	if len(result.Deleted) != 1 || result.Deleted[0] != "网站" {
+		t.Errorf("文件中删除的规则应被删除: %+v", result)
+	}
+	if _, err := prs.GetRuleByID(local.ID); err != nil {
+		t.Errorf("同步不应删除本地规则: %v", err)
+	}
+
+	// 修订号降低时拒绝同步
+	write(1, dbRule, web)
+	if _, err := prs.SyncRuleSubscription(); err == nil {
+		t.Error("修订号降低时应返回错误")
+	}
+
+	// 取消订阅后保留规则
+	if err := prs.UnsubscribeRuleFile(); err != nil {
+		t.Fatalf("取消订阅失败: %v", err)
+	}
+	subscription, err := prs.GetRuleSubscription()
+	if err != nil || subscription != nil {
+		t.Errorf("取消订阅后不应有订阅信息: %+v, %v", subscription, err)
+	}
+	synced, found, _ := prs.getRuleByName("数据库")
+	if !found {
+		t.Fatal("取消订阅后应保留已同步的规则")
+	}
+
+	// 重新订阅后，原先同步的规则已是本地规则，不会被删除
+	write(3, web)
+	result, err = prs.SubscribeRuleFile(path)
+	if err != nil {
+		t.Fatalf("重新订阅失败: %v", err)
+	}
+	if len(result.Deleted) != 0 || len(result.Created) != 1 {
+		t.Errorf("重新订阅结果错误: %+v", result)
+	}
+	if _, err := prs.GetRuleByID(synced.ID); err != nil {
+		t.Errorf("取消订阅前同步的规则应保留为本地规则: %v", err)
+	}
+}
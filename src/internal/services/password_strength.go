@@ -0,0 +1,1147 @@
+package services
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+/**
+ * 密码强度评估
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 参考zxcvbn的思路评估密码强度：先找出密码中可被猜测的模式（常见密码/单词、键盘图案、
+ *              日期、重复、字母数字序列、l33t替换），再求覆盖整个密码所需猜测次数最少的模式组合，
+ *              据此给出0-4的评分、估计猜测次数、不同攻击场景下的破解时间以及改进建议
+ */
+
+// 模式类型
+const (
+	StrengthPatternDictionary = "dictionary" // 常见密码、英文单词、账号相关信息
+	StrengthPatternSpatial    = "spatial"    // 键盘图案，如 qwerty、1qaz2wsx
+	StrengthPatternRepeat     = "repeat"     // 重复，如 aaa、abcabc
+	StrengthPatternSequence   = "sequence"   // 序列，如 abcd、6543
+	StrengthPatternDate       = "date"       // 日期，如 19900101、1/1/90
+	StrengthPatternYear       = "year"       // 年份，如 1990
+	StrengthPatternBruteforce = "bruteforce" // 无法识别，只能暴力破解
+)
+
+// 字典来源
+const (
+	strengthDictPasswords  = "passwords"   // 常见密码
+	strengthDictWords      = "words"       // 英文单词（EFF词表）
+	strengthDictUserInputs = "user_inputs" // 账号相关信息（标题、用户名、网址等）
+)
+
+const (
+	strengthBruteforceCardinality = 10    // 暴力破解每个字符的猜测基数
+	strengthMinGuessesSingleChar  = 10    // 单字符模式的最小猜测次数
+	strengthMinGuessesMultiChar   = 50    // 多字符模式的最小猜测次数
+	strengthMinYearSpace          = 20    // 年份的最小猜测空间
+	strengthMinGuessesGrowing     = 10000 // 每多一个模式额外增加的猜测次数基数
+	strengthMaxPasswordLength     = 128   // 参与分析的最大长度，超出部分按暴力破解计算
+)
+
+/**
+ * PasswordStrengthMatch 密码中识别出的模式
+ */
+type PasswordStrengthMatch struct {
+	Pattern      string  `json:"pattern"`       // 模式类型
+	Token        string  `json:"-"`             // 匹配到的片段（不返回前端，避免明文回显）
+	I            int     `json:"i"`             // 起始位置（按字符计）
+	J            int     `json:"j"`             // 结束位置（包含）
+	Guesses      float64 `json:"guesses"`       // 该片段的估计猜测次数
+	Dictionary   string  `json:"dictionary"`    // 字典来源（仅dictionary模式）
+	Rank         int     `json:"rank"`          // 在字典中的排名（仅dictionary模式）
+	Reversed     bool    `json:"reversed"`      // 是否倒序（仅dictionary模式）
+	L33t         bool    `json:"l33t"`          // 是否包含l33t替换（仅dictionary模式）
+	Turns        int     `json:"turns"`         // 键盘图案转折次数（仅spatial模式）
+	ShiftedCount int     `json:"shifted_count"` // 上档字符个数（仅spatial模式）
+	BaseToken    string  `json:"-"`             // 被重复的基础片段（仅repeat模式）
+	RepeatCount  int     `json:"repeat_count"`  // 重复次数（仅repeat模式）
+	Ascending    bool    `json:"ascending"`     // 是否升序（仅sequence模式）
+	Year         int     `json:"year"`          // 年份（date/year模式）
+	HasSeparator bool    `json:"has_separator"` // 日期是否带分隔符（仅date模式）
+
+	baseGuesses float64 // 被重复片段的猜测次数
+	l33tSubs    map[rune]rune
+}
+
+/**
+ * PasswordCrackTime 某种攻击场景下的破解时间
+ */
+type PasswordCrackTime struct {
+	Scenario         string  `json:"scenario"`           // 场景标识
+	Description      string  `json:"description"`        // 场景说明
+	GuessesPerSecond float64 `json:"guesses_per_second"` // 每秒猜测次数
+	Seconds          float64 `json:"seconds"`            // 估计破解秒数
+	Display          string  `json:"display"`            // 可读的破解时间
+}
+
+/**
+ * PasswordStrength 密码强度评估结果
+ */
+type PasswordStrength struct {
+	Score        int                     `json:"score"`         // 评分0-4：0-极弱 1-很弱 2-弱 3-较强 4-强
+	Label        string                  `json:"label"`         // 评分说明
+	Guesses      float64                 `json:"guesses"`       // 估计猜测次数
+	GuessesLog10 float64                 `json:"guesses_log10"` // 估计猜测次数的常用对数
+	CrackTimes   []PasswordCrackTime     `json:"crack_times"`   // 各场景破解时间
+	Warning      string                  `json:"warning"`       // 主要问题
+	Suggestions  []string                `json:"suggestions"`   // 改进建议
+	Sequence     []PasswordStrengthMatch `json:"sequence"`      // 覆盖整个密码的模式组合
+}
+
+// 评分说明
+var strengthLabels = []string{"极弱", "很弱", "弱", "较强", "强"}
+
+// 破解场景
+var strengthCrackScenarios = []struct {
+	scenario    string
+	description string
+	perSecond   float64
+}{
+	{"online_throttled", "在线攻击（有频率限制，每小时100次）", 100.0 / 3600},
+	{"online_unthrottled", "在线攻击（无频率限制，每秒10次）", 10},
+	{"offline_slow_hash", "离线攻击（慢哈希，如bcrypt/Argon2，每秒1万次）", 1e4},
+	{"offline_fast_hash", "离线攻击（快哈希，如MD5/SHA1，每秒100亿次）", 1e10},
+}
+
+// l33t替换表：替换字符 -> 可能的原字母
+var strengthL33tTable = map[rune][]rune{
+	'4': {'a'}, '@': {'a'},
+	'8': {'b'},
+	'(': {'c'}, '{': {'c'}, '[': {'c'}, '<': {'c'},
+	'3': {'e'},
+	'6': {'g'}, '9': {'g'},
+	'1': {'i', 'l'}, '!': {'i'}, '|': {'i', 'l'},
+	'7': {'l', 't'},
+	'0': {'o'},
+	'$': {'s'}, '5': {'s'},
+	'+': {'t'},
+	'%': {'x'},
+	'2': {'z'},
+}
+
+// 键盘布局（倾斜排列，每行相对上一行右移半个键位）
+var strengthKeyboardRows = [][2]string{
+	{"`1234567890-=", "~!@#$%^&*()_+"},
+	{"qwertyuiop[]\\", "QWERTYUIOP{}|"},
+	{"asdfghjkl;'", "ASDFGHJKL:\""},
+	{"zxcvbnm,./", "ZXCVBNM<>?"},
+}
+
+var (
+	strengthDictOnce      sync.Once
+	strengthRankedDicts   map[string]map[string]int
+	strengthKeyboardGraph map[rune][]rune // 键 -> 六个方向的相邻键（无则为0）
+	strengthShiftedKeys   map[rune]rune   // 上档字符 -> 对应的下档键
+	strengthKeyCount      float64
+	strengthKeyAvgDegree  float64
+
+	strengthDateSeparatorPattern = regexp.MustCompile(`^(\d{1,4})([\s/\\_.-])(\d{1,2})([\s/\\_.-])(\d{1,4})$`)
+	strengthYearPattern          = regexp.MustCompile(`19\d\d|20\d\d`)
+)
+
+/**
+ * initStrengthData 初始化字典和键盘图
+ */
+func initStrengthData() {
+	strengthRankedDicts = make(map[string]map[string]int)
+	for dict, path := range map[string]string{
+		strengthDictPasswords: "wordlists/common_passwords.txt",
+		strengthDictWords:     "wordlists/eff_large_wordlist.txt",
+	} {
+		ranked := make(map[string]int)
+		words, err := readEmbeddedWords(path)
+		if err == nil {
+			for i, word := range words {
+				word = strings.ToLower(word)
+				if _, exists := ranked[word]; !exists {
+					ranked[word] = i + 1
+				}
+			}
+		}
+		strengthRankedDicts[dict] = ranked
+	}
+
+	// 构建键盘邻接图，坐标x按行偏移，使相邻判断与实际键位一致
+	type pos struct{ x, y int }
+	positions := make(map[pos]rune)
+	strengthShiftedKeys = make(map[rune]rune)
+	for y, row := range strengthKeyboardRows {
+		lower := []rune(row[0])
+		upper := []rune(row[1])
+		offset := 0
+		if y > 0 {
+			offset = 1
+		}
+		for i, key := range lower {
+			positions[pos{i + offset, y}] = key
+			strengthShiftedKeys[upper[i]] = key
+		}
+	}
+
+	strengthKeyboardGraph = make(map[rune][]rune)
+	totalDegree := 0
+	for p, key := range positions {
+		// 左、右、左上、右上、左下、右下
+		directions := []pos{{p.x - 1, p.y}, {p.x + 1, p.y}, {p.x, p.y - 1}, {p.x + 1, p.y - 1}, {p.x - 1, p.y + 1}, {p.x, p.y + 1}}
+		neighbors := make([]rune, len(directions))
+		for i, d := range directions {
+			if n, ok := positions[d]; ok {
+				neighbors[i] = n
+				totalDegree++
+			}
+		}
+		strengthKeyboardGraph[key] = neighbors
+	}
+	strengthKeyCount = float64(len(positions))
+	strengthKeyAvgDegree = float64(totalDegree) / strengthKeyCount
+}
+
+/**
+ * EstimatePasswordStrength 评估密码强度
+ * @param password 密码
+ * @param userInputs 与账号相关的信息（标题、用户名、网址等），密码中包含这些信息时会被视为易猜测
+ * @return PasswordStrength 评估结果
+ */
+func EstimatePasswordStrength(password string, userInputs []string) PasswordStrength {
+	strengthDictOnce.Do(initStrengthData)
+
+	runes := []rune(password)
+	analyzed := runes
+	if len(analyzed) > strengthMaxPasswordLength {
+		analyzed = analyzed[:strengthMaxPasswordLength]
+	}
+
+	dicts := map[string]map[string]int{
+		strengthDictPasswords:  strengthRankedDicts[strengthDictPasswords],
+		strengthDictWords:      strengthRankedDicts[strengthDictWords],
+		strengthDictUserInputs: buildUserInputDict(userInputs),
+	}
+
+	logGuesses, sequence := mostGuessableSequence(analyzed, dicts)
+	// 超出分析长度的部分按暴力破解累加
+	logGuesses += float64(len(runes)-len(analyzed)) * math.Log10(strengthBruteforceCardinality)
+
+	result := PasswordStrength{
+		GuessesLog10: logGuesses,
+		Guesses:      math.Pow(10, logGuesses),
+		Score:        strengthScore(logGuesses),
+		Sequence:     sequence,
+	}
+	result.Label = strengthLabels[result.Score]
+	for _, s := range strengthCrackScenarios {
+		seconds := math.Pow(10, logGuesses) / s.perSecond
+		result.CrackTimes = append(result.CrackTimes, PasswordCrackTime{
+			Scenario:         s.scenario,
+			Description:      s.description,
+			GuessesPerSecond: s.perSecond,
+			Seconds:          seconds,
+			Display:          displayCrackTime(seconds),
+		})
+	}
+	result.Warning, result.Suggestions = strengthFeedback(result.Score, sequence)
+	return result
+}
+
+/**
+ * buildUserInputDict 构建账号相关信息字典
+ * @param userInputs 账号相关信息
+ * @return map[string]int 单词 -> 排名
+ * @description 除整体外，还会按非字母数字字符拆分（如邮箱、网址中的各段）
+ */
+func buildUserInputDict(userInputs []string) map[string]int {
+	dict := make(map[string]int)
+	rank := 1
+	add := func(word string) {
+		word = strings.ToLower(strings.TrimSpace(word))
+		if len([]rune(word)) < 3 {
+			return
+		}
+		if _, exists := dict[word]; !exists {
+			dict[word] = rank
+			rank++
+		}
+	}
+	for _, input := range userInputs {
+		add(input)
+		for _, part := range strings.FieldsFunc(input, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		}) {
+			add(part)
+		}
+	}
+	return dict
+}
+
+/**
+ * strengthScore 根据猜测次数计算评分
+ * @param logGuesses 猜测次数的常用对数
+ * @return int 评分0-4
+ */
+func strengthScore(logGuesses float64) int {
+	guesses := math.Pow(10, logGuesses)
+	const delta = 5
+	switch {
+	case guesses < 1e3+delta:
+		return 0
+	case guesses < 1e6+delta:
+		return 1
+	case guesses < 1e8+delta:
+		return 2
+	case guesses < 1e10+delta:
+		return 3
+	default:
+		return 4
+	}
+}
+
+/**
+ * displayCrackTime 将秒数转换为可读的时间描述
+ * @param seconds 秒数
+ * @return string 时间描述
+ */
+func displayCrackTime(seconds float64) string {
+	const (
+		minute  = 60.0
+		hour    = minute * 60
+		day     = hour * 24
+		month   = day * 31
+		year    = month * 12
+		century = year * 100
+	)
+	switch {
+	case seconds < 1:
+		return "不到1秒"
+	case seconds < minute:
+		return strconv.Itoa(int(math.Round(seconds))) + "秒"
+	case seconds < hour:
+		return strconv.Itoa(int(math.Round(seconds/minute))) + "分钟"
+	case seconds < day:
+		return strconv.Itoa(int(math.Round(seconds/hour))) + "小时"
+	case seconds < month:
+		return strconv.Itoa(int(math.Round(seconds/day))) + "天"
+	case seconds < year:
+		return strconv.Itoa(int(math.Round(seconds/month))) + "个月"
+	case seconds < century:
+		return strconv.Itoa(int(math.Round(seconds/year))) + "年"
+	default:
+		return "数百年以上"
+	}
+}
+
+/**
+ * mostGuessableSequence 求覆盖整个密码且猜测次数最少的模式组合
+ * @param runes 密码字符
+ * @param dicts 排名字典
+ * @return float64 猜测次数的常用对数
+ * @return []PasswordStrengthMatch 模式组合
+ * @description 动态规划：best[k][j] 表示用k个模式覆盖前j个字符时各模式猜测次数乘积的最小值（对数），
+ *              总猜测次数 = k! * 乘积 + 10000^(k-1)，模式越多越容易被攻击者按顺序尝试
+ */
+func mostGuessableSequence(runes []rune, dicts map[string]map[string]int) (float64, []PasswordStrengthMatch) {
+	n := len(runes)
+	if n == 0 {
+		return 0, []PasswordStrengthMatch{}
+	}
+
+	matches := omnimatch(runes, dicts)
+	byEnd := make([][]int, n)
+	for idx := range matches {
+		matches[idx].Guesses = estimateMatchGuesses(&matches[idx], dicts)
+		byEnd[matches[idx].J] = append(byEnd[matches[idx].J], idx)
+	}
+
+	type step struct {
+		match int // 匹配下标，-1表示暴力破解片段
+		start int // 片段起始位置
+	}
+	inf := math.Inf(1)
+	best := make([][]float64, n+1)
+	back := make([][]step, n+1)
+	for k := range best {
+		best[k] = make([]float64, n+1)
+		back[k] = make([]step, n+1)
+		for j := range best[k] {
+			best[k][j] = inf
+		}
+	}
+	best[0][0] = 0
+
+	bruteforceLog := func(length int) float64 {
+		minimum := float64(strengthMinGuessesMultiChar + 1)
+		if length == 1 {
+			minimum = strengthMinGuessesSingleChar + 1
+		}
+		return math.Max(float64(length)*math.Log10(strengthBruteforceCardinality), math.Log10(minimum))
+	}
+
+	for j := 1; j <= n; j++ {
+		for k := 1; k <= j; k++ {
+			// 以识别出的模式结尾
+			for _, idx := range byEnd[j-1] {
+				m := matches[idx]
+				if prev := best[k-1][m.I]; prev != inf {
+					if v := prev + math.Log10(m.Guesses); v < best[k][j] {
+						best[k][j] = v
+						back[k][j] = step{match: idx, start: m.I}
+					}
+				}
+			}
+			// 以暴力破解片段结尾
+			for i := 0; i < j; i++ {
+				if prev := best[k-1][i]; prev != inf {
+					if v := prev + bruteforceLog(j-i); v < best[k][j] {
+						best[k][j] = v
+						back[k][j] = step{match: -1, start: i}
+					}
+				}
+			}
+		}
+	}
+
+	bestLog := inf
+	bestK := 0
+	for k := 1; k <= n; k++ {
+		if best[k][n] == inf {
+			continue
+		}
+		logFact := 0.0
+		for f := 2; f <= k; f++ {
+			logFact += math.Log10(float64(f))
+		}
+		total := logAdd10(logFact+best[k][n], float64(k-1)*math.Log10(strengthMinGuessesGrowing))
+		if total < bestLog {
+			bestLog = total
+			bestK = k
+		}
+	}
+
+	// 回溯模式组合
+	var sequence []PasswordStrengthMatch
+	for j, k := n, bestK; j > 0; k-- {
+		s := back[k][j]
+		if s.match >= 0 {
+			sequence = append(sequence, matches[s.match])
+		} else {
+			sequence = append(sequence, PasswordStrengthMatch{
+				Pattern: StrengthPatternBruteforce,
+				Token:   string(runes[s.start:j]),
+				I:       s.start,
+				J:       j - 1,
+				Guesses: math.Pow(10, bruteforceLog(j-s.start)),
+			})
+		}
+		j = s.start
+	}
+	for l, r := 0, len(sequence)-1; l < r; l, r = l+1, r-1 {
+		sequence[l], sequence[r] = sequence[r], sequence[l]
+	}
+
+	return bestLog, sequence
+}
+
+/**
+ * logAdd10 计算 log10(10^a + 10^b)
+ */
+func logAdd10(a, b float64) float64 {
+	if a < b {
+		a, b = b, a
+	}
+	return a + math.Log10(1+math.Pow(10, b-a))
+}
+
+/**
+ * omnimatch 找出密码中所有可识别的模式
+ * @param runes 密码字符
+ * @param dicts 排名字典
+ * @return []PasswordStrengthMatch 模式列表
+ */
+func omnimatch(runes []rune, dicts map[string]map[string]int) []PasswordStrengthMatch {
+	var matches []PasswordStrengthMatch
+	matches = append(matches, dictionaryMatch(runes, dicts)...)
+	matches = append(matches, reverseDictionaryMatch(runes, dicts)...)
+	matches = append(matches, l33tMatch(runes, dicts)...)
+	matches = append(matches, spatialMatch(runes)...)
+	matches = append(matches, repeatMatch(runes, dicts)...)
+	matches = append(matches, sequenceMatch(runes)...)
+	matches = append(matches, yearMatch(runes)...)
+	matches = append(matches, dateMatch(runes)...)
+	sort.SliceStable(matches, func(a, b int) bool {
+		if matches[a].I != matches[b].I {
+			return matches[a].I < matches[b].I
+		}
+		return matches[a].J < matches[b].J
+	})
+	return matches
+}
+
+/**
+ * dictionaryMatch 字典匹配（忽略大小写）
+ */
+func dictionaryMatch(runes []rune, dicts map[string]map[string]int) []PasswordStrengthMatch {
+	var matches []PasswordStrengthMatch
+	lower := []rune(strings.ToLower(string(runes)))
+	if len(lower) != len(runes) {
+		lower = runes
+	}
+	for i := 0; i < len(runes); i++ {
+		for j := i; j < len(runes); j++ {
+			word := string(lower[i : j+1])
+			for dictName, dict := range dicts {
+				if rank, ok := dict[word]; ok {
+					matches = append(matches, PasswordStrengthMatch{
+						Pattern:    StrengthPatternDictionary,
+						Token:      string(runes[i : j+1]),
+						I:          i,
+						J:          j,
+						Dictionary: dictName,
+						Rank:       rank,
+					})
+				}
+			}
+		}
+	}
+	return matches
+}
+
+/**
+ * reverseDictionaryMatch 倒序字典匹配
+ */
+func reverseDictionaryMatch(runes []rune, dicts map[string]map[string]int) []PasswordStrengthMatch {
+	n := len(runes)
+	reversed := make([]rune, n)
+	for i, r := range runes {
+		reversed[n-1-i] = r
+	}
+	var matches []PasswordStrengthMatch
+	for _, m := range dictionaryMatch(reversed, dicts) {
+		// 回文本身已被正序匹配，不重复计算
+		if m.Token == reverseString(m.Token) {
+			continue
+		}
+		m.Token = reverseString(m.Token)
+		m.I, m.J = n-1-m.J, n-1-m.I
+		m.Reversed = true
+		matches = append(matches, m)
+	}
+	return matches
+}
+
+/**
+ * reverseString 倒序字符串
+ */
+func reverseString(s string) string {
+	runes := []rune(s)
+	for l, r := 0, len(runes)-1; l < r; l, r = l+1, r-1 {
+		runes[l], runes[r] = runes[r], runes[l]
+	}
+	return string(runes)
+}
+
+/**
+ * l33tMatch l33t替换字典匹配，如 p@ssw0rd
+ * @description 对包含替换字符的片段枚举可能的还原方式（最多16种），还原后再做字典匹配
+ */
+func l33tMatch(runes []rune, dicts map[string]map[string]int) []PasswordStrengthMatch {
+	var matches []PasswordStrengthMatch
+	n := len(runes)
+	for i := 0; i < n; i++ {
+		for j := i + 2; j < n; j++ {
+			token := runes[i : j+1]
+			var positions []int
+			for p, r := range token {
+				if _, ok := strengthL33tTable[r]; ok {
+					positions = append(positions, p)
+				}
+			}
+			if len(positions) == 0 || len(positions) == len(token) {
+				continue
+			}
+			for _, subs := range enumerateL33tSubs(token, positions) {
+				unsubbed := make([]rune, len(token))
+				for p, r := range token {
+					if orig, ok := subs[r]; ok {
+						unsubbed[p] = orig
+					} else {
+						unsubbed[p] = unicode.ToLower(r)
+					}
+				}
+				word := string(unsubbed)
+				for dictName, dict := range dicts {
+					if rank, ok := dict[word]; ok {
+						matches = append(matches, PasswordStrengthMatch{
+							Pattern:    StrengthPatternDictionary,
+							Token:      string(token),
+							I:          i,
+							J:          j,
+							Dictionary: dictName,
+							Rank:       rank,
+							L33t:       true,
+							l33tSubs:   subs,
+						})
+					}
+				}
+			}
+		}
+	}
+	return matches
+}
+
+/**
+ * enumerateL33tSubs 枚举片段中替换字符的还原方式
+ * @param token 片段
+ * @param positions 替换字符所在位置
+ * @return []map[rune]rune 替换字符 -> 原字母
+ */
+func enumerateL33tSubs(token []rune, positions []int) []map[rune]rune {
+	const maxCombinations = 16
+	var chars []rune
+	seen := make(map[rune]bool)
+	for _, p := range positions {
+		if !seen[token[p]] {
+			seen[token[p]] = true
+			chars = append(chars, token[p])
+		}
+	}
+
+	results := []map[rune]rune{{}}
+	for _, c := range chars {
+		var next []map[rune]rune
+		for _, partial := range results {
+			for _, orig := range strengthL33tTable[c] {
+				sub := make(map[rune]rune, len(partial)+1)
+				for k, v := range partial {
+					sub[k] = v
+				}
+				sub[c] = orig
+				next = append(next, sub)
+				if len(next) >= maxCombinations {
+					break
+				}
+			}
+		}
+		results = next
+	}
+	return results
+}
+
+/**
+ * spatialMatch 键盘图案匹配，长度至少3
+ */
+func spatialMatch(runes []rune) []PasswordStrengthMatch {
+	var matches []PasswordStrengthMatch
+	n := len(runes)
+	i := 0
+	for i < n-1 {
+		j := i + 1
+		turns := 0
+		lastDirection := -1
+		for j < n {
+			prev := unshiftKey(runes[j-1])
+			cur := unshiftKey(runes[j])
+			direction := -1
+			for d, neighbor := range strengthKeyboardGraph[prev] {
+				if neighbor != 0 && neighbor == cur {
+					direction = d
+					break
+				}
+			}
+			if direction < 0 {
+				break
+			}
+			if direction != lastDirection {
+				turns++
+				lastDirection = direction
+			}
+			j++
+		}
+		if j-i >= 3 {
+			shifted := 0
+			for _, r := range runes[i:j] {
+				if _, ok := strengthShiftedKeys[r]; ok {
+					shifted++
+				}
+			}
+			matches = append(matches, PasswordStrengthMatch{
+				Pattern:      StrengthPatternSpatial,
+				Token:        string(runes[i:j]),
+				I:            i,
+				J:            j - 1,
+				Turns:        turns,
+				ShiftedCount: shifted,
+			})
+		}
+		i = j
+	}
+	return matches
+}
+
+/**
+ * unshiftKey 将上档字符转换为对应的下档键
+ */
+func unshiftKey(r rune) rune {
+	if base, ok := strengthShiftedKeys[r]; ok {
+		return base
+	}
+	return r
+}
+
+/**
+ * repeatMatch 重复匹配，如 aaa、abcabc
+ * @description 从每个位置寻找最长的重复片段，基础片段的猜测次数递归评估
+ */
+func repeatMatch(runes []rune, dicts map[string]map[string]int) []PasswordStrengthMatch {
+	var matches []PasswordStrengthMatch
+	n := len(runes)
+	i := 0
+	for i < n {
+		bestLen, bestBase := 0, 0
+		for baseLen := 1; baseLen <= (n-i)/2; baseLen++ {
+			base := string(runes[i : i+baseLen])
+			count := 1
+			for k := i + baseLen; k+baseLen <= n && string(runes[k:k+baseLen]) == base; k += baseLen {
+				count++
+			}
+			if count >= 2 && count*baseLen > bestLen && (baseLen > 1 || count >= 3) {
+				bestLen, bestBase = count*baseLen, baseLen
+			}
+		}
+		if bestLen == 0 {
+			i++
+			continue
+		}
+		baseRunes := runes[i : i+bestBase]
+		baseLog, _ := mostGuessableSequence(baseRunes, dicts)
+		matches = append(matches, PasswordStrengthMatch{
+			Pattern:     StrengthPatternRepeat,
+			Token:       string(runes[i : i+bestLen]),
+			I:           i,
+			J:           i + bestLen - 1,
+			BaseToken:   string(baseRunes),
+			RepeatCount: bestLen / bestBase,
+			baseGuesses: math.Pow(10, baseLog),
+		})
+		i += bestLen
+	}
+	return matches
+}
+
+/**
+ * sequenceMatch 序列匹配，如 abcd、6543、acegi（步长不超过5，同一字符类别内）
+ */
+func sequenceMatch(runes []rune) []PasswordStrengthMatch {
+	var matches []PasswordStrengthMatch
+	n := len(runes)
+	class := func(r rune) int {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return 1
+		case r >= 'A' && r <= 'Z':
+			return 2
+		case r >= '0' && r <= '9':
+			return 3
+		default:
+			return 0
+		}
+	}
+	i := 0
+	for i < n-2 {
+		delta := int(runes[i+1]) - int(runes[i])
+		c := class(runes[i])
+		if c == 0 || delta == 0 || delta > 5 || delta < -5 || class(runes[i+1]) != c {
+			i++
+			continue
+		}
+		j := i + 1
+		for j+1 < n && class(runes[j+1]) == c && int(runes[j+1])-int(runes[j]) == delta {
+			j++
+		}
+		if j-i+1 >= 3 {
+			matches = append(matches, PasswordStrengthMatch{
+				Pattern:   StrengthPatternSequence,
+				Token:     string(runes[i : j+1]),
+				I:         i,
+				J:         j,
+				Ascending: delta > 0,
+			})
+			i = j
+		} else {
+			i++
+		}
+	}
+	return matches
+}
+
+/**
+ * yearMatch 年份匹配（1900-2099）
+ */
+func yearMatch(runes []rune) []PasswordStrengthMatch {
+	var matches []PasswordStrengthMatch
+	s := string(runes)
+	for _, loc := range strengthYearPattern.FindAllStringIndex(s, -1) {
+		token := s[loc[0]:loc[1]]
+		year, _ := strconv.Atoi(token)
+		i := len([]rune(s[:loc[0]]))
+		matches = append(matches, PasswordStrengthMatch{
+			Pattern: StrengthPatternYear,
+			Token:   token,
+			I:       i,
+			J:       i + len(token) - 1,
+			Year:    year,
+		})
+	}
+	return matches
+}
+
+/**
+ * dateMatch 日期匹配：4-8位纯数字（如 19900101、010190）或带分隔符的日期（如 1990-1-1、1/1/90）
+ */
+func dateMatch(runes []rune) []PasswordStrengthMatch {
+	var matches []PasswordStrengthMatch
+	n := len(runes)
+	// 纯数字日期的拆分方式：[日月年的分割点]
+	splits := map[int][][2]int{
+		4: {{1, 2}, {2, 3}},
+		5: {{1, 3}, {2, 3}},
+		6: {{1, 2}, {2, 4}, {4, 5}},
+		7: {{1, 3}, {2, 3}, {4, 5}, {4, 6}},
+		8: {{2, 4}, {4, 6}},
+	}
+	for i := 0; i < n; i++ {
+		for j := i + 3; j < n && j < i+10; j++ {
+			token := string(runes[i : j+1])
+			length := j - i + 1
+			if isAllDigits(token) && length <= 8 {
+				bestYear, found := 0, false
+				for _, sp := range splits[length] {
+					if year, ok := mapDate(token[:sp[0]], token[sp[0]:sp[1]], token[sp[1]:]); ok {
+						if !found || yearDistance(year) < yearDistance(bestYear) {
+							bestYear, found = year, true
+						}
+					}
+				}
+				if found {
+					matches = append(matches, PasswordStrengthMatch{
+						Pattern: StrengthPatternDate,
+						Token:   token,
+						I:       i,
+						J:       j,
+						Year:    bestYear,
+					})
+				}
+				continue
+			}
+			if length >= 6 {
+				if parts := strengthDateSeparatorPattern.FindStringSubmatch(token); parts != nil && parts[2] == parts[4] {
+					if year, ok := mapDate(parts[1], parts[3], parts[5]); ok {
+						matches = append(matches, PasswordStrengthMatch{
+							Pattern:      StrengthPatternDate,
+							Token:        token,
+							I:            i,
+							J:            j,
+							Year:         year,
+							HasSeparator: true,
+						})
+					}
+				}
+			}
+		}
+	}
+	return matches
+}
+
+/**
+ * mapDate 尝试将三段数字解释为日期（年在首或年在尾，日月顺序任意）
+ * @return int 年份
+ * @return bool 是否为有效日期
+ */
+func mapDate(a, b, c string) (int, bool) {
+	ia, _ := strconv.Atoi(a)
+	ib, _ := strconv.Atoi(b)
+	ic, _ := strconv.Atoi(c)
+	if ib <= 0 || ib > 31 {
+		return 0, false
+	}
+	validDayMonth := func(x, y int) bool {
+		return (x >= 1 && x <= 31 && y >= 1 && y <= 12) || (y >= 1 && y <= 31 && x >= 1 && x <= 12)
+	}
+	toYear := func(s string, v int) (int, bool) {
+		switch len(s) {
+		case 4:
+			if v >= 1000 && v <= 2050 {
+				return v, true
+			}
+		case 2:
+			if v > 50 {
+				return 1900 + v, true
+			}
+			return 2000 + v, true
+		}
+		return 0, false
+	}
+	if year, ok := toYear(c, ic); ok && validDayMonth(ia, ib) {
+		return year, true
+	}
+	if year, ok := toYear(a, ia); ok && validDayMonth(ib, ic) {
+		return year, true
+	}
+	return 0, false
+}
+
+/**
+ * yearDistance 年份与当前年份的距离
+ */
+func yearDistance(year int) int {
+	d := time.Now().Year() - year
+	if d < 0 {
+		d = -d
+	}
+	return d
+}
+
+/**
+ * isAllDigits 判断字符串是否全为数字
+ */
+func isAllDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+/**
+ * estimateMatchGuesses 估计单个模式的猜测次数
+ * @param m 模式
+ * @param dicts 排名字典
+ * @return float64 猜测次数
+ */
+func estimateMatchGuesses(m *PasswordStrengthMatch, dicts map[string]map[string]int) float64 {
+	var guesses float64
+	switch m.Pattern {
+	case StrengthPatternDictionary:
+		guesses = float64(m.Rank) * uppercaseVariations(m.Token) * l33tVariations(m)
+		if m.Reversed {
+			guesses *= 2
+		}
+	case StrengthPatternSpatial:
+		length := len([]rune(m.Token))
+		for i := 2; i <= length; i++ {
+			for j := 1; j <= m.Turns && j <= i-1; j++ {
+				guesses += binomial(i-1, j-1) * strengthKeyCount * math.Pow(strengthKeyAvgDegree, float64(j))
+			}
+		}
+		shifted := m.ShiftedCount
+		unshifted := length - shifted
+		if shifted > 0 {
+			if unshifted == 0 {
+				guesses *= 2
+			} else {
+				variations := 0.0
+				for i := 1; i <= shifted && i <= unshifted; i++ {
+					variations += binomial(shifted+unshifted, i)
+				}
+				guesses *= variations
+			}
+		}
+	case StrengthPatternRepeat:
+		guesses = m.baseGuesses * float64(m.RepeatCount)
+	case StrengthPatternSequence:
+		first := []rune(m.Token)[0]
+		var base float64
+		switch {
+		case strings.ContainsRune("aAzZ019", first):
+			base = 4
+		case unicode.IsDigit(first):
+			base = 10
+		default:
+			base = 26
+		}
+		if !m.Ascending {
+			base *= 2
+		}
+		guesses = base * float64(len([]rune(m.Token)))
+	case StrengthPatternYear:
+		guesses = math.Max(float64(yearDistance(m.Year)), strengthMinYearSpace)
+	case StrengthPatternDate:
+		guesses = math.Max(float64(yearDistance(m.Year)), strengthMinYearSpace) * 365
+		if m.HasSeparator {
+			guesses *= 4
+		}
+	default:
+		guesses = math.Pow(strengthBruteforceCardinality, float64(len([]rune(m.Token))))
+	}
+
+	minimum := float64(strengthMinGuessesMultiChar)
+	if len([]rune(m.Token)) == 1 {
+		minimum = strengthMinGuessesSingleChar
+	}
+	return math.Max(guesses, minimum)
+}
+
+/**
+ * uppercaseVariations 大小写变化带来的额外猜测倍数
+ */
+func uppercaseVariations(token string) float64 {
+	upper, lower := 0, 0
+	for _, r := range token {
+		if unicode.IsUpper(r) {
+			upper++
+		} else if unicode.IsLower(r) {
+			lower++
+		}
+	}
+	if upper == 0 {
+		return 1
+	}
+	runes := []rune(token)
+	// 首字母大写、末字母大写、全部大写是最常见的变化
+	firstOnly := unicode.IsUpper(runes[0]) && upper == 1
+	lastOnly := unicode.IsUpper(runes[len(runes)-1]) && upper == 1
+	if lower == 0 || firstOnly || lastOnly {
+		return 2
+	}
+	variations := 0.0
+	for i := 1; i <= upper && i <= lower; i++ {
+		variations += binomial(upper+lower, i)
+	}
+	return variations
+}
+
+/**
+ * l33tVariations l33t替换带来的额外猜测倍数
+ */
+func l33tVariations(m *PasswordStrengthMatch) float64 {
+	if !m.L33t {
+		return 1
+	}
+	variations := 1.0
+	for subbed, orig := range m.l33tSubs {
+		s, u := 0, 0
+		for _, r := range strings.ToLower(m.Token) {
+			if r == subbed {
+				s++
+			} else if r == orig {
+				u++
+			}
+		}
+		if s == 0 || u == 0 {
+			variations *= 2
+			continue
+		}
+		possibilities := 0.0
+		for i := 1; i <= s && i <= u; i++ {
+			possibilities += binomial(s+u, i)
+		}
+		variations *= possibilities
+	}
+	return variations
+}
+
+/**
+ * binomial 组合数 C(n, k)
+ */
+func binomial(n, k int) float64 {
+	if k < 0 || k > n {
+		return 0
+	}
+	result := 1.0
+	for i := 1; i <= k; i++ {
+		result = result * float64(n-k+i) / float64(i)
+	}
+	return result
+}
+
+/**
+ * strengthFeedback 根据评分和模式组合生成警告与建议
+ * @param score 评分
+ * @param sequence 模式组合
+ * @return string 警告
+ * @return []string 建议
+ */
+func strengthFeedback(score int, sequence []PasswordStrengthMatch) (string, []string) {
+	if len(sequence) == 0 {
+		return "", []string{
+			"使用几个单词组合，避免常见短语",
+			"不必非要使用符号、数字或大写字母",
+		}
+	}
+	if score > 2 {
+		return "", []string{}
+	}
+
+	// 以最长的模式为主要问题
+	longest := sequence[0]
+	for _, m := range sequence[1:] {
+		if len([]rune(m.Token)) > len([]rune(longest.Token)) {
+			longest = m
+		}
+	}
+
+	warning := ""
+	suggestions := []string{"再添加一两个单词，不常见的单词更好"}
+	switch longest.Pattern {
+	case StrengthPatternDictionary:
+		switch longest.Dictionary {
+		case strengthDictPasswords:
+			switch {
+			case len(sequence) == 1 && !longest.L33t && !longest.Reversed && longest.Rank <= 10:
+				warning = "这是最常用的10个密码之一"
+			case len(sequence) == 1 && !longest.L33t && !longest.Reversed && longest.Rank <= 100:
+				warning = "这是最常用的100个密码之一"
+			default:
+				warning = "这与常用密码非常相似"
+			}
+		case strengthDictUserInputs:
+			warning = "密码包含了标题、用户名或网址等账号相关信息"
+		default:
+			if len(sequence) == 1 {
+				warning = "单独的单词很容易被猜到"
+			}
+		}
+		runes := []rune(longest.Token)
+		if unicode.IsUpper(runes[0]) && uppercaseVariations(longest.Token) <= 2 {
+			if strings.ToUpper(longest.Token) == longest.Token {
+				suggestions = append(suggestions, "全部大写与全部小写几乎一样容易猜到")
+			} else {
+				suggestions = append(suggestions, "首字母大写帮助不大")
+			}
+		}
+		if longest.Reversed && len(runes) >= 4 {
+			suggestions = append(suggestions, "倒序拼写单词并不难猜")
+		}
+		if longest.L33t {
+			suggestions = append(suggestions, "用相似的数字或符号替换字母（如用@替换a）帮助不大")
+		}
+	case StrengthPatternSpatial:
+		if longest.Turns == 1 {
+			warning = "连续的一排键盘按键很容易被猜到"
+		} else {
+			warning = "较短的键盘图案很容易被猜到"
+		}
+		suggestions = append(suggestions, "使用更长且转折更多的键盘图案")
+	case StrengthPatternRepeat:
+		if len([]rune(longest.BaseToken)) == 1 {
+			warning = "重复的字符（如aaa）很容易被猜到"
+		} else {
+			warning = "重复的字符组（如abcabc）只比单独的abc略难猜"
+		}
+		suggestions = append(suggestions, "避免重复的单词和字符")
+	case StrengthPatternSequence:
+		warning = "字母或数字序列（如abc、6543）很容易被猜到"
+		suggestions = append(suggestions, "避免使用序列")
+	case StrengthPatternYear, StrengthPatternDate:
+		warning = "日期和年份很容易被猜到"
+		suggestions = append(suggestions, "避免使用与你相关的日期和年份")
+	}
+	return warning, suggestions
+}
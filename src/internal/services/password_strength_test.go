@@ -0,0 +1,94 @@
+package services
+
+import (
+	"testing"
+)
+
+/**
+ * TestEstimatePasswordStrength_Weak 测试常见弱密码
+ * 预期：常见密码、键盘图案、序列、重复、日期、l33t替换均被识别并给出低评分和警告
+ */
+func TestEstimatePasswordStrength_Weak(t *testing.T) {
+	cases := map[string]string{
+		"password":   StrengthPatternDictionary,
+		"P@ssw0rd":   StrengthPatternDictionary,
+		"qwertyuiop": StrengthPatternDictionary,
+		"zxcvfr":     StrengthPatternSpatial,
+		"abcdefgh":   StrengthPatternSequence,
+		"98765432":   StrengthPatternSequence,
+		"aaaaaaaa":   StrengthPatternRepeat,
+		"19900101":   StrengthPatternDate,
+		"drowssap":   StrengthPatternDictionary,
+	}
+
+	for password, pattern := range cases {
+		result := EstimatePasswordStrength(password, nil)
+		if result.Score > 1 {
+			t.Errorf("%s 评分应不高于1，实际%d", password, result.Score)
+		}
+		if result.Warning == "" {
+			t.Errorf("%s 应给出警告", password)
+		}
+		found := false
+		for _, m := range result.Sequence {
+			if m.Pattern == pattern {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("%s 应识别出%s模式，实际: %+v", password, pattern, result.Sequence)
+		}
+		if len(result.CrackTimes) != 4 {
+			t.Errorf("%s 应给出4种破解场景，实际%d", password, len(result.CrackTimes))
+		}
+	}
+}
+
+/**
+ * TestEstimatePasswordStrength_Strong 测试强密码
+ * 预期：长随机密码和多单词口令短语评分为4
+ */
+func TestEstimatePasswordStrength_Strong(t *testing.T) {
+	for _, password := range []string{
+		"k9#Tq2!vXw7$Lp4Z",
+		"correct horse battery staple velvet",
+	} {
+		result := EstimatePasswordStrength(password, nil)
+		if result.Score != 4 {
+			t.Errorf("%s 评分应为4，实际%d，猜测次数10^%.1f", password, result.Score, result.GuessesLog10)
+		}
+		if result.Warning != "" {
+			t.Errorf("%s 不应有警告: %s", password, result.Warning)
+		}
+	}
+}
+
+/**
+ * TestEstimatePasswordStrength_UserInputs 测试包含账号信息的密码
+ * 预期：密码包含用户名时评分降低
+ */
+func TestEstimatePasswordStrength_UserInputs(t *testing.T) {
+	password := "chenfengqing2024"
+	without := EstimatePasswordStrength(password, nil)
+	with := EstimatePasswordStrength(password, []string{"chenfengqing@example.com"})
+
+	if with.GuessesLog10 >= without.GuessesLog10 {
+		t.Errorf("包含用户名的密码猜测次数应更少: %.1f >= %.1f", with.GuessesLog10, without.GuessesLog10)
+	}
+	if with.Sequence[0].Dictionary != strengthDictUserInputs {
+		t.Errorf("应识别出账号相关信息，实际: %+v", with.Sequence[0])
+	}
+}
+
+/**
+ * TestEstimatePasswordStrength_Empty 测试空密码
+ */
+func TestEstimatePasswordStrength_Empty(t *testing.T) {
+	result := EstimatePasswordStrength("", nil)
+	if result.Score != 0 {
+		t.Errorf("空密码评分应为0，实际%d", result.Score)
+	}
+	if len(result.Suggestions) == 0 {
+		t.Error("空密码应给出建议")
+	}
+}
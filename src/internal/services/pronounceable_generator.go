@@ -0,0 +1,307 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+
+	"wepassword/internal/models"
+)
+
+/**
+ * 可发音密码生成器
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 按音节模型生成可发音密码：每个音节由辅音声母（单辅音或常见辅音组合）加元音韵腹（单元音或常见
+ *              双元音）组成，末尾可带一个辅音韵尾。声母只含辅音、韵腹只含元音，因此任一密码拆分成音节的方式
+ *              唯一，可以按长度精确统计所有可能的密码数量，并在其中均匀随机抽取
+ */
+
+// 音节组成部分，均为小写
+var (
+	// pronounceableOnsets 声母：LowerConsonants 去掉 q、x 后的单辅音及常见辅音组合
+	pronounceableOnsets = []string{
+		"b", "c", "d", "f", "g", "h", "j", "k", "l", "m", "n", "p", "r", "s", "t", "v", "w", "y", "z",
+		"bl", "br", "ch", "cl", "cr", "dr", "fl", "fr", "gl", "gr", "kl", "kr", "pl", "pr",
+		"sh", "sk", "sl", "sm", "sn", "sp", "st", "sw", "th", "tr", "tw", "wh", "spr", "str",
+	}
+
+	// pronounceableNuclei 韵腹：LowerVowels 中的单元音及常见双元音
+	pronounceableNuclei = []string{
+		"a", "e", "i", "o", "u",
+		"ai", "au", "ea", "ee", "ie", "oa", "oo", "ou",
+	}
+
+	// pronounceableCodas 韵尾：只出现在密码末尾，空字符串表示不带韵尾
+	pronounceableCodas = []string{
+		"",
+		"k", "l", "m", "n", "r", "s", "t",
+		"ck", "ld", "nd", "ng", "nt", "rd", "rt", "sh", "st", "th",
+	}
+)
+
+const (
+	// minPronounceableLetters 可发音部分的最少字母数（最短音节为两个字母）
+	minPronounceableLetters = 2
+
+	// maxPronounceableLength 可发音密码长度上限
+	maxPronounceableLength = 128
+)
+
+// syllableUnits 按长度分组的音节及韵尾
+type syllableUnits struct {
+	syllables map[int][]string // 长度 -> 音节列表
+	codas     map[int][]string // 长度 -> 韵尾列表
+	lengths   []int            // 音节长度（升序）
+	codaLens  []int            // 韵尾长度（升序）
+}
+
+var (
+	pronounceableUnits     *syllableUnits
+	pronounceableUnitsOnce sync.Once
+)
+
+/**
+ * getSyllableUnits 获取按长度分组的音节表
+ * @return *syllableUnits 音节表
+ */
+func getSyllableUnits() *syllableUnits {
+	pronounceableUnitsOnce.Do(func() {
+		units := &syllableUnits{
+			syllables: make(map[int][]string),
+			codas:     make(map[int][]string),
+		}
+		for _, onset := range pronounceableOnsets {
+			for _, nucleus := range pronounceableNuclei {
+				syllable := onset + nucleus
+				units.syllables[len(syllable)] = append(units.syllables[len(syllable)], syllable)
+			}
+		}
+		for _, coda := range pronounceableCodas {
+			units.codas[len(coda)] = append(units.codas[len(coda)], coda)
+		}
+		for length := range units.syllables {
+			units.lengths = append(units.lengths, length)
+		}
+		for length := range units.codas {
+			units.codaLens = append(units.codaLens, length)
+		}
+		sort.Ints(units.lengths)
+		sort.Ints(units.codaLens)
+		pronounceableUnits = units
+	})
+	return pronounceableUnits
+}
+
+/**
+ * pronounceableModel 指定字母数下的音节计数模型
+ * @description sequences[n] 为总长恰好为n的音节序列数（每个音节按大小写变化数加权），
+ *              total 为加上末尾韵尾后总长恰好为letters的密码数
+ */
+type pronounceableModel struct {
+	units     *syllableUnits
+	weight    int64 // 每个音节的大小写变化数：random 为2，其余为1
+	sequences []*big.Int
+	total     *big.Int
+}
+
+/**
+ * newPronounceableModel 创建音节计数模型
+ * @param letters 字母数
+ * @param randomCase 是否随机决定每个音节首字母大小写
+ * @return *pronounceableModel 计数模型
+ */
+func newPronounceableModel(letters int, randomCase bool) *pronounceableModel {
+	model := &pronounceableModel{
+		units:     getSyllableUnits(),
+		weight:    1,
+		sequences: make([]*big.Int, letters+1),
+		total:     new(big.Int),
+	}
+	if randomCase {
+		model.weight = 2
+	}
+
+	model.sequences[0] = big.NewInt(1)
+	for n := 1; n <= letters; n++ {
+		count := new(big.Int)
+		for _, length := range model.units.lengths {
+			if length > n {
+				break
+			}
+			ways := big.NewInt(int64(len(model.units.syllables[length])) * model.weight)
+			count.Add(count, ways.Mul(ways, model.sequences[n-length]))
+		}
+		model.sequences[n] = count
+	}
+
+	// 至少一个音节，末尾可带韵尾
+	for _, codaLen := range model.units.codaLens {
+		if letters-codaLen < 1 {
+			break
+		}
+		ways := big.NewInt(int64(len(model.units.codas[codaLen])))
+		model.total.Add(model.total, ways.Mul(ways, model.sequences[letters-codaLen]))
+	}
+
+	return model
+}
+
+/**
+ * decode 将 [0, total) 内的序号解码为可发音字符串
+ * @param letters 字母数
+ * @param index 序号，解码过程中会被修改
+ * @return []string 音节列表（末尾韵尾作为独立一项，可能为空字符串）
+ * @return []bool 各音节首字母是否随机大写（末尾韵尾始终为false）
+ * @description 序号按“韵尾长度 → 韵尾 → 依次每个音节”的混合进制展开，序号均匀时结果在所有可能的密码中均匀分布
+ */
+func (pm *pronounceableModel) decode(letters int, index *big.Int) ([]string, []bool) {
+	quotient := new(big.Int)
+
+	coda := ""
+	remaining := letters
+	for _, codaLen := range pm.units.codaLens {
+		if letters-codaLen < 1 {
+			break
+		}
+		codas := pm.units.codas[codaLen]
+		block := new(big.Int).Mul(big.NewInt(int64(len(codas))), pm.sequences[letters-codaLen])
+		if index.Cmp(block) < 0 {
+			quotient.DivMod(index, pm.sequences[letters-codaLen], index)
+			coda = codas[quotient.Int64()]
+			remaining = letters - codaLen
+			break
+		}
+		index.Sub(index, block)
+	}
+
+	var parts []string
+	var capitals []bool
+	for remaining > 0 {
+		for _, length := range pm.units.lengths {
+			if length > remaining {
+				break
+			}
+			syllables := pm.units.syllables[length]
+			block := big.NewInt(int64(len(syllables)) * pm.weight)
+			block.Mul(block, pm.sequences[remaining-length])
+			if index.Cmp(block) < 0 {
+				quotient.DivMod(index, pm.sequences[remaining-length], index)
+				choice := quotient.Int64()
+				parts = append(parts, syllables[choice/pm.weight])
+				capitals = append(capitals, choice%pm.weight == 1)
+				remaining -= length
+				break
+			}
+			index.Sub(index, block)
+		}
+	}
+
+	return append(parts, coda), append(capitals, false)
+}
+
+/**
+ * generatePronounceablePassword 生成可发音密码规则密码
+ * @param configJSON 配置JSON字符串
+ * @return string 生成的密码
+ * @return error 错误信息
+ */
+func (prs *PasswordRuleService) generatePronounceablePassword(configJSON string) (string, error) {
+	var config models.PronounceableRuleConfig
+	if err := json.Unmarshal([]byte(configJSON), &config); err != nil {
+		return "", fmt.Errorf("解析可发音密码规则配置失败: %w", err)
+	}
+
+	if err := prs.validatePronounceableConfig(config); err != nil {
+		return "", err
+	}
+
+	letters := config.Length - config.DigitCount - config.SymbolCount
+	model := newPronounceableModel(letters, config.Capitalization == "random")
+	index, err := rand.Int(rand.Reader, model.total)
+	if err != nil {
+		return "", fmt.Errorf("生成随机序号失败: %w", err)
+	}
+
+	parts, capitals := model.decode(letters, index)
+	for i, part := range parts {
+		if part == "" {
+			continue
+		}
+		switch {
+		case config.Capitalization == "upper":
+			parts[i] = strings.ToUpper(part)
+		case config.Capitalization == "syllable" && i < len(parts)-1,
+			config.Capitalization == "title" && i == 0,
+			capitals[i]:
+			parts[i] = strings.ToUpper(part[:1]) + part[1:]
+		}
+	}
+	password := []rune(strings.Join(parts, ""))
+
+	// 插入数字和符号：每个字符依次插入到当前密码的随机位置
+	symbols := config.Symbols
+	if symbols == "" {
+		symbols = DefaultPassphraseSymbols
+	}
+	extras := make([]rune, 0, config.DigitCount+config.SymbolCount)
+	for i := 0; i < config.DigitCount; i++ {
+		char, err := prs.getRandomChar(Digits)
+		if err != nil {
+			return "", err
+		}
+		extras = append(extras, char)
+	}
+	for i := 0; i < config.SymbolCount; i++ {
+		char, err := prs.getRandomChar(symbols)
+		if err != nil {
+			return "", err
+		}
+		extras = append(extras, char)
+	}
+	for _, extra := range extras {
+		index, err := rand.Int(rand.Reader, big.NewInt(int64(len(password)+1)))
+		if err != nil {
+			return "", fmt.Errorf("生成随机位置失败: %w", err)
+		}
+		pos := int(index.Int64())
+		password = append(password[:pos], append([]rune{extra}, password[pos:]...)...)
+	}
+
+	return string(password), nil
+}
+
+/**
+ * validatePronounceableConfig 验证可发音密码规则配置
+ * @param config 可发音密码规则配置
+ * @return error 错误信息
+ */
+func (prs *PasswordRuleService) validatePronounceableConfig(config models.PronounceableRuleConfig) error {
+	if config.Length <= 0 {
+		return fmt.Errorf("密码长度必须大于0")
+	}
+
+	if config.Length > maxPronounceableLength {
+		return fmt.Errorf("密码长度不能超过%d", maxPronounceableLength)
+	}
+
+	if config.DigitCount < 0 || config.SymbolCount < 0 {
+		return fmt.Errorf("插入的数字和符号个数不能为负数")
+	}
+
+	if config.Length-config.DigitCount-config.SymbolCount < minPronounceableLetters {
+		return fmt.Errorf("插入数字和符号后可发音部分不足%d个字母，请增加长度或减少插入的字符", minPronounceableLetters)
+	}
+
+	switch config.Capitalization {
+	case "", "lower", "upper", "title", "syllable", "random":
+	default:
+		return fmt.Errorf("不支持的大小写模式: %s", config.Capitalization)
+	}
+
+	return nil
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"encoding/json"
+	"math"
+	"math/big"
+	"regexp"
+	"strings"
+	"testing"
+	"unicode"
+
+	"wepassword/internal/models"
+)
+
+// 可发音部分的结构：若干“辅音+元音”音节，末尾可带辅音韵尾
+var pronounceablePattern = regexp.MustCompile(`^(?i)([bcdfghjklmnprstvwyz]+[aeiou]+)+[cdghklmnrst]*$`)
+
+/**
+ * TestGeneratePronounceablePassword 测试可发音密码生成
+ * 预期：总长度、插入的数字和符号个数符合配置，去掉插入字符后符合音节结构，每个音节首字母大写
+ */
+func TestGeneratePronounceablePassword(t *testing.T) {
+	prs := &PasswordRuleService{}
+
+	config := models.PronounceableRuleConfig{
+		Length:         14,
+		Capitalization: "syllable",
+		DigitCount:     2,
+		SymbolCount:    1,
+		Symbols:        "!",
+	}
+	configJSON, _ := json.Marshal(config)
+
+	for i := 0; i < 50; i++ {
+		password, err := prs.generatePronounceablePassword(string(configJSON))
+		if err != nil {
+			t.Fatalf("生成可发音密码失败: %v", err)
+		}
+		if len(password) != config.Length {
+			t.Fatalf("期望长度%d，实际%d: %s", config.Length, len(password), password)
+		}
+
+		digits := 0
+		symbols := 0
+		var letters strings.Builder
+		for _, r := range password {
+			switch {
+			case unicode.IsDigit(r):
+				digits++
+			case r == '!':
+				symbols++
+			default:
+				letters.WriteRune(r)
+			}
+		}
+		if digits != config.DigitCount || symbols != config.SymbolCount {
+			t.Errorf("数字或符号个数不符: %s", password)
+		}
+
+		core := letters.String()
+		if !pronounceablePattern.MatchString(core) {
+			t.Errorf("可发音部分不符合音节结构: %s", core)
+		}
+		if !unicode.IsUpper(rune(core[0])) {
+			t.Errorf("音节首字母应大写: %s", core)
+		}
+	}
+}
+
+/**
+ * TestPronounceableModel_Decode 测试音节计数模型
+ * 预期：4个字母时所有序号解码出的密码互不相同且长度正确，数量与手工计算一致
+ */
+func TestPronounceableModel_Decode(t *testing.T) {
+	const letters = 4
+	model := newPronounceableModel(letters, false)
+
+	// 2字母音节95个、3字母282个、4字母218个；S(2)=95、S(3)=282、S(4)=95×95+218=9243
+	// 总数 = S(4) + 7×S(3) + 10×S(2)（不带韵尾、1字母韵尾7个、2字母韵尾10个）
+	if model.total.Int64() != 12167 {
+		t.Fatalf("期望12167种组合，实际%s", model.total)
+	}
+
+	seen := make(map[string]bool)
+	for i := int64(0); i < model.total.Int64(); i++ {
+		parts, _ := model.decode(letters, big.NewInt(i))
+		password := strings.Join(parts, "")
+		if len(password) != letters {
+			t.Fatalf("序号%d解码长度错误: %s", i, password)
+		}
+		if !pronounceablePattern.MatchString(password) {
+			t.Fatalf("序号%d解码结果不符合音节结构: %s", i, password)
+		}
+		if seen[password] {
+			t.Fatalf("序号%d解码结果重复: %s", i, password)
+		}
+		seen[password] = true
+	}
+}
+
+/**
+ * TestPronounceableEntropy 测试可发音密码熵值
+ * 预期：无插入字符时等于log2(组合数)，随机大小写和插入字符都会增加熵值，配置无效时报错
+ */
+func TestPronounceableEntropy(t *testing.T) {
+	prs := &PasswordRuleService{}
+
+	bits, err := prs.pronounceableEntropy(models.PronounceableRuleConfig{Length: 4})
+	if err != nil {
+		t.Fatalf("计算熵值失败: %v", err)
+	}
+	if math.Abs(bits-math.Log2(12167)) > 1e-9 {
+		t.Errorf("期望熵值%.4f，实际%.4f", math.Log2(12167), bits)
+	}
+
+	plain, _ := prs.pronounceableEntropy(models.PronounceableRuleConfig{Length: 16})
+	random, _ := prs.pronounceableEntropy(models.PronounceableRuleConfig{Length: 16, Capitalization: "random"})
+	if random <= plain {
+		t.Errorf("随机大小写熵值(%.2f)应大于小写(%.2f)", random, plain)
+	}
+
+	// 1个数字插入到4个字母中：4字母组合数 × 10 × 5个位置
+	withDigit, _ := prs.pronounceableEntropy(models.PronounceableRuleConfig{Length: 5, DigitCount: 1})
+	if expected := math.Log2(12167 * 10 * 5); math.Abs(withDigit-expected) > 1e-9 {
+		t.Errorf("期望熵值%.4f，实际%.4f", expected, withDigit)
+	}
+
+	invalid := []models.PronounceableRuleConfig{
+		{Length: 0},
+		{Length: 129},
+		{Length: 4, DigitCount: 3},
+		{Length: 8, Capitalization: "camel"},
+	}
+	for _, config := range invalid {
+		if _, err := prs.pronounceableEntropy(config); err == nil {
+			t.Errorf("无效配置应返回错误: %+v", config)
+		}
+	}
+}
@@ -0,0 +1,561 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"wepassword/internal/crypto"
+	"wepassword/internal/database"
+	"wepassword/internal/models"
+	"wepassword/internal/utils"
+)
+
+/**
+ * 密码轮换钩子服务
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 为自有基础设施（数据库用户、Linux账号等）的账号绑定本地命令，修改密码时由UpdateAccount执行：
+ *              标准输入依次写入旧密码和新密码（各占一行），环境变量提供账号ID、标题、用户名和地址，
+ *              退出码为0时才保存新密码，非0或超时则不修改账号。
+ *              命令直接执行不经过shell，每次执行都记录到rotation_hook_runs表作为审计记录，
+ *              输出中出现的旧密码和新密码替换为星号后保存。
+ *              20251021 陈凤庆 执行钩子前先把新密码加密保存为账号的待提交轮换（account_rotation），
+ *              钩子成功后再写入账号并删除轮换状态；写入账号前中断时新密码不会丢失，可以提交该轮换
+ */
+
+const (
+	defaultRotationHookTimeout = 30  // 默认超时时间（秒）
+	maxRotationHookTimeout     = 600 // 最长超时时间（秒）
+	maxRotationHookOutput      = 64 * 1024
+	rotationHookWaitDelay      = 2 * time.Second // 超时结束进程后等待输出关闭的时间
+	rotationHookMask           = "******"
+)
+
+/**
+ * RotationHookService 密码轮换钩子服务
+ */
+type RotationHookService struct {
+	dbManager *database.DatabaseManager
+}
+
+/**
+ * NewRotationHookService 创建密码轮换钩子服务
+ * @param dbManager 数据库管理器
+ * @return *RotationHookService 密码轮换钩子服务实例
+ */
+func NewRotationHookService(dbManager *database.DatabaseManager) *RotationHookService {
+	return &RotationHookService{
+		dbManager: dbManager,
+	}
+}
+
+/**
+ * GetHook 获取账号的轮换钩子
+ * @param accountID 账号ID
+ * @return *models.RotationHook 轮换钩子，未设置时为nil
+ * @return error 错误信息
+ */
+func (rhs *RotationHookService) GetHook(accountID string) (*models.RotationHook, error) {
+	if !rhs.dbManager.IsOpened() {
+		return nil, fmt.Errorf("数据库未打开")
+	}
+	return loadRotationHook(rhs.dbManager.GetDB(), accountID)
+}
+
+/**
+ * GetHooks 获取所有轮换钩子
+ * @return []models.RotationHook 轮换钩子列表
+ * @return error 错误信息
+ */
+func (rhs *RotationHookService) GetHooks() ([]models.RotationHook, error) {
+	if !rhs.dbManager.IsOpened() {
+		return nil, fmt.Errorf("数据库未打开")
+	}
+
+	rows, err := rhs.dbManager.GetDB().Query(`
+		SELECT account_id, command, args, timeout_seconds, enabled, updated_at
+		FROM rotation_hooks
+		ORDER BY updated_at
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("查询轮换钩子失败: %w", err)
+	}
+	defer rows.Close()
+
+	hooks := make([]models.RotationHook, 0)
+	for rows.Next() {
+		hook, err := scanRotationHook(rows)
+		if err != nil {
+			return nil, err
+		}
+		hooks = append(hooks, hook)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("读取轮换钩子失败: %w", err)
+	}
+	return hooks, nil
+}
+
+/**
+ * SetHook 设置账号的轮换钩子
+ * @param hook 轮换钩子，超时时间为0时使用默认值
+ * @return models.RotationHook 保存后的轮换钩子
+ * @return error 命令不存在或超时时间超出范围时返回错误
+ */
+func (rhs *RotationHookService) SetHook(hook models.RotationHook) (models.RotationHook, error) {
+	if !rhs.dbManager.IsOpened() {
+		return models.RotationHook{}, fmt.Errorf("数据库未打开")
+	}
+
+	hook.Command = strings.TrimSpace(hook.Command)
+	if hook.Command == "" {
+		return models.RotationHook{}, fmt.Errorf("钩子命令不能为空")
+	}
+	if _, err := exec.LookPath(hook.Command); err != nil {
+		return models.RotationHook{}, fmt.Errorf("钩子命令不可执行: %w", err)
+	}
+	if hook.TimeoutSeconds == 0 {
+		hook.TimeoutSeconds = defaultRotationHookTimeout
+	}
+	if hook.TimeoutSeconds < 0 || hook.TimeoutSeconds > maxRotationHookTimeout {
+		return models.RotationHook{}, fmt.Errorf("超时时间应在1到%d秒之间", maxRotationHookTimeout)
+	}
+	if hook.Args == nil {
+		hook.Args = []string{}
+	}
+
+	db := rhs.dbManager.GetDB()
+	var exists int
+	if err := db.QueryRow("SELECT COUNT(*) FROM accounts WHERE id = ?", hook.AccountID).Scan(&exists); err != nil {
+		return models.RotationHook{}, fmt.Errorf("查询账号失败: %w", err)
+	}
+	if exists == 0 {
+		return models.RotationHook{}, fmt.Errorf("账号不存在: %s", hook.AccountID)
+	}
+
+	args, err := json.Marshal(hook.Args)
+	if err != nil {
+		return models.RotationHook{}, fmt.Errorf("序列化命令参数失败: %w", err)
+	}
+	hook.UpdatedAt = time.Now()
+	_, err = db.Exec(`
+		INSERT INTO rotation_hooks (account_id, command, args, timeout_seconds, enabled, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(account_id) DO UPDATE SET command = excluded.command, args = excluded.args,
+			timeout_seconds = excluded.timeout_seconds, enabled = excluded.enabled, updated_at = excluded.updated_at
+	`, hook.AccountID, hook.Command, string(args), hook.TimeoutSeconds, hook.Enabled, hook.UpdatedAt)
+	if err != nil {
+		return models.RotationHook{}, fmt.Errorf("保存轮换钩子失败: %w", err)
+	}
+	return hook, nil
+}
+
+/**
+ * DeleteHook 删除账号的轮换钩子，执行记录保留
+ * @param accountID 账号ID
+ * @return error 错误信息
+ */
+func (rhs *RotationHookService) DeleteHook(accountID string) error {
+	if !rhs.dbManager.IsOpened() {
+		return fmt.Errorf("数据库未打开")
+	}
+	if _, err := rhs.dbManager.GetDB().Exec("DELETE FROM rotation_hooks WHERE account_id = ?", accountID); err != nil {
+		return fmt.Errorf("删除轮换钩子失败: %w", err)
+	}
+	return nil
+}
+
+/**
+ * GetHookRuns 获取轮换钩子的执行记录
+ * @param accountID 账号ID，为空时返回所有账号的记录
+ * @param limit 最多返回的记录数，小于等于0时不限制
+ * @return []models.RotationHookRun 执行记录，最近的在前
+ * @return error 错误信息
+ */
+func (rhs *RotationHookService) GetHookRuns(accountID string, limit int) ([]models.RotationHookRun, error) {
+	if !rhs.dbManager.IsOpened() {
+		return nil, fmt.Errorf("数据库未打开")
+	}
+
+	query := `
+		SELECT id, account_id, title, command, started_at, duration_ms, exit_code, success, timed_out, output, error
+		FROM rotation_hook_runs`
+	args := make([]interface{}, 0, 2)
+	if accountID != "" {
+		query += " WHERE account_id = ?"
+		args = append(args, accountID)
+	}
+	query += " ORDER BY started_at DESC"
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	rows, err := rhs.dbManager.GetDB().Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询钩子执行记录失败: %w", err)
+	}
+	defer rows.Close()
+
+	runs := make([]models.RotationHookRun, 0)
+	for rows.Next() {
+		var run models.RotationHookRun
+		if err := rows.Scan(&run.ID, &run.AccountID, &run.Title, &run.Command, &run.StartedAt, &run.DurationMs,
+			&run.ExitCode, &run.Success, &run.TimedOut, &run.Output, &run.Error); err != nil {
+			return nil, fmt.Errorf("扫描钩子执行记录失败: %w", err)
+		}
+		runs = append(runs, run)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("读取钩子执行记录失败: %w", err)
+	}
+	return runs, nil
+}
+
+/**
+ * runAccountRotationHook 修改密码前执行账号的轮换钩子并记录审计
+ * @param db 数据库连接
+ * @param cryptoManager 加密管理器，用于加密待提交的新密码
+ * @param account 修改后的账号（解密后）
+ * @param oldPassword 旧密码
+ * @return bool 新密码是否已保存为待提交轮换，为true时调用方写入账号后应删除轮换状态
+ * @return error 钩子执行失败时返回错误，调用方不应保存新密码；账号未设置或未启用钩子时返回false和nil
+ * @description 新密码先保存为待提交轮换再执行钩子。账号已有待提交的其他新密码时拒绝修改；
+ *              待提交的就是这个新密码且之后钩子已执行成功（如上次写入账号前中断）时不再重复执行。
+ *              钩子超时时目标系统可能已经修改，保留待提交轮换，由用户确认后提交或回滚
+ */
+func runAccountRotationHook(db *sql.DB, cryptoManager *crypto.CryptoManager, account models.AccountDecrypted, oldPassword string) (bool, error) {
+	hook, err := loadRotationHook(db, account.ID)
+	if err != nil {
+		return false, err
+	}
+	if hook == nil || !hook.Enabled {
+		return false, nil
+	}
+
+	startedAt, created, err := stageRotationPassword(db, cryptoManager, account.ID, account.Password)
+	if err != nil {
+		return false, err
+	}
+	applied, err := rotationHookSucceededSince(db, account.ID, startedAt)
+	if err != nil {
+		return false, err
+	}
+	if applied {
+		return true, nil
+	}
+
+	run := executeRotationHook(*hook, account, oldPassword, account.Password)
+	if err := saveRotationHookRun(db, run); err != nil {
+		return false, err
+	}
+	if run.Success {
+		return true, nil
+	}
+
+	if run.TimedOut {
+		return false, fmt.Errorf("轮换钩子执行失败，新密码已保存为待提交的密码轮换，请确认目标系统后提交或回滚: %s", run.Error)
+	}
+	if created {
+		if _, err := db.Exec("DELETE FROM account_rotation WHERE account_id = ?", account.ID); err != nil {
+			return false, fmt.Errorf("删除待提交的新密码失败: %w", err)
+		}
+	}
+	return false, fmt.Errorf("轮换钩子执行失败，未保存新密码: %s", run.Error)
+}
+
+/**
+ * stageRotationPassword 把新密码保存为账号的待提交轮换
+ * @param db 数据库连接
+ * @param cryptoManager 加密管理器
+ * @param accountID 账号ID
+ * @param password 新密码
+ * @return time.Time 轮换开始时间
+ * @return bool 是否新建了轮换状态，账号已有待提交的同一新密码时为false
+ * @return error 账号已有待提交的其他新密码时返回错误
+ */
+func stageRotationPassword(db *sql.DB, cryptoManager *crypto.CryptoManager, accountID, password string) (time.Time, bool, error) {
+	var encrypted string
+	var startedAt time.Time
+	err := db.QueryRow("SELECT pending_password, started_at FROM account_rotation WHERE account_id = ?", accountID).Scan(&encrypted, &startedAt)
+	switch {
+	case err == nil:
+		pending, err := cryptoManager.Decrypt(encrypted)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("解密待提交的新密码失败: %w", err)
+		}
+		if pending != password {
+			return time.Time{}, false, fmt.Errorf("账号已有未完成的密码轮换，请先提交或回滚")
+		}
+		return startedAt, false, nil
+	case !errors.Is(err, sql.ErrNoRows):
+		return time.Time{}, false, fmt.Errorf("查询密码轮换失败: %w", err)
+	}
+
+	encrypted, err = cryptoManager.Encrypt(password)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("加密新密码失败: %w", err)
+	}
+	startedAt = time.Now()
+	_, err = db.Exec(`
+		INSERT INTO account_rotation (account_id, pending_password, rule_id, started_at)
+		VALUES (?, ?, '', ?)
+	`, accountID, encrypted, startedAt)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("保存待提交的新密码失败: %w", err)
+	}
+	return startedAt, true, nil
+}
+
+/**
+ * rotationHookSucceededSince 判断账号的轮换钩子在指定时间之后是否执行成功过
+ * @param db 数据库连接
+ * @param accountID 账号ID
+ * @param since 起始时间
+ * @return bool 是否执行成功过
+ * @return error 错误信息
+ */
+func rotationHookSucceededSince(db *sql.DB, accountID string, since time.Time) (bool, error) {
+	var startedAt time.Time
+	err := db.QueryRow(`
+		SELECT started_at FROM rotation_hook_runs
+		WHERE account_id = ? AND success = 1
+		ORDER BY started_at DESC LIMIT 1
+	`, accountID).Scan(&startedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("查询钩子执行记录失败: %w", err)
+	}
+	return !startedAt.Before(since), nil
+}
+
+/**
+ * executeRotationHook 执行轮换钩子
+ * @param hook 轮换钩子
+ * @param account 账号（解密后），用于设置环境变量
+ * @param oldPassword 旧密码
+ * @param newPassword 新密码
+ * @return models.RotationHookRun 执行记录
+ */
+func executeRotationHook(hook models.RotationHook, account models.AccountDecrypted, oldPassword, newPassword string) models.RotationHookRun {
+	timeout := hook.TimeoutSeconds
+	if timeout <= 0 {
+		timeout = defaultRotationHookTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, hook.Command, hook.Args...)
+	cmd.Stdin = strings.NewReader(oldPassword + "\n" + newPassword + "\n")
+	cmd.Env = append(os.Environ(),
+		"WEPASS_ACCOUNT_ID="+account.ID,
+		"WEPASS_ACCOUNT_TITLE="+account.Title,
+		"WEPASS_USERNAME="+account.Username,
+		"WEPASS_URL="+account.URL,
+	)
+	// 多保留两个密码长度的输出，保证截断处的密码也能完整替换
+	output := &limitedBuffer{limit: maxRotationHookOutput + len(oldPassword) + len(newPassword)}
+	cmd.Stdout = output
+	cmd.Stderr = output
+	cmd.WaitDelay = rotationHookWaitDelay
+
+	run := models.RotationHookRun{
+		ID:        utils.GenerateGUID(),
+		AccountID: account.ID,
+		Title:     account.Title,
+		Command:   strings.Join(append([]string{hook.Command}, hook.Args...), " "),
+		StartedAt: time.Now(),
+		ExitCode:  -1,
+	}
+	err := cmd.Run()
+	run.DurationMs = time.Since(run.StartedAt).Milliseconds()
+	// 缓冲区末尾可能正好截断在密码中间，截断时末尾的半个密码也一并替换
+	run.Output = maskPasswordsTruncated(output.String(), output.truncated, oldPassword, newPassword)
+	if output.truncated || len(run.Output) > maxRotationHookOutput {
+		run.Output = truncateUTF8(run.Output, maxRotationHookOutput) + "\n...(输出过长，已截断)"
+	}
+
+	var exitErr *exec.ExitError
+	switch {
+	case ctx.Err() == context.DeadlineExceeded:
+		run.TimedOut = true
+		run.Error = fmt.Sprintf("执行超时（%d秒）", timeout)
+	case errors.As(err, &exitErr):
+		run.ExitCode = exitErr.ExitCode()
+		run.Error = fmt.Sprintf("退出码为%d", run.ExitCode)
+	case err != nil:
+		run.Error = maskPasswords(err.Error(), oldPassword, newPassword)
+	default:
+		run.ExitCode = 0
+		run.Success = true
+	}
+	return run
+}
+
+/**
+ * loadRotationHook 读取账号的轮换钩子
+ * @param db 数据库连接
+ * @param accountID 账号ID
+ * @return *models.RotationHook 轮换钩子，未设置时为nil
+ * @return error 错误信息
+ */
+func loadRotationHook(db *sql.DB, accountID string) (*models.RotationHook, error) {
+	row := db.QueryRow(`
+		SELECT account_id, command, args, timeout_seconds, enabled, updated_at
+		FROM rotation_hooks
+		WHERE account_id = ?
+	`, accountID)
+	hook, err := scanRotationHook(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &hook, nil
+}
+
+/**
+ * scanRotationHook 扫描一行轮换钩子
+ * @param scanner sql.Row或sql.Rows
+ * @return models.RotationHook 轮换钩子
+ * @return error 错误信息，没有记录时包装sql.ErrNoRows
+ */
+func scanRotationHook(scanner interface{ Scan(...interface{}) error }) (models.RotationHook, error) {
+	var hook models.RotationHook
+	var args string
+	if err := scanner.Scan(&hook.AccountID, &hook.Command, &args, &hook.TimeoutSeconds, &hook.Enabled, &hook.UpdatedAt); err != nil {
+		return models.RotationHook{}, fmt.Errorf("查询轮换钩子失败: %w", err)
+	}
+	if err := json.Unmarshal([]byte(args), &hook.Args); err != nil {
+		return models.RotationHook{}, fmt.Errorf("解析命令参数失败: %w", err)
+	}
+	return hook, nil
+}
+
+/**
+ * saveRotationHookRun 保存钩子执行记录
+ * @param db 数据库连接
+ * @param run 执行记录
+ * @return error 错误信息
+ */
+func saveRotationHookRun(db *sql.DB, run models.RotationHookRun) error {
+	_, err := db.Exec(`
+		INSERT INTO rotation_hook_runs (id, account_id, title, command, started_at, duration_ms, exit_code, success, timed_out, output, error)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, run.ID, run.AccountID, run.Title, run.Command, run.StartedAt, run.DurationMs, run.ExitCode, run.Success, run.TimedOut, run.Output, run.Error)
+	if err != nil {
+		return fmt.Errorf("保存钩子执行记录失败: %w", err)
+	}
+	return nil
+}
+
+/**
+ * maskPasswords 将文本中出现的密码替换为星号
+ * @param text 文本
+ * @param passwords 需要隐藏的密码
+ * @return string 替换后的文本
+ */
+func maskPasswords(text string, passwords ...string) string {
+	return maskPasswordsTruncated(text, false, passwords...)
+}
+
+/**
+ * maskPasswordsTruncated 将文本中出现的密码替换为星号
+ * @param text 文本
+ * @param truncated 文本是否在末尾被截断
+ * @param passwords 需要隐藏的密码
+ * @return string 替换后的文本
+ * @description 先标记所有密码出现的位置（包括互相重叠、一个密码包含另一个的情况），再把每段连续的标记替换为星号；
+ *              文本被截断时末尾与密码开头部分相同的内容也视为密码，避免截断处残留半个密码
+ */
+func maskPasswordsTruncated(text string, truncated bool, passwords ...string) string {
+	covered := make([]bool, len(text))
+	mark := func(start, end int) {
+		for i := start; i < end; i++ {
+			covered[i] = true
+		}
+	}
+	for _, password := range passwords {
+		if password == "" {
+			continue
+		}
+		for i := 0; i < len(text); {
+			j := strings.Index(text[i:], password)
+			if j < 0 {
+				break
+			}
+			mark(i+j, i+j+len(password))
+			i += j + 1
+		}
+		if truncated {
+			for n := min(len(password)-1, len(text)); n > 0; n-- {
+				if strings.HasSuffix(text, password[:n]) {
+					mark(len(text)-n, len(text))
+					break
+				}
+			}
+		}
+	}
+
+	var masked strings.Builder
+	for i := 0; i < len(text); i++ {
+		if !covered[i] {
+			masked.WriteByte(text[i])
+		} else if i == 0 || !covered[i-1] {
+			masked.WriteString(rotationHookMask)
+		}
+	}
+	return masked.String()
+}
+
+/**
+ * truncateUTF8 截断文本到指定字节数，不截断多字节字符
+ * @param text 文本
+ * @param limit 最大字节数
+ * @return string 截断后的文本
+ */
+func truncateUTF8(text string, limit int) string {
+	if len(text) <= limit {
+		return text
+	}
+	for limit > 0 && !utf8.RuneStart(text[limit]) {
+		limit--
+	}
+	return text[:limit]
+}
+
+/**
+ * limitedBuffer 限制大小的输出缓冲区，超出部分丢弃
+ */
+type limitedBuffer struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (lb *limitedBuffer) Write(p []byte) (int, error) {
+	if remaining := lb.limit - lb.buf.Len(); remaining < len(p) {
+		lb.truncated = true
+		if remaining > 0 {
+			lb.buf.Write(p[:remaining])
+		}
+		return len(p), nil
+	}
+	return lb.buf.Write(p)
+}
+
+func (lb *limitedBuffer) String() string {
+	return lb.buf.String()
+}
@@ -0,0 +1,289 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"wepassword/internal/models"
+)
+
+/**
+ * 密码轮换钩子测试
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 使用shell脚本作为钩子，测试退出码决定是否保存新密码、超时、输出脱敏、
+ *              钩子成功后写入账号前中断的恢复以及批量轮换
+ */
+
+// writeHookScript 写入测试用的钩子脚本
+func writeHookScript(t *testing.T, body string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("钩子测试使用shell脚本")
+	}
+	path := filepath.Join(t.TempDir(), "hook.sh")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0700); err != nil {
+		t.Fatalf("写入钩子脚本失败: %v", err)
+	}
+	return path
+}
+
+func TestRotationHook_UpdateAccount(t *testing.T) {
+	accountService, typeID := newTestAccountService(t)
+	rhs := NewRotationHookService(accountService.dbManager)
+
+	account, err := accountService.CreateAccount("PostgreSQL", "app", "Old#Pass2024", "", typeID, "", 1)
+	if err != nil {
+		t.Fatalf("创建账号失败: %v", err)
+	}
+
+	// 钩子读取旧密码和新密码，新密码以Fail开头时失败
+	script := writeHookScript(t, `read old; read new
+echo "user=$WEPASS_USERNAME old=$old new=$new"
+case "$new" in Fail*) echo "拒绝" >&2; exit 3;; esac`)
+	if _, err := rhs.SetHook(models.RotationHook{AccountID: account.ID, Command: script, Enabled: true}); err != nil {
+		t.Fatalf("设置钩子失败: %v", err)
+	}
+	if _, err := rhs.SetHook(models.RotationHook{AccountID: account.ID, Command: "/nonexistent/hook"}); err == nil {
+		t.Error("命令不存在时应返回错误")
+	}
+
+	account.Password = "Fail#Pass2025"
+	if err := accountService.UpdateAccount(account); err == nil {
+		t.Fatal("钩子失败时应返回错误")
+	}
+	current, _ := accountService.GetAccountByID(account.ID)
+	if current.Password != "Old#Pass2024" {
+		t.Errorf("钩子失败时不应保存新密码: %s", current.Password)
+	}
+	prs := NewPasswordRotationService(accountService.dbManager, accountService)
+	if rotation, _ := prs.GetRotation(account.ID); rotation != nil {
+		t.Errorf("钩子明确失败时不应保留待提交的新密码: %+v", rotation)
+	}
+
+	account.Password = "New#Pass2025"
+	if err := accountService.UpdateAccount(account); err != nil {
+		t.Fatalf("钩子成功时应保存新密码: %v", err)
+	}
+
+	// 只修改标题不执行钩子
+	account.Title = "PostgreSQL生产库"
+	if err := accountService.UpdateAccount(account); err != nil {
+		t.Fatalf("更新账号失败: %v", err)
+	}
+
+	runs, err := rhs.GetHookRuns(account.ID, 0)
+	if err != nil || len(runs) != 2 {
+		t.Fatalf("应有2条执行记录: %+v, %v", runs, err)
+	}
+	failed, succeeded := runs[1], runs[0]
+	if failed.Success || failed.ExitCode != 3 || !strings.Contains(failed.Output, "拒绝") {
+		t.Errorf("失败记录错误: %+v", failed)
+	}
+	if !succeeded.Success || succeeded.ExitCode != 0 || succeeded.Output != "user=app old=****** new=******\n" {
+		t.Errorf("成功记录错误: %+v", succeeded)
+	}
+	if rotation, _ := prs.GetRotation(account.ID); rotation != nil {
+		t.Errorf("新密码写入账号后应删除待提交轮换: %+v", rotation)
+	}
+
+	// 删除账号时删除钩子，保留执行记录
+	if err := accountService.DeleteAccount(account.ID); err != nil {
+		t.Fatalf("删除账号失败: %v", err)
+	}
+	if hook, _ := rhs.GetHook(account.ID); hook != nil {
+		t.Errorf("删除账号后应删除钩子: %+v", hook)
+	}
+	if runs, _ := rhs.GetHookRuns("", 0); len(runs) != 2 {
+		t.Errorf("删除账号后应保留执行记录: %d", len(runs))
+	}
+}
+
+func TestRotationHook_StagedBeforeHook(t *testing.T) {
+	accountService, typeID := newTestAccountService(t)
+	rhs := NewRotationHookService(accountService.dbManager)
+	prs := NewPasswordRotationService(accountService.dbManager, accountService)
+
+	account, err := accountService.CreateAccount("LDAP", "svc", "Old#Pass2024", "", typeID, "", 1)
+	if err != nil {
+		t.Fatalf("创建账号失败: %v", err)
+	}
+	calls := filepath.Join(t.TempDir(), "calls")
+	script := writeHookScript(t, `read old; read new; echo "$new" >> "`+calls+`"`)
+	if _, err := rhs.SetHook(models.RotationHook{AccountID: account.ID, Command: script, Enabled: true}); err != nil {
+		t.Fatalf("设置钩子失败: %v", err)
+	}
+
+	// 模拟钩子成功后、写入账号前中断：新密码已保存为待提交轮换
+	account.Password = "New#Pass2025"
+	staged, err := runAccountRotationHook(accountService.dbManager.GetDB(), accountService.cryptoManager, account, "Old#Pass2024")
+	if err != nil || !staged {
+		t.Fatalf("钩子应执行成功并保存待提交轮换: %v, %v", staged, err)
+	}
+	rotation, _ := prs.GetRotation(account.ID)
+	if rotation == nil || rotation.PendingPassword != "New#Pass2025" {
+		t.Fatalf("执行钩子前应保存新密码: %+v", rotation)
+	}
+
+	// 待提交其他新密码时拒绝修改
+	other := account
+	other.Password = "Other#Pass2025"
+	if err := accountService.UpdateAccount(other); err == nil {
+		t.Error("已有待提交的其他新密码时应返回错误")
+	}
+
+	// 提交轮换时不重复执行已成功的钩子
+	if err := prs.CommitRotation(account.ID); err != nil {
+		t.Fatalf("提交轮换失败: %v", err)
+	}
+	current, _ := accountService.GetAccountByID(account.ID)
+	if current.Password != "New#Pass2025" {
+		t.Errorf("提交后应使用新密码: %s", current.Password)
+	}
+	data, _ := os.ReadFile(calls)
+	if string(data) != "New#Pass2025\n" {
+		t.Errorf("钩子应只执行一次: %q", data)
+	}
+}
+
+func TestMaskPasswords(t *testing.T) {
+	// 旧密码是新密码的一部分时先替换较长的新密码
+	if masked := maskPasswords("old=abc new=abc123", "abc", "abc123"); masked != "old=****** new=******" {
+		t.Errorf("脱敏结果错误: %s", masked)
+	}
+
+	// 输出超过上限时先脱敏再截断，截断处的密码不会泄露
+	secret := "S3cret#Password"
+	script := writeHookScript(t, `read old; read new; head -c `+strconv.Itoa(maxRotationHookOutput-5)+` /dev/zero | tr '\0' a; echo "$new"`)
+	run := executeRotationHook(models.RotationHook{Command: script}, models.AccountDecrypted{ID: "a"}, "old", secret)
+	if !run.Success || strings.Contains(run.Output, secret[:5]) || !strings.Contains(run.Output, "已截断") {
+		t.Errorf("截断处的密码应被替换: %q", run.Output[len(run.Output)-64:])
+	}
+}
+
+func TestExecuteRotationHook_RepeatedPasswordOutput(t *testing.T) {
+	// 钩子反复输出新密码直到超过上限，缓冲区末尾截断的半个密码也不能保存
+	secret := "Zq7#Vx9!Wp"
+	script := writeHookScript(t, `read old; read new; i=0; while [ $i -lt 10000 ]; do printf '%s' "$new"; i=$((i+1)); done`)
+	run := executeRotationHook(models.RotationHook{Command: script}, models.AccountDecrypted{ID: "a"}, "old", secret)
+	if !run.Success || !strings.Contains(run.Output, "已截断") {
+		t.Fatalf("输出应被截断: %+v", run.Error)
+	}
+	body := strings.TrimSuffix(run.Output, "\n...(输出过长，已截断)")
+	if rest := strings.ReplaceAll(body, rotationHookMask, ""); strings.ContainsAny(rest, secret) {
+		t.Errorf("输出中残留密码片段: %q", rest)
+	}
+}
+
+func TestMaskPasswordsTruncated(t *testing.T) {
+	if masked := maskPasswordsTruncated("log secretpa", true, "old", "secretpass"); masked != "log ******" {
+		t.Errorf("末尾的半个密码应替换: %q", masked)
+	}
+	if masked := maskPasswordsTruncated("log secretpass", true, "secretpass"); masked != "log ******" {
+		t.Errorf("完整的密码应替换: %q", masked)
+	}
+	if masked := maskPasswordsTruncated("log secretpa", false, "secretpass"); masked != "log secretpa" {
+		t.Errorf("未截断时不应替换部分内容: %q", masked)
+	}
+}
+
+func TestExecuteRotationHook_Timeout(t *testing.T) {
+	script := writeHookScript(t, "sleep 5")
+	hook := models.RotationHook{Command: script, TimeoutSeconds: 1}
+
+	start := time.Now()
+	run := executeRotationHook(hook, models.AccountDecrypted{ID: "a"}, "old", "new")
+	if run.Success || !run.TimedOut || run.ExitCode != -1 {
+		t.Errorf("应超时失败: %+v", run)
+	}
+	if time.Since(start) > 4*time.Second {
+		t.Errorf("超时后应结束进程，实际耗时%v", time.Since(start))
+	}
+}
+
+func TestBulkRotationScheduler(t *testing.T) {
+	accountService, typeID := newTestAccountService(t)
+	rhs := NewRotationHookService(accountService.dbManager)
+	prs := NewPasswordRotationService(accountService.dbManager, accountService)
+	scheduler := NewBulkRotationScheduler(accountService, prs, rhs)
+
+	script := writeHookScript(t, `read old; read new
+[ "$WEPASS_ACCOUNT_TITLE" != "Redis" ]`)
+	titles := []string{"MySQL", "Redis", "LDAP"}
+	for _, title := range titles {
+		account, err := accountService.CreateAccount(title, "svc", "Old#"+title, "", typeID, "", 1)
+		if err != nil {
+			t.Fatalf("创建账号失败: %v", err)
+		}
+		if _, err := rhs.SetHook(models.RotationHook{AccountID: account.ID, Command: script, Enabled: true}); err != nil {
+			t.Fatalf("设置钩子失败: %v", err)
+		}
+	}
+
+	generated := 0
+	generate := func(string) (string, string, error) {
+		generated++
+		return "Generated#" + strings.Repeat("x", generated), "rule-1", nil
+	}
+	if _, err := scheduler.Schedule(nil, time.Now().Add(time.Hour), 0, generate); err != nil {
+		t.Fatalf("安排批量轮换失败: %v", err)
+	}
+	if _, err := scheduler.Schedule(nil, time.Now(), 0, generate); err == nil {
+		t.Error("已有未结束的任务时应返回错误")
+	}
+	if err := scheduler.Cancel(); err != nil || scheduler.GetJob().Status != BulkRotationCancelled {
+		t.Fatalf("取消任务失败: %v", err)
+	}
+
+	if _, err := scheduler.Schedule(nil, time.Now(), 0, generate); err != nil {
+		t.Fatalf("安排批量轮换失败: %v", err)
+	}
+	deadline := time.Now().Add(10 * time.Second)
+	job := scheduler.GetJob()
+	for job.Status != BulkRotationCompleted && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+		job = scheduler.GetJob()
+	}
+	if job.Status != BulkRotationCompleted || len(job.Results) != 3 {
+		t.Fatalf("批量轮换未完成: %+v", job)
+	}
+
+	for _, result := range job.Results {
+		if result.Success != (result.Title != "Redis") {
+			t.Errorf("%s 结果错误: %+v", result.Title, result)
+		}
+	}
+	// 失败的账号保留轮换状态
+	pending, _ := prs.GetPendingRotations()
+	if len(pending) != 1 || pending[0].Title != "Redis" {
+		t.Fatalf("失败的账号应保留轮换状态: %+v", pending)
+	}
+
+	// 再次批量轮换时不处理已有进行中轮换的账号
+	if _, err := scheduler.Schedule([]string{pending[0].AccountID}, time.Now(), 0, generate); err != nil {
+		t.Fatalf("安排批量轮换失败: %v", err)
+	}
+	for job = scheduler.GetJob(); job.Status != BulkRotationCompleted; job = scheduler.GetJob() {
+		time.Sleep(20 * time.Millisecond)
+	}
+	if len(job.Results) != 1 || job.Results[0].Success {
+		t.Errorf("已有进行中轮换的账号应失败: %+v", job.Results)
+	}
+	if again, _ := prs.GetRotation(pending[0].AccountID); again == nil || again.PendingPassword != pending[0].PendingPassword {
+		t.Errorf("不应修改进行中的轮换: %+v", again)
+	}
+
+	// 指定未启用钩子的账号时拒绝安排
+	plain, err := accountService.CreateAccount("无钩子", "svc", "Old#Plain", "", typeID, "", 1)
+	if err != nil {
+		t.Fatalf("创建账号失败: %v", err)
+	}
+	if _, err := scheduler.Schedule([]string{plain.ID}, time.Now(), 0, generate); err == nil {
+		t.Error("指定未启用钩子的账号时应返回错误")
+	}
+}
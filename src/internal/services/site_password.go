@@ -0,0 +1,253 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/hkdf"
+
+	"wepassword/internal/models"
+)
+
+/**
+ * 确定性站点密码
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 类似LessPass的无状态密码：由主密码、站点、登录名和计数器经Argon2id（内存困难型KDF）
+ *              派生密钥，再用HKDF展开为确定性随机流，按通用规则的字符类别、最小位数、排除字符和
+ *              连续/顺序约束生成密码。相同输入在任何设备上都得到相同密码，计数器加1即可轮换
+ */
+
+// Argon2id 参数，修改后所有站点密码都会改变，因此带版本号固定下来
+const (
+	sitePasswordVersion = "wepassword-site-v1"
+	sitePasswordTime    = 3         // 迭代次数
+	sitePasswordMemory  = 64 * 1024 // 内存开销（KiB），即64MB
+	sitePasswordThreads = 4         // 并行度
+	sitePasswordKeyLen  = 32        // 派生密钥长度
+)
+
+/**
+ * GenerateSitePassword 根据规则确定性生成站点密码
+ * @param masterPassword 主密码
+ * @param profile 站点密码参数
+ * @return string 生成的密码
+ * @return error 错误信息
+ */
+func (prs *PasswordRuleService) GenerateSitePassword(masterPassword string, profile models.SitePasswordProfile) (string, error) {
+	rule, err := prs.GetRuleByID(profile.RuleID)
+	if err != nil {
+		return "", fmt.Errorf("获取密码规则失败: %w", err)
+	}
+
+	if rule.RuleType != "general" {
+		return "", fmt.Errorf("确定性站点密码只支持通用规则，当前规则类型: %s", rule.RuleType)
+	}
+
+	var config models.GeneralRuleConfig
+	if err := json.Unmarshal([]byte(rule.Config), &config); err != nil {
+		return "", fmt.Errorf("解析通用规则配置失败: %w", err)
+	}
+
+	return prs.GenerateSitePasswordByConfig(masterPassword, profile, config)
+}
+
+/**
+ * RotateSitePassword 将计数器加1并生成新的站点密码
+ * @param masterPassword 主密码
+ * @param profile 当前站点密码参数
+ * @return string 新密码
+ * @return models.SitePasswordProfile 计数器加1后的参数，调用方需保存新的计数器
+ * @return error 错误信息
+ */
+func (prs *PasswordRuleService) RotateSitePassword(masterPassword string, profile models.SitePasswordProfile) (string, models.SitePasswordProfile, error) {
+	if profile.Counter < 1 {
+		profile.Counter = 1
+	}
+	profile.Counter++
+
+	password, err := prs.GenerateSitePassword(masterPassword, profile)
+	if err != nil {
+		return "", profile, err
+	}
+	return password, profile, nil
+}
+
+/**
+ * GenerateSitePasswordByConfig 根据通用规则配置确定性生成站点密码
+ * @param masterPassword 主密码
+ * @param profile 站点密码参数（RuleID不使用）
+ * @param config 通用规则配置
+ * @return string 生成的密码
+ * @return error 错误信息
+ */
+func (prs *PasswordRuleService) GenerateSitePasswordByConfig(masterPassword string, profile models.SitePasswordProfile, config models.GeneralRuleConfig) (string, error) {
+	if masterPassword == "" {
+		return "", fmt.Errorf("主密码不能为空")
+	}
+
+	site := normalizeSite(profile.Site)
+	if site == "" {
+		return "", fmt.Errorf("站点不能为空")
+	}
+
+	if profile.Counter < 1 {
+		return "", fmt.Errorf("计数器必须大于0")
+	}
+
+	if err := prs.validateGeneralConfig(config); err != nil {
+		return "", err
+	}
+
+	charSets, minCounts, err := prs.buildGeneralCharSets(config)
+	if err != nil {
+		return "", err
+	}
+
+	constraints := passwordCharConstraints{
+		maxConsecutive:  config.MaxConsecutive,
+		forbidSequences: config.ForbidSequences,
+	}
+
+	key := deriveSiteKey(masterPassword, site, profile.Login, profile.Counter)
+
+	// 每次尝试使用独立的确定性随机流，不满足字符约束时按序号继续尝试，结果仍然确定
+	for attempt := 0; attempt < maxConstraintAttempts; attempt++ {
+		picker := &deterministicPicker{
+			reader: hkdf.Expand(sha256.New, key, []byte(fmt.Sprintf("%s/attempt/%d", sitePasswordVersion, attempt))),
+		}
+
+		password, err := picker.generate(charSets, minCounts, config.Length)
+		if err != nil {
+			return "", err
+		}
+		if checkCharConstraints(password, constraints) == nil {
+			return password, nil
+		}
+	}
+
+	return "", fmt.Errorf("尝试%d次仍无法生成满足字符约束的密码，请放宽连续重复或顺序字符限制", maxConstraintAttempts)
+}
+
+/**
+ * normalizeSite 规范化站点，网址只保留主机名，统一小写并去掉www.前缀
+ * @param site 站点
+ * @return string 规范化后的站点
+ */
+func normalizeSite(site string) string {
+	site = strings.TrimSpace(site)
+	if strings.Contains(site, "://") {
+		if parsed, err := url.Parse(site); err == nil && parsed.Hostname() != "" {
+			site = parsed.Hostname()
+		}
+	}
+	site = strings.ToLower(site)
+	return strings.TrimPrefix(site, "www.")
+}
+
+/**
+ * deriveSiteKey 使用Argon2id派生站点密钥
+ * @param masterPassword 主密码
+ * @param site 规范化后的站点
+ * @param login 登录名
+ * @param counter 计数器
+ * @return []byte 派生密钥
+ * @description 盐值由版本号、站点、登录名和计数器按长度前缀拼接，避免不同组合产生相同盐值
+ */
+func deriveSiteKey(masterPassword, site, login string, counter int) []byte {
+	var salt []byte
+	for _, part := range []string{sitePasswordVersion, site, login} {
+		salt = binary.BigEndian.AppendUint32(salt, uint32(len(part)))
+		salt = append(salt, part...)
+	}
+	salt = binary.BigEndian.AppendUint64(salt, uint64(counter))
+
+	return argon2.IDKey([]byte(masterPassword), salt, sitePasswordTime, sitePasswordMemory, sitePasswordThreads, sitePasswordKeyLen)
+}
+
+/**
+ * deterministicPicker 基于确定性随机流的均匀选择器
+ */
+type deterministicPicker struct {
+	reader io.Reader
+}
+
+/**
+ * intn 返回[0, n)内均匀分布的整数，使用拒绝采样避免取模偏差
+ * @param n 上界
+ * @return int 随机整数
+ * @return error 随机流耗尽时返回错误
+ */
+func (dp *deterministicPicker) intn(n int) (int, error) {
+	if n <= 0 {
+		return 0, fmt.Errorf("无效的随机范围: %d", n)
+	}
+
+	limit := (1 << 32) - (1<<32)%uint64(n)
+	var buf [4]byte
+	for {
+		if _, err := io.ReadFull(dp.reader, buf[:]); err != nil {
+			return 0, fmt.Errorf("读取确定性随机流失败: %w", err)
+		}
+		value := uint64(binary.BigEndian.Uint32(buf[:]))
+		if value < limit {
+			return int(value % uint64(n)), nil
+		}
+	}
+}
+
+/**
+ * generate 与 generateShuffledPassword 相同的算法：先满足各字符集最小数量，再填充并打乱
+ * @param charSets 字符集列表
+ * @param minCounts 最小数量列表
+ * @param totalLength 总长度
+ * @return string 生成的密码
+ * @return error 错误信息
+ */
+func (dp *deterministicPicker) generate(charSets []string, minCounts []int, totalLength int) (string, error) {
+	pick := func(charset []rune) (rune, error) {
+		index, err := dp.intn(len(charset))
+		if err != nil {
+			return 0, err
+		}
+		return charset[index], nil
+	}
+
+	var password []rune
+	var allChars []rune
+	for i, charset := range charSets {
+		chars := []rune(charset)
+		for j := 0; j < minCounts[i]; j++ {
+			char, err := pick(chars)
+			if err != nil {
+				return "", err
+			}
+			password = append(password, char)
+		}
+		allChars = append(allChars, chars...)
+	}
+
+	for len(password) < totalLength {
+		char, err := pick(allChars)
+		if err != nil {
+			return "", err
+		}
+		password = append(password, char)
+	}
+
+	for i := len(password) - 1; i > 0; i-- {
+		j, err := dp.intn(i + 1)
+		if err != nil {
+			return "", err
+		}
+		password[i], password[j] = password[j], password[i]
+	}
+
+	return string(password), nil
+}
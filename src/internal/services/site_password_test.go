@@ -0,0 +1,149 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"wepassword/internal/models"
+)
+
+/**
+ * 确定性站点密码测试
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 测试站点密码的确定性、计数器轮换、站点规范化和字符类别约束
+ */
+
+func testSiteConfig() models.GeneralRuleConfig {
+	return models.GeneralRuleConfig{
+		IncludeUppercase:    true,
+		IncludeLowercase:    true,
+		IncludeNumbers:      true,
+		IncludeSpecialChars: true,
+		Length:              16,
+		MinUppercase:        2,
+		MinLowercase:        2,
+		MinNumbers:          2,
+		MinSpecialChars:     2,
+		ExcludeAmbiguous:    true,
+		MaxConsecutive:      2,
+		ForbidSequences:     true,
+	}
+}
+
+func TestGenerateSitePassword_Deterministic(t *testing.T) {
+	prs := &PasswordRuleService{}
+	config := testSiteConfig()
+	profile := models.SitePasswordProfile{Site: "example.com", Login: "alice", Counter: 1}
+
+	first, err := prs.GenerateSitePasswordByConfig("master", profile, config)
+	if err != nil {
+		t.Fatalf("生成站点密码失败: %v", err)
+	}
+	second, err := prs.GenerateSitePasswordByConfig("master", profile, config)
+	if err != nil {
+		t.Fatalf("生成站点密码失败: %v", err)
+	}
+	if first != second {
+		t.Errorf("相同输入应生成相同密码: %s != %s", first, second)
+	}
+
+	if len(first) != config.Length {
+		t.Errorf("期望长度%d，实际%d", config.Length, len(first))
+	}
+	if err := prs.validateGeneratedPassword(first, config); err != nil {
+		t.Errorf("站点密码不满足规则: %v", err)
+	}
+
+	// 网址与域名规范化后相同
+	urlProfile := profile
+	urlProfile.Site = "https://WWW.Example.com/login?next=/"
+	viaURL, err := prs.GenerateSitePasswordByConfig("master", urlProfile, config)
+	if err != nil {
+		t.Fatalf("生成站点密码失败: %v", err)
+	}
+	if viaURL != first {
+		t.Errorf("规范化后的站点应生成相同密码: %s != %s", viaURL, first)
+	}
+}
+
+func TestGenerateSitePassword_InputsChangePassword(t *testing.T) {
+	prs := &PasswordRuleService{}
+	config := testSiteConfig()
+	base := models.SitePasswordProfile{Site: "example.com", Login: "alice", Counter: 1}
+
+	basePassword, err := prs.GenerateSitePasswordByConfig("master", base, config)
+	if err != nil {
+		t.Fatalf("生成站点密码失败: %v", err)
+	}
+
+	variants := map[string]func() (string, error){
+		"计数器": func() (string, error) {
+			p := base
+			p.Counter = 2
+			return prs.GenerateSitePasswordByConfig("master", p, config)
+		},
+		"站点": func() (string, error) {
+			p := base
+			p.Site = "example.org"
+			return prs.GenerateSitePasswordByConfig("master", p, config)
+		},
+		"登录名": func() (string, error) {
+			p := base
+			p.Login = "bob"
+			return prs.GenerateSitePasswordByConfig("master", p, config)
+		},
+		"主密码": func() (string, error) {
+			return prs.GenerateSitePasswordByConfig("master2", base, config)
+		},
+	}
+
+	for name, generate := range variants {
+		password, err := generate()
+		if err != nil {
+			t.Fatalf("%s: 生成站点密码失败: %v", name, err)
+		}
+		if password == basePassword {
+			t.Errorf("改变%s后应生成不同密码", name)
+		}
+	}
+}
+
+func TestGenerateSitePassword_Invalid(t *testing.T) {
+	prs := &PasswordRuleService{}
+	config := testSiteConfig()
+
+	cases := map[string]struct {
+		master  string
+		profile models.SitePasswordProfile
+	}{
+		"空主密码":  {"", models.SitePasswordProfile{Site: "example.com", Counter: 1}},
+		"空站点":   {"master", models.SitePasswordProfile{Site: "  ", Counter: 1}},
+		"计数器为0": {"master", models.SitePasswordProfile{Site: "example.com", Counter: 0}},
+	}
+
+	for name, c := range cases {
+		if _, err := prs.GenerateSitePasswordByConfig(c.master, c.profile, config); err == nil {
+			t.Errorf("%s: 期望返回错误", name)
+		}
+	}
+}
+
+func TestNormalizeSite(t *testing.T) {
+	cases := map[string]string{
+		"Example.COM":                    "example.com",
+		"  www.example.com ":             "example.com",
+		"https://accounts.Example.com/x": "accounts.example.com",
+		"http://www.example.com:8080/":   "example.com",
+	}
+
+	for input, expected := range cases {
+		if actual := normalizeSite(input); actual != expected {
+			t.Errorf("normalizeSite(%q) = %q，期望 %q", input, actual, expected)
+		}
+	}
+
+	if strings.Contains(normalizeSite("https://example.com/path"), "/") {
+		t.Error("规范化结果不应包含路径")
+	}
+}
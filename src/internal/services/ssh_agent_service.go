@@ -0,0 +1,452 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+
+	"wepassword/internal/logger"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+/**
+ * SSH代理服务
+ * @author 陈凤庆
+ * @date 20251020
+ * @description 内置ssh-agent，通过Unix套接字对外提供密码库中保存的SSH私钥，
+ *              支持逐次确认、有效期约束，密码库锁定或关闭时自动移除全部私钥
+ */
+
+// privateKeyPEMPattern 从备注等文本中提取PEM格式私钥
+var privateKeyPEMPattern = regexp.MustCompile(`(?s)-----BEGIN [A-Z0-9 ]*PRIVATE KEY-----.*?-----END [A-Z0-9 ]*PRIVATE KEY-----`)
+
+// ErrSSHAgentConfirmRejected 用户拒绝了私钥使用确认
+var ErrSSHAgentConfirmRejected = errors.New("用户拒绝使用该私钥")
+
+/**
+ * SSHKeyConstraints 私钥约束
+ */
+type SSHKeyConstraints struct {
+	ConfirmBeforeUse bool   `json:"confirm_before_use"` // 每次使用前需要用户确认
+	LifetimeSecs     uint32 `json:"lifetime_secs"`      // 有效期（秒），0表示不过期
+}
+
+/**
+ * SSHAgentKey SSH代理中的私钥信息（不包含私钥内容）
+ */
+type SSHAgentKey struct {
+	Fingerprint      string `json:"fingerprint"`        // SHA256指纹
+	Type             string `json:"type"`               // 密钥类型，如 ssh-ed25519
+	Comment          string `json:"comment"`            // 注释（通常为账号标题）
+	AccountID        string `json:"account_id"`         // 来源账号ID（客户端ssh-add添加的为空）
+	ConfirmBeforeUse bool   `json:"confirm_before_use"` // 是否需要确认
+}
+
+/**
+ * SSHAgentConfirmFunc 私钥使用确认回调
+ * @param key 即将使用的私钥信息
+ * @return bool 是否允许使用
+ */
+type SSHAgentConfirmFunc func(key SSHAgentKey) bool
+
+/**
+ * SSHAgentService SSH代理服务
+ * @description 实现 agent.ExtendedAgent 接口，在内置keyring基础上增加确认约束
+ */
+type SSHAgentService struct {
+	mu          sync.Mutex
+	keyring     agent.ExtendedAgent
+	keys        map[string]SSHAgentKey // 公钥（Marshal后）-> 私钥信息
+	listener    net.Listener
+	socketPath  string
+	confirmFunc SSHAgentConfirmFunc
+}
+
+/**
+ * NewSSHAgentService 创建SSH代理服务
+ * @return *SSHAgentService SSH代理服务实例
+ */
+func NewSSHAgentService() *SSHAgentService {
+	return &SSHAgentService{
+		keyring: agent.NewKeyring().(agent.ExtendedAgent),
+		keys:    make(map[string]SSHAgentKey),
+	}
+}
+
+/**
+ * SetConfirmFunc 设置私钥使用确认回调
+ * @param confirmFunc 确认回调，为nil时需要确认的私钥一律拒绝
+ */
+func (sas *SSHAgentService) SetConfirmFunc(confirmFunc SSHAgentConfirmFunc) {
+	sas.mu.Lock()
+	defer sas.mu.Unlock()
+	sas.confirmFunc = confirmFunc
+}
+
+/**
+ * Start 在指定Unix套接字上启动SSH代理
+ * @param socketPath 套接字路径
+ * @return error 错误信息
+ */
+func (sas *SSHAgentService) Start(socketPath string) error {
+	sas.mu.Lock()
+	defer sas.mu.Unlock()
+
+	if sas.listener != nil {
+		return fmt.Errorf("SSH代理已在运行: %s", sas.socketPath)
+	}
+
+	if socketPath == "" {
+		return fmt.Errorf("套接字路径不能为空")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(socketPath), 0700); err != nil {
+		return fmt.Errorf("创建套接字目录失败: %w", err)
+	}
+
+	// 清理上次异常退出遗留的套接字文件
+	if _, err := os.Stat(socketPath); err == nil {
+		if err := os.Remove(socketPath); err != nil {
+			return fmt.Errorf("清理旧套接字失败: %w", err)
+		}
+	}
+
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return fmt.Errorf("监听套接字失败: %w", err)
+	}
+
+	// 仅允许当前用户访问
+	if err := os.Chmod(socketPath, 0600); err != nil {
+		listener.Close()
+		return fmt.Errorf("设置套接字权限失败: %w", err)
+	}
+
+	sas.listener = listener
+	sas.socketPath = socketPath
+
+	go sas.acceptLoop(listener)
+
+	logger.Info("[SSH代理] SSH代理已启动: %s", socketPath)
+	return nil
+}
+
+/**
+ * acceptLoop 接受客户端连接
+ * @param listener 监听器
+ */
+func (sas *SSHAgentService) acceptLoop(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			logger.Error("[SSH代理] 接受连接失败: %v", err)
+			return
+		}
+
+		go func(c net.Conn) {
+			defer c.Close()
+			if err := agent.ServeAgent(sas, c); err != nil && !errors.Is(err, net.ErrClosed) {
+				logger.Debug("[SSH代理] 连接结束: %v", err)
+			}
+		}(conn)
+	}
+}
+
+/**
+ * Stop 停止SSH代理并移除全部私钥
+ * @return error 错误信息
+ */
+func (sas *SSHAgentService) Stop() error {
+	sas.mu.Lock()
+	listener := sas.listener
+	socketPath := sas.socketPath
+	sas.listener = nil
+	sas.socketPath = ""
+	sas.mu.Unlock()
+
+	if err := sas.RemoveAll(); err != nil {
+		logger.Error("[SSH代理] 移除私钥失败: %v", err)
+	}
+
+	if listener == nil {
+		return nil
+	}
+
+	if err := listener.Close(); err != nil {
+		return fmt.Errorf("关闭套接字失败: %w", err)
+	}
+	os.Remove(socketPath)
+
+	logger.Info("[SSH代理] SSH代理已停止")
+	return nil
+}
+
+/**
+ * IsRunning 检查SSH代理是否在运行
+ * @return bool 是否在运行
+ */
+func (sas *SSHAgentService) IsRunning() bool {
+	sas.mu.Lock()
+	defer sas.mu.Unlock()
+	return sas.listener != nil
+}
+
+/**
+ * GetSocketPath 获取当前套接字路径
+ * @return string 套接字路径（未运行时为空）
+ */
+func (sas *SSHAgentService) GetSocketPath() string {
+	sas.mu.Lock()
+	defer sas.mu.Unlock()
+	return sas.socketPath
+}
+
+/**
+ * ExtractPrivateKeyPEM 从文本（如账号备注）中提取PEM格式私钥
+ * @param text 文本
+ * @return string PEM私钥，未找到时为空
+ */
+func ExtractPrivateKeyPEM(text string) string {
+	return privateKeyPEMPattern.FindString(text)
+}
+
+/**
+ * AddVaultKey 添加密码库中的私钥
+ * @param accountID 来源账号ID
+ * @param comment 注释
+ * @param privateKeyPEM PEM格式私钥
+ * @param passphrase 私钥口令（私钥未加密时忽略）
+ * @param constraints 私钥约束
+ * @return SSHAgentKey 添加的私钥信息
+ * @return error 错误信息
+ */
+func (sas *SSHAgentService) AddVaultKey(accountID, comment, privateKeyPEM, passphrase string, constraints SSHKeyConstraints) (SSHAgentKey, error) {
+	if privateKeyPEM == "" {
+		return SSHAgentKey{}, fmt.Errorf("未找到私钥")
+	}
+
+	privateKey, err := ssh.ParseRawPrivateKey([]byte(privateKeyPEM))
+	var missing *ssh.PassphraseMissingError
+	if errors.As(err, &missing) {
+		privateKey, err = ssh.ParseRawPrivateKeyWithPassphrase([]byte(privateKeyPEM), []byte(passphrase))
+	}
+	if err != nil {
+		return SSHAgentKey{}, fmt.Errorf("解析私钥失败: %w", err)
+	}
+
+	addedKey := agent.AddedKey{
+		PrivateKey:       privateKey,
+		Comment:          comment,
+		LifetimeSecs:     constraints.LifetimeSecs,
+		ConfirmBeforeUse: constraints.ConfirmBeforeUse,
+	}
+	if err := sas.Add(addedKey); err != nil {
+		return SSHAgentKey{}, err
+	}
+
+	signer, err := ssh.NewSignerFromKey(privateKey)
+	if err != nil {
+		return SSHAgentKey{}, fmt.Errorf("生成签名器失败: %w", err)
+	}
+
+	sas.mu.Lock()
+	blob := string(signer.PublicKey().Marshal())
+	info := sas.keys[blob]
+	info.AccountID = accountID
+	sas.keys[blob] = info
+	sas.mu.Unlock()
+
+	logger.Info("[SSH代理] 已添加密码库私钥: %s (%s)，确认=%t，有效期=%d秒",
+		info.Fingerprint, comment, constraints.ConfirmBeforeUse, constraints.LifetimeSecs)
+	return info, nil
+}
+
+/**
+ * RemoveByFingerprint 按指纹移除私钥
+ * @param fingerprint SHA256指纹
+ * @return error 错误信息
+ */
+func (sas *SSHAgentService) RemoveByFingerprint(fingerprint string) error {
+	keys, err := sas.keyring.List()
+	if err != nil {
+		return fmt.Errorf("获取私钥列表失败: %w", err)
+	}
+
+	for _, key := range keys {
+		if ssh.FingerprintSHA256(key) == fingerprint {
+			return sas.Remove(key)
+		}
+	}
+	return fmt.Errorf("私钥不存在: %s", fingerprint)
+}
+
+/**
+ * ListKeys 获取当前代理中的私钥信息
+ * @return []SSHAgentKey 私钥信息列表
+ */
+func (sas *SSHAgentService) ListKeys() []SSHAgentKey {
+	result := make([]SSHAgentKey, 0)
+
+	keys, err := sas.keyring.List()
+	if err != nil {
+		return result
+	}
+
+	sas.mu.Lock()
+	defer sas.mu.Unlock()
+
+	// 20251020 陈凤庆 代理锁定时keyring返回空列表，这里不能据此清理附加信息，
+	// 否则解锁后找不到确认约束，需要确认的私钥会被直接用于签名；附加信息只在移除私钥时清理
+	for _, key := range keys {
+		if info, ok := sas.keys[string(key.Marshal())]; ok {
+			result = append(result, info)
+		}
+	}
+
+	return result
+}
+
+// ---- agent.ExtendedAgent 接口实现 ----
+
+/**
+ * List 返回代理中的身份列表
+ */
+func (sas *SSHAgentService) List() ([]*agent.Key, error) {
+	return sas.keyring.List()
+}
+
+/**
+ * Sign 使用私钥签名（需要确认的私钥会先请求用户确认）
+ */
+func (sas *SSHAgentService) Sign(key ssh.PublicKey, data []byte) (*ssh.Signature, error) {
+	return sas.SignWithFlags(key, data, 0)
+}
+
+/**
+ * SignWithFlags 使用私钥签名（带签名标志）
+ */
+func (sas *SSHAgentService) SignWithFlags(key ssh.PublicKey, data []byte, flags agent.SignatureFlags) (*ssh.Signature, error) {
+	if err := sas.confirmUse(key); err != nil {
+		return nil, err
+	}
+	return sas.keyring.SignWithFlags(key, data, flags)
+}
+
+/**
+ * confirmUse 对需要确认的私钥请求用户确认
+ * @param key 公钥
+ * @return error 拒绝时返回错误
+ */
+func (sas *SSHAgentService) confirmUse(key ssh.PublicKey) error {
+	sas.mu.Lock()
+	info, ok := sas.keys[string(key.Marshal())]
+	confirmFunc := sas.confirmFunc
+	sas.mu.Unlock()
+
+	if !ok || !info.ConfirmBeforeUse {
+		return nil
+	}
+
+	if confirmFunc == nil || !confirmFunc(info) {
+		logger.Info("[SSH代理] 私钥使用被拒绝: %s", info.Fingerprint)
+		return ErrSSHAgentConfirmRejected
+	}
+
+	logger.Info("[SSH代理] 私钥使用已确认: %s", info.Fingerprint)
+	return nil
+}
+
+/**
+ * Add 添加私钥（也用于响应客户端 ssh-add 请求）
+ */
+func (sas *SSHAgentService) Add(key agent.AddedKey) error {
+	if err := sas.keyring.Add(key); err != nil {
+		return fmt.Errorf("添加私钥失败: %w", err)
+	}
+
+	signer, err := ssh.NewSignerFromKey(key.PrivateKey)
+	if err != nil {
+		return fmt.Errorf("生成签名器失败: %w", err)
+	}
+
+	pub := signer.PublicKey()
+	sas.mu.Lock()
+	sas.keys[string(pub.Marshal())] = SSHAgentKey{
+		Fingerprint:      ssh.FingerprintSHA256(pub),
+		Type:             pub.Type(),
+		Comment:          key.Comment,
+		ConfirmBeforeUse: key.ConfirmBeforeUse,
+	}
+	sas.mu.Unlock()
+
+	return nil
+}
+
+/**
+ * Remove 移除指定私钥
+ */
+func (sas *SSHAgentService) Remove(key ssh.PublicKey) error {
+	if err := sas.keyring.Remove(key); err != nil {
+		return err
+	}
+
+	sas.mu.Lock()
+	delete(sas.keys, string(key.Marshal()))
+	sas.mu.Unlock()
+
+	logger.Info("[SSH代理] 已移除私钥: %s", ssh.FingerprintSHA256(key))
+	return nil
+}
+
+/**
+ * RemoveAll 移除全部私钥
+ */
+func (sas *SSHAgentService) RemoveAll() error {
+	if err := sas.keyring.RemoveAll(); err != nil {
+		return err
+	}
+
+	sas.mu.Lock()
+	sas.keys = make(map[string]SSHAgentKey)
+	sas.mu.Unlock()
+
+	logger.Info("[SSH代理] 已移除全部私钥")
+	return nil
+}
+
+/**
+ * Lock 锁定代理
+ */
+func (sas *SSHAgentService) Lock(passphrase []byte) error {
+	return sas.keyring.Lock(passphrase)
+}
+
+/**
+ * Unlock 解锁代理
+ */
+func (sas *SSHAgentService) Unlock(passphrase []byte) error {
+	return sas.keyring.Unlock(passphrase)
+}
+
+/**
+ * Signers 返回全部签名器（不经过确认，仅供进程内使用）
+ */
+func (sas *SSHAgentService) Signers() ([]ssh.Signer, error) {
+	return sas.keyring.Signers()
+}
+
+/**
+ * Extension 扩展请求（不支持）
+ */
+func (sas *SSHAgentService) Extension(extensionType string, contents []byte) ([]byte, error) {
+	return nil, agent.ErrExtensionUnsupported
+}
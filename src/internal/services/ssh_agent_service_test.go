@@ -0,0 +1,120 @@
+package services
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+/**
+ * SSH代理服务测试
+ * @author 陈凤庆
+ * @date 20251020
+ * @description 测试通过Unix套接字列出、签名、确认约束、锁定解锁及全部移除
+ */
+
+/**
+ * newTestSSHKeyPEM 生成测试用的ed25519 PEM私钥
+ */
+func newTestSSHKeyPEM(t *testing.T) string {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("生成私钥失败: %v", err)
+	}
+	block, err := ssh.MarshalPrivateKey(priv, "test")
+	if err != nil {
+		t.Fatalf("序列化私钥失败: %v", err)
+	}
+	return "备注前缀\n" + string(pem.EncodeToMemory(block)) + "备注后缀"
+}
+
+func TestSSHAgentService_ServeAndConfirm(t *testing.T) {
+	sas := NewSSHAgentService()
+	socketPath := filepath.Join(t.TempDir(), "agent.sock")
+	if err := sas.Start(socketPath); err != nil {
+		t.Fatalf("启动SSH代理失败: %v", err)
+	}
+	defer sas.Stop()
+
+	pemKey := ExtractPrivateKeyPEM(newTestSSHKeyPEM(t))
+	if pemKey == "" {
+		t.Fatal("应能从备注中提取私钥")
+	}
+
+	key, err := sas.AddVaultKey("account-1", "服务器", pemKey, "", SSHKeyConstraints{ConfirmBeforeUse: true})
+	if err != nil {
+		t.Fatalf("添加私钥失败: %v", err)
+	}
+	if key.AccountID != "account-1" || key.Type != ssh.KeyAlgoED25519 {
+		t.Errorf("私钥信息错误: %+v", key)
+	}
+
+	conn, err := net.Dial("unix", socketPath)
+	if err != nil {
+		t.Fatalf("连接SSH代理失败: %v", err)
+	}
+	defer conn.Close()
+	client := agent.NewClient(conn)
+
+	keys, err := client.List()
+	if err != nil || len(keys) != 1 {
+		t.Fatalf("客户端应看到1个私钥，实际: %d, 错误: %v", len(keys), err)
+	}
+
+	// 未设置确认回调时，需要确认的私钥应被拒绝
+	if _, err := client.Sign(keys[0], []byte("data")); err == nil {
+		t.Error("未确认时签名应失败")
+	}
+
+	sas.SetConfirmFunc(func(k SSHAgentKey) bool { return k.AccountID == "account-1" })
+	if _, err := client.Sign(keys[0], []byte("data")); err != nil {
+		t.Errorf("确认后签名应成功: %v", err)
+	}
+
+	if err := sas.RemoveAll(); err != nil {
+		t.Fatalf("移除全部私钥失败: %v", err)
+	}
+	keys, _ = client.List()
+	if len(keys) != 0 || len(sas.ListKeys()) != 0 {
+		t.Error("移除全部后不应再有私钥")
+	}
+
+	t.Log("✅ SSH代理服务测试通过")
+}
+
+func TestSSHAgentService_LockKeepsConfirm(t *testing.T) {
+	sas := NewSSHAgentService()
+	if _, err := sas.AddVaultKey("account-1", "服务器", ExtractPrivateKeyPEM(newTestSSHKeyPEM(t)), "", SSHKeyConstraints{ConfirmBeforeUse: true}); err != nil {
+		t.Fatalf("添加私钥失败: %v", err)
+	}
+	keys, err := sas.List()
+	if err != nil || len(keys) != 1 {
+		t.Fatalf("应有1个私钥: %d, %v", len(keys), err)
+	}
+
+	// 锁定期间列出私钥（ssh-add -x 后 ssh-add -l）
+	if err := sas.Lock([]byte("pw")); err != nil {
+		t.Fatalf("锁定失败: %v", err)
+	}
+	if len(sas.ListKeys()) != 0 {
+		t.Error("锁定期间不应列出私钥")
+	}
+	if err := sas.Unlock([]byte("pw")); err != nil {
+		t.Fatalf("解锁失败: %v", err)
+	}
+
+	// 解锁后确认约束仍然生效
+	if _, err := sas.Sign(keys[0], []byte("data")); err != ErrSSHAgentConfirmRejected {
+		t.Errorf("解锁后未确认的签名应被拒绝，实际: %v", err)
+	}
+	if len(sas.ListKeys()) != 1 {
+		t.Error("解锁后应能列出私钥")
+	}
+}
@@ -62,7 +62,7 @@ func (ts *TypeService) GetTypesByGroup(groupID string) ([]models.Type, error) {
 
 	// 20251002 陈凤庆 查询types表
 	rows, err := db.Query(`
-		SELECT id, name, icon, filter, group_id, sort_order, created_at, updated_at
+		SELECT id, name, icon, filter, group_id, COALESCE(password_rule_id, ''), sort_order, created_at, updated_at
 		FROM types
 		WHERE group_id = ?
 		ORDER BY sort_order, name
@@ -79,7 +79,7 @@ func (ts *TypeService) GetTypesByGroup(groupID string) ([]models.Type, error) {
 	for rows.Next() {
 		var typeItem models.Type
 		err := rows.Scan(
-			&typeItem.ID, &typeItem.Name, &typeItem.Icon, &typeItem.Filter, &typeItem.GroupID, &typeItem.SortOrder,
+			&typeItem.ID, &typeItem.Name, &typeItem.Icon, &typeItem.Filter, &typeItem.GroupID, &typeItem.PasswordRuleID, &typeItem.SortOrder,
 			&typeItem.CreatedAt, &typeItem.UpdatedAt,
 		)
 		if err != nil {
@@ -113,7 +113,7 @@ func (ts *TypeService) GetAllTypes() ([]models.Type, error) {
 	log.Printf("[TypeService] 开始查询所有types")
 
 	rows, err := db.Query(`
-		SELECT id, name, icon, filter, group_id, sort_order, created_at, updated_at
+		SELECT id, name, icon, filter, group_id, COALESCE(password_rule_id, ''), sort_order, created_at, updated_at
 		FROM types
 		ORDER BY group_id, sort_order, name
 	`)
@@ -128,7 +128,7 @@ func (ts *TypeService) GetAllTypes() ([]models.Type, error) {
 	for rows.Next() {
 		var typeItem models.Type
 		err := rows.Scan(
-			&typeItem.ID, &typeItem.Name, &typeItem.Icon, &typeItem.Filter, &typeItem.GroupID, &typeItem.SortOrder,
+			&typeItem.ID, &typeItem.Name, &typeItem.Icon, &typeItem.Filter, &typeItem.GroupID, &typeItem.PasswordRuleID, &typeItem.SortOrder,
 			&typeItem.CreatedAt, &typeItem.UpdatedAt,
 		)
 		if err != nil {
@@ -160,11 +160,11 @@ func (ts *TypeService) GetTypeByID(id string) (*models.Type, error) {
 	var typeItem models.Type
 	// 20251002 陈凤庆 查询types表
 	err := db.QueryRow(`
-		SELECT id, name, icon, filter, group_id, sort_order, created_at, updated_at
+		SELECT id, name, icon, filter, group_id, COALESCE(password_rule_id, ''), sort_order, created_at, updated_at
 		FROM types
 		WHERE id = ?
 	`, id).Scan(
-		&typeItem.ID, &typeItem.Name, &typeItem.Icon, &typeItem.Filter, &typeItem.GroupID, &typeItem.SortOrder,
+		&typeItem.ID, &typeItem.Name, &typeItem.Icon, &typeItem.Filter, &typeItem.GroupID, &typeItem.PasswordRuleID, &typeItem.SortOrder,
 		&typeItem.CreatedAt, &typeItem.UpdatedAt,
 	)
 
@@ -318,7 +318,7 @@ func (ts *TypeService) SearchTypes(keyword string) ([]models.Type, error) {
 	db := ts.dbManager.GetDB()
 	// 20251002 陈凤庆 查询types表
 	rows, err := db.Query(`
-		SELECT id, name, icon, filter, group_id, sort_order, created_at, updated_at
+		SELECT id, name, icon, filter, group_id, COALESCE(password_rule_id, ''), sort_order, created_at, updated_at
 		FROM types
 		WHERE name LIKE ?
 		ORDER BY sort_order, name
@@ -333,7 +333,7 @@ func (ts *TypeService) SearchTypes(keyword string) ([]models.Type, error) {
 	for rows.Next() {
 		var typeItem models.Type
 		err := rows.Scan(
-			&typeItem.ID, &typeItem.Name, &typeItem.Icon, &typeItem.Filter, &typeItem.GroupID, &typeItem.SortOrder,
+			&typeItem.ID, &typeItem.Name, &typeItem.Icon, &typeItem.Filter, &typeItem.GroupID, &typeItem.PasswordRuleID, &typeItem.SortOrder,
 			&typeItem.CreatedAt, &typeItem.UpdatedAt,
 		)
 		if err != nil {
@@ -0,0 +1,238 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"wepassword/internal/models"
+)
+
+/**
+ * 密码库健康报告
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 对解密后的账号做安全审计：弱密码、重复使用、过旧、http地址、空密码和未配置两步验证。
+ *              重复使用按每次生成报告时随机生成密钥的HMAC比较，报告中不保留密码明文或可复用的哈希。
+ *              账号没有单独的两步验证字段，备注中包含 otpauth:// 链接视为已配置；
+ *              密码为字段引用的账号是有意共用密码，不参与弱密码和重复使用检查
+ * @modify 20251021 陈凤庆 支持使用离线泄露数据检查密码是否已泄露
+ */
+
+// 健康检查问题代码
+const (
+	HealthEmptyPassword = "empty_password" // 密码为空
+	HealthWeak          = "weak"           // 弱密码
+	HealthReused        = "reused"         // 重复使用
+	HealthOld           = "old"            // 密码过旧
+	HealthInsecureURL   = "insecure_url"   // 使用http地址
+	HealthNo2FA         = "no_2fa"         // 未配置两步验证
+	HealthBreached      = "breached"       // 密码已泄露
+)
+
+// 问题严重程度
+const (
+	HealthSeverityHigh   = "high"
+	HealthSeverityMedium = "medium"
+	HealthSeverityLow    = "low"
+)
+
+const (
+	defaultHealthMaxAgeDays       = 365 // 默认密码过旧天数
+	defaultHealthMinStrengthScore = 3   // 默认弱密码评分阈值
+
+	healthOTPAuthPrefix = "otpauth://" // 两步验证链接前缀
+)
+
+// healthPenalties 各问题对账号得分的扣分（满分1）
+var healthPenalties = map[string]float64{
+	HealthEmptyPassword: 1,
+	HealthWeak:          0.5,
+	HealthReused:        0.5,
+	HealthOld:           0.2,
+	HealthInsecureURL:   0.2,
+	HealthNo2FA:         0.1,
+	HealthBreached:      1,
+}
+
+/**
+ * BuildHealthReport 生成密码库健康报告
+ * @param accounts 解密后的账号列表
+ * @param changedAt 账号ID到最近一次修改密码时间的映射，没有记录的账号按最后修改时间计算
+ * @param options 报告选项
+ * @param breaches 离线泄露查询，为nil时不检查泄露
+ * @param now 生成时间，用于计算密码使用天数
+ * @return models.HealthReport 健康报告
+ * @return error 错误信息
+ */
+func BuildHealthReport(accounts []models.AccountDecrypted, changedAt map[string]time.Time, options models.HealthReportOptions, breaches BreachLookup, now time.Time) (models.HealthReport, error) {
+	if options.MaxAgeDays <= 0 {
+		options.MaxAgeDays = defaultHealthMaxAgeDays
+	}
+	if options.MinStrengthScore <= 0 {
+		options.MinStrengthScore = defaultHealthMinStrengthScore
+	}
+
+	reused, err := findReusedPasswords(accounts)
+	if err != nil {
+		return models.HealthReport{}, err
+	}
+
+	report := models.HealthReport{
+		AccountCount:  len(accounts),
+		Accounts:      make([]models.AccountHealth, 0),
+		BreachChecked: breaches != nil,
+		GeneratedAt:   now,
+	}
+	totalScore := 0.0
+	for _, account := range accounts {
+		breachCount := 0
+		if breaches != nil && account.Password != "" && !HasReference(account.Password) {
+			breachCount, err = breaches.BreachCount(account.Password)
+			if err != nil {
+				return models.HealthReport{}, fmt.Errorf("检查密码泄露失败: %w", err)
+			}
+		}
+
+		health := checkAccountHealth(account, passwordChangedAt(account, changedAt), options, reused[account.ID], breachCount, now)
+		totalScore += float64(health.Score)
+		if len(health.Findings) == 0 {
+			continue
+		}
+
+		for _, finding := range health.Findings {
+			switch finding.Code {
+			case HealthEmptyPassword:
+				report.EmptyCount++
+			case HealthWeak:
+				report.WeakCount++
+			case HealthReused:
+				report.ReusedCount++
+			case HealthOld:
+				report.OldCount++
+			case HealthInsecureURL:
+				report.InsecureURLCount++
+			case HealthNo2FA:
+				report.No2FACount++
+			case HealthBreached:
+				report.BreachedCount++
+			}
+		}
+		report.Accounts = append(report.Accounts, health)
+	}
+
+	report.Score = 100
+	if len(accounts) > 0 {
+		report.Score = int(math.Round(totalScore / float64(len(accounts))))
+	}
+	return report, nil
+}
+
+/**
+ * checkAccountHealth 检查单个账号
+ * @param account 解密后的账号
+ * @param changedAt 最近一次修改密码的时间
+ * @param options 报告选项
+ * @param reusedWith 使用相同密码的其他账号ID
+ * @param breachCount 密码在泄露数据中出现的次数
+ * @param now 检查时间
+ * @return models.AccountHealth 检查结果
+ */
+func checkAccountHealth(account models.AccountDecrypted, changedAt time.Time, options models.HealthReportOptions, reusedWith []string, breachCount int, now time.Time) models.AccountHealth {
+	health := models.AccountHealth{
+		AccountID:  account.ID,
+		Title:      account.Title,
+		Findings:   make([]models.HealthFinding, 0),
+		ReusedWith: reusedWith,
+	}
+	add := func(code, severity, format string, args ...interface{}) {
+		health.Findings = append(health.Findings, models.HealthFinding{
+			Code:     code,
+			Severity: severity,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	isReference := HasReference(account.Password)
+	switch {
+	case account.Password == "":
+		add(HealthEmptyPassword, HealthSeverityHigh, "密码为空")
+	case !isReference:
+		strength := EstimatePasswordStrength(account.Password, []string{account.Title, account.Username, account.URL})
+		if strength.Score < options.MinStrengthScore {
+			add(HealthWeak, HealthSeverityHigh, "密码强度为%s", strength.Label)
+		}
+	}
+	if breachCount > 0 {
+		add(HealthBreached, HealthSeverityHigh, "密码已在数据泄露中出现%d次", breachCount)
+	}
+	if len(reusedWith) > 0 {
+		add(HealthReused, HealthSeverityHigh, "与其他%d个账号使用相同密码", len(reusedWith))
+	}
+
+	if !changedAt.IsZero() {
+		days := int(now.Sub(changedAt).Hours() / 24)
+		if days > options.MaxAgeDays {
+			add(HealthOld, HealthSeverityMedium, "密码已%d天未修改", days)
+		}
+	}
+
+	if strings.HasPrefix(strings.ToLower(strings.TrimSpace(account.URL)), "http://") {
+		add(HealthInsecureURL, HealthSeverityMedium, "地址使用未加密的http协议")
+	}
+
+	if account.Password != "" && !strings.Contains(strings.ToLower(account.Notes), healthOTPAuthPrefix) {
+		add(HealthNo2FA, HealthSeverityLow, "未配置两步验证")
+	}
+
+	penalty := 0.0
+	for _, finding := range health.Findings {
+		penalty += healthPenalties[finding.Code]
+	}
+	health.Score = int(math.Round(100 * math.Max(0, 1-penalty)))
+	return health
+}
+
+/**
+ * findReusedPasswords 找出使用相同密码的账号
+ * @param accounts 解密后的账号列表
+ * @return map[string][]string 账号ID -> 使用相同密码的其他账号ID
+ * @return error 错误信息
+ * @description 使用随机密钥的HMAC-SHA256比较密码，密钥只在本次调用中存在
+ */
+func findReusedPasswords(accounts []models.AccountDecrypted) (map[string][]string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("生成比较密钥失败: %w", err)
+	}
+
+	groups := make(map[string][]string)
+	for _, account := range accounts {
+		if account.Password == "" || HasReference(account.Password) {
+			continue
+		}
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(account.Password))
+		digest := string(mac.Sum(nil))
+		groups[digest] = append(groups[digest], account.ID)
+	}
+
+	reused := make(map[string][]string)
+	for _, ids := range groups {
+		if len(ids) < 2 {
+			continue
+		}
+		for _, id := range ids {
+			for _, other := range ids {
+				if other != id {
+					reused[id] = append(reused[id], other)
+				}
+			}
+		}
+	}
+	return reused, nil
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"wepassword/internal/models"
+)
+
+/**
+ * 密码库健康报告测试
+ * @author 陈凤庆
+ * @date 20251021
+ * @description 测试各类问题的识别、重复密码分组、字段引用的处理以及得分计算
+ */
+
+func healthByID(report models.HealthReport) map[string]models.AccountHealth {
+	result := make(map[string]models.AccountHealth)
+	for _, health := range report.Accounts {
+		result[health.AccountID] = health
+	}
+	return result
+}
+
+func healthCodes(health models.AccountHealth) map[string]bool {
+	codes := make(map[string]bool)
+	for _, finding := range health.Findings {
+		codes[finding.Code] = true
+	}
+	return codes
+}
+
+func TestBuildHealthReport(t *testing.T) {
+	now := time.Date(2025, 10, 21, 0, 0, 0, 0, time.UTC)
+	const strong = "vT7#qLm9!xRw2$Kp"
+	const otp = "otpauth://totp/Example?secret=JBSWY3DPEHPK3PXP"
+	accounts := []models.AccountDecrypted{
+		{ID: "ok", Title: "健康", Password: "Zr8@pWn4#Lq6!Yd2", URL: "https://a.example.com", Notes: otp, UpdatedAt: now},
+		{ID: "weak", Title: "弱密码", Password: "password123", Notes: otp, UpdatedAt: now},
+		{ID: "r1", Title: "重复1", Password: strong, Notes: otp, UpdatedAt: now},
+		{ID: "r2", Title: "重复2", Password: strong, Notes: otp, UpdatedAt: now},
+		{ID: "old", Title: "过旧", Password: "Hb3$kTz8!mQw5@Ln", Notes: otp, UpdatedAt: now.AddDate(-2, 0, 0)},
+		{ID: "http", Title: "明文地址", Password: "Fx2!nVr7$cJp9#Ws", URL: "HTTP://intranet", Notes: otp, UpdatedAt: now},
+		{ID: "empty", Title: "空密码", UpdatedAt: now},
+		{ID: "no2fa", Title: "无两步验证", Password: "Gm5#tYq1@zKe8!Rv", UpdatedAt: now},
+		{ID: "ref", Title: "引用", Password: "{REF:P@I:r1}", Notes: otp, UpdatedAt: now},
+		{ID: "stale", Title: "仅改过备注", Password: "Qd6!wHs3#bNx7$Tc", Notes: otp, UpdatedAt: now},
+	}
+	// 密码使用天数按记录的密码修改时间计算，而不是账号最后修改时间
+	changedAt := map[string]time.Time{"stale": now.AddDate(-2, 0, 0)}
+
+	report, err := BuildHealthReport(accounts, changedAt, models.HealthReportOptions{}, nil, now)
+	if err != nil {
+		t.Fatalf("生成健康报告失败: %v", err)
+	}
+
+	expected := map[string][]string{
+		"weak":  {HealthWeak},
+		"r1":    {HealthReused},
+		"r2":    {HealthReused},
+		"old":   {HealthOld},
+		"stale": {HealthOld},
+		"http":  {HealthInsecureURL},
+		"empty": {HealthEmptyPassword},
+		"no2fa": {HealthNo2FA},
+	}
+	found := healthByID(report)
+	if len(found) != len(expected) {
+		t.Errorf("期望%d个账号存在问题，实际%d个", len(expected), len(found))
+	}
+	for id, codes := range expected {
+		actual := healthCodes(found[id])
+		if len(actual) != len(codes) {
+			t.Errorf("账号%s问题错误: %+v", id, found[id].Findings)
+			continue
+		}
+		for _, code := range codes {
+			if !actual[code] {
+				t.Errorf("账号%s应包含问题%s: %+v", id, code, found[id].Findings)
+			}
+		}
+	}
+
+	if reusedWith := found["r1"].ReusedWith; len(reusedWith) != 1 || reusedWith[0] != "r2" {
+		t.Errorf("重复密码分组错误: %v", reusedWith)
+	}
+	if report.ReusedCount != 2 || report.WeakCount != 1 || report.EmptyCount != 1 || report.OldCount != 2 {
+		t.Errorf("问题统计错误: %+v", report)
+	}
+
+	// 得分：健康和引用各100，弱、重复各50，两个过旧和http各80，空密码0，无两步验证90，平均680/10=68
+	if report.Score != 68 {
+		t.Errorf("期望得分68，实际%d", report.Score)
+	}
+}
+
+func TestBuildHealthReport_Empty(t *testing.T) {
+	report, err := BuildHealthReport(nil, nil, models.HealthReportOptions{}, nil, time.Now())
+	if err != nil {
+		t.Fatalf("生成健康报告失败: %v", err)
+	}
+	if report.Score != 100 || len(report.Accounts) != 0 {
+		t.Errorf("空密码库报告错误: %+v", report)
+	}
+}
@@ -33,7 +33,7 @@ type VaultService struct {
 /**
  * NewVaultService 创建新的密码库服务
  * @param dbManager 数据库管理器
- * @param configManager 配置管理器
+ * @param configManager 配置管理器，为nil时打开密码库不更新配置文件
  * @return *VaultService 密码库服务实例
  */
 func NewVaultService(dbManager *database.DatabaseManager, configManager *config.ConfigManager) *VaultService {
@@ -207,12 +207,15 @@ func (vs *VaultService) OpenVault(vaultPath string, password string) error {
 	logger.Info("[登录] ✅ 主密钥设置完成")
 
 	// 更新配置文件
-	logger.Info("[登录] 正在更新配置文件...")
-	if err := vs.configManager.SetCurrentVaultPath(vaultPath); err != nil {
-		logger.Error("[登录] ❌ 更新配置文件失败: %v", err)
-		return fmt.Errorf("更新配置文件失败: %w", err)
+	// 20251020 陈凤庆 附加打开的密码库会话没有配置管理器，不修改当前密码库路径
+	if vs.configManager != nil {
+		logger.Info("[登录] 正在更新配置文件...")
+		if err := vs.configManager.SetCurrentVaultPath(vaultPath); err != nil {
+			logger.Error("[登录] ❌ 更新配置文件失败: %v", err)
+			return fmt.Errorf("更新配置文件失败: %w", err)
+		}
+		logger.Info("[登录] ✅ 配置文件更新完成")
 	}
-	logger.Info("[登录] ✅ 配置文件更新完成")
 
 	// 20251003 陈凤庆 标记密码库已打开
 	vs.isOpened = true
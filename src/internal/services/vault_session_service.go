@@ -0,0 +1,548 @@
+package services
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"wepassword/internal/database"
+	"wepassword/internal/logger"
+	"wepassword/internal/models"
+	"wepassword/internal/utils"
+)
+
+/**
+ * 多密码库会话服务
+ * @author 陈凤庆
+ * @date 20251020
+ * @description 在登录的主密码库之外同时打开多个密码库（如个人库和团队库），
+ *              每个密码库拥有独立的数据库连接、加密管理器和锁定状态，并提供跨库统一列表和搜索
+ */
+
+// PrimaryVaultID 主密码库的会话ID
+const PrimaryVaultID = "primary"
+
+/**
+ * VaultSession 单个已打开的密码库会话
+ */
+type VaultSession struct {
+	id              string
+	name            string
+	path            string
+	dbManager       *database.DatabaseManager
+	vaultService    *VaultService
+	accountService  *AccountService
+	groupService    *GroupService
+	typeService     *TypeService
+	locked          bool
+	openedAt        time.Time
+	autoLockMinutes int
+	lockTimer       *time.Timer
+	users           int  // 正在使用会话数据库的调用数
+	closePending    bool // 锁定时仍有调用在使用数据库，待最后一个调用释放后再关闭
+	unlocking       bool // 正在不持有锁的情况下打开数据库，期间不允许再次解锁
+}
+
+/**
+ * Info 获取会话信息
+ * @return models.VaultSessionInfo 会话信息
+ */
+func (s *VaultSession) Info() models.VaultSessionInfo {
+	return models.VaultSessionInfo{
+		ID:              s.id,
+		Name:            s.name,
+		Path:            s.path,
+		IsLocked:        s.locked,
+		AutoLockMinutes: s.autoLockMinutes,
+		OpenedAt:        s.openedAt,
+	}
+}
+
+/**
+ * DBManager 获取会话的数据库管理器
+ */
+func (s *VaultSession) DBManager() *database.DatabaseManager {
+	return s.dbManager
+}
+
+/**
+ * AccountService 获取会话的账号服务
+ */
+func (s *VaultSession) AccountService() *AccountService {
+	return s.accountService
+}
+
+/**
+ * GroupService 获取会话的分组服务
+ */
+func (s *VaultSession) GroupService() *GroupService {
+	return s.groupService
+}
+
+/**
+ * TypeService 获取会话的类型服务
+ */
+func (s *VaultSession) TypeService() *TypeService {
+	return s.typeService
+}
+
+/**
+ * VaultSessionService 多密码库会话服务
+ */
+type VaultSessionService struct {
+	mu       sync.Mutex
+	sessions map[string]*VaultSession
+	order    []string // 按打开顺序保存的会话ID
+}
+
+/**
+ * NewVaultSessionService 创建多密码库会话服务
+ * @return *VaultSessionService 多密码库会话服务实例
+ */
+func NewVaultSessionService() *VaultSessionService {
+	return &VaultSessionService{
+		sessions: make(map[string]*VaultSession),
+		order:    make([]string, 0),
+	}
+}
+
+/**
+ * VaultNameFromPath 从密码库路径获取密码库名称
+ * @param vaultPath 密码库文件路径
+ * @return string 文件名（不含后缀）
+ */
+func VaultNameFromPath(vaultPath string) string {
+	base := filepath.Base(vaultPath)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+/**
+ * SameVaultPath 判断两个密码库路径是否指向同一文件
+ */
+func SameVaultPath(a, b string) bool {
+	if a == "" || b == "" {
+		return false
+	}
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	if errA != nil || errB != nil {
+		return filepath.Clean(a) == filepath.Clean(b)
+	}
+	return absA == absB
+}
+
+/**
+ * OpenSession 打开一个附加密码库
+ * @param vaultPath 密码库文件路径
+ * @param password 该密码库的登录密码
+ * @param autoLockMinutes 空闲自动锁定分钟数，0表示不自动锁定
+ * @return models.VaultSessionInfo 会话信息
+ * @return error 错误信息
+ */
+func (vss *VaultSessionService) OpenSession(vaultPath string, password string, autoLockMinutes int) (models.VaultSessionInfo, error) {
+	if _, exists := vss.FindSessionByPath(vaultPath); exists {
+		return models.VaultSessionInfo{}, fmt.Errorf("密码库已打开: %s", vaultPath)
+	}
+
+	dbManager := database.NewDatabaseManager()
+	session := &VaultSession{
+		id:              utils.GenerateGUID(),
+		name:            VaultNameFromPath(vaultPath),
+		path:            vaultPath,
+		dbManager:       dbManager,
+		vaultService:    NewVaultService(dbManager, nil),
+		accountService:  NewAccountService(dbManager),
+		groupService:    NewGroupService(dbManager),
+		typeService:     NewTypeService(dbManager),
+		openedAt:        time.Now(),
+		autoLockMinutes: autoLockMinutes,
+	}
+
+	// 20251021 陈凤庆 密钥派生和打开数据库较慢，不持有锁，避免阻塞其他密码库的访问和自动锁定
+	if err := openSessionVault(session, password); err != nil {
+		return models.VaultSessionInfo{}, err
+	}
+
+	vss.mu.Lock()
+	defer vss.mu.Unlock()
+
+	// 打开期间可能有另一个调用打开了同一个密码库
+	for _, existing := range vss.sessions {
+		if SameVaultPath(existing.path, vaultPath) {
+			vss.closeSessionDB(session)
+			return models.VaultSessionInfo{}, fmt.Errorf("密码库已打开: %s", vaultPath)
+		}
+	}
+
+	vss.sessions[session.id] = session
+	vss.order = append(vss.order, session.id)
+	session.locked = false
+	vss.resetLockTimer(session)
+	logger.Info("[多密码库] 已打开密码库: %s (%s)", session.name, session.id)
+
+	return session.Info(), nil
+}
+
+/**
+ * openSessionVault 打开会话的数据库并设置加密管理器
+ * @description 不需要持有锁：调用方需保证会话尚未加入列表，或已标记为正在解锁且处于锁定状态，不会被其他调用使用
+ */
+func openSessionVault(session *VaultSession, password string) error {
+	if err := session.vaultService.OpenVault(session.path, password); err != nil {
+		session.vaultService.CloseVault()
+		return fmt.Errorf("打开密码库失败: %w", err)
+	}
+	session.accountService.SetCryptoManager(session.vaultService.GetCryptoManager())
+	session.vaultService.ClearCurrentPassword()
+	return nil
+}
+
+/**
+ * lockSession 锁定会话：关闭数据库并清理加密管理器（调用方需持有锁）
+ * @description 仍有调用在使用会话数据库时先标记为锁定（不再提供给新的调用），由最后一个调用释放时关闭数据库
+ */
+func (vss *VaultSessionService) lockSession(session *VaultSession) {
+	if session.lockTimer != nil {
+		session.lockTimer.Stop()
+		session.lockTimer = nil
+	}
+	if session.locked {
+		return
+	}
+	session.locked = true
+	if session.users > 0 {
+		session.closePending = true
+		logger.Info("[多密码库] 密码库正在使用，释放后锁定: %s", session.name)
+		return
+	}
+	vss.closeSessionDB(session)
+}
+
+/**
+ * closeSessionDB 关闭会话的数据库并清理加密管理器（调用方需持有锁）
+ */
+func (vss *VaultSessionService) closeSessionDB(session *VaultSession) {
+	session.vaultService.CloseVault()
+	session.accountService.SetCryptoManager(nil)
+	session.closePending = false
+	logger.Info("[多密码库] 密码库已锁定: %s", session.name)
+}
+
+/**
+ * acquireSession 登记一次对会话数据库的使用（调用方需持有锁）
+ * @return func() 释放函数，使用完成后必须调用，可重复调用
+ */
+func (vss *VaultSessionService) acquireSession(session *VaultSession) func() {
+	session.users++
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			vss.mu.Lock()
+			defer vss.mu.Unlock()
+			session.users--
+			if session.users == 0 && session.closePending {
+				vss.closeSessionDB(session)
+			}
+		})
+	}
+}
+
+/**
+ * resetLockTimer 重置会话的空闲自动锁定定时器（调用方需持有锁）
+ */
+func (vss *VaultSessionService) resetLockTimer(session *VaultSession) {
+	if session.lockTimer != nil {
+		session.lockTimer.Stop()
+		session.lockTimer = nil
+	}
+	if session.locked || session.autoLockMinutes <= 0 {
+		return
+	}
+
+	sessionID := session.id
+	session.lockTimer = time.AfterFunc(time.Duration(session.autoLockMinutes)*time.Minute, func() {
+		vss.mu.Lock()
+		defer vss.mu.Unlock()
+		if s, exists := vss.sessions[sessionID]; exists {
+			logger.Info("[多密码库] 密码库空闲超时，自动锁定: %s", s.name)
+			vss.lockSession(s)
+		}
+	})
+}
+
+/**
+ * UnlockSession 解锁已锁定的密码库会话
+ * @param sessionID 会话ID
+ * @param password 该密码库的登录密码
+ * @return error 错误信息
+ */
+func (vss *VaultSessionService) UnlockSession(sessionID string, password string) error {
+	vss.mu.Lock()
+	session, exists := vss.sessions[sessionID]
+	switch {
+	case !exists:
+		vss.mu.Unlock()
+		return fmt.Errorf("密码库会话不存在: %s", sessionID)
+	case !session.locked:
+		vss.mu.Unlock()
+		return nil
+	case session.closePending:
+		vss.mu.Unlock()
+		return fmt.Errorf("密码库正在锁定，请稍后重试: %s", session.name)
+	case session.unlocking:
+		vss.mu.Unlock()
+		return fmt.Errorf("密码库正在解锁，请稍后重试: %s", session.name)
+	}
+	session.unlocking = true
+	vss.mu.Unlock()
+
+	// 20251021 陈凤庆 会话处于锁定状态，不会提供给其他调用，打开数据库时不持有锁
+	err := openSessionVault(session, password)
+
+	vss.mu.Lock()
+	defer vss.mu.Unlock()
+	session.unlocking = false
+	if err != nil {
+		return err
+	}
+	if vss.sessions[sessionID] != session {
+		// 解锁期间会话已被关闭
+		vss.closeSessionDB(session)
+		return fmt.Errorf("密码库会话已关闭: %s", sessionID)
+	}
+	session.locked = false
+	vss.resetLockTimer(session)
+	logger.Info("[多密码库] 密码库已解锁: %s", session.name)
+	return nil
+}
+
+/**
+ * LockSession 锁定密码库会话，会话保留在列表中可再次解锁
+ * @param sessionID 会话ID
+ * @return error 错误信息
+ */
+func (vss *VaultSessionService) LockSession(sessionID string) error {
+	vss.mu.Lock()
+	defer vss.mu.Unlock()
+
+	session, exists := vss.sessions[sessionID]
+	if !exists {
+		return fmt.Errorf("密码库会话不存在: %s", sessionID)
+	}
+	vss.lockSession(session)
+	return nil
+}
+
+/**
+ * CloseSession 关闭密码库会话并从列表中移除
+ * @param sessionID 会话ID
+ * @return error 错误信息
+ */
+func (vss *VaultSessionService) CloseSession(sessionID string) error {
+	vss.mu.Lock()
+	defer vss.mu.Unlock()
+
+	session, exists := vss.sessions[sessionID]
+	if !exists {
+		return fmt.Errorf("密码库会话不存在: %s", sessionID)
+	}
+	vss.lockSession(session)
+	delete(vss.sessions, sessionID)
+	for i, id := range vss.order {
+		if id == sessionID {
+			vss.order = append(vss.order[:i], vss.order[i+1:]...)
+			break
+		}
+	}
+	logger.Info("[多密码库] 密码库会话已关闭: %s", session.name)
+	return nil
+}
+
+/**
+ * CloseAll 关闭全部附加密码库会话
+ */
+func (vss *VaultSessionService) CloseAll() {
+	vss.mu.Lock()
+	defer vss.mu.Unlock()
+
+	for _, session := range vss.sessions {
+		vss.lockSession(session)
+	}
+	vss.sessions = make(map[string]*VaultSession)
+	vss.order = make([]string, 0)
+}
+
+/**
+ * SetAutoLock 设置会话的空闲自动锁定时间
+ * @param sessionID 会话ID
+ * @param minutes 分钟数，0表示不自动锁定
+ * @return error 错误信息
+ */
+func (vss *VaultSessionService) SetAutoLock(sessionID string, minutes int) error {
+	if minutes < 0 {
+		return fmt.Errorf("自动锁定时间不能为负数")
+	}
+
+	vss.mu.Lock()
+	defer vss.mu.Unlock()
+
+	session, exists := vss.sessions[sessionID]
+	if !exists {
+		return fmt.Errorf("密码库会话不存在: %s", sessionID)
+	}
+	session.autoLockMinutes = minutes
+	vss.resetLockTimer(session)
+	return nil
+}
+
+/**
+ * ListSessions 获取全部附加密码库会话信息（按打开顺序）
+ * @return []models.VaultSessionInfo 会话信息列表
+ */
+func (vss *VaultSessionService) ListSessions() []models.VaultSessionInfo {
+	vss.mu.Lock()
+	defer vss.mu.Unlock()
+
+	infos := make([]models.VaultSessionInfo, 0, len(vss.order))
+	for _, id := range vss.order {
+		infos = append(infos, vss.sessions[id].Info())
+	}
+	return infos
+}
+
+/**
+ * GetSession 获取已解锁的会话，并重置其空闲自动锁定计时
+ * @param sessionID 会话ID
+ * @return *VaultSession 会话
+ * @return func() 释放函数，使用完会话后必须调用；释放前自动锁定和关闭不会关闭会话的数据库
+ * @return error 会话不存在或已锁定时返回错误
+ */
+func (vss *VaultSessionService) GetSession(sessionID string) (*VaultSession, func(), error) {
+	vss.mu.Lock()
+	defer vss.mu.Unlock()
+
+	session, exists := vss.sessions[sessionID]
+	if !exists {
+		return nil, nil, fmt.Errorf("密码库会话不存在: %s", sessionID)
+	}
+	if session.locked {
+		return nil, nil, fmt.Errorf("密码库已锁定: %s", session.name)
+	}
+	vss.resetLockTimer(session)
+	return session, vss.acquireSession(session), nil
+}
+
+/**
+ * FindSessionByPath 按密码库路径查找会话ID
+ * @param vaultPath 密码库文件路径
+ * @return string 会话ID
+ * @return bool 是否找到
+ */
+func (vss *VaultSessionService) FindSessionByPath(vaultPath string) (string, bool) {
+	vss.mu.Lock()
+	defer vss.mu.Unlock()
+
+	for id, session := range vss.sessions {
+		if SameVaultPath(session.path, vaultPath) {
+			return id, true
+		}
+	}
+	return "", false
+}
+
+/**
+ * unlockedSessions 获取全部已解锁的会话及其信息，并重置其空闲自动锁定计时
+ * @return func() 释放函数，使用完会话后必须调用
+ */
+func (vss *VaultSessionService) unlockedSessions() ([]*VaultSession, []models.VaultSessionInfo, func()) {
+	vss.mu.Lock()
+	defer vss.mu.Unlock()
+
+	sessions := make([]*VaultSession, 0, len(vss.order))
+	infos := make([]models.VaultSessionInfo, 0, len(vss.order))
+	releases := make([]func(), 0, len(vss.order))
+	for _, id := range vss.order {
+		session := vss.sessions[id]
+		if session.locked {
+			continue
+		}
+		vss.resetLockTimer(session)
+		sessions = append(sessions, session)
+		infos = append(infos, session.Info())
+		releases = append(releases, vss.acquireSession(session))
+	}
+	return sessions, infos, func() {
+		for _, release := range releases {
+			release()
+		}
+	}
+}
+
+/**
+ * ListAllAccounts 获取全部已解锁附加密码库的账号列表
+ * @return []models.VaultAccount 带来源标记的账号列表
+ * @return error 错误信息
+ */
+func (vss *VaultSessionService) ListAllAccounts() ([]models.VaultAccount, error) {
+	sessions, infos, release := vss.unlockedSessions()
+	defer release()
+
+	result := make([]models.VaultAccount, 0)
+	for i, session := range sessions {
+		accounts, err := session.accountService.GetAccountsByConditions("{}")
+		if err != nil {
+			return nil, fmt.Errorf("获取密码库 %s 的账号失败: %w", infos[i].Name, err)
+		}
+		result = append(result, TagVaultAccounts(accounts, infos[i])...)
+	}
+	return result, nil
+}
+
+/**
+ * SearchAllAccounts 在全部已解锁附加密码库中搜索账号
+ * @param keyword 搜索关键词
+ * @return []models.VaultAccount 带来源标记的搜索结果
+ * @return error 错误信息
+ */
+func (vss *VaultSessionService) SearchAllAccounts(keyword string) ([]models.VaultAccount, error) {
+	sessions, infos, release := vss.unlockedSessions()
+	defer release()
+
+	result := make([]models.VaultAccount, 0)
+	for i, session := range sessions {
+		accounts, err := session.accountService.SearchAccounts(keyword)
+		if err != nil {
+			return nil, fmt.Errorf("搜索密码库 %s 失败: %w", infos[i].Name, err)
+		}
+		result = append(result, TagVaultAccounts(accounts, infos[i])...)
+	}
+	return result, nil
+}
+
+/**
+ * TagVaultAccounts 为账号列表标记来源密码库，并去除用户名、密码和备注明文
+ * @param accounts 账号列表
+ * @param info 来源密码库会话信息
+ * @return []models.VaultAccount 带来源标记的账号列表
+ */
+func TagVaultAccounts(accounts []models.AccountDecrypted, info models.VaultSessionInfo) []models.VaultAccount {
+	tagged := make([]models.VaultAccount, 0, len(accounts))
+	for _, account := range accounts {
+		// 搜索结果包含用户名明文，列表结果只有脱敏用户名
+		if account.MaskedUsername == "" && account.Username != "" {
+			account.MaskedUsername = (&AccountService{}).maskUsername(account.Username)
+		}
+		account.Username = ""
+		account.Password = ""
+		account.Notes = ""
+		tagged = append(tagged, models.VaultAccount{
+			AccountDecrypted: account,
+			VaultID:          info.ID,
+			VaultName:        info.Name,
+			VaultPath:        info.Path,
+		})
+	}
+	return tagged
+}
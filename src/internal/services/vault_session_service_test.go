@@ -0,0 +1,250 @@
+package services
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"wepassword/internal/config"
+	"wepassword/internal/database"
+)
+
+/**
+ * 多密码库会话服务测试
+ * @author 陈凤庆
+ * @date 20251020
+ * @description 测试同时打开多个密码库、独立锁定、使用中锁定和跨库搜索
+ */
+
+/**
+ * newTestVaultFile 创建包含一个账号的临时密码库文件（测试辅助）
+ * @return string 密码库文件路径
+ */
+func newTestVaultFile(t *testing.T, name, password, accountTitle string) string {
+	t.Helper()
+
+	vaultPath := filepath.Join(t.TempDir(), name+".db")
+	dbManager := database.NewDatabaseManager()
+	vaultService := NewVaultService(dbManager, config.NewConfigManager())
+	if err := vaultService.CreateVault(vaultPath, password, "zh-CN"); err != nil {
+		t.Fatalf("创建密码库失败: %v", err)
+	}
+	defer vaultService.CloseVault()
+
+	accountService := NewAccountService(dbManager)
+	accountService.SetCryptoManager(vaultService.GetCryptoManager())
+
+	var typeID string
+	if err := dbManager.GetDB().QueryRow("SELECT id FROM types ORDER BY sort_order LIMIT 1").Scan(&typeID); err != nil {
+		t.Fatalf("获取类型失败: %v", err)
+	}
+	if _, err := accountService.CreateAccount(accountTitle, "user-"+name, "Pass#"+name+"2025", "", typeID, "", 1); err != nil {
+		t.Fatalf("创建账号失败: %v", err)
+	}
+	return vaultPath
+}
+
+func TestVaultSessionService_MultipleVaults(t *testing.T) {
+	personalPath := newTestVaultFile(t, "personal", "Personal#2025", "GitHub 个人")
+	teamPath := newTestVaultFile(t, "team", "Team#2025!", "GitHub 团队")
+
+	vss := NewVaultSessionService()
+	defer vss.CloseAll()
+
+	personal, err := vss.OpenSession(personalPath, "Personal#2025", 0)
+	if err != nil {
+		t.Fatalf("打开个人密码库失败: %v", err)
+	}
+	team, err := vss.OpenSession(teamPath, "Team#2025!", 0)
+	if err != nil {
+		t.Fatalf("打开团队密码库失败: %v", err)
+	}
+	if personal.Name != "personal" || team.Name != "team" {
+		t.Errorf("密码库名称错误: %s, %s", personal.Name, team.Name)
+	}
+
+	if _, err := vss.OpenSession(teamPath, "Team#2025!", 0); err == nil {
+		t.Error("重复打开同一密码库应失败")
+	}
+
+	results, err := vss.SearchAllAccounts("GitHub")
+	if err != nil {
+		t.Fatalf("跨库搜索失败: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("跨库搜索应找到2个账号，实际: %d", len(results))
+	}
+	sources := map[string]string{}
+	for _, r := range results {
+		sources[r.VaultID] = r.Title
+		if r.Password != "" || r.Username != "" {
+			t.Error("跨库结果不应包含用户名和密码明文")
+		}
+	}
+	if sources[personal.ID] != "GitHub 个人" || sources[team.ID] != "GitHub 团队" {
+		t.Errorf("搜索结果来源标记错误: %+v", sources)
+	}
+
+	// 锁定团队库不影响个人库
+	if err := vss.LockSession(team.ID); err != nil {
+		t.Fatalf("锁定团队密码库失败: %v", err)
+	}
+	accounts, err := vss.ListAllAccounts()
+	if err != nil {
+		t.Fatalf("获取跨库账号列表失败: %v", err)
+	}
+	if len(accounts) != 1 || accounts[0].VaultID != personal.ID {
+		t.Errorf("锁定后只应列出个人库账号: %+v", accounts)
+	}
+	if _, _, err := vss.GetSession(team.ID); err == nil {
+		t.Error("已锁定的会话不应可用")
+	}
+
+	if err := vss.UnlockSession(team.ID, "wrong-password"); err == nil {
+		t.Error("错误密码解锁应失败")
+	}
+	if err := vss.UnlockSession(team.ID, "Team#2025!"); err != nil {
+		t.Fatalf("解锁团队密码库失败: %v", err)
+	}
+	session, release, err := vss.GetSession(team.ID)
+	if err != nil {
+		t.Fatalf("获取团队会话失败: %v", err)
+	}
+	if all, _ := session.AccountService().GetAllAccounts(); len(all) != 1 || all[0].Password != "Pass#team2025" {
+		t.Errorf("解锁后应能解密团队库账号: %+v", all)
+	}
+
+	// 使用中的会话被锁定时，数据库在释放后才关闭
+	if err := vss.LockSession(team.ID); err != nil {
+		t.Fatalf("锁定团队密码库失败: %v", err)
+	}
+	if _, _, err := vss.GetSession(team.ID); err == nil {
+		t.Error("已锁定的会话不应再提供给新的调用")
+	}
+	if all, err := session.AccountService().GetAllAccounts(); err != nil || len(all) != 1 {
+		t.Errorf("释放前仍应能读取团队库账号: %+v, %v", all, err)
+	}
+	if err := vss.UnlockSession(team.ID, "Team#2025!"); err == nil {
+		t.Error("等待释放时解锁应失败")
+	}
+	release()
+	if session.DBManager().IsOpened() {
+		t.Error("释放后团队库数据库应已关闭")
+	}
+	if err := vss.UnlockSession(team.ID, "Team#2025!"); err != nil {
+		t.Fatalf("释放后解锁团队密码库失败: %v", err)
+	}
+
+	if err := vss.CloseSession(personal.ID); err != nil {
+		t.Fatalf("关闭个人密码库失败: %v", err)
+	}
+	if sessions := vss.ListSessions(); len(sessions) != 1 || sessions[0].ID != team.ID {
+		t.Errorf("关闭后会话列表错误: %+v", sessions)
+	}
+
+	t.Log("✅ 多密码库会话测试通过")
+}
+
+func TestVaultSessionService_ConcurrentOpen(t *testing.T) {
+	personalPath := newTestVaultFile(t, "personal", "Personal#2025", "GitHub 个人")
+	teamPath := newTestVaultFile(t, "team", "Team#2025!", "GitHub 团队")
+
+	vss := NewVaultSessionService()
+	defer vss.CloseAll()
+
+	personal, err := vss.OpenSession(personalPath, "Personal#2025", 0)
+	if err != nil {
+		t.Fatalf("打开个人密码库失败: %v", err)
+	}
+
+	// 同时打开同一个密码库，只能有一个成功
+	const openers = 4
+	var wg sync.WaitGroup
+	errs := make(chan error, openers)
+	for i := 0; i < openers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := vss.OpenSession(teamPath, "Team#2025!", 0)
+			errs <- err
+		}()
+	}
+
+	// 打开期间访问其他密码库不应被阻塞
+	openDone := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(openDone)
+	}()
+	for {
+		start := time.Now()
+		if _, release, err := vss.GetSession(personal.ID); err != nil {
+			t.Fatalf("打开其他密码库期间获取个人会话失败: %v", err)
+		} else {
+			release()
+		}
+		if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+			t.Errorf("打开其他密码库期间获取会话被阻塞: %v", elapsed)
+		}
+		select {
+		case <-openDone:
+		case <-time.After(time.Millisecond):
+			continue
+		}
+		break
+	}
+	close(errs)
+
+	succeeded := 0
+	for err := range errs {
+		if err == nil {
+			succeeded++
+		}
+	}
+	if succeeded != 1 {
+		t.Errorf("同时打开同一密码库应只成功一次，实际: %d", succeeded)
+	}
+	if sessions := vss.ListSessions(); len(sessions) != 2 {
+		t.Errorf("会话数量错误: %+v", sessions)
+	}
+
+	teamID, ok := vss.FindSessionByPath(teamPath)
+	if !ok {
+		t.Fatal("未找到团队密码库会话")
+	}
+	if err := vss.LockSession(teamID); err != nil {
+		t.Fatalf("锁定团队密码库失败: %v", err)
+	}
+
+	// 同时解锁同一个会话，不应出现两次打开数据库
+	unlockErrs := make(chan error, openers)
+	for i := 0; i < openers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			unlockErrs <- vss.UnlockSession(teamID, "Team#2025!")
+		}()
+	}
+	wg.Wait()
+	close(unlockErrs)
+	unlocked := 0
+	for err := range unlockErrs {
+		if err == nil {
+			unlocked++
+		}
+	}
+	if unlocked == 0 {
+		t.Error("同时解锁时至少应有一次成功")
+	}
+	session, release, err := vss.GetSession(teamID)
+	if err != nil {
+		t.Fatalf("解锁后获取团队会话失败: %v", err)
+	}
+	defer release()
+	if all, err := session.AccountService().GetAllAccounts(); err != nil || len(all) != 1 {
+		t.Errorf("解锁后应能读取团队库账号: %+v, %v", all, err)
+	}
+
+	t.Log("✅ 并发打开密码库测试通过")
+}
@@ -0,0 +1,420 @@
+package services
+
+import (
+	"fmt"
+
+	"wepassword/internal/logger"
+	"wepassword/internal/models"
+)
+
+/**
+ * 跨密码库账号复制/移动服务
+ * @author 陈凤庆
+ * @date 20251020
+ * @description 将账号从一个密码库直接复制或移动到另一个密码库：源库解密后用目标库的密钥重新加密，
+ *              按分组路径和类型名称映射（缺失时自动创建）目标库的分组和类型，并报告冲突；
+ *              附件和历史密码一并重新加密复制，复制失败时移动操作不删除源账号
+ */
+
+// 冲突处理策略
+const (
+	TransferConflictSkip      = "skip"      // 跳过冲突账号（默认）
+	TransferConflictDuplicate = "duplicate" // 仍然创建新账号
+	TransferConflictOverwrite = "overwrite" // 覆盖目标库中的同名账号
+)
+
+/**
+ * VaultEndpoint 参与复制/移动的一端密码库的服务集合
+ */
+type VaultEndpoint struct {
+	Name           string
+	AccountService *AccountService
+	GroupService   *GroupService
+	TypeService    *TypeService
+}
+
+/**
+ * Endpoint 获取会话对应的密码库服务集合
+ * @return VaultEndpoint 服务集合
+ */
+func (s *VaultSession) Endpoint() VaultEndpoint {
+	return VaultEndpoint{
+		Name:           s.name,
+		AccountService: s.accountService,
+		GroupService:   s.groupService,
+		TypeService:    s.typeService,
+	}
+}
+
+/**
+ * TransferConflict 冲突账号信息
+ */
+type TransferConflict struct {
+	SourceAccountID string `json:"source_account_id"` // 源账号ID
+	TargetAccountID string `json:"target_account_id"` // 目标库中已存在的账号ID
+	Title           string `json:"title"`             // 账号标题
+	TypePath        string `json:"type_path"`         // 分组路径/类型名称
+	Resolution      string `json:"resolution"`        // 处理方式：skip、duplicate、overwrite
+}
+
+/**
+ * VaultTransferResult 复制/移动结果
+ */
+type VaultTransferResult struct {
+	Total         int                `json:"total"`          // 请求处理的账号数
+	Transferred   int                `json:"transferred"`    // 新建到目标库的账号数
+	Overwritten   int                `json:"overwritten"`    // 覆盖目标库同名账号的数量
+	Skipped       int                `json:"skipped"`        // 因冲突跳过的账号数
+	Failed        int                `json:"failed"`         // 失败的账号数
+	Moved         bool               `json:"moved"`          // 是否为移动操作
+	CreatedGroups []string           `json:"created_groups"` // 在目标库新建的分组路径
+	CreatedTypes  []string           `json:"created_types"`  // 在目标库新建的类型（分组路径/类型名称）
+	Conflicts     []TransferConflict `json:"conflicts"`      // 冲突列表
+	Warnings      []string           `json:"warnings"`       // 警告（如字段引用在目标库中失效）
+	Errors        []string           `json:"errors"`         // 错误信息
+
+	// 20251021 陈凤庆 附件和历史密码随账号用目标库的密钥重新加密复制
+	CopiedAttachments int `json:"copied_attachments"` // 复制的附件数
+	CopiedHistory     int `json:"copied_history"`     // 复制的历史密码数
+}
+
+/**
+ * VaultTransferService 跨密码库账号复制/移动服务
+ */
+type VaultTransferService struct {
+	source VaultEndpoint
+	target VaultEndpoint
+
+	targetGroups   []models.Group           // 目标库分组缓存
+	targetTypes    map[string][]models.Type // 目标库按分组缓存的类型
+	targetAccounts map[string]models.AccountDecrypted
+}
+
+/**
+ * NewVaultTransferService 创建跨密码库账号复制/移动服务
+ * @param source 源密码库
+ * @param target 目标密码库（已用其自身的登录密码打开）
+ * @return *VaultTransferService 服务实例
+ */
+func NewVaultTransferService(source VaultEndpoint, target VaultEndpoint) *VaultTransferService {
+	return &VaultTransferService{
+		source:      source,
+		target:      target,
+		targetTypes: make(map[string][]models.Type),
+	}
+}
+
+/**
+ * conflictKey 冲突判定键：同一类型下标题和用户名均相同视为冲突
+ */
+func conflictKey(typeID, title, username string) string {
+	return typeID + "\x00" + title + "\x00" + username
+}
+
+/**
+ * Transfer 复制或移动账号到目标密码库
+ * @param accountIDs 源库中的账号ID列表
+ * @param move 是否移动（成功写入目标库后删除源账号）
+ * @param conflictStrategy 冲突处理策略：skip、duplicate、overwrite，为空时为skip
+ * @return VaultTransferResult 处理结果
+ * @return error 错误信息
+ */
+func (vts *VaultTransferService) Transfer(accountIDs []string, move bool, conflictStrategy string) (VaultTransferResult, error) {
+	result := VaultTransferResult{
+		Total:         len(accountIDs),
+		Moved:         move,
+		CreatedGroups: make([]string, 0),
+		CreatedTypes:  make([]string, 0),
+		Conflicts:     make([]TransferConflict, 0),
+		Warnings:      make([]string, 0),
+		Errors:        make([]string, 0),
+	}
+
+	if conflictStrategy == "" {
+		conflictStrategy = TransferConflictSkip
+	}
+	if conflictStrategy != TransferConflictSkip && conflictStrategy != TransferConflictDuplicate && conflictStrategy != TransferConflictOverwrite {
+		return result, fmt.Errorf("不支持的冲突处理策略: %s", conflictStrategy)
+	}
+	if len(accountIDs) == 0 {
+		return result, fmt.Errorf("请选择要处理的账号")
+	}
+
+	if err := vts.loadTarget(); err != nil {
+		return result, err
+	}
+
+	logger.Info("[跨库迁移] 开始%s %d 个账号: %s -> %s", transferVerb(move), len(accountIDs), vts.source.Name, vts.target.Name)
+
+	for _, accountID := range accountIDs {
+		account, err := vts.source.AccountService.GetAccountByID(accountID)
+		if err != nil {
+			result.Failed++
+			result.Errors = append(result.Errors, fmt.Sprintf("读取账号 %s 失败: %v", accountID, err))
+			continue
+		}
+
+		targetTypeID, typePath, err := vts.mapType(account.TypeID, &result)
+		if err != nil {
+			result.Failed++
+			result.Errors = append(result.Errors, fmt.Sprintf("账号 %s 映射分组类型失败: %v", account.Title, err))
+			continue
+		}
+
+		if HasReference(account.Username) || HasReference(account.Password) || HasReference(account.URL) || HasReference(account.Notes) {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("账号 %s 包含字段引用，在目标库中可能无法解析", account.Title))
+		}
+
+		key := conflictKey(targetTypeID, account.Title, account.Username)
+		existing, conflict := vts.targetAccounts[key]
+		if conflict {
+			result.Conflicts = append(result.Conflicts, TransferConflict{
+				SourceAccountID: account.ID,
+				TargetAccountID: existing.ID,
+				Title:           account.Title,
+				TypePath:        typePath,
+				Resolution:      conflictStrategy,
+			})
+		}
+
+		switch {
+		case conflict && conflictStrategy == TransferConflictSkip:
+			result.Skipped++
+			continue
+		case conflict && conflictStrategy == TransferConflictOverwrite:
+			existing.Password = account.Password
+			existing.URL = account.URL
+			existing.Notes = account.Notes
+			existing.InputMethod = account.InputMethod
+			// 20251021 陈凤庆 覆盖的是复制来的密码，不执行目标账号的轮换钩子
+			if err := vts.target.AccountService.updateAccount(existing, false); err != nil {
+				result.Failed++
+				result.Errors = append(result.Errors, fmt.Sprintf("覆盖账号 %s 失败: %v", account.Title, err))
+				continue
+			}
+			vts.targetAccounts[key] = existing
+			result.Overwritten++
+		default:
+			created, err := vts.copyAccount(account, targetTypeID)
+			if err != nil {
+				result.Failed++
+				result.Errors = append(result.Errors, fmt.Sprintf("复制账号 %s 失败: %v", account.Title, err))
+				continue
+			}
+			vts.targetAccounts[key] = created
+			result.Transferred++
+		}
+
+		targetAccountID := vts.targetAccounts[key].ID
+		attachments, history, err := vts.copyAccountData(account.ID, targetAccountID)
+		if err != nil {
+			message := fmt.Sprintf("复制账号 %s 的附件和历史密码失败: %v", account.Title, err)
+			if move {
+				message += "，未删除源账号"
+			}
+			result.Errors = append(result.Errors, message)
+			continue
+		}
+		result.CopiedAttachments += attachments
+		result.CopiedHistory += history
+
+		if move {
+			if err := vts.source.AccountService.DeleteAccount(account.ID); err != nil {
+				result.Errors = append(result.Errors, fmt.Sprintf("已复制但删除源账号 %s 失败: %v", account.Title, err))
+			}
+		}
+	}
+
+	logger.Info("[跨库迁移] 完成: 新建=%d, 覆盖=%d, 跳过=%d, 失败=%d", result.Transferred, result.Overwritten, result.Skipped, result.Failed)
+	return result, nil
+}
+
+/**
+ * copyAccountData 将源账号的附件和历史密码用目标库的密钥重新加密，复制到目标账号
+ * @param sourceAccountID 源账号ID
+ * @param targetAccountID 目标账号ID
+ * @return int 复制的附件数
+ * @return int 复制的历史密码数
+ * @return error 错误信息，失败时删除已复制到目标库的部分
+ */
+func (vts *VaultTransferService) copyAccountData(sourceAccountID, targetAccountID string) (int, int, error) {
+	sourceAttachments := NewAccountAttachmentService(vts.source.AccountService.dbManager, vts.source.AccountService)
+	attachments, err := sourceAttachments.GetAttachments(sourceAccountID)
+	if err != nil {
+		return 0, 0, err
+	}
+	history, err := NewPasswordRotationService(vts.source.AccountService.dbManager, vts.source.AccountService).GetPasswordHistory(sourceAccountID)
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(attachments) == 0 && len(history) == 0 {
+		return 0, 0, nil
+	}
+
+	targetDB := vts.target.AccountService.dbManager.GetDB()
+	targetCrypto := vts.target.AccountService.cryptoManager
+	var attachmentIDs, historyIDs []string
+	copyAll := func() error {
+		for _, attachment := range attachments {
+			_, data, err := sourceAttachments.GetAttachmentData(attachment.ID)
+			if err != nil {
+				return err
+			}
+			copied, err := addAccountAttachment(targetDB, targetCrypto, targetAccountID, attachment.Name, data, attachment.CreatedAt)
+			if err != nil {
+				return err
+			}
+			attachmentIDs = append(attachmentIDs, copied.ID)
+		}
+		for _, entry := range history {
+			id, err := addPasswordHistory(targetDB, targetCrypto, targetAccountID, entry.Password, entry.CreatedAt)
+			if err != nil {
+				return err
+			}
+			historyIDs = append(historyIDs, id)
+		}
+		return nil
+	}
+	if err := copyAll(); err != nil {
+		for _, id := range attachmentIDs {
+			if _, delErr := targetDB.Exec("DELETE FROM account_attachments WHERE id = ?", id); delErr != nil {
+				logger.Error("[跨库迁移] 删除已复制的附件失败: %s, %v", id, delErr)
+			}
+		}
+		for _, id := range historyIDs {
+			if _, delErr := targetDB.Exec("DELETE FROM password_history WHERE id = ?", id); delErr != nil {
+				logger.Error("[跨库迁移] 删除已复制的历史密码失败: %s, %v", id, delErr)
+			}
+		}
+		return 0, 0, err
+	}
+	return len(attachmentIDs), len(historyIDs), nil
+}
+
+/**
+ * transferVerb 日志中的操作名称
+ */
+func transferVerb(move bool) string {
+	if move {
+		return "移动"
+	}
+	return "复制"
+}
+
+/**
+ * loadTarget 加载目标库的分组和账号，用于映射和冲突判定
+ */
+func (vts *VaultTransferService) loadTarget() error {
+	groups, err := vts.target.GroupService.GetAllGroups()
+	if err != nil {
+		return fmt.Errorf("获取目标库分组失败: %w", err)
+	}
+	vts.targetGroups = groups
+
+	accounts, err := vts.target.AccountService.GetAllAccounts()
+	if err != nil {
+		return fmt.Errorf("获取目标库账号失败: %w", err)
+	}
+	vts.targetAccounts = make(map[string]models.AccountDecrypted, len(accounts))
+	for _, account := range accounts {
+		vts.targetAccounts[conflictKey(account.TypeID, account.Title, account.Username)] = account
+	}
+	return nil
+}
+
+/**
+ * mapType 将源库类型映射到目标库：按分组路径逐级匹配分组名称，再按名称匹配类型，缺失时创建
+ * @return string 目标库类型ID
+ * @return string 分组路径/类型名称
+ * @return error 错误信息
+ */
+func (vts *VaultTransferService) mapType(sourceTypeID string, result *VaultTransferResult) (string, string, error) {
+	sourceType, err := vts.source.TypeService.GetTypeByID(sourceTypeID)
+	if err != nil {
+		return "", "", fmt.Errorf("获取源类型失败: %w", err)
+	}
+
+	// 源分组路径（由顶级到当前分组）
+	sourceGroup, err := vts.source.GroupService.GetGroupByID(sourceType.GroupID)
+	if err != nil {
+		return "", "", fmt.Errorf("获取源分组失败: %w", err)
+	}
+	ancestors, err := vts.source.GroupService.GetGroupAncestors(sourceGroup.ID)
+	if err != nil {
+		return "", "", fmt.Errorf("获取源分组层级失败: %w", err)
+	}
+	path := make([]models.Group, 0, len(ancestors)+1)
+	for i := len(ancestors) - 1; i >= 0; i-- {
+		path = append(path, ancestors[i])
+	}
+	path = append(path, *sourceGroup)
+
+	parentID := ""
+	groupPath := ""
+	for _, group := range path {
+		if groupPath != "" {
+			groupPath += "/"
+		}
+		groupPath += group.Name
+
+		targetGroupID := ""
+		for _, g := range vts.targetGroups {
+			if g.ParentID == parentID && g.Name == group.Name {
+				targetGroupID = g.ID
+				break
+			}
+		}
+		if targetGroupID == "" {
+			created, err := vts.target.GroupService.CreateChildGroup(group.Name, parentID)
+			if err != nil {
+				return "", "", fmt.Errorf("创建分组 %s 失败: %w", groupPath, err)
+			}
+			vts.targetGroups = append(vts.targetGroups, created)
+			result.CreatedGroups = append(result.CreatedGroups, groupPath)
+			targetGroupID = created.ID
+		}
+		parentID = targetGroupID
+	}
+
+	typePath := groupPath + "/" + sourceType.Name
+	types, cached := vts.targetTypes[parentID]
+	if !cached {
+		types, err = vts.target.TypeService.GetTypesByGroup(parentID)
+		if err != nil {
+			return "", "", fmt.Errorf("获取目标库类型失败: %w", err)
+		}
+	}
+	for _, t := range types {
+		if t.Name == sourceType.Name {
+			vts.targetTypes[parentID] = types
+			return t.ID, typePath, nil
+		}
+	}
+
+	created, err := vts.target.TypeService.CreateType(sourceType.Name, parentID, sourceType.Icon)
+	if err != nil {
+		return "", "", fmt.Errorf("创建类型 %s 失败: %w", typePath, err)
+	}
+	vts.targetTypes[parentID] = append(types, created)
+	result.CreatedTypes = append(result.CreatedTypes, typePath)
+	return created.ID, typePath, nil
+}
+
+/**
+ * copyAccount 用目标库的密钥重新加密并创建账号，保留图标、收藏和使用统计
+ */
+func (vts *VaultTransferService) copyAccount(account *models.AccountDecrypted, targetTypeID string) (models.AccountDecrypted, error) {
+	created, err := vts.target.AccountService.CreateAccount(account.Title, account.Username, account.Password, account.URL, targetTypeID, account.Notes, account.InputMethod)
+	if err != nil {
+		return models.AccountDecrypted{}, err
+	}
+
+	created.Icon = account.Icon
+	created.IsFavorite = account.IsFavorite
+	created.UseCount = account.UseCount
+	created.LastUsedAt = account.LastUsedAt
+	if err := vts.target.AccountService.updateAccount(created, false); err != nil {
+		return models.AccountDecrypted{}, fmt.Errorf("更新账号属性失败: %w", err)
+	}
+	return created, nil
+}
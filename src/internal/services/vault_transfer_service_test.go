@@ -0,0 +1,166 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"wepassword/internal/models"
+)
+
+/**
+ * 跨密码库账号复制/移动测试
+ * @author 陈凤庆
+ * @date 20251020
+ * @description 测试重新加密、分组类型映射与创建、冲突覆盖和跳过、移动删除源账号、附件和历史密码的重新加密复制以及复制失败时保留源账号
+ */
+
+func TestVaultTransferService_CopyAndMove(t *testing.T) {
+	sourceAccounts, _ := newTestAccountService(t)
+	sourceGroups := NewGroupService(sourceAccounts.dbManager)
+	sourceTypes := NewTypeService(sourceAccounts.dbManager)
+	source := VaultEndpoint{Name: "source", AccountService: sourceAccounts, GroupService: sourceGroups, TypeService: sourceTypes}
+
+	team, err := sourceGroups.CreateGroup("团队")
+	if err != nil {
+		t.Fatalf("创建分组失败: %v", err)
+	}
+	ops, err := sourceGroups.CreateChildGroup("运维", team.ID)
+	if err != nil {
+		t.Fatalf("创建子分组失败: %v", err)
+	}
+	serverType, err := sourceTypes.CreateType("服务器", ops.ID, "fa-server")
+	if err != nil {
+		t.Fatalf("创建类型失败: %v", err)
+	}
+	web, err := sourceAccounts.CreateAccount("web-01", "admin", "Web#01Pass", "", serverType.ID, "", 1)
+	if err != nil {
+		t.Fatalf("创建账号失败: %v", err)
+	}
+	db, err := sourceAccounts.CreateAccount("db-01", "root", "Db#01Pass", "", serverType.ID, "", 1)
+	if err != nil {
+		t.Fatalf("创建账号失败: %v", err)
+	}
+
+	targetPath := newTestVaultFile(t, "target", "Target#2025", "已有账号")
+	vss := NewVaultSessionService()
+	defer vss.CloseAll()
+	info, err := vss.OpenSession(targetPath, "Target#2025", 0)
+	if err != nil {
+		t.Fatalf("打开目标密码库失败: %v", err)
+	}
+	session, release, _ := vss.GetSession(info.ID)
+	defer release()
+	target := session.Endpoint()
+
+	// 复制：目标库中创建 团队/运维/服务器 并用目标库密钥加密
+	result, err := NewVaultTransferService(source, target).Transfer([]string{web.ID}, false, "")
+	if err != nil {
+		t.Fatalf("复制账号失败: %v", err)
+	}
+	if result.Transferred != 1 || len(result.CreatedGroups) != 2 || len(result.CreatedTypes) != 1 {
+		t.Errorf("复制结果错误: %+v", result)
+	}
+	if result.CreatedTypes[0] != "团队/运维/服务器" {
+		t.Errorf("新建类型路径错误: %s", result.CreatedTypes[0])
+	}
+
+	found, err := target.AccountService.SearchAccounts("web-01")
+	if err != nil || len(found) != 1 || found[0].Password != "Web#01Pass" {
+		t.Fatalf("目标库应能解密复制的账号: %+v, 错误: %v", found, err)
+	}
+
+	// 覆盖冲突账号时不执行目标账号的轮换钩子（钩子总是失败）
+	if runtime.GOOS != "windows" {
+		hook := filepath.Join(t.TempDir(), "hook.sh")
+		if err := os.WriteFile(hook, []byte("#!/bin/sh\nexit 1\n"), 0700); err != nil {
+			t.Fatalf("写入钩子脚本失败: %v", err)
+		}
+		if _, err := NewRotationHookService(target.AccountService.dbManager).SetHook(models.RotationHook{AccountID: found[0].ID, Command: hook, Enabled: true}); err != nil {
+			t.Fatalf("设置钩子失败: %v", err)
+		}
+	}
+	web.Password = "Web#02Pass"
+	if err := sourceAccounts.UpdateAccount(web); err != nil {
+		t.Fatalf("更新源账号失败: %v", err)
+	}
+	result, err = NewVaultTransferService(source, target).Transfer([]string{web.ID}, false, TransferConflictOverwrite)
+	if err != nil || result.Overwritten != 1 {
+		t.Fatalf("覆盖账号失败: %+v, 错误: %v", result, err)
+	}
+	if found, _ := target.AccountService.SearchAccounts("web-01"); len(found) != 1 || found[0].Password != "Web#02Pass" {
+		t.Errorf("覆盖后目标账号应使用源账号密码: %+v", found)
+	}
+
+	// 再次复制同一账号：冲突被跳过
+	result, err = NewVaultTransferService(source, target).Transfer([]string{web.ID}, false, TransferConflictSkip)
+	if err != nil {
+		t.Fatalf("再次复制失败: %v", err)
+	}
+	if result.Skipped != 1 || len(result.Conflicts) != 1 || result.Conflicts[0].TargetAccountID != found[0].ID {
+		t.Errorf("冲突应被报告并跳过: %+v", result)
+	}
+	if len(result.CreatedGroups) != 0 || len(result.CreatedTypes) != 0 {
+		t.Error("已存在的分组类型不应重复创建")
+	}
+
+	// 移动：目标库新增，源库删除；附件和历史密码用目标库密钥重新加密复制
+	sourceAttachments := NewAccountAttachmentService(sourceAccounts.dbManager, sourceAccounts)
+	if _, err := sourceAttachments.AddAttachment(db.ID, "id_rsa", []byte("key")); err != nil {
+		t.Fatalf("添加附件失败: %v", err)
+	}
+	replacedAt := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	if _, err := addPasswordHistory(sourceAccounts.dbManager.GetDB(), sourceAccounts.cryptoManager, db.ID, "Db#00Pass", replacedAt); err != nil {
+		t.Fatalf("添加历史密码失败: %v", err)
+	}
+	result, err = NewVaultTransferService(source, target).Transfer([]string{db.ID}, true, "")
+	if err != nil || result.Transferred != 1 || len(result.Errors) != 0 {
+		t.Fatalf("移动账号失败: %+v, 错误: %v", result, err)
+	}
+	if result.CopiedAttachments != 1 || result.CopiedHistory != 1 {
+		t.Errorf("附件和历史密码应被复制: %+v", result)
+	}
+	if _, err := sourceAccounts.GetAccountByID(db.ID); err == nil {
+		t.Error("移动后源账号应被删除")
+	}
+	found, _ = target.AccountService.SearchAccounts("db-01")
+	if len(found) != 1 {
+		t.Fatal("移动后目标库应有该账号")
+	}
+	targetAttachments := NewAccountAttachmentService(target.AccountService.dbManager, target.AccountService)
+	attachments, err := targetAttachments.GetAttachments(found[0].ID)
+	if err != nil || len(attachments) != 1 {
+		t.Fatalf("目标账号应有附件: %+v, %v", attachments, err)
+	}
+	if _, data, err := targetAttachments.GetAttachmentData(attachments[0].ID); err != nil || string(data) != "key" {
+		t.Errorf("目标库应能解密附件: %q, %v", data, err)
+	}
+	history, err := NewPasswordRotationService(target.AccountService.dbManager, target.AccountService).GetPasswordHistory(found[0].ID)
+	if err != nil || len(history) != 1 || history[0].Password != "Db#00Pass" || !history[0].CreatedAt.Equal(replacedAt) {
+		t.Errorf("目标库应能解密历史密码: %+v, %v", history, err)
+	}
+
+	// 附件复制失败时不删除源账号
+	cache, err := sourceAccounts.CreateAccount("cache-01", "redis", "Cache#01Pass", "", serverType.ID, "", 1)
+	if err != nil {
+		t.Fatalf("创建账号失败: %v", err)
+	}
+	if _, err := sourceAttachments.AddAttachment(cache.ID, "redis.conf", []byte("conf")); err != nil {
+		t.Fatalf("添加附件失败: %v", err)
+	}
+	if _, err := target.AccountService.dbManager.GetDB().Exec("DROP TABLE account_attachments"); err != nil {
+		t.Fatalf("删除目标库附件表失败: %v", err)
+	}
+	result, err = NewVaultTransferService(source, target).Transfer([]string{cache.ID}, true, "")
+	if err != nil || len(result.Errors) != 1 || !strings.Contains(result.Errors[0], "未删除源账号") {
+		t.Errorf("复制附件失败应报告错误: %+v, %v", result, err)
+	}
+	if _, err := sourceAccounts.GetAccountByID(cache.ID); err != nil {
+		t.Errorf("复制附件失败时源账号应保留: %v", err)
+	}
+
+	t.Log("✅ 跨密码库复制/移动测试通过")
+}